@@ -11,7 +11,6 @@ const BUCKET_NAME = process.env.S3_BUCKET || "guia-app-pdfs";
 async function createGuide(datos, logoBase64) {
   console.log("=== Iniciando creación de guía ===");
   
-  let browser = null;
   let connection = null;
   let guide_id = null;
 
@@ -139,13 +138,65 @@ async function createGuide(datos, logoBase64) {
     );
     console.log("Estado inicial insertado");
 
+    await connection.commit();
+    console.log("Transacción comprometida");
+
+  } catch (error) {
+    console.error("Error en creación de guía:", error);
+
+    // Rollback si hay error
+    if (connection) {
+      try {
+        await connection.rollback();
+        console.log("Rollback ejecutado");
+      } catch (rollbackErr) {
+        console.error("Error en rollback:", rollbackErr);
+      }
+    }
+
+    throw error;
+
+  } finally {
+    // Cerrar conexión
+    if (connection) {
+      try {
+        await connection.release();
+        console.log("Conexión DB cerrada");
+      } catch (closeErr) {
+        console.error("Error cerrando conexión:", closeErr);
+      }
+    }
+  }
+
+  /* -------------------------------------------------
+     5️⃣ GENERAR PDF (fuera de la transacción)
+  ------------------------------------------------- */
+  return await generateGuidePDF(guide_id, logoBase64);
+}
+
+/**
+ * Genera el PDF de una guía ya creada a partir de los datos en BD,
+ * lo sube a S3 y actualiza pdf_url / pdf_s3_key.
+ * Lo invoca el backend Go (type GUIDE_PDF) después de crear la guía.
+ */
+async function generateGuidePDF(guide_id, logoBase64) {
+  console.log("=== Generando PDF de guía", guide_id, "===");
+
+  let browser = null;
+  let connection = null;
+
+  try {
+    connection = await getConnection();
+
     /* -------------------------------------------------
-       5️⃣ OBTENER DATOS COMPLETOS PARA EL PDF
+       1️⃣ OBTENER DATOS COMPLETOS PARA EL PDF
     ------------------------------------------------- */
     const [guideData] = await connection.execute(
       `SELECT
         sg.guide_id,
+        sg.guide_number,
         sg.service_type,
+        sg.payment_method,
         sg.price,
         sg.declared_value,
         sender.full_name AS sender_name,
@@ -175,19 +226,18 @@ async function createGuide(datos, logoBase64) {
     );
 
     if (!guideData.length) {
-      throw new Error("No se pudieron recuperar los datos de la guía");
+      throw new Error(`Guía ${guide_id} no encontrada`);
     }
 
     const fullGuideData = guideData[0];
     console.log("Datos completos obtenidos");
 
-    await connection.commit();
-    console.log("Transacción comprometida");
-
     /* -------------------------------------------------
-       6️⃣ GENERAR PDF
+       2️⃣ GENERAR PDF
     ------------------------------------------------- */
-    const numGuia = String(guide_id).padStart(8, '0');
+    // Número de guía con dígito de verificación; las guías sin numerar
+    // (antes del backfill) conservan el formato de 8 dígitos
+    const numGuia = fullGuideData.guide_number || String(guide_id).padStart(8, '0');
     console.log("Generando PDF para guía:", numGuia);
 
     const chromiumPath = await chromium.executablePath();
@@ -219,14 +269,14 @@ async function createGuide(datos, logoBase64) {
         telefono: fullGuideData.receiver_phone
       },
       detalle: {
-        metodoPago: service.payment_method || 'CONTADO',
-        tipoEnvio: service.shipping_type || 'TERRESTRE',
+        metodoPago: fullGuideData.payment_method || 'CASH',
+        tipoEnvio: 'TERRESTRE',
         claseProducto: fullGuideData.description || 'GENERAL',
-        valorDeclarado: pricing.declared_value,
-        peso: package_data.weight_kg,
-        flete: pricing.price,
+        valorDeclarado: fullGuideData.declared_value,
+        peso: fullGuideData.weight_kg,
+        flete: fullGuideData.price,
         otros: 0,
-        total: pricing.price,
+        total: fullGuideData.price,
         numPiezas: fullGuideData.pieces,
         descripcion: fullGuideData.description,
         observaciones: fullGuideData.special_notes || ''
@@ -251,7 +301,7 @@ async function createGuide(datos, logoBase64) {
     console.log("PDF generado, tamaño:", pdfBuffer.length);
 
     /* -------------------------------------------------
-       7️⃣ SUBIR PDF A S3
+       3️⃣ SUBIR PDF A S3
     ------------------------------------------------- */
     const fileName = `guias/guia-${numGuia}.pdf`;
     const uploadCommand = new PutObjectCommand({
@@ -290,18 +340,7 @@ async function createGuide(datos, logoBase64) {
     };
 
   } catch (error) {
-    console.error("Error en creación de guía:", error);
-    
-    // Rollback si hay error
-    if (connection) {
-      try {
-        await connection.rollback();
-        console.log("Rollback ejecutado");
-      } catch (rollbackErr) {
-        console.error("Error en rollback:", rollbackErr);
-      }
-    }
-    
+    console.error("Error generando PDF de guía:", error);
     throw error;
 
   } finally {
@@ -326,4 +365,4 @@ async function createGuide(datos, logoBase64) {
   }
 }
 
-module.exports = { createGuide };
\ No newline at end of file
+module.exports = { createGuide, generateGuidePDF };
\ No newline at end of file
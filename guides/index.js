@@ -1,6 +1,6 @@
 const fs = require('fs');
 const path = require('path');
-const { createGuide } = require('./guideHandler');
+const { createGuide, generateGuidePDF } = require('./guideHandler');
 const { generateCashClosePDF } = require('./cashCloseHandler');
 
 // Cargar logo una sola vez
@@ -40,6 +40,9 @@ exports.handler = async (event) => {
     if (datos.type === 'CASH_CLOSE') {
       console.log(">>> Tipo: CIERRE DE CAJA");
       return await handleCashClose(datos);
+    } else if (datos.type === 'GUIDE_PDF') {
+      console.log(">>> Tipo: PDF DE GUÍA EXISTENTE");
+      return await handleGuidePDF(datos);
     } else {
       console.log(">>> Tipo: GUÍA DE TRANSPORTE");
       return await handleGuide(datos);
@@ -96,6 +99,55 @@ async function handleGuide(datos) {
   }
 }
 
+// ===================================
+// HANDLER PARA PDF DE GUÍA EXISTENTE
+// (la guía la crea el backend Go)
+// ===================================
+async function handleGuidePDF(datos) {
+  try {
+    if (!datos.guide_id) {
+      return {
+        statusCode: 400,
+        headers: {
+          "Content-Type": "application/json",
+          "Access-Control-Allow-Origin": "*"
+        },
+        body: JSON.stringify({
+          error: "Falta el campo requerido: guide_id"
+        })
+      };
+    }
+
+    const result = await generateGuidePDF(datos.guide_id, LOGO_BASE64);
+
+    return {
+      statusCode: 200,
+      headers: {
+        "Content-Type": "application/json",
+        "Access-Control-Allow-Origin": "*"
+      },
+      body: JSON.stringify({
+        ...result,
+        message: "PDF de guía generado exitosamente"
+      })
+    };
+
+  } catch (error) {
+    console.error("Error en handler de PDF de guía:", error);
+    return {
+      statusCode: 500,
+      headers: {
+        "Content-Type": "application/json",
+        "Access-Control-Allow-Origin": "*"
+      },
+      body: JSON.stringify({
+        error: "Error generando PDF de guía",
+        details: error.message
+      })
+    };
+  }
+}
+
 // ===================================
 // HANDLER PARA CIERRE DE CAJA
 // ===================================
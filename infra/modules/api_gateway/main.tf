@@ -56,6 +56,13 @@ resource "aws_apigatewayv2_stage" "default" {
   api_id = aws_apigatewayv2_api.api.id
   name   = "$default"
   auto_deploy = true
+
+  # Tope global del rastreo público, además del límite por IP y por guía
+  route_settings {
+    route_key              = aws_apigatewayv2_route.public_track.route_key
+    throttling_burst_limit = 20
+    throttling_rate_limit  = 10
+  }
 }
 
 # Lambda permission
@@ -173,6 +180,55 @@ resource "aws_apigatewayv2_route" "guides_search" {
   authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
 }
 
+# GET /api/v1/guides/status-reasons - Motivos de los estados de excepción
+resource "aws_apigatewayv2_route" "guides_status_reasons" {
+  api_id    = aws_apigatewayv2_api.api.id
+  route_key = "GET /api/v1/guides/status-reasons"
+
+  target             = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+# POST /api/v1/quotes - Cotizador público (sin token)
+resource "aws_apigatewayv2_route" "quotes_create" {
+  api_id    = aws_apigatewayv2_api.api.id
+  route_key = "POST /api/v1/quotes"
+
+  target             = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "NONE"
+}
+
+# GET /api/v1/public/track/{guideNumber} - Rastreo público (sin token; la Lambda
+# exige los últimos 4 dígitos y limita por IP y por guía)
+resource "aws_apigatewayv2_route" "public_track" {
+  api_id    = aws_apigatewayv2_api.api.id
+  route_key = "GET /api/v1/public/track/{guideNumber}"
+
+  target             = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "NONE"
+}
+
+# POST /api/v1/guides - Crear guía (antes en la Lambda de Node.js)
+resource "aws_apigatewayv2_route" "guides_create" {
+  api_id    = aws_apigatewayv2_api.api.id
+  route_key = "POST /api/v1/guides"
+
+  target             = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+# POST /api/v1/guides/{id}/pdf - Regenerar PDF de la guía
+resource "aws_apigatewayv2_route" "guides_pdf_generate" {
+  api_id    = aws_apigatewayv2_api.api.id
+  route_key = "POST /api/v1/guides/{id}/pdf"
+
+  target             = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
 # PUT /guides/{id}/status
 resource "aws_apigatewayv2_route" "guides_status" {
   api_id    = aws_apigatewayv2_api.api.id
@@ -183,6 +239,365 @@ resource "aws_apigatewayv2_route" "guides_status" {
   authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
 }
 
+# GET /guides/{id}/attempts - Intentos de entrega de la guía
+resource "aws_apigatewayv2_route" "guides_attempts" {
+  api_id    = aws_apigatewayv2_api.api.id
+  route_key = "GET /api/v1/guides/{id}/attempts"
+
+  target             = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+# GET /guides/{id}/proof - Prueba de entrega de la guía
+resource "aws_apigatewayv2_route" "guides_proof" {
+  api_id    = aws_apigatewayv2_api.api.id
+  route_key = "GET /api/v1/guides/{id}/proof"
+
+  target             = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+# GET /guides/{id}/barcode - Código de barras (Code 128) o QR de la guía
+resource "aws_apigatewayv2_route" "guides_barcode" {
+  api_id    = aws_apigatewayv2_api.api.id
+  route_key = "GET /api/v1/guides/{id}/barcode"
+
+  target             = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+# GET /guides/{id}/label - Rótulo térmico 10x15 cm (ZPL o PDF)
+resource "aws_apigatewayv2_route" "guides_label" {
+  api_id    = aws_apigatewayv2_api.api.id
+  route_key = "GET /api/v1/guides/{id}/label"
+
+  target             = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+# GET /guides/{id}/location - Ubicación en bodega e historial de movimientos
+resource "aws_apigatewayv2_route" "guides_location" {
+  api_id    = aws_apigatewayv2_api.api.id
+  route_key = "GET /api/v1/guides/{id}/location"
+
+  target             = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+# GET /guides/{id}/rndc - Remesa RNDC de la guía (XML y validación)
+resource "aws_apigatewayv2_route" "guides_rndc_get" {
+  api_id    = aws_apigatewayv2_api.api.id
+  route_key = "GET /api/v1/guides/{id}/rndc"
+
+  target             = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+# POST /guides/{id}/rndc - Reportar la remesa al RNDC
+resource "aws_apigatewayv2_route" "guides_rndc_submit" {
+  api_id    = aws_apigatewayv2_api.api.id
+  route_key = "POST /api/v1/guides/{id}/rndc"
+
+  target             = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+# POST /scans - Lote de guías escaneadas (recepción, bodega, despacho)
+resource "aws_apigatewayv2_route" "scans_create" {
+  api_id    = aws_apigatewayv2_api.api.id
+  route_key = "POST /api/v1/scans"
+
+  target             = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+# -----------------------------------------
+# Warehouses
+
+# POST /warehouses - Crear bodega
+resource "aws_apigatewayv2_route" "warehouses_create" {
+  api_id    = aws_apigatewayv2_api.api.id
+  route_key = "POST /api/v1/warehouses"
+
+  target             = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+# GET /warehouses - Listar bodegas
+resource "aws_apigatewayv2_route" "warehouses_list" {
+  api_id    = aws_apigatewayv2_api.api.id
+  route_key = "GET /api/v1/warehouses"
+
+  target             = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+# GET /warehouses/aging - Antigüedad de guías en bodega
+resource "aws_apigatewayv2_route" "warehouses_aging" {
+  api_id    = aws_apigatewayv2_api.api.id
+  route_key = "GET /api/v1/warehouses/aging"
+
+  target             = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+# POST /warehouses/check-in - Ingresar guías o piezas a un bin
+resource "aws_apigatewayv2_route" "warehouses_check_in" {
+  api_id    = aws_apigatewayv2_api.api.id
+  route_key = "POST /api/v1/warehouses/check-in"
+
+  target             = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+# POST /warehouses/move - Mover guías o piezas a otro bin
+resource "aws_apigatewayv2_route" "warehouses_move" {
+  api_id    = aws_apigatewayv2_api.api.id
+  route_key = "POST /api/v1/warehouses/move"
+
+  target             = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+# POST /warehouses/check-out - Sacar guías o piezas de la bodega
+resource "aws_apigatewayv2_route" "warehouses_check_out" {
+  api_id    = aws_apigatewayv2_api.api.id
+  route_key = "POST /api/v1/warehouses/check-out"
+
+  target             = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+# POST /warehouses/{id}/bins - Crear bin
+resource "aws_apigatewayv2_route" "warehouses_bins_create" {
+  api_id    = aws_apigatewayv2_api.api.id
+  route_key = "POST /api/v1/warehouses/{id}/bins"
+
+  target             = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+# GET /warehouses/{id}/bins - Bins de la bodega
+resource "aws_apigatewayv2_route" "warehouses_bins_list" {
+  api_id    = aws_apigatewayv2_api.api.id
+  route_key = "GET /api/v1/warehouses/{id}/bins"
+
+  target             = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+# GET /warehouses/{id}/stock - Guías y piezas ubicadas en la bodega
+resource "aws_apigatewayv2_route" "warehouses_stock" {
+  api_id    = aws_apigatewayv2_api.api.id
+  route_key = "GET /api/v1/warehouses/{id}/stock"
+
+  target             = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+# POST /warehouses/{id}/inventory-counts - Iniciar conteo físico
+resource "aws_apigatewayv2_route" "inventory_counts_create" {
+  api_id    = aws_apigatewayv2_api.api.id
+  route_key = "POST /api/v1/warehouses/{id}/inventory-counts"
+
+  target             = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+# GET /inventory-counts/{id} - Conteo con su conciliación
+resource "aws_apigatewayv2_route" "inventory_counts_get" {
+  api_id    = aws_apigatewayv2_api.api.id
+  route_key = "GET /api/v1/inventory-counts/{id}"
+
+  target             = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+# POST /inventory-counts/{id}/scans - Códigos escaneados en un bin
+resource "aws_apigatewayv2_route" "inventory_counts_scans" {
+  api_id    = aws_apigatewayv2_api.api.id
+  route_key = "POST /api/v1/inventory-counts/{id}/scans"
+
+  target             = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+# POST /inventory-counts/{id}/close - Cerrar conteo
+resource "aws_apigatewayv2_route" "inventory_counts_close" {
+  api_id    = aws_apigatewayv2_api.api.id
+  route_key = "POST /api/v1/inventory-counts/{id}/close"
+
+  target             = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+# -----------------------------------------
+# Manifests
+
+# POST /manifests - Crear manifiesto de despacho
+resource "aws_apigatewayv2_route" "manifests_create" {
+  api_id    = aws_apigatewayv2_api.api.id
+  route_key = "POST /api/v1/manifests"
+
+  target             = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+# GET /manifests - Listar manifiestos
+resource "aws_apigatewayv2_route" "manifests_list" {
+  api_id    = aws_apigatewayv2_api.api.id
+  route_key = "GET /api/v1/manifests"
+
+  target             = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+# GET /manifests/pending-guides - Guías pendientes de despacho
+resource "aws_apigatewayv2_route" "manifests_pending_guides" {
+  api_id    = aws_apigatewayv2_api.api.id
+  route_key = "GET /api/v1/manifests/pending-guides"
+
+  target             = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+# GET /manifests/{id} - Manifiesto con sus guías y totales
+resource "aws_apigatewayv2_route" "manifests_get" {
+  api_id    = aws_apigatewayv2_api.api.id
+  route_key = "GET /api/v1/manifests/{id}"
+
+  target             = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+# POST /manifests/{id}/guides - Cargar guías al manifiesto
+resource "aws_apigatewayv2_route" "manifests_add_guides" {
+  api_id    = aws_apigatewayv2_api.api.id
+  route_key = "POST /api/v1/manifests/{id}/guides"
+
+  target             = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+# POST /manifests/{id}/close - Despachar manifiesto
+resource "aws_apigatewayv2_route" "manifests_close" {
+  api_id    = aws_apigatewayv2_api.api.id
+  route_key = "POST /api/v1/manifests/{id}/close"
+
+  target             = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+# POST /manifests/{id}/receive - Recibir manifiesto en destino
+resource "aws_apigatewayv2_route" "manifests_receive" {
+  api_id    = aws_apigatewayv2_api.api.id
+  route_key = "POST /api/v1/manifests/{id}/receive"
+
+  target             = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+# GET /manifests/{id}/pdf - Manifiesto impreso
+resource "aws_apigatewayv2_route" "manifests_pdf" {
+  api_id    = aws_apigatewayv2_api.api.id
+  route_key = "GET /api/v1/manifests/{id}/pdf"
+
+  target             = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+# GET /manifests/{id}/rndc - Manifiesto de carga RNDC (XML y validación)
+resource "aws_apigatewayv2_route" "manifests_rndc_get" {
+  api_id    = aws_apigatewayv2_api.api.id
+  route_key = "GET /api/v1/manifests/{id}/rndc"
+
+  target             = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+# POST /manifests/{id}/rndc - Reportar remesas y manifiesto al RNDC
+resource "aws_apigatewayv2_route" "manifests_rndc_submit" {
+  api_id    = aws_apigatewayv2_api.api.id
+  route_key = "POST /api/v1/manifests/{id}/rndc"
+
+  target             = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+# -----------------------------------------
+# Hubs
+
+# POST /hubs - Crear hub con su cobertura
+resource "aws_apigatewayv2_route" "hubs_create" {
+  api_id    = aws_apigatewayv2_api.api.id
+  route_key = "POST /api/v1/hubs"
+
+  target             = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+# GET /hubs - Listar hubs
+resource "aws_apigatewayv2_route" "hubs_list" {
+  api_id    = aws_apigatewayv2_api.api.id
+  route_key = "GET /api/v1/hubs"
+
+  target             = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+# GET /hubs/{id} - Obtener hub
+resource "aws_apigatewayv2_route" "hubs_get" {
+  api_id    = aws_apigatewayv2_api.api.id
+  route_key = "GET /api/v1/hubs/{id}"
+
+  target             = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+# PUT /hubs/{id} - Actualizar nombre, estado o cobertura
+resource "aws_apigatewayv2_route" "hubs_update" {
+  api_id    = aws_apigatewayv2_api.api.id
+  route_key = "PUT /api/v1/hubs/{id}"
+
+  target             = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
 # -----------------------------------------
 # Cash Closes
 
@@ -476,6 +891,46 @@ resource "aws_apigatewayv2_route" "assignments_status" {
   authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
 }
 
+// POST /assignments/{id}/attempts - Registrar intento de entrega fallido
+resource "aws_apigatewayv2_route" "assignments_attempts" {
+  api_id    = aws_apigatewayv2_api.api.id
+  route_key = "POST /api/v1/assignments/{id}/attempts"
+
+  target             = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+// POST /assignments/{id}/proof/upload-urls - URLs de carga de la prueba de entrega
+resource "aws_apigatewayv2_route" "assignments_proof_upload_urls" {
+  api_id    = aws_apigatewayv2_api.api.id
+  route_key = "POST /api/v1/assignments/{id}/proof/upload-urls"
+
+  target             = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+// POST /assignments/{id}/handover-code - Reenviar código de entrega
+resource "aws_apigatewayv2_route" "assignments_handover_code" {
+  api_id    = aws_apigatewayv2_api.api.id
+  route_key = "POST /api/v1/assignments/{id}/handover-code"
+
+  target             = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+// POST /assignments/{id}/handover-code/override - Omitir código de entrega (ADMIN)
+resource "aws_apigatewayv2_route" "assignments_handover_code_override" {
+  api_id    = aws_apigatewayv2_api.api.id
+  route_key = "POST /api/v1/assignments/{id}/handover-code/override"
+
+  target             = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
 // GET /assignments/{id}/history
 resource "aws_apigatewayv2_route" "assignments_history" {
   api_id = aws_apigatewayv2_api.api.id
@@ -557,4 +1012,84 @@ resource "aws_apigatewayv2_route" "admin_clients_ranking" {
   target = "integrations/${aws_apigatewayv2_integration.lambda.id}"
   authorization_type = "JWT"
   authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
-}
\ No newline at end of file
+}
+
+// GET /admin/rates - Listar tarifas
+resource "aws_apigatewayv2_route" "admin_rates" {
+  api_id = aws_apigatewayv2_api.api.id
+  route_key = "GET /api/v1/admin/rates"
+
+  target = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+// POST /admin/rates - Crear tarifa o programar una nueva versión
+resource "aws_apigatewayv2_route" "admin_rates_create" {
+  api_id = aws_apigatewayv2_api.api.id
+  route_key = "POST /api/v1/admin/rates"
+
+  target = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+// GET /admin/rates/{id} - Obtener tarifa
+resource "aws_apigatewayv2_route" "admin_rates_by_id" {
+  api_id = aws_apigatewayv2_api.api.id
+  route_key = "GET /api/v1/admin/rates/{id}"
+
+  target = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+// PUT /admin/rates/{id} - Actualizar tarifa programada
+resource "aws_apigatewayv2_route" "admin_rates_update" {
+  api_id = aws_apigatewayv2_api.api.id
+  route_key = "PUT /api/v1/admin/rates/{id}"
+
+  target = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+// DELETE /admin/rates/{id} - Retirar tarifa
+resource "aws_apigatewayv2_route" "admin_rates_retire" {
+  api_id = aws_apigatewayv2_api.api.id
+  route_key = "DELETE /api/v1/admin/rates/{id}"
+
+  target = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+// GET /admin/rates/{id}/history - Historial de cambios de la tarifa
+resource "aws_apigatewayv2_route" "admin_rates_history" {
+  api_id = aws_apigatewayv2_api.api.id
+  route_key = "GET /api/v1/admin/rates/{id}/history"
+
+  target = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+// POST /admin/rates/import - Importar tarifario CSV/XLSX
+resource "aws_apigatewayv2_route" "admin_rates_import" {
+  api_id = aws_apigatewayv2_api.api.id
+  route_key = "POST /api/v1/admin/rates/import"
+
+  target = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
+
+// GET /admin/rates/export - Exportar la tarifa vigente
+resource "aws_apigatewayv2_route" "admin_rates_export" {
+  api_id = aws_apigatewayv2_api.api.id
+  route_key = "GET /api/v1/admin/rates/export"
+
+  target = "integrations/${aws_apigatewayv2_integration.lambda.id}"
+  authorization_type = "JWT"
+  authorizer_id      = aws_apigatewayv2_authorizer.cognito.id
+}
@@ -8,7 +8,7 @@ resource "aws_s3_bucket_cors_configuration" "pdfs" {
 
   cors_rule {
     allowed_headers = ["*"]
-    allowed_methods = ["GET", "HEAD"]
+    allowed_methods = ["GET", "HEAD", "PUT"] # PUT: firma y fotos de la prueba de entrega (URLs pre-firmadas)
     allowed_origins = [
       "http://localhost:4200",
       "http://localhost:3000",
@@ -0,0 +1,203 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// KeySet resuelve la llave pública RSA correspondiente a un kid
+type KeySet interface {
+	Key(kid string) (*rsa.PublicKey, error)
+}
+
+// jwk es una llave del documento JWKS (solo RSA)
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type jwksDocument struct {
+	Keys []jwk `json:"keys"`
+}
+
+// StaticKeySet es un conjunto fijo de llaves, útil para pruebas con llaves
+// generadas localmente.
+type StaticKeySet map[string]*rsa.PublicKey
+
+// Key retorna la llave asociada al kid
+func (s StaticKeySet) Key(kid string) (*rsa.PublicKey, error) {
+	key, ok := s[kid]
+	if !ok {
+		return nil, fmt.Errorf("llave no encontrada para kid '%s'", kid)
+	}
+	return key, nil
+}
+
+// RemoteKeySet carga el JWKS desde una URL o un archivo y lo cachea.
+// Si llega un kid desconocido vuelve a cargar el documento (rotación de llaves),
+// respetando un intervalo mínimo entre recargas.
+type RemoteKeySet struct {
+	source     string
+	fromFile   bool
+	ttl        time.Duration
+	minRefresh time.Duration
+	client     *http.Client
+
+	mu          sync.Mutex
+	keys        map[string]*rsa.PublicKey
+	fetchedAt   time.Time
+	lastAttempt time.Time
+}
+
+// NewRemoteKeySet crea un KeySet que descarga el JWKS desde una URL
+func NewRemoteKeySet(url string, ttl time.Duration) *RemoteKeySet {
+	return &RemoteKeySet{
+		source:     url,
+		ttl:        ttl,
+		minRefresh: 30 * time.Second,
+		client:     &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// NewFileKeySet crea un KeySet que lee el JWKS desde un archivo local
+func NewFileKeySet(path string) *RemoteKeySet {
+	return &RemoteKeySet{
+		source:     path,
+		fromFile:   true,
+		ttl:        0,
+		minRefresh: 30 * time.Second,
+	}
+}
+
+// Key retorna la llave para el kid, recargando el JWKS si expiró o si el kid no existe
+func (r *RemoteKeySet) Key(kid string) (*rsa.PublicKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	expired := r.keys == nil || (r.ttl > 0 && time.Since(r.fetchedAt) > r.ttl)
+	if expired {
+		if err := r.refresh(); err != nil && r.keys == nil {
+			return nil, err
+		}
+	}
+
+	if key, ok := r.keys[kid]; ok {
+		return key, nil
+	}
+
+	// kid desconocido: posible rotación de llaves
+	if time.Since(r.lastAttempt) >= r.minRefresh {
+		if err := r.refresh(); err != nil {
+			return nil, err
+		}
+		if key, ok := r.keys[kid]; ok {
+			return key, nil
+		}
+	}
+
+	return nil, fmt.Errorf("llave no encontrada para kid '%s'", kid)
+}
+
+// refresh se llama con el mutex tomado
+func (r *RemoteKeySet) refresh() error {
+	r.lastAttempt = time.Now()
+
+	raw, err := r.load()
+	if err != nil {
+		fmt.Println("Error cargando JWKS:", err.Error())
+		return err
+	}
+
+	keys, err := ParseJWKS(raw)
+	if err != nil {
+		return err
+	}
+
+	r.keys = keys
+	r.fetchedAt = time.Now()
+	return nil
+}
+
+func (r *RemoteKeySet) load() ([]byte, error) {
+	if r.fromFile {
+		return os.ReadFile(r.source)
+	}
+
+	resp, err := r.client.Get(r.source)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("JWKS respondió %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// ParseJWKS convierte un documento JWKS en llaves RSA indexadas por kid
+func ParseJWKS(raw []byte) (map[string]*rsa.PublicKey, error) {
+	var doc jwksDocument
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("JWKS inválido: %s", err.Error())
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(doc.Keys))
+	for _, k := range doc.Keys {
+		if k.Kty != "RSA" || k.Kid == "" {
+			continue
+		}
+		key, err := rsaKeyFromJWK(k)
+		if err != nil {
+			return nil, err
+		}
+		keys[k.Kid] = key
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("JWKS sin llaves RSA")
+	}
+	return keys, nil
+}
+
+func rsaKeyFromJWK(k jwk) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("módulo inválido en kid '%s'", k.Kid)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("exponente inválido en kid '%s'", k.Kid)
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
+
+// JWKFromPublicKey serializa una llave pública como JWK; permite construir
+// documentos JWKS con llaves generadas localmente.
+func JWKFromPublicKey(kid string, key *rsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kid": kid,
+		"kty": "RSA",
+		"alg": "RS256",
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
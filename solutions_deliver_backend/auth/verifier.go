@@ -0,0 +1,268 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Claims son los claims validados de un token de Cognito
+type Claims struct {
+	Sub      string   `json:"sub"`
+	Username string   `json:"username"`
+	Email    string   `json:"email"`
+	Issuer   string   `json:"iss"`
+	Audience []string `json:"aud"`
+	ClientID string   `json:"client_id"`
+	TokenUse string   `json:"token_use"`
+	Scope    string   `json:"scope"`
+	Groups   []string `json:"cognito:groups"`
+	Exp      int64    `json:"exp"`
+	Nbf      int64    `json:"nbf"`
+	Iat      int64    `json:"iat"`
+}
+
+// rawClaims acepta aud como string o arreglo
+type rawClaims struct {
+	Sub         string          `json:"sub"`
+	Username    string          `json:"username"`
+	CognitoUser string          `json:"cognito:username"`
+	Email       string          `json:"email"`
+	Iss         string          `json:"iss"`
+	Aud         json.RawMessage `json:"aud"`
+	ClientID    string          `json:"client_id"`
+	TokenUse    string          `json:"token_use"`
+	Scope       string          `json:"scope"`
+	Groups      []string        `json:"cognito:groups"`
+	Exp         int64           `json:"exp"`
+	Nbf         int64           `json:"nbf"`
+	Iat         int64           `json:"iat"`
+}
+
+// Verifier valida tokens RS256 contra un JWKS
+type Verifier struct {
+	Keys      KeySet
+	Issuer    string
+	ClientIDs []string // valores aceptados de aud (id token) o client_id (access token)
+	TokenUse  string   // "id", "access" o vacío para aceptar ambos
+	ClockSkew time.Duration
+	Now       func() time.Time
+
+	// SkipAudience desactiva la validación de aud/client_id; sin ClientIDs
+	// y sin esta opción todos los tokens se rechazan
+	SkipAudience bool
+}
+
+// NewVerifierFromEnv construye un Verifier con la configuración de Cognito:
+//
+//	COGNITO_ISSUER o COGNITO_REGION + COGNITO_USER_POOL_ID
+//	COGNITO_CLIENT_ID (uno o varios separados por coma)
+//	JWKS_FILE o JWKS_URL (por defecto <issuer>/.well-known/jwks.json)
+//	JWT_TOKEN_USE (id|access), JWT_CLOCK_SKEW (p.ej. "60s")
+//	JWT_SKIP_AUDIENCE=true para no validar aud/client_id (solo desarrollo)
+//
+// Retorna nil sin error si no hay issuer configurado, y error si hay issuer
+// pero falta COGNITO_CLIENT_ID sin haber desactivado la validación de audiencia.
+func NewVerifierFromEnv() (*Verifier, error) {
+	issuer := os.Getenv("COGNITO_ISSUER")
+	if issuer == "" && os.Getenv("COGNITO_USER_POOL_ID") != "" {
+		region := os.Getenv("COGNITO_REGION")
+		if region == "" {
+			region = "us-east-1"
+		}
+		issuer = fmt.Sprintf("https://cognito-idp.%s.amazonaws.com/%s", region, os.Getenv("COGNITO_USER_POOL_ID"))
+	}
+	if issuer == "" {
+		return nil, nil
+	}
+
+	var keys KeySet
+	if file := os.Getenv("JWKS_FILE"); file != "" {
+		keys = NewFileKeySet(file)
+	} else {
+		url := os.Getenv("JWKS_URL")
+		if url == "" {
+			url = strings.TrimSuffix(issuer, "/") + "/.well-known/jwks.json"
+		}
+		keys = NewRemoteKeySet(url, 12*time.Hour)
+	}
+
+	skew := time.Minute
+	if value, err := time.ParseDuration(os.Getenv("JWT_CLOCK_SKEW")); err == nil {
+		skew = value
+	}
+
+	var clientIDs []string
+	for _, id := range strings.Split(os.Getenv("COGNITO_CLIENT_ID"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			clientIDs = append(clientIDs, id)
+		}
+	}
+	skipAudience := os.Getenv("JWT_SKIP_AUDIENCE") == "true"
+	if len(clientIDs) == 0 && !skipAudience {
+		return nil, errors.New("COGNITO_CLIENT_ID no definido; use JWT_SKIP_AUDIENCE=true para omitir la validación de audiencia")
+	}
+
+	return &Verifier{
+		Keys:         keys,
+		Issuer:       issuer,
+		ClientIDs:    clientIDs,
+		TokenUse:     os.Getenv("JWT_TOKEN_USE"),
+		ClockSkew:    skew,
+		SkipAudience: skipAudience,
+	}, nil
+}
+
+// Verify valida firma, emisor, audiencia, uso y vigencia del token
+func (v *Verifier) Verify(token string) (Claims, error) {
+	var claims Claims
+
+	token = strings.TrimSpace(strings.TrimPrefix(token, "Bearer "))
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return claims, errors.New("el token no es válido")
+	}
+
+	headerRaw, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return claims, errors.New("error al decodificar el header del token")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := json.Unmarshal(headerRaw, &header); err != nil {
+		return claims, errors.New("header del token inválido")
+	}
+	if header.Alg != "RS256" {
+		return claims, errors.New("algoritmo de firma no soportado: " + header.Alg)
+	}
+
+	key, err := v.Keys.Key(header.Kid)
+	if err != nil {
+		return claims, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return claims, errors.New("error al decodificar la firma del token")
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return claims, errors.New("firma del token inválida")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return claims, errors.New("error al decodificar el payload del token")
+	}
+	var raw rawClaims
+	if err := json.Unmarshal(payload, &raw); err != nil {
+		return claims, errors.New("payload del token inválido")
+	}
+
+	claims = Claims{
+		Sub:      raw.Sub,
+		Username: raw.Username,
+		Email:    raw.Email,
+		Issuer:   raw.Iss,
+		ClientID: raw.ClientID,
+		TokenUse: raw.TokenUse,
+		Scope:    raw.Scope,
+		Groups:   raw.Groups,
+		Exp:      raw.Exp,
+		Nbf:      raw.Nbf,
+		Iat:      raw.Iat,
+	}
+	if claims.Username == "" {
+		claims.Username = raw.CognitoUser
+	}
+	claims.Audience, err = parseAudience(raw.Aud)
+	if err != nil {
+		return claims, err
+	}
+
+	if err := v.validate(claims); err != nil {
+		return claims, err
+	}
+	return claims, nil
+}
+
+func (v *Verifier) validate(c Claims) error {
+	if c.Sub == "" {
+		return errors.New("el token no contiene 'sub'")
+	}
+
+	if v.Issuer != "" && c.Issuer != v.Issuer {
+		return errors.New("emisor del token inválido")
+	}
+
+	if v.TokenUse != "" && c.TokenUse != v.TokenUse {
+		return fmt.Errorf("token_use inválido: se esperaba '%s'", v.TokenUse)
+	}
+	if c.TokenUse != "" && c.TokenUse != "id" && c.TokenUse != "access" {
+		return errors.New("token_use inválido")
+	}
+
+	if !v.SkipAudience {
+		if len(v.ClientIDs) == 0 {
+			return errors.New("el verificador no tiene client_id configurado")
+		}
+		if !v.audienceAllowed(c) {
+			return errors.New("audiencia del token inválida")
+		}
+	}
+
+	now := time.Now()
+	if v.Now != nil {
+		now = v.Now()
+	}
+	if c.Exp == 0 || now.After(time.Unix(c.Exp, 0).Add(v.ClockSkew)) {
+		return errors.New("el token ha expirado")
+	}
+	if c.Nbf != 0 && now.Add(v.ClockSkew).Before(time.Unix(c.Nbf, 0)) {
+		return errors.New("el token aún no es válido")
+	}
+	if c.Iat != 0 && now.Add(v.ClockSkew).Before(time.Unix(c.Iat, 0)) {
+		return errors.New("el token fue emitido en el futuro")
+	}
+
+	return nil
+}
+
+// audienceAllowed: los id tokens traen aud, los access tokens de Cognito traen client_id
+func (v *Verifier) audienceAllowed(c Claims) bool {
+	candidates := append([]string{c.ClientID}, c.Audience...)
+	for _, candidate := range candidates {
+		for _, allowed := range v.ClientIDs {
+			if candidate != "" && candidate == allowed {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func parseAudience(raw json.RawMessage) ([]string, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	var single string
+	if err := json.Unmarshal(raw, &single); err == nil {
+		return []string{single}, nil
+	}
+
+	var many []string
+	if err := json.Unmarshal(raw, &many); err != nil {
+		return nil, errors.New("claim 'aud' inválido")
+	}
+	return many, nil
+}
@@ -0,0 +1,374 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testIssuer   = "https://cognito-idp.us-east-1.amazonaws.com/us-east-1_test"
+	testClientID = "client-123"
+)
+
+var testNow = time.Unix(1760000000, 0)
+
+func generateKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("no se pudo generar la llave: %v", err)
+	}
+	return key
+}
+
+func encodeSegment(t *testing.T, value interface{}) string {
+	t.Helper()
+	raw, err := json.Marshal(value)
+	if err != nil {
+		t.Fatalf("no se pudo serializar: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// signRS256 arma un JWT RS256 con el kid dado
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	t.Helper()
+	signingInput := encodeSegment(t, map[string]string{"alg": "RS256", "kid": kid, "typ": "JWT"}) + "." + encodeSegment(t, claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("no se pudo firmar: %v", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// validClaims claims de un id token vigente en testNow
+func validClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"sub":       "user-1",
+		"email":     "user@example.com",
+		"iss":       testIssuer,
+		"aud":       testClientID,
+		"token_use": "id",
+		"exp":       testNow.Add(time.Hour).Unix(),
+		"iat":       testNow.Add(-time.Minute).Unix(),
+	}
+}
+
+func testVerifier(keys KeySet) *Verifier {
+	return &Verifier{
+		Keys:      keys,
+		Issuer:    testIssuer,
+		ClientIDs: []string{testClientID},
+		TokenUse:  "id",
+		ClockSkew: time.Minute,
+		Now:       func() time.Time { return testNow },
+	}
+}
+
+func TestVerifierVerify(t *testing.T) {
+	key := generateKey(t)
+	otherKey := generateKey(t)
+	v := testVerifier(StaticKeySet{"kid-1": &key.PublicKey})
+
+	with := func(changes map[string]interface{}) map[string]interface{} {
+		claims := validClaims()
+		for k, value := range changes {
+			if value == nil {
+				delete(claims, k)
+				continue
+			}
+			claims[k] = value
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		err   string
+	}{
+		{"válido", signRS256(t, key, "kid-1", validClaims()), ""},
+		{"con prefijo Bearer", "Bearer " + signRS256(t, key, "kid-1", validClaims()), ""},
+		{"aud como arreglo", signRS256(t, key, "kid-1", with(map[string]interface{}{"aud": []string{"other", testClientID}})), ""},
+		{"audiencia en client_id", signRS256(t, key, "kid-1", with(map[string]interface{}{"aud": nil, "client_id": testClientID})), ""},
+
+		{"firma de otra llave", signRS256(t, otherKey, "kid-1", validClaims()), "firma del token inválida"},
+		{"kid desconocido", signRS256(t, key, "kid-2", validClaims()), "llave no encontrada para kid 'kid-2'"},
+		{"payload alterado", tamperPayload(t, signRS256(t, key, "kid-1", validClaims())), "firma del token inválida"},
+		{"alg none", unsignedToken(t, "none"), "algoritmo de firma no soportado: none"},
+		{"alg HS256", hs256Token(t, key), "algoritmo de firma no soportado: HS256"},
+		{"no es JWT", "abc.def", "el token no es válido"},
+
+		{"emisor distinto", signRS256(t, key, "kid-1", with(map[string]interface{}{"iss": "https://evil.example.com"})), "emisor del token inválido"},
+		{"audiencia distinta", signRS256(t, key, "kid-1", with(map[string]interface{}{"aud": "other"})), "audiencia del token inválida"},
+		{"sin audiencia", signRS256(t, key, "kid-1", with(map[string]interface{}{"aud": nil})), "audiencia del token inválida"},
+		{"token_use access", signRS256(t, key, "kid-1", with(map[string]interface{}{"token_use": "access"})), "token_use inválido: se esperaba 'id'"},
+		{"sin sub", signRS256(t, key, "kid-1", with(map[string]interface{}{"sub": nil})), "el token no contiene 'sub'"},
+
+		{"expirado dentro del margen", signRS256(t, key, "kid-1", with(map[string]interface{}{"exp": testNow.Add(-30 * time.Second).Unix()})), ""},
+		{"expirado fuera del margen", signRS256(t, key, "kid-1", with(map[string]interface{}{"exp": testNow.Add(-2 * time.Minute).Unix()})), "el token ha expirado"},
+		{"sin exp", signRS256(t, key, "kid-1", with(map[string]interface{}{"exp": nil})), "el token ha expirado"},
+		{"nbf dentro del margen", signRS256(t, key, "kid-1", with(map[string]interface{}{"nbf": testNow.Add(30 * time.Second).Unix()})), ""},
+		{"nbf fuera del margen", signRS256(t, key, "kid-1", with(map[string]interface{}{"nbf": testNow.Add(2 * time.Minute).Unix()})), "el token aún no es válido"},
+		{"iat en el futuro", signRS256(t, key, "kid-1", with(map[string]interface{}{"iat": testNow.Add(2 * time.Minute).Unix()})), "el token fue emitido en el futuro"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := v.Verify(tt.token)
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("error inesperado: %v", err)
+				}
+				if claims.Sub != "user-1" {
+					t.Errorf("sub = %q, se esperaba user-1", claims.Sub)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.err {
+				t.Fatalf("error = %v, se esperaba %q", err, tt.err)
+			}
+		})
+	}
+}
+
+// Con TokenUse vacío se aceptan access tokens, que traen client_id en lugar de aud
+func TestVerifierAccessToken(t *testing.T) {
+	key := generateKey(t)
+	v := testVerifier(StaticKeySet{"kid-1": &key.PublicKey})
+	v.TokenUse = ""
+
+	claims := validClaims()
+	delete(claims, "aud")
+	claims["client_id"] = testClientID
+	claims["token_use"] = "access"
+	claims["cognito:groups"] = []string{"admins"}
+
+	got, err := v.Verify(signRS256(t, key, "kid-1", claims))
+	if err != nil {
+		t.Fatalf("error inesperado: %v", err)
+	}
+	if got.ClientID != testClientID || got.TokenUse != "access" || len(got.Groups) != 1 {
+		t.Errorf("claims = %+v", got)
+	}
+}
+
+// Sin client_id configurado se rechaza todo, salvo que se omita la audiencia explícitamente
+func TestVerifierWithoutClientIDs(t *testing.T) {
+	key := generateKey(t)
+	v := testVerifier(StaticKeySet{"kid-1": &key.PublicKey})
+	v.ClientIDs = nil
+	token := signRS256(t, key, "kid-1", validClaims())
+
+	if _, err := v.Verify(token); err == nil || err.Error() != "el verificador no tiene client_id configurado" {
+		t.Fatalf("error = %v, se esperaba rechazo por falta de client_id", err)
+	}
+
+	v.SkipAudience = true
+	if _, err := v.Verify(token); err != nil {
+		t.Fatalf("error inesperado con SkipAudience: %v", err)
+	}
+}
+
+func TestNewVerifierFromEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		issuer   string
+		clientID string
+		skip     string
+		wantNil  bool
+		wantErr  bool
+	}{
+		{"sin issuer", "", "", "", true, false},
+		{"con client_id", testIssuer, testClientID, "", false, false},
+		{"sin client_id", testIssuer, "", "", true, true},
+		{"sin client_id con opt-out", testIssuer, "", "true", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("COGNITO_ISSUER", tt.issuer)
+			t.Setenv("COGNITO_USER_POOL_ID", "")
+			t.Setenv("COGNITO_CLIENT_ID", tt.clientID)
+			t.Setenv("JWT_SKIP_AUDIENCE", tt.skip)
+			t.Setenv("JWKS_FILE", "jwks.json")
+
+			v, err := NewVerifierFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, se esperaba error: %v", err, tt.wantErr)
+			}
+			if (v == nil) != tt.wantNil {
+				t.Fatalf("verificador = %v, se esperaba nil: %v", v, tt.wantNil)
+			}
+			if v != nil && v.SkipAudience != (tt.skip == "true") {
+				t.Errorf("SkipAudience = %v", v.SkipAudience)
+			}
+		})
+	}
+}
+
+func tamperPayload(t *testing.T, token string) string {
+	parts := strings.Split(token, ".")
+	claims := validClaims()
+	claims["sub"] = "admin"
+	parts[1] = encodeSegment(t, claims)
+	return strings.Join(parts, ".")
+}
+
+func unsignedToken(t *testing.T, alg string) string {
+	return encodeSegment(t, map[string]string{"alg": alg, "kid": "kid-1"}) + "." + encodeSegment(t, validClaims()) + "."
+}
+
+// hs256Token firma con HMAC usando la llave pública como secreto (confusión de algoritmo)
+func hs256Token(t *testing.T, key *rsa.PrivateKey) string {
+	signingInput := encodeSegment(t, map[string]string{"alg": "HS256", "kid": "kid-1"}) + "." + encodeSegment(t, validClaims())
+	mac := hmac.New(sha256.New, key.PublicKey.N.Bytes())
+	mac.Write([]byte(signingInput))
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// jwksServer sirve un JWKS que se puede rotar y cuenta las descargas
+type jwksServer struct {
+	mu       sync.Mutex
+	keys     map[string]*rsa.PublicKey
+	requests int
+}
+
+func (s *jwksServer) setKeys(keys map[string]*rsa.PublicKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+func (s *jwksServer) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func (s *jwksServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+
+	doc := map[string][]map[string]string{"keys": {}}
+	for kid, key := range s.keys {
+		doc["keys"] = append(doc["keys"], JWKFromPublicKey(kid, key))
+	}
+	json.NewEncoder(w).Encode(doc)
+}
+
+func TestRemoteKeySetRotation(t *testing.T) {
+	oldKey := generateKey(t)
+	newKey := generateKey(t)
+
+	jwks := &jwksServer{}
+	jwks.setKeys(map[string]*rsa.PublicKey{"kid-old": &oldKey.PublicKey})
+	server := httptest.NewServer(jwks)
+	defer server.Close()
+
+	keys := NewRemoteKeySet(server.URL, time.Hour)
+	v := testVerifier(keys)
+
+	if _, err := v.Verify(signRS256(t, oldKey, "kid-old", validClaims())); err != nil {
+		t.Fatalf("llave inicial: %v", err)
+	}
+	if _, err := v.Verify(signRS256(t, oldKey, "kid-old", validClaims())); err != nil {
+		t.Fatalf("llave en caché: %v", err)
+	}
+	if got := jwks.count(); got != 1 {
+		t.Fatalf("descargas = %d, se esperaba 1 (caché)", got)
+	}
+
+	// Rotación: el kid nuevo dentro del intervalo mínimo no recarga el JWKS
+	jwks.setKeys(map[string]*rsa.PublicKey{"kid-new": &newKey.PublicKey})
+	newToken := signRS256(t, newKey, "kid-new", validClaims())
+	if _, err := v.Verify(newToken); err == nil || err.Error() != "llave no encontrada para kid 'kid-new'" {
+		t.Fatalf("error = %v, se esperaba kid no encontrado", err)
+	}
+	if got := jwks.count(); got != 1 {
+		t.Fatalf("descargas = %d, se esperaba 1 (intervalo mínimo)", got)
+	}
+
+	// Pasado el intervalo mínimo el kid desconocido recarga el JWKS
+	keys.mu.Lock()
+	keys.lastAttempt = time.Now().Add(-keys.minRefresh)
+	keys.mu.Unlock()
+	if _, err := v.Verify(newToken); err != nil {
+		t.Fatalf("llave rotada: %v", err)
+	}
+	if got := jwks.count(); got != 2 {
+		t.Fatalf("descargas = %d, se esperaba 2", got)
+	}
+
+	// La llave retirada ya no valida
+	if _, err := v.Verify(signRS256(t, oldKey, "kid-old", validClaims())); err == nil {
+		t.Fatal("se esperaba error con la llave retirada")
+	}
+}
+
+func TestRemoteKeySetServerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	keys := NewRemoteKeySet(server.URL, time.Hour)
+	if _, err := keys.Key("kid-1"); err == nil || err.Error() != "JWKS respondió 500" {
+		t.Fatalf("error = %v, se esperaba JWKS respondió 500", err)
+	}
+}
+
+func TestParseJWKS(t *testing.T) {
+	key := generateKey(t)
+
+	tests := []struct {
+		name string
+		doc  string
+		err  string
+	}{
+		{"válido", mustJSON(t, map[string]interface{}{"keys": []map[string]string{JWKFromPublicKey("kid-1", &key.PublicKey)}}), ""},
+		{"sin llaves RSA", `{"keys": [{"kid": "ec", "kty": "EC"}]}`, "JWKS sin llaves RSA"},
+		{"vacío", `{"keys": []}`, "JWKS sin llaves RSA"},
+		{"módulo inválido", `{"keys": [{"kid": "k", "kty": "RSA", "n": "***", "e": "AQAB"}]}`, "módulo inválido en kid 'k'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := ParseJWKS([]byte(tt.doc))
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("error = %v, se esperaba %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error inesperado: %v", err)
+			}
+			if !keys["kid-1"].Equal(&key.PublicKey) {
+				t.Error("la llave parseada no coincide con la original")
+			}
+		})
+	}
+}
+
+func mustJSON(t *testing.T, value interface{}) string {
+	t.Helper()
+	raw, err := json.Marshal(value)
+	if err != nil {
+		t.Fatalf("no se pudo serializar: %v", err)
+	}
+	return string(raw)
+}
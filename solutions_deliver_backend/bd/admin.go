@@ -20,16 +20,22 @@ func init() {
 }
 
 // GetAdminDashboardStats obtiene todas las estadísticas del dashboard admin
-func GetAdminDashboardStats() (models.AdminDashboardStats, error) {
+// del alcance: guías con origen o destino en el hub (ingresos por origen) y
+// repartidores del hub. El consolidado incluye el resumen de cada hub.
+func GetAdminDashboardStats(scope models.HubScope) (models.AdminDashboardStats, error) {
 	fmt.Println("GetAdminDashboardStats")
 
 	var stats models.AdminDashboardStats
+	stats.HubID = scope.HubID
 
 	err := DbConnect()
 	if err != nil {
 		return stats, err
 	}
-	defer Db.Close()
+
+	guideFilter, guideArgs := guideHubCondition(scope, guideAnyCity)
+	salesFilter, salesArgs := guideHubCondition(scope, guideOriginCity)
+	userFilter, userArgs := userHubCondition(scope, "u")
 
 	// ====================================
 	// KPIs PRINCIPALES
@@ -37,38 +43,38 @@ func GetAdminDashboardStats() (models.AdminDashboardStats, error) {
 
 	// Guías de hoy
 	err = Db.QueryRow(`
-		SELECT COUNT(*) FROM shipping_guides
+		SELECT COUNT(*) FROM shipping_guides sg
 		WHERE DATE(created_at) = CURDATE()
-	`).Scan(&stats.ShipmentsToday)
+	`+salesFilter, salesArgs...).Scan(&stats.ShipmentsToday)
 	if err != nil {
 		return stats, fmt.Errorf("error obteniendo guías de hoy: %w", err)
 	}
 
 	// Guías de ayer
 	err = Db.QueryRow(`
-		SELECT COUNT(*) FROM shipping_guides
+		SELECT COUNT(*) FROM shipping_guides sg
 		WHERE DATE(created_at) = DATE_SUB(CURDATE(), INTERVAL 1 DAY)
-	`).Scan(&stats.ShipmentsYesterday)
+	`+salesFilter, salesArgs...).Scan(&stats.ShipmentsYesterday)
 	if err != nil {
 		return stats, fmt.Errorf("error obteniendo guías de ayer: %w", err)
 	}
 
 	// Entregadas hoy
 	err = Db.QueryRow(`
-		SELECT COUNT(*) FROM shipping_guides
+		SELECT COUNT(*) FROM shipping_guides sg
 		WHERE current_status = 'DELIVERED'
 		AND DATE(updated_at) = CURDATE()
-	`).Scan(&stats.Delivered)
+	`+guideFilter, guideArgs...).Scan(&stats.Delivered)
 	if err != nil {
 		return stats, fmt.Errorf("error obteniendo entregadas hoy: %w", err)
 	}
 
 	// Entregadas ayer
 	err = Db.QueryRow(`
-		SELECT COUNT(*) FROM shipping_guides
+		SELECT COUNT(*) FROM shipping_guides sg
 		WHERE current_status = 'DELIVERED'
 		AND DATE(updated_at) = DATE_SUB(CURDATE(), INTERVAL 1 DAY)
-	`).Scan(&stats.DeliveredYesterday)
+	`+guideFilter, guideArgs...).Scan(&stats.DeliveredYesterday)
 	if err != nil {
 		return stats, fmt.Errorf("error obteniendo entregadas ayer: %w", err)
 	}
@@ -78,47 +84,61 @@ func GetAdminDashboardStats() (models.AdminDashboardStats, error) {
 		stats.DeliveryRate = float64(stats.Delivered) / float64(stats.ShipmentsToday) * 100
 	}
 
-	// Pendientes totales
+	// Pendientes totales (sin estados finales)
 	err = Db.QueryRow(`
-		SELECT COUNT(*) FROM shipping_guides
-		WHERE current_status NOT IN ('DELIVERED')
-	`).Scan(&stats.Pending)
+		SELECT COUNT(*) FROM shipping_guides sg
+		WHERE current_status NOT IN ('DELIVERED', 'RETURNED_TO_SENDER', 'CANCELLED')
+	`+guideFilter, guideArgs...).Scan(&stats.Pending)
 	if err != nil {
 		return stats, fmt.Errorf("error obteniendo pendientes: %w", err)
 	}
 
 	// Pendientes en ruta
 	err = Db.QueryRow(`
-		SELECT COUNT(*) FROM shipping_guides
+		SELECT COUNT(*) FROM shipping_guides sg
 		WHERE current_status IN ('IN_ROUTE', 'OUT_FOR_DELIVERY')
-	`).Scan(&stats.PendingInRoute)
+	`+guideFilter, guideArgs...).Scan(&stats.PendingInRoute)
 	if err != nil {
 		return stats, fmt.Errorf("error obteniendo pendientes en ruta: %w", err)
 	}
 
 	// Pendientes en oficina (bodega)
 	err = Db.QueryRow(`
-		SELECT COUNT(*) FROM shipping_guides
+		SELECT COUNT(*) FROM shipping_guides sg
 		WHERE current_status IN ('CREATED', 'IN_WAREHOUSE')
-	`).Scan(&stats.PendingInOffice)
+	`+guideFilter, guideArgs...).Scan(&stats.PendingInOffice)
 	if err != nil {
 		return stats, fmt.Errorf("error obteniendo pendientes en oficina: %w", err)
 	}
 
+	// Excepciones
+	err = Db.QueryRow(`
+		SELECT
+			COALESCE(SUM(current_status = 'DELIVERY_FAILED'), 0),
+			COALESCE(SUM(current_status = 'RETURNED_TO_SENDER'), 0),
+			COALESCE(SUM(current_status = 'CANCELLED'), 0),
+			COALESCE(SUM(current_status = 'ON_HOLD'), 0)
+		FROM shipping_guides sg
+		WHERE 1 = 1
+	`+guideFilter, guideArgs...).Scan(&stats.DeliveryFailed, &stats.ReturnedToSender, &stats.Cancelled, &stats.OnHold)
+	if err != nil {
+		return stats, fmt.Errorf("error obteniendo excepciones: %w", err)
+	}
+
 	// Ingresos de hoy
 	err = Db.QueryRow(`
-		SELECT COALESCE(SUM(price), 0) FROM shipping_guides
+		SELECT COALESCE(SUM(price), 0) FROM shipping_guides sg
 		WHERE DATE(created_at) = CURDATE()
-	`).Scan(&stats.RevenueToday)
+	`+salesFilter, salesArgs...).Scan(&stats.RevenueToday)
 	if err != nil {
 		return stats, fmt.Errorf("error obteniendo ingresos de hoy: %w", err)
 	}
 
 	// Ingresos de ayer
 	err = Db.QueryRow(`
-		SELECT COALESCE(SUM(price), 0) FROM shipping_guides
+		SELECT COALESCE(SUM(price), 0) FROM shipping_guides sg
 		WHERE DATE(created_at) = DATE_SUB(CURDATE(), INTERVAL 1 DAY)
-	`).Scan(&stats.RevenueYesterday)
+	`+salesFilter, salesArgs...).Scan(&stats.RevenueYesterday)
 	if err != nil {
 		return stats, fmt.Errorf("error obteniendo ingresos de ayer: %w", err)
 	}
@@ -130,10 +150,10 @@ func GetAdminDashboardStats() (models.AdminDashboardStats, error) {
 	// Tiempo promedio de entrega (en horas) - últimos 30 días
 	err = Db.QueryRow(`
 		SELECT COALESCE(AVG(TIMESTAMPDIFF(HOUR, created_at, updated_at)), 0)
-		FROM shipping_guides
+		FROM shipping_guides sg
 		WHERE current_status = 'DELIVERED'
 		AND updated_at >= DATE_SUB(CURDATE(), INTERVAL 30 DAY)
-	`).Scan(&stats.AverageDeliveryTime)
+	`+guideFilter, guideArgs...).Scan(&stats.AverageDeliveryTime)
 	if err != nil {
 		// No es crítico, continuamos con 0
 		stats.AverageDeliveryTime = 0
@@ -141,10 +161,11 @@ func GetAdminDashboardStats() (models.AdminDashboardStats, error) {
 
 	// Tasa de satisfacción - promedio de calificaciones (escala 1-5 convertida a porcentaje)
 	err = Db.QueryRow(`
-		SELECT COALESCE(AVG(rating) * 20, 0)
-		FROM delivery_ratings
-		WHERE created_at >= DATE_SUB(CURDATE(), INTERVAL 30 DAY)
-	`).Scan(&stats.SatisfactionRate)
+		SELECT COALESCE(AVG(r.rating) * 20, 0)
+		FROM delivery_ratings r
+		JOIN users u ON r.delivery_user_id = u.user_uuid
+		WHERE r.created_at >= DATE_SUB(CURDATE(), INTERVAL 30 DAY)
+	`+userFilter, userArgs...).Scan(&stats.SatisfactionRate)
 	if err != nil {
 		// No es crítico, continuamos con 0
 		stats.SatisfactionRate = 0
@@ -161,9 +182,10 @@ func GetAdminDashboardStats() (models.AdminDashboardStats, error) {
 	// ====================================
 	statusRows, err := Db.Query(`
 		SELECT current_status, COUNT(*) as count
-		FROM shipping_guides
+		FROM shipping_guides sg
+		WHERE 1 = 1 `+guideFilter+`
 		GROUP BY current_status
-	`)
+	`, guideArgs...)
 	if err != nil {
 		return stats, fmt.Errorf("error obteniendo distribución: %w", err)
 	}
@@ -217,10 +239,10 @@ func GetAdminDashboardStats() (models.AdminDashboardStats, error) {
 			FROM delivery_ratings
 			GROUP BY delivery_user_id
 		) ratings ON u.user_uuid = ratings.delivery_user_id
-		WHERE u.role = 'DELIVERY'
+		WHERE u.role = 'DELIVERY' `+userFilter+`
 		ORDER BY completed.cnt DESC
 		LIMIT 10
-	`)
+	`, userArgs...)
 	if err != nil {
 		return stats, fmt.Errorf("error obteniendo rendimiento: %w", err)
 	}
@@ -264,10 +286,10 @@ func GetAdminDashboardStats() (models.AdminDashboardStats, error) {
 		LEFT JOIN users u ON da.delivery_user_id = u.user_uuid
 		LEFT JOIN guide_parties receiver ON sg.guide_id = receiver.guide_id AND receiver.party_role = 'RECEIVER'
 		WHERE da.status IN ('PENDING', 'IN_PROGRESS')
-		AND da.assignment_type = 'DELIVERY'
+		AND da.assignment_type = 'DELIVERY' `+userFilter+`
 		ORDER BY da.assigned_at DESC
 		LIMIT 20
-	`)
+	`, userArgs...)
 	if err != nil {
 		return stats, fmt.Errorf("error obteniendo entregas en tiempo real: %w", err)
 	}
@@ -324,10 +346,10 @@ func GetAdminDashboardStats() (models.AdminDashboardStats, error) {
 			GROUP BY da.delivery_user_id, c.name
 			LIMIT 1
 		) zone ON u.user_uuid = zone.delivery_user_id
-		WHERE u.role = 'DELIVERY'
+		WHERE u.role = 'DELIVERY' `+userFilter+`
 		AND total.cnt > 0
 		ORDER BY total.cnt DESC
-	`)
+	`, userArgs...)
 	if err != nil {
 		return stats, fmt.Errorf("error obteniendo rutas activas: %w", err)
 	}
@@ -355,7 +377,17 @@ func GetAdminDashboardStats() (models.AdminDashboardStats, error) {
 	// ====================================
 	// ALERTAS DEL SISTEMA
 	// ====================================
-	stats.Alerts = generateSystemAlerts()
+	stats.Alerts = generateSystemAlerts(scope)
+
+	// ====================================
+	// RESUMEN POR HUB (CONSOLIDADO)
+	// ====================================
+	if scope.Consolidated() {
+		stats.ByHub, err = getHubSummaries()
+		if err != nil {
+			return stats, fmt.Errorf("error obteniendo resumen por hub: %w", err)
+		}
+	}
 
 	// Asegurar que los arrays no sean nil
 	if stats.StatusDistribution == nil {
@@ -378,23 +410,26 @@ func GetAdminDashboardStats() (models.AdminDashboardStats, error) {
 }
 
 // generateSystemAlerts genera alertas del sistema basadas en datos reales
-func generateSystemAlerts() []models.SystemAlert {
+func generateSystemAlerts(scope models.HubScope) []models.SystemAlert {
 	var alerts []models.SystemAlert
 
 	err := DbConnect()
 	if err != nil {
 		return alerts
 	}
-	defer Db.Close()
+
+	guideFilter, guideArgs := guideHubCondition(scope, guideAnyCity)
+	pickupFilter, pickupArgs := guideHubCondition(scope, guideOriginCity)
+	userFilter, userArgs := userHubCondition(scope, "u")
 
 	// Alerta: Guías retrasadas (más de 24 horas sin actualización)
 	delayedRows, err := Db.Query(`
 		SELECT guide_id, current_status, TIMESTAMPDIFF(HOUR, updated_at, NOW()) as hours_delayed
-		FROM shipping_guides
-		WHERE current_status NOT IN ('DELIVERED')
-		AND updated_at < DATE_SUB(NOW(), INTERVAL 24 HOUR)
+		FROM shipping_guides sg
+		WHERE current_status NOT IN ('DELIVERED', 'RETURNED_TO_SENDER', 'CANCELLED')
+		AND updated_at < DATE_SUB(NOW(), INTERVAL 24 HOUR) `+guideFilter+`
 		LIMIT 5
-	`)
+	`, guideArgs...)
 	if err == nil {
 		defer delayedRows.Close()
 		for delayedRows.Next() {
@@ -420,11 +455,11 @@ func generateSystemAlerts() []models.SystemAlert {
 		FROM users u
 		JOIN delivery_assignments da ON u.user_uuid = da.delivery_user_id
 		WHERE da.status = 'PENDING'
-		AND da.assigned_at < DATE_SUB(NOW(), INTERVAL 2 HOUR)
+		AND da.assigned_at < DATE_SUB(NOW(), INTERVAL 2 HOUR) `+userFilter+`
 		GROUP BY u.user_uuid, u.full_name
 		HAVING pending_count > 0
 		LIMIT 5
-	`)
+	`, userArgs...)
 	if err == nil {
 		defer inactiveRows.Close()
 		for inactiveRows.Next() {
@@ -454,7 +489,7 @@ func generateSystemAlerts() []models.SystemAlert {
 			WHERE da.guide_id = sg.guide_id
 			AND da.status NOT IN ('CANCELLED')
 		)
-	`).Scan(&unassignedCount)
+	`+pickupFilter, pickupArgs...).Scan(&unassignedCount)
 	if err == nil && unassignedCount > 0 {
 		alerts = append(alerts, models.SystemAlert{
 			ID:          "unassigned_guides",
@@ -468,8 +503,56 @@ func generateSystemAlerts() []models.SystemAlert {
 	return alerts
 }
 
-// GetEmployees obtiene la lista de empleados
-func GetEmployees(role string) ([]models.Employee, error) {
+// getHubSummaries resumen de cada hub para el dashboard consolidado: guías e
+// ingresos de hoy por origen, pendientes por origen o destino y repartidores
+func getHubSummaries() ([]models.HubSummary, error) {
+	summaries := []models.HubSummary{}
+
+	hubGuide := func(cities string) string {
+		return fmt.Sprintf(`EXISTS (
+				SELECT 1 FROM cities gc
+				JOIN hub_coverage hc ON hc.city_id = gc.id OR hc.department_id = gc.department_id
+				WHERE gc.id IN (%s)
+				AND hc.hub_id = h.hub_id)`, cities)
+	}
+
+	rows, err := Db.Query(fmt.Sprintf(`
+		SELECT
+			h.hub_id,
+			h.code,
+			h.name,
+			(SELECT COUNT(*) FROM shipping_guides sg
+			 WHERE DATE(sg.created_at) = CURDATE() AND %[1]s) AS shipments_today,
+			(SELECT COALESCE(SUM(sg.price), 0) FROM shipping_guides sg
+			 WHERE DATE(sg.created_at) = CURDATE() AND %[1]s) AS revenue_today,
+			(SELECT COUNT(*) FROM shipping_guides sg
+			 WHERE sg.current_status NOT IN ('DELIVERED', 'RETURNED_TO_SENDER', 'CANCELLED')
+			 AND %[2]s) AS pending,
+			(SELECT COUNT(*) FROM users u
+			 WHERE u.hub_id = h.hub_id AND u.role = 'DELIVERY') AS couriers
+		FROM hubs h
+		WHERE h.active = TRUE
+		ORDER BY h.code
+	`, hubGuide(guideOriginCity), hubGuide(guideAnyCity)))
+	if err != nil {
+		return summaries, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var hs models.HubSummary
+		err := rows.Scan(&hs.HubID, &hs.Code, &hs.Name, &hs.ShipmentsToday, &hs.RevenueToday, &hs.Pending, &hs.Couriers)
+		if err != nil {
+			return summaries, err
+		}
+		summaries = append(summaries, hs)
+	}
+
+	return summaries, nil
+}
+
+// GetEmployees obtiene la lista de empleados del alcance
+func GetEmployees(role string, scope models.HubScope) ([]models.Employee, error) {
 	fmt.Printf("GetEmployees -> Role: %s\n", role)
 
 	var employees []models.Employee
@@ -478,7 +561,6 @@ func GetEmployees(role string) ([]models.Employee, error) {
 	if err != nil {
 		return employees, err
 	}
-	defer Db.Close()
 
 	query := `
 		SELECT
@@ -489,8 +571,11 @@ func GetEmployees(role string) ([]models.Employee, error) {
 			u.role,
 			u.created_at,
 			u.last_login,
-			COALESCE(completed.cnt, 0) as total_completed
+			COALESCE(completed.cnt, 0) as total_completed,
+			u.hub_id,
+			h.code AS hub_code
 		FROM users u
+		LEFT JOIN hubs h ON u.hub_id = h.hub_id
 		LEFT JOIN (
 			SELECT delivery_user_id, COUNT(*) as cnt
 			FROM delivery_assignments
@@ -500,18 +585,19 @@ func GetEmployees(role string) ([]models.Employee, error) {
 		WHERE u.role != 'CLIENT'
 	`
 
+	var args []interface{}
 	if role != "" {
 		query += " AND u.role = ?"
+		args = append(args, role)
 	}
 
+	hubFilter, hubArgs := userHubCondition(scope, "u")
+	query += " " + hubFilter
+	args = append(args, hubArgs...)
+
 	query += " ORDER BY u.full_name"
 
-	var rows *sql.Rows
-	if role != "" {
-		rows, err = Db.Query(query, role)
-	} else {
-		rows, err = Db.Query(query)
-	}
+	rows, err := Db.Query(query, args...)
 	if err != nil {
 		return employees, err
 	}
@@ -519,9 +605,10 @@ func GetEmployees(role string) ([]models.Employee, error) {
 
 	for rows.Next() {
 		var e models.Employee
-		var fullName, phone sql.NullString
+		var fullName, phone, hubCode sql.NullString
 		var lastLogin sql.NullTime
 		var createdAt time.Time
+		var hubID sql.NullInt64
 
 		err := rows.Scan(
 			&e.UserUUID,
@@ -532,10 +619,13 @@ func GetEmployees(role string) ([]models.Employee, error) {
 			&createdAt,
 			&lastLogin,
 			&e.TotalCompleted,
+			&hubID,
+			&hubCode,
 		)
 		if err != nil {
 			continue
 		}
+		setEmployeeHub(&e, hubID, hubCode)
 
 		if fullName.Valid {
 			e.FullName = fullName.String
@@ -589,7 +679,6 @@ func GetEmployeeByID(userUUID string) (models.Employee, error) {
 	if err != nil {
 		return e, err
 	}
-	defer Db.Close()
 
 	query := `
 		SELECT
@@ -600,8 +689,11 @@ func GetEmployeeByID(userUUID string) (models.Employee, error) {
 			u.role,
 			u.created_at,
 			u.last_login,
-			COALESCE(completed.cnt, 0) as total_completed
+			COALESCE(completed.cnt, 0) as total_completed,
+			u.hub_id,
+			h.code AS hub_code
 		FROM users u
+		LEFT JOIN hubs h ON u.hub_id = h.hub_id
 		LEFT JOIN (
 			SELECT delivery_user_id, COUNT(*) as cnt
 			FROM delivery_assignments
@@ -611,9 +703,10 @@ func GetEmployeeByID(userUUID string) (models.Employee, error) {
 		WHERE u.user_uuid = ?
 	`
 
-	var fullName, phone sql.NullString
+	var fullName, phone, hubCode sql.NullString
 	var lastLogin sql.NullTime
 	var createdAt time.Time
+	var hubID sql.NullInt64
 
 	err = Db.QueryRow(query, userUUID).Scan(
 		&e.UserUUID,
@@ -624,6 +717,8 @@ func GetEmployeeByID(userUUID string) (models.Employee, error) {
 		&createdAt,
 		&lastLogin,
 		&e.TotalCompleted,
+		&hubID,
+		&hubCode,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -631,6 +726,7 @@ func GetEmployeeByID(userUUID string) (models.Employee, error) {
 		}
 		return e, err
 	}
+	setEmployeeHub(&e, hubID, hubCode)
 
 	if fullName.Valid {
 		e.FullName = fullName.String
@@ -658,7 +754,6 @@ func GetUserByDocument(documentNumber string) (models.Employee, error) {
 	if err != nil {
 		return e, err
 	}
-	defer Db.Close()
 
 	query := `
 		SELECT
@@ -671,8 +766,11 @@ func GetUserByDocument(documentNumber string) (models.Employee, error) {
 			u.number_document,
 			u.created_at,
 			u.last_login,
-			COALESCE(completed.cnt, 0) as total_completed
+			COALESCE(completed.cnt, 0) as total_completed,
+			u.hub_id,
+			h.code AS hub_code
 		FROM users u
+		LEFT JOIN hubs h ON u.hub_id = h.hub_id
 		LEFT JOIN (
 			SELECT delivery_user_id, COUNT(*) as cnt
 			FROM delivery_assignments
@@ -682,9 +780,10 @@ func GetUserByDocument(documentNumber string) (models.Employee, error) {
 		WHERE u.number_document = ?
 	`
 
-	var fullName, phone, typeDoc, numberDoc sql.NullString
+	var fullName, phone, typeDoc, numberDoc, hubCode sql.NullString
 	var lastLogin sql.NullTime
 	var createdAt time.Time
+	var hubID sql.NullInt64
 
 	err = Db.QueryRow(query, documentNumber).Scan(
 		&e.UserUUID,
@@ -697,6 +796,8 @@ func GetUserByDocument(documentNumber string) (models.Employee, error) {
 		&createdAt,
 		&lastLogin,
 		&e.TotalCompleted,
+		&hubID,
+		&hubCode,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -704,6 +805,7 @@ func GetUserByDocument(documentNumber string) (models.Employee, error) {
 		}
 		return e, err
 	}
+	setEmployeeHub(&e, hubID, hubCode)
 
 	if fullName.Valid {
 		e.FullName = fullName.String
@@ -748,7 +850,15 @@ func GetUserByDocument(documentNumber string) (models.Employee, error) {
 	return e, nil
 }
 
-// UpdateEmployee actualiza un empleado
+// setEmployeeHub asigna el hub leído (NULL: sin hub)
+func setEmployeeHub(e *models.Employee, hubID sql.NullInt64, hubCode sql.NullString) {
+	if hubID.Valid {
+		e.HubID = &hubID.Int64
+		e.HubCode = hubCode.String
+	}
+}
+
+// UpdateEmployee actualiza un empleado. HubID 0 quita el hub.
 func UpdateEmployee(userUUID string, req models.UpdateEmployeeRequest) error {
 	fmt.Printf("UpdateEmployee -> ID: %s\n", userUUID)
 
@@ -756,7 +866,6 @@ func UpdateEmployee(userUUID string, req models.UpdateEmployeeRequest) error {
 	if err != nil {
 		return err
 	}
-	defer Db.Close()
 
 	query := `UPDATE users SET `
 	var args []interface{}
@@ -774,6 +883,10 @@ func UpdateEmployee(userUUID string, req models.UpdateEmployeeRequest) error {
 		updates = append(updates, "role = ?")
 		args = append(args, req.Role)
 	}
+	if req.HubID != nil {
+		updates = append(updates, "hub_id = ?")
+		args = append(args, nullIfZero(*req.HubID))
+	}
 
 	if len(updates) == 0 {
 		return fmt.Errorf("no hay campos para actualizar")
@@ -800,7 +913,6 @@ func GetClientRanking(filters models.ClientRankingFilters) (models.ClientRanking
 	if err != nil {
 		return response, err
 	}
-	defer Db.Close()
 
 	// Construir la consulta base
 	query := `
@@ -832,6 +944,11 @@ func GetClientRanking(filters models.ClientRankingFilters) (models.ClientRanking
 		args = append(args, filters.DateTo)
 	}
 
+	// Filtro de hub (guías con origen en el hub)
+	hubFilter, hubArgs := guideHubCondition(models.HubScope{HubID: filters.HubID}, guideOriginCity)
+	query += " " + hubFilter
+	args = append(args, hubArgs...)
+
 	query += " GROUP BY u.user_uuid, u.full_name, u.email, u.phone"
 
 	// Filtro de mínimo de guías
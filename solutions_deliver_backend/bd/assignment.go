@@ -20,7 +20,6 @@ func CreateAssignment(req models.CreateAssignmentRequest, assignedBy string) (mo
 	if err != nil {
 		return assignment, err
 	}
-	defer Db.Close()
 
 	// Iniciar transacción
 	tx, err := Db.Begin()
@@ -45,11 +44,12 @@ func CreateAssignment(req models.CreateAssignmentRequest, assignedBy string) (mo
 		return assignment, fmt.Errorf("ya existe una asignación activa de tipo %s para esta guía", req.AssignmentType)
 	}
 
-	// Insertar asignación
+	// Insertar asignación (vehículo y bodegas solo en traslados)
 	insertQuery := `
 		INSERT INTO delivery_assignments
-		(guide_id, delivery_user_id, assignment_type, status, notes, assigned_by, assigned_at)
-		VALUES (?, ?, ?, 'PENDING', ?, ?, NOW())
+		(guide_id, delivery_user_id, assignment_type, status, notes, assigned_by, assigned_at,
+		 vehicle_plate, origin_warehouse_id, destination_warehouse_id)
+		VALUES (?, ?, ?, 'PENDING', ?, ?, NOW(), ?, ?, ?)
 	`
 
 	result, err := tx.Exec(insertQuery,
@@ -58,6 +58,9 @@ func CreateAssignment(req models.CreateAssignmentRequest, assignedBy string) (mo
 		req.AssignmentType,
 		req.Notes,
 		assignedBy,
+		nullIfEmpty(req.VehiclePlate),
+		nullIfZero(req.OriginWarehouseID),
+		nullIfZero(req.DestinationWarehouseID),
 	)
 	if err != nil {
 		tx.Rollback()
@@ -102,7 +105,6 @@ func GetAssignmentByID(assignmentID int64) (models.DeliveryAssignment, error) {
 	if err != nil {
 		return assignment, err
 	}
-	defer Db.Close()
 
 	query := `
 		SELECT
@@ -110,6 +112,7 @@ func GetAssignmentByID(assignmentID int64) (models.DeliveryAssignment, error) {
 			da.guide_id,
 			da.delivery_user_id,
 			du.full_name AS delivery_user_name,
+			du.hub_id,
 			da.assignment_type,
 			da.status,
 			da.notes,
@@ -118,6 +121,13 @@ func GetAssignmentByID(assignmentID int64) (models.DeliveryAssignment, error) {
 			da.assigned_at,
 			da.updated_at,
 			da.completed_at,
+			da.attempt_number,
+			da.scheduled_date,
+			da.vehicle_plate,
+			da.origin_warehouse_id,
+			ow.name AS origin_warehouse_name,
+			da.destination_warehouse_id,
+			dw.name AS destination_warehouse_name,
 			sg.service_type,
 			sg.current_status,
 			oc.name AS origin_city_name,
@@ -132,6 +142,8 @@ func GetAssignmentByID(assignmentID int64) (models.DeliveryAssignment, error) {
 		FROM delivery_assignments da
 		LEFT JOIN users du ON da.delivery_user_id = du.user_uuid
 		LEFT JOIN users abu ON da.assigned_by = abu.user_uuid
+		LEFT JOIN warehouses ow ON da.origin_warehouse_id = ow.warehouse_id
+		LEFT JOIN warehouses dw ON da.destination_warehouse_id = dw.warehouse_id
 		LEFT JOIN shipping_guides sg ON da.guide_id = sg.guide_id
 		LEFT JOIN cities oc ON sg.origin_city_id = oc.id
 		LEFT JOIN cities dc ON sg.destination_city_id = dc.id
@@ -140,11 +152,13 @@ func GetAssignmentByID(assignmentID int64) (models.DeliveryAssignment, error) {
 		WHERE da.assignment_id = ?
 	`
 
-	var completedAt sql.NullTime
+	var completedAt, scheduledDate sql.NullTime
 	var deliveryUserName, assignedByName sql.NullString
 	var senderName, senderAddr, senderPhone sql.NullString
 	var receiverName, receiverAddr, receiverPhone sql.NullString
 	var notes sql.NullString
+	var hubID sql.NullInt64
+	var transfer transferColumns
 	var guideInfo models.GuideInfo
 	var guideCreatedAt time.Time
 
@@ -154,6 +168,7 @@ func GetAssignmentByID(assignmentID int64) (models.DeliveryAssignment, error) {
 		&assignment.GuideID,
 		&assignment.DeliveryUserID,
 		&deliveryUserName,
+		&hubID,
 		&assignment.AssignmentType,
 		&assignment.Status,
 		&notes,
@@ -162,6 +177,13 @@ func GetAssignmentByID(assignmentID int64) (models.DeliveryAssignment, error) {
 		&assignment.AssignedAt,
 		&assignment.UpdatedAt,
 		&completedAt,
+		&assignment.AttemptNumber,
+		&scheduledDate,
+		&transfer.vehiclePlate,
+		&transfer.originID,
+		&transfer.originName,
+		&transfer.destinationID,
+		&transfer.destinationName,
 		&guideInfo.ServiceType,
 		&guideInfo.CurrentStatus,
 		&guideInfo.OriginCityName,
@@ -185,6 +207,9 @@ func GetAssignmentByID(assignmentID int64) (models.DeliveryAssignment, error) {
 	if deliveryUserName.Valid {
 		assignment.DeliveryUserName = deliveryUserName.String
 	}
+	if hubID.Valid {
+		assignment.HubID = &hubID.Int64
+	}
 	if assignedByName.Valid {
 		assignment.AssignedByName = assignedByName.String
 	}
@@ -194,6 +219,10 @@ func GetAssignmentByID(assignmentID int64) (models.DeliveryAssignment, error) {
 	if completedAt.Valid {
 		assignment.CompletedAt = &completedAt.Time
 	}
+	if scheduledDate.Valid {
+		assignment.ScheduledDate = scheduledDate.Time.Format("2006-01-02")
+	}
+	transfer.apply(&assignment)
 
 	// Información de la guía
 	guideInfo.GuideID = assignment.GuideID
@@ -222,6 +251,20 @@ func GetAssignmentByID(assignmentID int64) (models.DeliveryAssignment, error) {
 	return assignment, nil
 }
 
+// transferColumns columnas de traslado (NULL en las demás asignaciones)
+type transferColumns struct {
+	vehiclePlate                sql.NullString
+	originID, destinationID     sql.NullInt64
+	originName, destinationName sql.NullString
+}
+
+func (t transferColumns) apply(a *models.DeliveryAssignment) {
+	a.VehiclePlate = t.vehiclePlate.String
+	a.OriginWarehouseID = t.originID.Int64
+	a.OriginWarehouseName = t.originName.String
+	a.DestinationWarehouseID = t.destinationID.Int64
+	a.DestinationWarehouseName = t.destinationName.String
+}
 
 // ReassignDelivery reasigna una entrega a otro entregador (solo ADMIN)
 func ReassignDelivery(assignmentID int64, newDeliveryUserID string, notes string, changedBy string) (models.DeliveryAssignment, error) {
@@ -233,7 +276,6 @@ func ReassignDelivery(assignmentID int64, newDeliveryUserID string, notes string
 	if err != nil {
 		return assignment, err
 	}
-	defer Db.Close()
 
 	tx, err := Db.Begin()
 	if err != nil {
@@ -290,8 +332,9 @@ func ReassignDelivery(assignmentID int64, newDeliveryUserID string, notes string
 	return GetAssignmentByID(assignmentID)
 }
 
-// UpdateAssignmentStatus actualiza el estado de una asignación
-func UpdateAssignmentStatus(assignmentID int64, newStatus models.AssignmentStatus, notes string, changedBy string) (models.DeliveryAssignment, error) {
+// UpdateAssignmentStatus actualiza el estado de una asignación y, si
+// guideChange no es nil, el de su guía en la misma transacción
+func UpdateAssignmentStatus(assignmentID int64, newStatus models.AssignmentStatus, notes string, changedBy string, guideChange *models.GuideStatusChange) (models.DeliveryAssignment, error) {
 	fmt.Printf("UpdateAssignmentStatus -> ID: %d, Status: %s\n", assignmentID, newStatus)
 
 	var assignment models.DeliveryAssignment
@@ -300,17 +343,17 @@ func UpdateAssignmentStatus(assignmentID int64, newStatus models.AssignmentStatu
 	if err != nil {
 		return assignment, err
 	}
-	defer Db.Close()
 
 	tx, err := Db.Begin()
 	if err != nil {
 		return assignment, err
 	}
 
-	// Obtener estado actual
+	// Obtener y bloquear el estado actual
+	var guideID int64
 	var currentStatus string
-	query := `SELECT status FROM delivery_assignments WHERE assignment_id = ?`
-	err = tx.QueryRow(query, assignmentID).Scan(&currentStatus)
+	query := `SELECT guide_id, status FROM delivery_assignments WHERE assignment_id = ? FOR UPDATE`
+	err = tx.QueryRow(query, assignmentID).Scan(&guideID, &currentStatus)
 	if err != nil {
 		tx.Rollback()
 		return assignment, fmt.Errorf("asignación no encontrada")
@@ -349,6 +392,14 @@ func UpdateAssignmentStatus(assignmentID int64, newStatus models.AssignmentStatu
 		return assignment, err
 	}
 
+	if guideChange != nil {
+		err = updateGuideStatusTx(tx, guideID, guideChange.Status, guideChange.Reason, "", changedBy)
+		if err != nil {
+			tx.Rollback()
+			return assignment, err
+		}
+	}
+
 	err = tx.Commit()
 	if err != nil {
 		return assignment, err
@@ -368,7 +419,6 @@ func GetAssignmentsByFilters(filters models.AssignmentFilters) ([]models.Deliver
 	if err != nil {
 		return assignments, 0, err
 	}
-	defer Db.Close()
 
 	// Construcción dinámica de WHERE
 	var conditions []string
@@ -404,13 +454,22 @@ func GetAssignmentsByFilters(filters models.AssignmentFilters) ([]models.Deliver
 		args = append(args, *filters.DateTo)
 	}
 
+	if filters.HubID != nil {
+		conditions = append(conditions, "du.hub_id = ?")
+		args = append(args, *filters.HubID)
+	}
+
 	whereClause := ""
 	if len(conditions) > 0 {
 		whereClause = "WHERE " + strings.Join(conditions, " AND ")
 	}
 
 	// Contar total
-	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM delivery_assignments da %s`, whereClause)
+	countQuery := fmt.Sprintf(`
+		SELECT COUNT(*) FROM delivery_assignments da
+		LEFT JOIN users du ON da.delivery_user_id = du.user_uuid
+		%s
+	`, whereClause)
 	err = Db.QueryRow(countQuery, args...).Scan(&total)
 	if err != nil {
 		return assignments, 0, err
@@ -423,6 +482,7 @@ func GetAssignmentsByFilters(filters models.AssignmentFilters) ([]models.Deliver
 			da.guide_id,
 			da.delivery_user_id,
 			du.full_name AS delivery_user_name,
+			du.hub_id,
 			da.assignment_type,
 			da.status,
 			da.notes,
@@ -431,6 +491,13 @@ func GetAssignmentsByFilters(filters models.AssignmentFilters) ([]models.Deliver
 			da.assigned_at,
 			da.updated_at,
 			da.completed_at,
+			da.attempt_number,
+			da.scheduled_date,
+			da.vehicle_plate,
+			da.origin_warehouse_id,
+			ow.name AS origin_warehouse_name,
+			da.destination_warehouse_id,
+			dw.name AS destination_warehouse_name,
 			sg.service_type,
 			sg.current_status,
 			oc.name AS origin_city_name,
@@ -445,6 +512,8 @@ func GetAssignmentsByFilters(filters models.AssignmentFilters) ([]models.Deliver
 		FROM delivery_assignments da
 		LEFT JOIN users du ON da.delivery_user_id = du.user_uuid
 		LEFT JOIN users abu ON da.assigned_by = abu.user_uuid
+		LEFT JOIN warehouses ow ON da.origin_warehouse_id = ow.warehouse_id
+		LEFT JOIN warehouses dw ON da.destination_warehouse_id = dw.warehouse_id
 		LEFT JOIN shipping_guides sg ON da.guide_id = sg.guide_id
 		LEFT JOIN cities oc ON sg.origin_city_id = oc.id
 		LEFT JOIN cities dc ON sg.destination_city_id = dc.id
@@ -466,17 +535,20 @@ func GetAssignmentsByFilters(filters models.AssignmentFilters) ([]models.Deliver
 	for rows.Next() {
 		var a models.DeliveryAssignment
 		var guideInfo models.GuideInfo
-		var completedAt sql.NullTime
+		var completedAt, scheduledDate sql.NullTime
 		var deliveryUserName, assignedByName, notes sql.NullString
 		var senderName, senderAddr, senderPhone sql.NullString
 		var receiverName, receiverAddr, receiverPhone sql.NullString
 		var guideCreatedAt sql.NullTime
+		var hubID sql.NullInt64
+		var transfer transferColumns
 
 		err := rows.Scan(
 			&a.AssignmentID,
 			&a.GuideID,
 			&a.DeliveryUserID,
 			&deliveryUserName,
+			&hubID,
 			&a.AssignmentType,
 			&a.Status,
 			&notes,
@@ -485,6 +557,13 @@ func GetAssignmentsByFilters(filters models.AssignmentFilters) ([]models.Deliver
 			&a.AssignedAt,
 			&a.UpdatedAt,
 			&completedAt,
+			&a.AttemptNumber,
+			&scheduledDate,
+			&transfer.vehiclePlate,
+			&transfer.originID,
+			&transfer.originName,
+			&transfer.destinationID,
+			&transfer.destinationName,
 			&guideInfo.ServiceType,
 			&guideInfo.CurrentStatus,
 			&guideInfo.OriginCityName,
@@ -504,6 +583,9 @@ func GetAssignmentsByFilters(filters models.AssignmentFilters) ([]models.Deliver
 		if deliveryUserName.Valid {
 			a.DeliveryUserName = deliveryUserName.String
 		}
+		if hubID.Valid {
+			a.HubID = &hubID.Int64
+		}
 		if assignedByName.Valid {
 			a.AssignedByName = assignedByName.String
 		}
@@ -513,6 +595,10 @@ func GetAssignmentsByFilters(filters models.AssignmentFilters) ([]models.Deliver
 		if completedAt.Valid {
 			a.CompletedAt = &completedAt.Time
 		}
+		if scheduledDate.Valid {
+			a.ScheduledDate = scheduledDate.Time.Format("2006-01-02")
+		}
+		transfer.apply(&a)
 
 		// Asignar datos del sender
 		if senderName.Valid {
@@ -550,8 +636,8 @@ func GetAssignmentsByFilters(filters models.AssignmentFilters) ([]models.Deliver
 	return assignments, total, nil
 }
 
-// GetDeliveryUsers obtiene la lista de entregadores disponibles
-func GetDeliveryUsers() ([]models.DeliveryUser, error) {
+// GetDeliveryUsers obtiene la lista de entregadores disponibles del alcance
+func GetDeliveryUsers(scope models.HubScope) ([]models.DeliveryUser, error) {
 	fmt.Println("GetDeliveryUsers")
 
 	var users []models.DeliveryUser
@@ -560,9 +646,10 @@ func GetDeliveryUsers() ([]models.DeliveryUser, error) {
 	if err != nil {
 		return users, err
 	}
-	defer Db.Close()
 
-	query := `
+	hubFilter, args := userHubCondition(scope, "u")
+
+	query := fmt.Sprintf(`
 		SELECT
 			u.user_uuid,
 			u.full_name,
@@ -591,10 +678,11 @@ func GetDeliveryUsers() ([]models.DeliveryUser, error) {
 			GROUP BY delivery_user_id
 		) completed ON u.user_uuid = completed.delivery_user_id
 		WHERE u.role = 'DELIVERY'
+		%s
 		ORDER BY u.full_name
-	`
+	`, hubFilter)
 
-	rows, err := Db.Query(query)
+	rows, err := Db.Query(query, args...)
 	if err != nil {
 		return users, err
 	}
@@ -630,9 +718,10 @@ func GetDeliveryUsers() ([]models.DeliveryUser, error) {
 	return users, nil
 }
 
-// GetPendingPickups obtiene guías pendientes de recoger (origen Bogotá)
-func GetPendingPickups() ([]models.PendingGuide, error) {
-	fmt.Println("GetPendingPickups - Buscando guías con estado CREATED y origen BOGOTÁ D.C.")
+// GetPendingPickups obtiene guías pendientes de recoger: su ciudad de origen
+// la cubre un hub del alcance
+func GetPendingPickups(scope models.HubScope) ([]models.PendingGuide, error) {
+	fmt.Println("GetPendingPickups - Buscando guías con estado CREATED y origen en el hub")
 
 	var guides []models.PendingGuide
 
@@ -640,9 +729,10 @@ func GetPendingPickups() ([]models.PendingGuide, error) {
 	if err != nil {
 		return guides, err
 	}
-	defer Db.Close()
 
-	query := `
+	hubFilter, args := hubCondition(scope)
+
+	query := fmt.Sprintf(`
 		SELECT
 			sg.guide_id,
 			sg.service_type,
@@ -658,7 +748,11 @@ func GetPendingPickups() ([]models.PendingGuide, error) {
 		LEFT JOIN cities dc ON sg.destination_city_id = dc.id
 		LEFT JOIN guide_parties sender ON sg.guide_id = sender.guide_id AND sender.party_role = 'SENDER'
 		WHERE sg.current_status = 'CREATED'
-		AND UPPER(oc.name) = 'BOGOTÁ D.C.'
+		AND EXISTS (
+			SELECT 1 FROM hubs h
+			WHERE h.active = TRUE %s
+			AND %s
+		)
 		AND NOT EXISTS (
 			SELECT 1 FROM delivery_assignments da
 			WHERE da.guide_id = sg.guide_id
@@ -666,9 +760,9 @@ func GetPendingPickups() ([]models.PendingGuide, error) {
 			AND da.status IN ('PENDING', 'IN_PROGRESS')
 		)
 		ORDER BY sg.created_at ASC
-	`
+	`, hubFilter, hubCoversCity("oc"))
 
-	rows, err := Db.Query(query)
+	rows, err := Db.Query(query, args...)
 	if err != nil {
 		fmt.Printf("GetPendingPickups - Error en query: %v\n", err)
 		return guides, err
@@ -715,9 +809,14 @@ func GetPendingPickups() ([]models.PendingGuide, error) {
 	return guides, nil
 }
 
-// GetPendingDeliveries obtiene guías pendientes de entregar (destino Bogotá, en bodega)
-func GetPendingDeliveries() ([]models.PendingGuide, error) {
-	fmt.Println("GetPendingDeliveries - Buscando guías con estado IN_WAREHOUSE y destino BOGOTÁ D.C.")
+// GetPendingDeliveries obtiene guías pendientes de entregar cuyo destino
+// cubre un hub del alcance: en bodega sin asignación activa, y reintentos de
+// entregas fallidas mientras no se haya creado la devolución al remitente.
+// Las guías en bodega deben estar ya en el hub: origen en el mismo hub,
+// traslado completado a una bodega del hub o manifiesto recibido. Incluye
+// los bins donde está ubicada cada guía.
+func GetPendingDeliveries(scope models.HubScope) ([]models.PendingGuide, error) {
+	fmt.Println("GetPendingDeliveries - Buscando guías IN_WAREHOUSE / DELIVERY_FAILED con destino en el hub")
 
 	var guides []models.PendingGuide
 
@@ -725,9 +824,10 @@ func GetPendingDeliveries() ([]models.PendingGuide, error) {
 	if err != nil {
 		return guides, err
 	}
-	defer Db.Close()
 
-	query := `
+	hubFilter, args := hubCondition(scope)
+
+	query := fmt.Sprintf(`
 		SELECT
 			sg.guide_id,
 			sg.service_type,
@@ -737,25 +837,222 @@ func GetPendingDeliveries() ([]models.PendingGuide, error) {
 			receiver.full_name AS contact_name,
 			receiver.address AS contact_address,
 			receiver.phone AS contact_phone,
-			sg.created_at
+			sg.created_at,
+			(SELECT COUNT(*) FROM delivery_attempts att WHERE att.guide_id = sg.guide_id) AS attempt_count,
+			retry.assignment_id,
+			retry.delivery_user_id,
+			retry.scheduled_date,
+			(SELECT GROUP_CONCAT(DISTINCT wb.code ORDER BY wb.code SEPARATOR ',')
+			 FROM warehouse_stock ws
+			 JOIN warehouse_bins wb ON wb.bin_id = ws.bin_id
+			 WHERE ws.guide_id = sg.guide_id) AS bins
+		FROM shipping_guides sg
+		LEFT JOIN cities oc ON sg.origin_city_id = oc.id
+		LEFT JOIN cities dc ON sg.destination_city_id = dc.id
+		LEFT JOIN guide_parties receiver ON sg.guide_id = receiver.guide_id AND receiver.party_role = 'RECEIVER'
+		LEFT JOIN delivery_assignments retry ON retry.guide_id = sg.guide_id
+			AND retry.assignment_type = 'DELIVERY'
+			AND retry.status = 'PENDING'
+			AND sg.current_status = 'DELIVERY_FAILED'
+		WHERE EXISTS (
+			SELECT 1 FROM hubs h
+			WHERE h.active = TRUE %[1]s
+			AND %[2]s
+			AND (sg.current_status = 'DELIVERY_FAILED'
+				OR %[3]s
+				OR EXISTS (
+					SELECT 1 FROM delivery_assignments t
+					JOIN warehouses tw ON tw.warehouse_id = t.destination_warehouse_id
+					JOIN cities twc ON twc.id = tw.city_id
+					WHERE t.guide_id = sg.guide_id
+					AND t.assignment_type = 'TRANSFER'
+					AND t.status = 'COMPLETED'
+					AND %[4]s
+				)
+				OR EXISTS (
+					SELECT 1 FROM manifest_guides mg
+					WHERE mg.guide_id = sg.guide_id
+					AND mg.status IN ('RECEIVED', 'PARTIAL')
+				))
+		)
+		AND (
+			(sg.current_status = 'IN_WAREHOUSE'
+				AND NOT EXISTS (
+					SELECT 1 FROM delivery_assignments da
+					WHERE da.guide_id = sg.guide_id
+					AND da.assignment_type = 'DELIVERY'
+					AND da.status IN ('PENDING', 'IN_PROGRESS')
+				))
+			OR
+			(sg.current_status = 'DELIVERY_FAILED'
+				AND NOT EXISTS (
+					SELECT 1 FROM delivery_assignments da
+					WHERE da.guide_id = sg.guide_id
+					AND da.assignment_type = 'RETURN'
+					AND da.status IN ('PENDING', 'IN_PROGRESS', 'COMPLETED')
+				))
+		)
+		ORDER BY sg.created_at ASC
+	`, hubFilter, hubCoversCity("dc"), hubCoversCity("oc"), hubCoversCity("twc"))
+
+	rows, err := Db.Query(query, args...)
+	if err != nil {
+		fmt.Printf("GetPendingDeliveries - Error en query: %v\n", err)
+		return guides, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var g models.PendingGuide
+		var contactName, contactAddr, contactPhone sql.NullString
+		var createdAt time.Time
+		var retryID sql.NullInt64
+		var retryUser sql.NullString
+		var scheduledDate sql.NullTime
+		var bins sql.NullString
+
+		err := rows.Scan(
+			&g.GuideID,
+			&g.ServiceType,
+			&g.CurrentStatus,
+			&g.OriginCityName,
+			&g.DestinationCityName,
+			&contactName,
+			&contactAddr,
+			&contactPhone,
+			&createdAt,
+			&g.AttemptCount,
+			&retryID,
+			&retryUser,
+			&scheduledDate,
+			&bins,
+		)
+		if err != nil {
+			fmt.Printf("GetPendingDeliveries - Error en scan: %v\n", err)
+			return guides, err
+		}
+
+		if contactName.Valid {
+			g.ContactName = contactName.String
+		}
+		if contactAddr.Valid {
+			g.ContactAddress = contactAddr.String
+		}
+		if contactPhone.Valid {
+			g.ContactPhone = contactPhone.String
+		}
+		g.CreatedAt = createdAt.Format(time.RFC3339)
+		g.AssignmentType = models.AssignmentDelivery
+
+		// Reintento de una entrega fallida
+		g.IsReattempt = g.CurrentStatus == string(models.StatusDeliveryFailed)
+		if retryID.Valid {
+			g.ReattemptAssignmentID = retryID.Int64
+		}
+		if retryUser.Valid {
+			g.ReattemptDeliveryUserID = retryUser.String
+		}
+		if scheduledDate.Valid {
+			g.ScheduledDate = scheduledDate.Time.Format("2006-01-02")
+		}
+
+		// Bins de bodega para que el entregador recoja más rápido
+		if bins.Valid && bins.String != "" {
+			g.Bins = strings.Split(bins.String, ",")
+		}
+
+		guides = append(guides, g)
+	}
+
+	fmt.Printf("GetPendingDeliveries - Guías encontradas: %d\n", len(guides))
+	return guides, nil
+}
+
+// GetPendingTransfers obtiene guías pendientes de traslado a otra ciudad: en
+// bodega de un hub del alcance (la de sus bins o de su último traslado; si no
+// hay, la ciudad de origen) cuyo destino no cubre ese hub, sin traslado
+// activo, sin manifiesto y sin haber llegado ya a una bodega de su ciudad de
+// destino. Incluye los bins y la bodega donde está cada guía.
+func GetPendingTransfers(scope models.HubScope) ([]models.PendingGuide, error) {
+	fmt.Println("GetPendingTransfers - Buscando guías IN_WAREHOUSE con destino fuera del hub")
+
+	var guides []models.PendingGuide
+
+	err := DbConnect()
+	if err != nil {
+		return guides, err
+	}
+
+	hubFilter, args := hubCondition(scope)
+
+	query := fmt.Sprintf(`
+		SELECT
+			sg.guide_id,
+			sg.service_type,
+			sg.current_status,
+			oc.name AS origin_city_name,
+			dc.name AS destination_city_name,
+			receiver.full_name AS contact_name,
+			receiver.address AS contact_address,
+			receiver.phone AS contact_phone,
+			sg.created_at,
+			(SELECT GROUP_CONCAT(DISTINCT wb.code ORDER BY wb.code SEPARATOR ',')
+			 FROM warehouse_stock ws
+			 JOIN warehouse_bins wb ON wb.bin_id = ws.bin_id
+			 WHERE ws.guide_id = sg.guide_id) AS bins,
+			cw.warehouse_id
 		FROM shipping_guides sg
 		LEFT JOIN cities oc ON sg.origin_city_id = oc.id
 		LEFT JOIN cities dc ON sg.destination_city_id = dc.id
 		LEFT JOIN guide_parties receiver ON sg.guide_id = receiver.guide_id AND receiver.party_role = 'RECEIVER'
+		LEFT JOIN warehouses cw ON cw.warehouse_id = COALESCE(
+			(SELECT wb.warehouse_id
+			 FROM warehouse_stock ws
+			 JOIN warehouse_bins wb ON wb.bin_id = ws.bin_id
+			 WHERE ws.guide_id = sg.guide_id
+			 LIMIT 1),
+			(SELECT t.destination_warehouse_id
+			 FROM delivery_assignments t
+			 WHERE t.guide_id = sg.guide_id
+			 AND t.assignment_type = 'TRANSFER'
+			 AND t.status = 'COMPLETED'
+			 ORDER BY t.completed_at DESC
+			 LIMIT 1)
+			)
+		LEFT JOIN cities lc ON lc.id = COALESCE(cw.city_id, sg.origin_city_id)
 		WHERE sg.current_status = 'IN_WAREHOUSE'
-		AND UPPER(dc.name) = 'BOGOTÁ D.C.'
+		AND EXISTS (
+			SELECT 1 FROM hubs h
+			WHERE h.active = TRUE %s
+			AND %s
+			AND NOT %s
+		)
 		AND NOT EXISTS (
 			SELECT 1 FROM delivery_assignments da
 			WHERE da.guide_id = sg.guide_id
-			AND da.assignment_type = 'DELIVERY'
+			AND da.assignment_type = 'TRANSFER'
 			AND da.status IN ('PENDING', 'IN_PROGRESS')
 		)
+		AND NOT EXISTS (
+			SELECT 1 FROM delivery_assignments da
+			JOIN warehouses w ON w.warehouse_id = da.destination_warehouse_id
+			WHERE da.guide_id = sg.guide_id
+			AND da.assignment_type = 'TRANSFER'
+			AND da.status = 'COMPLETED'
+			AND w.city_id = sg.destination_city_id
+		)
+		AND NOT EXISTS (
+			SELECT 1 FROM manifest_guides mg
+			JOIN manifests m ON m.manifest_id = mg.manifest_id
+			WHERE mg.guide_id = sg.guide_id
+			AND (m.status IN ('OPEN', 'CLOSED') OR mg.status IN ('RECEIVED', 'PARTIAL'))
+		)
 		ORDER BY sg.created_at ASC
-	`
+	`, hubFilter, hubCoversCity("lc"), hubCoversCity("dc"))
 
-	rows, err := Db.Query(query)
+	rows, err := Db.Query(query, args...)
 	if err != nil {
-		fmt.Printf("GetPendingDeliveries - Error en query: %v\n", err)
+		fmt.Printf("GetPendingTransfers - Error en query: %v\n", err)
 		return guides, err
 	}
 	defer rows.Close()
@@ -764,6 +1061,8 @@ func GetPendingDeliveries() ([]models.PendingGuide, error) {
 		var g models.PendingGuide
 		var contactName, contactAddr, contactPhone sql.NullString
 		var createdAt time.Time
+		var bins sql.NullString
+		var warehouseID sql.NullInt64
 
 		err := rows.Scan(
 			&g.GuideID,
@@ -775,9 +1074,11 @@ func GetPendingDeliveries() ([]models.PendingGuide, error) {
 			&contactAddr,
 			&contactPhone,
 			&createdAt,
+			&bins,
+			&warehouseID,
 		)
 		if err != nil {
-			fmt.Printf("GetPendingDeliveries - Error en scan: %v\n", err)
+			fmt.Printf("GetPendingTransfers - Error en scan: %v\n", err)
 			return guides, err
 		}
 
@@ -791,12 +1092,19 @@ func GetPendingDeliveries() ([]models.PendingGuide, error) {
 			g.ContactPhone = contactPhone.String
 		}
 		g.CreatedAt = createdAt.Format(time.RFC3339)
-		g.AssignmentType = models.AssignmentDelivery
+		g.AssignmentType = models.AssignmentTransfer
+
+		if bins.Valid && bins.String != "" {
+			g.Bins = strings.Split(bins.String, ",")
+		}
+		if warehouseID.Valid {
+			g.WarehouseID = warehouseID.Int64
+		}
 
 		guides = append(guides, g)
 	}
 
-	fmt.Printf("GetPendingDeliveries - Guías encontradas: %d\n", len(guides))
+	fmt.Printf("GetPendingTransfers - Guías encontradas: %d\n", len(guides))
 	return guides, nil
 }
 
@@ -810,7 +1118,6 @@ func GetMyAssignments(deliveryUserID string) (models.MyAssignmentsResponse, erro
 	if err != nil {
 		return response, err
 	}
-	defer Db.Close()
 
 	// Obtener asignaciones de PICKUP
 	pickupFilters := models.AssignmentFilters{
@@ -819,8 +1126,6 @@ func GetMyAssignments(deliveryUserID string) (models.MyAssignmentsResponse, erro
 		Limit:          100,
 		Offset:         0,
 	}
-	// Reconectar para evitar problemas de conexión cerrada
-	Db.Close()
 	pickups, _, err := GetAssignmentsByFilters(pickupFilters)
 	if err != nil {
 		return response, err
@@ -840,12 +1145,37 @@ func GetMyAssignments(deliveryUserID string) (models.MyAssignmentsResponse, erro
 	}
 	response.Deliveries = deliveries
 
+	// Obtener devoluciones al remitente
+	returnFilters := models.AssignmentFilters{
+		DeliveryUserID: deliveryUserID,
+		AssignmentType: models.AssignmentReturn,
+		Limit:          100,
+		Offset:         0,
+	}
+	returns, _, err := GetAssignmentsByFilters(returnFilters)
+	if err != nil {
+		return response, err
+	}
+	response.Returns = returns
+
+	// Obtener traslados entre bodegas
+	transferFilters := models.AssignmentFilters{
+		DeliveryUserID: deliveryUserID,
+		AssignmentType: models.AssignmentTransfer,
+		Limit:          100,
+		Offset:         0,
+	}
+	transfers, _, err := GetAssignmentsByFilters(transferFilters)
+	if err != nil {
+		return response, err
+	}
+	response.Transfers = transfers
+
 	// Calcular estadísticas
 	err = DbConnect()
 	if err != nil {
 		return response, err
 	}
-	defer Db.Close()
 
 	// Stats
 	statsQuery := `
@@ -854,6 +1184,8 @@ func GetMyAssignments(deliveryUserID string) (models.MyAssignmentsResponse, erro
 			SUM(CASE WHEN assignment_type = 'DELIVERY' AND status = 'PENDING' THEN 1 ELSE 0 END) as pending_deliveries,
 			SUM(CASE WHEN assignment_type = 'PICKUP' AND status = 'IN_PROGRESS' THEN 1 ELSE 0 END) as in_progress_pickups,
 			SUM(CASE WHEN assignment_type = 'DELIVERY' AND status = 'IN_PROGRESS' THEN 1 ELSE 0 END) as in_progress_deliveries,
+			SUM(CASE WHEN assignment_type = 'TRANSFER' AND status = 'PENDING' THEN 1 ELSE 0 END) as pending_transfers,
+			SUM(CASE WHEN assignment_type = 'TRANSFER' AND status = 'IN_PROGRESS' THEN 1 ELSE 0 END) as in_progress_transfers,
 			SUM(CASE WHEN status = 'COMPLETED' AND DATE(completed_at) = CURDATE() THEN 1 ELSE 0 END) as completed_today,
 			SUM(CASE WHEN status = 'COMPLETED' AND completed_at >= DATE_SUB(CURDATE(), INTERVAL 7 DAY) THEN 1 ELSE 0 END) as completed_this_week
 		FROM delivery_assignments
@@ -865,6 +1197,8 @@ func GetMyAssignments(deliveryUserID string) (models.MyAssignmentsResponse, erro
 		&response.Stats.PendingDeliveries,
 		&response.Stats.InProgressPickups,
 		&response.Stats.InProgressDeliveries,
+		&response.Stats.PendingTransfers,
+		&response.Stats.InProgressTransfers,
 		&response.Stats.CompletedToday,
 		&response.Stats.CompletedThisWeek,
 	)
@@ -876,8 +1210,9 @@ func GetMyAssignments(deliveryUserID string) (models.MyAssignmentsResponse, erro
 	return response, nil
 }
 
-// GetAssignmentStats obtiene estadísticas generales de asignaciones
-func GetAssignmentStats() (models.AssignmentStatsResponse, error) {
+// GetAssignmentStats obtiene estadísticas generales de asignaciones de los
+// repartidores del alcance
+func GetAssignmentStats(scope models.HubScope) (models.AssignmentStatsResponse, error) {
 	fmt.Println("GetAssignmentStats")
 
 	var stats models.AssignmentStatsResponse
@@ -887,67 +1222,76 @@ func GetAssignmentStats() (models.AssignmentStatsResponse, error) {
 	if err != nil {
 		return stats, err
 	}
-	defer Db.Close()
+
+	// Asignaciones de los repartidores del hub (alias da, u)
+	hubFilter, args := userHubCondition(scope, "u")
+	from := `
+		FROM delivery_assignments da
+		JOIN users u ON da.delivery_user_id = u.user_uuid
+		WHERE 1 = 1 ` + hubFilter
 
 	// Total asignaciones
-	err = Db.QueryRow(`SELECT COUNT(*) FROM delivery_assignments`).Scan(&stats.TotalAssignments)
+	err = Db.QueryRow(`SELECT COUNT(*)`+from, args...).Scan(&stats.TotalAssignments)
 	if err != nil {
 		return stats, err
 	}
 
 	// Pickups pendientes
-	err = Db.QueryRow(`
-		SELECT COUNT(*) FROM delivery_assignments
-		WHERE assignment_type = 'PICKUP' AND status = 'PENDING'
-	`).Scan(&stats.PendingPickups)
+	err = Db.QueryRow(`SELECT COUNT(*)`+from+`
+		AND da.assignment_type = 'PICKUP' AND da.status = 'PENDING'
+	`, args...).Scan(&stats.PendingPickups)
 	if err != nil {
 		return stats, err
 	}
 
 	// Deliveries pendientes
-	err = Db.QueryRow(`
-		SELECT COUNT(*) FROM delivery_assignments
-		WHERE assignment_type = 'DELIVERY' AND status = 'PENDING'
-	`).Scan(&stats.PendingDeliveries)
+	err = Db.QueryRow(`SELECT COUNT(*)`+from+`
+		AND da.assignment_type = 'DELIVERY' AND da.status = 'PENDING'
+	`, args...).Scan(&stats.PendingDeliveries)
 	if err != nil {
 		return stats, err
 	}
 
 	// Pickups en progreso
-	err = Db.QueryRow(`
-		SELECT COUNT(*) FROM delivery_assignments
-		WHERE assignment_type = 'PICKUP' AND status = 'IN_PROGRESS'
-	`).Scan(&stats.InProgressPickups)
+	err = Db.QueryRow(`SELECT COUNT(*)`+from+`
+		AND da.assignment_type = 'PICKUP' AND da.status = 'IN_PROGRESS'
+	`, args...).Scan(&stats.InProgressPickups)
 	if err != nil {
 		return stats, err
 	}
 
 	// Deliveries en progreso
+	err = Db.QueryRow(`SELECT COUNT(*)`+from+`
+		AND da.assignment_type = 'DELIVERY' AND da.status = 'IN_PROGRESS'
+	`, args...).Scan(&stats.InProgressDeliveries)
+	if err != nil {
+		return stats, err
+	}
+
+	// Traslados pendientes y en ruta
 	err = Db.QueryRow(`
-		SELECT COUNT(*) FROM delivery_assignments
-		WHERE assignment_type = 'DELIVERY' AND status = 'IN_PROGRESS'
-	`).Scan(&stats.InProgressDeliveries)
+		SELECT
+			COALESCE(SUM(CASE WHEN da.status = 'PENDING' THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN da.status = 'IN_PROGRESS' THEN 1 ELSE 0 END), 0)`+from+`
+		AND da.assignment_type = 'TRANSFER'
+	`, args...).Scan(&stats.PendingTransfers, &stats.InProgressTransfers)
 	if err != nil {
 		return stats, err
 	}
 
 	// Completadas hoy
-	err = Db.QueryRow(`
-		SELECT COUNT(*) FROM delivery_assignments
-		WHERE status = 'COMPLETED' AND DATE(completed_at) = CURDATE()
-	`).Scan(&stats.CompletedToday)
+	err = Db.QueryRow(`SELECT COUNT(*)`+from+`
+		AND da.status = 'COMPLETED' AND DATE(da.completed_at) = CURDATE()
+	`, args...).Scan(&stats.CompletedToday)
 	if err != nil {
 		return stats, err
 	}
 
 	// Por entregador
-	rows, err := Db.Query(`
-		SELECT u.full_name, COUNT(*) as cnt
-		FROM delivery_assignments da
-		JOIN users u ON da.delivery_user_id = u.user_uuid
-		WHERE da.status IN ('PENDING', 'IN_PROGRESS')
+	rows, err := Db.Query(`SELECT u.full_name, COUNT(*) as cnt`+from+`
+		AND da.status IN ('PENDING', 'IN_PROGRESS')
 		GROUP BY da.delivery_user_id, u.full_name
-	`)
+	`, args...)
 	if err != nil {
 		return stats, err
 	}
@@ -976,7 +1320,6 @@ func GetAssignmentHistory(assignmentID int64) ([]models.AssignmentHistory, error
 	if err != nil {
 		return history, err
 	}
-	defer Db.Close()
 
 	query := `
 		SELECT
@@ -1048,3 +1391,58 @@ func GetAssignmentHistory(assignmentID int64) ([]models.AssignmentHistory, error
 
 	return history, nil
 }
+
+// cancelOpenAssignments cancela, dentro de la transacción dada, las asignaciones
+// abiertas (PENDING / IN_PROGRESS) de un tipo para la guía y lo registra en el historial
+func cancelOpenAssignments(tx *sql.Tx, guideID int64, assignmentType models.AssignmentType, changedBy string, notes string) error {
+	rows, err := tx.Query(`
+		SELECT assignment_id, status FROM delivery_assignments
+		WHERE guide_id = ? AND assignment_type = ? AND status IN ('PENDING', 'IN_PROGRESS')
+		FOR UPDATE
+	`, guideID, assignmentType)
+	if err != nil {
+		return err
+	}
+
+	type openAssignment struct {
+		id     int64
+		status string
+	}
+	var open []openAssignment
+	for rows.Next() {
+		var a openAssignment
+		if err := rows.Scan(&a.id, &a.status); err != nil {
+			rows.Close()
+			return err
+		}
+		open = append(open, a)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, a := range open {
+		fmt.Printf("cancelOpenAssignments -> AssignmentID: %d, GuideID: %d\n", a.id, guideID)
+
+		_, err = tx.Exec(`
+			UPDATE delivery_assignments
+			SET status = 'CANCELLED', updated_at = NOW()
+			WHERE assignment_id = ?
+		`, a.id)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO assignment_history
+			(assignment_id, action, previous_status, new_status, changed_by, notes)
+			VALUES (?, 'CANCELLED', ?, 'CANCELLED', ?, ?)
+		`, a.id, a.status, changedBy, notes)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -29,7 +29,6 @@ func CreateCashClose(close *models.CashClose) (int64, error) {
 	if err != nil {
 		return 0, err
 	}
-	defer Db.Close()
 
 	query := `
 		INSERT INTO cash_closes (
@@ -38,8 +37,8 @@ func CreateCashClose(close *models.CashClose) (int64, error) {
 			total_cash, total_cod, total_credit,
 			total_freight, total_other, total_handling, total_discounts,
 			total_units, total_weight,
-			created_by
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			hub_id, created_by
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	result, err := Db.Exec(
@@ -49,7 +48,7 @@ func CreateCashClose(close *models.CashClose) (int64, error) {
 		close.TotalCash, close.TotalCOD, close.TotalCredit,
 		close.TotalFreight, close.TotalOther, close.TotalHandling, close.TotalDiscounts,
 		close.TotalUnits, close.TotalWeight,
-		close.CreatedBy,
+		close.HubID, close.CreatedBy,
 	)
 
 	if err != nil {
@@ -72,7 +71,6 @@ func CreateCashCloseDetail(detail *models.CashCloseDetail) error {
 	if err != nil {
 		return err
 	}
-	defer Db.Close()
 
 	query := `
 		INSERT INTO cash_close_details (
@@ -94,8 +92,8 @@ func CreateCashCloseDetail(detail *models.CashCloseDetail) error {
 	return err
 }
 
-// GetGuidesForCashClose gets guides for cash close
-func GetGuidesForCashClose(startDate, endDate time.Time) ([]models.CashCloseDetail, error) {
+// GetGuidesForCashClose gets guides for cash close (by origin hub when scoped)
+func GetGuidesForCashClose(startDate, endDate time.Time, scope models.HubScope) ([]models.CashCloseDetail, error) {
 	fmt.Printf("GetGuidesForCashClose -> StartDate: %s, EndDate: %s\n", startDate, endDate)
 
 	var details []models.CashCloseDetail
@@ -104,16 +102,20 @@ func GetGuidesForCashClose(startDate, endDate time.Time) ([]models.CashCloseDeta
 	if err != nil {
 		return details, err
 	}
-	defer Db.Close()
 
+	// Solo entran guías entregadas. Las anuladas (CANCELLED) y las que siguen
+	// en excepción (DELIVERY_FAILED, ON_HOLD...) quedan fuera del cierre.
+	// Unidades y peso se suman de las piezas; las guías anteriores a las
+	// piezas usan el paquete.
+	hubFilter, hubArgs := guideHubCondition(scope, guideOriginCity)
 	query := `
 		SELECT 
 			sg.guide_id,
 			DATE(sg.created_at) as date,
 			sender.full_name as sender,
 			dest_city.name as destination,
-			COALESCE(p.pieces, 1) as units,
-			COALESCE(p.weight_kg, 0) as weight,
+			COALESCE(pc.units, p.pieces, 1) as units,
+			COALESCE(pc.weight, p.weight_kg, 0) as weight,
 			sg.price as freight,
 			0 as other,
 			0 as handling,
@@ -124,12 +126,19 @@ func GetGuidesForCashClose(startDate, endDate time.Time) ([]models.CashCloseDeta
 		LEFT JOIN guide_parties sender ON sg.guide_id = sender.guide_id AND sender.party_role = 'SENDER'
 		LEFT JOIN cities dest_city ON sg.destination_city_id = dest_city.id
 		LEFT JOIN packages p ON sg.guide_id = p.guide_id
+		LEFT JOIN (
+			SELECT guide_id, COUNT(*) AS units, SUM(weight_kg) AS weight
+			FROM guide_pieces
+			GROUP BY guide_id
+		) pc ON sg.guide_id = pc.guide_id
 		WHERE DATE(sg.created_at) BETWEEN ? AND ?
 		AND sg.current_status = 'DELIVERED'
+		` + hubFilter + `
 		ORDER BY sg.created_at ASC
 	`
 
-	rows, err := Db.Query(query, startDate, endDate)
+	args := append([]interface{}{startDate, endDate}, hubArgs...)
+	rows, err := Db.Query(query, args...)
 	if err != nil {
 		return details, err
 	}
@@ -174,7 +183,6 @@ func UpdateCashClosePDF(closeID int64, pdfURL, pdfS3Key string) error {
 	if err != nil {
 		return err
 	}
-	defer Db.Close()
 
 	query := `
 		UPDATE cash_closes
@@ -196,40 +204,60 @@ func GetCashCloseByID(closeID int64) (models.CashClose, error) {
 	if err != nil {
 		return close, err
 	}
-	defer Db.Close()
 
 	query := `
-		SELECT 
-			close_id, period_type, start_date, end_date,
-			total_guides, total_amount,
-			total_cash, total_cod, total_credit,
-			total_freight, total_other, total_handling, total_discounts,
-			total_units, total_weight,
-			COALESCE(pdf_url, '') as pdf_url,
-			COALESCE(pdf_s3_key, '') as pdf_s3_key,
-			created_by, created_at
-		FROM cash_closes
-		WHERE close_id = ?
+		SELECT` + cashCloseColumns + `
+		WHERE cc.close_id = ?
 	`
 
-	row := Db.QueryRow(query, closeID)
-	err = row.Scan(
+	close, err = scanCashClose(Db.QueryRow(query, closeID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return close, fmt.Errorf("cash close not found")
+		}
+		return close, err
+	}
+
+	return close, nil
+}
+
+// cashCloseColumns columns of cash_closes with the hub code (aliases cc, h)
+const cashCloseColumns = `
+			cc.close_id, cc.period_type, cc.start_date, cc.end_date,
+			cc.total_guides, cc.total_amount,
+			cc.total_cash, cc.total_cod, cc.total_credit,
+			cc.total_freight, cc.total_other, cc.total_handling, cc.total_discounts,
+			cc.total_units, cc.total_weight,
+			COALESCE(cc.pdf_url, '') as pdf_url,
+			COALESCE(cc.pdf_s3_key, '') as pdf_s3_key,
+			cc.hub_id, h.code AS hub_code,
+			cc.created_by, cc.created_at
+		FROM cash_closes cc
+		LEFT JOIN hubs h ON cc.hub_id = h.hub_id`
+
+func scanCashClose(row rowScanner) (models.CashClose, error) {
+	var close models.CashClose
+	var hubID sql.NullInt64
+	var hubCode sql.NullString
+
+	err := row.Scan(
 		&close.CloseID, &close.PeriodType, &close.StartDate, &close.EndDate,
 		&close.TotalGuides, &close.TotalAmount,
 		&close.TotalCash, &close.TotalCOD, &close.TotalCredit,
 		&close.TotalFreight, &close.TotalOther, &close.TotalHandling, &close.TotalDiscounts,
 		&close.TotalUnits, &close.TotalWeight,
 		&close.PDFURL, &close.PDFS3Key,
+		&hubID, &hubCode,
 		&close.CreatedBy, &close.CreatedAt,
 	)
-
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return close, fmt.Errorf("cash close not found")
-		}
 		return close, err
 	}
 
+	if hubID.Valid {
+		close.HubID = &hubID.Int64
+		close.HubCode = hubCode.String
+	}
 	return close, nil
 }
 
@@ -243,7 +271,6 @@ func GetCashCloseDetails(closeID int64) ([]models.CashCloseDetail, error) {
 	if err != nil {
 		return details, err
 	}
-	defer Db.Close()
 
 	query := `
 		SELECT 
@@ -281,8 +308,8 @@ func GetCashCloseDetails(closeID int64) ([]models.CashCloseDetail, error) {
 	return details, nil
 }
 
-// GetAllCashCloses gets all cash closes
-func GetAllCashCloses(limit, offset int) ([]models.CashClose, int, error) {
+// GetAllCashCloses gets all cash closes (only the hub's closes when scoped)
+func GetAllCashCloses(limit, offset int, scope models.HubScope) ([]models.CashClose, int, error) {
 	fmt.Printf("GetAllCashCloses -> Limit: %d, Offset: %d\n", limit, offset)
 
 	var closes []models.CashClose
@@ -292,48 +319,38 @@ func GetAllCashCloses(limit, offset int) ([]models.CashClose, int, error) {
 	if err != nil {
 		return closes, 0, err
 	}
-	defer Db.Close()
+
+	where := ""
+	var args []interface{}
+	if !scope.Consolidated() {
+		where = "WHERE cc.hub_id = ?"
+		args = append(args, *scope.HubID)
+	}
 
 	// Get total
-	countQuery := `SELECT COUNT(*) FROM cash_closes`
-	err = Db.QueryRow(countQuery).Scan(&total)
+	countQuery := `SELECT COUNT(*) FROM cash_closes cc ` + where
+	err = Db.QueryRow(countQuery, args...).Scan(&total)
 	if err != nil {
 		return closes, 0, err
 	}
 
 	// Get paginated data
 	query := `
-		SELECT 
-			close_id, period_type, start_date, end_date,
-			total_guides, total_amount,
-			total_cash, total_cod, total_credit,
-			total_freight, total_other, total_handling, total_discounts,
-			total_units, total_weight,
-			COALESCE(pdf_url, '') as pdf_url,
-			COALESCE(pdf_s3_key, '') as pdf_s3_key,
-			created_by, created_at
-		FROM cash_closes
-		ORDER BY created_at DESC
+		SELECT` + cashCloseColumns + `
+		` + where + `
+		ORDER BY cc.created_at DESC
 		LIMIT ? OFFSET ?
 	`
 
-	rows, err := Db.Query(query, limit, offset)
+	args = append(args, limit, offset)
+	rows, err := Db.Query(query, args...)
 	if err != nil {
 		return closes, 0, err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var close models.CashClose
-		err := rows.Scan(
-			&close.CloseID, &close.PeriodType, &close.StartDate, &close.EndDate,
-			&close.TotalGuides, &close.TotalAmount,
-			&close.TotalCash, &close.TotalCOD, &close.TotalCredit,
-			&close.TotalFreight, &close.TotalOther, &close.TotalHandling, &close.TotalDiscounts,
-			&close.TotalUnits, &close.TotalWeight,
-			&close.PDFURL, &close.PDFS3Key,
-			&close.CreatedBy, &close.CreatedAt,
-		)
+		close, err := scanCashClose(rows)
 		if err != nil {
 			return closes, 0, err
 		}
@@ -343,8 +360,8 @@ func GetAllCashCloses(limit, offset int) ([]models.CashClose, int, error) {
 	return closes, total, nil
 }
 
-// GetCashCloseStats gets close statistics
-func GetCashCloseStats() (models.CashCloseStatsResponse, error) {
+// GetCashCloseStats gets close statistics (by origin hub when scoped)
+func GetCashCloseStats(scope models.HubScope) (models.CashCloseStatsResponse, error) {
 	fmt.Println("Bd: GetCashCloseStats")
 
 	var stats models.CashCloseStatsResponse
@@ -354,7 +371,6 @@ func GetCashCloseStats() (models.CashCloseStatsResponse, error) {
 	if err != nil {
 		return stats, err
 	}
-	defer Db.Close()
 
 	// Usar zona horaria de Colombia
 	now := time.Now().In(colombiaLoc)
@@ -369,6 +385,8 @@ func GetCashCloseStats() (models.CashCloseStatsResponse, error) {
 	}
 	weekStart := time.Date(now.Year(), now.Month(), now.Day()-weekday+1, 0, 0, 0, 0, colombiaLoc).Format("2006-01-02")
 
+	hubFilter, hubArgs := guideHubCondition(scope, guideOriginCity)
+
 	// ===================================
 	// SUMAR DESDE LAS GUÍAS, NO LOS CIERRES
 	// ===================================
@@ -376,10 +394,10 @@ func GetCashCloseStats() (models.CashCloseStatsResponse, error) {
 	// Today's total - Sumar guías DELIVERED del día de hoy
 	err = Db.QueryRow(`
 		SELECT COALESCE(SUM(price), 0)
-		FROM shipping_guides
+		FROM shipping_guides sg
 		WHERE DATE(created_at) = ?
 		AND current_status = 'DELIVERED'
-	`, today).Scan(&stats.TodayTotal)
+	`+hubFilter, append([]interface{}{today}, hubArgs...)...).Scan(&stats.TodayTotal)
 
 	if err != nil && err != sql.ErrNoRows {
 		return stats, err
@@ -388,10 +406,10 @@ func GetCashCloseStats() (models.CashCloseStatsResponse, error) {
 	// Week's total - Sumar guías DELIVERED de esta semana
 	err = Db.QueryRow(`
 		SELECT COALESCE(SUM(price), 0)
-		FROM shipping_guides
+		FROM shipping_guides sg
 		WHERE DATE(created_at) >= ?
 		AND current_status = 'DELIVERED'
-	`, weekStart).Scan(&stats.WeekTotal)
+	`+hubFilter, append([]interface{}{weekStart}, hubArgs...)...).Scan(&stats.WeekTotal)
 
 	if err != nil && err != sql.ErrNoRows {
 		return stats, err
@@ -400,10 +418,10 @@ func GetCashCloseStats() (models.CashCloseStatsResponse, error) {
 	// Month's total - Sumar guías DELIVERED de este mes
 	err = Db.QueryRow(`
 		SELECT COALESCE(SUM(price), 0)
-		FROM shipping_guides
+		FROM shipping_guides sg
 		WHERE DATE(created_at) >= ?
 		AND current_status = 'DELIVERED'
-	`, monthStart).Scan(&stats.MonthTotal)
+	`+hubFilter, append([]interface{}{monthStart}, hubArgs...)...).Scan(&stats.MonthTotal)
 
 	if err != nil && err != sql.ErrNoRows {
 		return stats, err
@@ -412,10 +430,10 @@ func GetCashCloseStats() (models.CashCloseStatsResponse, error) {
 	// Year's total - Sumar guías DELIVERED de este año
 	err = Db.QueryRow(`
 		SELECT COALESCE(SUM(price), 0)
-		FROM shipping_guides
+		FROM shipping_guides sg
 		WHERE DATE(created_at) >= ?
 		AND current_status = 'DELIVERED'
-	`, yearStart).Scan(&stats.YearTotal)
+	`+hubFilter, append([]interface{}{yearStart}, hubArgs...)...).Scan(&stats.YearTotal)
 
 	if err != nil && err != sql.ErrNoRows {
 		return stats, err
@@ -426,11 +444,12 @@ func GetCashCloseStats() (models.CashCloseStatsResponse, error) {
 		SELECT 
 			payment_method,
 			COALESCE(SUM(price), 0) as total
-		FROM shipping_guides
+		FROM shipping_guides sg
 		WHERE DATE(created_at) >= ?
 		AND current_status = 'DELIVERED'
+		`+hubFilter+`
 		GROUP BY payment_method
-	`, monthStart)
+	`, append([]interface{}{monthStart}, hubArgs...)...)
 
 	if err != nil && err != sql.ErrNoRows {
 		return stats, err
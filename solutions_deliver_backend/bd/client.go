@@ -22,7 +22,6 @@ func GetUserProfile(userUUID string) (models.ClientProfile, error) {
 	if err != nil {
 		return profile, err
 	}
-	defer Db.Close()
 
 	query := `
 		SELECT 
@@ -66,7 +65,6 @@ func UpdateUserProfile(userUUID string, updateData models.ClientProfileUpdate) (
 	if err != nil {
 		return models.ClientProfile{}, err
 	}
-	defer Db.Close()
 
 	// Construir UPDATE dinámico
 	var setParts []string
@@ -124,12 +122,12 @@ func GetClientActiveGuides(userUUID string) ([]models.ShippingGuide, error) {
 	if err != nil {
 		return guides, err
 	}
-	defer Db.Close()
 
 	// Consulta: guías donde el usuario es remitente O destinatario Y NO están entregadas
 	query := `
 		SELECT DISTINCT
 			sg.guide_id,
+			sg.guide_number,
 			sg.service_type,
 			sg.payment_method,
 			sg.declared_value,
@@ -178,7 +176,7 @@ func GetClientActiveGuides(userUUID string) ([]models.ShippingGuide, error) {
 				SELECT number_document FROM users WHERE user_uuid = ?
 			)
 		)
-		AND sg.current_status != 'DELIVERED'
+		AND sg.current_status NOT IN ('DELIVERED', 'RETURNED_TO_SENDER', 'CANCELLED')
 		ORDER BY sg.created_at DESC
 	`
 
@@ -203,9 +201,11 @@ func GetClientActiveGuides(userUUID string) ([]models.ShippingGuide, error) {
 		var pkgID sql.NullInt64
 		var pkgWeight sql.NullFloat64
 		var pkgPieces sql.NullInt64
+		var guideNumber sql.NullString
 
 		err := rows.Scan(
 			&guide.GuideID,
+			&guideNumber,
 			&guide.ServiceType,
 			&guide.PaymentMethod,
 			&guide.DeclaredValue,
@@ -247,6 +247,7 @@ func GetClientActiveGuides(userUUID string) ([]models.ShippingGuide, error) {
 		if err != nil {
 			return guides, err
 		}
+		guide.GuideNumber = guideNumber.String
 
 		// Asignar sender si existe
 		if senderPartyID.Valid {
@@ -303,7 +304,6 @@ func GetClientGuideHistory(filters models.ClientGuideFilters) ([]models.Shipping
 	if err != nil {
 		return guides, err
 	}
-	defer Db.Close()
 
 	// Construcción dinámica de WHERE
 	var conditions []string
@@ -344,10 +344,11 @@ func GetClientGuideHistory(filters models.ClientGuideFilters) ([]models.Shipping
 		searchPattern := "%" + filters.SearchTerm + "%"
 		conditions = append(conditions, `(
 			CAST(sg.guide_id AS CHAR) LIKE ? OR
+			sg.guide_number LIKE ? OR
 			oc.name LIKE ? OR
 			dc.name LIKE ?
 		)`)
-		args = append(args, searchPattern, searchPattern, searchPattern)
+		args = append(args, searchPattern, "%"+models.NormalizeGuideNumber(filters.SearchTerm)+"%", searchPattern, searchPattern)
 	}
 
 	whereClause := "WHERE " + strings.Join(conditions, " AND ")
@@ -355,6 +356,7 @@ func GetClientGuideHistory(filters models.ClientGuideFilters) ([]models.Shipping
 	query := fmt.Sprintf(`
 		SELECT DISTINCT
 			sg.guide_id,
+			sg.guide_number,
 			sg.service_type,
 			sg.payment_method,
 			sg.declared_value,
@@ -422,9 +424,11 @@ func GetClientGuideHistory(filters models.ClientGuideFilters) ([]models.Shipping
 		var pkgID sql.NullInt64
 		var pkgWeight sql.NullFloat64
 		var pkgPieces sql.NullInt64
+		var guideNumber sql.NullString
 
 		err := rows.Scan(
 			&guide.GuideID,
+			&guideNumber,
 			&guide.ServiceType,
 			&guide.PaymentMethod,
 			&guide.DeclaredValue,
@@ -449,6 +453,7 @@ func GetClientGuideHistory(filters models.ClientGuideFilters) ([]models.Shipping
 		if err != nil {
 			return guides, err
 		}
+		guide.GuideNumber = guideNumber.String
 
 		// Asignar relaciones
 		if senderPartyID.Valid {
@@ -507,7 +512,6 @@ func GetClientStats(userUUID string) (models.ClientStats, error) {
 	if err != nil {
 		return stats, err
 	}
-	defer Db.Close()
 
 	// Total de guías del cliente
 	totalQuery := `
@@ -546,7 +550,7 @@ func GetClientStats(userUUID string) (models.ClientStats, error) {
 				SELECT number_document FROM users WHERE user_uuid = ?
 			)
 		)
-		AND sg.current_status != 'DELIVERED'
+		AND sg.current_status NOT IN ('DELIVERED', 'RETURNED_TO_SENDER', 'CANCELLED')
 	`
 
 	err = Db.QueryRow(activeQuery, userUUID, userUUID, userUUID).Scan(&stats.ActiveGuides)
@@ -604,7 +608,6 @@ func ValidateGuideAccess(guideID int64, userUUID string) (bool, error) {
 	if err != nil {
 		return false, err
 	}
-	defer Db.Close()
 
 	// El usuario puede ver la guía si:
 	// 1. Es el creador
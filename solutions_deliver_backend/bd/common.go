@@ -4,6 +4,9 @@ import (
 	"database/sql"
 	"fmt"
 	"os"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
 	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/secretm"
@@ -11,36 +14,124 @@ import (
 )
 
 var SecretModel models.SecretRDSJson
-var err error
+
+// Db es el pool de conexiones compartido por todo el paquete.
+// Se inicializa una sola vez por contenedor Lambda (o por proceso en el
+// servidor local) y no debe cerrarse después de cada consulta.
 var Db *sql.DB
 
+var (
+	secretMu     sync.Mutex
+	secretLoaded bool
+	dbMu         sync.Mutex
+)
+
+// ReadSecret lee las credenciales de Secrets Manager solo la primera vez;
+// las invocaciones siguientes del mismo contenedor reutilizan el valor en memoria.
 func ReadSecret() error {
-	SecretModel, err = secretm.GetSecret(os.Getenv("SecretName"))
-	return err
+	secretMu.Lock()
+	defer secretMu.Unlock()
+
+	if secretLoaded {
+		return nil
+	}
+
+	secret, err := secretm.GetSecret(os.Getenv("SecretName"))
+	if err != nil {
+		return err
+	}
+
+	SecretModel = secret
+	secretLoaded = true
+	return nil
+}
+
+// ReadSecretFromEnv carga las credenciales de la BD desde variables de entorno
+// (DB_HOST, DB_PORT, DB_USER, DB_PASSWORD, DB_NAME) en lugar de Secrets Manager.
+// Se usa para ejecutar el API localmente contra un MySQL propio.
+func ReadSecretFromEnv() error {
+	host := os.Getenv("DB_HOST")
+	if host == "" {
+		return fmt.Errorf("DB_HOST no definido")
+	}
+
+	port := os.Getenv("DB_PORT")
+	if port == "" {
+		port = "3306"
+	}
+
+	dbName := os.Getenv("DB_NAME")
+	if dbName == "" {
+		return fmt.Errorf("DB_NAME no definido")
+	}
+
+	SecretModel = models.SecretRDSJson{
+		Username: os.Getenv("DB_USER"),
+		Password: os.Getenv("DB_PASSWORD"),
+		Engine:   "mysql",
+		// ConnStr solo usa Host, por eso se incluye el puerto
+		Host:   host + ":" + port,
+		Port:   port,
+		DBName: dbName,
+	}
+
+	secretMu.Lock()
+	secretLoaded = true
+	secretMu.Unlock()
+	return nil
 }
 
+// DbConnect inicializa el pool de conexiones si aún no existe.
+// Es seguro llamarlo al inicio de cada función: después de la primera
+// conexión exitosa solo retorna nil. Si el Ping falla el pool se descarta
+// para reintentar en la siguiente llamada.
 func DbConnect() error {
-	Db, err = sql.Open("mysql", ConnStr(SecretModel))
+	dbMu.Lock()
+	defer dbMu.Unlock()
+
+	if Db != nil {
+		return nil
+	}
+
+	pool, err := sql.Open("mysql", ConnStr(SecretModel))
 	if err != nil {
 		fmt.Println(err.Error())
 		return err
 	}
 
-	err = Db.Ping()
+	pool.SetMaxOpenConns(envInt("DB_MAX_OPEN_CONNS", 10))
+	pool.SetMaxIdleConns(envInt("DB_MAX_IDLE_CONNS", 5))
+	pool.SetConnMaxLifetime(envDuration("DB_CONN_MAX_LIFETIME", 5*time.Minute))
+	pool.SetConnMaxIdleTime(envDuration("DB_CONN_MAX_IDLE_TIME", 2*time.Minute))
+
+	err = pool.Ping()
 	if err != nil {
 		fmt.Println(err.Error())
+		pool.Close()
 		return err
 	}
 
-	// Establecer zona horaria de Colombia para la sesión MySQL
-	_, err = Db.Exec("SET time_zone = '-05:00'")
+	Db = pool
+	fmt.Println("Conexión exitosa de la BD")
+	return nil
+}
+
+// envInt lee un entero de una variable de entorno, con valor por defecto
+func envInt(name string, def int) int {
+	value, err := strconv.Atoi(os.Getenv(name))
 	if err != nil {
-		fmt.Println("Advertencia: No se pudo establecer timezone de sesión:", err.Error())
-		// No retornamos error, continuamos con la conexión
+		return def
 	}
+	return value
+}
 
-	fmt.Println("Conexión exitosa de la BD")
-	return nil
+// envDuration lee una duración (p.ej. "5m", "30s") de una variable de entorno
+func envDuration(name string, def time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(name))
+	if err != nil {
+		return def
+	}
+	return value
 }
 
 func ConnStr(claves models.SecretRDSJson) string {
@@ -50,36 +141,26 @@ func ConnStr(claves models.SecretRDSJson) string {
 	dbEndpoint = claves.Host
 	dbName = claves.DBName
 
-	// CAMBIO IMPORTANTE: Agregar zona horaria de Colombia
+	// Zona horaria de Colombia: loc para los time.Time de Go y time_zone
+	// ('-05:00') como variable de sesión en cada conexión del pool
 	return fmt.Sprintf(
-		"%s:%s@tcp(%s)/%s?allowCleartextPasswords=true&parseTime=true&loc=America%%2FBogota",
+		"%s:%s@tcp(%s)/%s?allowCleartextPasswords=true&parseTime=true&loc=America%%2FBogota&time_zone=%%27-05%%3A00%%27",
 		dbUser, authToken, dbEndpoint, dbName,
 	)
 }
 
-func UserExists(UserUUID string) (bool, error) {
-	fmt.Println("Comienza UserExists " + UserUUID)
-
-	err := DbConnect()
-	if err != nil {
-		return false, err
-	}
-	defer Db.Close()
-
-	query := "SELECT UserUUID FROM users WHERE UserUUID = ?"
-
-	rows, err := Db.Query(query, UserUUID)
-	if err != nil {
-		return false, err
+// nullIfEmpty convierte un string vacío en NULL para columnas opcionales
+func nullIfEmpty(value string) interface{} {
+	if value == "" {
+		return nil
 	}
-	var valor string
-	rows.Next()
-	rows.Scan(&valor)
-
-	fmt.Println("UserExists > Ejecución exitosa - valor devuelto " + valor)
+	return value
+}
 
-	if valor == "1" {
-		return true, nil
+// nullIfZero convierte un ID en 0 en NULL para llaves foráneas opcionales
+func nullIfZero(value int64) interface{} {
+	if value == 0 {
+		return nil
 	}
-	return false, nil
+	return value
 }
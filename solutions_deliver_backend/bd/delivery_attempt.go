@@ -0,0 +1,258 @@
+package bd
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+)
+
+// RegisterDeliveryAttempt registra un intento de entrega fallido sobre una
+// asignación DELIVERY en progreso. En una sola transacción: guarda el intento,
+// cancela la asignación, pasa la guía a DELIVERY_FAILED y crea la siguiente
+// asignación: un reintento para nextDate con el mismo entregador o, si se
+// agotaron los intentos, una devolución (RETURN) hacia el remitente.
+func RegisterDeliveryAttempt(assignmentID int64, req models.RegisterAttemptRequest, maxAttempts int, nextDate time.Time, userUUID string) (models.DeliveryAttemptResult, error) {
+	fmt.Printf("RegisterDeliveryAttempt -> AssignmentID: %d, Reason: %s\n", assignmentID, req.ReasonCode)
+
+	var result models.DeliveryAttemptResult
+
+	err := DbConnect()
+	if err != nil {
+		return result, err
+	}
+
+	tx, err := Db.Begin()
+	if err != nil {
+		return result, err
+	}
+
+	// Bloquear la asignación
+	var guideID int64
+	var deliveryUserID string
+	var assignmentType models.AssignmentType
+	var status models.AssignmentStatus
+	err = tx.QueryRow(`
+		SELECT guide_id, delivery_user_id, assignment_type, status
+		FROM delivery_assignments
+		WHERE assignment_id = ?
+		FOR UPDATE
+	`, assignmentID).Scan(&guideID, &deliveryUserID, &assignmentType, &status)
+	if err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return result, fmt.Errorf("asignación no encontrada")
+		}
+		return result, err
+	}
+
+	if assignmentType != models.AssignmentDelivery {
+		tx.Rollback()
+		return result, fmt.Errorf("solo las asignaciones de entrega admiten intentos")
+	}
+	if status != models.AssignmentInProgress {
+		tx.Rollback()
+		return result, fmt.Errorf("la entrega debe estar en progreso para registrar un intento")
+	}
+
+	// Número de intento: se cuenta por guía, no por asignación
+	var previous int
+	err = tx.QueryRow(`SELECT COUNT(*) FROM delivery_attempts WHERE guide_id = ?`, guideID).Scan(&previous)
+	if err != nil {
+		tx.Rollback()
+		return result, err
+	}
+	attemptNumber := previous + 1
+
+	insertQuery := `
+		INSERT INTO delivery_attempts
+		(assignment_id, guide_id, attempt_number, reason_code, notes, latitude, longitude, attempted_by, attempted_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, NOW())
+	`
+	res, err := tx.Exec(insertQuery,
+		assignmentID,
+		guideID,
+		attemptNumber,
+		req.ReasonCode,
+		req.Notes,
+		req.Latitude,
+		req.Longitude,
+		userUUID,
+	)
+	if err != nil {
+		tx.Rollback()
+		return result, err
+	}
+	attemptID, err := res.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return result, err
+	}
+
+	// Cerrar la asignación actual
+	_, err = tx.Exec(`
+		UPDATE delivery_assignments
+		SET status = 'CANCELLED', updated_at = NOW()
+		WHERE assignment_id = ?
+	`, assignmentID)
+	if err != nil {
+		tx.Rollback()
+		return result, err
+	}
+
+	historyNotes := fmt.Sprintf("Intento de entrega %d fallido: %s", attemptNumber, req.ReasonCode.Label())
+	_, err = tx.Exec(`
+		INSERT INTO assignment_history
+		(assignment_id, action, previous_status, new_status, changed_by, notes)
+		VALUES (?, 'STATUS_CHANGE', ?, 'CANCELLED', ?, ?)
+	`, assignmentID, status, userUUID, historyNotes)
+	if err != nil {
+		tx.Rollback()
+		return result, err
+	}
+
+	// La guía queda en DELIVERY_FAILED con el motivo del intento
+	err = updateGuideStatusTx(tx, guideID, models.StatusDeliveryFailed, req.ReasonCode, req.Notes, userUUID)
+	if err != nil {
+		tx.Rollback()
+		return result, err
+	}
+
+	// Siguiente asignación: reintento o devolución al remitente
+	nextType := models.AssignmentDelivery
+	nextAttempt := attemptNumber + 1
+	nextNotes := fmt.Sprintf("Reintento de entrega %d programado para %s", nextAttempt, nextDate.Format("2006-01-02"))
+	if attemptNumber >= maxAttempts {
+		nextType = models.AssignmentReturn
+		nextAttempt = 1
+		nextNotes = fmt.Sprintf("Devolución al remitente: se agotaron los %d intentos de entrega", maxAttempts)
+		result.ReturnToSender = true
+	}
+
+	nextID, err := insertScheduledAssignment(tx, guideID, deliveryUserID, nextType, nextAttempt, nextDate, nextNotes, userUUID)
+	if err != nil {
+		tx.Rollback()
+		return result, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return result, err
+	}
+
+	result.NextAssignmentID = nextID
+	result.Attempt = models.DeliveryAttempt{
+		AttemptID:     attemptID,
+		AssignmentID:  assignmentID,
+		GuideID:       guideID,
+		AttemptNumber: attemptNumber,
+		ReasonCode:    req.ReasonCode,
+		Notes:         req.Notes,
+		Latitude:      req.Latitude,
+		Longitude:     req.Longitude,
+		AttemptedBy:   userUUID,
+		AttemptedAt:   time.Now(),
+	}
+
+	return result, nil
+}
+
+// insertScheduledAssignment crea, dentro de la transacción dada, una asignación
+// PENDING programada para la fecha dada y registra su creación en el historial
+func insertScheduledAssignment(tx *sql.Tx, guideID int64, deliveryUserID string, assignmentType models.AssignmentType, attemptNumber int, scheduledDate time.Time, notes string, assignedBy string) (int64, error) {
+	res, err := tx.Exec(`
+		INSERT INTO delivery_assignments
+		(guide_id, delivery_user_id, assignment_type, status, attempt_number, scheduled_date, notes, assigned_by, assigned_at)
+		VALUES (?, ?, ?, 'PENDING', ?, ?, ?, ?, NOW())
+	`, guideID, deliveryUserID, assignmentType, attemptNumber, scheduledDate.Format("2006-01-02"), notes, assignedBy)
+	if err != nil {
+		return 0, err
+	}
+
+	assignmentID, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO assignment_history
+		(assignment_id, action, new_delivery_user_id, new_status, changed_by, notes)
+		VALUES (?, 'CREATED', ?, 'PENDING', ?, ?)
+	`, assignmentID, deliveryUserID, assignedBy, notes)
+	if err != nil {
+		return 0, err
+	}
+
+	return assignmentID, nil
+}
+
+// GetGuideDeliveryAttempts obtiene los intentos de entrega de una guía
+func GetGuideDeliveryAttempts(guideID int64) ([]models.DeliveryAttempt, error) {
+	fmt.Printf("GetGuideDeliveryAttempts -> GuideID: %d\n", guideID)
+
+	attempts := []models.DeliveryAttempt{}
+
+	err := DbConnect()
+	if err != nil {
+		return attempts, err
+	}
+
+	query := `
+		SELECT
+			attempt_id,
+			assignment_id,
+			guide_id,
+			attempt_number,
+			reason_code,
+			notes,
+			latitude,
+			longitude,
+			attempted_by,
+			attempted_at
+		FROM delivery_attempts
+		WHERE guide_id = ?
+		ORDER BY attempt_number ASC
+	`
+
+	rows, err := Db.Query(query, guideID)
+	if err != nil {
+		return attempts, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a models.DeliveryAttempt
+		var notes sql.NullString
+		var latitude, longitude sql.NullFloat64
+
+		err := rows.Scan(
+			&a.AttemptID,
+			&a.AssignmentID,
+			&a.GuideID,
+			&a.AttemptNumber,
+			&a.ReasonCode,
+			&notes,
+			&latitude,
+			&longitude,
+			&a.AttemptedBy,
+			&a.AttemptedAt,
+		)
+		if err != nil {
+			return attempts, err
+		}
+
+		if notes.Valid {
+			a.Notes = notes.String
+		}
+		if latitude.Valid && longitude.Valid {
+			lat, lng := latitude.Float64, longitude.Float64
+			a.Latitude = &lat
+			a.Longitude = &lng
+		}
+
+		attempts = append(attempts, a)
+	}
+
+	return attempts, rows.Err()
+}
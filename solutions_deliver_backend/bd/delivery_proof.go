@@ -0,0 +1,190 @@
+package bd
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+)
+
+// CompleteDeliveryWithProof completa una asignación DELIVERY, guarda su prueba
+// de entrega y aplica guideChange en la misma transacción
+func CompleteDeliveryWithProof(assignmentID int64, proof models.DeliveryProofRequest, notes string, changedBy string, guideChange *models.GuideStatusChange) (models.DeliveryAssignment, error) {
+	fmt.Printf("CompleteDeliveryWithProof -> AssignmentID: %d\n", assignmentID)
+
+	var assignment models.DeliveryAssignment
+
+	err := DbConnect()
+	if err != nil {
+		return assignment, err
+	}
+
+	tx, err := Db.Begin()
+	if err != nil {
+		return assignment, err
+	}
+
+	// Bloquear la asignación
+	var guideID int64
+	var currentStatus string
+	err = tx.QueryRow(`
+		SELECT guide_id, status FROM delivery_assignments
+		WHERE assignment_id = ?
+		FOR UPDATE
+	`, assignmentID).Scan(&guideID, &currentStatus)
+	if err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return assignment, fmt.Errorf("asignación no encontrada")
+		}
+		return assignment, err
+	}
+
+	if currentStatus == string(models.AssignmentCompleted) || currentStatus == string(models.AssignmentCancelled) {
+		tx.Rollback()
+		return assignment, fmt.Errorf("la asignación ya está cerrada")
+	}
+
+	_, err = tx.Exec(`
+		UPDATE delivery_assignments
+		SET status = 'COMPLETED', updated_at = NOW(), completed_at = NOW()
+		WHERE assignment_id = ?
+	`, assignmentID)
+	if err != nil {
+		tx.Rollback()
+		return assignment, err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO assignment_history
+		(assignment_id, action, previous_status, new_status, changed_by, notes)
+		VALUES (?, 'STATUS_CHANGE', ?, 'COMPLETED', ?, ?)
+	`, assignmentID, currentStatus, changedBy, notes)
+	if err != nil {
+		tx.Rollback()
+		return assignment, err
+	}
+
+	photoKeys := proof.PhotoKeys
+	if photoKeys == nil {
+		photoKeys = []string{}
+	}
+	photosJSON, err := json.Marshal(photoKeys)
+	if err != nil {
+		tx.Rollback()
+		return assignment, err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO delivery_proofs
+		(assignment_id, guide_id, receiver_name, receiver_document, signature_s3_key, photo_s3_keys,
+		 latitude, longitude, captured_by, captured_at)
+		VALUES (?, ?, NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), ?, ?, ?, ?, NOW())
+	`,
+		assignmentID,
+		guideID,
+		proof.ReceiverName,
+		proof.ReceiverDocument,
+		proof.SignatureKey,
+		string(photosJSON),
+		proof.Latitude,
+		proof.Longitude,
+		changedBy,
+	)
+	if err != nil {
+		tx.Rollback()
+		return assignment, err
+	}
+
+	if guideChange != nil {
+		err = updateGuideStatusTx(tx, guideID, guideChange.Status, guideChange.Reason, "", changedBy)
+		if err != nil {
+			tx.Rollback()
+			return assignment, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return assignment, err
+	}
+
+	return GetAssignmentByID(assignmentID)
+}
+
+// GetDeliveryProofByGuide obtiene la prueba de entrega más reciente de una guía
+func GetDeliveryProofByGuide(guideID int64) (models.DeliveryProof, error) {
+	fmt.Printf("GetDeliveryProofByGuide -> GuideID: %d\n", guideID)
+
+	var proof models.DeliveryProof
+
+	err := DbConnect()
+	if err != nil {
+		return proof, err
+	}
+
+	query := `
+		SELECT
+			proof_id,
+			assignment_id,
+			guide_id,
+			receiver_name,
+			receiver_document,
+			signature_s3_key,
+			photo_s3_keys,
+			latitude,
+			longitude,
+			captured_by,
+			captured_at
+		FROM delivery_proofs
+		WHERE guide_id = ?
+		ORDER BY captured_at DESC, proof_id DESC
+		LIMIT 1
+	`
+
+	var receiverName, receiverDocument, signatureKey, photoKeys sql.NullString
+	var latitude, longitude sql.NullFloat64
+
+	err = Db.QueryRow(query, guideID).Scan(
+		&proof.ProofID,
+		&proof.AssignmentID,
+		&proof.GuideID,
+		&receiverName,
+		&receiverDocument,
+		&signatureKey,
+		&photoKeys,
+		&latitude,
+		&longitude,
+		&proof.CapturedBy,
+		&proof.CapturedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return proof, fmt.Errorf("prueba de entrega no encontrada")
+		}
+		return proof, err
+	}
+
+	if receiverName.Valid {
+		proof.ReceiverName = receiverName.String
+	}
+	if receiverDocument.Valid {
+		proof.ReceiverDocument = receiverDocument.String
+	}
+	if signatureKey.Valid {
+		proof.SignatureS3Key = signatureKey.String
+	}
+	if photoKeys.Valid && photoKeys.String != "" {
+		if err := json.Unmarshal([]byte(photoKeys.String), &proof.PhotoS3Keys); err != nil {
+			return proof, fmt.Errorf("fotos de la prueba de entrega inválidas: %v", err)
+		}
+	}
+	if latitude.Valid && longitude.Valid {
+		lat, lng := latitude.Float64, longitude.Float64
+		proof.Latitude = &lat
+		proof.Longitude = &lng
+	}
+
+	return proof, nil
+}
@@ -20,7 +20,6 @@ func SearchFrequentPartiesByNameAndCity(searchTerm string, cityID int64, partyTy
 	if err != nil {
 		return parties, 0, err
 	}
-	defer Db.Close()
 
 	searchPattern := "%" + searchTerm + "%"
 
@@ -138,7 +137,6 @@ func SearchFrequentPartiesByNameOnly(searchTerm string, partyType models.PartyTy
 	if err != nil {
 		return parties, 0, err
 	}
-	defer Db.Close()
 
 	searchPattern := "%" + searchTerm + "%"
 
@@ -225,7 +223,6 @@ func GetFrequentPartiesByDocument(documentNumber string, cityID int64, partyType
 	if err != nil {
 		return parties, 0, err
 	}
-	defer Db.Close()
 
 	// Contar total
 	countQuery := `
@@ -340,7 +337,6 @@ func UpsertFrequentParty(req models.CreateFrequentPartyRequest) error {
 	if err != nil {
 		return err
 	}
-	defer Db.Close()
 
 	// Verificar si ya existe la combinación documento + ciudad + dirección
 	checkQuery := `
@@ -449,7 +445,6 @@ func GetFrequentPartyStats() (map[string]interface{}, error) {
 	if err != nil {
 		return stats, err
 	}
-	defer Db.Close()
 
 	// Total de partes únicas
 	var totalUnique int
@@ -19,7 +19,6 @@ func GetGuidesByFilters(filters models.GuideFilters) ([]models.ShippingGuide, in
 	if err != nil {
 		return guides, 0, err
 	}
-	defer Db.Close()
 
 	// Construcción dinámica de WHERE
 	var conditions []string
@@ -48,8 +47,10 @@ func GetGuidesByFilters(filters models.GuideFilters) ([]models.ShippingGuide, in
 	// BÚSQUEDA MEJORADA: Incluye guide_id, ciudades, nombres y documentos
 	if filters.SearchTerm != "" {
 		searchPattern := "%" + filters.SearchTerm + "%"
+		numberPattern := "%" + models.NormalizeGuideNumber(filters.SearchTerm) + "%"
 		conditions = append(conditions, `(
 			CAST(sg.guide_id AS CHAR) LIKE ? OR 
+			sg.guide_number LIKE ? OR
 			oc.name LIKE ? OR 
 			dc.name LIKE ? OR
 			sender.full_name LIKE ? OR
@@ -57,7 +58,7 @@ func GetGuidesByFilters(filters models.GuideFilters) ([]models.ShippingGuide, in
 			sender.document_number LIKE ? OR
 			receiver.document_number LIKE ?
 		)`)
-		args = append(args, searchPattern, searchPattern, searchPattern, searchPattern, searchPattern, searchPattern, searchPattern)
+		args = append(args, searchPattern, numberPattern, searchPattern, searchPattern, searchPattern, searchPattern, searchPattern, searchPattern)
 	}
 
 	if filters.DateFrom != nil {
@@ -95,6 +96,7 @@ func GetGuidesByFilters(filters models.GuideFilters) ([]models.ShippingGuide, in
 	query := fmt.Sprintf(`
 		SELECT 
 			sg.guide_id,
+			sg.guide_number,
 			sg.service_type,
 			sg.payment_method,
 			sg.declared_value,
@@ -124,7 +126,8 @@ func GetGuidesByFilters(filters models.GuideFilters) ([]models.ShippingGuide, in
 			receiver.phone,
 			receiver.email,
 			receiver.address,
-			receiver.city_id
+			receiver.city_id,
+			(SELECT COUNT(DISTINCT gpc.status) FROM guide_pieces gpc WHERE gpc.guide_id = sg.guide_id) > 1 AS partial
 		FROM shipping_guides sg
 		LEFT JOIN cities oc ON sg.origin_city_id = oc.id
 		LEFT JOIN cities dc ON sg.destination_city_id = dc.id
@@ -147,6 +150,7 @@ func GetGuidesByFilters(filters models.GuideFilters) ([]models.ShippingGuide, in
 		var guide models.ShippingGuide
 		var sender models.GuideParty
 		var receiver models.GuideParty
+		var guideNumber sql.NullString
 
 		// Variables nullable para el scan
 		var senderPartyID, receiverPartyID sql.NullInt64
@@ -157,6 +161,7 @@ func GetGuidesByFilters(filters models.GuideFilters) ([]models.ShippingGuide, in
 
 		err := rows.Scan(
 			&guide.GuideID,
+			&guideNumber,
 			&guide.ServiceType,
 			&guide.PaymentMethod,
 			&guide.DeclaredValue,
@@ -189,11 +194,14 @@ func GetGuidesByFilters(filters models.GuideFilters) ([]models.ShippingGuide, in
 			&receiverEmail,
 			&receiverAddr,
 			&receiverCityID,
+			&guide.Partial,
 		)
 
 		if err != nil {
 			return guides, 0, err
 		}
+		guide.GuideNumber = guideNumber.String
+		guide.Partial = guide.Partial && !guide.CurrentStatus.IsFinal()
 
 		// Asignar sender si existe
 		if senderPartyID.Valid {
@@ -241,16 +249,17 @@ func GetGuideByID(guideID int64) (models.ShippingGuide, error) {
 	if err != nil {
 		return guide, err
 	}
-	defer Db.Close()
 
 	// Consulta principal de la guía
 	query := `
 		SELECT 
 			sg.guide_id,
+			sg.guide_number,
 			sg.service_type,
 			sg.payment_method,
 			sg.declared_value,
 			sg.price,
+			sg.rate_id,
 			sg.current_status,
 			sg.origin_city_id,
 			oc.name AS origin_city_name,
@@ -258,6 +267,8 @@ func GetGuideByID(guideID int64) (models.ShippingGuide, error) {
 			dc.name AS destination_city_name,
 			sg.pdf_url,
 			sg.pdf_s3_key,
+			sg.rndc_remesa_id,
+			sg.rndc_manifest_id,
 			sg.created_by,
 			sg.created_at,
 			sg.updated_at
@@ -267,13 +278,18 @@ func GetGuideByID(guideID int64) (models.ShippingGuide, error) {
 		WHERE sg.guide_id = ?
 	`
 
+	var rateID sql.NullInt64
+	var guideNumber, rndcRemesaID, rndcManifestID sql.NullString
+
 	row := Db.QueryRow(query, guideID)
 	err = row.Scan(
 		&guide.GuideID,
+		&guideNumber,
 		&guide.ServiceType,
 		&guide.PaymentMethod,
 		&guide.DeclaredValue,
 		&guide.Price,
+		&rateID,
 		&guide.CurrentStatus,
 		&guide.OriginCityID,
 		&guide.OriginCityName,
@@ -281,6 +297,8 @@ func GetGuideByID(guideID int64) (models.ShippingGuide, error) {
 		&guide.DestinationCityName,
 		&guide.PDFUrl,
 		&guide.PDFS3Key,
+		&rndcRemesaID,
+		&rndcManifestID,
 		&guide.CreatedBy,
 		&guide.CreatedAt,
 		&guide.UpdatedAt,
@@ -292,6 +310,10 @@ func GetGuideByID(guideID int64) (models.ShippingGuide, error) {
 		}
 		return guide, err
 	}
+	guide.RateID = rateID.Int64
+	guide.GuideNumber = guideNumber.String
+	guide.RNDCRemesaID = rndcRemesaID.String
+	guide.RNDCManifestID = rndcManifestID.String
 
 	// Obtener partes (remitente y destinatario)
 	parties, err := getGuideParties(guideID)
@@ -312,6 +334,13 @@ func GetGuideByID(guideID int64) (models.ShippingGuide, error) {
 		guide.Package = &pkg
 	}
 
+	// Obtener piezas
+	pieces, err := getGuidePieces(guideID)
+	if err == nil {
+		guide.Pieces = pieces
+		guide.Partial = !guide.CurrentStatus.IsFinal() && models.PiecesPartial(pieces)
+	}
+
 	// Obtener historial
 	history, err := getGuideHistory(guideID)
 	if err == nil {
@@ -429,6 +458,8 @@ func getGuideHistory(guideID int64) ([]models.StatusHistory, error) {
 			history_id,
 			guide_id,
 			status,
+			COALESCE(reason_code, ''),
+			COALESCE(notes, ''),
 			updated_by,
 			updated_at
 		FROM guide_status_history
@@ -449,6 +480,8 @@ func getGuideHistory(guideID int64) ([]models.StatusHistory, error) {
 			&h.HistoryID,
 			&h.GuideID,
 			&h.Status,
+			&h.ReasonCode,
+			&h.Notes,
 			&h.UpdatedBy,
 			&h.UpdatedAt,
 		)
@@ -464,14 +497,13 @@ func getGuideHistory(guideID int64) ([]models.StatusHistory, error) {
 }
 
 // UpdateGuideStatus actualiza el estado de una guía y registra en el historial
-func UpdateGuideStatus(guideID int64, status models.GuideStatus, userUUID string) error {
+func UpdateGuideStatus(guideID int64, status models.GuideStatus, reason models.GuideStatusReason, notes string, userUUID string) error {
 	fmt.Printf("UpdateGuideStatus -> GuideID: %d, Status: %s\n", guideID, status)
 
 	err := DbConnect()
 	if err != nil {
 		return err
 	}
-	defer Db.Close()
 
 	// Iniciar transacción
 	tx, err := Db.Begin()
@@ -479,6 +511,40 @@ func UpdateGuideStatus(guideID int64, status models.GuideStatus, userUUID string
 		return err
 	}
 
+	err = updateGuideStatusTx(tx, guideID, status, reason, notes, userUUID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// Commit de la transacción
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// updateGuideStatusTx cambia el estado de la guía dentro de la transacción dada:
+// valida la transición, registra el historial y aplica sus efectos. El
+// llamador hace el Rollback si retorna error.
+func updateGuideStatusTx(tx *sql.Tx, guideID int64, status models.GuideStatus, reason models.GuideStatusReason, notes string, userUUID string) error {
+	// Bloquear la guía y validar la transición contra el estado actual
+	var currentStatus models.GuideStatus
+	err := tx.QueryRow(`SELECT current_status FROM shipping_guides WHERE guide_id = ? FOR UPDATE`, guideID).Scan(&currentStatus)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("Guía no encontrada")
+		}
+		return err
+	}
+
+	transition, ok := models.FindGuideTransition(currentStatus, status)
+	if !ok {
+		return fmt.Errorf("transición de estado no permitida")
+	}
+
 	// Actualizar estado en shipping_guides
 	updateQuery := `
 		UPDATE shipping_guides
@@ -488,26 +554,39 @@ func UpdateGuideStatus(guideID int64, status models.GuideStatus, userUUID string
 
 	_, err = tx.Exec(updateQuery, status, guideID)
 	if err != nil {
-		tx.Rollback()
 		return err
 	}
 
 	// Insertar en historial
 	historyQuery := `
-		INSERT INTO guide_status_history (guide_id, status, updated_by, updated_at)
-		VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		INSERT INTO guide_status_history (guide_id, status, reason_code, notes, updated_by, updated_at)
+		VALUES (?, ?, NULLIF(?, ''), NULLIF(?, ''), ?, CURRENT_TIMESTAMP)
 	`
 
-	_, err = tx.Exec(historyQuery, guideID, status, userUUID)
+	_, err = tx.Exec(historyQuery, guideID, status, reason, notes, userUUID)
 	if err != nil {
-		tx.Rollback()
 		return err
 	}
 
-	// Commit de la transacción
-	err = tx.Commit()
-	if err != nil {
-		return err
+	// Efectos de la transición
+	effectNotes := fmt.Sprintf("Cerrada automáticamente: la guía pasó a %s", status)
+	if transition.HasEffect(models.EffectCancelOpenPickups) {
+		err = cancelOpenAssignments(tx, guideID, models.AssignmentPickup, userUUID, effectNotes)
+		if err != nil {
+			return err
+		}
+	}
+	if transition.HasEffect(models.EffectCancelOpenDeliveries) {
+		err = cancelOpenAssignments(tx, guideID, models.AssignmentDelivery, userUUID, effectNotes)
+		if err != nil {
+			return err
+		}
+	}
+	if transition.HasEffect(models.EffectCancelOpenReturns) {
+		err = cancelOpenAssignments(tx, guideID, models.AssignmentReturn, userUUID, effectNotes)
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -524,7 +603,6 @@ func GetGuideStats(userUUID string) (models.GuideStatsResponse, error) {
 	if err != nil {
 		return stats, err
 	}
-	defer Db.Close()
 
 	// Total del día
 	todayQuery := `
@@ -548,11 +626,11 @@ func GetGuideStats(userUUID string) (models.GuideStatsResponse, error) {
 		return stats, err
 	}
 
-	// Total pendientes (no entregados)
+	// Total pendientes (sin estados finales)
 	pendingQuery := `
 		SELECT COUNT(*)
 		FROM shipping_guides
-		WHERE current_status != 'DELIVERED' 
+		WHERE current_status NOT IN ('DELIVERED', 'RETURNED_TO_SENDER', 'CANCELLED')
 	`
 	err = Db.QueryRow(pendingQuery).Scan(&stats.TotalPending)
 	if err != nil {
@@ -595,7 +673,6 @@ func GuideExists(guideID int64) bool {
 	if err != nil {
 		return false
 	}
-	defer Db.Close()
 
 	query := `SELECT COUNT(*) FROM shipping_guides WHERE guide_id = ?`
 
@@ -618,7 +695,6 @@ func GetGuidePDFInfo(guideID int64) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	defer Db.Close()
 
 	query := `
 		SELECT pdf_s3_key
@@ -640,3 +716,164 @@ func GetGuidePDFInfo(guideID int64) (string, error) {
 
 	return pdfS3Key, nil
 }
+
+// CreateGuide inserta la guía, sus partes, el paquete, las piezas y el estado
+// inicial en una sola transacción. Asigna GuideID, los IDs de partes, paquete
+// y piezas, y los códigos de las piezas.
+func CreateGuide(guide *models.ShippingGuide, numberPrefix string, userUUID string) error {
+	fmt.Printf("CreateGuide -> Origin: %d, Destination: %d, Prefix: %s, UserUUID: %s\n",
+		guide.OriginCityID, guide.DestinationCityID, numberPrefix, userUUID)
+
+	err := DbConnect()
+	if err != nil {
+		return err
+	}
+
+	// Iniciar transacción
+	tx, err := Db.Begin()
+	if err != nil {
+		return err
+	}
+
+	guideNumber, err := nextGuideNumberTx(tx, numberPrefix)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	guideQuery := `
+		INSERT INTO shipping_guides
+		(guide_number, service_type, payment_method, declared_value, price, rate_id,
+		 origin_city_id, destination_city_id, current_status, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, 'CREATED', ?)
+	`
+
+	result, err := tx.Exec(guideQuery,
+		guideNumber,
+		guide.ServiceType,
+		guide.PaymentMethod,
+		guide.DeclaredValue,
+		guide.Price,
+		nullIfZero(guide.RateID),
+		guide.OriginCityID,
+		guide.DestinationCityID,
+		userUUID,
+	)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	guideID, err := result.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// Partes (remitente y destinatario)
+	partyQuery := `
+		INSERT INTO guide_parties
+		(guide_id, party_role, full_name, document_type, document_number,
+		 phone, email, address, city_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	for _, party := range []*models.GuideParty{guide.Sender, guide.Receiver} {
+		result, err = tx.Exec(partyQuery,
+			guideID,
+			party.PartyRole,
+			party.FullName,
+			party.DocumentType,
+			party.DocumentNumber,
+			party.Phone,
+			nullIfEmpty(party.Email),
+			party.Address,
+			party.CityID,
+		)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		party.PartyID, _ = result.LastInsertId()
+		party.GuideID = guideID
+	}
+
+	// Paquete
+	packageQuery := `
+		INSERT INTO packages
+		(guide_id, weight_kg, pieces, length_cm, width_cm, height_cm,
+		 insured, description, special_notes)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err = tx.Exec(packageQuery,
+		guideID,
+		guide.Package.WeightKg,
+		guide.Package.Pieces,
+		guide.Package.LengthCM,
+		guide.Package.WidthCM,
+		guide.Package.HeightCM,
+		guide.Package.Insured,
+		nullIfEmpty(guide.Package.Description),
+		nullIfEmpty(guide.Package.SpecialNotes),
+	)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	guide.Package.PackageID, _ = result.LastInsertId()
+	guide.Package.GuideID = guideID
+
+	// Piezas, cada una con su código
+	err = insertGuidePiecesTx(tx, guideID, guide.Package.PackageID, guideNumber, guide.Pieces)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// Estado inicial
+	historyQuery := `
+		INSERT INTO guide_status_history (guide_id, status, updated_by)
+		VALUES (?, 'CREATED', ?)
+	`
+
+	_, err = tx.Exec(historyQuery, guideID, userUUID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// Commit de la transacción
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	guide.GuideID = guideID
+	guide.GuideNumber = guideNumber
+	guide.CurrentStatus = models.StatusCreated
+	guide.CreatedBy = userUUID
+
+	return nil
+}
+
+// UpdateGuidePDF guarda la URL y la llave S3 del PDF de una guía
+func UpdateGuidePDF(guideID int64, pdfURL, pdfS3Key string) error {
+	fmt.Printf("UpdateGuidePDF -> GuideID: %d, S3Key: %s\n", guideID, pdfS3Key)
+
+	err := DbConnect()
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE shipping_guides
+		SET pdf_url = ?, pdf_s3_key = ?
+		WHERE guide_id = ?
+	`
+
+	_, err = Db.Exec(query, pdfURL, pdfS3Key, guideID)
+	return err
+}
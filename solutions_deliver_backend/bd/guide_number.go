@@ -0,0 +1,152 @@
+package bd
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+)
+
+// nextGuideNumberTx toma el siguiente consecutivo del prefijo (bloqueando su
+// fila hasta el commit) y arma el número de guía con dígito de verificación
+func nextGuideNumberTx(tx *sql.Tx, prefix string) (string, error) {
+	if !models.ValidGuideNumberPrefix(prefix) {
+		return "", models.ErrGuideNumberPrefixValue
+	}
+
+	_, err := tx.Exec(`
+		INSERT IGNORE INTO guide_number_sequences (prefix, last_value)
+		VALUES (?, 0)
+	`, prefix)
+	if err != nil {
+		return "", err
+	}
+
+	var last int64
+	err = tx.QueryRow(`
+		SELECT last_value FROM guide_number_sequences WHERE prefix = ? FOR UPDATE
+	`, prefix).Scan(&last)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE guide_number_sequences SET last_value = ? WHERE prefix = ?
+	`, last+1, prefix)
+	if err != nil {
+		return "", err
+	}
+
+	return models.NewGuideNumber(prefix, last+1)
+}
+
+// GetGuideIDByNumber obtiene el guide_id de un número de guía ya normalizado
+func GetGuideIDByNumber(guideNumber string) (int64, error) {
+	fmt.Printf("GetGuideIDByNumber -> GuideNumber: %s\n", guideNumber)
+
+	var guideID int64
+
+	err := DbConnect()
+	if err != nil {
+		return 0, err
+	}
+
+	err = Db.QueryRow(`
+		SELECT guide_id FROM shipping_guides WHERE guide_number = ?
+	`, guideNumber).Scan(&guideID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("Guía no encontrada")
+		}
+		return 0, err
+	}
+
+	return guideID, nil
+}
+
+// BackfillGuideNumbers asigna número de guía a las guías que no lo tienen
+// (creadas antes del formato o por la Lambda de Node.js), en orden de
+// guide_id y en lotes de batchSize. Cada lote es una transacción; se puede
+// ejecutar varias veces. Retorna cuántas guías actualizó.
+func BackfillGuideNumbers(prefix string, batchSize int) (int, error) {
+	fmt.Printf("BackfillGuideNumbers -> Prefix: %s, BatchSize: %d\n", prefix, batchSize)
+
+	err := DbConnect()
+	if err != nil {
+		return 0, err
+	}
+
+	if batchSize < 1 {
+		batchSize = 500
+	}
+
+	total := 0
+	for {
+		updated, err := backfillGuideNumbersBatch(prefix, batchSize)
+		if err != nil {
+			return total, err
+		}
+		total += updated
+		if updated < batchSize {
+			return total, nil
+		}
+	}
+}
+
+func backfillGuideNumbersBatch(prefix string, batchSize int) (int, error) {
+	tx, err := Db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := tx.Query(`
+		SELECT guide_id FROM shipping_guides
+		WHERE guide_number IS NULL
+		ORDER BY guide_id
+		LIMIT ?
+		FOR UPDATE
+	`, batchSize)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	var guideIDs []int64
+	for rows.Next() {
+		var guideID int64
+		if err := rows.Scan(&guideID); err != nil {
+			rows.Close()
+			tx.Rollback()
+			return 0, err
+		}
+		guideIDs = append(guideIDs, guideID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	for _, guideID := range guideIDs {
+		number, err := nextGuideNumberTx(tx, prefix)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+
+		_, err = tx.Exec(`
+			UPDATE shipping_guides SET guide_number = ? WHERE guide_id = ?
+		`, number, guideID)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	fmt.Printf("BackfillGuideNumbers -> Lote de %d guías\n", len(guideIDs))
+	return len(guideIDs), nil
+}
@@ -0,0 +1,133 @@
+package bd
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+)
+
+// insertGuidePiecesTx inserta las piezas de una guía nueva con su código
+// (número de guía + número de pieza). El llamador hace el Rollback si
+// retorna error.
+func insertGuidePiecesTx(tx *sql.Tx, guideID int64, packageID int64, guideNumber string, pieces []models.GuidePiece) error {
+	query := `
+		INSERT INTO guide_pieces
+		(guide_id, package_id, piece_number, piece_code, weight_kg,
+		 length_cm, width_cm, height_cm, description, status)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, 'CREATED')
+	`
+
+	for i := range pieces {
+		piece := &pieces[i]
+		piece.GuideID = guideID
+		piece.PieceNumber = i + 1
+		piece.PieceCode = models.NewPieceCode(guideNumber, piece.PieceNumber)
+		piece.Status = models.StatusCreated
+
+		result, err := tx.Exec(query,
+			guideID,
+			packageID,
+			piece.PieceNumber,
+			piece.PieceCode,
+			piece.WeightKg,
+			piece.LengthCM,
+			piece.WidthCM,
+			piece.HeightCM,
+			nullIfEmpty(piece.Description),
+			piece.Status,
+		)
+		if err != nil {
+			return err
+		}
+		piece.PieceID, _ = result.LastInsertId()
+	}
+
+	return nil
+}
+
+// getGuidePieces obtiene las piezas de una guía en orden. Las guías
+// anteriores a las piezas no tienen filas.
+func getGuidePieces(guideID int64) ([]models.GuidePiece, error) {
+	var pieces []models.GuidePiece
+
+	query := `
+		SELECT
+			piece_id,
+			guide_id,
+			piece_number,
+			piece_code,
+			weight_kg,
+			length_cm,
+			width_cm,
+			height_cm,
+			description,
+			status,
+			last_scan_point,
+			last_scanned_at
+		FROM guide_pieces
+		WHERE guide_id = ?
+		ORDER BY piece_number
+	`
+
+	rows, err := Db.Query(query, guideID)
+	if err != nil {
+		return pieces, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var piece models.GuidePiece
+		var description, lastScanPoint sql.NullString
+		var lastScannedAt sql.NullTime
+
+		err := rows.Scan(
+			&piece.PieceID,
+			&piece.GuideID,
+			&piece.PieceNumber,
+			&piece.PieceCode,
+			&piece.WeightKg,
+			&piece.LengthCM,
+			&piece.WidthCM,
+			&piece.HeightCM,
+			&description,
+			&piece.Status,
+			&lastScanPoint,
+			&lastScannedAt,
+		)
+		if err != nil {
+			return pieces, err
+		}
+
+		piece.Description = description.String
+		piece.LastScanPoint = models.ScanPoint(lastScanPoint.String)
+		if lastScannedAt.Valid {
+			piece.LastScannedAt = &lastScannedAt.Time
+		}
+
+		pieces = append(pieces, piece)
+	}
+
+	return pieces, rows.Err()
+}
+
+// UpdatePieceScanStatus registra el estado del escaneo en una pieza o, con
+// pieceNumber 0, en todas las piezas de la guía
+func UpdatePieceScanStatus(guideID int64, pieceNumber int, status models.GuideStatus, point models.ScanPoint) error {
+	fmt.Printf("UpdatePieceScanStatus -> GuideID: %d, Piece: %d, Status: %s\n", guideID, pieceNumber, status)
+
+	err := DbConnect()
+	if err != nil {
+		return err
+	}
+
+	// El router valida antes que la pieza exista en la guía
+	query := `
+		UPDATE guide_pieces
+		SET status = ?, last_scan_point = ?, last_scanned_at = NOW()
+		WHERE guide_id = ? AND (? = 0 OR piece_number = ?)
+	`
+
+	_, err = Db.Exec(query, status, point, guideID, pieceNumber, pieceNumber)
+	return err
+}
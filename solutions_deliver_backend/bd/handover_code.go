@@ -0,0 +1,271 @@
+package bd
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+)
+
+// SaveHandoverCode guarda (o reemplaza) el código de entrega de una asignación
+// y registra el envío en el historial. Los fallos acumulados y el bloqueo se
+// conservan: reenviar el código no da intentos nuevos.
+func SaveHandoverCode(code models.HandoverCode, changedBy string) error {
+	fmt.Printf("SaveHandoverCode -> AssignmentID: %d, SentTo: %s\n", code.AssignmentID, code.SentTo)
+
+	err := DbConnect()
+	if err != nil {
+		return err
+	}
+
+	tx, err := Db.Begin()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO handover_codes
+		(assignment_id, guide_id, code_hash, channel, sent_to, expires_at, failed_attempts, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, 0, NOW())
+		ON DUPLICATE KEY UPDATE
+			code_hash = VALUES(code_hash),
+			channel = VALUES(channel),
+			sent_to = VALUES(sent_to),
+			expires_at = VALUES(expires_at),
+			verified_at = NULL,
+			overridden_by = NULL,
+			override_reason = NULL,
+			created_at = NOW()
+	`, code.AssignmentID, code.GuideID, code.CodeHash, code.Channel, code.SentTo, code.ExpiresAt)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO assignment_history (assignment_id, action, changed_by, notes)
+		VALUES (?, 'HANDOVER_CODE_SENT', ?, ?)
+	`, code.AssignmentID, changedBy, fmt.Sprintf("Código de entrega enviado a %s", code.SentTo))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetHandoverCode obtiene el código de entrega de una asignación
+func GetHandoverCode(assignmentID int64) (models.HandoverCode, error) {
+	fmt.Printf("GetHandoverCode -> AssignmentID: %d\n", assignmentID)
+
+	var code models.HandoverCode
+
+	err := DbConnect()
+	if err != nil {
+		return code, err
+	}
+
+	code, err = scanHandoverCode(Db.QueryRow(`
+		SELECT assignment_id, guide_id, code_hash, channel, sent_to, expires_at, failed_attempts,
+			locked_until, verified_at, overridden_by, override_reason, created_at
+		FROM handover_codes
+		WHERE assignment_id = ?
+	`, assignmentID))
+	if err == sql.ErrNoRows {
+		return code, fmt.Errorf("código de entrega no encontrado")
+	}
+	return code, err
+}
+
+// VerifyHandoverCode compara el hash recibido con el guardado. Cada fallo
+// queda en el historial; cada maxAttempts fallos el código se bloquea con la
+// duración escalonada de models.HandoverLockout.
+func VerifyHandoverCode(assignmentID int64, codeHash string, maxAttempts int, lockout time.Duration, changedBy string) (models.HandoverVerification, error) {
+	fmt.Printf("VerifyHandoverCode -> AssignmentID: %d\n", assignmentID)
+
+	var result models.HandoverVerification
+
+	err := DbConnect()
+	if err != nil {
+		return result, err
+	}
+
+	tx, err := Db.Begin()
+	if err != nil {
+		return result, err
+	}
+
+	code, err := scanHandoverCode(tx.QueryRow(`
+		SELECT assignment_id, guide_id, code_hash, channel, sent_to, expires_at, failed_attempts,
+			locked_until, verified_at, overridden_by, override_reason, created_at
+		FROM handover_codes
+		WHERE assignment_id = ?
+		FOR UPDATE
+	`, assignmentID))
+	if err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return result, fmt.Errorf("código de entrega no encontrado")
+		}
+		return result, err
+	}
+
+	now := time.Now()
+	switch {
+	case code.VerifiedAt != nil:
+		tx.Rollback()
+		result.Result = models.HandoverValid
+		return result, nil
+	case code.LockedUntil != nil && code.LockedUntil.After(now):
+		tx.Rollback()
+		result.Result = models.HandoverLocked
+		result.LockedUntil = code.LockedUntil
+		return result, nil
+	case code.ExpiresAt.Before(now):
+		tx.Rollback()
+		result.Result = models.HandoverExpired
+		return result, nil
+	}
+
+	if subtle.ConstantTimeCompare([]byte(code.CodeHash), []byte(codeHash)) == 1 {
+		_, err = tx.Exec(`UPDATE handover_codes SET verified_at = NOW() WHERE assignment_id = ?`, assignmentID)
+		if err != nil {
+			tx.Rollback()
+			return result, err
+		}
+		result.Result = models.HandoverValid
+		return result, tx.Commit()
+	}
+
+	// Código incorrecto
+	failed := code.FailedAttempts + 1
+	var lockedUntil *time.Time
+	notes := fmt.Sprintf("Código de entrega incorrecto (%d fallos, quedan %d intentos)", failed, models.HandoverRemainingAttempts(failed, maxAttempts))
+	if duration := models.HandoverLockout(failed, maxAttempts, lockout); duration > 0 {
+		until := now.Add(duration)
+		lockedUntil = &until
+		notes = fmt.Sprintf("Código de entrega incorrecto (%d fallos): bloqueado hasta %s", failed, until.Format("2006-01-02 15:04"))
+	}
+
+	_, err = tx.Exec(`
+		UPDATE handover_codes SET failed_attempts = ?, locked_until = ?
+		WHERE assignment_id = ?
+	`, failed, lockedUntil, assignmentID)
+	if err != nil {
+		tx.Rollback()
+		return result, err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO assignment_history (assignment_id, action, changed_by, notes)
+		VALUES (?, 'HANDOVER_CODE_FAILED', ?, ?)
+	`, assignmentID, changedBy, notes)
+	if err != nil {
+		tx.Rollback()
+		return result, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return result, err
+	}
+
+	result.Result = models.HandoverInvalid
+	result.RemainingAttempts = models.HandoverRemainingAttempts(failed, maxAttempts)
+	if lockedUntil != nil {
+		result.Result = models.HandoverLocked
+		result.LockedUntil = lockedUntil
+		result.RemainingAttempts = 0
+	}
+	return result, nil
+}
+
+// OverrideHandoverCode marca el código como verificado por un ADMIN, con el
+// motivo en el historial. Si el código nunca se generó, se crea el registro.
+func OverrideHandoverCode(assignmentID int64, guideID int64, reason string, adminUUID string) error {
+	fmt.Printf("OverrideHandoverCode -> AssignmentID: %d\n", assignmentID)
+
+	err := DbConnect()
+	if err != nil {
+		return err
+	}
+
+	tx, err := Db.Begin()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO handover_codes
+		(assignment_id, guide_id, code_hash, expires_at, verified_at, overridden_by, override_reason, created_at)
+		VALUES (?, ?, '', NOW(), NOW(), ?, ?, NOW())
+		ON DUPLICATE KEY UPDATE
+			verified_at = NOW(),
+			locked_until = NULL,
+			overridden_by = VALUES(overridden_by),
+			override_reason = VALUES(override_reason)
+	`, assignmentID, guideID, adminUUID, reason)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO assignment_history (assignment_id, action, changed_by, notes)
+		VALUES (?, 'HANDOVER_CODE_OVERRIDE', ?, ?)
+	`, assignmentID, adminUUID, reason)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// scanHandoverCode lee una fila de handover_codes
+func scanHandoverCode(row *sql.Row) (models.HandoverCode, error) {
+	var code models.HandoverCode
+	var channel, sentTo, overriddenBy, overrideReason sql.NullString
+	var lockedUntil, verifiedAt sql.NullTime
+
+	err := row.Scan(
+		&code.AssignmentID,
+		&code.GuideID,
+		&code.CodeHash,
+		&channel,
+		&sentTo,
+		&code.ExpiresAt,
+		&code.FailedAttempts,
+		&lockedUntil,
+		&verifiedAt,
+		&overriddenBy,
+		&overrideReason,
+		&code.CreatedAt,
+	)
+	if err != nil {
+		return code, err
+	}
+
+	if channel.Valid {
+		code.Channel = channel.String
+	}
+	if sentTo.Valid {
+		code.SentTo = sentTo.String
+	}
+	if lockedUntil.Valid {
+		code.LockedUntil = &lockedUntil.Time
+	}
+	if verifiedAt.Valid {
+		code.VerifiedAt = &verifiedAt.Time
+	}
+	if overriddenBy.Valid {
+		code.OverriddenBy = overriddenBy.String
+	}
+	if overrideReason.Valid {
+		code.OverrideReason = overrideReason.String
+	}
+
+	return code, nil
+}
@@ -0,0 +1,287 @@
+package bd
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+)
+
+// CreateHub inserta un hub con su cobertura. El código es único.
+func CreateHub(hub *models.Hub) error {
+	fmt.Printf("CreateHub -> Code: %s\n", hub.Code)
+
+	err := DbConnect()
+	if err != nil {
+		return err
+	}
+
+	var count int
+	err = Db.QueryRow(`SELECT COUNT(*) FROM hubs WHERE code = ?`, hub.Code).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("ya existe un hub con ese código")
+	}
+
+	tx, err := Db.Begin()
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO hubs (code, name, city_id, active, created_by)
+		VALUES (?, ?, ?, TRUE, ?)
+	`, hub.Code, hub.Name, hub.CityID, hub.CreatedBy)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	hub.HubID, err = result.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := insertHubCoverage(tx, hub.HubID, hub.Coverage); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	hub.Active = true
+	hub.CreatedAt = time.Now()
+
+	return tx.Commit()
+}
+
+// UpdateHub actualiza nombre, estado y cobertura (reemplazándola) del hub
+func UpdateHub(hub models.Hub) error {
+	fmt.Printf("UpdateHub -> HubID: %d\n", hub.HubID)
+
+	err := DbConnect()
+	if err != nil {
+		return err
+	}
+
+	tx, err := Db.Begin()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE hubs SET name = ?, active = ? WHERE hub_id = ?`, hub.Name, hub.Active, hub.HubID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.Exec(`DELETE FROM hub_coverage WHERE hub_id = ?`, hub.HubID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := insertHubCoverage(tx, hub.HubID, hub.Coverage); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func insertHubCoverage(tx *sql.Tx, hubID int64, coverage []models.HubCoverage) error {
+	for _, c := range coverage {
+		_, err := tx.Exec(`
+			INSERT INTO hub_coverage (hub_id, city_id, department_id)
+			VALUES (?, ?, ?)
+		`, hubID, nullIfZero(c.CityID), nullIfZero(c.DepartmentID))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hubColumns columnas de hubs con la ciudad sede y los usuarios asignados (alias h, c)
+const hubColumns = `
+			h.hub_id,
+			h.code,
+			h.name,
+			h.city_id,
+			c.name AS city_name,
+			h.active,
+			(SELECT COUNT(*) FROM users u WHERE u.hub_id = h.hub_id) AS users,
+			h.created_by,
+			h.created_at
+		FROM hubs h
+		LEFT JOIN cities c ON h.city_id = c.id`
+
+func scanHub(row rowScanner) (models.Hub, error) {
+	var h models.Hub
+	var cityName, createdBy sql.NullString
+
+	err := row.Scan(&h.HubID, &h.Code, &h.Name, &h.CityID, &cityName, &h.Active, &h.Users, &createdBy, &h.CreatedAt)
+	if err != nil {
+		return h, err
+	}
+
+	h.CityName = cityName.String
+	h.CreatedBy = createdBy.String
+	return h, nil
+}
+
+// GetHubs lista los hubs con su cobertura
+func GetHubs() ([]models.Hub, error) {
+	fmt.Println("GetHubs")
+
+	var hubs []models.Hub
+
+	err := DbConnect()
+	if err != nil {
+		return hubs, err
+	}
+
+	rows, err := Db.Query(`SELECT` + hubColumns + ` ORDER BY h.code`)
+	if err != nil {
+		return hubs, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		h, err := scanHub(rows)
+		if err != nil {
+			return hubs, err
+		}
+		hubs = append(hubs, h)
+	}
+	rows.Close()
+
+	coverage, err := getHubCoverage(0)
+	if err != nil {
+		return hubs, err
+	}
+	for i := range hubs {
+		hubs[i].Coverage = coverage[hubs[i].HubID]
+	}
+
+	return hubs, nil
+}
+
+// GetHubByID obtiene un hub con su cobertura
+func GetHubByID(hubID int64) (models.Hub, error) {
+	fmt.Printf("GetHubByID -> HubID: %d\n", hubID)
+
+	err := DbConnect()
+	if err != nil {
+		return models.Hub{}, err
+	}
+
+	h, err := scanHub(Db.QueryRow(`SELECT`+hubColumns+` WHERE h.hub_id = ?`, hubID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return h, fmt.Errorf("Hub no encontrado")
+		}
+		return h, err
+	}
+
+	coverage, err := getHubCoverage(hubID)
+	if err != nil {
+		return h, err
+	}
+	h.Coverage = coverage[hubID]
+
+	return h, nil
+}
+
+// getHubCoverage cobertura por hub (hubID 0: todos los hubs)
+func getHubCoverage(hubID int64) (map[int64][]models.HubCoverage, error) {
+	coverage := map[int64][]models.HubCoverage{}
+
+	query := `
+		SELECT hc.hub_id, hc.city_id, c.name, hc.department_id, d.name
+		FROM hub_coverage hc
+		LEFT JOIN cities c ON hc.city_id = c.id
+		LEFT JOIN departments d ON hc.department_id = d.id
+	`
+	var args []interface{}
+	if hubID != 0 {
+		query += " WHERE hc.hub_id = ?"
+		args = append(args, hubID)
+	}
+	query += " ORDER BY hc.hub_id, d.name, c.name"
+
+	rows, err := Db.Query(query, args...)
+	if err != nil {
+		return coverage, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var cityID, departmentID sql.NullInt64
+		var cityName, departmentName sql.NullString
+		if err := rows.Scan(&id, &cityID, &cityName, &departmentID, &departmentName); err != nil {
+			return coverage, err
+		}
+		coverage[id] = append(coverage[id], models.HubCoverage{
+			CityID:         cityID.Int64,
+			CityName:       cityName.String,
+			DepartmentID:   departmentID.Int64,
+			DepartmentName: departmentName.String,
+		})
+	}
+
+	return coverage, nil
+}
+
+// ==========================================
+// ALCANCE POR HUB EN CONSULTAS
+// ==========================================
+
+// hubCoversCity condición: el hub (alias h) cubre la ciudad (alias de
+// cities), directamente o por su departamento
+func hubCoversCity(city string) string {
+	return fmt.Sprintf(`EXISTS (
+			SELECT 1 FROM hub_coverage hc
+			WHERE hc.hub_id = h.hub_id
+			AND (hc.city_id = %[1]s.id OR hc.department_id = %[1]s.department_id))`, city)
+}
+
+// hubCondition filtra el alias h por el alcance (vacío en el consolidado)
+func hubCondition(scope models.HubScope) (string, []interface{}) {
+	if scope.Consolidated() {
+		return "", nil
+	}
+	return "AND h.hub_id = ?", []interface{}{*scope.HubID}
+}
+
+// Ciudades con las que una guía (alias sg) pertenece a un hub: el origen
+// para ventas y recogidas; origen o destino para la operación
+const (
+	guideOriginCity = "sg.origin_city_id"
+	guideAnyCity    = "sg.origin_city_id, sg.destination_city_id"
+)
+
+// guideHubCondition condición para guías (alias sg) con alguna de las
+// ciudades dadas cubierta por el hub del alcance (vacía en el consolidado)
+func guideHubCondition(scope models.HubScope, cities string) (string, []interface{}) {
+	if scope.Consolidated() {
+		return "", nil
+	}
+	return fmt.Sprintf(`AND EXISTS (
+			SELECT 1 FROM hub_coverage hc
+			JOIN cities gc ON gc.id IN (%s)
+			WHERE hc.hub_id = ?
+			AND (hc.city_id = gc.id OR hc.department_id = gc.department_id))`, cities), []interface{}{*scope.HubID}
+}
+
+// userHubCondition condición para usuarios (alias dado) del hub del alcance
+// (vacía en el consolidado)
+func userHubCondition(scope models.HubScope, alias string) (string, []interface{}) {
+	if scope.Consolidated() {
+		return "", nil
+	}
+	return fmt.Sprintf("AND %s.hub_id = ?", alias), []interface{}{*scope.HubID}
+}
@@ -0,0 +1,265 @@
+package bd
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+)
+
+// CreateInventoryCount abre un conteo físico. Solo puede haber un conteo
+// abierto por bodega.
+func CreateInventoryCount(count *models.InventoryCount) error {
+	fmt.Printf("CreateInventoryCount -> WarehouseID: %d\n", count.WarehouseID)
+
+	err := DbConnect()
+	if err != nil {
+		return err
+	}
+
+	tx, err := Db.Begin()
+	if err != nil {
+		return err
+	}
+
+	var open int
+	err = tx.QueryRow(`
+		SELECT COUNT(*) FROM inventory_counts
+		WHERE warehouse_id = ? AND status = 'OPEN'
+		FOR UPDATE
+	`, count.WarehouseID).Scan(&open)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if open > 0 {
+		tx.Rollback()
+		return fmt.Errorf("ya hay un conteo abierto en la bodega")
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO inventory_counts (warehouse_id, status, notes, started_by)
+		VALUES (?, 'OPEN', ?, ?)
+	`, count.WarehouseID, nullIfEmpty(count.Notes), count.StartedBy)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	count.CountID, err = result.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	count.Status = models.InventoryCountOpen
+	count.StartedAt = time.Now()
+
+	return tx.Commit()
+}
+
+// GetInventoryCount obtiene un conteo
+func GetInventoryCount(countID int64) (models.InventoryCount, error) {
+	fmt.Printf("GetInventoryCount -> CountID: %d\n", countID)
+
+	var count models.InventoryCount
+
+	err := DbConnect()
+	if err != nil {
+		return count, err
+	}
+
+	var notes, closedBy sql.NullString
+	var closedAt sql.NullTime
+
+	err = Db.QueryRow(`
+		SELECT count_id, warehouse_id, status, notes, applied, started_by, started_at, closed_by, closed_at
+		FROM inventory_counts
+		WHERE count_id = ?
+	`, countID).Scan(&count.CountID, &count.WarehouseID, &count.Status, &notes, &count.Applied,
+		&count.StartedBy, &count.StartedAt, &closedBy, &closedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return count, fmt.Errorf("Conteo no encontrado")
+		}
+		return count, err
+	}
+
+	count.Notes = notes.String
+	count.ClosedBy = closedBy.String
+	count.ClosedAt = nullTimePtr(closedAt)
+	return count, nil
+}
+
+// AddInventoryCountItems registra las guías y piezas escaneadas en el conteo
+func AddInventoryCountItems(items []models.InventoryCountItem) error {
+	fmt.Printf("AddInventoryCountItems -> Items: %d\n", len(items))
+
+	err := DbConnect()
+	if err != nil {
+		return err
+	}
+
+	tx, err := Db.Begin()
+	if err != nil {
+		return err
+	}
+
+	for i := range items {
+		item := &items[i]
+		result, err := tx.Exec(`
+			INSERT INTO inventory_count_items (count_id, guide_id, piece_number, bin_id, code, scanned_by)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, item.CountID, item.GuideID, item.PieceNumber, item.BinID, item.Code, item.ScannedBy)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		item.ItemID, err = result.LastInsertId()
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		item.ScannedAt = time.Now()
+	}
+
+	return tx.Commit()
+}
+
+// GetInventoryCountItems lista lo escaneado en el conteo, en orden de escaneo
+func GetInventoryCountItems(countID int64) ([]models.InventoryCountItem, error) {
+	fmt.Printf("GetInventoryCountItems -> CountID: %d\n", countID)
+
+	var items []models.InventoryCountItem
+
+	err := DbConnect()
+	if err != nil {
+		return items, err
+	}
+
+	rows, err := Db.Query(`
+		SELECT i.item_id, i.count_id, i.guide_id, i.piece_number, i.bin_id, wb.code, i.code, i.scanned_by, i.scanned_at
+		FROM inventory_count_items i
+		JOIN warehouse_bins wb ON wb.bin_id = i.bin_id
+		WHERE i.count_id = ?
+		ORDER BY i.item_id
+	`, countID)
+	if err != nil {
+		return items, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item models.InventoryCountItem
+		err := rows.Scan(&item.ItemID, &item.CountID, &item.GuideID, &item.PieceNumber, &item.BinID,
+			&item.BinCode, &item.Code, &item.ScannedBy, &item.ScannedAt)
+		if err != nil {
+			return items, err
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// GetExpectedInventory lo que debería estar en la bodega: las piezas de
+// guías IN_WAREHOUSE ubicadas en sus bins y las que están sin ubicar
+// (BinID 0). Las guías sin detalle de piezas cuentan como pieza 0.
+func GetExpectedInventory(warehouseID int64) ([]models.StockItem, error) {
+	fmt.Printf("GetExpectedInventory -> WarehouseID: %d\n", warehouseID)
+
+	var items []models.StockItem
+
+	err := DbConnect()
+	if err != nil {
+		return items, err
+	}
+
+	rows, err := Db.Query(`
+		SELECT
+			sg.guide_id,
+			sg.guide_number,
+			gi.piece_number,
+			gi.piece_code,
+			sg.current_status,
+			wb.warehouse_id,
+			wb.bin_id,
+			wb.code,
+			ws.located_by,
+			ws.located_at
+		FROM shipping_guides sg
+		JOIN (
+			SELECT gp.guide_id, gp.piece_number, gp.piece_code
+			FROM guide_pieces gp
+			JOIN shipping_guides g ON g.guide_id = gp.guide_id AND g.current_status = 'IN_WAREHOUSE'
+			UNION ALL
+			SELECT g.guide_id, 0, NULL
+			FROM shipping_guides g
+			WHERE g.current_status = 'IN_WAREHOUSE'
+			AND NOT EXISTS (SELECT 1 FROM guide_pieces gp WHERE gp.guide_id = g.guide_id)
+		) gi ON gi.guide_id = sg.guide_id
+		LEFT JOIN warehouse_stock ws ON ws.guide_id = gi.guide_id AND ws.piece_number = gi.piece_number
+		LEFT JOIN warehouse_bins wb ON wb.bin_id = ws.bin_id
+		WHERE ws.stock_id IS NULL OR wb.warehouse_id = ?
+		ORDER BY wb.code, sg.guide_id, gi.piece_number
+	`, warehouseID)
+	if err != nil {
+		fmt.Printf("GetExpectedInventory - Error en query: %v\n", err)
+		return items, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item models.StockItem
+		var guideNumber, pieceCode, binCode, locatedBy sql.NullString
+		var warehouse, binID sql.NullInt64
+		var locatedAt sql.NullTime
+
+		err := rows.Scan(&item.GuideID, &guideNumber, &item.PieceNumber, &pieceCode, &item.CurrentStatus,
+			&warehouse, &binID, &binCode, &locatedBy, &locatedAt)
+		if err != nil {
+			return items, err
+		}
+
+		item.GuideNumber = guideNumber.String
+		item.PieceCode = pieceCode.String
+		item.WarehouseID = warehouse.Int64
+		item.BinID = binID.Int64
+		item.BinCode = binCode.String
+		item.LocatedBy = locatedBy.String
+		item.LocatedAt = nullTimePtr(locatedAt)
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// CloseInventoryCount cierra un conteo abierto
+func CloseInventoryCount(countID int64, applied bool, userUUID string) error {
+	fmt.Printf("CloseInventoryCount -> CountID: %d, Applied: %t\n", countID, applied)
+
+	err := DbConnect()
+	if err != nil {
+		return err
+	}
+
+	result, err := Db.Exec(`
+		UPDATE inventory_counts
+		SET status = 'CLOSED', applied = ?, closed_by = ?, closed_at = NOW()
+		WHERE count_id = ? AND status = 'OPEN'
+	`, applied, userUUID, countID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("el conteo ya está cerrado")
+	}
+
+	return nil
+}
@@ -17,7 +17,6 @@ func GetAllDepartments() ([]models.Department, error) {
 	if err != nil {
 		return departments, err
 	}
-	defer Db.Close()
 
 	query := `
 		SELECT id, dane_code, name
@@ -53,7 +52,6 @@ func GetAllCities() ([]models.City, error) {
 	if err != nil {
 		return cities, err
 	}
-	defer Db.Close()
 
 	query := `
 		SELECT 
@@ -105,7 +103,6 @@ func GetCitiesByDepartment(departmentID int64) ([]models.City, error) {
 	if err != nil {
 		return cities, err
 	}
-	defer Db.Close()
 
 	query := `
 		SELECT 
@@ -157,7 +154,6 @@ func GetCityByID(cityID int64) (models.City, error) {
 	if err != nil {
 		return city, err
 	}
-	defer Db.Close()
 
 	query := `
 		SELECT 
@@ -202,7 +198,6 @@ func SearchCities(searchTerm string) ([]models.City, error) {
 	if err != nil {
 		return cities, err
 	}
-	defer Db.Close()
 
 	searchPattern := "%" + searchTerm + "%"
 	query := `
@@ -257,7 +252,6 @@ func DepartmentExists(departmentID int64) bool {
 	if err != nil {
 		return false
 	}
-	defer Db.Close()
 
 	query := `SELECT COUNT(*) FROM departments WHERE id = ?`
 
@@ -278,7 +272,6 @@ func CityExists(cityID int64) bool {
 	if err != nil {
 		return false
 	}
-	defer Db.Close()
 
 	query := `SELECT COUNT(*) FROM cities WHERE id = ?`
 
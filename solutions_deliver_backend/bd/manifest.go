@@ -0,0 +1,584 @@
+package bd
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+)
+
+// CreateManifest inserta un manifiesto abierto
+func CreateManifest(manifest *models.Manifest) error {
+	fmt.Printf("CreateManifest -> Route: %s, Date: %s\n", manifest.Route, manifest.DispatchDate.Format("2006-01-02"))
+
+	err := DbConnect()
+	if err != nil {
+		return err
+	}
+
+	result, err := Db.Exec(`
+		INSERT INTO manifests
+		(route, dispatch_date, vehicle_plate, driver_name, driver_document, driver_phone, status, notes, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, 'OPEN', ?, ?)
+	`, manifest.Route, manifest.DispatchDate.Format("2006-01-02"), manifest.VehiclePlate, manifest.DriverName,
+		manifest.DriverDocument, nullIfEmpty(manifest.DriverPhone), nullIfEmpty(manifest.Notes), manifest.CreatedBy)
+	if err != nil {
+		return err
+	}
+
+	manifest.ManifestID, err = result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	manifest.Status = models.ManifestOpen
+	manifest.CreatedAt = time.Now()
+
+	return nil
+}
+
+// manifestColumns columnas de manifests con los totales de sus guías (alias m, t)
+const manifestColumns = `
+			m.manifest_id,
+			m.route,
+			m.dispatch_date,
+			m.vehicle_plate,
+			m.driver_name,
+			m.driver_document,
+			m.driver_phone,
+			m.status,
+			m.notes,
+			m.receive_notes,
+			m.rndc_manifest_id,
+			COALESCE(t.guides, 0),
+			COALESCE(t.pieces, 0),
+			COALESCE(t.weight_kg, 0),
+			COALESCE(t.declared_value, 0),
+			COALESCE(t.cod_amount, 0),
+			m.created_by,
+			m.created_at,
+			m.closed_by,
+			m.closed_at,
+			m.received_by,
+			m.received_at
+		FROM manifests m
+		LEFT JOIN (
+			SELECT manifest_id,
+				COUNT(*) AS guides,
+				SUM(pieces) AS pieces,
+				SUM(weight_kg) AS weight_kg,
+				SUM(declared_value) AS declared_value,
+				SUM(cod_amount) AS cod_amount
+			FROM manifest_guides
+			GROUP BY manifest_id
+		) t ON t.manifest_id = m.manifest_id`
+
+func scanManifest(row rowScanner) (models.Manifest, error) {
+	var m models.Manifest
+	var driverPhone, notes, receiveNotes, rndcManifestID, closedBy, receivedBy sql.NullString
+	var closedAt, receivedAt sql.NullTime
+
+	err := row.Scan(
+		&m.ManifestID,
+		&m.Route,
+		&m.DispatchDate,
+		&m.VehiclePlate,
+		&m.DriverName,
+		&m.DriverDocument,
+		&driverPhone,
+		&m.Status,
+		&notes,
+		&receiveNotes,
+		&rndcManifestID,
+		&m.Totals.Guides,
+		&m.Totals.Pieces,
+		&m.Totals.WeightKg,
+		&m.Totals.DeclaredValue,
+		&m.Totals.CODToCollect,
+		&m.CreatedBy,
+		&m.CreatedAt,
+		&closedBy,
+		&closedAt,
+		&receivedBy,
+		&receivedAt,
+	)
+	if err != nil {
+		return m, err
+	}
+
+	m.DriverPhone = driverPhone.String
+	m.Notes = notes.String
+	m.ReceiveNotes = receiveNotes.String
+	m.RNDCManifestID = rndcManifestID.String
+	m.ClosedBy = closedBy.String
+	m.ClosedAt = nullTimePtr(closedAt)
+	m.ReceivedBy = receivedBy.String
+	m.ReceivedAt = nullTimePtr(receivedAt)
+	return m, nil
+}
+
+// GetManifests lista los manifiestos con sus totales, del más reciente al más antiguo
+func GetManifests(filters models.ManifestFilters) ([]models.Manifest, error) {
+	fmt.Printf("GetManifests -> Status: %s, Route: %s\n", filters.Status, filters.Route)
+
+	var manifests []models.Manifest
+
+	err := DbConnect()
+	if err != nil {
+		return manifests, err
+	}
+
+	var where []string
+	var args []interface{}
+	if filters.Status != "" {
+		where = append(where, "m.status = ?")
+		args = append(args, filters.Status)
+	}
+	if filters.Route != "" {
+		where = append(where, "m.route = ?")
+		args = append(args, filters.Route)
+	}
+	if filters.DispatchDate != nil {
+		where = append(where, "m.dispatch_date = ?")
+		args = append(args, filters.DispatchDate.Format("2006-01-02"))
+	}
+
+	query := `SELECT` + manifestColumns
+	if len(where) > 0 {
+		query += "\n\t\tWHERE " + strings.Join(where, " AND ")
+	}
+	query += "\n\t\tORDER BY m.dispatch_date DESC, m.manifest_id DESC"
+
+	rows, err := Db.Query(query, args...)
+	if err != nil {
+		return manifests, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		m, err := scanManifest(rows)
+		if err != nil {
+			return manifests, err
+		}
+		manifests = append(manifests, m)
+	}
+
+	return manifests, rows.Err()
+}
+
+// GetManifestByID obtiene el manifiesto con sus guías
+func GetManifestByID(manifestID int64) (models.Manifest, error) {
+	fmt.Printf("GetManifestByID -> ManifestID: %d\n", manifestID)
+
+	var manifest models.Manifest
+
+	err := DbConnect()
+	if err != nil {
+		return manifest, err
+	}
+
+	manifest, err = scanManifest(Db.QueryRow(`SELECT`+manifestColumns+`
+		WHERE m.manifest_id = ?
+	`, manifestID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return manifest, fmt.Errorf("Manifiesto no encontrado")
+		}
+		return manifest, err
+	}
+
+	rows, err := Db.Query(`
+		SELECT
+			mg.manifest_id,
+			mg.guide_id,
+			sg.guide_number,
+			dc.name,
+			receiver.full_name,
+			mg.payment_method,
+			mg.pieces,
+			mg.weight_kg,
+			mg.declared_value,
+			mg.cod_amount,
+			mg.status,
+			mg.received_pieces,
+			mg.added_by,
+			mg.added_at
+		FROM manifest_guides mg
+		JOIN shipping_guides sg ON sg.guide_id = mg.guide_id
+		LEFT JOIN cities dc ON dc.id = sg.destination_city_id
+		LEFT JOIN guide_parties receiver ON receiver.guide_id = sg.guide_id AND receiver.party_role = 'RECEIVER'
+		WHERE mg.manifest_id = ?
+		ORDER BY mg.added_at, mg.guide_id
+	`, manifestID)
+	if err != nil {
+		return manifest, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var g models.ManifestGuide
+		var guideNumber, cityName, receiverName sql.NullString
+		var addedAt time.Time
+
+		err := rows.Scan(&g.ManifestID, &g.GuideID, &guideNumber, &cityName, &receiverName, &g.PaymentMethod,
+			&g.Pieces, &g.WeightKg, &g.DeclaredValue, &g.CODAmount, &g.Status, &g.ReceivedPieces, &g.AddedBy, &addedAt)
+		if err != nil {
+			return manifest, err
+		}
+
+		g.GuideNumber = guideNumber.String
+		g.DestinationCityName = cityName.String
+		g.ReceiverName = receiverName.String
+		g.Route = manifest.Route
+		g.AddedAt = &addedAt
+		manifest.Guides = append(manifest.Guides, g)
+	}
+
+	return manifest, rows.Err()
+}
+
+// GetPendingDispatchGuides guías en bodega con destino fuera de Bogotá que
+// no están en un manifiesto abierto o en ruta ni fueron recibidas de uno,
+// ni tienen un traslado activo o ya llegaron trasladadas a su ciudad.
+// La ruta es la de la tarifa con que se cotizó la guía (o la vigente al
+// crearla); route vacío: todas las rutas.
+func GetPendingDispatchGuides(route string) ([]models.ManifestGuide, error) {
+	fmt.Printf("GetPendingDispatchGuides -> Route: %s\n", route)
+
+	var guides []models.ManifestGuide
+
+	err := DbConnect()
+	if err != nil {
+		return guides, err
+	}
+
+	rows, err := Db.Query(`
+		SELECT * FROM (
+			SELECT
+				sg.guide_id,
+				sg.guide_number,
+				COALESCE(r.route, (
+					SELECT cr.route FROM shipping_rates cr
+					WHERE cr.origin_city_id = sg.origin_city_id
+					AND cr.destination_city_id = sg.destination_city_id
+					AND cr.status = 'ACTIVE'
+					AND cr.effective_date <= DATE(sg.created_at)
+					ORDER BY cr.effective_date DESC, cr.id DESC
+					LIMIT 1
+				)) AS route,
+				dc.name AS destination_city_name,
+				receiver.full_name AS receiver_name,
+				sg.payment_method,
+				COALESCE(NULLIF((SELECT COUNT(*) FROM guide_pieces gp WHERE gp.guide_id = sg.guide_id), 0), p.pieces, 1) AS pieces,
+				COALESCE((SELECT SUM(gp.weight_kg) FROM guide_pieces gp WHERE gp.guide_id = sg.guide_id), p.weight_kg, 0) AS weight_kg,
+				sg.declared_value,
+				CASE WHEN sg.payment_method = 'COD' THEN sg.price ELSE 0 END AS cod_amount
+			FROM shipping_guides sg
+			LEFT JOIN shipping_rates r ON r.id = sg.rate_id
+			LEFT JOIN cities dc ON dc.id = sg.destination_city_id
+			LEFT JOIN guide_parties receiver ON receiver.guide_id = sg.guide_id AND receiver.party_role = 'RECEIVER'
+			LEFT JOIN packages p ON p.guide_id = sg.guide_id
+			WHERE sg.current_status = 'IN_WAREHOUSE'
+			AND UPPER(dc.name) <> 'BOGOTÁ D.C.'
+			AND NOT EXISTS (
+				SELECT 1 FROM manifest_guides mg
+				JOIN manifests m ON m.manifest_id = mg.manifest_id
+				WHERE mg.guide_id = sg.guide_id
+				AND (m.status IN ('OPEN', 'CLOSED') OR mg.status IN ('RECEIVED', 'PARTIAL'))
+			)
+			AND NOT EXISTS (
+				SELECT 1 FROM delivery_assignments da
+				LEFT JOIN warehouses w ON w.warehouse_id = da.destination_warehouse_id
+				WHERE da.guide_id = sg.guide_id
+				AND da.assignment_type = 'TRANSFER'
+				AND (da.status IN ('PENDING', 'IN_PROGRESS')
+					OR (da.status = 'COMPLETED' AND w.city_id = sg.destination_city_id))
+			)
+		) pending
+		WHERE (? = '' OR route = ?)
+		ORDER BY route, guide_id
+	`, route, route)
+	if err != nil {
+		return guides, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var g models.ManifestGuide
+		var guideNumber, guideRoute, cityName, receiverName sql.NullString
+
+		err := rows.Scan(&g.GuideID, &guideNumber, &guideRoute, &cityName, &receiverName, &g.PaymentMethod,
+			&g.Pieces, &g.WeightKg, &g.DeclaredValue, &g.CODAmount)
+		if err != nil {
+			return guides, err
+		}
+
+		g.GuideNumber = guideNumber.String
+		g.Route = guideRoute.String
+		g.DestinationCityName = cityName.String
+		g.ReceiverName = receiverName.String
+		guides = append(guides, g)
+	}
+
+	return guides, rows.Err()
+}
+
+// GetActiveManifestByGuide manifiesto abierto o en ruta que tiene la guía (0: ninguno)
+func GetActiveManifestByGuide(guideID int64) (int64, error) {
+	fmt.Printf("GetActiveManifestByGuide -> GuideID: %d\n", guideID)
+
+	err := DbConnect()
+	if err != nil {
+		return 0, err
+	}
+
+	var manifestID int64
+	err = Db.QueryRow(`
+		SELECT m.manifest_id
+		FROM manifest_guides mg
+		JOIN manifests m ON m.manifest_id = mg.manifest_id
+		WHERE mg.guide_id = ?
+		AND m.status IN ('OPEN', 'CLOSED')
+		LIMIT 1
+	`, guideID).Scan(&manifestID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	return manifestID, nil
+}
+
+// lockManifest bloquea el manifiesto dentro de la transacción y retorna su estado
+func lockManifest(tx *sql.Tx, manifestID int64) (models.ManifestStatus, error) {
+	var status models.ManifestStatus
+	err := tx.QueryRow(`SELECT status FROM manifests WHERE manifest_id = ? FOR UPDATE`, manifestID).Scan(&status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return status, fmt.Errorf("Manifiesto no encontrado")
+		}
+		return status, err
+	}
+	return status, nil
+}
+
+// AddManifestGuides carga guías a un manifiesto abierto. Una guía solo puede
+// estar en un manifiesto abierto o en ruta.
+func AddManifestGuides(manifestID int64, guides []models.ManifestGuide, userUUID string) error {
+	fmt.Printf("AddManifestGuides -> ManifestID: %d, Guides: %d\n", manifestID, len(guides))
+
+	err := DbConnect()
+	if err != nil {
+		return err
+	}
+
+	tx, err := Db.Begin()
+	if err != nil {
+		return err
+	}
+
+	status, err := lockManifest(tx, manifestID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if status != models.ManifestOpen {
+		tx.Rollback()
+		return fmt.Errorf("el manifiesto no está abierto")
+	}
+
+	for _, g := range guides {
+		var count int
+		err = tx.QueryRow(`
+			SELECT COUNT(*)
+			FROM manifest_guides mg
+			JOIN manifests m ON m.manifest_id = mg.manifest_id
+			WHERE mg.guide_id = ?
+			AND m.status IN ('OPEN', 'CLOSED')
+		`, g.GuideID).Scan(&count)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		if count > 0 {
+			tx.Rollback()
+			return fmt.Errorf("la guía ya está en un manifiesto")
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO manifest_guides
+			(manifest_id, guide_id, payment_method, pieces, weight_kg, declared_value, cod_amount, status, added_by)
+			VALUES (?, ?, ?, ?, ?, ?, ?, 'LOADED', ?)
+		`, manifestID, g.GuideID, g.PaymentMethod, g.Pieces, g.WeightKg, g.DeclaredValue, g.CODAmount, userUUID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// manifestGuideIDs guías del manifiesto con su número (para los mensajes)
+func manifestGuideIDs(tx *sql.Tx, manifestID int64) (map[int64]string, []int64, error) {
+	rows, err := tx.Query(`
+		SELECT mg.guide_id, COALESCE(sg.guide_number, '')
+		FROM manifest_guides mg
+		JOIN shipping_guides sg ON sg.guide_id = mg.guide_id
+		WHERE mg.manifest_id = ?
+		ORDER BY mg.guide_id
+	`, manifestID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	numbers := map[int64]string{}
+	var ids []int64
+	for rows.Next() {
+		var guideID int64
+		var number string
+		if err := rows.Scan(&guideID, &number); err != nil {
+			return nil, nil, err
+		}
+		if number == "" {
+			number = fmt.Sprintf("%d", guideID)
+		}
+		numbers[guideID] = number
+		ids = append(ids, guideID)
+	}
+	return numbers, ids, rows.Err()
+}
+
+// CloseManifest despacha el manifiesto: en una sola transacción pasa todas
+// sus guías a IN_ROUTE (con su historial), las saca de los bins de la
+// bodega y marca el manifiesto CLOSED. Si alguna guía no se puede
+// despachar no cambia nada.
+func CloseManifest(manifestID int64, userUUID string) error {
+	fmt.Printf("CloseManifest -> ManifestID: %d\n", manifestID)
+
+	err := DbConnect()
+	if err != nil {
+		return err
+	}
+
+	tx, err := Db.Begin()
+	if err != nil {
+		return err
+	}
+
+	status, err := lockManifest(tx, manifestID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if status != models.ManifestOpen {
+		tx.Rollback()
+		return fmt.Errorf("el manifiesto no está abierto")
+	}
+
+	numbers, guideIDs, err := manifestGuideIDs(tx, manifestID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if len(guideIDs) == 0 {
+		tx.Rollback()
+		return fmt.Errorf("el manifiesto no tiene guías")
+	}
+
+	notes := fmt.Sprintf("Despachada en el manifiesto %d", manifestID)
+	for _, guideID := range guideIDs {
+		err = updateGuideStatusTx(tx, guideID, models.StatusInRoute, "", notes, userUUID)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("la guía %s no se puede despachar: %s", numbers[guideID], err.Error())
+		}
+
+		_, err = checkOutGuideItemsTx(tx, guideID, nil, notes, userUUID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`
+		UPDATE manifests
+		SET status = 'CLOSED', closed_by = ?, closed_at = NOW()
+		WHERE manifest_id = ?
+	`, userUUID, manifestID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ReceiveManifest registra la recepción en destino. guides trae el
+// resultado de cada guía del manifiesto: las RECEIVED y PARTIAL pasan a
+// IN_WAREHOUSE; las MISSING siguen IN_ROUTE. Todo en una transacción.
+func ReceiveManifest(manifestID int64, guides []models.ManifestGuide, notes string, userUUID string) error {
+	fmt.Printf("ReceiveManifest -> ManifestID: %d, Guides: %d\n", manifestID, len(guides))
+
+	err := DbConnect()
+	if err != nil {
+		return err
+	}
+
+	tx, err := Db.Begin()
+	if err != nil {
+		return err
+	}
+
+	status, err := lockManifest(tx, manifestID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if status != models.ManifestClosed {
+		tx.Rollback()
+		return fmt.Errorf("el manifiesto no está en ruta")
+	}
+
+	for _, g := range guides {
+		_, err = tx.Exec(`
+			UPDATE manifest_guides
+			SET status = ?, received_pieces = ?
+			WHERE manifest_id = ? AND guide_id = ?
+		`, g.Status, g.ReceivedPieces, manifestID, g.GuideID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if g.Status != models.ManifestGuideReceived && g.Status != models.ManifestGuidePartial {
+			continue
+		}
+
+		historyNotes := fmt.Sprintf("Recibida del manifiesto %d", manifestID)
+		if g.Status == models.ManifestGuidePartial {
+			historyNotes += fmt.Sprintf(" (parcial: %d de %d piezas)", g.ReceivedPieces, g.Pieces)
+		}
+		err = updateGuideStatusTx(tx, g.GuideID, models.StatusInWarehouse, "", historyNotes, userUUID)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("la guía %s no se puede recibir: %s", g.GuideNumber, err.Error())
+		}
+	}
+
+	_, err = tx.Exec(`
+		UPDATE manifests
+		SET status = 'RECEIVED', received_by = ?, received_at = NOW(), receive_notes = ?
+		WHERE manifest_id = ?
+	`, userUUID, nullIfEmpty(notes), manifestID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
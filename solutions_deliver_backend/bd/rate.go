@@ -0,0 +1,599 @@
+package bd
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+)
+
+// rateColumns columnas de shipping_rates con los nombres de ciudades (alias r, oc, dc)
+const rateColumns = `
+			r.id,
+			r.origin_city_id,
+			oc.name AS origin_city_name,
+			r.destination_city_id,
+			dc.name AS destination_city_name,
+			r.route,
+			r.travel_frequency,
+			r.min_dispatch_kg,
+			r.price_per_kg,
+			r.min_value,
+			r.effective_date,
+			r.status,
+			r.retired_at,
+			r.created_by,
+			r.created_at,
+			r.updated_at
+		FROM shipping_rates r
+		LEFT JOIN cities oc ON r.origin_city_id = oc.id
+		LEFT JOIN cities dc ON r.destination_city_id = dc.id`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanRate(row rowScanner) (models.ShippingRate, error) {
+	var rate models.ShippingRate
+	var originName, destinationName, createdBy sql.NullString
+	var retiredAt sql.NullTime
+
+	err := row.Scan(
+		&rate.ID,
+		&rate.OriginCityID,
+		&originName,
+		&rate.DestinationCityID,
+		&destinationName,
+		&rate.Route,
+		&rate.TravelFrequency,
+		&rate.MinDispatchKg,
+		&rate.PricePerKg,
+		&rate.MinValue,
+		&rate.EffectiveDate,
+		&rate.Status,
+		&retiredAt,
+		&createdBy,
+		&rate.CreatedAt,
+		&rate.UpdatedAt,
+	)
+	if err != nil {
+		return rate, err
+	}
+
+	rate.OriginCityName = originName.String
+	rate.DestinationCityName = destinationName.String
+	rate.CreatedBy = createdBy.String
+	if retiredAt.Valid {
+		rate.RetiredAt = &retiredAt.Time
+	}
+
+	return rate, nil
+}
+
+// GetShippingRate obtiene la tarifa vigente entre dos ciudades en la fecha dada
+// (la ACTIVE de mayor effective_date que ya esté en vigor ese día)
+func GetShippingRate(originCityID, destinationCityID int64, date time.Time) (models.ShippingRate, error) {
+	fmt.Printf("GetShippingRate -> Origin: %d, Destination: %d, Date: %s\n", originCityID, destinationCityID, date.Format("2006-01-02"))
+
+	var rate models.ShippingRate
+
+	err := DbConnect()
+	if err != nil {
+		return rate, err
+	}
+
+	query := `
+		SELECT` + rateColumns + `
+		WHERE r.origin_city_id = ?
+		AND r.destination_city_id = ?
+		AND r.status = 'ACTIVE'
+		AND r.effective_date <= ?
+		ORDER BY r.effective_date DESC, r.id DESC
+		LIMIT 1
+	`
+
+	rate, err = scanRate(Db.QueryRow(query, originCityID, destinationCityID, date.Format("2006-01-02")))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return rate, fmt.Errorf("no hay tarifa para la ruta")
+		}
+		return rate, err
+	}
+
+	return rate, nil
+}
+
+// GetRatesByFilters lista tarifas (todas las versiones) aplicando filtros
+func GetRatesByFilters(filters models.RateFilters) ([]models.ShippingRate, int, error) {
+	fmt.Println("GetRatesByFilters")
+
+	var rates []models.ShippingRate
+	var total int
+
+	err := DbConnect()
+	if err != nil {
+		return rates, 0, err
+	}
+
+	var conditions []string
+	var args []interface{}
+
+	if filters.OriginCityID != nil {
+		conditions = append(conditions, "r.origin_city_id = ?")
+		args = append(args, *filters.OriginCityID)
+	}
+
+	if filters.DestinationCityID != nil {
+		conditions = append(conditions, "r.destination_city_id = ?")
+		args = append(args, *filters.DestinationCityID)
+	}
+
+	if filters.Route != "" {
+		conditions = append(conditions, "r.route LIKE ?")
+		args = append(args, "%"+filters.Route+"%")
+	}
+
+	if filters.Status != "" {
+		conditions = append(conditions, "r.status = ?")
+		args = append(args, filters.Status)
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM shipping_rates r %s`, whereClause)
+
+	err = Db.QueryRow(countQuery, args...).Scan(&total)
+	if err != nil {
+		return rates, 0, err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT%s
+		%s
+		ORDER BY r.route, r.origin_city_id, r.destination_city_id, r.effective_date DESC, r.id DESC
+		LIMIT ? OFFSET ?
+	`, rateColumns, whereClause)
+
+	args = append(args, filters.Limit, filters.Offset)
+
+	rows, err := Db.Query(query, args...)
+	if err != nil {
+		return rates, 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		rate, err := scanRate(rows)
+		if err != nil {
+			return rates, 0, err
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, total, nil
+}
+
+// GetRateByID obtiene una tarifa por ID
+func GetRateByID(rateID int64) (models.ShippingRate, error) {
+	fmt.Printf("GetRateByID -> RateID: %d\n", rateID)
+
+	var rate models.ShippingRate
+
+	err := DbConnect()
+	if err != nil {
+		return rate, err
+	}
+
+	query := `
+		SELECT` + rateColumns + `
+		WHERE r.id = ?
+	`
+
+	rate, err = scanRate(Db.QueryRow(query, rateID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return rate, fmt.Errorf("tarifa no encontrada")
+		}
+		return rate, err
+	}
+
+	return rate, nil
+}
+
+// rateVersionExists indica si ya hay otra tarifa activa para la ruta en la misma fecha
+func rateVersionExists(tx *sql.Tx, rate models.ShippingRate) (bool, error) {
+	var count int
+	err := tx.QueryRow(`
+		SELECT COUNT(*)
+		FROM shipping_rates
+		WHERE origin_city_id = ?
+		AND destination_city_id = ?
+		AND effective_date = ?
+		AND status = 'ACTIVE'
+		AND id <> ?
+	`, rate.OriginCityID, rate.DestinationCityID, rate.EffectiveDate.Format("2006-01-02"), rate.ID).Scan(&count)
+	return count > 0, err
+}
+
+// insertRateHistory registra un cambio de tarifa dentro de la transacción.
+// previous es nil al crear y updated es nil al retirar.
+func insertRateHistory(tx *sql.Tx, rateID int64, action models.RateAction, previous, updated *models.ShippingRate, userUUID string) error {
+	var prevPrice, newPrice, prevMin, newMin, prevKg, newKg, prevDate, newDate interface{}
+	if previous != nil {
+		prevPrice, prevMin, prevKg = previous.PricePerKg, previous.MinValue, previous.MinDispatchKg
+		prevDate = previous.EffectiveDate.Format("2006-01-02")
+	}
+	if updated != nil {
+		newPrice, newMin, newKg = updated.PricePerKg, updated.MinValue, updated.MinDispatchKg
+		newDate = updated.EffectiveDate.Format("2006-01-02")
+	}
+
+	_, err := tx.Exec(`
+		INSERT INTO shipping_rate_history
+		(rate_id, action,
+		 previous_price_per_kg, new_price_per_kg,
+		 previous_min_value, new_min_value,
+		 previous_min_dispatch_kg, new_min_dispatch_kg,
+		 previous_effective_date, new_effective_date,
+		 changed_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, rateID, action, prevPrice, newPrice, prevMin, newMin, prevKg, newKg, prevDate, newDate, userUUID)
+	return err
+}
+
+// CreateRate inserta una nueva versión de tarifa y registra el historial
+func CreateRate(rate *models.ShippingRate, userUUID string) error {
+	fmt.Printf("CreateRate -> Origin: %d, Destination: %d, EffectiveDate: %s\n",
+		rate.OriginCityID, rate.DestinationCityID, rate.EffectiveDate.Format("2006-01-02"))
+
+	err := DbConnect()
+	if err != nil {
+		return err
+	}
+
+	tx, err := Db.Begin()
+	if err != nil {
+		return err
+	}
+
+	exists, err := rateVersionExists(tx, *rate)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if exists {
+		tx.Rollback()
+		return fmt.Errorf("ya existe una tarifa para la ruta en esa fecha")
+	}
+
+	err = insertRate(tx, rate, userUUID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// insertRate inserta la tarifa y su entrada CREATED en el historial dentro de
+// la transacción. Asigna ID, Status y CreatedBy.
+func insertRate(tx *sql.Tx, rate *models.ShippingRate, userUUID string) error {
+	result, err := tx.Exec(`
+		INSERT INTO shipping_rates
+		(origin_city_id, destination_city_id, route, travel_frequency,
+		 min_dispatch_kg, price_per_kg, min_value, effective_date, status, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, 'ACTIVE', ?)
+	`,
+		rate.OriginCityID,
+		rate.DestinationCityID,
+		rate.Route,
+		rate.TravelFrequency,
+		rate.MinDispatchKg,
+		rate.PricePerKg,
+		rate.MinValue,
+		rate.EffectiveDate.Format("2006-01-02"),
+		userUUID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rateID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	err = insertRateHistory(tx, rateID, models.RateActionCreated, nil, rate, userUUID)
+	if err != nil {
+		return err
+	}
+
+	rate.ID = rateID
+	rate.Status = models.RateActive
+	rate.CreatedBy = userUUID
+
+	return nil
+}
+
+// ImportRates inserta todas las tarifas de una importación en una sola
+// transacción: si alguna ruta ya tiene versión en esa fecha no se guarda ninguna
+func ImportRates(rates []models.ShippingRate, userUUID string) error {
+	fmt.Printf("ImportRates -> Rates: %d, UserUUID: %s\n", len(rates), userUUID)
+
+	err := DbConnect()
+	if err != nil {
+		return err
+	}
+
+	tx, err := Db.Begin()
+	if err != nil {
+		return err
+	}
+
+	for i := range rates {
+		exists, err := rateVersionExists(tx, rates[i])
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		if exists {
+			tx.Rollback()
+			return fmt.Errorf("ya existe una tarifa para la ruta en esa fecha")
+		}
+
+		err = insertRate(tx, &rates[i], userUUID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetCurrentRates obtiene la tarifa en vigor de cada ruta en la fecha dada
+func GetCurrentRates(date time.Time) ([]models.ShippingRate, error) {
+	fmt.Printf("GetCurrentRates -> Date: %s\n", date.Format("2006-01-02"))
+
+	var rates []models.ShippingRate
+
+	err := DbConnect()
+	if err != nil {
+		return rates, err
+	}
+
+	day := date.Format("2006-01-02")
+	query := `
+		SELECT` + rateColumns + `
+		WHERE r.status = 'ACTIVE'
+		AND r.effective_date <= ?
+		AND NOT EXISTS (
+			SELECT 1
+			FROM shipping_rates n
+			WHERE n.origin_city_id = r.origin_city_id
+			AND n.destination_city_id = r.destination_city_id
+			AND n.status = 'ACTIVE'
+			AND n.effective_date <= ?
+			AND (n.effective_date > r.effective_date
+			     OR (n.effective_date = r.effective_date AND n.id > r.id))
+		)
+		ORDER BY r.route, oc.name, dc.name
+	`
+
+	rows, err := Db.Query(query, day, day)
+	if err != nil {
+		return rates, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		rate, err := scanRate(rows)
+		if err != nil {
+			return rates, err
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, nil
+}
+
+// UpdateRate guarda los cambios de una tarifa y registra los valores anteriores
+func UpdateRate(previous, updated models.ShippingRate, userUUID string) error {
+	fmt.Printf("UpdateRate -> RateID: %d, UserUUID: %s\n", updated.ID, userUUID)
+
+	err := DbConnect()
+	if err != nil {
+		return err
+	}
+
+	tx, err := Db.Begin()
+	if err != nil {
+		return err
+	}
+
+	exists, err := rateVersionExists(tx, updated)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if exists {
+		tx.Rollback()
+		return fmt.Errorf("ya existe una tarifa para la ruta en esa fecha")
+	}
+
+	_, err = tx.Exec(`
+		UPDATE shipping_rates
+		SET route = ?, travel_frequency = ?, min_dispatch_kg = ?,
+		    price_per_kg = ?, min_value = ?, effective_date = ?
+		WHERE id = ?
+	`,
+		updated.Route,
+		updated.TravelFrequency,
+		updated.MinDispatchKg,
+		updated.PricePerKg,
+		updated.MinValue,
+		updated.EffectiveDate.Format("2006-01-02"),
+		updated.ID,
+	)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = insertRateHistory(tx, updated.ID, models.RateActionUpdated, &previous, &updated, userUUID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RetireRate retira una tarifa: deja de aplicarse a nuevas guías, pero se
+// conserva para las guías que ya la referencian
+func RetireRate(rateID int64, userUUID string) error {
+	fmt.Printf("RetireRate -> RateID: %d, UserUUID: %s\n", rateID, userUUID)
+
+	err := DbConnect()
+	if err != nil {
+		return err
+	}
+
+	tx, err := Db.Begin()
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`
+		UPDATE shipping_rates
+		SET status = 'RETIRED', retired_at = NOW()
+		WHERE id = ? AND status = 'ACTIVE'
+	`, rateID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	affected, _ := result.RowsAffected()
+	if affected == 0 {
+		tx.Rollback()
+		return fmt.Errorf("la tarifa ya está retirada")
+	}
+
+	err = insertRateHistory(tx, rateID, models.RateActionRetired, nil, nil, userUUID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetRateHistory obtiene el historial de cambios de una tarifa
+func GetRateHistory(rateID int64) ([]models.RateChange, error) {
+	fmt.Printf("GetRateHistory -> RateID: %d\n", rateID)
+
+	var history []models.RateChange
+
+	err := DbConnect()
+	if err != nil {
+		return history, err
+	}
+
+	query := `
+		SELECT
+			h.id,
+			h.rate_id,
+			h.action,
+			h.previous_price_per_kg,
+			h.new_price_per_kg,
+			h.previous_min_value,
+			h.new_min_value,
+			h.previous_min_dispatch_kg,
+			h.new_min_dispatch_kg,
+			h.previous_effective_date,
+			h.new_effective_date,
+			h.changed_by,
+			u.full_name,
+			h.changed_at
+		FROM shipping_rate_history h
+		LEFT JOIN users u ON h.changed_by = u.user_uuid
+		WHERE h.rate_id = ?
+		ORDER BY h.changed_at DESC, h.id DESC
+	`
+
+	rows, err := Db.Query(query, rateID)
+	if err != nil {
+		return history, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var change models.RateChange
+		var prevPrice, newPrice, prevMin, newMin sql.NullFloat64
+		var prevKg, newKg sql.NullInt64
+		var prevDate, newDate sql.NullTime
+		var changedByName sql.NullString
+
+		err := rows.Scan(
+			&change.ID,
+			&change.RateID,
+			&change.Action,
+			&prevPrice,
+			&newPrice,
+			&prevMin,
+			&newMin,
+			&prevKg,
+			&newKg,
+			&prevDate,
+			&newDate,
+			&change.ChangedBy,
+			&changedByName,
+			&change.ChangedAt,
+		)
+		if err != nil {
+			return history, err
+		}
+
+		change.PreviousPricePerKg = nullFloatPtr(prevPrice)
+		change.NewPricePerKg = nullFloatPtr(newPrice)
+		change.PreviousMinValue = nullFloatPtr(prevMin)
+		change.NewMinValue = nullFloatPtr(newMin)
+		change.PreviousMinDispatchKg = nullIntPtr(prevKg)
+		change.NewMinDispatchKg = nullIntPtr(newKg)
+		change.PreviousEffectiveDate = nullTimePtr(prevDate)
+		change.NewEffectiveDate = nullTimePtr(newDate)
+		change.ChangedByName = changedByName.String
+
+		history = append(history, change)
+	}
+
+	return history, nil
+}
+
+func nullFloatPtr(value sql.NullFloat64) *float64 {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Float64
+}
+
+func nullIntPtr(value sql.NullInt64) *int {
+	if !value.Valid {
+		return nil
+	}
+	v := int(value.Int64)
+	return &v
+}
+
+func nullTimePtr(value sql.NullTime) *time.Time {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Time
+}
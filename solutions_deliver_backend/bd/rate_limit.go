@@ -0,0 +1,101 @@
+package bd
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+)
+
+// rateLimitPurgeEvery cada cuántas solicitudes (en promedio) se borran
+// las ventanas vencidas de rate_limits
+const rateLimitPurgeEvery = 100
+
+// rateLimitRetention antigüedad mínima de una ventana para borrarla; es
+// mayor que cualquier ventana en uso para no reiniciar contadores vigentes
+const rateLimitRetention = 24 * time.Hour
+
+// HitRateLimit registra una solicitud en el contador del bucket y retorna
+// cuántas lleva en la ventana actual. Si la ventana venció, el contador
+// se reinicia. El contador vive en la base de datos para que el límite
+// aplique a todos los contenedores Lambda. De vez en cuando borra las
+// filas vencidas para que la tabla no crezca con cada IP o guía consultada.
+func HitRateLimit(bucket string, window time.Duration) (models.RateLimitHit, error) {
+	fmt.Printf("HitRateLimit -> Bucket: %s\n", bucket)
+
+	var hit models.RateLimitHit
+
+	err := DbConnect()
+	if err != nil {
+		return hit, err
+	}
+
+	seconds := int64(window / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	tx, err := Db.Begin()
+	if err != nil {
+		return hit, err
+	}
+
+	// MySQL evalúa las asignaciones en orden: hits se calcula con el
+	// window_start anterior, y window_start todavía no ha cambiado
+	_, err = tx.Exec(`
+		INSERT INTO rate_limits (bucket, window_start, hits)
+		VALUES (?, NOW(), 1)
+		ON DUPLICATE KEY UPDATE
+			hits = IF(window_start <= NOW() - INTERVAL ? SECOND, 1, hits + 1),
+			window_start = IF(window_start <= NOW() - INTERVAL ? SECOND, NOW(), window_start)
+	`, bucket, seconds, seconds)
+	if err != nil {
+		tx.Rollback()
+		return hit, err
+	}
+
+	var windowStart time.Time
+	err = tx.QueryRow(`
+		SELECT hits, window_start FROM rate_limits WHERE bucket = ?
+	`, bucket).Scan(&hit.Count, &windowStart)
+	if err != nil {
+		tx.Rollback()
+		return hit, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return hit, err
+	}
+
+	if rand.Intn(rateLimitPurgeEvery) == 0 {
+		purgeRateLimits(window)
+	}
+
+	hit.ResetAt = windowStart.Add(time.Duration(seconds) * time.Second)
+	return hit, nil
+}
+
+// purgeRateLimits borra por lotes las ventanas que vencieron hace más de
+// rateLimitRetention (o de la ventana, si es mayor). No es crítico: un
+// error solo se registra.
+func purgeRateLimits(window time.Duration) {
+	retention := rateLimitRetention
+	if window > retention {
+		retention = window
+	}
+
+	result, err := Db.Exec(`
+		DELETE FROM rate_limits
+		WHERE window_start < NOW() - INTERVAL ? SECOND
+		LIMIT 1000
+	`, int64(retention/time.Second))
+	if err != nil {
+		fmt.Printf("Warning: no se pudieron purgar los rate limits: %s\n", err.Error())
+		return
+	}
+
+	if rows, err := result.RowsAffected(); err == nil && rows > 0 {
+		fmt.Printf("purgeRateLimits -> %d ventanas vencidas borradas\n", rows)
+	}
+}
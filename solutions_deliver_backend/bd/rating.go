@@ -18,7 +18,6 @@ func CreateDeliveryRating(req models.CreateRatingRequest, clientUserID string) (
 	if err != nil {
 		return rating, err
 	}
-	defer Db.Close()
 
 	// Verificar que la asignación existe y está completada
 	var deliveryUserID string
@@ -105,7 +104,6 @@ func GetDeliveryRatingByID(ratingID int64) (models.DeliveryRating, error) {
 	if err != nil {
 		return rating, err
 	}
-	defer Db.Close()
 
 	query := `
 		SELECT
@@ -172,7 +170,6 @@ func GetRatingByAssignmentID(assignmentID int64) (models.DeliveryRating, error)
 	if err != nil {
 		return rating, err
 	}
-	defer Db.Close()
 
 	query := `
 		SELECT
@@ -241,7 +238,6 @@ func GetDeliveryUserRatings(deliveryUserID string, limit int) ([]models.Delivery
 	if err != nil {
 		return ratings, 0, 0, err
 	}
-	defer Db.Close()
 
 	// Obtener total y promedio
 	statsQuery := `
@@ -329,7 +325,6 @@ func GetDeliveryPerformanceStats(deliveryUserID string) (models.DeliveryPerforma
 	if err != nil {
 		return stats, err
 	}
-	defer Db.Close()
 
 	// Zona horaria de Colombia
 	loc, err := time.LoadLocation("America/Bogota")
@@ -349,11 +344,12 @@ func GetDeliveryPerformanceStats(deliveryUserID string) (models.DeliveryPerforma
 	lastMonthStartStr := lastMonthStart.Format("2006-01-02")
 	lastMonthEndStr := monthStart.AddDate(0, 0, -1).Format("2006-01-02")
 
-	// Entregas esta semana
+	// Entregas esta semana (los traslados entre bodegas se cuentan aparte)
 	weekQuery := `
 		SELECT COUNT(*)
 		FROM delivery_assignments
 		WHERE delivery_user_id = ?
+		AND assignment_type <> 'TRANSFER'
 		AND status = 'COMPLETED'
 		AND DATE(completed_at) >= ?
 	`
@@ -367,6 +363,7 @@ func GetDeliveryPerformanceStats(deliveryUserID string) (models.DeliveryPerforma
 		SELECT COUNT(*)
 		FROM delivery_assignments
 		WHERE delivery_user_id = ?
+		AND assignment_type <> 'TRANSFER'
 		AND status = 'COMPLETED'
 		AND DATE(completed_at) >= ? AND DATE(completed_at) <= ?
 	`
@@ -384,6 +381,23 @@ func GetDeliveryPerformanceStats(deliveryUserID string) (models.DeliveryPerforma
 		stats.DeliveriesChangePercent = 0
 	}
 
+	// Traslados completados esta semana y la pasada
+	transfersQuery := `
+		SELECT
+			COALESCE(SUM(CASE WHEN DATE(completed_at) >= ? THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN DATE(completed_at) >= ? AND DATE(completed_at) <= ? THEN 1 ELSE 0 END), 0)
+		FROM delivery_assignments
+		WHERE delivery_user_id = ?
+		AND assignment_type = 'TRANSFER'
+		AND status = 'COMPLETED'
+	`
+	err = Db.QueryRow(transfersQuery, weekStartStr, lastWeekStartStr, lastWeekEndStr, deliveryUserID).
+		Scan(&stats.TransfersThisWeek, &stats.TransfersLastWeek)
+	if err != nil {
+		stats.TransfersThisWeek = 0
+		stats.TransfersLastWeek = 0
+	}
+
 	// Tasa de éxito (completadas vs total)
 	var totalAssignments, completedAssignments int
 	successQuery := `
@@ -405,6 +419,7 @@ func GetDeliveryPerformanceStats(deliveryUserID string) (models.DeliveryPerforma
 		SELECT COALESCE(AVG(TIMESTAMPDIFF(MINUTE, assigned_at, completed_at)), 0)
 		FROM delivery_assignments
 		WHERE delivery_user_id = ?
+		AND assignment_type <> 'TRANSFER'
 		AND status = 'COMPLETED'
 		AND DATE(completed_at) >= ?
 		AND assigned_at IS NOT NULL
@@ -419,6 +434,7 @@ func GetDeliveryPerformanceStats(deliveryUserID string) (models.DeliveryPerforma
 		SELECT COALESCE(AVG(TIMESTAMPDIFF(MINUTE, assigned_at, completed_at)), 0)
 		FROM delivery_assignments
 		WHERE delivery_user_id = ?
+		AND assignment_type <> 'TRANSFER'
 		AND status = 'COMPLETED'
 		AND DATE(completed_at) >= ? AND DATE(completed_at) <= ?
 		AND assigned_at IS NOT NULL
@@ -471,6 +487,7 @@ func GetDeliveryPerformanceStats(deliveryUserID string) (models.DeliveryPerforma
 			SELECT COUNT(*)
 			FROM delivery_assignments
 			WHERE delivery_user_id = ?
+			AND assignment_type <> 'TRANSFER'
 			AND status = 'COMPLETED'
 			AND DATE(completed_at) = ?
 		`
@@ -548,7 +565,6 @@ func GetClientPendingRatings(clientUserID string) ([]models.ClientPendingRating,
 	if err != nil {
 		return pending, err
 	}
-	defer Db.Close()
 
 	// Primero obtenemos el number_document del cliente
 	var clientDocNumber sql.NullString
@@ -0,0 +1,164 @@
+package bd
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+)
+
+// SaveRNDCSubmission registra el envío al RNDC. Si fue aceptado, en la
+// misma transacción guarda el ingresoid en la guía (remesa) o en el
+// manifiesto y en sus guías (manifiesto).
+func SaveRNDCSubmission(submission *models.RNDCSubmission) error {
+	fmt.Printf("SaveRNDCSubmission -> Process: %s, Consecutive: %s, Success: %t\n",
+		submission.Process, submission.Consecutive, submission.Success)
+
+	err := DbConnect()
+	if err != nil {
+		return err
+	}
+
+	tx, err := Db.Begin()
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO rndc_submissions
+		(process, guide_id, manifest_id, consecutive, success, ingreso_id, error_message,
+		 request_xml, response_xml, submitted_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, submission.Process, submission.GuideID, submission.ManifestID, submission.Consecutive, submission.Success,
+		nullIfEmpty(submission.IngresoID), nullIfEmpty(submission.Error),
+		nullIfEmpty(submission.RequestXML), nullIfEmpty(submission.ResponseXML), submission.SubmittedBy)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	submission.SubmissionID, err = result.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if submission.Success {
+		switch {
+		case submission.Process == models.RNDCRemesa && submission.GuideID != nil:
+			_, err = tx.Exec(`
+				UPDATE shipping_guides SET rndc_remesa_id = ? WHERE guide_id = ?
+			`, submission.IngresoID, *submission.GuideID)
+		case submission.Process == models.RNDCManifiesto && submission.ManifestID != nil:
+			_, err = tx.Exec(`
+				UPDATE manifests SET rndc_manifest_id = ? WHERE manifest_id = ?
+			`, submission.IngresoID, *submission.ManifestID)
+			if err == nil {
+				_, err = tx.Exec(`
+					UPDATE shipping_guides sg
+					JOIN manifest_guides mg ON mg.guide_id = sg.guide_id
+					SET sg.rndc_manifest_id = ?
+					WHERE mg.manifest_id = ?
+				`, submission.IngresoID, *submission.ManifestID)
+			}
+		}
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	submission.SubmittedAt = time.Now()
+	return nil
+}
+
+// GetRNDCSubmissions historial de envíos al RNDC, del más reciente al más antiguo
+func GetRNDCSubmissions(filters models.RNDCFilters) ([]models.RNDCSubmission, error) {
+	fmt.Println("GetRNDCSubmissions")
+
+	var submissions []models.RNDCSubmission
+
+	err := DbConnect()
+	if err != nil {
+		return submissions, err
+	}
+
+	var where []string
+	var args []interface{}
+	if filters.GuideID != nil {
+		where = append(where, "guide_id = ?")
+		args = append(args, *filters.GuideID)
+	}
+	if filters.ManifestID != nil {
+		where = append(where, "manifest_id = ?")
+		args = append(args, *filters.ManifestID)
+	}
+
+	query := `
+		SELECT
+			submission_id,
+			process,
+			guide_id,
+			manifest_id,
+			consecutive,
+			success,
+			ingreso_id,
+			error_message,
+			request_xml,
+			response_xml,
+			submitted_by,
+			submitted_at
+		FROM rndc_submissions`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY submitted_at DESC, submission_id DESC"
+
+	rows, err := Db.Query(query, args...)
+	if err != nil {
+		return submissions, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s models.RNDCSubmission
+		var guideID, manifestID sql.NullInt64
+		var ingresoID, errorMessage, requestXML, responseXML sql.NullString
+
+		err := rows.Scan(
+			&s.SubmissionID,
+			&s.Process,
+			&guideID,
+			&manifestID,
+			&s.Consecutive,
+			&s.Success,
+			&ingresoID,
+			&errorMessage,
+			&requestXML,
+			&responseXML,
+			&s.SubmittedBy,
+			&s.SubmittedAt,
+		)
+		if err != nil {
+			return submissions, err
+		}
+
+		if guideID.Valid {
+			s.GuideID = &guideID.Int64
+		}
+		if manifestID.Valid {
+			s.ManifestID = &manifestID.Int64
+		}
+		s.IngresoID = ingresoID.String
+		s.Error = errorMessage.String
+		s.RequestXML = requestXML.String
+		s.ResponseXML = responseXML.String
+		submissions = append(submissions, s)
+	}
+
+	return submissions, nil
+}
@@ -3,6 +3,7 @@ package bd
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"time"
@@ -10,6 +11,7 @@ import (
 	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/awsgo"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 )
 
 var (
@@ -78,6 +80,40 @@ func GetPresignedURL(s3Key string, expirationMinutes int) (string, error) {
 	return result.URL, nil
 }
 
+// GetPresignedPutURL genera una URL pre-firmada para subir un archivo a S3.
+// El cliente debe enviar el mismo Content-Type con el que se firmó.
+func GetPresignedPutURL(s3Key string, contentType string, expirationMinutes int) (string, error) {
+	fmt.Printf("GetPresignedPutURL -> Bucket: %s, Key: %s, Expiration: %d min\n", s3BucketName, s3Key, expirationMinutes)
+
+	if s3Client == nil {
+		return "", fmt.Errorf("cliente S3 no inicializado")
+	}
+
+	if s3Key == "" {
+		return "", fmt.Errorf("s3Key vacío")
+	}
+
+	presignClient := s3.NewPresignClient(s3Client)
+
+	result, err := presignClient.PresignPutObject(
+		context.Background(),
+		&s3.PutObjectInput{
+			Bucket:      aws.String(s3BucketName),
+			Key:         aws.String(s3Key),
+			ContentType: aws.String(contentType),
+		},
+		func(opts *s3.PresignOptions) {
+			opts.Expires = time.Duration(expirationMinutes) * time.Minute
+		},
+	)
+
+	if err != nil {
+		return "", fmt.Errorf("error al generar URL pre-firmada de carga: %v", err)
+	}
+
+	return result.URL, nil
+}
+
 // DownloadFileFromS3 descarga un archivo de S3 y retorna los bytes
 func DownloadFileFromS3(s3Key string) ([]byte, string, error) {
 	fmt.Printf("DownloadFileFromS3 -> Bucket: %s, Key: %s\n", s3BucketName, s3Key)
@@ -151,6 +187,34 @@ func UploadFileToS3(s3Key string, data []byte, contentType string) error {
 	return nil
 }
 
+// S3ObjectExists verifica con HeadObject que el archivo exista en el bucket
+func S3ObjectExists(s3Key string) (bool, error) {
+	if s3Client == nil {
+		return false, fmt.Errorf("cliente S3 no inicializado")
+	}
+
+	if s3Key == "" {
+		return false, fmt.Errorf("s3Key vacío")
+	}
+
+	_, err := s3Client.HeadObject(
+		context.Background(),
+		&s3.HeadObjectInput{
+			Bucket: aws.String(s3BucketName),
+			Key:    aws.String(s3Key),
+		},
+	)
+	if err != nil {
+		var notFound *types.NotFound
+		if errors.As(err, &notFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("error al consultar archivo en S3: %v", err)
+	}
+
+	return true, nil
+}
+
 func min(a, b int) int {
 	if a < b {
 		return a
@@ -0,0 +1,76 @@
+package bd
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+)
+
+// RecordGuideScan registra un escaneo exitoso de la guía
+func RecordGuideScan(scan *models.GuideScan) error {
+	fmt.Printf("RecordGuideScan -> GuideID: %d, Point: %s\n", scan.GuideID, scan.ScanPoint)
+
+	err := DbConnect()
+	if err != nil {
+		return err
+	}
+
+	result, err := Db.Exec(`
+		INSERT INTO guide_scans (guide_id, piece_number, scan_point, code, result, previous_status, new_status, scanned_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, scan.GuideID, scan.PieceNumber, scan.ScanPoint, scan.Code, scan.Result, scan.PreviousStatus, scan.NewStatus, scan.ScannedBy)
+	if err != nil {
+		return err
+	}
+
+	scanID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	scan.ScanID = scanID
+	scan.ScannedAt = time.Now()
+
+	return nil
+}
+
+// GetRecentGuideScan retorna el último escaneo de la guía (pieceNumber 0) o
+// de una de sus piezas en el punto dado dentro de la ventana. La ventana se
+// evalúa con el reloj de MySQL, el mismo que llena scanned_at.
+func GetRecentGuideScan(guideID int64, pieceNumber int, point models.ScanPoint, window time.Duration) (models.GuideScan, error) {
+	fmt.Printf("GetRecentGuideScan -> GuideID: %d, Piece: %d, Point: %s\n", guideID, pieceNumber, point)
+
+	var scan models.GuideScan
+
+	err := DbConnect()
+	if err != nil {
+		return scan, err
+	}
+
+	seconds := int64(window / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	var scannedBy sql.NullString
+	err = Db.QueryRow(`
+		SELECT scan_id, guide_id, piece_number, scan_point, code, result, previous_status, new_status, scanned_by, scanned_at
+		FROM guide_scans
+		WHERE guide_id = ? AND piece_number = ? AND scan_point = ? AND scanned_at > NOW() - INTERVAL ? SECOND
+		ORDER BY scanned_at DESC, scan_id DESC
+		LIMIT 1
+	`, guideID, pieceNumber, point, seconds).Scan(
+		&scan.ScanID, &scan.GuideID, &scan.PieceNumber, &scan.ScanPoint, &scan.Code, &scan.Result,
+		&scan.PreviousStatus, &scan.NewStatus, &scannedBy, &scan.ScannedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return scan, fmt.Errorf("Escaneo no encontrado")
+		}
+		return scan, err
+	}
+	scan.ScannedBy = scannedBy.String
+
+	return scan, nil
+}
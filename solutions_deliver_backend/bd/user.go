@@ -28,16 +28,16 @@ func GetUserRole(userUUID string) (models.User, error) {
 	if err != nil {
 		return user, err
 	}
-	defer Db.Close()
 
 	query := `
-		SELECT user_uuid, email, role
+		SELECT user_uuid, email, role, hub_id
 		FROM users
 		WHERE user_uuid = ?
 	`
 
+	var hubID sql.NullInt64
 	row := Db.QueryRow(query, userUUID)
-	err = row.Scan(&user.UserUUID, &user.UserEmail, &user.Role)
+	err = row.Scan(&user.UserUUID, &user.UserEmail, &user.Role, &hubID)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -45,6 +45,9 @@ func GetUserRole(userUUID string) (models.User, error) {
 		}
 		return user, err
 	}
+	if hubID.Valid {
+		user.HubID = &hubID.Int64
+	}
 
 	return user, nil
 }
@@ -57,7 +60,6 @@ func UpdateLastLogin(userUUID string) error {
 	if err != nil {
 		return err
 	}
-	defer Db.Close()
 
 	now := time.Now().In(userColombiaLoc)
 
@@ -0,0 +1,614 @@
+package bd
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+)
+
+// CreateWarehouse inserta una bodega. El código es único.
+func CreateWarehouse(warehouse *models.Warehouse) error {
+	fmt.Printf("CreateWarehouse -> Code: %s\n", warehouse.Code)
+
+	err := DbConnect()
+	if err != nil {
+		return err
+	}
+
+	var count int
+	err = Db.QueryRow(`SELECT COUNT(*) FROM warehouses WHERE code = ?`, warehouse.Code).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("ya existe una bodega con ese código")
+	}
+
+	result, err := Db.Exec(`
+		INSERT INTO warehouses (code, name, city_id, address, active, created_by)
+		VALUES (?, ?, ?, ?, TRUE, ?)
+	`, warehouse.Code, warehouse.Name, warehouse.CityID, nullIfEmpty(warehouse.Address), warehouse.CreatedBy)
+	if err != nil {
+		return err
+	}
+
+	warehouse.WarehouseID, err = result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	warehouse.Active = true
+	warehouse.CreatedAt = time.Now()
+
+	return nil
+}
+
+// warehouseColumns columnas de warehouses con el nombre de la ciudad (alias w, c)
+const warehouseColumns = `
+			w.warehouse_id,
+			w.code,
+			w.name,
+			w.city_id,
+			c.name AS city_name,
+			w.address,
+			w.active,
+			w.created_by,
+			w.created_at
+		FROM warehouses w
+		LEFT JOIN cities c ON w.city_id = c.id`
+
+func scanWarehouse(row rowScanner) (models.Warehouse, error) {
+	var w models.Warehouse
+	var cityName, address, createdBy sql.NullString
+
+	err := row.Scan(&w.WarehouseID, &w.Code, &w.Name, &w.CityID, &cityName, &address, &w.Active, &createdBy, &w.CreatedAt)
+	if err != nil {
+		return w, err
+	}
+
+	w.CityName = cityName.String
+	w.Address = address.String
+	w.CreatedBy = createdBy.String
+	return w, nil
+}
+
+// GetWarehouses lista las bodegas
+func GetWarehouses() ([]models.Warehouse, error) {
+	fmt.Println("GetWarehouses")
+
+	var warehouses []models.Warehouse
+
+	err := DbConnect()
+	if err != nil {
+		return warehouses, err
+	}
+
+	rows, err := Db.Query(`SELECT` + warehouseColumns + ` ORDER BY w.code`)
+	if err != nil {
+		return warehouses, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		w, err := scanWarehouse(rows)
+		if err != nil {
+			return warehouses, err
+		}
+		warehouses = append(warehouses, w)
+	}
+
+	return warehouses, nil
+}
+
+// GetWarehouseByID obtiene una bodega
+func GetWarehouseByID(warehouseID int64) (models.Warehouse, error) {
+	fmt.Printf("GetWarehouseByID -> WarehouseID: %d\n", warehouseID)
+
+	err := DbConnect()
+	if err != nil {
+		return models.Warehouse{}, err
+	}
+
+	w, err := scanWarehouse(Db.QueryRow(`SELECT`+warehouseColumns+` WHERE w.warehouse_id = ?`, warehouseID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return w, fmt.Errorf("Bodega no encontrada")
+		}
+		return w, err
+	}
+
+	return w, nil
+}
+
+// CreateBin inserta un bin. El código es único dentro de la bodega.
+func CreateBin(bin *models.WarehouseBin) error {
+	fmt.Printf("CreateBin -> WarehouseID: %d, Code: %s\n", bin.WarehouseID, bin.Code)
+
+	err := DbConnect()
+	if err != nil {
+		return err
+	}
+
+	var count int
+	err = Db.QueryRow(`
+		SELECT COUNT(*) FROM warehouse_bins WHERE warehouse_id = ? AND code = ?
+	`, bin.WarehouseID, bin.Code).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("ya existe un bin con ese código en la bodega")
+	}
+
+	result, err := Db.Exec(`
+		INSERT INTO warehouse_bins (warehouse_id, code, description, active)
+		VALUES (?, ?, ?, TRUE)
+	`, bin.WarehouseID, bin.Code, nullIfEmpty(bin.Description))
+	if err != nil {
+		return err
+	}
+
+	bin.BinID, err = result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	bin.Active = true
+	bin.CreatedAt = time.Now()
+
+	return nil
+}
+
+// binColumns columnas de warehouse_bins con las piezas ubicadas (alias b)
+const binColumns = `
+			b.bin_id,
+			b.warehouse_id,
+			b.code,
+			b.description,
+			b.active,
+			(SELECT COUNT(*) FROM warehouse_stock ws WHERE ws.bin_id = b.bin_id) AS items,
+			b.created_at
+		FROM warehouse_bins b`
+
+func scanBin(row rowScanner) (models.WarehouseBin, error) {
+	var b models.WarehouseBin
+	var description sql.NullString
+
+	err := row.Scan(&b.BinID, &b.WarehouseID, &b.Code, &description, &b.Active, &b.Items, &b.CreatedAt)
+	if err != nil {
+		return b, err
+	}
+
+	b.Description = description.String
+	return b, nil
+}
+
+// GetBins lista los bins de una bodega
+func GetBins(warehouseID int64) ([]models.WarehouseBin, error) {
+	fmt.Printf("GetBins -> WarehouseID: %d\n", warehouseID)
+
+	var bins []models.WarehouseBin
+
+	err := DbConnect()
+	if err != nil {
+		return bins, err
+	}
+
+	rows, err := Db.Query(`SELECT`+binColumns+` WHERE b.warehouse_id = ? ORDER BY b.code`, warehouseID)
+	if err != nil {
+		return bins, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		b, err := scanBin(rows)
+		if err != nil {
+			return bins, err
+		}
+		bins = append(bins, b)
+	}
+
+	return bins, nil
+}
+
+// GetBinByID obtiene un bin
+func GetBinByID(binID int64) (models.WarehouseBin, error) {
+	fmt.Printf("GetBinByID -> BinID: %d\n", binID)
+
+	err := DbConnect()
+	if err != nil {
+		return models.WarehouseBin{}, err
+	}
+
+	b, err := scanBin(Db.QueryRow(`SELECT`+binColumns+` WHERE b.bin_id = ?`, binID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return b, fmt.Errorf("Bin no encontrado")
+		}
+		return b, err
+	}
+
+	return b, nil
+}
+
+// stockColumns columnas de la ubicación de una guía o pieza (alias ws, sg,
+// gp, wb, w)
+const stockColumns = `
+			ws.guide_id,
+			sg.guide_number,
+			ws.piece_number,
+			gp.piece_code,
+			sg.current_status,
+			w.warehouse_id,
+			w.code,
+			wb.bin_id,
+			wb.code,
+			ws.located_by,
+			ws.located_at
+		FROM warehouse_stock ws
+		JOIN shipping_guides sg ON sg.guide_id = ws.guide_id
+		LEFT JOIN guide_pieces gp ON gp.guide_id = ws.guide_id AND gp.piece_number = ws.piece_number
+		JOIN warehouse_bins wb ON wb.bin_id = ws.bin_id
+		JOIN warehouses w ON w.warehouse_id = wb.warehouse_id`
+
+func scanStockItem(row rowScanner) (models.StockItem, error) {
+	var item models.StockItem
+	var guideNumber, pieceCode, locatedBy sql.NullString
+	var locatedAt time.Time
+
+	err := row.Scan(
+		&item.GuideID,
+		&guideNumber,
+		&item.PieceNumber,
+		&pieceCode,
+		&item.CurrentStatus,
+		&item.WarehouseID,
+		&item.WarehouseCode,
+		&item.BinID,
+		&item.BinCode,
+		&locatedBy,
+		&locatedAt,
+	)
+	if err != nil {
+		return item, err
+	}
+
+	item.GuideNumber = guideNumber.String
+	item.PieceCode = pieceCode.String
+	item.LocatedBy = locatedBy.String
+	item.LocatedAt = &locatedAt
+	return item, nil
+}
+
+// GetStock lista las guías y piezas ubicadas, filtradas por bodega, bin o guía
+func GetStock(filters models.StockFilters) ([]models.StockItem, error) {
+	fmt.Println("GetStock")
+
+	var items []models.StockItem
+
+	err := DbConnect()
+	if err != nil {
+		return items, err
+	}
+
+	var where []string
+	var args []interface{}
+	if filters.WarehouseID != nil {
+		where = append(where, "w.warehouse_id = ?")
+		args = append(args, *filters.WarehouseID)
+	}
+	if filters.BinID != nil {
+		where = append(where, "wb.bin_id = ?")
+		args = append(args, *filters.BinID)
+	}
+	if filters.GuideID != nil {
+		where = append(where, "ws.guide_id = ?")
+		args = append(args, *filters.GuideID)
+	}
+
+	query := `SELECT` + stockColumns
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	query += ` ORDER BY wb.code, ws.guide_id, ws.piece_number`
+
+	rows, err := Db.Query(query, args...)
+	if err != nil {
+		return items, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item, err := scanStockItem(rows)
+		if err != nil {
+			return items, err
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// LocateGuideItems ubica las piezas de la guía en el bin (pieza 0: la guía
+// sin piezas) y registra el movement. Un MOVE de algo que no tenía
+// ubicación se registra como CHECK_IN; lo que ya está en el bin no cambia.
+func LocateGuideItems(guideID int64, pieces []int, binID int64, movement models.MovementType, notes string, userUUID string) error {
+	fmt.Printf("LocateGuideItems -> GuideID: %d, Pieces: %v, BinID: %d\n", guideID, pieces, binID)
+
+	err := DbConnect()
+	if err != nil {
+		return err
+	}
+
+	tx, err := Db.Begin()
+	if err != nil {
+		return err
+	}
+
+	for _, piece := range pieces {
+		var current sql.NullInt64
+		err = tx.QueryRow(`
+			SELECT bin_id FROM warehouse_stock
+			WHERE guide_id = ? AND piece_number = ?
+			FOR UPDATE
+		`, guideID, piece).Scan(&current)
+		if err != nil && err != sql.ErrNoRows {
+			tx.Rollback()
+			return err
+		}
+		if current.Valid && current.Int64 == binID {
+			continue
+		}
+
+		movementType := movement
+		if !current.Valid && movement == models.MovementMove {
+			movementType = models.MovementCheckIn
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO warehouse_stock (guide_id, piece_number, bin_id, located_by, located_at)
+			VALUES (?, ?, ?, ?, NOW())
+			ON DUPLICATE KEY UPDATE bin_id = VALUES(bin_id), located_by = VALUES(located_by), located_at = NOW()
+		`, guideID, piece, binID, userUUID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		err = insertWarehouseMovement(tx, guideID, piece, movementType, current, sql.NullInt64{Int64: binID, Valid: true}, notes, userUUID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// CheckOutGuideItems saca de la bodega las piezas de la guía (nil: todas
+// las ubicadas). Retorna cuántas tenían ubicación.
+func CheckOutGuideItems(guideID int64, pieces []int, notes string, userUUID string) (int, error) {
+	fmt.Printf("CheckOutGuideItems -> GuideID: %d, Pieces: %v\n", guideID, pieces)
+
+	err := DbConnect()
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := Db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	removed, err := checkOutGuideItemsTx(tx, guideID, pieces, notes, userUUID)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	return removed, tx.Commit()
+}
+
+// checkOutGuideItemsTx saca las piezas de la bodega dentro de la
+// transacción dada. El llamador hace el Rollback si retorna error.
+func checkOutGuideItemsTx(tx *sql.Tx, guideID int64, pieces []int, notes string, userUUID string) (int, error) {
+	rows, err := tx.Query(`
+		SELECT piece_number, bin_id FROM warehouse_stock
+		WHERE guide_id = ?
+		FOR UPDATE
+	`, guideID)
+	if err != nil {
+		return 0, err
+	}
+
+	located := map[int]int64{}
+	for rows.Next() {
+		var piece int
+		var binID int64
+		if err := rows.Scan(&piece, &binID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		located[piece] = binID
+	}
+	rows.Close()
+
+	if pieces == nil {
+		for piece := range located {
+			pieces = append(pieces, piece)
+		}
+	}
+
+	removed := 0
+	for _, piece := range pieces {
+		binID, ok := located[piece]
+		if !ok {
+			continue
+		}
+
+		_, err = tx.Exec(`DELETE FROM warehouse_stock WHERE guide_id = ? AND piece_number = ?`, guideID, piece)
+		if err != nil {
+			return 0, err
+		}
+
+		err = insertWarehouseMovement(tx, guideID, piece, models.MovementCheckOut, sql.NullInt64{Int64: binID, Valid: true}, sql.NullInt64{}, notes, userUUID)
+		if err != nil {
+			return 0, err
+		}
+		removed++
+	}
+
+	return removed, nil
+}
+
+func insertWarehouseMovement(tx *sql.Tx, guideID int64, piece int, movement models.MovementType, from, to sql.NullInt64, notes string, userUUID string) error {
+	_, err := tx.Exec(`
+		INSERT INTO warehouse_movements
+		(guide_id, piece_number, movement_type, from_bin_id, to_bin_id, notes, moved_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, guideID, piece, movement, from, to, nullIfEmpty(notes), userUUID)
+	return err
+}
+
+// GetGuideMovements historial de movimientos de bodega de una guía
+func GetGuideMovements(guideID int64) ([]models.WarehouseMovement, error) {
+	fmt.Printf("GetGuideMovements -> GuideID: %d\n", guideID)
+
+	var movements []models.WarehouseMovement
+
+	err := DbConnect()
+	if err != nil {
+		return movements, err
+	}
+
+	rows, err := Db.Query(`
+		SELECT
+			m.movement_id,
+			m.guide_id,
+			m.piece_number,
+			m.movement_type,
+			m.from_bin_id,
+			fb.code,
+			m.to_bin_id,
+			tb.code,
+			m.notes,
+			m.moved_by,
+			m.moved_at
+		FROM warehouse_movements m
+		LEFT JOIN warehouse_bins fb ON fb.bin_id = m.from_bin_id
+		LEFT JOIN warehouse_bins tb ON tb.bin_id = m.to_bin_id
+		WHERE m.guide_id = ?
+		ORDER BY m.moved_at, m.movement_id
+	`, guideID)
+	if err != nil {
+		return movements, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m models.WarehouseMovement
+		var fromBin, toBin sql.NullInt64
+		var fromCode, toCode, notes sql.NullString
+
+		err := rows.Scan(&m.MovementID, &m.GuideID, &m.PieceNumber, &m.MovementType,
+			&fromBin, &fromCode, &toBin, &toCode, &notes, &m.MovedBy, &m.MovedAt)
+		if err != nil {
+			return movements, err
+		}
+
+		if fromBin.Valid {
+			m.FromBinID = &fromBin.Int64
+		}
+		if toBin.Valid {
+			m.ToBinID = &toBin.Int64
+		}
+		m.FromBinCode = fromCode.String
+		m.ToBinCode = toCode.String
+		m.Notes = notes.String
+		movements = append(movements, m)
+	}
+
+	return movements, nil
+}
+
+// GetWarehouseAging lista las guías IN_WAREHOUSE con la fecha en que
+// entraron a bodega (último cambio a IN_WAREHOUSE), de la más antigua a la
+// más reciente. Con WarehouseID solo las que tienen alguna pieza ubicada
+// en esa bodega.
+func GetWarehouseAging(filters models.AgingFilters) ([]models.AgingItem, error) {
+	fmt.Printf("GetWarehouseAging -> MinDays: %d\n", filters.MinDays)
+
+	var items []models.AgingItem
+
+	err := DbConnect()
+	if err != nil {
+		return items, err
+	}
+
+	query := `
+		SELECT
+			sg.guide_id,
+			sg.guide_number,
+			sg.service_type,
+			dc.name AS destination_city_name,
+			receiver.full_name AS receiver_name,
+			COALESCE(
+				(SELECT MAX(h.updated_at) FROM guide_status_history h
+				 WHERE h.guide_id = sg.guide_id AND h.status = 'IN_WAREHOUSE'),
+				sg.updated_at
+			) AS since,
+			GREATEST((SELECT COUNT(*) FROM guide_pieces gp WHERE gp.guide_id = sg.guide_id), 1) AS pieces,
+			(SELECT COUNT(*) FROM warehouse_stock ws WHERE ws.guide_id = sg.guide_id) AS located,
+			(SELECT GROUP_CONCAT(DISTINCT wb.code ORDER BY wb.code SEPARATOR ',')
+			 FROM warehouse_stock ws
+			 JOIN warehouse_bins wb ON wb.bin_id = ws.bin_id
+			 WHERE ws.guide_id = sg.guide_id) AS bins
+		FROM shipping_guides sg
+		LEFT JOIN cities dc ON sg.destination_city_id = dc.id
+		LEFT JOIN guide_parties receiver ON sg.guide_id = receiver.guide_id AND receiver.party_role = 'RECEIVER'
+		WHERE sg.current_status = 'IN_WAREHOUSE'
+	`
+	var args []interface{}
+	if filters.WarehouseID != nil {
+		query += `
+		AND EXISTS (
+			SELECT 1 FROM warehouse_stock ws
+			JOIN warehouse_bins wb ON wb.bin_id = ws.bin_id
+			WHERE ws.guide_id = sg.guide_id AND wb.warehouse_id = ?
+		)`
+		args = append(args, *filters.WarehouseID)
+	}
+	query = `SELECT * FROM (` + query + `) aging WHERE since <= NOW() - INTERVAL ? DAY ORDER BY since ASC`
+	args = append(args, filters.MinDays)
+
+	rows, err := Db.Query(query, args...)
+	if err != nil {
+		fmt.Printf("GetWarehouseAging - Error en query: %v\n", err)
+		return items, err
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	for rows.Next() {
+		var item models.AgingItem
+		var guideNumber, destination, receiver, bins sql.NullString
+
+		err := rows.Scan(&item.GuideID, &guideNumber, &item.ServiceType, &destination, &receiver,
+			&item.InWarehouseSince, &item.Pieces, &item.LocatedPieces, &bins)
+		if err != nil {
+			return items, err
+		}
+
+		item.GuideNumber = guideNumber.String
+		item.DestinationCityName = destination.String
+		item.ReceiverName = receiver.String
+		item.DaysInWarehouse = int(now.Sub(item.InWarehouseSince).Hours() / 24)
+		if bins.Valid && bins.String != "" {
+			item.Bins = strings.Split(bins.String, ",")
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/awsgo"
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/bd"
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+)
+
+// Asigna número de guía a las guías que no lo tienen (anteriores a
+// sql/guides/guide_numbers.sql). Se puede ejecutar varias veces: solo toma
+// guías con guide_number NULL, en orden de guide_id. Las credenciales de la
+// BD se leen igual que en cmd/server (variables DB_* o SecretName).
+
+func main() {
+	prefix := flag.String("prefix", os.Getenv("GUIDE_NUMBER_PREFIX"), "prefijo de oficina (2 a 4 letras)")
+	batch := flag.Int("batch", 500, "guías por transacción")
+	flag.Parse()
+
+	*prefix = strings.ToUpper(strings.TrimSpace(*prefix))
+	if *prefix == "" {
+		*prefix = "SD"
+	}
+	if !models.ValidGuideNumberPrefix(*prefix) {
+		fmt.Println(models.ErrGuideNumberPrefixValue.Error())
+		os.Exit(1)
+	}
+
+	if err := loadDBConfig(); err != nil {
+		fmt.Println("Error cargando configuración de BD:", err.Error())
+		os.Exit(1)
+	}
+
+	updated, err := bd.BackfillGuideNumbers(*prefix, *batch)
+	if err != nil {
+		fmt.Printf("Error numerando guías (%d actualizadas antes del error): %s\n", updated, err.Error())
+		os.Exit(1)
+	}
+
+	fmt.Printf("Guías numeradas: %d\n", updated)
+}
+
+func loadDBConfig() error {
+	if os.Getenv("DB_HOST") == "" && os.Getenv("SecretName") != "" {
+		awsgo.InicializoAWS()
+		return bd.ReadSecret()
+	}
+	return bd.ReadSecretFromEnv()
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/rndc"
+)
+
+// Stub local del servicio SOAP del RNDC (AtenderMensajeRNDC) para probar el
+// reporte de remesas y manifiestos sin conexión. El backend lo usa con
+// RNDC_URL=http://localhost:8090/; las credenciales que acepta son
+// RNDC_USERNAME y RNDC_PASSWORD (sin usuario acepta cualquiera). Los
+// registros viven en memoria mientras corre el proceso.
+
+func main() {
+	addr := flag.String("addr", ":8090", "dirección donde escucha el stub")
+	flag.Parse()
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           rndc.NewStub(os.Getenv("RNDC_USERNAME"), os.Getenv("RNDC_PASSWORD")),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	fmt.Println("Stub RNDC escuchando en " + *addr)
+	if err := srv.ListenAndServe(); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+}
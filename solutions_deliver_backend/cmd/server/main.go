@@ -0,0 +1,172 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/auth"
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/awsgo"
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/bd"
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/handlers"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Servidor HTTP para desarrollo local.
+// Expone las mismas rutas que la Lambda traduciendo cada request de net/http
+// a events.APIGatewayV2HTTPRequest, de modo que handlers.Manejadores se ejecuta
+// sin cambios. Las credenciales de la BD se leen de variables de entorno
+// (ver bd.ReadSecretFromEnv) y el JWT se verifica localmente con HS256
+// usando LOCAL_JWT_SECRET.
+
+func main() {
+	addr := flag.String("addr", ":8080", "dirección donde escucha el servidor")
+	flag.Parse()
+
+	if err := loadDBConfig(); err != nil {
+		fmt.Println("Error cargando configuración de BD:", err.Error())
+		os.Exit(1)
+	}
+
+	// S3 es opcional en local: solo se inicializa si hay bucket configurado
+	if bucket := os.Getenv("S3_BUCKET_NAME"); bucket != "" {
+		awsgo.InicializoAWS()
+		if err := bd.InitS3Client(bucket); err != nil {
+			fmt.Println("Advertencia: no se pudo inicializar S3:", err.Error())
+		}
+	}
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           newHandler(os.Getenv("UrlPrefix"), os.Getenv("LOCAL_JWT_SECRET")),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	fmt.Println("Servidor local escuchando en " + *addr)
+	if err := srv.ListenAndServe(); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+}
+
+// loadDBConfig prioriza las variables DB_* y solo recurre a Secrets Manager
+// cuando se define SecretName sin DB_HOST.
+func loadDBConfig() error {
+	if os.Getenv("DB_HOST") == "" && os.Getenv("SecretName") != "" {
+		awsgo.InicializoAWS()
+		return bd.ReadSecret()
+	}
+	return bd.ReadSecretFromEnv()
+}
+
+func newHandler(prefix string, jwtSecret string) http.Handler {
+	if prefix == "" {
+		prefix = "/api/v1"
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		setCORSHeaders(w)
+
+		request, err := toAPIGatewayRequest(r, prefix, jwtSecret)
+		if err != nil {
+			writeJSON(w, 400, fmt.Sprintf(`{"error": "%s"}`, err.Error()))
+			return
+		}
+
+		path := strings.Replace(request.RawPath, prefix, "", -1)
+		status, message := handlers.Manejadores(path, request.RequestContext.HTTP.Method, request.Body, request.Headers, request)
+
+		fmt.Printf("%s %s -> %d\n", r.Method, r.URL.Path, status)
+		if status == 405 {
+			w.Header().Set("Allow", handlers.AllowedMethods(path))
+		}
+		writeJSON(w, status, message)
+	})
+}
+
+// toAPIGatewayRequest arma el evento tal como lo entrega API Gateway HTTP API v2:
+// headers en minúscula, query params con valores separados por coma, path params
+// resueltos con la tabla de rutas de handlers y claims del JWT en el authorizer.
+func toAPIGatewayRequest(r *http.Request, prefix string, jwtSecret string) (events.APIGatewayV2HTTPRequest, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return events.APIGatewayV2HTTPRequest{}, fmt.Errorf("no se pudo leer el body")
+	}
+
+	headers := make(map[string]string, len(r.Header))
+	for k, v := range r.Header {
+		headers[strings.ToLower(k)] = strings.Join(v, ",")
+	}
+
+	var query map[string]string
+	if values := r.URL.Query(); len(values) > 0 {
+		query = make(map[string]string, len(values))
+		for k, v := range values {
+			query[k] = strings.Join(v, ",")
+		}
+	}
+
+	routeKey, pathParams := handlers.MatchRoute(r.Method, strings.TrimPrefix(r.URL.Path, prefix))
+
+	// API Gateway entrega solo la IP; RemoteAddr trae también el puerto
+	sourceIP, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		sourceIP = r.RemoteAddr
+	}
+
+	request := events.APIGatewayV2HTTPRequest{
+		Version:               "2.0",
+		RouteKey:              routeKey,
+		RawPath:               r.URL.Path,
+		RawQueryString:        r.URL.RawQuery,
+		Headers:               headers,
+		QueryStringParameters: query,
+		PathParameters:        pathParams,
+		Body:                  string(body),
+		RequestContext: events.APIGatewayV2HTTPRequestContext{
+			RouteKey:  routeKey,
+			Stage:     "$default",
+			RequestID: fmt.Sprintf("local-%d", time.Now().UnixNano()),
+			HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{
+				Method:    r.Method,
+				Path:      r.URL.Path,
+				Protocol:  r.Proto,
+				SourceIP:  sourceIP,
+				UserAgent: r.UserAgent(),
+			},
+		},
+	}
+
+	// Sin token válido no se adjunta authorizer y validateAuthorization responde 401
+	if token := r.Header.Get("Authorization"); token != "" {
+		claims, err := auth.ValidarTokenLocal(token, jwtSecret)
+		if err != nil {
+			fmt.Println("Token local rechazado:", err.Error())
+		} else {
+			request.RequestContext.Authorizer = &events.APIGatewayV2HTTPRequestContextAuthorizerDescription{
+				JWT: &events.APIGatewayV2HTTPRequestContextAuthorizerJWTDescription{
+					Claims: claims,
+				},
+			}
+		}
+	}
+
+	return request, nil
+}
+
+func setCORSHeaders(w http.ResponseWriter) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+}
+
+func writeJSON(w http.ResponseWriter, status int, body string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write([]byte(body))
+}
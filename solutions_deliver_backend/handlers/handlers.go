@@ -4,21 +4,54 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/auth"
 	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/repository"
 	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/routers"
 	"github.com/aws/aws-lambda-go/events"
-	// "strconv"
 )
 
+// apiRoutes es la tabla de rutas del API, construida una sola vez
+var apiRoutes = NewAPIRouter()
+
+// repos son los repositorios que usa el middleware de roles
+var repos = repository.NewMySQLRepositories()
+
+// InitRepositories inyecta los repositorios en handlers y routers
+func InitRepositories(r repository.Repositories) {
+	repos = r
+	routers.InitRepositories(r)
+}
+
 func Manejadores(path string, method string, body string, headers map[string]string, request events.APIGatewayV2HTTPRequest) (int, string) {
 	fmt.Println("Voy a procesar " + path + " > " + method)
 
 	// NORMALIZAR PATH: Eliminar prefijo /api/v1 si existe
-	path = strings.TrimPrefix(path, "/api/v1")
+	path = stripStagePrefix(path)
+
+	route, params, status := apiRoutes.Match(method, path)
 
-	id := request.PathParameters["id"]
-	idn, _ := strconv.Atoi(id)
+	// Preflight CORS: basta con que el path exista
+	if method == "OPTIONS" && status != 404 {
+		return 200, "OK"
+	}
+
+	switch status {
+	case 404:
+		return 404, `{"error": "Ruta no encontrada"}`
+	case 405:
+		return 405, `{"error": "Método no permitido"}`
+	}
+
+	if route.Policy.Public {
+		return route.Handler(RouteContext{
+			Body:    body,
+			Params:  params,
+			Request: request,
+		})
+	}
 
 	isValid, statusCode, userUUID := validateAuthorization(path, method, request)
 
@@ -26,36 +59,90 @@ func Manejadores(path string, method string, body string, headers map[string]str
 		return statusCode, userUUID
 	}
 
-	switch {
+	return authorize(route, RouteContext{
+		Body:    body,
+		User:    userUUID,
+		Params:  params,
+		Request: request,
+	})
+}
 
-	case strings.HasPrefix(path, "/admin"):
-		return ProccessAdmin(body, path, method, userUUID, request)
+// authorize aplica la política de la ruta: resuelve el rol una sola vez,
+// valida los roles permitidos y la regla de propiedad, y luego invoca el handler.
+func authorize(route *Route, ctx RouteContext) (int, string) {
+	policy := route.Policy
 
-	case strings.HasPrefix(path, "/guides"):
-		return ProcesoGuias(body, path, method, userUUID, idn, request)
+	if !policy.Authenticated {
+		user, err := repos.Users.GetUserRole(ctx.User)
+		if err != nil {
+			fmt.Printf("Error obteniendo rol: %s\n", err.Error())
+			return 403, `{"error": "No autorizado - Rol no permitido"}`
+		}
+		if !policy.Allows(user.Role) {
+			return 403, `{"error": "No autorizado - Rol no permitido"}`
+		}
+		ctx.Role = user.Role
+		ctx.HubID = user.HubID
+	}
 
-	case strings.HasPrefix(path, "/auth"):
-		return ProcesoAutencaciones(body, path, method, userUUID, id, request)
+	if policy.Owner != nil {
+		if status, message := policy.Owner(ctx); status != 0 {
+			return status, message
+		}
+	}
 
-	case strings.HasPrefix(path, "/locations"):
-		return ProcesoUbicaciones(body, path, method, userUUID, id, request)
+	return route.Handler(ctx)
+}
 
-	case strings.HasPrefix(path, "/cash-close"):
-		return ProccessCashClose(body, path, method, userUUID, idn, request)
+// stripStagePrefix elimina el prefijo de stage /api/v1 del path
+func stripStagePrefix(path string) string {
+	return strings.TrimPrefix(path, "/api/v1")
+}
 
-	case strings.HasPrefix(path, "/client"):
-		return ProccessClient(body, path, method, userUUID, id, request)
+// AllowedMethods retorna el valor del header Allow para un path que
+// respondió 405 (vacío si el path no existe)
+func AllowedMethods(path string) string {
+	return strings.Join(apiRoutes.Allowed(stripStagePrefix(path)), ", ")
+}
 
-	case strings.HasPrefix(path, "/frequent-parties"):
-		return ProccessFrequentParties(body, path, method, userUUID, request)
+// MatchRoute resuelve la ruta declarada para el método y path (sin prefijo).
+// Retorna la llave estilo API Gateway y los path params; lo usa el servidor local
+// para armar el evento igual que API Gateway.
+func MatchRoute(method string, path string) (string, map[string]string) {
+	route, params, status := apiRoutes.Match(method, path)
+	if status != 200 {
+		return "$default", nil
+	}
+	if len(params) == 0 {
+		return route.Key(), nil
+	}
+	return route.Key(), params
+}
 
-	case strings.HasPrefix(path, "/assignments"):
-		return ProccessAssignments(body, path, method, userUUID, request)
+// tokenVerifier valida el JWT cuando el request no pasó por el authorizer
+// de API Gateway (p.ej. invocación directa o servidor local). Se configura
+// con las variables de auth.NewVerifierFromEnv; nil deshabilita el respaldo.
+// Si la configuración es inválida, el respaldo queda deshabilitado.
+var (
+	tokenVerifier     *auth.Verifier
+	tokenVerifierOnce sync.Once
+)
 
-	default:
-		return 400, "Method Invalid"
-	}
+// InitTokenVerifier inyecta el verificador de JWT (útil en pruebas)
+func InitTokenVerifier(v *auth.Verifier) {
+	tokenVerifierOnce.Do(func() {})
+	tokenVerifier = v
+}
 
+func getTokenVerifier() *auth.Verifier {
+	tokenVerifierOnce.Do(func() {
+		verifier, err := auth.NewVerifierFromEnv()
+		if err != nil {
+			fmt.Println("Verificador de JWT deshabilitado:", err.Error())
+		}
+		tokenVerifier = verifier
+	})
+	return tokenVerifier
 }
 
 func validateAuthorization(path string, method string, request events.APIGatewayV2HTTPRequest) (bool, int, string) {
@@ -72,7 +159,7 @@ func validateAuthorization(path string, method string, request events.APIGateway
 	if request.RequestContext.Authorizer == nil ||
 		request.RequestContext.Authorizer.JWT == nil ||
 		request.RequestContext.Authorizer.JWT.Claims == nil {
-		return false, 401, "No autorizado"
+		return verifyBearerToken(request)
 	}
 
 	claims := request.RequestContext.Authorizer.JWT.Claims
@@ -85,422 +172,891 @@ func validateAuthorization(path string, method string, request events.APIGateway
 	return true, 200, userUUID
 }
 
-func ProcesoGuias(body string, path string, method string, user string, id int, request events.APIGatewayV2HTTPRequest) (int, string) {
-	fmt.Printf("ProcesoGuias -> Path: %s, Mehtod: %s \n", path, method)
-
-	switch {
-	// GET /guides - Obtener lista de guías con filtros
-	case path == "/guides" && method == "GET":
-		return routers.GetGuides(request, user)
-
-	// GET /guides/stats - Obtener estadísticas de guías
-	case path == "/guides/stats" && method == "GET":
-		return routers.GetGuidesStats(user)
-
-	// GET /guides/search
-	case path == "/guides/search" && method == "GET":
-		searchTerm := ""
-		if request.QueryStringParameters != nil {
-			searchTerm = request.QueryStringParameters["q"]
-		}
-
-		if searchTerm == "" {
-			return 400, `{"error": "Parámetro 'q' requerido para búsqueda"}`
-		}
-		return routers.SearchGuides(searchTerm)
-
-	// GET /guides/{id}/pdf - Obtener URL pre-firmada para descargar PDF
-	case strings.HasPrefix(path, "/guides/") && strings.HasSuffix(path, "/pdf") && method == "GET":
-		if id <= 0 {
-			return 400, `{"error": "ID de guía inválido"}`
-		}
-		return routers.GetGuidePDFURL(int64(id))
-
-	// GET /guides/{id} - Obtener detalle de una guía específica
-	case strings.HasPrefix(path, "/guides/") && !strings.Contains(path, "/status") && method == "GET":
-		if id <= 0 {
-			return 400, `{"error": "ID de guía inválido"}`
-		}
-		return routers.GetGuideByID(int64(id))
+// verifyBearerToken valida el header Authorization con el verificador local
+// y retorna el sub del token, como lo haría el authorizer JWT.
+func verifyBearerToken(request events.APIGatewayV2HTTPRequest) (bool, int, string) {
+	verifier := getTokenVerifier()
+	if verifier == nil {
+		return false, 401, "No autorizado"
+	}
 
-	// PUT /guides/{id}/status - Actualizar estado de una guía
-	case strings.HasPrefix(path, "/guides/") && strings.HasSuffix(path, "/status") && method == "PUT":
-		if id <= 0 {
-			return 400, `{"error": "ID de guía inválido"}`
-		}
-		return routers.UpdateGuideStatus(int64(id), body, user)
+	token := request.Headers["authorization"]
+	if token == "" {
+		token = request.Headers["Authorization"]
+	}
+	if token == "" {
+		return false, 401, "No autorizado"
+	}
 
-	default:
-		return 400, "Method Invalid"
+	claims, err := verifier.Verify(token)
+	if err != nil {
+		fmt.Println("Token rechazado:", err.Error())
+		return false, 401, "No autorizado"
 	}
 
+	return true, 200, claims.Sub
 }
 
-func ProcesoAutencaciones(body string, path string, method string, user string, id string, request events.APIGatewayV2HTTPRequest) (int, string) {
-	switch {
-	case path == "/auth/role" && method == "GET":
-		return routers.GetRole(user)
+// NewAPIRouter registra todas las rutas del API
+func NewAPIRouter() *Router {
+	r := NewRouter()
+
+	registerAuthRoutes(r)
+	registerLocationRoutes(r)
+	registerGuideRoutes(r)
+	registerScanRoutes(r)
+	registerQuoteRoutes(r)
+	registerPublicTrackRoutes(r)
+	registerCashCloseRoutes(r)
+	registerClientRoutes(r)
+	registerFrequentPartyRoutes(r)
+	registerAssignmentRoutes(r)
+	registerWarehouseRoutes(r)
+	registerManifestRoutes(r)
+	registerHubRoutes(r)
+	registerAdminRoutes(r)
+
+	// Una ruta sin política explícita es un error de programación: falla al iniciar
+	if err := r.Validate(); err != nil {
+		panic("política de acceso inválida en " + err.Error())
 	}
 
-	return 400, "Method Invalid"
+	return r
 }
 
-func ProcesoUbicaciones(body string, path string, method string, user string, id string, request events.APIGatewayV2HTTPRequest) (int, string) {
-	fmt.Printf("ProcesoUbicaciones -> Path: %s, Mehtod: %s \n", path, method)
+func registerAuthRoutes(r *Router) {
+	// GET /auth/role - Obtener rol del usuario autenticado
+	r.Handle("GET", "/auth/role", authenticatedOnly, func(c RouteContext) (int, string) {
+		return routers.GetRole(c.User)
+	})
+}
 
-	switch {
+func registerLocationRoutes(r *Router) {
 	// GET /locations/departments - Obtener todos los departamentos
-	case path == "/locations/departments" && method == "GET":
+	r.Handle("GET", "/locations/departments", allow(rolesAll), func(c RouteContext) (int, string) {
 		return routers.GetDepartments()
+	})
 
 	// GET /locations/cities - Obtener ciudades (con filtro opcional por departamento)
-	case path == "/locations/cities" && method == "GET":
-		// Obtener parámetro de query department_id si existe
-		departmentID := ""
-		if request.QueryStringParameters != nil {
-			departmentID = request.QueryStringParameters["department_id"]
-		}
-		return routers.GetCities(departmentID)
+	r.Handle("GET", "/locations/cities", allow(rolesAll), func(c RouteContext) (int, string) {
+		return routers.GetCities(queryParam(c.Request, "department_id"))
+	})
 
 	// GET /locations/cities/{id} - Obtener una ciudad especifica
-	case strings.HasPrefix(path, "/locations/cities/") && method == "GET":
-		// Extraer el ID de la ciudad del path
-		cityIDStr := strings.TrimPrefix(path, "/locations/cities/")
-		cityID, err := strconv.ParseInt(cityIDStr, 10, 64)
+	r.Handle("GET", "/locations/cities/{id:int}", allow(rolesAll), func(c RouteContext) (int, string) {
+		cityID, err := c.Params.Int64("id")
 		if err != nil {
-			return 400, fmt.Sprintf(`{"error": "ID de ciudad inválido"}`)
+			return 400, `{"error": "ID de ciudad inválido"}`
 		}
 		return routers.GetCityByID(cityID)
+	})
 
 	// GET /locations/search - Búsqueda de ciudades por nombre
-	case path == "/locations/search" && method == "GET":
-		// Obtener parámetro de query search_term si existe
-		searchTerm := ""
-		if request.QueryStringParameters != nil {
-			searchTerm = request.QueryStringParameters["q"]
+	r.Handle("GET", "/locations/search", allow(rolesAll), func(c RouteContext) (int, string) {
+		searchTerm := queryParam(c.Request, "q")
+		if searchTerm == "" {
+			return 400, `{"error": "Parámetro 'q' requerido para búsqueda"}`
 		}
+		return routers.SearchCities(searchTerm)
+	})
+}
+
+func registerQuoteRoutes(r *Router) {
+	// POST /quotes - Cotizar envío (público, lo usa el sitio web)
+	r.Handle("POST", "/quotes", publicAccess, func(c RouteContext) (int, string) {
+		return routers.CreateQuote(c.Body)
+	})
+}
 
+func registerPublicTrackRoutes(r *Router) {
+	// GET /public/track/{guideNumber}?verify=1234 - Rastreo sin cuenta
+	// (público; segundo factor y límite de consultas por IP y por guía)
+	r.Handle("GET", "/public/track/{guideNumber}", publicAccess, func(c RouteContext) (int, string) {
+		return routers.PublicTrackGuide(c.Params.String("guideNumber"), queryParam(c.Request, "verify"), c.Request.RequestContext.HTTP.SourceIP)
+	})
+}
+
+// guideRef resuelve el parámetro {id} de las rutas de guías: acepta el
+// guide_id o el número de guía (ver routers.ResolveGuideRef)
+func guideRef(c RouteContext) (int64, int, string) {
+	return routers.ResolveGuideRef(c.Params.String("id"))
+}
+
+func registerGuideRoutes(r *Router) {
+	// GET /guides - Obtener lista de guías con filtros
+	r.Handle("GET", "/guides", allow(rolesAdminSecretary), func(c RouteContext) (int, string) {
+		return routers.GetGuides(c.Request, c.User)
+	})
+
+	// POST /guides - Crear guía (el precio se calcula con la tarifa vigente)
+	r.Handle("POST", "/guides", allow(rolesGuideCreators), func(c RouteContext) (int, string) {
+		return routers.CreateGuide(c.Body, c.User)
+	})
+
+	// GET /guides/stats - Obtener estadísticas de guías
+	r.Handle("GET", "/guides/stats", allow(rolesAdminSecretary), func(c RouteContext) (int, string) {
+		return routers.GetGuidesStats(c.User)
+	})
+
+	// GET /guides/status-reasons - Motivos de los estados de excepción
+	r.Handle("GET", "/guides/status-reasons", allow(rolesAdminSecretary), func(c RouteContext) (int, string) {
+		return routers.GetGuideStatusReasons()
+	})
+
+	// GET /guides/search
+	r.Handle("GET", "/guides/search", allow(rolesAdminSecretary), func(c RouteContext) (int, string) {
+		searchTerm := queryParam(c.Request, "q")
 		if searchTerm == "" {
 			return 400, `{"error": "Parámetro 'q' requerido para búsqueda"}`
 		}
-		return routers.SearchCities(searchTerm)
-	}
+		return routers.SearchGuides(searchTerm)
+	})
+
+	// GET /guides/{id} - Obtener detalle de una guía específica
+	r.Handle("GET", "/guides/{id}", allow(rolesAll).withOwner(clientOwnsGuide("id")), func(c RouteContext) (int, string) {
+		guideID, status, message := guideRef(c)
+		if status != 0 {
+			return status, message
+		}
+		return routers.GetGuideByID(guideID)
+	})
 
-	return 400, "Method Invalid"
+	// GET /guides/{id}/pdf - Obtener URL pre-firmada para descargar PDF
+	r.Handle("GET", "/guides/{id}/pdf", allow(rolesAll).withOwner(clientOwnsGuide("id")), func(c RouteContext) (int, string) {
+		guideID, status, message := guideRef(c)
+		if status != 0 {
+			return status, message
+		}
+		return routers.GetGuidePDFURL(guideID)
+	})
+
+	// POST /guides/{id}/pdf - (Re)generar el PDF de una guía
+	r.Handle("POST", "/guides/{id}/pdf", allow(rolesGuideCreators).withOwner(clientOwnsGuide("id")), func(c RouteContext) (int, string) {
+		guideID, status, message := guideRef(c)
+		if status != 0 {
+			return status, message
+		}
+		return routers.GenerateGuidePDF(guideID)
+	})
+
+	// PUT /guides/{id}/status - Actualizar estado de una guía
+	r.Handle("PUT", "/guides/{id}/status", allow(rolesAdminSecretary), func(c RouteContext) (int, string) {
+		guideID, status, message := guideRef(c)
+		if status != 0 {
+			return status, message
+		}
+		return routers.UpdateGuideStatus(guideID, c.Body, c.User, c.Role)
+	})
+
+	// GET /guides/{id}/attempts - Intentos de entrega de una guía
+	r.Handle("GET", "/guides/{id}/attempts", allow(rolesAdminSecretary), func(c RouteContext) (int, string) {
+		guideID, status, message := guideRef(c)
+		if status != 0 {
+			return status, message
+		}
+		return routers.GetGuideDeliveryAttempts(guideID)
+	})
+
+	// GET /guides/{id}/proof - Prueba de entrega de una guía
+	r.Handle("GET", "/guides/{id}/proof", allow(rolesAdminSecretary), func(c RouteContext) (int, string) {
+		guideID, status, message := guideRef(c)
+		if status != 0 {
+			return status, message
+		}
+		return routers.GetDeliveryProof(guideID)
+	})
+
+	// GET /guides/{id}/barcode?type=code128|qr&format=png|svg&piece=N - Código de la guía o de una pieza
+	r.Handle("GET", "/guides/{id}/barcode", allow(rolesAll).withOwner(clientOwnsGuide("id")), func(c RouteContext) (int, string) {
+		guideID, status, message := guideRef(c)
+		if status != 0 {
+			return status, message
+		}
+		return routers.GetGuideBarcode(guideID, queryParam(c.Request, "type"), queryParam(c.Request, "format"), queryParam(c.Request, "piece"))
+	})
+
+	// GET /guides/{id}/label?format=zpl|pdf - Rótulo térmico 10x15 cm (uno por pieza)
+	r.Handle("GET", "/guides/{id}/label", allow(rolesGuideCreators).withOwner(clientOwnsGuide("id")), func(c RouteContext) (int, string) {
+		guideID, status, message := guideRef(c)
+		if status != 0 {
+			return status, message
+		}
+		return routers.GetGuideLabel(guideID, queryParam(c.Request, "format"))
+	})
+
+	// GET /guides/{id}/location - Ubicación en bodega e historial de movimientos
+	r.Handle("GET", "/guides/{id}/location", allow(rolesAdminSecretary), func(c RouteContext) (int, string) {
+		guideID, status, message := guideRef(c)
+		if status != 0 {
+			return status, message
+		}
+		return routers.GetGuideLocation(guideID)
+	})
+
+	// GET /guides/{id}/rndc - XML de la remesa del RNDC, validación y envíos
+	r.Handle("GET", "/guides/{id}/rndc", allow(rolesAdminSecretary), func(c RouteContext) (int, string) {
+		guideID, status, message := guideRef(c)
+		if status != 0 {
+			return status, message
+		}
+		return routers.GetGuideRNDC(guideID)
+	})
+
+	// POST /guides/{id}/rndc - Reportar la remesa al RNDC (ADMIN)
+	r.Handle("POST", "/guides/{id}/rndc", allow(rolesAdmin), func(c RouteContext) (int, string) {
+		guideID, status, message := guideRef(c)
+		if status != 0 {
+			return status, message
+		}
+		return routers.SubmitGuideRNDC(guideID, c.User)
+	})
+}
+
+func registerScanRoutes(r *Router) {
+	// POST /scans - Lote de códigos escaneados en recepción, bodega o despacho
+	r.Handle("POST", "/scans", allow(rolesAdminSecretary), func(c RouteContext) (int, string) {
+		return routers.RegisterScans(c.Body, c.User, c.Role)
+	})
 }
 
-func ProccessCashClose(body string, path string, method string, user string, id int, request events.APIGatewayV2HTTPRequest) (int, string) {
-	switch {
+func registerCashCloseRoutes(r *Router) {
 	// POST /cash-close - Generate new close
-	case path == "/cash-close" && method == "POST":
-		return routers.GenerateCashClose(body, user)
+	r.Handle("POST", "/cash-close", allow(rolesAdmin), func(c RouteContext) (int, string) {
+		scope, status, message := hubScope(c)
+		if status != 0 {
+			return status, message
+		}
+		return routers.GenerateCashClose(c.Body, c.User, scope)
+	})
 
 	// GET /cash-close - List closes
-	case path == "/cash-close" && method == "GET":
-		return routers.GetCashCloses(request)
+	r.Handle("GET", "/cash-close", allow(rolesAdmin), func(c RouteContext) (int, string) {
+		scope, status, message := hubScope(c)
+		if status != 0 {
+			return status, message
+		}
+		return routers.GetCashCloses(c.Request, scope)
+	})
 
 	// GET /cash-close/stats - Statistics
-	case path == "/cash-close/stats" && method == "GET":
-		return routers.GetCashCloseStats()
-
-	// GET /cash-close/{id}/pdf - Get specific close PDF
-	case strings.HasPrefix(path, "/cash-close/") && strings.Contains(path, "/pdf") && method == "GET":
-		if id <= 0 {
-			return 400, `{"error": "Invalid close ID"}`
+	r.Handle("GET", "/cash-close/stats", allow(rolesAdmin), func(c RouteContext) (int, string) {
+		scope, status, message := hubScope(c)
+		if status != 0 {
+			return status, message
 		}
-		return routers.GetCashClosePDFURL(int64(id))
+		return routers.GetCashCloseStats(scope)
+	})
 
 	// GET /cash-close/{id} - Get specific close
-	case strings.HasPrefix(path, "/cash-close/") && !strings.Contains(path, "/stats") && method == "GET":
-		if id <= 0 {
+	r.Handle("GET", "/cash-close/{id:int}", allow(rolesAdmin), func(c RouteContext) (int, string) {
+		closeID, err := c.Params.Int64("id")
+		if err != nil {
 			return 400, `{"error": "Invalid close ID"}`
 		}
-		return routers.GetCashCloseByID(int64(id))
+		return routers.GetCashCloseByID(closeID)
+	})
 
-	default:
-		return 400, "Method Invalid"
-	}
+	// GET /cash-close/{id}/pdf - Get specific close PDF
+	r.Handle("GET", "/cash-close/{id:int}/pdf", allow(rolesAdmin), func(c RouteContext) (int, string) {
+		closeID, err := c.Params.Int64("id")
+		if err != nil {
+			return 400, `{"error": "Invalid close ID"}`
+		}
+		return routers.GetCashClosePDFURL(closeID)
+	})
 }
 
-func ProccessClient(body string, path string, method string, user string, id string, request events.APIGatewayV2HTTPRequest) (int, string) {
-	fmt.Printf("ProccessClient -> Path:%s, Method: %s\n", path, method)
-
-	switch {
-
+func registerClientRoutes(r *Router) {
 	// GET /client/profile - Obtener perfil del cliente
-	case path == "/client/profile" && method == "GET":
-		return routers.GetClientProfile(user)
+	r.Handle("GET", "/client/profile", allow(rolesClient), func(c RouteContext) (int, string) {
+		return routers.GetClientProfile(c.User)
+	})
 
 	// PUT /client/profile
-	case path == "/client/profile" && method == "PUT":
-		return routers.UpdateClientProfile(body, user)
+	r.Handle("PUT", "/client/profile", allow(rolesClient), func(c RouteContext) (int, string) {
+		return routers.UpdateClientProfile(c.Body, c.User)
+	})
 
 	// GET /client/guides/active - Obtener guías activas del cliente
-	case path == "/client/guides/active" && method == "GET":
-		return routers.GetClientActiveGuides(user)
+	r.Handle("GET", "/client/guides/active", allow(rolesClient), func(c RouteContext) (int, string) {
+		return routers.GetClientActiveGuides(c.User)
+	})
 
 	// GET /client/guides/history - Obtener histórico de guías del cliente
-	case path == "/client/guides/history" && method == "GET":
-		return routers.GetClientGuideHistory(request, user)
+	r.Handle("GET", "/client/guides/history", allow(rolesClient), func(c RouteContext) (int, string) {
+		return routers.GetClientGuideHistory(c.Request, c.User)
+	})
 
 	// GET /client/guides/track/{guideNumber} - Rastrear guía por número
-	case strings.HasPrefix(path, "/client/guides/track/") && method == "GET":
-		guideNumber := strings.TrimPrefix(path, "/client/guides/track/")
+	r.Handle("GET", "/client/guides/track/{guideNumber}", allow(rolesClient).withOwner(clientOwnsGuide("guideNumber")), func(c RouteContext) (int, string) {
+		guideNumber := c.Params.String("guideNumber")
 		if guideNumber == "" {
 			return 400, `{"error": "Number of guide required"}`
 		}
-		return routers.TrackGuideByNumber(guideNumber, user)
+		return routers.TrackGuideByNumber(guideNumber, c.User)
+	})
 
-	// GET client/stats - Obtener estadísticas del cliente
-	case path == "/client/stats" && method == "GET":
-		return routers.GetClientStats(user)
+	// GET /client/stats - Obtener estadísticas del cliente
+	// API Gateway publica la ruta como /client/guides/stats, se registran ambas
+	clientStats := func(c RouteContext) (int, string) {
+		return routers.GetClientStats(c.User)
+	}
+	r.Handle("GET", "/client/stats", allow(rolesClient), clientStats)
+	r.Handle("GET", "/client/guides/stats", allow(rolesClient), clientStats)
 
 	// GET /client/ratings/pending - Obtener entregas pendientes de calificar
-	case path == "/client/ratings/pending" && method == "GET":
-		return routers.GetClientPendingRatings(user)
-
-	// POST /client/ratings - Crear calificación (alternativa)
-	case path == "/client/ratings" && method == "POST":
-		return routers.CreateDeliveryRating(body, user)
-
-	default:
-		return 400, "Method Invalid"
-	}
+	r.Handle("GET", "/client/ratings/pending", allow(rolesClient), func(c RouteContext) (int, string) {
+		return routers.GetClientPendingRatings(c.User)
+	})
+
+	// POST /client/ratings - Crear calificación
+	r.Handle("POST", "/client/ratings", allow(rolesClient), func(c RouteContext) (int, string) {
+		return routers.CreateDeliveryRating(c.Body, c.User, 0)
+	})
 }
 
-// ProccessFrequentParties maneja las peticiones de partes frecuentes
-func ProccessFrequentParties(body string, path string, method string, user string, request events.APIGatewayV2HTTPRequest) (int, string) {
-	fmt.Printf("ProccessFrequentParties -> Path:%s, Method: %s\n", path, method)
-
-	switch {
-
+func registerFrequentPartyRoutes(r *Router) {
 	// GET /frequent-parties/search-by-name - Buscar SOLO por nombre (autocompletado inicial)
 	// Retorna clientes únicos sin importar la ciudad
-	case path == "/frequent-parties/search-by-name" && method == "GET":
-		searchTerm := ""
-		partyTypeStr := ""
-
-		if request.QueryStringParameters != nil {
-			searchTerm = request.QueryStringParameters["q"]
-			partyTypeStr = request.QueryStringParameters["party_type"]
-		}
-
+	r.Handle("GET", "/frequent-parties/search-by-name", allow(rolesGuideCreators), func(c RouteContext) (int, string) {
+		searchTerm := queryParam(c.Request, "q")
 		if searchTerm == "" {
 			return 400, `{"error": "Parámetro 'q' requerido para búsqueda"}`
 		}
-
-		var partyType models.PartyType
-		if partyTypeStr == "SENDER" {
-			partyType = models.PartySender
-		} else if partyTypeStr == "RECEIVER" {
-			partyType = models.PartyReceiver
-		}
-
-		return routers.SearchFrequentPartiesByNameOnly(searchTerm, partyType)
+		return routers.SearchFrequentPartiesByNameOnly(searchTerm, parsePartyType(queryParam(c.Request, "party_type")))
+	})
 
 	// GET /frequent-parties/search-by-name-and-city - Buscar por nombre Y ciudad
-	// Retorna TODAS las direcciones de ese cliente en esa ciudad
-	case path == "/frequent-parties/search-by-name-and-city" && method == "GET":
-		searchTerm := ""
-		cityIDStr := ""
-		partyTypeStr := ""
-
-		if request.QueryStringParameters != nil {
-			searchTerm = request.QueryStringParameters["q"]
-			cityIDStr = request.QueryStringParameters["city_id"]
-			partyTypeStr = request.QueryStringParameters["party_type"]
-		}
-
+	// Retorna TODAS las direcciones de ese cliente en esa ciudad.
+	// API Gateway publica la ruta como /frequent-parties/by-name-and-city, se registran ambas
+	byNameAndCity := func(c RouteContext) (int, string) {
+		searchTerm := queryParam(c.Request, "q")
 		if searchTerm == "" {
 			return 400, `{"error": "Parámetro 'q' requerido para búsqueda"}`
 		}
 
-		if cityIDStr == "" {
-			return 400, `{"error": "Parámetro 'city_id' requerido"}`
-		}
-
-		cityID, err := strconv.ParseInt(cityIDStr, 10, 64)
-		if err != nil {
-			return 400, `{"error": "city_id debe ser un número válido"}`
-		}
-
-		var partyType models.PartyType
-		if partyTypeStr == "SENDER" {
-			partyType = models.PartySender
-		} else if partyTypeStr == "RECEIVER" {
-			partyType = models.PartyReceiver
+		cityID, status, message := requiredCityID(c.Request)
+		if status != 0 {
+			return status, message
 		}
 
-		return routers.SearchFrequentPartiesByNameAndCity(searchTerm, cityID, partyType)
+		return routers.SearchFrequentPartiesByNameAndCity(searchTerm, cityID, parsePartyType(queryParam(c.Request, "party_type")))
+	}
+	r.Handle("GET", "/frequent-parties/search-by-name-and-city", allow(rolesGuideCreators), byNameAndCity)
+	r.Handle("GET", "/frequent-parties/by-name-and-city", allow(rolesGuideCreators), byNameAndCity)
 
 	// GET /frequent-parties/by-document - Obtener direcciones por documento y ciudad
-	case path == "/frequent-parties/by-document" && method == "GET":
-		documentNumber := ""
-		cityIDStr := ""
-		partyTypeStr := ""
-
-		if request.QueryStringParameters != nil {
-			documentNumber = request.QueryStringParameters["document_number"]
-			cityIDStr = request.QueryStringParameters["city_id"]
-			partyTypeStr = request.QueryStringParameters["party_type"]
-		}
-
+	r.Handle("GET", "/frequent-parties/by-document", allow(rolesGuideCreators), func(c RouteContext) (int, string) {
+		documentNumber := queryParam(c.Request, "document_number")
 		if documentNumber == "" {
 			return 400, `{"error": "Parámetro 'document_number' requerido"}`
 		}
 
-		if cityIDStr == "" {
-			return 400, `{"error": "Parámetro 'city_id' requerido"}`
-		}
-
-		cityID, err := strconv.ParseInt(cityIDStr, 10, 64)
-		if err != nil {
-			return 400, `{"error": "city_id debe ser un número válido"}`
-		}
-
-		var partyType models.PartyType
-		if partyTypeStr == "SENDER" {
-			partyType = models.PartySender
-		} else if partyTypeStr == "RECEIVER" {
-			partyType = models.PartyReceiver
+		cityID, status, message := requiredCityID(c.Request)
+		if status != 0 {
+			return status, message
 		}
 
-		return routers.GetFrequentPartiesByDocument(documentNumber, cityID, partyType)
+		return routers.GetFrequentPartiesByDocument(documentNumber, cityID, parsePartyType(queryParam(c.Request, "party_type")))
+	})
 
 	// POST /frequent-parties - Registrar nueva parte frecuente
-	case path == "/frequent-parties" && method == "POST":
-		return routers.UpsertFrequentParty(body)
+	r.Handle("POST", "/frequent-parties", allow(rolesGuideCreators), func(c RouteContext) (int, string) {
+		return routers.UpsertFrequentParty(c.Body)
+	})
 
 	// GET /frequent-parties/stats - Obtener estadísticas
-	case path == "/frequent-parties/stats" && method == "GET":
+	r.Handle("GET", "/frequent-parties/stats", allow(rolesAdminSecretary), func(c RouteContext) (int, string) {
 		return routers.GetFrequentPartyStats()
-
-	default:
-		return 400, "Method Invalid"
-	}
+	})
 }
 
-func ProccessAssignments(body string, path string, method string, user string, request events.APIGatewayV2HTTPRequest) (int, string) {
-	fmt.Printf("ProccessAssignments -> Path:%s, Method: %s\n", path, method)
-
-	switch {
-	// ==========================================
-	// RUTAS EXACTAS
-	// ==========================================
-
+func registerAssignmentRoutes(r *Router) {
 	// POST /assignments - Crear asignación
-	case path == "/assignments" && method == "POST":
-		return routers.CreateAssignment(body, user)
+	r.Handle("POST", "/assignments", allow(rolesAdminSecretary), func(c RouteContext) (int, string) {
+		scope, status, message := hubScope(c)
+		if status != 0 {
+			return status, message
+		}
+		return routers.CreateAssignment(c.Body, c.User, scope)
+	})
 
 	// GET /assignments - Listar asignaciones
-	case path == "/assignments" && method == "GET":
-		return routers.GetAssignments(request)
+	r.Handle("GET", "/assignments", allow(rolesAdminSecretary), func(c RouteContext) (int, string) {
+		scope, status, message := hubScope(c)
+		if status != 0 {
+			return status, message
+		}
+		return routers.GetAssignments(c.Request, scope)
+	})
 
 	// GET /assignments/my - Listar mis asignaciones (DELIVERY)
-	case path == "/assignments/my" && method == "GET":
-		return routers.GetMyAssignments(user)
+	r.Handle("GET", "/assignments/my", allow(rolesDelivery), func(c RouteContext) (int, string) {
+		return routers.GetMyAssignments(c.User)
+	})
 
 	// GET /assignments/my/performance - Estadísticas de rendimiento (DELIVERY)
-	case path == "/assignments/my/performance" && method == "GET":
-		return routers.GetMyPerformanceStats(user)
+	r.Handle("GET", "/assignments/my/performance", allow(rolesDelivery), func(c RouteContext) (int, string) {
+		return routers.GetMyPerformanceStats(c.User)
+	})
 
 	// GET /assignments/delivery-users - Listar repartidores
-	case path == "/assignments/delivery-users" && method == "GET":
-		return routers.GetDeliveryUsers()
+	r.Handle("GET", "/assignments/delivery-users", allow(rolesAdminSecretary), func(c RouteContext) (int, string) {
+		scope, status, message := hubScope(c)
+		if status != 0 {
+			return status, message
+		}
+		return routers.GetDeliveryUsers(scope)
+	})
 
 	// GET /assignments/pending-guides - Listar guías pendientes
-	case path == "/assignments/pending-guides" && method == "GET":
-		return routers.GetPendingGuides(user)
+	r.Handle("GET", "/assignments/pending-guides", allow(rolesAdminSecretary), func(c RouteContext) (int, string) {
+		scope, status, message := hubScope(c)
+		if status != 0 {
+			return status, message
+		}
+		return routers.GetPendingGuides(scope)
+	})
 
 	// GET /assignments/stats - Obtener estadísticas (ADMIN, SECRETARY)
-	case path == "/assignments/stats" && method == "GET":
-		return routers.GetAssignmentStats(user)
+	r.Handle("GET", "/assignments/stats", allow(rolesAdminSecretary), func(c RouteContext) (int, string) {
+		scope, status, message := hubScope(c)
+		if status != 0 {
+			return status, message
+		}
+		return routers.GetAssignmentStats(scope)
+	})
 
-	// ==========================================
-	// RUTAS CON PARÁMETROS
-	// ==========================================
+	// GET /assignments/{id} - Obtener asignación
+	r.Handle("GET", "/assignments/{id:int}", allow(rolesAdminSecretary).withOwner(ownsAssignment), func(c RouteContext) (int, string) {
+		assignmentID, err := c.Params.Int64("id")
+		if err != nil {
+			return 400, `{"error": "ID de asignación inválido"}`
+		}
+		return routers.GetAssignmentByID(assignmentID)
+	})
 
 	// PUT /assignments/{id}/reassign
-	case strings.Contains(path, "/reassign") && method == "PUT":
-		return routers.ReassignDelivery(body, user, path)
+	r.Handle("PUT", "/assignments/{id:int}/reassign", allow(rolesAdmin), func(c RouteContext) (int, string) {
+		assignmentID, err := c.Params.Int64("id")
+		if err != nil {
+			return 400, `{"error": "ID de asignación inválido"}`
+		}
+		return routers.ReassignDelivery(c.Body, c.User, assignmentID)
+	})
 
 	// PUT /assignments/{id}/status
-	case strings.Contains(path, "/status") && method == "PUT":
-		return routers.UpdateAssignmentStatus(body, user, path)
+	r.Handle("PUT", "/assignments/{id:int}/status", allow(rolesStaff).withOwner(ownsAssignment), func(c RouteContext) (int, string) {
+		assignmentID, err := c.Params.Int64("id")
+		if err != nil {
+			return 400, `{"error": "ID de asignación inválido"}`
+		}
+		return routers.UpdateAssignmentStatus(c.Body, c.User, assignmentID)
+	})
+
+	// POST /assignments/{id}/attempts - Registrar intento de entrega fallido
+	r.Handle("POST", "/assignments/{id:int}/attempts", allow(rolesStaff).withOwner(ownsAssignment), func(c RouteContext) (int, string) {
+		assignmentID, err := c.Params.Int64("id")
+		if err != nil {
+			return 400, `{"error": "ID de asignación inválido"}`
+		}
+		return routers.RegisterDeliveryAttempt(c.Body, c.User, assignmentID)
+	})
+
+	// POST /assignments/{id}/proof/upload-urls - URLs de carga de firma y fotos
+	r.Handle("POST", "/assignments/{id:int}/proof/upload-urls", allow(rolesStaff).withOwner(ownsAssignment), func(c RouteContext) (int, string) {
+		assignmentID, err := c.Params.Int64("id")
+		if err != nil {
+			return 400, `{"error": "ID de asignación inválido"}`
+		}
+		return routers.RequestProofUploadURLs(c.Body, assignmentID)
+	})
+
+	// POST /assignments/{id}/handover-code - Reenviar código de entrega al destinatario
+	r.Handle("POST", "/assignments/{id:int}/handover-code", allow(rolesStaff).withOwner(ownsAssignment), func(c RouteContext) (int, string) {
+		assignmentID, err := c.Params.Int64("id")
+		if err != nil {
+			return 400, `{"error": "ID de asignación inválido"}`
+		}
+		return routers.ResendHandoverCode(assignmentID, c.User)
+	})
+
+	// POST /assignments/{id}/handover-code/override - Omitir código de entrega (ADMIN)
+	r.Handle("POST", "/assignments/{id:int}/handover-code/override", allow(rolesAdmin), func(c RouteContext) (int, string) {
+		assignmentID, err := c.Params.Int64("id")
+		if err != nil {
+			return 400, `{"error": "ID de asignación inválido"}`
+		}
+		return routers.OverrideHandoverCode(c.Body, assignmentID, c.User)
+	})
 
 	// GET /assignments/{id}/history
-	case strings.Contains(path, "/history") && method == "GET":
-		return routers.GetAssignmentHistory(user, path)
+	r.Handle("GET", "/assignments/{id:int}/history", allow(rolesAdminSecretary).withOwner(ownsAssignment), func(c RouteContext) (int, string) {
+		assignmentID, err := c.Params.Int64("id")
+		if err != nil {
+			return 400, `{"error": "ID de asignación inválido"}`
+		}
+		return routers.GetAssignmentHistory(assignmentID)
+	})
 
 	// POST /assignments/{id}/rate - Crear calificación (CLIENT)
-	case strings.Contains(path, "/rate") && method == "POST":
-		return routers.CreateDeliveryRating(body, user)
+	r.Handle("POST", "/assignments/{id:int}/rate", allow(rolesClient).withOwner(ownsAssignment), func(c RouteContext) (int, string) {
+		assignmentID, err := c.Params.Int64("id")
+		if err != nil {
+			return 400, `{"error": "ID de asignación inválido"}`
+		}
+		return routers.CreateDeliveryRating(c.Body, c.User, assignmentID)
+	})
 
 	// GET /assignments/{id}/rating - Obtener calificación
-	case strings.Contains(path, "/rating") && method == "GET":
-		assignmentID := extractAssignmentIDFromPath(path, "/rating")
-		if assignmentID == 0 {
+	r.Handle("GET", "/assignments/{id:int}/rating", allow(rolesAll).withOwner(ownsAssignment), func(c RouteContext) (int, string) {
+		assignmentID, err := c.Params.Int64("id")
+		if err != nil {
 			return 400, `{"error": "ID de asignación inválido"}`
 		}
-		return routers.GetAssignmentRating(user, assignmentID)
+		return routers.GetAssignmentRating(assignmentID)
+	})
+}
 
-	// GET /assignments/{id} - obtener asignación (debe ser la última ruta con prefijo)
-	case strings.HasPrefix(path, "/assignments/") && method == "GET":
-		return routers.GetAssignmentByID(user, path)
+func registerWarehouseRoutes(r *Router) {
+	// POST /warehouses - Crear bodega (ADMIN)
+	r.Handle("POST", "/warehouses", allow(rolesAdmin), func(c RouteContext) (int, string) {
+		return routers.CreateWarehouse(c.Body, c.User)
+	})
+
+	// GET /warehouses - Listar bodegas
+	r.Handle("GET", "/warehouses", allow(rolesAdminSecretary), func(c RouteContext) (int, string) {
+		return routers.GetWarehouses()
+	})
+
+	// GET /warehouses/aging?warehouse_id=&min_days= - Antigüedad de guías en bodega
+	r.Handle("GET", "/warehouses/aging", allow(rolesAdminSecretary), func(c RouteContext) (int, string) {
+		return routers.GetWarehouseAging(c.Request)
+	})
+
+	// POST /warehouses/check-in - Ingresar guías o piezas a un bin
+	r.Handle("POST", "/warehouses/check-in", allow(rolesAdminSecretary), func(c RouteContext) (int, string) {
+		return routers.WarehouseCheckIn(c.Body, c.User, c.Role)
+	})
+
+	// POST /warehouses/move - Mover guías o piezas a otro bin
+	r.Handle("POST", "/warehouses/move", allow(rolesAdminSecretary), func(c RouteContext) (int, string) {
+		return routers.WarehouseMove(c.Body, c.User, c.Role)
+	})
+
+	// POST /warehouses/check-out - Sacar guías o piezas de la bodega
+	r.Handle("POST", "/warehouses/check-out", allow(rolesAdminSecretary), func(c RouteContext) (int, string) {
+		return routers.WarehouseCheckOut(c.Body, c.User)
+	})
+
+	// POST /warehouses/{id}/bins - Crear bin (ADMIN)
+	r.Handle("POST", "/warehouses/{id:int}/bins", allow(rolesAdmin), func(c RouteContext) (int, string) {
+		warehouseID, err := c.Params.Int64("id")
+		if err != nil {
+			return 400, `{"error": "ID de bodega inválido"}`
+		}
+		return routers.CreateBin(warehouseID, c.Body)
+	})
 
-	default:
-		return 404, `{"error": "Ruta no encontrada"}`
-	}
+	// GET /warehouses/{id}/bins - Bins de la bodega
+	r.Handle("GET", "/warehouses/{id:int}/bins", allow(rolesAdminSecretary), func(c RouteContext) (int, string) {
+		warehouseID, err := c.Params.Int64("id")
+		if err != nil {
+			return 400, `{"error": "ID de bodega inválido"}`
+		}
+		return routers.GetWarehouseBins(warehouseID)
+	})
 
+	// GET /warehouses/{id}/stock?bin_id= - Guías y piezas ubicadas en la bodega
+	r.Handle("GET", "/warehouses/{id:int}/stock", allow(rolesAdminSecretary), func(c RouteContext) (int, string) {
+		warehouseID, err := c.Params.Int64("id")
+		if err != nil {
+			return 400, `{"error": "ID de bodega inválido"}`
+		}
+		return routers.GetWarehouseStock(warehouseID, c.Request)
+	})
+
+	// POST /warehouses/{id}/inventory-counts - Iniciar conteo físico
+	r.Handle("POST", "/warehouses/{id:int}/inventory-counts", allow(rolesAdminSecretary), func(c RouteContext) (int, string) {
+		warehouseID, err := c.Params.Int64("id")
+		if err != nil {
+			return 400, `{"error": "ID de bodega inválido"}`
+		}
+		return routers.StartInventoryCount(warehouseID, c.Body, c.User)
+	})
+
+	// GET /inventory-counts/{id} - Conteo con su conciliación
+	r.Handle("GET", "/inventory-counts/{id:int}", allow(rolesAdminSecretary), func(c RouteContext) (int, string) {
+		countID, err := c.Params.Int64("id")
+		if err != nil {
+			return 400, `{"error": "ID de conteo inválido"}`
+		}
+		return routers.GetInventoryCount(countID)
+	})
+
+	// POST /inventory-counts/{id}/scans - Registrar códigos escaneados en un bin
+	r.Handle("POST", "/inventory-counts/{id:int}/scans", allow(rolesAdminSecretary), func(c RouteContext) (int, string) {
+		countID, err := c.Params.Int64("id")
+		if err != nil {
+			return 400, `{"error": "ID de conteo inválido"}`
+		}
+		return routers.ScanInventoryCount(countID, c.Body, c.User)
+	})
+
+	// POST /inventory-counts/{id}/close - Cerrar el conteo (apply: reubicar hallazgos)
+	r.Handle("POST", "/inventory-counts/{id:int}/close", allow(rolesAdminSecretary), func(c RouteContext) (int, string) {
+		countID, err := c.Params.Int64("id")
+		if err != nil {
+			return 400, `{"error": "ID de conteo inválido"}`
+		}
+		return routers.CloseInventoryCount(countID, c.Body, c.User)
+	})
 }
 
-// extractAssignmentIDFromPath extrae el ID de asignación del path
-func extractAssignmentIDFromPath(path string, suffix string) int64 {
-	path = strings.TrimPrefix(path, "/assignments/")
-	path = strings.TrimSuffix(path, suffix)
-	id, err := strconv.ParseInt(path, 10, 64)
-	if err != nil {
-		return 0
-	}
-	return id
+func registerManifestRoutes(r *Router) {
+	// POST /manifests - Crear manifiesto de despacho
+	r.Handle("POST", "/manifests", allow(rolesAdminSecretary), func(c RouteContext) (int, string) {
+		return routers.CreateManifest(c.Body, c.User)
+	})
+
+	// GET /manifests?status=&route=&date= - Listar manifiestos
+	r.Handle("GET", "/manifests", allow(rolesAdminSecretary), func(c RouteContext) (int, string) {
+		return routers.GetManifests(c.Request)
+	})
+
+	// GET /manifests/pending-guides?route= - Guías en bodega pendientes de despacho
+	r.Handle("GET", "/manifests/pending-guides", allow(rolesAdminSecretary), func(c RouteContext) (int, string) {
+		return routers.GetPendingDispatchGuides(c.Request)
+	})
+
+	// GET /manifests/{id} - Manifiesto con sus guías y totales
+	r.Handle("GET", "/manifests/{id:int}", allow(rolesAdminSecretary), func(c RouteContext) (int, string) {
+		manifestID, err := c.Params.Int64("id")
+		if err != nil {
+			return 400, `{"error": "ID de manifiesto inválido"}`
+		}
+		return routers.GetManifest(manifestID)
+	})
+
+	// POST /manifests/{id}/guides - Cargar guías al manifiesto
+	r.Handle("POST", "/manifests/{id:int}/guides", allow(rolesAdminSecretary), func(c RouteContext) (int, string) {
+		manifestID, err := c.Params.Int64("id")
+		if err != nil {
+			return 400, `{"error": "ID de manifiesto inválido"}`
+		}
+		return routers.AddManifestGuides(manifestID, c.Body, c.User)
+	})
+
+	// POST /manifests/{id}/close - Despachar: guías a IN_ROUTE
+	r.Handle("POST", "/manifests/{id:int}/close", allow(rolesAdminSecretary), func(c RouteContext) (int, string) {
+		manifestID, err := c.Params.Int64("id")
+		if err != nil {
+			return 400, `{"error": "ID de manifiesto inválido"}`
+		}
+		return routers.CloseManifest(manifestID, c.User)
+	})
+
+	// POST /manifests/{id}/receive - Recibir en destino: guías a IN_WAREHOUSE
+	r.Handle("POST", "/manifests/{id:int}/receive", allow(rolesAdminSecretary), func(c RouteContext) (int, string) {
+		manifestID, err := c.Params.Int64("id")
+		if err != nil {
+			return 400, `{"error": "ID de manifiesto inválido"}`
+		}
+		return routers.ReceiveManifest(manifestID, c.Body, c.User)
+	})
+
+	// GET /manifests/{id}/pdf - Manifiesto impreso
+	r.Handle("GET", "/manifests/{id:int}/pdf", allow(rolesAdminSecretary), func(c RouteContext) (int, string) {
+		manifestID, err := c.Params.Int64("id")
+		if err != nil {
+			return 400, `{"error": "ID de manifiesto inválido"}`
+		}
+		return routers.GetManifestPDF(manifestID)
+	})
+
+	// GET /manifests/{id}/rndc - XML del manifiesto de carga del RNDC, validación y envíos
+	r.Handle("GET", "/manifests/{id:int}/rndc", allow(rolesAdminSecretary), func(c RouteContext) (int, string) {
+		manifestID, err := c.Params.Int64("id")
+		if err != nil {
+			return 400, `{"error": "ID de manifiesto inválido"}`
+		}
+		return routers.GetManifestRNDC(manifestID)
+	})
+
+	// POST /manifests/{id}/rndc - Reportar remesas pendientes y manifiesto al RNDC (ADMIN)
+	r.Handle("POST", "/manifests/{id:int}/rndc", allow(rolesAdmin), func(c RouteContext) (int, string) {
+		manifestID, err := c.Params.Int64("id")
+		if err != nil {
+			return 400, `{"error": "ID de manifiesto inválido"}`
+		}
+		return routers.SubmitManifestRNDC(manifestID, c.User)
+	})
 }
 
-// ProccessAdmin maneja las peticiones del panel de administración
-func ProccessAdmin(body string, path string, method string, user string, request events.APIGatewayV2HTTPRequest) (int, string) {
-	fmt.Printf("ProccessAdmin -> Path:%s, Method: %s\n", path, method)
+func registerHubRoutes(r *Router) {
+	// POST /hubs - Crear hub con su cobertura (ADMIN)
+	r.Handle("POST", "/hubs", allow(rolesAdmin), func(c RouteContext) (int, string) {
+		return routers.CreateHub(c.Body, c.User)
+	})
+
+	// GET /hubs - Listar hubs
+	r.Handle("GET", "/hubs", allow(rolesAdmin), func(c RouteContext) (int, string) {
+		return routers.GetHubs()
+	})
+
+	// GET /hubs/{id} - Obtener hub
+	r.Handle("GET", "/hubs/{id:int}", allow(rolesAdmin), func(c RouteContext) (int, string) {
+		hubID, err := c.Params.Int64("id")
+		if err != nil {
+			return 400, `{"error": "ID de hub inválido"}`
+		}
+		return routers.GetHubByID(hubID)
+	})
+
+	// PUT /hubs/{id} - Actualizar nombre, estado o cobertura
+	r.Handle("PUT", "/hubs/{id:int}", allow(rolesAdmin), func(c RouteContext) (int, string) {
+		hubID, err := c.Params.Int64("id")
+		if err != nil {
+			return 400, `{"error": "ID de hub inválido"}`
+		}
+		return routers.UpdateHub(hubID, c.Body)
+	})
+}
 
-	switch {
+func registerAdminRoutes(r *Router) {
 	// GET /admin/stats - Obtener estadísticas del dashboard
-	case path == "/admin/stats" && method == "GET":
-		return routers.GetAdminDashboardStats(user)
+	r.Handle("GET", "/admin/stats", allow(rolesAdmin), func(c RouteContext) (int, string) {
+		scope, status, message := hubScope(c)
+		if status != 0 {
+			return status, message
+		}
+		return routers.GetAdminDashboardStats(scope)
+	})
 
 	// GET /admin/employees - Listar empleados
-	case path == "/admin/employees" && method == "GET":
-		return routers.GetEmployees(request, user)
+	r.Handle("GET", "/admin/employees", allow(rolesAdmin), func(c RouteContext) (int, string) {
+		scope, status, message := hubScope(c)
+		if status != 0 {
+			return status, message
+		}
+		return routers.GetEmployees(c.Request, scope)
+	})
 
 	// GET /admin/users/search - Buscar usuario por documento
-	case path == "/admin/users/search" && method == "GET":
-		documentNumber := ""
-		if request.QueryStringParameters != nil {
-			documentNumber = request.QueryStringParameters["document"]
-		}
-		return routers.GetUserByDocument(user, documentNumber)
+	r.Handle("GET", "/admin/users/search", allow(rolesAdmin), func(c RouteContext) (int, string) {
+		return routers.GetUserByDocument(queryParam(c.Request, "document"))
+	})
 
 	// GET /admin/employees/{id} - Obtener empleado por ID
-	case strings.HasPrefix(path, "/admin/employees/") && method == "GET":
-		employeeID := strings.TrimPrefix(path, "/admin/employees/")
-		return routers.GetEmployeeByID(user, employeeID)
+	r.Handle("GET", "/admin/employees/{id}", allow(rolesAdmin), func(c RouteContext) (int, string) {
+		return routers.GetEmployeeByID(c.Params.String("id"))
+	})
 
 	// PUT /admin/employees/{id} - Actualizar empleado
-	case strings.HasPrefix(path, "/admin/employees/") && method == "PUT":
-		employeeID := strings.TrimPrefix(path, "/admin/employees/")
-		return routers.UpdateEmployee(body, user, employeeID)
+	r.Handle("PUT", "/admin/employees/{id}", allow(rolesAdmin), func(c RouteContext) (int, string) {
+		return routers.UpdateEmployee(c.Body, c.Params.String("id"))
+	})
 
 	// GET /admin/clients/ranking - Obtener ranking de mejores clientes
-	case path == "/admin/clients/ranking" && method == "GET":
-		return routers.GetClientRanking(request, user)
+	r.Handle("GET", "/admin/clients/ranking", allow(rolesAdmin), func(c RouteContext) (int, string) {
+		scope, status, message := hubScope(c)
+		if status != 0 {
+			return status, message
+		}
+		return routers.GetClientRanking(c.Request, scope)
+	})
+
+	// GET /admin/rates - Listar tarifas (filtros: origen, destino, ruta, estado)
+	r.Handle("GET", "/admin/rates", allow(rolesAdmin), func(c RouteContext) (int, string) {
+		return routers.GetRates(c.Request)
+	})
+
+	// POST /admin/rates - Crear tarifa o programar una nueva versión
+	r.Handle("POST", "/admin/rates", allow(rolesAdmin), func(c RouteContext) (int, string) {
+		return routers.CreateRate(c.Body, c.User)
+	})
+
+	// POST /admin/rates/import - Importar tarifario CSV/XLSX (dry_run para vista previa)
+	r.Handle("POST", "/admin/rates/import", allow(rolesAdmin), func(c RouteContext) (int, string) {
+		return routers.ImportRates(c.Body, c.User)
+	})
+
+	// GET /admin/rates/export - Exportar la tarifa vigente en CSV/XLSX
+	r.Handle("GET", "/admin/rates/export", allow(rolesAdmin), func(c RouteContext) (int, string) {
+		return routers.ExportRates(c.Request)
+	})
+
+	// GET /admin/rates/{id} - Obtener tarifa por ID
+	r.Handle("GET", "/admin/rates/{id:int}", allow(rolesAdmin), func(c RouteContext) (int, string) {
+		rateID, err := c.Params.Int64("id")
+		if err != nil {
+			return 400, `{"error": "ID de tarifa inválido"}`
+		}
+		return routers.GetRateByID(rateID)
+	})
 
-	default:
-		return 404, `{"error": "Ruta no encontrada"}`
+	// PUT /admin/rates/{id} - Actualizar tarifa programada
+	r.Handle("PUT", "/admin/rates/{id:int}", allow(rolesAdmin), func(c RouteContext) (int, string) {
+		rateID, err := c.Params.Int64("id")
+		if err != nil {
+			return 400, `{"error": "ID de tarifa inválido"}`
+		}
+		return routers.UpdateRate(rateID, c.Body, c.User)
+	})
+
+	// DELETE /admin/rates/{id} - Retirar tarifa
+	r.Handle("DELETE", "/admin/rates/{id:int}", allow(rolesAdmin), func(c RouteContext) (int, string) {
+		rateID, err := c.Params.Int64("id")
+		if err != nil {
+			return 400, `{"error": "ID de tarifa inválido"}`
+		}
+		return routers.RetireRate(rateID, c.User)
+	})
+
+	// GET /admin/rates/{id}/history - Historial de cambios de la tarifa
+	r.Handle("GET", "/admin/rates/{id:int}/history", allow(rolesAdmin), func(c RouteContext) (int, string) {
+		rateID, err := c.Params.Int64("id")
+		if err != nil {
+			return 400, `{"error": "ID de tarifa inválido"}`
+		}
+		return routers.GetRateHistory(rateID)
+	})
+}
+
+// hubScope alcance por hub del request: ADMIN elige con ?hub_id= (vacío:
+// consolidado) y los demás roles quedan en su propio hub
+func hubScope(c RouteContext) (models.HubScope, int, string) {
+	return routers.ResolveHubScope(c.Role, c.HubID, queryParam(c.Request, "hub_id"))
+}
+
+// queryParam retorna un parámetro de query string (vacío si no existe)
+func queryParam(request events.APIGatewayV2HTTPRequest, name string) string {
+	if request.QueryStringParameters == nil {
+		return ""
+	}
+	return request.QueryStringParameters[name]
+}
+
+func parsePartyType(value string) models.PartyType {
+	switch value {
+	case "SENDER":
+		return models.PartySender
+	case "RECEIVER":
+		return models.PartyReceiver
+	}
+	return ""
+}
+
+// requiredCityID valida el parámetro city_id; status 0 indica que es válido
+func requiredCityID(request events.APIGatewayV2HTTPRequest) (int64, int, string) {
+	cityIDStr := queryParam(request, "city_id")
+	if cityIDStr == "" {
+		return 0, 400, `{"error": "Parámetro 'city_id' requerido"}`
+	}
+
+	cityID, err := strconv.ParseInt(cityIDStr, 10, 64)
+	if err != nil {
+		return 0, 400, `{"error": "city_id debe ser un número válido"}`
 	}
+	return cityID, 0, ""
 }
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/routers"
+)
+
+// Policy define quién puede invocar una ruta. Toda ruta registrada debe
+// declarar una política explícita: roles permitidos, Authenticated o Public.
+// El middleware de Manejadores la aplica con una sola consulta de rol.
+type Policy struct {
+	// Public indica que la ruta no requiere token (p.ej. cotizador del sitio público)
+	Public bool
+	// Authenticated indica que basta con un token válido; no se consulta el rol
+	// (p.ej. /auth/role, que es justamente la consulta del rol)
+	Authenticated bool
+	// Roles permitidos para la ruta
+	Roles []models.UserRole
+	// Owner es una regla opcional de propiedad del recurso
+	Owner OwnershipRule
+}
+
+// OwnershipRule valida que el usuario pueda acceder al recurso de la ruta.
+// Retorna status 0 si el acceso es válido.
+type OwnershipRule func(c RouteContext) (int, string)
+
+var (
+	rolesAdmin          = []models.UserRole{models.RoleAdmin}
+	rolesAdminSecretary = []models.UserRole{models.RoleAdmin, models.RoleSecretary}
+	rolesStaff          = []models.UserRole{models.RoleAdmin, models.RoleSecretary, models.RoleDelivery}
+	rolesGuideCreators  = []models.UserRole{models.RoleAdmin, models.RoleSecretary, models.RoleClient}
+	rolesDelivery       = []models.UserRole{models.RoleDelivery}
+	rolesClient         = []models.UserRole{models.RoleClient}
+	rolesAll            = []models.UserRole{models.RoleAdmin, models.RoleSecretary, models.RoleDelivery, models.RoleClient}
+)
+
+// authenticatedOnly es la política de rutas que no dependen del rol
+var authenticatedOnly = Policy{Authenticated: true}
+
+// publicAccess es la política de rutas anónimas
+var publicAccess = Policy{Public: true}
+
+// allow crea una política para los roles dados
+func allow(roles []models.UserRole) Policy {
+	return Policy{Roles: roles}
+}
+
+// withOwner agrega una regla de propiedad a la política
+func (p Policy) withOwner(rule OwnershipRule) Policy {
+	p.Owner = rule
+	return p
+}
+
+// Allows indica si el rol puede invocar la ruta
+func (p Policy) Allows(role models.UserRole) bool {
+	if p.Public || p.Authenticated {
+		return true
+	}
+	for _, allowed := range p.Roles {
+		if allowed == role {
+			return true
+		}
+	}
+	return false
+}
+
+// validate verifica que la política sea explícita y use roles conocidos
+func (p Policy) validate() error {
+	if p.Public {
+		if p.Authenticated || len(p.Roles) > 0 || p.Owner != nil {
+			return errors.New("la política Public no puede tener roles ni reglas de propiedad")
+		}
+		return nil
+	}
+	if p.Authenticated && len(p.Roles) > 0 {
+		return errors.New("la política no puede ser Authenticated y tener roles")
+	}
+	if !p.Authenticated && len(p.Roles) == 0 {
+		return errors.New("la ruta no tiene política de acceso")
+	}
+	if p.Authenticated && p.Owner != nil {
+		return errors.New("las reglas de propiedad requieren roles")
+	}
+	for _, role := range p.Roles {
+		if !isKnownRole(role) {
+			return fmt.Errorf("rol desconocido '%s'", role)
+		}
+	}
+	return nil
+}
+
+func isKnownRole(role models.UserRole) bool {
+	for _, known := range rolesAll {
+		if role == known {
+			return true
+		}
+	}
+	return false
+}
+
+// ==========================================
+// REGLAS DE PROPIEDAD
+// ==========================================
+
+// clientOwnsGuide: un CLIENT solo accede a guías donde ValidateGuideAccess
+// es verdadero (creador, remitente o destinatario). Los demás roles pasan.
+// El parámetro puede ser el guide_id o el número de guía.
+func clientOwnsGuide(param string) OwnershipRule {
+	return func(c RouteContext) (int, string) {
+		if c.Role != models.RoleClient {
+			return 0, ""
+		}
+
+		// Si la referencia es inválida o no existe, el handler responde el error
+		guideID, status, _ := routers.ResolveGuideRef(c.Params.String(param))
+		if status != 0 {
+			return 0, ""
+		}
+
+		return checkGuideAccess(guideID, c.User)
+	}
+}
+
+// ownsAssignment: un DELIVERY solo accede a sus propias asignaciones, una
+// SECRETARY solo a las de repartidores de su hub y un CLIENT solo a
+// asignaciones de guías a las que tiene acceso.
+func ownsAssignment(c RouteContext) (int, string) {
+	if c.Role == models.RoleAdmin {
+		return 0, ""
+	}
+
+	assignmentID, err := c.Params.Int64("id")
+	if err != nil {
+		return 0, ""
+	}
+
+	assignment, err := repos.Assignments.GetAssignmentByID(assignmentID)
+	if err != nil {
+		return 404, fmt.Sprintf(`{"error": "%s"}`, err.Error())
+	}
+
+	if c.Role == models.RoleDelivery {
+		if assignment.DeliveryUserID != c.User {
+			return 403, `{"error": "No autorizado - Solo puedes acceder a tus propias asignaciones"}`
+		}
+		return 0, ""
+	}
+
+	if c.Role == models.RoleSecretary {
+		if c.HubID == nil || !(models.HubScope{HubID: c.HubID}).Includes(assignment.HubID) {
+			return 403, `{"error": "No autorizado - La asignación es de otro hub"}`
+		}
+		return 0, ""
+	}
+
+	return checkGuideAccess(assignment.GuideID, c.User)
+}
+
+func checkGuideAccess(guideID int64, userUUID string) (int, string) {
+	hasAccess, err := repos.Guides.ValidateGuideAccess(guideID, userUUID)
+	if err != nil {
+		if err.Error() == "Guía no encontrada" {
+			return 404, `{"error": "Guía no encontrada"}`
+		}
+		return 500, fmt.Sprintf(`{"error": "Error al validar acceso: %s"}`, err.Error())
+	}
+	if !hasAccess {
+		return 403, `{"error": "No tienes permiso para ver esta guía"}`
+	}
+	return 0, ""
+}
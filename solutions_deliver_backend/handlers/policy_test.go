@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/repository/memory"
+)
+
+// policyFixture usuarios, guía y asignación compartidos por las pruebas de
+// autorización
+type policyFixture struct {
+	guideID      int64
+	assignmentID int64
+	hubID        int64
+	otherHubID   int64
+}
+
+func newPolicyFixture(t *testing.T) policyFixture {
+	t.Helper()
+	r, store := memory.NewRepositories()
+	InitRepositories(r)
+
+	f := policyFixture{
+		hubID:      store.AddHub(models.Hub{Code: "BOG", Name: "Bogotá"}),
+		otherHubID: store.AddHub(models.Hub{Code: "MED", Name: "Medellín"}),
+	}
+
+	store.AddUser(models.User{UserUUID: "admin", Role: models.RoleAdmin})
+	store.AddUser(models.User{UserUUID: "secretary", Role: models.RoleSecretary, HubID: &f.hubID})
+	store.AddUser(models.User{UserUUID: "secretary-other", Role: models.RoleSecretary, HubID: &f.otherHubID})
+	store.AddUser(models.User{UserUUID: "secretary-no-hub", Role: models.RoleSecretary})
+	store.AddUser(models.User{UserUUID: "delivery", Role: models.RoleDelivery, HubID: &f.hubID})
+	store.AddUser(models.User{UserUUID: "delivery-other", Role: models.RoleDelivery, HubID: &f.hubID})
+	store.AddUser(models.User{UserUUID: "client", Role: models.RoleClient})
+	store.AddUser(models.User{UserUUID: "client-other", Role: models.RoleClient})
+
+	f.guideID = store.AddGuide(models.ShippingGuide{CurrentStatus: models.StatusInWarehouse, CreatedBy: "client"})
+	f.assignmentID = store.AddAssignment(models.DeliveryAssignment{
+		GuideID:        f.guideID,
+		AssignmentType: models.AssignmentDelivery,
+		Status:         models.AssignmentPending,
+		DeliveryUserID: "delivery",
+	})
+	return f
+}
+
+func TestAuthorizeRoles(t *testing.T) {
+	newPolicyFixture(t)
+
+	tests := []struct {
+		name   string
+		policy Policy
+		user   string
+		status int
+	}{
+		{"admin en ruta de admin", allow(rolesAdmin), "admin", 200},
+		{"secretaria en ruta de admin", allow(rolesAdmin), "secretary", 403},
+		{"secretaria en ruta de staff", allow(rolesStaff), "secretary", 200},
+		{"repartidor en ruta de staff", allow(rolesStaff), "delivery", 200},
+		{"cliente en ruta de staff", allow(rolesStaff), "client", 403},
+		{"cliente en ruta de cliente", allow(rolesClient), "client", 200},
+		{"admin en ruta de cliente", allow(rolesClient), "admin", 403},
+		{"repartidor en ruta de repartidor", allow(rolesDelivery), "delivery", 200},
+		{"secretaria en ruta de repartidor", allow(rolesDelivery), "secretary", 403},
+		{"usuario sin registro", allow(rolesAll), "unknown", 403},
+		{"authenticated no consulta el rol", authenticatedOnly, "unknown", 200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			route := &Route{Policy: tt.policy, Handler: func(c RouteContext) (int, string) { return 200, "" }}
+			status, body := authorize(route, RouteContext{User: tt.user})
+			if status != tt.status {
+				t.Errorf("status = %d (%s), se esperaba %d", status, body, tt.status)
+			}
+		})
+	}
+}
+
+// authorize resuelve el rol y el hub una sola vez y los pasa al handler
+func TestAuthorizeSetsRoleAndHub(t *testing.T) {
+	f := newPolicyFixture(t)
+
+	var got RouteContext
+	route := &Route{Policy: allow(rolesStaff), Handler: func(c RouteContext) (int, string) {
+		got = c
+		return 200, ""
+	}}
+	if status, body := authorize(route, RouteContext{User: "secretary"}); status != 200 {
+		t.Fatalf("status = %d (%s), se esperaba 200", status, body)
+	}
+	if got.Role != models.RoleSecretary {
+		t.Errorf("rol = %s, se esperaba SECRETARY", got.Role)
+	}
+	if got.HubID == nil || *got.HubID != f.hubID {
+		t.Errorf("hub = %v, se esperaba %d", got.HubID, f.hubID)
+	}
+}
+
+func TestAuthorizeOwnership(t *testing.T) {
+	f := newPolicyFixture(t)
+
+	tests := []struct {
+		name   string
+		rule   OwnershipRule
+		param  string
+		user   string
+		status int
+	}{
+		{"admin en cualquier asignación", ownsAssignment, "assignment", "admin", 200},
+		{"repartidor en su asignación", ownsAssignment, "assignment", "delivery", 200},
+		{"repartidor en asignación ajena", ownsAssignment, "assignment", "delivery-other", 403},
+		{"secretaria del hub", ownsAssignment, "assignment", "secretary", 200},
+		{"secretaria de otro hub", ownsAssignment, "assignment", "secretary-other", 403},
+		{"secretaria sin hub", ownsAssignment, "assignment", "secretary-no-hub", 403},
+		{"cliente con acceso a la guía", ownsAssignment, "assignment", "client", 200},
+		{"cliente sin acceso a la guía", ownsAssignment, "assignment", "client-other", 403},
+		{"asignación inexistente", ownsAssignment, "missing", "delivery", 404},
+		{"cliente en su guía", clientOwnsGuide("id"), "guide", "client", 200},
+		{"cliente en guía ajena", clientOwnsGuide("id"), "guide", "client-other", 403},
+		{"staff en guía de cliente", clientOwnsGuide("id"), "guide", "secretary", 200},
+	}
+
+	ids := map[string]string{
+		"assignment": formatID(f.assignmentID),
+		"guide":      formatID(f.guideID),
+		"missing":    "999999",
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			route := &Route{
+				Policy:  allow(rolesAll).withOwner(tt.rule),
+				Handler: func(c RouteContext) (int, string) { return 200, "" },
+			}
+			ctx := RouteContext{User: tt.user, Params: PathParams{"id": ids[tt.param]}}
+			status, body := authorize(route, ctx)
+			if status != tt.status {
+				t.Errorf("status = %d (%s), se esperaba %d", status, body, tt.status)
+			}
+		})
+	}
+}
+
+func formatID(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
+
+// Cada ruta del API declara una política válida; las rutas públicas y las
+// que no consultan el rol son solo las esperadas
+func TestAPIRoutesPolicies(t *testing.T) {
+	router := NewAPIRouter()
+
+	public := map[string]bool{
+		"POST /quotes":                    true,
+		"GET /public/track/{guideNumber}": true,
+	}
+	authenticated := map[string]bool{
+		"GET /auth/role": true,
+	}
+	// Catálogos compartidos: no tienen dueño
+	catalogs := map[string]bool{
+		"GET /locations/cities/{id}": true,
+	}
+
+	for _, route := range router.Routes() {
+		t.Run(route.Key(), func(t *testing.T) {
+			if err := route.Policy.validate(); err != nil {
+				t.Fatalf("política inválida: %v", err)
+			}
+			if route.Policy.Public != public[route.Key()] {
+				t.Errorf("Public = %v, se esperaba %v", route.Policy.Public, public[route.Key()])
+			}
+			if route.Policy.Authenticated != authenticated[route.Key()] {
+				t.Errorf("Authenticated = %v, se esperaba %v", route.Policy.Authenticated, authenticated[route.Key()])
+			}
+			// Un CLIENT solo llega a recursos con parámetros a través de una
+			// regla de propiedad
+			if route.Policy.Allows(models.RoleClient) && !route.Policy.Public && route.hasParams() &&
+				route.Policy.Owner == nil && !catalogs[route.Key()] {
+				t.Errorf("ruta con parámetros abierta a CLIENT sin regla de propiedad")
+			}
+		})
+	}
+}
+
+func TestAPISensitiveRouteRoles(t *testing.T) {
+	router := NewAPIRouter()
+	routes := map[string]*Route{}
+	for _, route := range router.Routes() {
+		routes[route.Key()] = route
+	}
+
+	admin := rolesAdmin
+	adminSecretary := rolesAdminSecretary
+
+	tests := []struct {
+		key   string
+		roles []models.UserRole
+		owner bool
+	}{
+		{"GET /cash-close", admin, false},
+		{"POST /cash-close", admin, false},
+		{"GET /cash-close/{id}/pdf", admin, false},
+		{"GET /admin/employees", admin, false},
+		{"PUT /admin/employees/{id}", admin, false},
+		{"GET /admin/users/search", admin, false},
+		{"POST /admin/rates/import", admin, false},
+		{"DELETE /admin/rates/{id}", admin, false},
+		{"POST /hubs", admin, false},
+		{"PUT /hubs/{id}", admin, false},
+		{"PUT /assignments/{id}/reassign", admin, false},
+		{"POST /assignments/{id}/handover-code/override", admin, false},
+		{"POST /guides/{id}/rndc", admin, false},
+		{"POST /manifests/{id}/rndc", admin, false},
+		{"POST /warehouses", admin, false},
+		{"PUT /guides/{id}/status", adminSecretary, false},
+		{"GET /guides/{id}/proof", adminSecretary, false},
+		{"GET /guides/{id}/location", adminSecretary, false},
+		{"POST /scans", adminSecretary, false},
+		{"GET /guides", adminSecretary, false},
+		{"POST /assignments", adminSecretary, false},
+		{"GET /assignments/{id}", adminSecretary, true},
+		{"PUT /assignments/{id}/status", rolesStaff, true},
+		{"POST /assignments/{id}/proof/upload-urls", rolesStaff, true},
+		{"GET /assignments/my", rolesDelivery, false},
+		{"POST /assignments/{id}/rate", rolesClient, true},
+		{"GET /guides/{id}", rolesAll, true},
+		{"GET /guides/{id}/pdf", rolesAll, true},
+		{"GET /client/guides/track/{guideNumber}", rolesClient, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			route, ok := routes[tt.key]
+			if !ok {
+				t.Fatalf("ruta no registrada")
+			}
+			if !reflect.DeepEqual(route.Policy.Roles, tt.roles) {
+				t.Errorf("roles = %v, se esperaba %v", route.Policy.Roles, tt.roles)
+			}
+			if (route.Policy.Owner != nil) != tt.owner {
+				t.Errorf("regla de propiedad = %v, se esperaba %v", route.Policy.Owner != nil, tt.owner)
+			}
+		})
+	}
+}
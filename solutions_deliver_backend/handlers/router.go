@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// RouteContext agrupa los datos del request que recibe cada handler de ruta
+type RouteContext struct {
+	Body    string
+	User    string
+	Role    models.UserRole // vacío en rutas con política Authenticated
+	HubID   *int64          // hub del usuario (nil: sin hub o ruta Authenticated)
+	Params  PathParams
+	Request events.APIGatewayV2HTTPRequest
+}
+
+// RouteHandler es la firma de los handlers registrados en la tabla de rutas
+type RouteHandler func(ctx RouteContext) (int, string)
+
+// PathParams contiene los parámetros extraídos del path, p.ej. {id}
+type PathParams map[string]string
+
+// String retorna el parámetro como texto (vacío si no existe)
+func (p PathParams) String(name string) string {
+	return p[name]
+}
+
+// Int64 retorna el parámetro como entero positivo
+func (p PathParams) Int64(name string) (int64, error) {
+	value, err := strconv.ParseInt(p[name], 10, 64)
+	if err != nil || value <= 0 {
+		return 0, errors.New("parámetro '" + name + "' inválido")
+	}
+	return value, nil
+}
+
+// Route es una entrada de la tabla de rutas.
+// Pattern usa segmentos literales y parámetros: /assignments/{id:int}/status.
+// Los parámetros ":int" solo coinciden con segmentos numéricos.
+// Policy define los roles y reglas de propiedad de la ruta.
+type Route struct {
+	Method  string
+	Pattern string
+	Policy  Policy
+	Handler RouteHandler
+
+	segments []routeSegment
+}
+
+type routeSegment struct {
+	literal string
+	param   string
+	isInt   bool
+}
+
+// Key retorna la ruta en formato de API Gateway: "GET /guides/{id}"
+func (r *Route) Key() string {
+	parts := make([]string, 0, len(r.segments))
+	for _, seg := range r.segments {
+		if seg.param != "" {
+			parts = append(parts, "{"+seg.param+"}")
+		} else {
+			parts = append(parts, seg.literal)
+		}
+	}
+	return r.Method + " /" + strings.Join(parts, "/")
+}
+
+// Router es el registro declarativo de rutas del API
+type Router struct {
+	routes []*Route
+}
+
+// NewRouter crea un registro de rutas vacío
+func NewRouter() *Router {
+	return &Router{}
+}
+
+// Handle registra una ruta. Entra en pánico si el patrón está duplicado,
+// ya que es un error de programación detectable al iniciar.
+func (rt *Router) Handle(method string, pattern string, policy Policy, handler RouteHandler) {
+	route := &Route{
+		Method:   method,
+		Pattern:  pattern,
+		Policy:   policy,
+		Handler:  handler,
+		segments: parsePattern(pattern),
+	}
+
+	for _, existing := range rt.routes {
+		if existing.Key() == route.Key() {
+			panic("ruta duplicada: " + route.Key())
+		}
+	}
+
+	rt.routes = append(rt.routes, route)
+}
+
+// Routes retorna las rutas registradas en orden de registro
+func (rt *Router) Routes() []*Route {
+	return rt.routes
+}
+
+// Validate verifica que todas las rutas tengan una política de acceso explícita
+func (rt *Router) Validate() error {
+	for _, route := range rt.routes {
+		if err := route.Policy.validate(); err != nil {
+			return fmt.Errorf("%s: %s", route.Key(), err.Error())
+		}
+		if route.Policy.Owner != nil && !route.hasParams() {
+			return fmt.Errorf("%s: regla de propiedad sin parámetros en la ruta", route.Key())
+		}
+	}
+	return nil
+}
+
+func (r *Route) hasParams() bool {
+	for _, seg := range r.segments {
+		if seg.param != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Match busca la ruta para el método y path dados.
+// Retorna 200 si hay coincidencia, 405 si el path existe con otro método
+// y 404 si ningún patrón coincide con el path.
+// Los segmentos literales tienen prioridad sobre los parámetros.
+func (rt *Router) Match(method string, path string) (*Route, PathParams, int) {
+	segments := splitPath(path)
+
+	var best *Route
+	var bestParams PathParams
+	bestScore := -1
+	pathExists := false
+
+	for _, route := range rt.routes {
+		params, score, ok := route.match(segments)
+		if !ok {
+			continue
+		}
+		pathExists = true
+
+		if route.Method != method {
+			continue
+		}
+
+		if score > bestScore {
+			best, bestParams, bestScore = route, params, score
+		}
+	}
+
+	if best != nil {
+		return best, bestParams, 200
+	}
+	if pathExists {
+		return nil, nil, 405
+	}
+	return nil, nil, 404
+}
+
+// Allowed retorna los métodos registrados para el path, ordenados; se usa
+// en el header Allow de las respuestas 405
+func (rt *Router) Allowed(path string) []string {
+	segments := splitPath(path)
+
+	seen := map[string]bool{}
+	methods := []string{}
+	for _, route := range rt.routes {
+		if _, _, ok := route.match(segments); !ok || seen[route.Method] {
+			continue
+		}
+		seen[route.Method] = true
+		methods = append(methods, route.Method)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+func (r *Route) match(segments []string) (PathParams, int, bool) {
+	if len(segments) != len(r.segments) {
+		return nil, 0, false
+	}
+
+	params := PathParams{}
+	score := 0
+	for i, seg := range r.segments {
+		if seg.param == "" {
+			if seg.literal != segments[i] {
+				return nil, 0, false
+			}
+			score++
+			continue
+		}
+
+		if seg.isInt {
+			if _, err := strconv.ParseInt(segments[i], 10, 64); err != nil {
+				return nil, 0, false
+			}
+		}
+		params[seg.param] = segments[i]
+	}
+
+	return params, score, true
+}
+
+func parsePattern(pattern string) []routeSegment {
+	parts := splitPath(pattern)
+	segments := make([]routeSegment, 0, len(parts))
+
+	for _, part := range parts {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			name := strings.Trim(part, "{}")
+			isInt := false
+			if strings.HasSuffix(name, ":int") {
+				name = strings.TrimSuffix(name, ":int")
+				isInt = true
+			}
+			segments = append(segments, routeSegment{param: name, isInt: isInt})
+			continue
+		}
+		segments = append(segments, routeSegment{literal: part})
+	}
+
+	return segments
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return []string{}
+	}
+	return strings.Split(path, "/")
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func testRouter() *Router {
+	r := NewRouter()
+	noop := func(c RouteContext) (int, string) { return 200, "" }
+
+	r.Handle("GET", "/assignments", allow(rolesAdmin), noop)
+	r.Handle("POST", "/assignments", allow(rolesAdmin), noop)
+	r.Handle("GET", "/assignments/{id:int}", allow(rolesAdmin), noop)
+	r.Handle("GET", "/assignments/my", allow(rolesDelivery), noop)
+	r.Handle("PUT", "/assignments/{id:int}/status", allow(rolesStaff), noop)
+	r.Handle("GET", "/guides/{ref}", allow(rolesAdmin), noop)
+	r.Handle("GET", "/guides/stats", allow(rolesAdmin), noop)
+	return r
+}
+
+func TestRouterMatch(t *testing.T) {
+	r := testRouter()
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		status  int
+		pattern string
+		params  PathParams
+	}{
+		{"literal", "GET", "/assignments", 200, "/assignments", PathParams{}},
+		{"barra final", "GET", "/assignments/", 200, "/assignments", PathParams{}},
+		{"parámetro entero", "GET", "/assignments/42", 200, "/assignments/{id:int}", PathParams{"id": "42"}},
+		{"literal sobre parámetro", "GET", "/assignments/my", 200, "/assignments/my", PathParams{}},
+		{"literal sobre parámetro de texto", "GET", "/guides/stats", 200, "/guides/stats", PathParams{}},
+		{"parámetro de texto", "GET", "/guides/SD-0001", 200, "/guides/{ref}", PathParams{"ref": "SD-0001"}},
+		{"parámetro anidado", "PUT", "/assignments/7/status", 200, "/assignments/{id:int}/status", PathParams{"id": "7"}},
+		{"int rechaza texto", "GET", "/assignments/abc", 404, "", nil},
+		{"int rechaza texto anidado", "PUT", "/assignments/abc/status", 404, "", nil},
+		{"path inexistente", "GET", "/nope", 404, "", nil},
+		{"segmentos de más", "GET", "/assignments/7/status/extra", 404, "", nil},
+		{"método no permitido", "DELETE", "/assignments", 405, "", nil},
+		{"método no permitido con parámetro", "POST", "/assignments/7/status", 405, "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			route, params, status := r.Match(tt.method, tt.path)
+			if status != tt.status {
+				t.Fatalf("status = %d, se esperaba %d", status, tt.status)
+			}
+			if tt.status != 200 {
+				if route != nil {
+					t.Fatalf("ruta %s, se esperaba nil", route.Key())
+				}
+				return
+			}
+			if route.Pattern != tt.pattern {
+				t.Errorf("patrón = %s, se esperaba %s", route.Pattern, tt.pattern)
+			}
+			if !reflect.DeepEqual(params, tt.params) {
+				t.Errorf("params = %v, se esperaba %v", params, tt.params)
+			}
+		})
+	}
+}
+
+// Las rutas registradas en NewAPIRouter resuelven al patrón esperado
+func TestAPIRouterMatch(t *testing.T) {
+	r := NewAPIRouter()
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		pattern string
+		params  PathParams
+	}{
+		{"búsqueda de guías", "GET", "/guides/search", "/guides/search", PathParams{}},
+		{"guía por id", "GET", "/guides/15", "/guides/{id}", PathParams{"id": "15"}},
+		{"guía por número", "GET", "/guides/SD-0001", "/guides/{id}", PathParams{"id": "SD-0001"}},
+		{"historial de asignación", "GET", "/assignments/7/history", "/assignments/{id:int}/history", PathParams{"id": "7"}},
+		{"estado de asignación", "PUT", "/assignments/7/status", "/assignments/{id:int}/status", PathParams{"id": "7"}},
+		{"calificar asignación", "POST", "/assignments/7/rate", "/assignments/{id:int}/rate", PathParams{"id": "7"}},
+		{"mis asignaciones", "GET", "/assignments/my", "/assignments/my", PathParams{}},
+		{"PDF de cierre de caja", "GET", "/cash-close/3/pdf", "/cash-close/{id:int}/pdf", PathParams{"id": "3"}},
+		{"estadísticas del cliente", "GET", "/client/guides/stats", "/client/guides/stats", PathParams{}},
+		{"rastreo del cliente", "GET", "/client/guides/track/SD-0001", "/client/guides/track/{guideNumber}", PathParams{"guideNumber": "SD-0001"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			route, params, status := r.Match(tt.method, tt.path)
+			if status != 200 {
+				t.Fatalf("status = %d, se esperaba 200", status)
+			}
+			if route.Pattern != tt.pattern {
+				t.Errorf("patrón = %s, se esperaba %s", route.Pattern, tt.pattern)
+			}
+			if !reflect.DeepEqual(params, tt.params) {
+				t.Errorf("params = %v, se esperaba %v", params, tt.params)
+			}
+		})
+	}
+}
+
+func TestRouterAllowed(t *testing.T) {
+	r := testRouter()
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"/assignments", []string{"GET", "POST"}},
+		{"/assignments/7/status", []string{"PUT"}},
+		{"/assignments/my", []string{"GET"}},
+		{"/assignments/abc/status", []string{}},
+		{"/nope", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := r.Allowed(tt.path); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Allowed(%s) = %v, se esperaba %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouterDuplicatePanics(t *testing.T) {
+	r := testRouter()
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("se esperaba pánico por ruta duplicada")
+		}
+	}()
+	r.Handle("GET", "/assignments/{id:int}", allow(rolesAdmin), func(c RouteContext) (int, string) { return 200, "" })
+}
+
+// Las respuestas 404/405 se resuelven antes de validar el token
+func TestManejadoresRouting(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+		body   string
+		allow  string
+	}{
+		{"inexistente", "GET", "/nope", 404, `{"error": "Ruta no encontrada"}`, ""},
+		{"inexistente con prefijo", "GET", "/api/v1/nope", 404, `{"error": "Ruta no encontrada"}`, ""},
+		{"int rechaza texto", "GET", "/api/v1/assignments/abc", 404, `{"error": "Ruta no encontrada"}`, ""},
+		{"método no permitido", "DELETE", "/assignments", 405, `{"error": "Método no permitido"}`, "GET, POST"},
+		{"método no permitido con prefijo", "DELETE", "/api/v1/assignments", 405, `{"error": "Método no permitido"}`, "GET, POST"},
+		{"método no permitido con parámetro", "GET", "/api/v1/assignments/7/rate", 405, `{"error": "Método no permitido"}`, "POST"},
+		{"preflight", "OPTIONS", "/api/v1/assignments/7", 200, "OK", ""},
+		{"preflight inexistente", "OPTIONS", "/api/v1/nope", 404, `{"error": "Ruta no encontrada"}`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := Manejadores(tt.path, tt.method, "", map[string]string{}, events.APIGatewayV2HTTPRequest{})
+			if status != tt.status || body != tt.body {
+				t.Fatalf("Manejadores = %d %s, se esperaba %d %s", status, body, tt.status, tt.body)
+			}
+			if status == 405 {
+				if got := AllowedMethods(tt.path); got != tt.allow {
+					t.Errorf("AllowedMethods = %q, se esperaba %q", got, tt.allow)
+				}
+			}
+		})
+	}
+}
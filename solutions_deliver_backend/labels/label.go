@@ -0,0 +1,309 @@
+// Package labels genera el rótulo térmico de las guías (10x15 cm): ZPL II
+// para las impresoras Zebra y un PDF del mismo tamaño. Los dos formatos
+// salen del mismo diseño en milímetros (layout), así que imprimen lo mismo.
+// Se genera un rótulo por pieza ("PIEZA 1 DE 3").
+package labels
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/utils"
+)
+
+// Tamaño del rótulo en milímetros
+const (
+	LabelWidthMM  = 100.0
+	LabelHeightMM = 150.0
+)
+
+// CompanyName nombre que encabeza el rótulo
+const CompanyName = "SOLUCIONES"
+
+const (
+	marginMM        = 3.0
+	barcodeModuleMM = 0.375 // 3 puntos a 203 dpi
+	barcodeHeightMM = 22.0
+	lineWidthMM     = 0.375
+)
+
+// Label datos del rótulo: la guía completa (partes y paquete) y la ruta de
+// la tarifa con la que se cotizó
+type Label struct {
+	Guide           models.ShippingGuide
+	Route           string // shipping_rates.route
+	TravelFrequency string // shipping_rates.travel_frequency
+}
+
+// Pieces número de rótulos a imprimir (uno por pieza, mínimo 1)
+func (l Label) Pieces() int {
+	if len(l.Guide.Pieces) > 0 {
+		return len(l.Guide.Pieces)
+	}
+	if l.Guide.Package == nil || l.Guide.Package.Pieces < 1 {
+		return 1
+	}
+	return l.Guide.Package.Pieces
+}
+
+// BarcodeValue código de la guía: el número de guía o, en guías
+// anteriores a la numeración, el guide_id
+func (l Label) BarcodeValue() string {
+	if l.Guide.GuideNumber != "" {
+		return l.Guide.GuideNumber
+	}
+	return strconv.FormatInt(l.Guide.GuideID, 10)
+}
+
+type elementKind int
+
+const (
+	elementText elementKind = iota
+	elementLine
+	elementBox
+	elementBarcode
+)
+
+// element primitiva del diseño. x, y es la esquina superior izquierda en
+// milímetros; en los textos h es la altura de la letra.
+type element struct {
+	kind elementKind
+	x, y float64
+	w, h float64
+	bold bool
+	text string
+	bars []bool
+}
+
+// layout arma el rótulo de la pieza piece (de 1 a Pieces())
+func (l Label) layout(piece int) ([]element, error) {
+	g := l.Guide
+	// Cada pieza lleva su propio código; las guías sin detalle de piezas
+	// imprimen el de la guía en todos los rótulos
+	detail, hasDetail := models.FindPiece(g.Pieces, piece)
+	value := l.BarcodeValue()
+	if hasDetail && detail.PieceCode != "" {
+		value = detail.PieceCode
+	}
+
+	bars, err := utils.EncodeCode128(value)
+	if err != nil {
+		return nil, err
+	}
+
+	width := LabelWidthMM - 2*marginMM
+	var e []element
+
+	// Encabezado: empresa y servicio
+	e = append(e,
+		text(marginMM, 3, 6, true, CompanyName),
+		element{kind: elementBox, x: 62, y: 2, w: 35, h: 8},
+		centered(62, 35, 3.5, 5, true, serviceLabel(g.ServiceType)),
+		hline(11),
+	)
+
+	// Ruta
+	route := "Ruta: " + orDash(l.Route)
+	if l.TravelFrequency != "" {
+		route += " - Frecuencia: " + l.TravelFrequency
+	}
+	e = append(e,
+		text(marginMM, 13, 3.5, false, fit("ORIGEN: "+strings.ToUpper(cityName(g.OriginCityName, g.OriginCityID)), 3.5, width)),
+		text(marginMM, 18, 8, true, fit(strings.ToUpper(cityName(g.DestinationCityName, g.DestinationCityID)), 8, width)),
+		text(marginMM, 27, 3, false, fit(route, 3, width)),
+		hline(31),
+	)
+
+	// Destinatario
+	y := 33.0
+	e = append(e, text(marginMM, y, 3, true, "DESTINATARIO"))
+	if r := g.Receiver; r != nil {
+		e = append(e, text(marginMM, y+4, 4.5, true, fit(r.FullName, 4.5, width)))
+		for i, line := range wrap(r.Address, 3.5, width, 2) {
+			e = append(e, text(marginMM, y+10+float64(i)*4.5, 3.5, false, line))
+		}
+		e = append(e, text(marginMM, y+19.5, 3.5, false, fit(partyContact(*r), 3.5, width)))
+	}
+	e = append(e, hline(59))
+
+	// Remitente
+	y = 61
+	e = append(e, text(marginMM, y, 3, true, "REMITENTE"))
+	if s := g.Sender; s != nil {
+		e = append(e,
+			text(marginMM, y+4, 3.5, false, fit(s.FullName, 3.5, width)),
+			text(marginMM, y+8.5, 3, false, fit(s.Address, 3, width)),
+			text(marginMM, y+12.5, 3, false, fit(partyContact(*s), 3, width)),
+		)
+	}
+	e = append(e, hline(80))
+
+	// Pieza, peso, pago y contenido
+	e = append(e, text(marginMM, 82, 7, true, fmt.Sprintf("PIEZA %d DE %d", piece, l.Pieces())))
+	// Con detalle de piezas, el peso y el contenido son los de la pieza
+	weight, description := 0.0, ""
+	if g.Package != nil {
+		weight, description = g.Package.WeightKg, g.Package.Description
+	}
+	if hasDetail {
+		weight = detail.WeightKg
+		if detail.Description != "" {
+			description = detail.Description
+		}
+	}
+	if weight > 0 {
+		e = append(e, text(66, 83.5, 4, false, "PESO "+formatKg(weight)))
+	}
+	e = append(e, text(marginMM, 91, 4, true, fit(paymentText(g), 4, width)))
+	if description != "" {
+		e = append(e, text(marginMM, 96, 3, false, fit("Contenido: "+description, 3, width)))
+	}
+	e = append(e, hline(101))
+
+	// Código de barras centrado y código legible (de la pieza o de la guía)
+	barsWidth := float64(len(bars)) * barcodeModuleMM
+	e = append(e,
+		element{kind: elementBarcode, x: (LabelWidthMM - barsWidth) / 2, y: 104, w: barsWidth, h: barcodeHeightMM, text: value, bars: bars},
+		centered(0, LabelWidthMM, 128, 6, true, value),
+		hline(137),
+	)
+
+	// Pie
+	footer := fmt.Sprintf("Creada: %s", g.CreatedAt.Format("2006-01-02 15:04"))
+	if g.GuideNumber != "" {
+		footer += fmt.Sprintf(" - ID %d", g.GuideID)
+	}
+	e = append(e, text(marginMM, 139.5, 3, false, footer))
+
+	return e, nil
+}
+
+func text(x, y, h float64, bold bool, value string) element {
+	return element{kind: elementText, x: x, y: y, h: h, bold: bold, text: value}
+}
+
+// centered centra el texto en el ancho [x, x+w] (según el ancho estimado)
+func centered(x, w, y, h float64, bold bool, value string) element {
+	value = fit(value, h, w)
+	offset := (w - textWidth(value, h)) / 2
+	if offset < 0 {
+		offset = 0
+	}
+	return text(x+offset, y, h, bold, value)
+}
+
+// hline línea horizontal de margen a margen
+func hline(y float64) element {
+	return element{kind: elementLine, x: marginMM, y: y, w: LabelWidthMM - 2*marginMM, h: lineWidthMM}
+}
+
+// textWidth ancho estimado del texto: las fuentes de la impresora y de PDF
+// no son iguales, se usa un promedio conservador por carácter
+func textWidth(value string, h float64) float64 {
+	return float64(len([]rune(value))) * h * 0.6
+}
+
+// fit recorta el texto para que quepa en una línea de ancho w
+func fit(value string, h float64, w float64) string {
+	value = strings.Join(strings.Fields(value), " ")
+	runes := []rune(value)
+	max := int(w / (h * 0.6))
+	if len(runes) <= max {
+		return value
+	}
+	if max < 1 {
+		return ""
+	}
+	return strings.TrimSpace(string(runes[:max-1])) + "."
+}
+
+// wrap parte el texto en máximo maxLines líneas de ancho w; la última se recorta
+func wrap(value string, h float64, w float64, maxLines int) []string {
+	max := int(w / (h * 0.6))
+	var lines []string
+	current := ""
+	for _, word := range strings.Fields(value) {
+		candidate := word
+		if current != "" {
+			candidate = current + " " + word
+		}
+		if len([]rune(candidate)) <= max || current == "" {
+			current = candidate
+			continue
+		}
+		lines = append(lines, current)
+		current = word
+	}
+	if current != "" {
+		lines = append(lines, current)
+	}
+
+	if len(lines) > maxLines {
+		last := strings.Join(lines[maxLines-1:], " ")
+		lines = append(lines[:maxLines-1], last)
+	}
+	for i := range lines {
+		lines[i] = fit(lines[i], h, w)
+	}
+	return lines
+}
+
+func partyContact(p models.GuideParty) string {
+	contact := "Tel: " + orDash(p.Phone)
+	if p.DocumentNumber != "" {
+		docType := p.DocumentType
+		if docType == "" {
+			docType = "Doc"
+		}
+		contact += " - " + docType + ": " + p.DocumentNumber
+	}
+	return contact
+}
+
+func cityName(name string, id int64) string {
+	if name != "" {
+		return name
+	}
+	return fmt.Sprintf("Ciudad %d", id)
+}
+
+func serviceLabel(service models.ServiceType) string {
+	switch service {
+	case models.ServiceNormal:
+		return "NORMAL"
+	case models.ServicePriority:
+		return "PRIORITARIO"
+	case models.ServiceExpress:
+		return "EXPRESS"
+	}
+	return string(service)
+}
+
+// paymentText forma de pago; en contraentrega incluye el valor a cobrar
+func paymentText(g models.ShippingGuide) string {
+	switch g.PaymentMethod {
+	case models.PaymentCOD:
+		return "CONTRAENTREGA - COBRAR " + utils.FormatCOP(g.Price)
+	case models.PaymentCredit:
+		return "PAGO: CRÉDITO"
+	case models.PaymentCash:
+		return "PAGO: CONTADO"
+	}
+	return "PAGO: " + string(g.PaymentMethod)
+}
+
+// formatKg formatea el peso con coma decimal (12,5 kg)
+func formatKg(kg float64) string {
+	value := strconv.FormatFloat(kg, 'f', 2, 64)
+	value = strings.TrimRight(strings.TrimRight(value, "0"), ".")
+	return strings.Replace(value, ".", ",", 1) + " kg"
+}
+
+func orDash(value string) string {
+	if strings.TrimSpace(value) == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,121 @@
+package labels
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+)
+
+// go test ./labels -update regenera los archivos de testdata
+var update = flag.Bool("update", false, "regenera los archivos golden de testdata")
+
+// Fecha fija para que el pie del rótulo no cambie entre ejecuciones
+var goldenCreatedAt = time.Date(2024, 3, 15, 9, 30, 0, 0, time.UTC)
+
+func goldenLabels() map[string]Label {
+	receiver := &models.GuideParty{
+		FullName:       "Ana María Pérez Gómez",
+		DocumentType:   "CC",
+		DocumentNumber: "52123456",
+		Phone:          "3001234567",
+		Address:        "Carrera 45 # 26-85 Torre 3 Apartamento 1204 Conjunto Residencial Los Almendros, barrio La Esmeralda",
+	}
+	sender := &models.GuideParty{
+		FullName: "Comercializadora El Puerto S.A.S.",
+		Phone:    "6017654321",
+		Address:  "Calle 13 # 68-20 Bodega 4",
+	}
+
+	return map[string]Label{
+		// Contraentrega con detalle de piezas: cada rótulo lleva su código
+		"cod_pieces": {
+			Guide: models.ShippingGuide{
+				GuideID:             18,
+				GuideNumber:         "SD00000018",
+				ServiceType:         models.ServiceExpress,
+				PaymentMethod:       models.PaymentCOD,
+				Price:               185000,
+				OriginCityName:      "Bogotá",
+				DestinationCityName: "Medellín",
+				CreatedAt:           goldenCreatedAt,
+				Sender:              sender,
+				Receiver:            receiver,
+				Package:             &models.Package{WeightKg: 12.5, Pieces: 2, Description: "Repuestos"},
+				Pieces: []models.GuidePiece{
+					{PieceNumber: 1, PieceCode: "SD00000018-01", WeightKg: 8, Description: "Caja de frenos"},
+					{PieceNumber: 2, PieceCode: "SD00000018-02", WeightKg: 4.5},
+				},
+			},
+			Route:           "BOG-MED",
+			TravelFrequency: "Diaria",
+		},
+		// Guía anterior a la numeración, sin remitente ni tarifa
+		"legacy": {
+			Guide: models.ShippingGuide{
+				GuideID:           20240001,
+				ServiceType:       models.ServiceNormal,
+				PaymentMethod:     models.PaymentCredit,
+				OriginCityID:      11001,
+				DestinationCityID: 5001,
+				CreatedAt:         goldenCreatedAt,
+				Receiver:          &models.GuideParty{FullName: "José Núñez", Address: "Av. 80 ^ 45~2"},
+			},
+		},
+	}
+}
+
+func TestRenderGolden(t *testing.T) {
+	renderers := map[string]func(Label) ([]byte, error){
+		".zpl": RenderZPL,
+		".pdf": RenderPDF,
+	}
+
+	for name, label := range goldenLabels() {
+		for ext, render := range renderers {
+			t.Run(name+ext, func(t *testing.T) {
+				got, err := render(label)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				path := filepath.Join("testdata", name+ext)
+				if *update {
+					if err := os.WriteFile(path, got, 0o644); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				want, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatalf("%v (ejecute go test ./labels -update)", err)
+				}
+				if !bytes.Equal(got, want) {
+					t.Errorf("%s no coincide con el golden; si el cambio es intencional ejecute go test ./labels -update", path)
+				}
+			})
+		}
+	}
+}
+
+// Los dos formatos imprimen un rótulo por pieza
+func TestRenderPieces(t *testing.T) {
+	label := goldenLabels()["cod_pieces"]
+
+	zpl, err := RenderZPL(label)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := bytes.Count(zpl, []byte("^XA")); n != 2 {
+		t.Errorf("formatos ZPL = %d, se esperaba 2", n)
+	}
+	for _, code := range []string{"SD00000018-01", "SD00000018-02"} {
+		if !bytes.Contains(zpl, []byte("^FD"+code+"^FS")) {
+			t.Errorf("falta el código de la pieza %s", code)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package labels
+
+import (
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/utils"
+)
+
+// RenderPDF genera el rótulo en PDF de 10x15 cm: una página por pieza
+func RenderPDF(l Label) ([]byte, error) {
+	doc := utils.NewPDFDocument(pt(LabelWidthMM), pt(LabelHeightMM))
+
+	for piece := 1; piece <= l.Pieces(); piece++ {
+		elements, err := l.layout(piece)
+		if err != nil {
+			return nil, err
+		}
+
+		page := doc.AddPage()
+		for _, e := range elements {
+			switch e.kind {
+			case elementText:
+				// La línea base queda al 80% de la altura de la letra
+				page.Text(pt(e.x), pt(e.y+e.h*0.8), pt(e.h), e.bold, e.text)
+			case elementLine:
+				page.FillRect(pt(e.x), pt(e.y), pt(e.w), pt(e.h))
+			case elementBox:
+				page.StrokeRect(pt(e.x), pt(e.y), pt(e.w), pt(e.h), pt(lineWidthMM))
+			case elementBarcode:
+				drawBars(page, e)
+			}
+		}
+	}
+
+	return doc.Bytes(), nil
+}
+
+// drawBars dibuja cada tramo de barras negras como un rectángulo
+func drawBars(page *utils.PDFPage, e element) {
+	for i := 0; i < len(e.bars); {
+		if !e.bars[i] {
+			i++
+			continue
+		}
+		start := i
+		for i < len(e.bars) && e.bars[i] {
+			i++
+		}
+		x := e.x + float64(start)*barcodeModuleMM
+		page.FillRect(pt(x), pt(e.y), pt(float64(i-start)*barcodeModuleMM), pt(e.h))
+	}
+}
+
+func pt(mm float64) float64 {
+	return mm * utils.PDFPointsPerMM
+}
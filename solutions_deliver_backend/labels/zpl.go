@@ -0,0 +1,55 @@
+package labels
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ZPL a 203 dpi (8 puntos por milímetro)
+const zplDotsPerMM = 8.0
+
+// RenderZPL genera el rótulo en ZPL II: un formato ^XA...^XZ por pieza.
+// El texto va en UTF-8 (^CI28) con la fuente escalable 0.
+func RenderZPL(l Label) ([]byte, error) {
+	var b strings.Builder
+
+	for piece := 1; piece <= l.Pieces(); piece++ {
+		elements, err := l.layout(piece)
+		if err != nil {
+			return nil, err
+		}
+
+		b.WriteString("^XA\n^CI28\n")
+		fmt.Fprintf(&b, "^PW%d\n^LL%d\n^LH0,0\n", dots(LabelWidthMM), dots(LabelHeightMM))
+
+		for _, e := range elements {
+			switch e.kind {
+			case elementText:
+				fmt.Fprintf(&b, "^FO%d,%d^A0N,%d,%d^FD%s^FS\n", dots(e.x), dots(e.y), dots(e.h), dots(e.h), zplText(e.text))
+			case elementLine:
+				fmt.Fprintf(&b, "^FO%d,%d^GB%d,%d,%d^FS\n", dots(e.x), dots(e.y), dots(e.w), dots(e.h), dots(e.h))
+			case elementBox:
+				fmt.Fprintf(&b, "^FO%d,%d^GB%d,%d,%d^FS\n", dots(e.x), dots(e.y), dots(e.w), dots(e.h), dots(lineWidthMM))
+			case elementBarcode:
+				// Modo A: la impresora elige los sets de Code 128; la línea
+				// legible se imprime aparte, como en el PDF
+				fmt.Fprintf(&b, "^FO%d,%d^BY%d^BCN,%d,N,N,N,A^FD%s^FS\n",
+					dots(e.x), dots(e.y), dots(barcodeModuleMM), dots(e.h), zplText(e.text))
+			}
+		}
+
+		b.WriteString("^PQ1\n^XZ\n")
+	}
+
+	return []byte(b.String()), nil
+}
+
+func dots(mm float64) int {
+	return int(math.Round(mm * zplDotsPerMM))
+}
+
+// zplText quita los prefijos de comando (^ y ~) del texto
+func zplText(value string) string {
+	return strings.NewReplacer("^", " ", "~", " ").Replace(value)
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"strings"
 
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/auth"
 	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/awsgo"
 	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/bd"
 	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/handlers"
@@ -28,12 +29,18 @@ func main() {
 		panic(err)
 	}
 
+	// Una configuración de Cognito incompleta debe fallar al arrancar,
+	// no dejar pasar tokens de otros clientes del user pool
+	verifier, err := auth.NewVerifierFromEnv()
+	if err != nil {
+		panic(err)
+	}
+	handlers.InitTokenVerifier(verifier)
+
 	lambda.Start(EjecutarLambda)
 }
 
 func EjecutarLambda(ctx context.Context, request events.APIGatewayV2HTTPRequest) (*events.APIGatewayProxyResponse, error) {
-	awsgo.InicializoAWS()
-
 	if !ValidoParametros() {
 		panic("Error en los parámetros. Debe enviar 'SecretName', 'UrlPrefix'")
 	}
@@ -46,6 +53,7 @@ func EjecutarLambda(ctx context.Context, request events.APIGatewayV2HTTPRequest)
 	body := request.Body
 	header := request.Headers
 
+	// El secreto se cachea por contenedor; solo la primera invocación llama a Secrets Manager
 	err := bd.ReadSecret()
 	if err != nil {
 		return &events.APIGatewayProxyResponse{
@@ -60,6 +68,9 @@ func EjecutarLambda(ctx context.Context, request events.APIGatewayV2HTTPRequest)
 	headersResp := map[string]string{
 		"Content-Type": "application/json",
 	}
+	if status == 405 {
+		headersResp["Allow"] = handlers.AllowedMethods(path)
+	}
 	res = &events.APIGatewayProxyResponse{
 		StatusCode: status,
 		Body:       string(message),
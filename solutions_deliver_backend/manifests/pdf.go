@@ -0,0 +1,263 @@
+// Package manifests genera el PDF del manifiesto de despacho (carta, una
+// o varias páginas): datos del vehículo y el conductor, una fila por guía
+// y los totales calculados por el servidor, con las firmas de despacho y
+// recepción.
+package manifests
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/labels"
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/utils"
+)
+
+// Tamaño carta en milímetros
+const (
+	PageWidthMM  = 215.9
+	PageHeightMM = 279.4
+)
+
+const (
+	marginMM     = 12.0
+	rowHeightMM  = 5.5
+	tableTopMM   = 66.0 // línea base de la primera fila de la tabla
+	tableEndMM   = 262.0
+	summaryMM    = 42.0 // totales y firmas al final de la última página
+	textSizePt   = 8.0
+	headerSizePt = 8.0
+	lineWidthPt  = 0.6
+)
+
+// column columna de la tabla; las numéricas se alinean a la derecha en x
+type column struct {
+	title    string
+	x        float64
+	right    bool
+	maxChars int
+}
+
+var columns = []column{
+	{title: "#", x: marginMM},
+	{title: "GUÍA", x: 20},
+	{title: "DESTINO", x: 44, maxChars: 20},
+	{title: "DESTINATARIO", x: 79, maxChars: 26},
+	{title: "PZS", x: 128, right: true},
+	{title: "PESO KG", x: 146, right: true},
+	{title: "VALOR DECLARADO", x: 175, right: true},
+	{title: "CONTRAENTREGA", x: PageWidthMM - marginMM, right: true},
+}
+
+// Number número impreso del manifiesto (MD-000123)
+func Number(manifestID int64) string {
+	return fmt.Sprintf("MD-%06d", manifestID)
+}
+
+// RenderPDF genera el PDF del manifiesto. generatedAt se imprime en el pie
+// (la salida no depende de la hora actual).
+func RenderPDF(m models.Manifest, generatedAt time.Time) ([]byte, error) {
+	if len(m.Guides) == 0 {
+		return nil, fmt.Errorf("el manifiesto no tiene guías")
+	}
+
+	rowsArea := tableEndMM - tableTopMM
+	perPage := int(rowsArea / rowHeightMM)
+	var pages [][]models.ManifestGuide
+	for start := 0; start < len(m.Guides); start += perPage {
+		end := start + perPage
+		if end > len(m.Guides) {
+			end = len(m.Guides)
+		}
+		pages = append(pages, m.Guides[start:end])
+	}
+	// Los totales y las firmas van debajo de la última fila; si no caben,
+	// van solos en una página más
+	last := pages[len(pages)-1]
+	if tableTopMM+float64(len(last)+1)*rowHeightMM+summaryMM > PageHeightMM-marginMM {
+		pages = append(pages, nil)
+	}
+
+	doc := utils.NewPDFDocument(pt(PageWidthMM), pt(PageHeightMM))
+	index := 0
+	for i, rows := range pages {
+		page := doc.AddPage()
+		drawHeader(page, m)
+
+		y := tableTopMM
+		if rows != nil {
+			drawTableHeader(page, y-rowHeightMM)
+			for _, g := range rows {
+				index++
+				drawRow(page, y, index, g)
+				y += rowHeightMM
+			}
+		}
+		if i == len(pages)-1 {
+			drawSummary(page, y-3.8, m)
+		}
+
+		footer := fmt.Sprintf("%s - Generado %s - Página %d de %d",
+			Number(m.ManifestID), generatedAt.Format("2006-01-02 15:04"), i+1, len(pages))
+		text(page, marginMM, PageHeightMM-marginMM+4, 7, false, footer)
+	}
+
+	return doc.Bytes(), nil
+}
+
+func drawHeader(page *utils.PDFPage, m models.Manifest) {
+	text(page, marginMM, 18, 16, true, labels.CompanyName)
+	text(page, marginMM, 25, 10, false, "MANIFIESTO DE DESPACHO")
+	textRight(page, PageWidthMM-marginMM, 18, 14, true, Number(m.ManifestID))
+	textRight(page, PageWidthMM-marginMM, 25, 9, false, "Estado: "+statusText(m.Status))
+	page.Line(pt(marginMM), pt(29), pt(PageWidthMM-marginMM), pt(29), lineWidthPt)
+
+	rows := [][2]string{
+		{"Ruta", m.Route},
+		{"Fecha de despacho", m.DispatchDate.Format("2006-01-02")},
+		{"Vehículo (placa)", m.VehiclePlate},
+		{"Conductor", m.DriverName + " - CC " + m.DriverDocument},
+	}
+	if m.DriverPhone != "" {
+		rows[3][1] += " - Tel. " + m.DriverPhone
+	}
+	y := 35.0
+	for _, r := range rows {
+		text(page, marginMM, y, 9, true, r[0]+":")
+		text(page, marginMM+34, y, 9, false, r[1])
+		y += 5
+	}
+	if m.Notes != "" {
+		text(page, marginMM, y, 9, true, "Notas:")
+		text(page, marginMM+34, y, 9, false, truncate(m.Notes, 95))
+	}
+}
+
+func drawTableHeader(page *utils.PDFPage, y float64) {
+	page.StrokeRect(pt(marginMM), pt(y-3.8), pt(PageWidthMM-2*marginMM), pt(rowHeightMM), lineWidthPt)
+	for _, c := range columns {
+		if c.right {
+			textRight(page, c.x-1, y, headerSizePt, true, c.title)
+		} else {
+			text(page, c.x+1, y, headerSizePt, true, c.title)
+		}
+	}
+}
+
+func drawRow(page *utils.PDFPage, y float64, index int, g models.ManifestGuide) {
+	number := g.GuideNumber
+	if number == "" {
+		number = strconv.FormatInt(g.GuideID, 10)
+	}
+	cod := "-"
+	if g.CODAmount > 0 {
+		cod = utils.FormatCOP(g.CODAmount)
+	}
+
+	values := []string{
+		strconv.Itoa(index),
+		number,
+		g.DestinationCityName,
+		g.ReceiverName,
+		strconv.Itoa(g.Pieces),
+		formatKg(g.WeightKg),
+		utils.FormatCOP(g.DeclaredValue),
+		cod,
+	}
+	for i, c := range columns {
+		value := values[i]
+		if c.maxChars > 0 {
+			value = truncate(value, c.maxChars)
+		}
+		if c.right {
+			textRight(page, c.x-1, y, textSizePt, false, value)
+		} else {
+			text(page, c.x+1, y, textSizePt, false, value)
+		}
+	}
+}
+
+func drawSummary(page *utils.PDFPage, y float64, m models.Manifest) {
+	t := m.Totals
+	page.Line(pt(marginMM), pt(y), pt(PageWidthMM-marginMM), pt(y), lineWidthPt)
+	y += 5
+	text(page, marginMM+1, y, 9, true, fmt.Sprintf("TOTALES: %d guías", t.Guides))
+	totals := []string{strconv.Itoa(t.Pieces), formatKg(t.WeightKg), utils.FormatCOP(t.DeclaredValue), utils.FormatCOP(t.CODToCollect)}
+	for i, value := range totals {
+		textRight(page, columns[4+i].x-1, y, 9, true, value)
+	}
+
+	y += 22
+	signatures := []string{"Despachó", "Conductor", "Recibió"}
+	width := (PageWidthMM - 2*marginMM - 20) / 3
+	for i, label := range signatures {
+		x := marginMM + float64(i)*(width+10)
+		page.Line(pt(x), pt(y), pt(x+width), pt(y), lineWidthPt/2)
+		text(page, x, y+4, 8, false, label)
+	}
+}
+
+func statusText(status models.ManifestStatus) string {
+	switch status {
+	case models.ManifestOpen:
+		return "ABIERTO"
+	case models.ManifestClosed:
+		return "EN RUTA"
+	case models.ManifestReceived:
+		return "RECIBIDO"
+	}
+	return string(status)
+}
+
+// text escribe en (x, y) mm; y es la línea base
+func text(page *utils.PDFPage, x, y, size float64, bold bool, value string) {
+	page.Text(pt(x), pt(y), size, bold, value)
+}
+
+// textRight escribe el texto terminando en x mm. El ancho se estima con las
+// métricas de Helvetica para cifras y signos (lo que se alinea a la derecha).
+func textRight(page *utils.PDFPage, x, y, size float64, bold bool, value string) {
+	page.Text(pt(x)-textWidth(value, size), pt(y), size, bold, value)
+}
+
+// textWidth ancho aproximado del texto en puntos
+func textWidth(value string, size float64) float64 {
+	units := 0
+	for _, r := range value {
+		switch {
+		case r >= '0' && r <= '9', r == '$':
+			units += 556
+		case r == '.' || r == ',' || r == ' ':
+			units += 278
+		case r == '-':
+			units += 333
+		case r >= 'A' && r <= 'Z', r == 'Á' || r == 'Í' || r == 'Ó':
+			units += 700
+		default:
+			units += 556
+		}
+	}
+	return float64(units) * size / 1000
+}
+
+// truncate recorta el texto a maxChars caracteres (como labels.fit)
+func truncate(value string, maxChars int) string {
+	runes := []rune(strings.TrimSpace(value))
+	if len(runes) <= maxChars {
+		return string(runes)
+	}
+	return strings.TrimSpace(string(runes[:maxChars-1])) + "."
+}
+
+// formatKg peso con coma decimal (12,5)
+func formatKg(kg float64) string {
+	value := strconv.FormatFloat(kg, 'f', 2, 64)
+	value = strings.TrimRight(strings.TrimRight(value, "0"), ".")
+	return strings.Replace(value, ".", ",", 1)
+}
+
+func pt(mm float64) float64 {
+	return mm * utils.PDFPointsPerMM
+}
@@ -14,6 +14,12 @@ type AdminDashboardStats struct {
 	RevenueToday       float64 `json:"revenue_today"`
 	RevenueYesterday   float64 `json:"revenue_yesterday"`
 
+	// Excepciones: guías que están hoy en cada estado
+	DeliveryFailed   int `json:"delivery_failed"`
+	ReturnedToSender int `json:"returned_to_sender"`
+	Cancelled        int `json:"cancelled"`
+	OnHold           int `json:"on_hold"`
+
 	// Métricas de entregas
 	AverageDeliveryTime float64 `json:"average_delivery_time"` // Tiempo promedio en horas
 	SatisfactionRate    float64 `json:"satisfaction_rate"`     // Porcentaje de satisfacción
@@ -32,6 +38,11 @@ type AdminDashboardStats struct {
 
 	// Alertas del sistema
 	Alerts []SystemAlert `json:"alerts"`
+
+	// Hub del dashboard (vacío en el consolidado) y, en el consolidado,
+	// el resumen de cada hub
+	HubID *int64       `json:"hub_id,omitempty"`
+	ByHub []HubSummary `json:"by_hub,omitempty"`
 }
 
 // StatusCount conteo por estado
@@ -97,6 +108,8 @@ type Employee struct {
 	TotalCompleted int      `json:"total_completed,omitempty"`
 	TypeDocument   string   `json:"type_document,omitempty"`
 	NumberDocument string   `json:"number_document,omitempty"`
+	HubID          *int64   `json:"hub_id,omitempty"`
+	HubCode        string   `json:"hub_code,omitempty"`
 }
 
 // EmployeesListResponse respuesta de lista de empleados
@@ -120,6 +133,7 @@ type UpdateEmployeeRequest struct {
 	FullName string   `json:"full_name,omitempty"`
 	Phone    string   `json:"phone,omitempty"`
 	Role     UserRole `json:"role,omitempty"`
+	HubID    *int64   `json:"hub_id,omitempty"` // 0 quita el hub
 }
 
 // ClientRanking representa un cliente en el ranking
@@ -142,6 +156,7 @@ type ClientRankingFilters struct {
 	MinGuides int    `json:"min_guides"` // mínimo de guías
 	DateFrom  string `json:"date_from"`  // fecha desde
 	DateTo    string `json:"date_to"`    // fecha hasta
+	HubID     *int64 `json:"hub_id"`     // guías con origen en el hub
 }
 
 // ClientRankingResponse respuesta del ranking de clientes
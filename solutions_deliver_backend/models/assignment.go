@@ -8,6 +8,8 @@ type AssignmentType string
 const (
 	AssignmentPickup   AssignmentType = "PICKUP"   // Recoger paquete
 	AssignmentDelivery AssignmentType = "DELIVERY" // Entregar paquete
+	AssignmentReturn   AssignmentType = "RETURN"   // Devolver paquete al remitente
+	AssignmentTransfer AssignmentType = "TRANSFER" // Trayecto entre bodegas (hubs) de dos ciudades
 )
 
 // AssignmentStatus estado de la asignación
@@ -28,6 +30,11 @@ const (
 	ActionReassigned   HistoryAction = "REASSIGNED"
 	ActionStatusChange HistoryAction = "STATUS_CHANGE"
 	ActionCancelled    HistoryAction = "CANCELLED"
+
+	// Código de entrega (OTP)
+	ActionHandoverCodeSent     HistoryAction = "HANDOVER_CODE_SENT"
+	ActionHandoverCodeFailed   HistoryAction = "HANDOVER_CODE_FAILED"
+	ActionHandoverCodeOverride HistoryAction = "HANDOVER_CODE_OVERRIDE"
 )
 
 // DeliveryAssignment representa una asignación de entregador
@@ -44,6 +51,18 @@ type DeliveryAssignment struct {
 	AssignedAt       time.Time        `json:"assigned_at"`
 	UpdatedAt        time.Time        `json:"updated_at"`
 	CompletedAt      *time.Time       `json:"completed_at,omitempty"`
+	AttemptNumber    int              `json:"attempt_number"`           // intento de entrega (1 = primera salida)
+	ScheduledDate    string           `json:"scheduled_date,omitempty"` // YYYY-MM-DD, reintentos y devoluciones
+
+	// Traslados (TRANSFER): vehículo y bodegas de origen y destino
+	VehiclePlate             string `json:"vehicle_plate,omitempty"`
+	OriginWarehouseID        int64  `json:"origin_warehouse_id,omitempty"`
+	OriginWarehouseName      string `json:"origin_warehouse_name,omitempty"`
+	DestinationWarehouseID   int64  `json:"destination_warehouse_id,omitempty"`
+	DestinationWarehouseName string `json:"destination_warehouse_name,omitempty"`
+
+	// Hub del repartidor
+	HubID *int64 `json:"hub_id,omitempty"`
 
 	// Información de la guía
 	Guide *GuideInfo `json:"guide,omitempty"`
@@ -103,6 +122,20 @@ type PendingGuide struct {
 	ContactPhone        string         `json:"contact_phone"`
 	CreatedAt           string         `json:"created_at"`
 	AssignmentType      AssignmentType `json:"assignment_type"`
+
+	// Reintentos de entrega (solo en entregas)
+	AttemptCount            int    `json:"attempt_count"`
+	IsReattempt             bool   `json:"is_reattempt"`
+	ReattemptAssignmentID   int64  `json:"reattempt_assignment_id,omitempty"`
+	ReattemptDeliveryUserID string `json:"reattempt_delivery_user_id,omitempty"`
+	ScheduledDate           string `json:"scheduled_date,omitempty"`
+
+	// Bins donde está ubicada la guía en bodega (entregas y traslados)
+	Bins []string `json:"bins,omitempty"`
+
+	// Bodega donde está la guía (solo en traslados): la de sus bins o la
+	// de destino de su último traslado
+	WarehouseID int64 `json:"warehouse_id,omitempty"`
 }
 
 // REQUEST/RESPONSE MODELS
@@ -113,6 +146,11 @@ type CreateAssignmentRequest struct {
 	DeliveryUserID string         `json:"delivery_user_id"`
 	AssignmentType AssignmentType `json:"assignment_type"`
 	Notes          string         `json:"notes,omitempty"`
+
+	// Obligatorios en los traslados (TRANSFER)
+	VehiclePlate           string `json:"vehicle_plate,omitempty"`
+	OriginWarehouseID      int64  `json:"origin_warehouse_id,omitempty"`
+	DestinationWarehouseID int64  `json:"destination_warehouse_id,omitempty"`
 }
 
 // CancelAssignmentResponse respuesta de creación asignación
@@ -139,8 +177,12 @@ type ReassignResponse struct {
 
 // UpdateStatusRequest petición para actualizar estado
 type UpdateAssignmentStatusRequest struct {
-	Status AssignmentStatus `json:"status"`
-	Notes  string           `json:"notes,omitempty"`
+	Status AssignmentStatus      `json:"status"`
+	Notes  string                `json:"notes,omitempty"`
+	Proof  *DeliveryProofRequest `json:"proof,omitempty"` // obligatorio al completar una entrega
+
+	// Código que el destinatario entrega al mensajero (guías COD o de alto valor)
+	HandoverCode string `json:"handover_code,omitempty"`
 }
 
 // UpdateStatusResponse respuesta de actualización
@@ -158,6 +200,7 @@ type AssignmentFilters struct {
 	GuideID        *int64           `json:"guide_id,omitempty"`
 	DateFrom       *time.Time       `json:"date_from,omitempty"`
 	DateTo         *time.Time       `json:"date_to,omitempty"`
+	HubID          *int64           `json:"hub_id,omitempty"` // hub del repartidor
 	Limit          int              `json:"limit"`
 	Offset         int              `json:"offset"`
 }
@@ -180,6 +223,7 @@ type DeliveryUsersListResponse struct {
 type PendingGuidesResponse struct {
 	Pickups    []PendingGuide `json:"pickups"`
 	Deliveries []PendingGuide `json:"deliveries"`
+	Transfers  []PendingGuide `json:"transfers"`
 }
 
 // AssignmentStatsResponse estadísticas de asignaciones
@@ -189,6 +233,8 @@ type AssignmentStatsResponse struct {
 	PendingDeliveries    int            `json:"pending_deliveries"`
 	InProgressPickups    int            `json:"in_progress_pickups"`
 	InProgressDeliveries int            `json:"in_progress_deliveries"`
+	PendingTransfers     int            `json:"pending_transfers"`
+	InProgressTransfers  int            `json:"in_progress_transfers"`
 	CompletedToday       int            `json:"completed_today"`
 	ByDeliveryUser       map[string]int `json:"by_delivery_user"`
 }
@@ -197,6 +243,8 @@ type AssignmentStatsResponse struct {
 type MyAssignmentsResponse struct {
 	Pickups    []DeliveryAssignment `json:"pickups"`
 	Deliveries []DeliveryAssignment `json:"deliveries"`
+	Returns    []DeliveryAssignment `json:"returns"`
+	Transfers  []DeliveryAssignment `json:"transfers"`
 	Stats      MyAssignmentStats    `json:"stats"`
 }
 
@@ -206,6 +254,8 @@ type MyAssignmentStats struct {
 	PendingDeliveries    int `json:"pending_deliveries"`
 	InProgressPickups    int `json:"in_progress_pickups"`
 	InProgressDeliveries int `json:"in_progress_deliveries"`
+	PendingTransfers     int `json:"pending_transfers"`
+	InProgressTransfers  int `json:"in_progress_transfers"`
 	CompletedToday       int `json:"completed_today"`
 	CompletedThisWeek    int `json:"completed_this_week"`
 }
@@ -32,6 +32,10 @@ type CashClose struct {
 	PDFURL   string `json:"pdf_url,omitempty"`
 	PDFS3Key string `json:"pdf_s3_key,omitempty"`
 
+	// Hub (empty in the consolidated close of all hubs)
+	HubID   *int64 `json:"hub_id,omitempty"`
+	HubCode string `json:"hub_code,omitempty"`
+
 	// Audit
 	CreatedBy string    `json:"created_by"`
 	CreatedAt time.Time `json:"created_at"`
@@ -72,7 +76,7 @@ type CashCloseRequest struct {
 type CashCloseResponse struct {
 	Close   CashClose         `json:"close"`
 	Details []CashCloseDetail `json:"details"`
-	PDFURL  string            `json:"pdf_url, omitempty"`
+	PDFURL  string            `json:"pdf_url,omitempty"`
 }
 
 // CashCloseListResponse list of closes
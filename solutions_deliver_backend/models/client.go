@@ -47,7 +47,21 @@ type ClientGuidesResponse struct {
 
 // ClientTrackGuideResponse respuesta para rastrear guía
 type ClientTrackGuideResponse struct {
-	Guide ShippingGuide `json:"guide"`
+	Guide             ShippingGuide      `json:"guide"`
+	StatusLabel       string             `json:"status_label"`
+	StatusDescription string             `json:"status_description"`
+	Timeline          []ClientTrackEvent `json:"timeline"`
+	ProofOfDelivery   *DeliveryProof     `json:"proof_of_delivery,omitempty"`
+}
+
+// ClientTrackEvent evento del rastreo con el texto que ve el cliente.
+// Las notas internas del cambio de estado no se incluyen.
+type ClientTrackEvent struct {
+	Status      GuideStatus `json:"status"`
+	Label       string      `json:"label"`
+	Description string      `json:"description"`
+	Reason      string      `json:"reason,omitempty"`
+	Date        time.Time   `json:"date"`
 }
 
 // ==========================================
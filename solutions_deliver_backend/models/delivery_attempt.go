@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// DeliveryAttempt intento de entrega fallido. Cada intento cierra la
+// asignación DELIVERY en curso y deja la guía en DELIVERY_FAILED.
+type DeliveryAttempt struct {
+	AttemptID     int64             `json:"attempt_id"`
+	AssignmentID  int64             `json:"assignment_id"`
+	GuideID       int64             `json:"guide_id"`
+	AttemptNumber int               `json:"attempt_number"`
+	ReasonCode    GuideStatusReason `json:"reason_code"`
+	Notes         string            `json:"notes"`
+	Latitude      *float64          `json:"latitude,omitempty"`
+	Longitude     *float64          `json:"longitude,omitempty"`
+	AttemptedBy   string            `json:"attempted_by"`
+	AttemptedAt   time.Time         `json:"attempted_at"`
+}
+
+// RegisterAttemptRequest petición para registrar un intento fallido
+type RegisterAttemptRequest struct {
+	ReasonCode GuideStatusReason `json:"reason_code"`
+	Notes      string            `json:"notes"`
+	Latitude   *float64          `json:"latitude,omitempty"`
+	Longitude  *float64          `json:"longitude,omitempty"`
+}
+
+// DeliveryAttemptResult resultado de registrar un intento: la asignación
+// que sigue (reintento o devolución al remitente)
+type DeliveryAttemptResult struct {
+	Attempt          DeliveryAttempt
+	NextAssignmentID int64
+	ReturnToSender   bool
+}
+
+// RegisterAttemptResponse respuesta del registro de un intento
+type RegisterAttemptResponse struct {
+	Success        bool                `json:"success"`
+	Attempt        DeliveryAttempt     `json:"attempt"`
+	MaxAttempts    int                 `json:"max_attempts"`
+	ReturnToSender bool                `json:"return_to_sender"`
+	NextAssignment *DeliveryAssignment `json:"next_assignment,omitempty"`
+	Message        string              `json:"message"`
+}
+
+// DeliveryAttemptsResponse intentos de entrega de una guía
+type DeliveryAttemptsResponse struct {
+	GuideID     int64             `json:"guide_id"`
+	Attempts    []DeliveryAttempt `json:"attempts"`
+	MaxAttempts int               `json:"max_attempts"`
+}
@@ -0,0 +1,72 @@
+package models
+
+import "time"
+
+// ProofEvidence evidencia que puede exigirse al completar una entrega
+type ProofEvidence string
+
+const (
+	EvidenceReceiverName     ProofEvidence = "RECEIVER_NAME"
+	EvidenceReceiverDocument ProofEvidence = "RECEIVER_DOCUMENT"
+	EvidenceSignature        ProofEvidence = "SIGNATURE"
+	EvidencePhoto            ProofEvidence = "PHOTO"
+	EvidenceLocation         ProofEvidence = "LOCATION"
+)
+
+// DeliveryProof prueba de entrega registrada al completar una asignación DELIVERY
+type DeliveryProof struct {
+	ProofID          int64     `json:"proof_id"`
+	AssignmentID     int64     `json:"assignment_id"`
+	GuideID          int64     `json:"guide_id"`
+	ReceiverName     string    `json:"receiver_name"`
+	ReceiverDocument string    `json:"receiver_document"`
+	SignatureS3Key   string    `json:"signature_s3_key,omitempty"`
+	SignatureURL     string    `json:"signature_url,omitempty"`
+	PhotoS3Keys      []string  `json:"photo_s3_keys,omitempty"`
+	PhotoURLs        []string  `json:"photo_urls,omitempty"`
+	Latitude         *float64  `json:"latitude,omitempty"`
+	Longitude        *float64  `json:"longitude,omitempty"`
+	CapturedBy       string    `json:"captured_by"`
+	CapturedAt       time.Time `json:"captured_at"`
+}
+
+// DeliveryProofRequest prueba de entrega enviada junto con el COMPLETED.
+// Las imágenes se suben antes a S3 con las URLs de POST /assignments/{id}/proof/upload-urls
+type DeliveryProofRequest struct {
+	ReceiverName     string   `json:"receiver_name"`
+	ReceiverDocument string   `json:"receiver_document"`
+	SignatureKey     string   `json:"signature_key,omitempty"`
+	PhotoKeys        []string `json:"photo_keys,omitempty"`
+	Latitude         *float64 `json:"latitude,omitempty"`
+	Longitude        *float64 `json:"longitude,omitempty"`
+}
+
+// ProofUploadRequest petición de URLs de carga para la prueba de entrega
+type ProofUploadRequest struct {
+	Signature        bool   `json:"signature"`
+	Photos           int    `json:"photos"`
+	PhotoContentType string `json:"photo_content_type,omitempty"` // image/jpeg (por defecto) o image/png
+}
+
+// ProofUploadURL URL pre-firmada (PUT) para subir una imagen
+type ProofUploadURL struct {
+	S3Key       string `json:"s3_key"`
+	UploadURL   string `json:"upload_url"`
+	ContentType string `json:"content_type"`
+}
+
+// ProofUploadResponse URLs de carga de la firma y las fotos
+type ProofUploadResponse struct {
+	AssignmentID int64            `json:"assignment_id"`
+	ExpiresIn    int              `json:"expires_in"` // minutos
+	Signature    *ProofUploadURL  `json:"signature,omitempty"`
+	Photos       []ProofUploadURL `json:"photos"`
+	Required     []ProofEvidence  `json:"required"`
+	MaxPhotos    int              `json:"max_photos"`
+}
+
+// DeliveryProofErrorResponse respuesta cuando falta evidencia obligatoria
+type DeliveryProofErrorResponse struct {
+	Error   string          `json:"error"`
+	Missing []ProofEvidence `json:"missing"`
+}
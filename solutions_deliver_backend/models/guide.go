@@ -30,6 +30,12 @@ const (
 	StatusInWarehouse    GuideStatus = "IN_WAREHOUSE"
 	StatusOutForDelivery GuideStatus = "OUT_FOR_DELIVERY"
 	StatusDelivered      GuideStatus = "DELIVERED"
+
+	// Estados de excepción (requieren motivo y nota)
+	StatusDeliveryFailed   GuideStatus = "DELIVERY_FAILED"
+	StatusReturnedToSender GuideStatus = "RETURNED_TO_SENDER"
+	StatusCancelled        GuideStatus = "CANCELLED"
+	StatusOnHold           GuideStatus = "ON_HOLD"
 )
 
 // PartyRole roles de las partes
@@ -43,10 +49,12 @@ const (
 // ShippingGuide representa una guía de envío completa
 type ShippingGuide struct {
 	GuideID             int64         `json:"guide_id"`
+	GuideNumber         string        `json:"guide_number,omitempty"`
 	ServiceType         ServiceType   `json:"service_type"`
 	PaymentMethod       PaymentMethod `json:"payment_method"`
 	DeclaredValue       float64       `json:"declared_value"`
 	Price               float64       `json:"price"`
+	RateID              int64         `json:"rate_id,omitempty"`
 	CurrentStatus       GuideStatus   `json:"current_status"`
 	OriginCityID        int64         `json:"origin_city_id"`
 	OriginCityName      string        `json:"origin_city_name,omitempty"`
@@ -54,6 +62,8 @@ type ShippingGuide struct {
 	DestinationCityName string        `json:"destination_city_name,omitempty"`
 	PDFUrl              string        `json:"pdf_url,omitempty"`
 	PDFS3Key            string        `json:"pdf_s3_key,omitempty"`
+	RNDCRemesaID        string        `json:"rndc_remesa_id,omitempty"`   // ingresoid de la remesa en el RNDC
+	RNDCManifestID      string        `json:"rndc_manifest_id,omitempty"` // ingresoid del manifiesto en el RNDC
 	CreatedBy           string        `json:"created_by"`
 	CreatedAt           time.Time     `json:"created_at"`
 	UpdatedAt           time.Time     `json:"updated_at"`
@@ -62,7 +72,11 @@ type ShippingGuide struct {
 	Sender   *GuideParty     `json:"sender,omitempty"`
 	Receiver *GuideParty     `json:"receiver,omitempty"`
 	Package  *Package        `json:"package,omitempty"`
+	Pieces   []GuidePiece    `json:"pieces,omitempty"`
 	History  []StatusHistory `json:"history,omitempty"`
+
+	// Partial indica que las piezas de la guía están en estados distintos
+	Partial bool `json:"partial"`
 }
 
 // GuideParty representa una parte (remitente o destinatario)
@@ -96,11 +110,13 @@ type Package struct {
 
 // StatusHistory representa el historial de estados
 type StatusHistory struct {
-	HistoryID int64       `json:"history_id"`
-	GuideID   int64       `json:"guide_id"`
-	Status    GuideStatus `json:"status"`
-	UpdatedBy string      `json:"updated_by"`
-	UpdatedAt time.Time   `json:"updated_at"`
+	HistoryID  int64             `json:"history_id"`
+	GuideID    int64             `json:"guide_id"`
+	Status     GuideStatus       `json:"status"`
+	ReasonCode GuideStatusReason `json:"reason_code,omitempty"`
+	Notes      string            `json:"notes,omitempty"`
+	UpdatedBy  string            `json:"updated_by"`
+	UpdatedAt  time.Time         `json:"updated_at"`
 }
 
 // GuideFilters filtros para búsqueda de guías
@@ -139,7 +155,9 @@ type GuideStatsResponse struct {
 
 // UpdateStatusRequest petición para actualizar estado
 type UpdateStatusRequest struct {
-	Status GuideStatus `json:"status"`
+	Status     GuideStatus       `json:"status"`
+	ReasonCode GuideStatusReason `json:"reason_code,omitempty"` // obligatorio en estados de excepción
+	Notes      string            `json:"notes,omitempty"`       // obligatorio en estados de excepción
 }
 
 // UpdateStatusResponse respuesta de actualización de estado
@@ -149,3 +167,25 @@ type UpdateStatusResponse struct {
 	NewStatus GuideStatus `json:"new_status"`
 	Message   string      `json:"message"`
 }
+
+// GuideStatusReasonOption motivo con su texto para el selector del despacho
+type GuideStatusReasonOption struct {
+	Code  GuideStatusReason `json:"code"`
+	Label string            `json:"label"`
+}
+
+// GuideExceptionStatus estado de excepción con sus motivos válidos
+type GuideExceptionStatus struct {
+	Status  GuideStatus               `json:"status"`
+	Label   string                    `json:"label"`
+	Reasons []GuideStatusReasonOption `json:"reasons"`
+}
+
+// GuideTransitionErrorResponse respuesta cuando el cambio de estado no es
+// una transición permitida para la guía
+type GuideTransitionErrorResponse struct {
+	Error           string        `json:"error"`
+	CurrentStatus   GuideStatus   `json:"current_status"`
+	RequestedStatus GuideStatus   `json:"requested_status"`
+	AllowedNext     []GuideStatus `json:"allowed_next"`
+}
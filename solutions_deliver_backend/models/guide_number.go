@@ -0,0 +1,110 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Número de guía legible: prefijo de la oficina (2 a 4 letras) + consecutivo
+// de 7 dígitos (o más) + dígito de verificación Luhn, p.ej. SD00001234.
+// Es independiente del guide_id interno y se asigna al crear la guía.
+
+// GuideNumberSequenceDigits dígitos mínimos del consecutivo
+const GuideNumberSequenceDigits = 7
+
+var (
+	ErrGuideNumberFormat      = errors.New("formato de número de guía inválido: se espera prefijo de 2 a 4 letras, consecutivo y dígito de verificación (p.ej. SD00001234)")
+	ErrGuideNumberCheckDigit  = errors.New("número de guía inválido: el dígito de verificación no coincide, revise que esté bien digitado")
+	ErrGuideNumberPrefixValue = errors.New("el prefijo del número de guía debe tener de 2 a 4 letras")
+)
+
+// NewGuideNumber arma el número de guía con su dígito de verificación
+func NewGuideNumber(prefix string, sequence int64) (string, error) {
+	prefix = strings.ToUpper(strings.TrimSpace(prefix))
+	if !ValidGuideNumberPrefix(prefix) {
+		return "", ErrGuideNumberPrefixValue
+	}
+	if sequence <= 0 {
+		return "", fmt.Errorf("consecutivo de número de guía inválido: %d", sequence)
+	}
+
+	digits := fmt.Sprintf("%0*d", GuideNumberSequenceDigits, sequence)
+	return prefix + digits + string(luhnCheckDigit(digits)), nil
+}
+
+// ParseGuideNumber normaliza (mayúsculas, sin espacios ni guiones) y valida
+// el formato y el dígito de verificación, sin consultar la base de datos
+func ParseGuideNumber(raw string) (string, error) {
+	number := NormalizeGuideNumber(raw)
+
+	letters := 0
+	for letters < len(number) && number[letters] >= 'A' && number[letters] <= 'Z' {
+		letters++
+	}
+	digits := number[letters:]
+
+	if !ValidGuideNumberPrefix(number[:letters]) || len(digits) < GuideNumberSequenceDigits+1 || !allDigits(digits) {
+		return "", ErrGuideNumberFormat
+	}
+
+	body, check := digits[:len(digits)-1], digits[len(digits)-1]
+	if luhnCheckDigit(body) != check {
+		return "", ErrGuideNumberCheckDigit
+	}
+	return number, nil
+}
+
+// LooksLikeGuideNumber indica si el texto tiene la forma de un número de guía
+// (prefijo de letras seguido de dígitos), aunque el dígito de verificación falle
+func LooksLikeGuideNumber(raw string) bool {
+	_, err := ParseGuideNumber(raw)
+	return err == nil || err == ErrGuideNumberCheckDigit
+}
+
+// NormalizeGuideNumber pasa a mayúsculas y quita espacios y guiones
+func NormalizeGuideNumber(raw string) string {
+	replacer := strings.NewReplacer(" ", "", "-", "", ".", "")
+	return strings.ToUpper(replacer.Replace(strings.TrimSpace(raw)))
+}
+
+// ValidGuideNumberPrefix indica si el prefijo tiene de 2 a 4 letras A-Z
+func ValidGuideNumberPrefix(prefix string) bool {
+	if len(prefix) < 2 || len(prefix) > 4 {
+		return false
+	}
+	for _, r := range prefix {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// luhnCheckDigit calcula el dígito Luhn (detecta errores de un dígito y
+// la mayoría de transposiciones de dígitos adyacentes)
+func luhnCheckDigit(digits string) byte {
+	sum := 0
+	double := true
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+func allDigits(value string) bool {
+	for i := 0; i < len(value); i++ {
+		if value[i] < '0' || value[i] > '9' {
+			return false
+		}
+	}
+	return value != ""
+}
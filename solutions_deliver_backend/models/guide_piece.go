@@ -0,0 +1,125 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Piezas de una guía. Una guía puede llevar varias cajas, cada una con su
+// peso, medidas, descripción y código propio (número de guía + "-" + número
+// de pieza, p.ej. SD00000018-002). El paquete (packages) guarda los
+// totales, calculados a partir de las piezas.
+
+// MaxGuidePieces máximo de piezas por guía (el código usa 3 dígitos)
+const MaxGuidePieces = 999
+
+// GuidePiece pieza (caja) de una guía
+type GuidePiece struct {
+	PieceID     int64   `json:"piece_id"`
+	GuideID     int64   `json:"guide_id"`
+	PieceNumber int     `json:"piece_number"` // 1..N
+	PieceCode   string  `json:"piece_code"`
+	WeightKg    float64 `json:"weight_kg"`
+	LengthCM    float64 `json:"length_cm"`
+	WidthCM     float64 `json:"width_cm"`
+	HeightCM    float64 `json:"height_cm"`
+	Description string  `json:"description,omitempty"`
+
+	// Estado del último escaneo de la pieza (CREATED si no se ha escaneado)
+	Status        GuideStatus `json:"status"`
+	LastScanPoint ScanPoint   `json:"last_scan_point,omitempty"`
+	LastScannedAt *time.Time  `json:"last_scanned_at,omitempty"`
+}
+
+// NewPieceCode código de la pieza: número de guía y número de pieza
+func NewPieceCode(guideNumber string, piece int) string {
+	return fmt.Sprintf("%s-%03d", guideNumber, piece)
+}
+
+// ParsePieceCode separa un código de pieza en número de guía (ya validado)
+// y número de pieza. false si no tiene el formato de código de pieza.
+func ParsePieceCode(raw string) (string, int, bool) {
+	code := strings.ToUpper(strings.Join(strings.Fields(raw), ""))
+
+	i := strings.LastIndex(code, "-")
+	if i < 0 || len(code)-i-1 != 3 || !allDigits(code[i+1:]) {
+		return "", 0, false
+	}
+
+	number, err := ParseGuideNumber(code[:i])
+	if err != nil {
+		return "", 0, false
+	}
+
+	piece := 0
+	for _, d := range code[i+1:] {
+		piece = piece*10 + int(d-'0')
+	}
+	if piece < 1 {
+		return "", 0, false
+	}
+	return number, piece, true
+}
+
+// ApplyPieceTotals calcula los totales del paquete a partir de las piezas:
+// número de piezas, peso total y las medidas mayores de cada lado
+func ApplyPieceTotals(pkg *Package, pieces []GuidePiece) {
+	pkg.Pieces = len(pieces)
+	pkg.WeightKg, pkg.LengthCM, pkg.WidthCM, pkg.HeightCM = 0, 0, 0, 0
+	for _, p := range pieces {
+		pkg.WeightKg += p.WeightKg
+		pkg.LengthCM = math.Max(pkg.LengthCM, p.LengthCM)
+		pkg.WidthCM = math.Max(pkg.WidthCM, p.WidthCM)
+		pkg.HeightCM = math.Max(pkg.HeightCM, p.HeightCM)
+	}
+	pkg.WeightKg = math.Round(pkg.WeightKg*100) / 100
+}
+
+// SplitPackage reparte un paquete de N piezas iguales en N piezas con las
+// mismas medidas y el peso dividido (la última lleva el residuo del
+// redondeo). Se usa cuando la guía llega sin detalle de piezas.
+func SplitPackage(pkg Package) []GuidePiece {
+	count := pkg.Pieces
+	if count < 1 {
+		count = 1
+	}
+
+	each := math.Floor(pkg.WeightKg/float64(count)*100) / 100
+	pieces := make([]GuidePiece, count)
+	for i := range pieces {
+		pieces[i] = GuidePiece{
+			PieceNumber: i + 1,
+			WeightKg:    each,
+			LengthCM:    pkg.LengthCM,
+			WidthCM:     pkg.WidthCM,
+			HeightCM:    pkg.HeightCM,
+			Description: pkg.Description,
+		}
+	}
+	last := pkg.WeightKg - each*float64(count-1)
+	pieces[count-1].WeightKg = math.Round(last*100) / 100
+	return pieces
+}
+
+// PiecesPartial indica si las piezas de la guía están en estados distintos
+// (p.ej. 2 de 3 cajas llegaron a bodega)
+func PiecesPartial(pieces []GuidePiece) bool {
+	for _, p := range pieces {
+		if p.Status != pieces[0].Status {
+			return true
+		}
+	}
+	return false
+}
+
+// FindPiece busca la pieza por número
+func FindPiece(pieces []GuidePiece, number int) (GuidePiece, bool) {
+	for _, p := range pieces {
+		if p.PieceNumber == number {
+			return p, true
+		}
+	}
+	return GuidePiece{}, false
+}
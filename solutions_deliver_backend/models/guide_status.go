@@ -0,0 +1,369 @@
+package models
+
+// Máquina de estados de la guía. Toda actualización de current_status,
+// manual (PUT /guides/{id}/status), automática (cascada de una
+// asignación o de un manifiesto) o por escaneo (POST /scans), debe
+// corresponder a una transición de esta tabla.
+
+// GuideTransitionTrigger origen del cambio de estado
+type GuideTransitionTrigger string
+
+const (
+	TriggerManual     GuideTransitionTrigger = "MANUAL"     // PUT /guides/{id}/status
+	TriggerAssignment GuideTransitionTrigger = "ASSIGNMENT" // cascada de PUT /assignments/{id}/status
+	TriggerScan       GuideTransitionTrigger = "SCAN"       // POST /scans
+	TriggerManifest   GuideTransitionTrigger = "MANIFEST"   // cierre y recepción de /manifests
+)
+
+// GuideSideEffect efecto obligatorio que se aplica en la misma transacción
+// que el cambio de estado
+type GuideSideEffect string
+
+const (
+	// EffectCancelOpenPickups cancela las recogidas PENDING / IN_PROGRESS
+	EffectCancelOpenPickups GuideSideEffect = "CANCEL_OPEN_PICKUPS"
+	// EffectCancelOpenDeliveries cancela las entregas PENDING / IN_PROGRESS
+	EffectCancelOpenDeliveries GuideSideEffect = "CANCEL_OPEN_DELIVERIES"
+	// EffectCancelOpenReturns cancela las devoluciones PENDING / IN_PROGRESS
+	EffectCancelOpenReturns GuideSideEffect = "CANCEL_OPEN_RETURNS"
+)
+
+// GuideTransition transición legal entre dos estados
+type GuideTransition struct {
+	From GuideStatus
+	To   GuideStatus
+	// Roles que pueden hacer la transición manualmente
+	Roles []UserRole
+	// ByAssignment indica que la transición la puede disparar una asignación
+	ByAssignment bool
+	// ByScan indica que la transición la puede disparar un escaneo
+	ByScan bool
+	// ByManifest indica que la transición la puede disparar un manifiesto
+	ByManifest bool
+	// Effects se aplican junto con el cambio de estado
+	Effects []GuideSideEffect
+}
+
+// guideTransitions tabla de transiciones legales. DELIVERED, RETURNED_TO_SENDER
+// y CANCELLED son estados finales.
+var guideTransitions = []GuideTransition{
+	// Recogida completada por el entregador (o registrada por el admin)
+	{From: StatusCreated, To: StatusInRoute, Roles: []UserRole{RoleAdmin}, ByAssignment: true, ByScan: true,
+		Effects: []GuideSideEffect{EffectCancelOpenPickups}},
+	// El remitente entrega el paquete en la oficina
+	{From: StatusCreated, To: StatusInWarehouse, Roles: []UserRole{RoleAdmin, RoleSecretary}, ByScan: true,
+		Effects: []GuideSideEffect{EffectCancelOpenPickups}},
+	// Llegada a bodega (también al completar o cancelar un traslado en ruta)
+	{From: StatusInRoute, To: StatusInWarehouse, Roles: []UserRole{RoleAdmin, RoleSecretary}, ByAssignment: true, ByScan: true, ByManifest: true},
+	// Despacho desde bodega hacia otra ciudad (manifiesto o traslado)
+	{From: StatusInWarehouse, To: StatusInRoute, Roles: []UserRole{RoleAdmin}, ByAssignment: true, ByManifest: true},
+	// Entrega iniciada
+	{From: StatusInWarehouse, To: StatusOutForDelivery, Roles: []UserRole{RoleAdmin}, ByAssignment: true, ByScan: true},
+	// Entrega cancelada: el paquete vuelve a bodega
+	{From: StatusOutForDelivery, To: StatusInWarehouse, Roles: []UserRole{RoleAdmin, RoleSecretary}, ByAssignment: true,
+		Effects: []GuideSideEffect{EffectCancelOpenDeliveries}},
+	// Entrega completada: solo desde la asignación, que exige la prueba de
+	// entrega y el código de entrega (o su anulación auditada); no es manual
+	{From: StatusOutForDelivery, To: StatusDelivered, ByAssignment: true,
+		Effects: []GuideSideEffect{EffectCancelOpenPickups, EffectCancelOpenDeliveries}},
+
+	// Entrega fallida (no estaba el destinatario, rechazo, dirección errada...)
+	// (el entregador lo registra como intento de entrega)
+	{From: StatusOutForDelivery, To: StatusDeliveryFailed, Roles: []UserRole{RoleAdmin, RoleSecretary}, ByAssignment: true,
+		Effects: []GuideSideEffect{EffectCancelOpenDeliveries}},
+	{From: StatusDeliveryFailed, To: StatusInWarehouse, Roles: []UserRole{RoleAdmin, RoleSecretary}, ByScan: true,
+		Effects: []GuideSideEffect{EffectCancelOpenReturns}},
+	// Nuevo intento de entrega
+	{From: StatusDeliveryFailed, To: StatusOutForDelivery, Roles: []UserRole{RoleAdmin}, ByAssignment: true, ByScan: true},
+	// Devolución completada, o cerrada en mostrador
+	{From: StatusDeliveryFailed, To: StatusReturnedToSender, Roles: []UserRole{RoleAdmin, RoleSecretary}, ByAssignment: true,
+		Effects: []GuideSideEffect{EffectCancelOpenDeliveries, EffectCancelOpenReturns}},
+	{From: StatusDeliveryFailed, To: StatusOnHold, Roles: []UserRole{RoleAdmin, RoleSecretary},
+		Effects: []GuideSideEffect{EffectCancelOpenDeliveries, EffectCancelOpenReturns}},
+
+	// Devolución al remitente desde bodega
+	{From: StatusInWarehouse, To: StatusReturnedToSender, Roles: []UserRole{RoleAdmin, RoleSecretary},
+		Effects: []GuideSideEffect{EffectCancelOpenDeliveries}},
+
+	// Anulación: en mostrador antes de la recogida, o por el admin
+	{From: StatusCreated, To: StatusCancelled, Roles: []UserRole{RoleAdmin, RoleSecretary},
+		Effects: []GuideSideEffect{EffectCancelOpenPickups}},
+	{From: StatusInWarehouse, To: StatusCancelled, Roles: []UserRole{RoleAdmin},
+		Effects: []GuideSideEffect{EffectCancelOpenDeliveries}},
+	{From: StatusOnHold, To: StatusCancelled, Roles: []UserRole{RoleAdmin}},
+
+	// Retención y liberación
+	{From: StatusCreated, To: StatusOnHold, Roles: []UserRole{RoleAdmin, RoleSecretary},
+		Effects: []GuideSideEffect{EffectCancelOpenPickups}},
+	{From: StatusInWarehouse, To: StatusOnHold, Roles: []UserRole{RoleAdmin, RoleSecretary},
+		Effects: []GuideSideEffect{EffectCancelOpenDeliveries}},
+	{From: StatusOnHold, To: StatusCreated, Roles: []UserRole{RoleAdmin, RoleSecretary}},
+	{From: StatusOnHold, To: StatusInWarehouse, Roles: []UserRole{RoleAdmin, RoleSecretary}},
+	{From: StatusOnHold, To: StatusReturnedToSender, Roles: []UserRole{RoleAdmin, RoleSecretary}},
+}
+
+// FindGuideTransition busca la transición de from a to
+func FindGuideTransition(from, to GuideStatus) (GuideTransition, bool) {
+	for _, t := range guideTransitions {
+		if t.From == from && t.To == to {
+			return t, true
+		}
+	}
+	return GuideTransition{}, false
+}
+
+// Allows indica si la transición la puede hacer el rol dado (trigger manual),
+// una asignación (trigger ASSIGNMENT), un escaneo (trigger SCAN) o un
+// manifiesto (trigger MANIFEST); en estos no importa el rol, lo valida la
+// ruta que los origina
+func (t GuideTransition) Allows(role UserRole, trigger GuideTransitionTrigger) bool {
+	switch trigger {
+	case TriggerAssignment:
+		return t.ByAssignment
+	case TriggerScan:
+		return t.ByScan
+	case TriggerManifest:
+		return t.ByManifest
+	}
+	for _, r := range t.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// HasEffect indica si la transición incluye el efecto dado
+func (t GuideTransition) HasEffect(effect GuideSideEffect) bool {
+	for _, e := range t.Effects {
+		if e == effect {
+			return true
+		}
+	}
+	return false
+}
+
+// NextGuideStatuses retorna los estados a los que se puede pasar desde from
+// con el rol y el origen dados
+func NextGuideStatuses(from GuideStatus, role UserRole, trigger GuideTransitionTrigger) []GuideStatus {
+	next := []GuideStatus{}
+	for _, t := range guideTransitions {
+		if t.From == from && t.Allows(role, trigger) {
+			next = append(next, t.To)
+		}
+	}
+	return next
+}
+
+// IsValidGuideStatus indica si el estado existe
+func IsValidGuideStatus(status GuideStatus) bool {
+	switch status {
+	case StatusCreated, StatusInRoute, StatusInWarehouse, StatusOutForDelivery, StatusDelivered,
+		StatusDeliveryFailed, StatusReturnedToSender, StatusCancelled, StatusOnHold:
+		return true
+	}
+	return false
+}
+
+// IsFinal indica si la guía ya no tiene más movimientos
+func (s GuideStatus) IsFinal() bool {
+	return s == StatusDelivered || s == StatusReturnedToSender || s == StatusCancelled
+}
+
+// Label nombre del estado para mostrar al usuario
+func (s GuideStatus) Label() string {
+	switch s {
+	case StatusCreated:
+		return "Creada"
+	case StatusInRoute:
+		return "En ruta"
+	case StatusInWarehouse:
+		return "En bodega"
+	case StatusOutForDelivery:
+		return "En reparto"
+	case StatusDelivered:
+		return "Entregada"
+	case StatusDeliveryFailed:
+		return "Entrega fallida"
+	case StatusReturnedToSender:
+		return "Devuelta al remitente"
+	case StatusCancelled:
+		return "Anulada"
+	case StatusOnHold:
+		return "Retenida"
+	}
+	return string(s)
+}
+
+// ClientDescription texto del estado para el rastreo del cliente
+func (s GuideStatus) ClientDescription() string {
+	switch s {
+	case StatusCreated:
+		return "Tu envío fue registrado y está pendiente de recogida"
+	case StatusInRoute:
+		return "Tu envío va en camino"
+	case StatusInWarehouse:
+		return "Tu envío está en nuestra bodega"
+	case StatusOutForDelivery:
+		return "Tu envío salió a reparto"
+	case StatusDelivered:
+		return "Tu envío fue entregado"
+	case StatusDeliveryFailed:
+		return "No pudimos entregar tu envío. Te contactaremos para un nuevo intento"
+	case StatusReturnedToSender:
+		return "Tu envío fue devuelto al remitente"
+	case StatusCancelled:
+		return "La guía fue anulada"
+	case StatusOnHold:
+		return "Tu envío está retenido temporalmente"
+	}
+	return s.Label()
+}
+
+// ==========================================
+// MOTIVOS DE LOS ESTADOS DE EXCEPCIÓN
+// ==========================================
+
+// GuideStatusReason código de motivo de un estado de excepción
+type GuideStatusReason string
+
+const (
+	ReasonReceiverAbsent      GuideStatusReason = "RECEIVER_ABSENT"
+	ReasonRefused             GuideStatusReason = "REFUSED"
+	ReasonWrongAddress        GuideStatusReason = "WRONG_ADDRESS"
+	ReasonUnreachableArea     GuideStatusReason = "UNREACHABLE_AREA"
+	ReasonPaymentPending      GuideStatusReason = "PAYMENT_PENDING"
+	ReasonMaxAttempts         GuideStatusReason = "MAX_ATTEMPTS"
+	ReasonSenderRequest       GuideStatusReason = "SENDER_REQUEST"
+	ReasonDataError           GuideStatusReason = "DATA_ERROR"
+	ReasonDuplicated          GuideStatusReason = "DUPLICATED"
+	ReasonAddressVerification GuideStatusReason = "ADDRESS_VERIFICATION"
+	ReasonDamagedPackage      GuideStatusReason = "DAMAGED_PACKAGE"
+	ReasonAuthorityHold       GuideStatusReason = "AUTHORITY_HOLD"
+	ReasonOther               GuideStatusReason = "OTHER"
+)
+
+// ExceptionGuideStatuses estados que exigen motivo y nota
+var ExceptionGuideStatuses = []GuideStatus{
+	StatusDeliveryFailed, StatusReturnedToSender, StatusCancelled, StatusOnHold,
+}
+
+// guideStatusReasons motivos válidos por estado de excepción
+var guideStatusReasons = map[GuideStatus][]GuideStatusReason{
+	StatusDeliveryFailed: {
+		ReasonReceiverAbsent, ReasonRefused, ReasonWrongAddress,
+		ReasonUnreachableArea, ReasonPaymentPending, ReasonOther,
+	},
+	StatusReturnedToSender: {
+		ReasonRefused, ReasonMaxAttempts, ReasonWrongAddress, ReasonSenderRequest, ReasonOther,
+	},
+	StatusCancelled: {
+		ReasonSenderRequest, ReasonDataError, ReasonDuplicated, ReasonOther,
+	},
+	StatusOnHold: {
+		ReasonAddressVerification, ReasonPaymentPending, ReasonDamagedPackage,
+		ReasonSenderRequest, ReasonAuthorityHold, ReasonOther,
+	},
+}
+
+// RequiresReason indica si el estado exige código de motivo y nota
+func (s GuideStatus) RequiresReason() bool {
+	_, ok := guideStatusReasons[s]
+	return ok
+}
+
+// GuideStatusReasons retorna los motivos válidos para el estado
+func GuideStatusReasons(status GuideStatus) []GuideStatusReason {
+	return guideStatusReasons[status]
+}
+
+// IsValidGuideStatusReason indica si el motivo aplica al estado
+func IsValidGuideStatusReason(status GuideStatus, reason GuideStatusReason) bool {
+	for _, r := range guideStatusReasons[status] {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// Label texto del motivo para mostrar al usuario (también al cliente)
+func (r GuideStatusReason) Label() string {
+	switch r {
+	case ReasonReceiverAbsent:
+		return "No había quién recibiera el envío"
+	case ReasonRefused:
+		return "El destinatario rechazó el envío"
+	case ReasonWrongAddress:
+		return "Dirección incorrecta o incompleta"
+	case ReasonUnreachableArea:
+		return "Zona de difícil acceso"
+	case ReasonPaymentPending:
+		return "Pago pendiente"
+	case ReasonMaxAttempts:
+		return "Se agotaron los intentos de entrega"
+	case ReasonSenderRequest:
+		return "Solicitud del remitente"
+	case ReasonDataError:
+		return "Error en los datos de la guía"
+	case ReasonDuplicated:
+		return "Guía duplicada"
+	case ReasonAddressVerification:
+		return "Verificación de la dirección"
+	case ReasonDamagedPackage:
+		return "Revisión del estado del paquete"
+	case ReasonAuthorityHold:
+		return "Retenido por una autoridad"
+	case ReasonOther:
+		return "Otro motivo"
+	}
+	return string(r)
+}
+
+// GuideStatusChange cambio de estado de la guía que se aplica en la misma
+// transacción que el cambio de una asignación
+type GuideStatusChange struct {
+	Status GuideStatus
+	Reason GuideStatusReason
+}
+
+// GuideStatusForAssignment retorna el estado al que pasa la guía cuando una
+// asignación cambia de estado. false si el cambio no afecta la guía.
+func GuideStatusForAssignment(assignmentType AssignmentType, status AssignmentStatus, current GuideStatus) (GuideStatus, bool) {
+	switch assignmentType {
+	case AssignmentPickup:
+		if status == AssignmentCompleted {
+			return StatusInRoute, true
+		}
+	case AssignmentReturn:
+		if status == AssignmentCompleted {
+			return StatusReturnedToSender, true
+		}
+	case AssignmentTransfer:
+		switch status {
+		case AssignmentInProgress:
+			return StatusInRoute, true
+		case AssignmentCompleted:
+			return StatusInWarehouse, true
+		case AssignmentCancelled:
+			// Un traslado cancelado en ruta vuelve a la bodega de origen
+			if current == StatusInRoute {
+				return StatusInWarehouse, true
+			}
+		}
+	case AssignmentDelivery:
+		switch status {
+		case AssignmentInProgress:
+			return StatusOutForDelivery, true
+		case AssignmentCompleted:
+			return StatusDelivered, true
+		case AssignmentCancelled:
+			// Solo si la entrega ya había salido; una entrega pendiente
+			// cancelada deja la guía en bodega
+			if current == StatusOutForDelivery {
+				return StatusInWarehouse, true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,216 @@
+package models
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+var allGuideStatuses = []GuideStatus{
+	StatusCreated, StatusInRoute, StatusInWarehouse, StatusOutForDelivery, StatusDelivered,
+	StatusDeliveryFailed, StatusReturnedToSender, StatusCancelled, StatusOnHold,
+}
+
+var allRoles = []UserRole{RoleAdmin, RoleSecretary, RoleDelivery, RoleClient}
+
+var allTriggers = []GuideTransitionTrigger{TriggerManual, TriggerAssignment, TriggerScan, TriggerManifest}
+
+// expectedTransition especificación de una transición: roles del trigger
+// MANUAL, triggers automáticos y efectos
+type expectedTransition struct {
+	roles    []UserRole
+	triggers []GuideTransitionTrigger
+	effects  []GuideSideEffect
+}
+
+var (
+	admin          = []UserRole{RoleAdmin}
+	adminSecretary = []UserRole{RoleAdmin, RoleSecretary}
+)
+
+// expectedGuideTransitions es la máquina de estados esperada; cualquier par
+// from→to que no esté aquí debe ser ilegal
+var expectedGuideTransitions = map[[2]GuideStatus]expectedTransition{
+	{StatusCreated, StatusInRoute}: {admin, []GuideTransitionTrigger{TriggerAssignment, TriggerScan},
+		[]GuideSideEffect{EffectCancelOpenPickups}},
+	{StatusCreated, StatusInWarehouse}: {adminSecretary, []GuideTransitionTrigger{TriggerScan},
+		[]GuideSideEffect{EffectCancelOpenPickups}},
+	{StatusCreated, StatusCancelled}: {adminSecretary, nil,
+		[]GuideSideEffect{EffectCancelOpenPickups}},
+	{StatusCreated, StatusOnHold}: {adminSecretary, nil,
+		[]GuideSideEffect{EffectCancelOpenPickups}},
+
+	{StatusInRoute, StatusInWarehouse}: {adminSecretary, []GuideTransitionTrigger{TriggerAssignment, TriggerScan, TriggerManifest}, nil},
+
+	{StatusInWarehouse, StatusInRoute}:        {admin, []GuideTransitionTrigger{TriggerAssignment, TriggerManifest}, nil},
+	{StatusInWarehouse, StatusOutForDelivery}: {admin, []GuideTransitionTrigger{TriggerAssignment, TriggerScan}, nil},
+	{StatusInWarehouse, StatusReturnedToSender}: {adminSecretary, nil,
+		[]GuideSideEffect{EffectCancelOpenDeliveries}},
+	{StatusInWarehouse, StatusCancelled}: {admin, nil,
+		[]GuideSideEffect{EffectCancelOpenDeliveries}},
+	{StatusInWarehouse, StatusOnHold}: {adminSecretary, nil,
+		[]GuideSideEffect{EffectCancelOpenDeliveries}},
+
+	{StatusOutForDelivery, StatusInWarehouse}: {adminSecretary, []GuideTransitionTrigger{TriggerAssignment},
+		[]GuideSideEffect{EffectCancelOpenDeliveries}},
+	{StatusOutForDelivery, StatusDelivered}: {nil, []GuideTransitionTrigger{TriggerAssignment},
+		[]GuideSideEffect{EffectCancelOpenPickups, EffectCancelOpenDeliveries}},
+	{StatusOutForDelivery, StatusDeliveryFailed}: {adminSecretary, []GuideTransitionTrigger{TriggerAssignment},
+		[]GuideSideEffect{EffectCancelOpenDeliveries}},
+
+	{StatusDeliveryFailed, StatusInWarehouse}: {adminSecretary, []GuideTransitionTrigger{TriggerScan},
+		[]GuideSideEffect{EffectCancelOpenReturns}},
+	{StatusDeliveryFailed, StatusOutForDelivery}: {admin, []GuideTransitionTrigger{TriggerAssignment, TriggerScan}, nil},
+	{StatusDeliveryFailed, StatusReturnedToSender}: {adminSecretary, []GuideTransitionTrigger{TriggerAssignment},
+		[]GuideSideEffect{EffectCancelOpenDeliveries, EffectCancelOpenReturns}},
+	{StatusDeliveryFailed, StatusOnHold}: {adminSecretary, nil,
+		[]GuideSideEffect{EffectCancelOpenDeliveries, EffectCancelOpenReturns}},
+
+	{StatusOnHold, StatusCancelled}:        {admin, nil, nil},
+	{StatusOnHold, StatusCreated}:          {adminSecretary, nil, nil},
+	{StatusOnHold, StatusInWarehouse}:      {adminSecretary, nil, nil},
+	{StatusOnHold, StatusReturnedToSender}: {adminSecretary, nil, nil},
+}
+
+func (e expectedTransition) allows(role UserRole, trigger GuideTransitionTrigger) bool {
+	if trigger == TriggerManual {
+		for _, r := range e.roles {
+			if r == role {
+				return true
+			}
+		}
+		return false
+	}
+	for _, t := range e.triggers {
+		if t == trigger {
+			return true
+		}
+	}
+	return false
+}
+
+// Recorre todos los pares from→to con cada trigger y cada rol
+func TestGuideTransitions(t *testing.T) {
+	for _, from := range allGuideStatuses {
+		for _, to := range allGuideStatuses {
+			expected, legal := expectedGuideTransitions[[2]GuideStatus{from, to}]
+			name := string(from) + "→" + string(to)
+
+			t.Run(name, func(t *testing.T) {
+				transition, ok := FindGuideTransition(from, to)
+				if ok != legal {
+					t.Fatalf("FindGuideTransition = %v, se esperaba %v", ok, legal)
+				}
+				if !legal {
+					return
+				}
+
+				for _, trigger := range allTriggers {
+					for _, role := range append(allRoles, "") {
+						if got, want := transition.Allows(role, trigger), expected.allows(role, trigger); got != want {
+							t.Errorf("Allows(%q, %s) = %v, se esperaba %v", role, trigger, got, want)
+						}
+					}
+				}
+
+				effects := []GuideSideEffect{EffectCancelOpenPickups, EffectCancelOpenDeliveries, EffectCancelOpenReturns}
+				for _, effect := range effects {
+					want := false
+					for _, e := range expected.effects {
+						want = want || e == effect
+					}
+					if got := transition.HasEffect(effect); got != want {
+						t.Errorf("HasEffect(%s) = %v, se esperaba %v", effect, got, want)
+					}
+				}
+			})
+		}
+	}
+}
+
+// Los estados finales no tienen salida
+func TestGuideFinalStatuses(t *testing.T) {
+	for _, status := range allGuideStatuses {
+		hasNext := false
+		for _, to := range allGuideStatuses {
+			_, ok := FindGuideTransition(status, to)
+			hasNext = hasNext || ok
+		}
+		if status.IsFinal() == hasNext {
+			t.Errorf("%s: IsFinal = %v con transiciones de salida = %v", status, status.IsFinal(), hasNext)
+		}
+	}
+}
+
+func TestNextGuideStatuses(t *testing.T) {
+	for _, from := range allGuideStatuses {
+		for _, trigger := range allTriggers {
+			for _, role := range allRoles {
+				want := []GuideStatus{}
+				for _, to := range allGuideStatuses {
+					if e, ok := expectedGuideTransitions[[2]GuideStatus{from, to}]; ok && e.allows(role, trigger) {
+						want = append(want, to)
+					}
+				}
+
+				got := NextGuideStatuses(from, role, trigger)
+				sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+				sort.Slice(want, func(i, j int) bool { return want[i] < want[j] })
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("NextGuideStatuses(%s, %s, %s) = %v, se esperaba %v", from, role, trigger, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestGuideStatusForAssignment(t *testing.T) {
+	tests := []struct {
+		assignmentType AssignmentType
+		status         AssignmentStatus
+		current        GuideStatus
+		want           GuideStatus
+		update         bool
+	}{
+		{AssignmentPickup, AssignmentPending, StatusCreated, "", false},
+		{AssignmentPickup, AssignmentInProgress, StatusCreated, "", false},
+		{AssignmentPickup, AssignmentCompleted, StatusCreated, StatusInRoute, true},
+		{AssignmentPickup, AssignmentCancelled, StatusCreated, "", false},
+
+		{AssignmentDelivery, AssignmentPending, StatusInWarehouse, "", false},
+		{AssignmentDelivery, AssignmentInProgress, StatusInWarehouse, StatusOutForDelivery, true},
+		{AssignmentDelivery, AssignmentInProgress, StatusDeliveryFailed, StatusOutForDelivery, true},
+		{AssignmentDelivery, AssignmentCompleted, StatusOutForDelivery, StatusDelivered, true},
+		{AssignmentDelivery, AssignmentCancelled, StatusOutForDelivery, StatusInWarehouse, true},
+		{AssignmentDelivery, AssignmentCancelled, StatusInWarehouse, "", false},
+
+		{AssignmentTransfer, AssignmentPending, StatusInWarehouse, "", false},
+		{AssignmentTransfer, AssignmentInProgress, StatusInWarehouse, StatusInRoute, true},
+		{AssignmentTransfer, AssignmentCompleted, StatusInRoute, StatusInWarehouse, true},
+		{AssignmentTransfer, AssignmentCancelled, StatusInRoute, StatusInWarehouse, true},
+		{AssignmentTransfer, AssignmentCancelled, StatusInWarehouse, "", false},
+
+		{AssignmentReturn, AssignmentPending, StatusDeliveryFailed, "", false},
+		{AssignmentReturn, AssignmentInProgress, StatusDeliveryFailed, "", false},
+		{AssignmentReturn, AssignmentCompleted, StatusDeliveryFailed, StatusReturnedToSender, true},
+		{AssignmentReturn, AssignmentCancelled, StatusDeliveryFailed, "", false},
+	}
+
+	for _, tt := range tests {
+		name := string(tt.assignmentType) + "/" + string(tt.status) + "/" + string(tt.current)
+		t.Run(name, func(t *testing.T) {
+			got, update := GuideStatusForAssignment(tt.assignmentType, tt.status, tt.current)
+			if got != tt.want || update != tt.update {
+				t.Fatalf("GuideStatusForAssignment = %s, %v; se esperaba %s, %v", got, update, tt.want, tt.update)
+			}
+			if !update {
+				return
+			}
+			// Toda cascada debe ser una transición que una asignación puede disparar
+			transition, ok := FindGuideTransition(tt.current, got)
+			if !ok || !transition.Allows("", TriggerAssignment) {
+				t.Errorf("%s→%s no es una transición por asignación", tt.current, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// HandoverCode código de un solo uso con el que el destinatario confirma la
+// entrega. Solo se guarda el hash del código.
+type HandoverCode struct {
+	AssignmentID   int64      `json:"assignment_id"`
+	GuideID        int64      `json:"guide_id"`
+	CodeHash       string     `json:"-"`
+	Channel        string     `json:"channel"` // SMS, EMAIL o SMS,EMAIL
+	SentTo         string     `json:"sent_to"` // destino enmascarado
+	ExpiresAt      time.Time  `json:"expires_at"`
+	FailedAttempts int        `json:"failed_attempts"`
+	LockedUntil    *time.Time `json:"locked_until,omitempty"`
+	VerifiedAt     *time.Time `json:"verified_at,omitempty"`
+	OverriddenBy   string     `json:"overridden_by,omitempty"`
+	OverrideReason string     `json:"override_reason,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// HashHandoverCode HMAC-SHA256 del código ligado a la asignación. Con solo
+// 10^6 códigos posibles un hash sin llave se revierte por fuerza bruta; la
+// llave es un secreto del servidor que no está en la BD.
+func HashHandoverCode(key []byte, assignmentID int64, code string) string {
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "%d:%s", assignmentID, code)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// HandoverCheckResult resultado de verificar un código de entrega
+type HandoverCheckResult string
+
+const (
+	HandoverValid   HandoverCheckResult = "VALID"
+	HandoverInvalid HandoverCheckResult = "INVALID"
+	HandoverLocked  HandoverCheckResult = "LOCKED"
+	HandoverExpired HandoverCheckResult = "EXPIRED"
+)
+
+// HandoverVerification resultado de la verificación con el estado del bloqueo
+type HandoverVerification struct {
+	Result            HandoverCheckResult `json:"result"`
+	RemainingAttempts int                 `json:"remaining_attempts"`
+	LockedUntil       *time.Time          `json:"locked_until,omitempty"`
+}
+
+// handoverMaxLockout tope del bloqueo escalonado
+const handoverMaxLockout = 24 * time.Hour
+
+// HandoverLockout duración del bloqueo tras failedAttempts fallos acumulados
+// (no se reinician al reenviar el código). Se bloquea cada maxAttempts
+// fallos y cada bloqueo dura el doble del anterior, hasta 24h. Retorna 0 si
+// el fallo no bloquea.
+func HandoverLockout(failedAttempts int, maxAttempts int, lockout time.Duration) time.Duration {
+	if maxAttempts < 1 || failedAttempts < maxAttempts || failedAttempts%maxAttempts != 0 {
+		return 0
+	}
+	for i := failedAttempts / maxAttempts; i > 1 && lockout < handoverMaxLockout; i-- {
+		lockout *= 2
+	}
+	if lockout > handoverMaxLockout {
+		return handoverMaxLockout
+	}
+	return lockout
+}
+
+// HandoverRemainingAttempts intentos que quedan antes del siguiente bloqueo
+func HandoverRemainingAttempts(failedAttempts int, maxAttempts int) int {
+	if maxAttempts < 1 {
+		return 0
+	}
+	return maxAttempts - failedAttempts%maxAttempts
+}
+
+// HandoverOverrideRequest petición del admin para omitir el código
+type HandoverOverrideRequest struct {
+	Reason string `json:"reason"`
+}
+
+// HandoverCodeResponse respuesta del envío u omisión del código
+type HandoverCodeResponse struct {
+	Success      bool         `json:"success"`
+	AssignmentID int64        `json:"assignment_id"`
+	Code         HandoverCode `json:"handover_code"`
+	Message      string       `json:"message"`
+}
+
+// HandoverErrorResponse respuesta cuando el código no es válido
+type HandoverErrorResponse struct {
+	Error             string     `json:"error"`
+	RemainingAttempts int        `json:"remaining_attempts"`
+	LockedUntil       *time.Time `json:"locked_until,omitempty"`
+}
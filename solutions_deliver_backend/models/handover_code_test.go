@@ -0,0 +1,34 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHandoverLockout(t *testing.T) {
+	tests := []struct {
+		failed    int
+		lockout   time.Duration
+		remaining int
+	}{
+		{1, 0, 4},
+		{4, 0, 1},
+		{5, 15 * time.Minute, 5},
+		{6, 0, 4},
+		{10, 30 * time.Minute, 5},
+		{15, time.Hour, 5},
+		{20, 2 * time.Hour, 5},
+		{35, 16 * time.Hour, 5},
+		{40, 24 * time.Hour, 5},
+		{500, 24 * time.Hour, 5},
+	}
+
+	for _, tt := range tests {
+		if got := HandoverLockout(tt.failed, 5, 15*time.Minute); got != tt.lockout {
+			t.Errorf("HandoverLockout(%d) = %s, se esperaba %s", tt.failed, got, tt.lockout)
+		}
+		if got := HandoverRemainingAttempts(tt.failed, 5); got != tt.remaining {
+			t.Errorf("HandoverRemainingAttempts(%d) = %d, se esperaba %d", tt.failed, got, tt.remaining)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package models
+
+import "time"
+
+// Hubs (sedes) de la operación. Cada hub cubre ciudades y departamentos
+// completos; las guías se asignan al hub que cubre su ciudad de origen
+// (recogidas, ingresos) o de destino (entregas). Secretarias y repartidores
+// pertenecen a un hub y solo operan sobre él; ADMIN ve todos o elige uno.
+
+// Hub sede de la operación
+type Hub struct {
+	HubID     int64         `json:"hub_id"`
+	Code      string        `json:"code"`
+	Name      string        `json:"name"`
+	CityID    int64         `json:"city_id"` // ciudad sede
+	CityName  string        `json:"city_name,omitempty"`
+	Active    bool          `json:"active"`
+	Coverage  []HubCoverage `json:"coverage"`
+	Users     int           `json:"users"` // secretarias y repartidores asignados
+	CreatedBy string        `json:"created_by,omitempty"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// HubCoverage ciudad o departamento completo que cubre el hub
+type HubCoverage struct {
+	CityID         int64  `json:"city_id,omitempty"`
+	CityName       string `json:"city_name,omitempty"`
+	DepartmentID   int64  `json:"department_id,omitempty"`
+	DepartmentName string `json:"department_name,omitempty"`
+}
+
+// Covers indica si el hub cubre la ciudad, directamente o por su
+// departamento (la misma regla de hub_coverage en las vistas)
+func (h Hub) Covers(city City) bool {
+	for _, c := range h.Coverage {
+		if c.CityID == city.ID || (c.DepartmentID != 0 && c.DepartmentID == city.DepartmentID) {
+			return true
+		}
+	}
+	return false
+}
+
+// HubScope alcance de una consulta por hub. HubID nil: todos los hubs
+// (consolidado, solo ADMIN).
+type HubScope struct {
+	HubID *int64
+}
+
+// Consolidated indica si el alcance incluye todos los hubs
+func (s HubScope) Consolidated() bool {
+	return s.HubID == nil
+}
+
+// Includes indica si el hub está dentro del alcance
+func (s HubScope) Includes(hubID *int64) bool {
+	if s.HubID == nil {
+		return true
+	}
+	return hubID != nil && *hubID == *s.HubID
+}
+
+// CreateHubRequest datos para crear un hub. La ciudad sede se agrega a la
+// cobertura si no está incluida.
+type CreateHubRequest struct {
+	Code          string  `json:"code"`
+	Name          string  `json:"name"`
+	CityID        int64   `json:"city_id"`
+	CityIDs       []int64 `json:"city_ids,omitempty"`
+	DepartmentIDs []int64 `json:"department_ids,omitempty"`
+}
+
+// UpdateHubRequest cambios de un hub. Si se envía city_ids o
+// department_ids, la cobertura se reemplaza por completo.
+type UpdateHubRequest struct {
+	Name          string  `json:"name,omitempty"`
+	Active        *bool   `json:"active,omitempty"`
+	CityIDs       []int64 `json:"city_ids,omitempty"`
+	DepartmentIDs []int64 `json:"department_ids,omitempty"`
+}
+
+// HubSummary resumen de un hub en el dashboard consolidado
+type HubSummary struct {
+	HubID          int64   `json:"hub_id"`
+	Code           string  `json:"code"`
+	Name           string  `json:"name"`
+	ShipmentsToday int     `json:"shipments_today"`
+	RevenueToday   float64 `json:"revenue_today"`
+	Pending        int     `json:"pending"`
+	Couriers       int     `json:"couriers"`
+}
@@ -0,0 +1,12 @@
+package models
+
+// LabelResponse rótulo térmico de la guía (GET /guides/{id}/label)
+type LabelResponse struct {
+	GuideID     int64  `json:"guide_id"`
+	GuideNumber string `json:"guide_number"`
+	Format      string `json:"format"` // zpl | pdf
+	Pieces      int    `json:"pieces"` // un rótulo por pieza
+	FileName    string `json:"file_name"`
+	ContentType string `json:"content_type"`
+	Content     string `json:"content"` // base64
+}
@@ -0,0 +1,205 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// Manifiestos de despacho: las guías intermunicipales salen de la bodega
+// agrupadas por la ruta de la tarifa (shipping_rates.route, p.ej.
+// "BOGOTA - NEIVA") en un vehículo con su conductor. Cerrar el manifiesto
+// despacha las guías (IN_ROUTE) y recibirlo en destino las ingresa a
+// bodega (IN_WAREHOUSE), marcando las diferencias.
+
+// ManifestStatus estado del manifiesto
+type ManifestStatus string
+
+const (
+	ManifestOpen     ManifestStatus = "OPEN"     // se están cargando guías
+	ManifestClosed   ManifestStatus = "CLOSED"   // despachado, en ruta
+	ManifestReceived ManifestStatus = "RECEIVED" // recibido en destino
+)
+
+// ManifestGuideStatus resultado de la recepción de cada guía del manifiesto
+type ManifestGuideStatus string
+
+const (
+	ManifestGuideLoaded   ManifestGuideStatus = "LOADED"   // cargada, sin recibir
+	ManifestGuideReceived ManifestGuideStatus = "RECEIVED" // llegaron todas las piezas
+	ManifestGuidePartial  ManifestGuideStatus = "PARTIAL"  // llegaron solo algunas piezas
+	ManifestGuideMissing  ManifestGuideStatus = "MISSING"  // no llegó; sigue IN_ROUTE
+)
+
+// Manifest manifiesto de despacho
+type Manifest struct {
+	ManifestID     int64          `json:"manifest_id"`
+	Route          string         `json:"route"`
+	DispatchDate   time.Time      `json:"dispatch_date"`
+	VehiclePlate   string         `json:"vehicle_plate"`
+	DriverName     string         `json:"driver_name"`
+	DriverDocument string         `json:"driver_document"`
+	DriverPhone    string         `json:"driver_phone,omitempty"`
+	Status         ManifestStatus `json:"status"`
+	Notes          string         `json:"notes,omitempty"`
+	ReceiveNotes   string         `json:"receive_notes,omitempty"`
+	RNDCManifestID string         `json:"rndc_manifest_id,omitempty"` // ingresoid en el RNDC
+	Totals         ManifestTotals `json:"totals"`
+	CreatedBy      string         `json:"created_by"`
+	CreatedAt      time.Time      `json:"created_at"`
+	ClosedBy       string         `json:"closed_by,omitempty"`
+	ClosedAt       *time.Time     `json:"closed_at,omitempty"`
+	ReceivedBy     string         `json:"received_by,omitempty"`
+	ReceivedAt     *time.Time     `json:"received_at,omitempty"`
+
+	Guides []ManifestGuide `json:"guides,omitempty"`
+}
+
+// ManifestTotals totales del manifiesto, calculados de sus guías
+type ManifestTotals struct {
+	Guides        int     `json:"guides"`
+	Pieces        int     `json:"pieces"`
+	WeightKg      float64 `json:"weight_kg"`
+	DeclaredValue float64 `json:"declared_value"`
+	CODToCollect  float64 `json:"cod_to_collect"` // flete de las guías contraentrega
+}
+
+// ManifestGuide guía cargada en el manifiesto. Piezas, peso y valores se
+// copian de la guía al cargarla.
+type ManifestGuide struct {
+	ManifestID          int64               `json:"manifest_id,omitempty"`
+	GuideID             int64               `json:"guide_id"`
+	GuideNumber         string              `json:"guide_number,omitempty"`
+	Route               string              `json:"route,omitempty"`
+	DestinationCityName string              `json:"destination_city_name,omitempty"`
+	ReceiverName        string              `json:"receiver_name,omitempty"`
+	PaymentMethod       PaymentMethod       `json:"payment_method"`
+	Pieces              int                 `json:"pieces"`
+	WeightKg            float64             `json:"weight_kg"`
+	DeclaredValue       float64             `json:"declared_value"`
+	CODAmount           float64             `json:"cod_amount"`
+	Status              ManifestGuideStatus `json:"status,omitempty"`
+	ReceivedPieces      int                 `json:"received_pieces"`
+	AddedBy             string              `json:"added_by,omitempty"`
+	AddedAt             *time.Time          `json:"added_at,omitempty"`
+}
+
+// ManifestFilters filtros del listado de manifiestos
+type ManifestFilters struct {
+	Status       ManifestStatus
+	Route        string
+	DispatchDate *time.Time
+}
+
+// CreateManifestRequest datos para crear un manifiesto. DispatchDate es
+// YYYY-MM-DD; por defecto la fecha de hoy.
+type CreateManifestRequest struct {
+	Route          string `json:"route"`
+	DispatchDate   string `json:"dispatch_date"`
+	VehiclePlate   string `json:"vehicle_plate"`
+	DriverName     string `json:"driver_name"`
+	DriverDocument string `json:"driver_document"`
+	DriverPhone    string `json:"driver_phone,omitempty"`
+	Notes          string `json:"notes,omitempty"`
+}
+
+// ManifestCodesRequest códigos de guía para cargar al manifiesto
+// (POST /manifests/{id}/guides)
+type ManifestCodesRequest struct {
+	Codes []string `json:"codes"`
+}
+
+// ReceiveManifestRequest códigos (de guía o de pieza) escaneados al
+// descargar el vehículo en destino
+type ReceiveManifestRequest struct {
+	Codes []string `json:"codes"`
+	Notes string   `json:"notes,omitempty"`
+}
+
+// ManifestCodeResult resultado de cada código cargado al manifiesto
+type ManifestCodeResult struct {
+	Code        string `json:"code"`
+	GuideID     int64  `json:"guide_id,omitempty"`
+	GuideNumber string `json:"guide_number,omitempty"`
+	Success     bool   `json:"success"`
+	Error       string `json:"error,omitempty"`
+}
+
+// ManifestGuidesResponse respuesta de la carga de guías
+type ManifestGuidesResponse struct {
+	Total     int                  `json:"total"`
+	Succeeded int                  `json:"succeeded"`
+	Failed    int                  `json:"failed"`
+	Items     []ManifestCodeResult `json:"items"`
+	Manifest  Manifest             `json:"manifest"`
+}
+
+// ManifestReceiveResponse resultado de la recepción: el manifiesto con el
+// estado de cada guía y las diferencias encontradas
+type ManifestReceiveResponse struct {
+	Manifest   Manifest `json:"manifest"`
+	Received   int      `json:"received"`
+	Partial    int      `json:"partial"`
+	Missing    int      `json:"missing"`
+	Unexpected []string `json:"unexpected"` // códigos que no son del manifiesto
+	Invalid    []string `json:"invalid"`    // códigos que no corresponden a ninguna guía
+}
+
+// ManifestPDFResponse manifiesto impreso (GET /manifests/{id}/pdf)
+type ManifestPDFResponse struct {
+	ManifestID  int64  `json:"manifest_id"`
+	FileName    string `json:"file_name"`
+	ContentType string `json:"content_type"`
+	Content     string `json:"content"` // base64
+}
+
+// ApplyManifestTotals calcula los totales del manifiesto a partir de sus guías
+func ApplyManifestTotals(m *Manifest) {
+	var totals ManifestTotals
+	for _, g := range m.Guides {
+		totals.Guides++
+		totals.Pieces += g.Pieces
+		totals.WeightKg += g.WeightKg
+		totals.DeclaredValue += g.DeclaredValue
+		totals.CODToCollect += g.CODAmount
+	}
+	totals.WeightKg = math.Round(totals.WeightKg*100) / 100
+	m.Totals = totals
+}
+
+// NewManifestGuide línea del manifiesto con los datos de la guía: piezas y
+// peso del paquete (o de sus piezas), valor declarado y, en contraentrega,
+// el flete a cobrar
+func NewManifestGuide(guide ShippingGuide, route string) ManifestGuide {
+	line := ManifestGuide{
+		GuideID:             guide.GuideID,
+		GuideNumber:         guide.GuideNumber,
+		Route:               route,
+		DestinationCityName: guide.DestinationCityName,
+		PaymentMethod:       guide.PaymentMethod,
+		DeclaredValue:       guide.DeclaredValue,
+		Status:              ManifestGuideLoaded,
+	}
+	if guide.Receiver != nil {
+		line.ReceiverName = guide.Receiver.FullName
+	}
+	if guide.PaymentMethod == PaymentCOD {
+		line.CODAmount = guide.Price
+	}
+
+	switch {
+	case len(guide.Pieces) > 0:
+		line.Pieces = len(guide.Pieces)
+		for _, p := range guide.Pieces {
+			line.WeightKg += p.WeightKg
+		}
+		line.WeightKg = math.Round(line.WeightKg*100) / 100
+	case guide.Package != nil:
+		line.Pieces = guide.Package.Pieces
+		line.WeightKg = guide.Package.WeightKg
+	}
+	if line.Pieces < 1 {
+		line.Pieces = 1
+	}
+
+	return line
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// PublicTrackResponse rastreo público de una guía (sin cuenta).
+// Solo expone datos redactados: nombres enmascarados, ciudades, el
+// historial de estados sin notas y la fecha estimada de entrega.
+type PublicTrackResponse struct {
+	GuideNumber       string             `json:"guide_number"`
+	ServiceType       ServiceType        `json:"service_type"`
+	Status            GuideStatus        `json:"status"`
+	StatusLabel       string             `json:"status_label"`
+	StatusDescription string             `json:"status_description"`
+	OriginCity        string             `json:"origin_city"`
+	DestinationCity   string             `json:"destination_city"`
+	SenderName        string             `json:"sender_name"`
+	ReceiverName      string             `json:"receiver_name"`
+	CreatedAt         time.Time          `json:"created_at"`
+	EstimatedDelivery string             `json:"estimated_delivery,omitempty"` // YYYY-MM-DD
+	DeliveredAt       *time.Time         `json:"delivered_at,omitempty"`
+	Timeline          []ClientTrackEvent `json:"timeline"`
+}
+
+// RateLimitHit resultado de registrar una solicitud en una ventana de límite
+type RateLimitHit struct {
+	Count   int       `json:"count"`
+	ResetAt time.Time `json:"reset_at"`
+}
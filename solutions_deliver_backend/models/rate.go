@@ -0,0 +1,259 @@
+package models
+
+import "time"
+
+// RateStatus estado de una tarifa
+type RateStatus string
+
+const (
+	RateActive  RateStatus = "ACTIVE"
+	RateRetired RateStatus = "RETIRED"
+)
+
+// RateAction tipo de cambio registrado en el historial de tarifas
+type RateAction string
+
+const (
+	RateActionCreated RateAction = "CREATED"
+	RateActionUpdated RateAction = "UPDATED"
+	RateActionRetired RateAction = "RETIRED"
+)
+
+// ShippingRate tarifa de envío entre dos ciudades (tabla shipping_rates).
+// Cada cambio de precio es una nueva fila con otra EffectiveDate; la tarifa
+// aplicable es la ACTIVE con mayor EffectiveDate que ya esté en vigor.
+type ShippingRate struct {
+	ID                  int64      `json:"id"`
+	OriginCityID        int64      `json:"origin_city_id"`
+	OriginCityName      string     `json:"origin_city_name,omitempty"`
+	DestinationCityID   int64      `json:"destination_city_id"`
+	DestinationCityName string     `json:"destination_city_name,omitempty"`
+	Route               string     `json:"route"`
+	TravelFrequency     string     `json:"travel_frequency"`
+	MinDispatchKg       int        `json:"min_dispatch_kg"`
+	PricePerKg          float64    `json:"price_per_kg"`
+	MinValue            float64    `json:"min_value"`
+	EffectiveDate       time.Time  `json:"effective_date"`
+	Status              RateStatus `json:"status"`
+	RetiredAt           *time.Time `json:"retired_at,omitempty"`
+	CreatedBy           string     `json:"created_by,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// RateFilters filtros para el listado de tarifas
+type RateFilters struct {
+	OriginCityID      *int64     `json:"origin_city_id,omitempty"`
+	DestinationCityID *int64     `json:"destination_city_id,omitempty"`
+	Route             string     `json:"route,omitempty"`
+	Status            RateStatus `json:"status,omitempty"`
+	Limit             int        `json:"limit"`
+	Offset            int        `json:"offset"`
+}
+
+// RatesListResponse respuesta para lista de tarifas
+type RatesListResponse struct {
+	Rates  []ShippingRate `json:"rates"`
+	Total  int            `json:"total"`
+	Limit  int            `json:"limit"`
+	Offset int            `json:"offset"`
+}
+
+// CreateRateRequest datos para crear (o programar) una tarifa.
+// EffectiveDate es YYYY-MM-DD; por defecto la fecha de hoy.
+type CreateRateRequest struct {
+	OriginCityID      int64   `json:"origin_city_id"`
+	DestinationCityID int64   `json:"destination_city_id"`
+	Route             string  `json:"route"`
+	TravelFrequency   string  `json:"travel_frequency"`
+	MinDispatchKg     int     `json:"min_dispatch_kg"`
+	PricePerKg        float64 `json:"price_per_kg"`
+	MinValue          float64 `json:"min_value"`
+	EffectiveDate     string  `json:"effective_date"`
+}
+
+// UpdateRateRequest campos modificables de una tarifa. Los precios y la
+// fecha solo se pueden cambiar mientras la tarifa no esté en vigor.
+type UpdateRateRequest struct {
+	Route           *string  `json:"route,omitempty"`
+	TravelFrequency *string  `json:"travel_frequency,omitempty"`
+	MinDispatchKg   *int     `json:"min_dispatch_kg,omitempty"`
+	PricePerKg      *float64 `json:"price_per_kg,omitempty"`
+	MinValue        *float64 `json:"min_value,omitempty"`
+	EffectiveDate   *string  `json:"effective_date,omitempty"`
+}
+
+// RateChange entrada del historial de una tarifa (tabla shipping_rate_history)
+type RateChange struct {
+	ID                    int64      `json:"id"`
+	RateID                int64      `json:"rate_id"`
+	Action                RateAction `json:"action"`
+	PreviousPricePerKg    *float64   `json:"previous_price_per_kg,omitempty"`
+	NewPricePerKg         *float64   `json:"new_price_per_kg,omitempty"`
+	PreviousMinValue      *float64   `json:"previous_min_value,omitempty"`
+	NewMinValue           *float64   `json:"new_min_value,omitempty"`
+	PreviousMinDispatchKg *int       `json:"previous_min_dispatch_kg,omitempty"`
+	NewMinDispatchKg      *int       `json:"new_min_dispatch_kg,omitempty"`
+	PreviousEffectiveDate *time.Time `json:"previous_effective_date,omitempty"`
+	NewEffectiveDate      *time.Time `json:"new_effective_date,omitempty"`
+	ChangedBy             string     `json:"changed_by"`
+	ChangedByName         string     `json:"changed_by_name,omitempty"`
+	ChangedAt             time.Time  `json:"changed_at"`
+}
+
+// RateHistoryResponse historial de cambios de una tarifa
+type RateHistoryResponse struct {
+	RateID  int64        `json:"rate_id"`
+	History []RateChange `json:"history"`
+	Total   int          `json:"total"`
+}
+
+// RateImportFormat formato del archivo de importación/exportación de tarifas
+type RateImportFormat string
+
+const (
+	RateFormatCSV  RateImportFormat = "CSV"
+	RateFormatXLSX RateImportFormat = "XLSX"
+)
+
+// RateImportRowStatus resultado de una fila del archivo de tarifas
+type RateImportRowStatus string
+
+const (
+	RateRowNew       RateImportRowStatus = "NEW"       // la ruta no tiene tarifa vigente
+	RateRowChanged   RateImportRowStatus = "CHANGED"   // cambia algún valor de la tarifa vigente
+	RateRowUnchanged RateImportRowStatus = "UNCHANGED" // igual a la vigente, no se importa
+	RateRowUnmatched RateImportRowStatus = "UNMATCHED" // ciudad no encontrada en cities
+	RateRowAmbiguous RateImportRowStatus = "AMBIGUOUS" // el nombre coincide con varias ciudades
+	RateRowInvalid   RateImportRowStatus = "INVALID"   // valores inválidos o ruta repetida
+)
+
+// RateImportRequest archivo de tarifas a importar (POST /admin/rates/import).
+// Content es el archivo en base64; Format se deduce de FileName si no se envía.
+// OriginCityID es el origen por defecto cuando el archivo no trae columna de origen.
+type RateImportRequest struct {
+	FileName      string           `json:"file_name"`
+	Format        RateImportFormat `json:"format,omitempty"`
+	Content       string           `json:"content"`
+	EffectiveDate string           `json:"effective_date"`
+	OriginCityID  int64            `json:"origin_city_id,omitempty"`
+	DryRun        bool             `json:"dry_run"`
+}
+
+// RateImportRow fila del archivo con las ciudades resueltas y la diferencia
+// contra la tarifa que estaría vigente en la effective_date
+type RateImportRow struct {
+	Line                 int                 `json:"line"`
+	Status               RateImportRowStatus `json:"status"`
+	Origin               string              `json:"origin"`
+	Destination          string              `json:"destination"`
+	OriginCityID         int64               `json:"origin_city_id,omitempty"`
+	DestinationCityID    int64               `json:"destination_city_id,omitempty"`
+	Route                string              `json:"route"`
+	TravelFrequency      string              `json:"travel_frequency"`
+	MinDispatchKg        int                 `json:"min_dispatch_kg"`
+	PricePerKg           float64             `json:"price_per_kg"`
+	MinValue             float64             `json:"min_value"`
+	CurrentRateID        int64               `json:"current_rate_id,omitempty"`
+	CurrentPricePerKg    *float64            `json:"current_price_per_kg,omitempty"`
+	CurrentMinValue      *float64            `json:"current_min_value,omitempty"`
+	CurrentMinDispatchKg *int                `json:"current_min_dispatch_kg,omitempty"`
+	CurrentRoute         string              `json:"current_route,omitempty"`
+	CurrentFrequency     string              `json:"current_travel_frequency,omitempty"`
+	Candidates           []City              `json:"candidates,omitempty"`
+	Error                string              `json:"error,omitempty"`
+}
+
+// RateImportSummary conteo de filas por resultado
+type RateImportSummary struct {
+	TotalRows int `json:"total_rows"`
+	New       int `json:"new"`
+	Changed   int `json:"changed"`
+	Unchanged int `json:"unchanged"`
+	Unmatched int `json:"unmatched"`
+	Ambiguous int `json:"ambiguous"`
+	Invalid   int `json:"invalid"`
+}
+
+// RateImportResponse resultado de la importación (o de la vista previa)
+type RateImportResponse struct {
+	Success       bool              `json:"success"`
+	DryRun        bool              `json:"dry_run"`
+	Applied       bool              `json:"applied"`
+	EffectiveDate string            `json:"effective_date"`
+	Summary       RateImportSummary `json:"summary"`
+	Rows          []RateImportRow   `json:"rows"`
+	Message       string            `json:"message"`
+}
+
+// RateExportResponse archivo con la tarifa vigente (GET /admin/rates/export)
+type RateExportResponse struct {
+	FileName    string           `json:"file_name"`
+	Format      RateImportFormat `json:"format"`
+	ContentType string           `json:"content_type"`
+	Content     string           `json:"content"` // base64
+	Date        string           `json:"date"`
+	Total       int              `json:"total"`
+}
+
+// InForce indica si la tarifa está activa y ya en vigor en la fecha dada
+func (r ShippingRate) InForce(date time.Time) bool {
+	return r.Status == RateActive && !r.EffectiveDate.After(date)
+}
+
+// PriceBreakdown detalle del cálculo del precio de una guía
+type PriceBreakdown struct {
+	RateID             int64       `json:"rate_id"`
+	Route              string      `json:"route"`
+	TravelFrequency    string      `json:"travel_frequency"`
+	EffectiveDate      string      `json:"effective_date"`
+	ServiceType        ServiceType `json:"service_type"`
+	ActualWeightKg     float64     `json:"actual_weight_kg"`
+	VolumetricWeightKg float64     `json:"volumetric_weight_kg"`
+	MinDispatchKg      int         `json:"min_dispatch_kg"`
+	BillableWeightKg   float64     `json:"billable_weight_kg"`
+	PricePerKg         float64     `json:"price_per_kg"`
+	MinValue           float64     `json:"min_value"`
+	Freight            float64     `json:"freight"`
+	ServiceMultiplier  float64     `json:"service_multiplier"`
+	Insurance          float64     `json:"insurance"`
+	Total              float64     `json:"total"`
+}
+
+// QuoteRequest datos para cotizar un envío (POST /quotes).
+// Date es opcional (YYYY-MM-DD); por defecto se usa la tarifa vigente hoy.
+// Si Insured no se envía, se asegura cuando DeclaredValue es mayor a 0
+// (la misma regla que aplica la creación de guías).
+type QuoteRequest struct {
+	OriginCityID      int64       `json:"origin_city_id"`
+	DestinationCityID int64       `json:"destination_city_id"`
+	WeightKg          float64     `json:"weight_kg"`
+	LengthCM          float64     `json:"length_cm"`
+	WidthCM           float64     `json:"width_cm"`
+	HeightCM          float64     `json:"height_cm"`
+	Pieces            int         `json:"pieces"`
+	DeclaredValue     float64     `json:"declared_value"`
+	Insured           *bool       `json:"insured,omitempty"`
+	ServiceType       ServiceType `json:"service_type"`
+	Date              string      `json:"date,omitempty"`
+}
+
+// QuoteResponse respuesta de la cotización
+type QuoteResponse struct {
+	Success         bool           `json:"success"`
+	OriginCity      string         `json:"origin_city"`
+	DestinationCity string         `json:"destination_city"`
+	Date            string         `json:"date"`
+	Quote           PriceBreakdown `json:"quote"`
+}
+
+// CreateGuideResponse respuesta de creación de guía
+type CreateGuideResponse struct {
+	Success     bool           `json:"success"`
+	GuideID     int64          `json:"guide_id"`
+	GuideNumber string         `json:"guide_number"`
+	Guide       ShippingGuide  `json:"guide"`
+	Pricing     PriceBreakdown `json:"pricing"`
+	PDFURL      string         `json:"pdf_url,omitempty"`
+	Message     string         `json:"message"`
+}
@@ -37,6 +37,8 @@ type DeliveryPerformanceStats struct {
 	DeliveriesThisWeek    int                `json:"deliveries_this_week"`
 	DeliveriesLastWeek    int                `json:"deliveries_last_week"`
 	DeliveriesChangePercent float64          `json:"deliveries_change_percent"`
+	TransfersThisWeek     int                `json:"transfers_this_week"` // traslados entre bodegas, aparte de las entregas
+	TransfersLastWeek     int                `json:"transfers_last_week"`
 	SuccessRate           float64            `json:"success_rate"`
 	AvgTimeMinutes        int                `json:"avg_time_minutes"`
 	AvgTimeLastWeek       int                `json:"avg_time_last_week"`
@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+// RNDCProcess documento reportado al RNDC
+type RNDCProcess string
+
+const (
+	RNDCRemesa     RNDCProcess = "REMESA"     // una por guía
+	RNDCManifiesto RNDCProcess = "MANIFIESTO" // uno por manifiesto de despacho
+)
+
+// RNDCSubmission envío de un documento al RNDC, exitoso o no. Si el RNDC lo
+// acepta, IngresoID es el consecutivo que asignó y queda en la guía (remesa)
+// o en el manifiesto y sus guías (manifiesto).
+type RNDCSubmission struct {
+	SubmissionID int64       `json:"submission_id"`
+	Process      RNDCProcess `json:"process"`
+	GuideID      *int64      `json:"guide_id,omitempty"`
+	ManifestID   *int64      `json:"manifest_id,omitempty"`
+	Consecutive  string      `json:"consecutive"` // CONSECUTIVOREMESA o NUMMANIFIESTOCARGA enviado
+	Success      bool        `json:"success"`
+	IngresoID    string      `json:"ingreso_id,omitempty"`
+	Error        string      `json:"error,omitempty"`
+	RequestXML   string      `json:"request_xml,omitempty"` // sin la contraseña
+	ResponseXML  string      `json:"response_xml,omitempty"`
+	SubmittedBy  string      `json:"submitted_by"`
+	SubmittedAt  time.Time   `json:"submitted_at"`
+}
+
+// RNDCFilters filtros del historial de envíos
+type RNDCFilters struct {
+	GuideID    *int64
+	ManifestID *int64
+}
+
+// RNDCPreviewResponse XML que se enviaría al RNDC, con su validación
+// (GET /guides/{id}/rndc y GET /manifests/{id}/rndc)
+type RNDCPreviewResponse struct {
+	Process     RNDCProcess      `json:"process"`
+	Consecutive string           `json:"consecutive"`
+	XML         string           `json:"xml,omitempty"`
+	Valid       bool             `json:"valid"`
+	Problems    []string         `json:"problems"`
+	IngresoID   string           `json:"ingreso_id,omitempty"` // ya reportado
+	Remesas     []RNDCRemesaInfo `json:"remesas,omitempty"`    // manifiesto
+	Submissions []RNDCSubmission `json:"submissions"`
+}
+
+// RNDCRemesaInfo remesa de una guía del manifiesto
+type RNDCRemesaInfo struct {
+	GuideID     int64    `json:"guide_id"`
+	GuideNumber string   `json:"guide_number"`
+	Consecutive string   `json:"consecutive"`
+	IngresoID   string   `json:"ingreso_id,omitempty"`
+	Valid       bool     `json:"valid"`
+	Problems    []string `json:"problems,omitempty"`
+}
+
+// RNDCSubmitResponse resultado del reporte: las remesas enviadas (las que
+// faltaban) y el manifiesto
+type RNDCSubmitResponse struct {
+	Success    bool             `json:"success"`
+	Remesas    []RNDCSubmission `json:"remesas"`
+	Manifiesto *RNDCSubmission  `json:"manifiesto,omitempty"`
+	Message    string           `json:"message"`
+}
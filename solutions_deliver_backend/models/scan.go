@@ -0,0 +1,111 @@
+package models
+
+import "time"
+
+// ScanPoint punto de la operación donde se escanea el código de la guía
+type ScanPoint string
+
+const (
+	ScanReception ScanPoint = "RECEPTION" // recepción del paquete (mostrador o recogida)
+	ScanWarehouse ScanPoint = "WAREHOUSE" // ingreso a bodega
+	ScanDispatch  ScanPoint = "DISPATCH"  // salida a reparto
+)
+
+// scanPointStatuses estado al que pasa la guía al escanearla en cada punto
+var scanPointStatuses = map[ScanPoint]GuideStatus{
+	ScanReception: StatusInRoute,
+	ScanWarehouse: StatusInWarehouse,
+	ScanDispatch:  StatusOutForDelivery,
+}
+
+// TargetStatus retorna el estado que aplica el punto de escaneo.
+// false si el punto no existe.
+func (p ScanPoint) TargetStatus() (GuideStatus, bool) {
+	status, ok := scanPointStatuses[p]
+	return status, ok
+}
+
+// Label nombre del punto para mostrar al usuario
+func (p ScanPoint) Label() string {
+	switch p {
+	case ScanReception:
+		return "Recepción"
+	case ScanWarehouse:
+		return "Bodega"
+	case ScanDispatch:
+		return "Despacho"
+	}
+	return string(p)
+}
+
+// ScanResult resultado de un código dentro de un lote de escaneo
+type ScanResult string
+
+const (
+	ScanApplied   ScanResult = "APPLIED"   // se aplicó la transición
+	ScanUnchanged ScanResult = "UNCHANGED" // la guía (o la pieza) ya estaba en el estado del punto
+	ScanDuplicate ScanResult = "DUPLICATE" // escaneo repetido dentro de la ventana
+	ScanRejected  ScanResult = "REJECTED"  // código inválido, guía no encontrada o transición no permitida
+)
+
+// GuideScan registro de un escaneo exitoso (APPLIED o UNCHANGED) de la
+// guía completa o de una de sus piezas
+type GuideScan struct {
+	ScanID         int64       `json:"scan_id"`
+	GuideID        int64       `json:"guide_id"`
+	PieceNumber    int         `json:"piece_number,omitempty"` // 0: la guía completa
+	ScanPoint      ScanPoint   `json:"scan_point"`
+	Code           string      `json:"code"`
+	Result         ScanResult  `json:"result"`
+	PreviousStatus GuideStatus `json:"previous_status"`
+	NewStatus      GuideStatus `json:"new_status"`
+	ScannedBy      string      `json:"scanned_by"`
+	ScannedAt      time.Time   `json:"scanned_at"`
+}
+
+// ScanBatchRequest lote de códigos escaneados (POST /scans)
+type ScanBatchRequest struct {
+	ScanPoint ScanPoint `json:"scan_point"`
+	Codes     []string  `json:"codes"`
+	Notes     string    `json:"notes,omitempty"`
+}
+
+// ScanItemResult resultado de cada código del lote
+type ScanItemResult struct {
+	Code           string      `json:"code"`
+	GuideID        int64       `json:"guide_id,omitempty"`
+	GuideNumber    string      `json:"guide_number,omitempty"`
+	PieceNumber    int         `json:"piece_number,omitempty"`
+	Success        bool        `json:"success"`
+	Result         ScanResult  `json:"result"`
+	PreviousStatus GuideStatus `json:"previous_status,omitempty"`
+	NewStatus      GuideStatus `json:"new_status,omitempty"`
+	Error          string      `json:"error,omitempty"`
+
+	// Partial indica que, tras el escaneo, las piezas de la guía quedaron
+	// en estados distintos
+	Partial bool `json:"partial,omitempty"`
+}
+
+// ScanBatchResponse respuesta de POST /scans
+type ScanBatchResponse struct {
+	ScanPoint  ScanPoint        `json:"scan_point"`
+	Total      int              `json:"total"`
+	Applied    int              `json:"applied"`
+	Unchanged  int              `json:"unchanged"`
+	Duplicates int              `json:"duplicates"`
+	Rejected   int              `json:"rejected"`
+	Items      []ScanItemResult `json:"items"`
+}
+
+// BarcodeResponse imagen del código de la guía (GET /guides/{id}/barcode)
+type BarcodeResponse struct {
+	GuideID     int64  `json:"guide_id"`
+	GuideNumber string `json:"guide_number"`
+	Value       string `json:"value"` // texto codificado
+	Type        string `json:"type"`  // code128 | qr
+	Format      string `json:"format"`
+	FileName    string `json:"file_name"`
+	ContentType string `json:"content_type"`
+	Content     string `json:"content"` // base64
+}
@@ -18,4 +18,5 @@ type User struct {
 	TypeDocument   string   `json:"typeDocument"`
 	NumberDocument string   `json:"numberDocument"`
 	Role           UserRole `json:"role"`
+	HubID          *int64   `json:"hubId,omitempty"`
 }
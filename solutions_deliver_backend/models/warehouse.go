@@ -0,0 +1,266 @@
+package models
+
+import "time"
+
+// Bodegas y ubicaciones (bins). Cada guía en bodega se ubica por pieza
+// (pieza 0: guías sin detalle de piezas) en un bin; warehouse_stock guarda
+// la ubicación actual y warehouse_movements el historial.
+
+// Warehouse bodega
+type Warehouse struct {
+	WarehouseID int64     `json:"warehouse_id"`
+	Code        string    `json:"code"`
+	Name        string    `json:"name"`
+	CityID      int64     `json:"city_id"`
+	CityName    string    `json:"city_name,omitempty"`
+	Address     string    `json:"address,omitempty"`
+	Active      bool      `json:"active"`
+	CreatedBy   string    `json:"created_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// WarehouseBin ubicación dentro de la bodega (estante, nivel, posición)
+type WarehouseBin struct {
+	BinID       int64     `json:"bin_id"`
+	WarehouseID int64     `json:"warehouse_id"`
+	Code        string    `json:"code"` // p.ej. A-01-03, único por bodega
+	Description string    `json:"description,omitempty"`
+	Active      bool      `json:"active"`
+	Items       int       `json:"items"` // guías o piezas ubicadas hoy
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// CreateWarehouseRequest datos para crear una bodega
+type CreateWarehouseRequest struct {
+	Code    string `json:"code"`
+	Name    string `json:"name"`
+	CityID  int64  `json:"city_id"`
+	Address string `json:"address,omitempty"`
+}
+
+// CreateBinRequest datos para crear un bin en una bodega
+type CreateBinRequest struct {
+	Code        string `json:"code"`
+	Description string `json:"description,omitempty"`
+}
+
+// StockItem guía (o pieza) ubicada en un bin. BinID 0: en bodega sin ubicar.
+type StockItem struct {
+	GuideID       int64       `json:"guide_id"`
+	GuideNumber   string      `json:"guide_number,omitempty"`
+	PieceNumber   int         `json:"piece_number,omitempty"`
+	PieceCode     string      `json:"piece_code,omitempty"`
+	CurrentStatus GuideStatus `json:"current_status"`
+	WarehouseID   int64       `json:"warehouse_id,omitempty"`
+	WarehouseCode string      `json:"warehouse_code,omitempty"`
+	BinID         int64       `json:"bin_id,omitempty"`
+	BinCode       string      `json:"bin_code,omitempty"`
+	LocatedBy     string      `json:"located_by,omitempty"`
+	LocatedAt     *time.Time  `json:"located_at,omitempty"`
+}
+
+// StockFilters filtros de la consulta de ubicaciones
+type StockFilters struct {
+	WarehouseID *int64
+	BinID       *int64
+	GuideID     *int64
+}
+
+// MovementType tipo de movimiento de bodega
+type MovementType string
+
+const (
+	MovementCheckIn     MovementType = "CHECK_IN"     // ingreso al bin
+	MovementMove        MovementType = "MOVE"         // cambio de bin
+	MovementCheckOut    MovementType = "CHECK_OUT"    // salida de la bodega
+	MovementCountAdjust MovementType = "COUNT_ADJUST" // reubicación al cerrar un conteo
+)
+
+// WarehouseMovement movimiento de una guía (o pieza) entre bins
+type WarehouseMovement struct {
+	MovementID   int64        `json:"movement_id"`
+	GuideID      int64        `json:"guide_id"`
+	PieceNumber  int          `json:"piece_number,omitempty"`
+	MovementType MovementType `json:"movement_type"`
+	FromBinID    *int64       `json:"from_bin_id,omitempty"`
+	FromBinCode  string       `json:"from_bin_code,omitempty"`
+	ToBinID      *int64       `json:"to_bin_id,omitempty"`
+	ToBinCode    string       `json:"to_bin_code,omitempty"`
+	Notes        string       `json:"notes,omitempty"`
+	MovedBy      string       `json:"moved_by"`
+	MovedAt      time.Time    `json:"moved_at"`
+}
+
+// WarehouseMoveRequest códigos (de guía o de pieza) para ubicar en un bin
+// (POST /warehouses/check-in y /warehouses/move)
+type WarehouseMoveRequest struct {
+	BinID int64    `json:"bin_id"`
+	Codes []string `json:"codes"`
+	Notes string   `json:"notes,omitempty"`
+}
+
+// WarehouseCheckOutRequest códigos que salen de la bodega (POST /warehouses/check-out)
+type WarehouseCheckOutRequest struct {
+	Codes []string `json:"codes"`
+	Notes string   `json:"notes,omitempty"`
+}
+
+// WarehouseItemResult resultado de cada código de un lote de bodega
+type WarehouseItemResult struct {
+	Code        string   `json:"code"`
+	GuideID     int64    `json:"guide_id,omitempty"`
+	GuideNumber string   `json:"guide_number,omitempty"`
+	Pieces      []int    `json:"pieces,omitempty"` // piezas afectadas (vacío: la guía sin piezas)
+	Success     bool     `json:"success"`
+	FromBins    []string `json:"from_bins,omitempty"`
+	BinCode     string   `json:"bin_code,omitempty"`
+	Error       string   `json:"error,omitempty"`
+}
+
+// WarehouseBatchResponse respuesta de check-in, move y check-out
+type WarehouseBatchResponse struct {
+	Total     int                   `json:"total"`
+	Succeeded int                   `json:"succeeded"`
+	Failed    int                   `json:"failed"`
+	Items     []WarehouseItemResult `json:"items"`
+}
+
+// GuideLocationResponse ubicación actual e historial de movimientos de una guía
+type GuideLocationResponse struct {
+	GuideID       int64               `json:"guide_id"`
+	GuideNumber   string              `json:"guide_number,omitempty"`
+	CurrentStatus GuideStatus         `json:"current_status"`
+	Locations     []StockItem         `json:"locations"`
+	Movements     []WarehouseMovement `json:"movements"`
+}
+
+// AgingFilters filtros del reporte de antigüedad en bodega
+type AgingFilters struct {
+	WarehouseID *int64
+	MinDays     int
+}
+
+// AgingItem guía en bodega con su antigüedad
+type AgingItem struct {
+	GuideID             int64     `json:"guide_id"`
+	GuideNumber         string    `json:"guide_number,omitempty"`
+	ServiceType         string    `json:"service_type"`
+	DestinationCityName string    `json:"destination_city_name"`
+	ReceiverName        string    `json:"receiver_name,omitempty"`
+	InWarehouseSince    time.Time `json:"in_warehouse_since"`
+	DaysInWarehouse     int       `json:"days_in_warehouse"`
+	Pieces              int       `json:"pieces"`
+	LocatedPieces       int       `json:"located_pieces"`
+	Bins                []string  `json:"bins,omitempty"`
+}
+
+// AgingBucket guías por rango de días en bodega
+type AgingBucket struct {
+	Label   string `json:"label"`
+	MinDays int    `json:"min_days"`
+	MaxDays *int   `json:"max_days,omitempty"` // nil: sin límite
+	Count   int    `json:"count"`
+}
+
+// AgingReport reporte de antigüedad (GET /warehouses/aging)
+type AgingReport struct {
+	GeneratedAt time.Time     `json:"generated_at"`
+	MinDays     int           `json:"min_days"`
+	Total       int           `json:"total"`
+	Buckets     []AgingBucket `json:"buckets"`
+	Items       []AgingItem   `json:"items"`
+}
+
+// InventoryCountStatus estado de un conteo físico
+type InventoryCountStatus string
+
+const (
+	InventoryCountOpen   InventoryCountStatus = "OPEN"
+	InventoryCountClosed InventoryCountStatus = "CLOSED"
+)
+
+// InventoryCount conteo físico de una bodega. Mientras está abierto se
+// escanean los bins; al cerrarlo se concilia contra lo esperado.
+type InventoryCount struct {
+	CountID     int64                `json:"count_id"`
+	WarehouseID int64                `json:"warehouse_id"`
+	Status      InventoryCountStatus `json:"status"`
+	Notes       string               `json:"notes,omitempty"`
+	Applied     bool                 `json:"applied"` // se reubicaron los hallazgos al cerrar
+	StartedBy   string               `json:"started_by"`
+	StartedAt   time.Time            `json:"started_at"`
+	ClosedBy    string               `json:"closed_by,omitempty"`
+	ClosedAt    *time.Time           `json:"closed_at,omitempty"`
+}
+
+// InventoryCountItem guía o pieza escaneada en un bin durante el conteo
+type InventoryCountItem struct {
+	ItemID      int64     `json:"item_id"`
+	CountID     int64     `json:"count_id"`
+	GuideID     int64     `json:"guide_id"`
+	PieceNumber int       `json:"piece_number,omitempty"`
+	BinID       int64     `json:"bin_id"`
+	BinCode     string    `json:"bin_code,omitempty"`
+	Code        string    `json:"code"`
+	ScannedBy   string    `json:"scanned_by"`
+	ScannedAt   time.Time `json:"scanned_at"`
+}
+
+// InventoryCountRequest datos para iniciar un conteo
+type InventoryCountRequest struct {
+	Notes string `json:"notes,omitempty"`
+}
+
+// InventoryScanRequest códigos escaneados en un bin durante el conteo
+type InventoryScanRequest struct {
+	BinID int64    `json:"bin_id"`
+	Codes []string `json:"codes"`
+}
+
+// CloseInventoryCountRequest cierre del conteo. Con Apply se reubican las
+// piezas encontradas en otro bin o sin ubicar en el bin donde se escanearon.
+type CloseInventoryCountRequest struct {
+	Apply bool `json:"apply"`
+}
+
+// InventoryResult resultado de la conciliación de cada guía o pieza
+type InventoryResult string
+
+const (
+	InventoryMatched    InventoryResult = "MATCHED"    // en el bin esperado
+	InventoryMisplaced  InventoryResult = "MISPLACED"  // encontrada en otro bin
+	InventoryUnlocated  InventoryResult = "UNLOCATED"  // en bodega sin ubicar; encontrada en un bin
+	InventoryMissing    InventoryResult = "MISSING"    // esperada y no escaneada
+	InventoryUnexpected InventoryResult = "UNEXPECTED" // escaneada pero no debería estar en la bodega
+)
+
+// InventoryLine guía o pieza conciliada
+type InventoryLine struct {
+	GuideID         int64           `json:"guide_id"`
+	GuideNumber     string          `json:"guide_number,omitempty"`
+	PieceNumber     int             `json:"piece_number,omitempty"`
+	CurrentStatus   GuideStatus     `json:"current_status,omitempty"`
+	Result          InventoryResult `json:"result"`
+	ExpectedBinCode string          `json:"expected_bin_code,omitempty"`
+	ScannedBinID    int64           `json:"scanned_bin_id,omitempty"`
+	ScannedBinCode  string          `json:"scanned_bin_code,omitempty"`
+}
+
+// InventoryReconciliation escaneado contra esperado (guías IN_WAREHOUSE
+// ubicadas en la bodega o sin ubicar)
+type InventoryReconciliation struct {
+	Expected   int             `json:"expected"`
+	Scanned    int             `json:"scanned"`
+	Matched    int             `json:"matched"`
+	Misplaced  int             `json:"misplaced"`
+	Unlocated  int             `json:"unlocated"`
+	Missing    int             `json:"missing"`
+	Unexpected int             `json:"unexpected"`
+	Lines      []InventoryLine `json:"lines"`
+}
+
+// InventoryCountResponse conteo con su conciliación
+type InventoryCountResponse struct {
+	Count          InventoryCount          `json:"count"`
+	Reconciliation InventoryReconciliation `json:"reconciliation"`
+}
@@ -0,0 +1,55 @@
+// Package notify envía mensajes a clientes y destinatarios (SMS / correo).
+// El proveedor es intercambiable: por defecto solo se escribe en el log,
+// útil en local y en pruebas.
+package notify
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Message mensaje a enviar; Phone y Email pueden venir vacíos
+type Message struct {
+	Phone   string
+	Email   string
+	Subject string
+	Body    string
+}
+
+// Notifier proveedor de envío de mensajes
+type Notifier interface {
+	Send(msg Message) error
+}
+
+// LogNotifier escribe el mensaje en el log en lugar de enviarlo
+type LogNotifier struct{}
+
+// Send implementa Notifier
+func (LogNotifier) Send(msg Message) error {
+	fmt.Printf("LogNotifier -> Tel: %s, Email: %s, Asunto: %s\n%s\n", msg.Phone, msg.Email, msg.Subject, msg.Body)
+	return nil
+}
+
+var (
+	mu       sync.Mutex
+	notifier Notifier = LogNotifier{}
+)
+
+// SetNotifier reemplaza el proveedor de envío
+func SetNotifier(n Notifier) {
+	mu.Lock()
+	defer mu.Unlock()
+	notifier = n
+}
+
+// Send envía el mensaje con el proveedor configurado
+func Send(msg Message) error {
+	mu.Lock()
+	n := notifier
+	mu.Unlock()
+
+	if msg.Phone == "" && msg.Email == "" {
+		return fmt.Errorf("mensaje sin teléfono ni correo")
+	}
+	return n.Send(msg)
+}
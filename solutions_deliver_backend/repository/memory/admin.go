@@ -0,0 +1,237 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+)
+
+// ==========================================
+// AdminRepository
+// ==========================================
+
+func (s *Store) GetAdminDashboardStats(scope models.HubScope) (models.AdminDashboardStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := models.AdminDashboardStats{
+		StatusDistribution: []models.StatusCount{},
+		WorkerPerformance:  []models.WorkerStats{},
+		RealtimeDeliveries: []models.RealtimeDelivery{},
+		ActiveRoutes:       []models.ActiveRoute{},
+		Alerts:             []models.SystemAlert{},
+		HubID:              scope.HubID,
+	}
+
+	counts := map[models.GuideStatus]int{}
+	total := 0
+	for _, g := range s.guides {
+		if !s.guideInScope(scope, g.OriginCityID, g.DestinationCityID) {
+			continue
+		}
+		total++
+		counts[g.CurrentStatus]++
+		switch g.CurrentStatus {
+		case models.StatusDelivered:
+			stats.Delivered++
+		case models.StatusInRoute, models.StatusOutForDelivery:
+			stats.Pending++
+			stats.PendingInRoute++
+		case models.StatusCreated, models.StatusInWarehouse:
+			stats.Pending++
+			stats.PendingInOffice++
+		case models.StatusDeliveryFailed:
+			stats.Pending++
+			stats.DeliveryFailed++
+		case models.StatusOnHold:
+			stats.Pending++
+			stats.OnHold++
+		case models.StatusReturnedToSender:
+			stats.ReturnedToSender++
+		case models.StatusCancelled:
+			stats.Cancelled++
+		}
+	}
+
+	if total > 0 {
+		stats.DeliveryRate = float64(stats.Delivered) * 100 / float64(total)
+	}
+	for _, status := range []models.GuideStatus{
+		models.StatusCreated, models.StatusInRoute, models.StatusInWarehouse,
+		models.StatusOutForDelivery, models.StatusDelivered, models.StatusDeliveryFailed,
+		models.StatusReturnedToSender, models.StatusCancelled, models.StatusOnHold,
+	} {
+		sc := models.StatusCount{Status: string(status), Count: counts[status]}
+		if total > 0 {
+			sc.Percentage = float64(counts[status]) * 100 / float64(total)
+		}
+		stats.StatusDistribution = append(stats.StatusDistribution, sc)
+	}
+
+	if scope.Consolidated() {
+		stats.ByHub = s.hubSummaries()
+	}
+
+	return stats, nil
+}
+
+// hubSummaries resumen por hub activo para el dashboard consolidado
+// (requiere el mutex tomado)
+func (s *Store) hubSummaries() []models.HubSummary {
+	summaries := []models.HubSummary{}
+	today := time.Now().Format("2006-01-02")
+	for _, h := range s.scopeHubs(models.HubScope{}) {
+		hs := models.HubSummary{HubID: h.HubID, Code: h.Code, Name: h.Name}
+		for _, g := range s.guides {
+			if s.hubCovers(h, g.OriginCityID) && g.CreatedAt.Format("2006-01-02") == today {
+				hs.ShipmentsToday++
+				hs.RevenueToday += g.Price
+			}
+			if (s.hubCovers(h, g.OriginCityID) || s.hubCovers(h, g.DestinationCityID)) &&
+				g.CurrentStatus != models.StatusDelivered && g.CurrentStatus != models.StatusReturnedToSender &&
+				g.CurrentStatus != models.StatusCancelled {
+				hs.Pending++
+			}
+		}
+		for _, u := range s.users {
+			if u.Role == models.RoleDelivery && u.HubID != nil && *u.HubID == h.HubID {
+				hs.Couriers++
+			}
+		}
+		summaries = append(summaries, hs)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Code < summaries[j].Code })
+	return summaries
+}
+
+func (s *Store) GetEmployees(role string, scope models.HubScope) ([]models.Employee, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	employees := []models.Employee{}
+	for _, u := range s.users {
+		if u.Role == models.RoleClient {
+			continue
+		}
+		if role != "" && string(u.Role) != role {
+			continue
+		}
+		if !scope.Includes(u.HubID) {
+			continue
+		}
+		employees = append(employees, s.employeeFromUser(u))
+	}
+	sort.Slice(employees, func(i, j int) bool { return employees[i].FullName < employees[j].FullName })
+	return employees, nil
+}
+
+func (s *Store) GetEmployeeByID(userUUID string) (models.Employee, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[userUUID]
+	if !ok {
+		return models.Employee{}, fmt.Errorf("empleado no encontrado")
+	}
+	return s.employeeFromUser(u), nil
+}
+
+func (s *Store) GetUserByDocument(documentNumber string) (models.Employee, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, u := range s.users {
+		if u.NumberDocument == documentNumber {
+			return s.employeeFromUser(u), nil
+		}
+	}
+	return models.Employee{}, fmt.Errorf("usuario no encontrado")
+}
+
+func (s *Store) UpdateEmployee(userUUID string, req models.UpdateEmployeeRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[userUUID]
+	if !ok {
+		return fmt.Errorf("empleado no encontrado")
+	}
+	if req.FullName != "" {
+		u.FullName = req.FullName
+	}
+	if req.Phone != "" {
+		u.Phone = req.Phone
+	}
+	if req.Role != "" {
+		u.Role = req.Role
+	}
+	if req.HubID != nil {
+		u.HubID = nil
+		if *req.HubID != 0 {
+			hubID := *req.HubID
+			u.HubID = &hubID
+		}
+	}
+	s.users[userUUID] = u
+	return nil
+}
+
+func (s *Store) GetClientRanking(filters models.ClientRankingFilters) (models.ClientRankingResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	clients := []models.ClientRanking{}
+	for _, u := range s.users {
+		if u.Role != models.RoleClient {
+			continue
+		}
+		entry := models.ClientRanking{UserUUID: u.UserUUID, FullName: u.FullName, Email: u.UserEmail, Phone: u.Phone}
+		for _, g := range s.guides {
+			if !s.ownsGuide(g, u.UserUUID) || !s.guideInScope(models.HubScope{HubID: filters.HubID}, g.OriginCityID) {
+				continue
+			}
+			entry.TotalGuides++
+			entry.TotalSpent += g.Price
+		}
+		if entry.TotalGuides == 0 || entry.TotalGuides < filters.MinGuides {
+			continue
+		}
+		entry.AvgValue = entry.TotalSpent / float64(entry.TotalGuides)
+		clients = append(clients, entry)
+	}
+
+	sort.Slice(clients, func(i, j int) bool {
+		less := clients[i].TotalGuides < clients[j].TotalGuides
+		if filters.SortBy == "total_spent" {
+			less = clients[i].TotalSpent < clients[j].TotalSpent
+		}
+		if filters.Order == "asc" {
+			return less
+		}
+		return !less
+	})
+
+	clients = paginate(clients, filters.Limit, 0)
+	return models.ClientRankingResponse{Clients: clients, Total: len(clients)}, nil
+}
+
+// employeeFromUser requiere el mutex tomado (código del hub)
+func (s *Store) employeeFromUser(u models.User) models.Employee {
+	employee := models.Employee{
+		UserUUID:       u.UserUUID,
+		FullName:       u.FullName,
+		Email:          u.UserEmail,
+		Phone:          u.Phone,
+		Role:           u.Role,
+		Status:         "Activo",
+		TypeDocument:   u.TypeDocument,
+		NumberDocument: u.NumberDocument,
+		HubID:          u.HubID,
+	}
+	if u.HubID != nil {
+		employee.HubCode = s.hubs[*u.HubID].Code
+	}
+	return employee
+}
@@ -0,0 +1,912 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+)
+
+// ==========================================
+// AssignmentRepository
+// ==========================================
+
+func (s *Store) CreateAssignment(req models.CreateAssignmentRequest, assignedBy string) (models.DeliveryAssignment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, a := range s.assignments {
+		if a.GuideID == req.GuideID && a.AssignmentType == req.AssignmentType &&
+			a.Status != models.AssignmentCancelled && a.Status != models.AssignmentCompleted {
+			return models.DeliveryAssignment{}, fmt.Errorf("ya existe una asignación activa de tipo %s para esta guía", req.AssignmentType)
+		}
+	}
+
+	now := time.Now()
+	assignment := models.DeliveryAssignment{
+		AssignmentID:   s.newID(),
+		GuideID:        req.GuideID,
+		DeliveryUserID: req.DeliveryUserID,
+		AssignmentType: req.AssignmentType,
+		Status:         models.AssignmentPending,
+		Notes:          req.Notes,
+		AssignedBy:     assignedBy,
+		AssignedAt:     now,
+		UpdatedAt:      now,
+		AttemptNumber:  1,
+
+		VehiclePlate:             req.VehiclePlate,
+		OriginWarehouseID:        req.OriginWarehouseID,
+		OriginWarehouseName:      s.warehouses[req.OriginWarehouseID].Name,
+		DestinationWarehouseID:   req.DestinationWarehouseID,
+		DestinationWarehouseName: s.warehouses[req.DestinationWarehouseID].Name,
+	}
+	s.assignments[assignment.AssignmentID] = assignment
+	s.logAssignment(models.AssignmentHistory{
+		AssignmentID:      assignment.AssignmentID,
+		Action:            models.ActionCreated,
+		NewDeliveryUserID: req.DeliveryUserID,
+		NewStatus:         string(models.AssignmentPending),
+		ChangedBy:         assignedBy,
+		Notes:             req.Notes,
+	})
+
+	return assignment, nil
+}
+
+func (s *Store) GetAssignmentByID(assignmentID int64) (models.DeliveryAssignment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	assignment, ok := s.assignments[assignmentID]
+	if !ok {
+		return assignment, fmt.Errorf("asignación no encontrada")
+	}
+	assignment.HubID = s.users[assignment.DeliveryUserID].HubID
+	return assignment, nil
+}
+
+func (s *Store) GetAssignmentsByFilters(filters models.AssignmentFilters) ([]models.DeliveryAssignment, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := s.filterAssignments(filters)
+	return paginate(result, filters.Limit, filters.Offset), len(result), nil
+}
+
+func (s *Store) ReassignDelivery(assignmentID int64, newDeliveryUserID string, notes string, changedBy string) (models.DeliveryAssignment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	assignment, ok := s.assignments[assignmentID]
+	if !ok {
+		return assignment, fmt.Errorf("asignación no encontrada")
+	}
+	if assignment.Status != models.AssignmentPending && assignment.Status != models.AssignmentInProgress {
+		return assignment, fmt.Errorf("solo se pueden reasignar asignaciones pendientes o en progreso")
+	}
+
+	previous := assignment.DeliveryUserID
+	assignment.DeliveryUserID = newDeliveryUserID
+	assignment.UpdatedAt = time.Now()
+	s.assignments[assignmentID] = assignment
+	s.logAssignment(models.AssignmentHistory{
+		AssignmentID:           assignmentID,
+		Action:                 models.ActionReassigned,
+		PreviousDeliveryUserID: previous,
+		NewDeliveryUserID:      newDeliveryUserID,
+		ChangedBy:              changedBy,
+		Notes:                  notes,
+	})
+
+	return assignment, nil
+}
+
+func (s *Store) UpdateAssignmentStatus(assignmentID int64, newStatus models.AssignmentStatus, notes string, changedBy string, guideChange *models.GuideStatusChange) (models.DeliveryAssignment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	assignment, ok := s.assignments[assignmentID]
+	if !ok {
+		return assignment, fmt.Errorf("asignación no encontrada")
+	}
+	if err := s.checkGuideChange(assignment.GuideID, guideChange); err != nil {
+		return assignment, err
+	}
+
+	previous := assignment.Status
+	now := time.Now()
+	assignment.Status = newStatus
+	assignment.UpdatedAt = now
+	if newStatus == models.AssignmentCompleted {
+		assignment.CompletedAt = &now
+	}
+	s.assignments[assignmentID] = assignment
+	s.logAssignment(models.AssignmentHistory{
+		AssignmentID:   assignmentID,
+		Action:         models.ActionStatusChange,
+		PreviousStatus: string(previous),
+		NewStatus:      string(newStatus),
+		ChangedBy:      changedBy,
+		Notes:          notes,
+	})
+
+	if guideChange != nil {
+		s.updateGuideStatus(assignment.GuideID, guideChange.Status, guideChange.Reason, "", changedBy)
+	}
+
+	return assignment, nil
+}
+
+// checkGuideChange valida el cambio de la guía antes de tocar la asignación,
+// para que ambos se apliquen o ninguno (requiere el mutex tomado)
+func (s *Store) checkGuideChange(guideID int64, guideChange *models.GuideStatusChange) error {
+	if guideChange == nil {
+		return nil
+	}
+	guide, ok := s.guides[guideID]
+	if !ok {
+		return fmt.Errorf("Guía no encontrada")
+	}
+	if _, ok := models.FindGuideTransition(guide.CurrentStatus, guideChange.Status); !ok {
+		return fmt.Errorf("transición de estado no permitida")
+	}
+	return nil
+}
+
+func (s *Store) GetAssignmentHistory(assignmentID int64) ([]models.AssignmentHistory, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]models.AssignmentHistory(nil), s.assignmentLog[assignmentID]...), nil
+}
+
+func (s *Store) GetAssignmentStats(scope models.HubScope) (models.AssignmentStatsResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := models.AssignmentStatsResponse{ByDeliveryUser: map[string]int{}}
+	today := time.Now().Format("2006-01-02")
+	for _, a := range s.assignments {
+		if !s.userInScope(scope, a.DeliveryUserID) {
+			continue
+		}
+		stats.TotalAssignments++
+		stats.ByDeliveryUser[a.DeliveryUserID]++
+		switch {
+		case a.Status == models.AssignmentPending && a.AssignmentType == models.AssignmentPickup:
+			stats.PendingPickups++
+		case a.Status == models.AssignmentPending && a.AssignmentType == models.AssignmentDelivery:
+			stats.PendingDeliveries++
+		case a.Status == models.AssignmentInProgress && a.AssignmentType == models.AssignmentPickup:
+			stats.InProgressPickups++
+		case a.Status == models.AssignmentInProgress && a.AssignmentType == models.AssignmentDelivery:
+			stats.InProgressDeliveries++
+		case a.Status == models.AssignmentPending && a.AssignmentType == models.AssignmentTransfer:
+			stats.PendingTransfers++
+		case a.Status == models.AssignmentInProgress && a.AssignmentType == models.AssignmentTransfer:
+			stats.InProgressTransfers++
+		case a.Status == models.AssignmentCompleted && a.CompletedAt != nil && a.CompletedAt.Format("2006-01-02") == today:
+			stats.CompletedToday++
+		}
+	}
+	return stats, nil
+}
+
+func (s *Store) GetMyAssignments(deliveryUserID string) (models.MyAssignmentsResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	response := models.MyAssignmentsResponse{
+		Pickups:    s.filterAssignments(models.AssignmentFilters{DeliveryUserID: deliveryUserID, AssignmentType: models.AssignmentPickup}),
+		Deliveries: s.filterAssignments(models.AssignmentFilters{DeliveryUserID: deliveryUserID, AssignmentType: models.AssignmentDelivery}),
+		Returns:    s.filterAssignments(models.AssignmentFilters{DeliveryUserID: deliveryUserID, AssignmentType: models.AssignmentReturn}),
+		Transfers:  s.filterAssignments(models.AssignmentFilters{DeliveryUserID: deliveryUserID, AssignmentType: models.AssignmentTransfer}),
+	}
+
+	now := time.Now()
+	weekAgo := now.AddDate(0, 0, -7)
+	all := append(append([]models.DeliveryAssignment{}, response.Pickups...), response.Deliveries...)
+	for _, a := range append(all, response.Transfers...) {
+		switch a.Status {
+		case models.AssignmentPending:
+			switch a.AssignmentType {
+			case models.AssignmentPickup:
+				response.Stats.PendingPickups++
+			case models.AssignmentTransfer:
+				response.Stats.PendingTransfers++
+			default:
+				response.Stats.PendingDeliveries++
+			}
+		case models.AssignmentInProgress:
+			switch a.AssignmentType {
+			case models.AssignmentPickup:
+				response.Stats.InProgressPickups++
+			case models.AssignmentTransfer:
+				response.Stats.InProgressTransfers++
+			default:
+				response.Stats.InProgressDeliveries++
+			}
+		case models.AssignmentCompleted:
+			if a.CompletedAt == nil {
+				continue
+			}
+			if a.CompletedAt.Format("2006-01-02") == now.Format("2006-01-02") {
+				response.Stats.CompletedToday++
+			}
+			if a.CompletedAt.After(weekAgo) {
+				response.Stats.CompletedThisWeek++
+			}
+		}
+	}
+
+	return response, nil
+}
+
+func (s *Store) GetDeliveryUsers(scope models.HubScope) ([]models.DeliveryUser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	users := []models.DeliveryUser{}
+	for _, u := range s.users {
+		if u.Role != models.RoleDelivery || !scope.Includes(u.HubID) {
+			continue
+		}
+		du := models.DeliveryUser{UserID: u.UserUUID, FullName: u.FullName, Email: u.UserEmail, Phone: u.Phone}
+		for _, a := range s.assignments {
+			if a.DeliveryUserID != u.UserUUID {
+				continue
+			}
+			switch {
+			case a.Status == models.AssignmentCompleted:
+				du.TotalCompleted++
+			case a.Status == models.AssignmentCancelled:
+			case a.AssignmentType == models.AssignmentPickup:
+				du.ActivePickups++
+			default:
+				du.ActiveDeliveries++
+			}
+		}
+		users = append(users, du)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].FullName < users[j].FullName })
+	return users, nil
+}
+
+func (s *Store) GetPendingPickups(scope models.HubScope) ([]models.PendingGuide, error) {
+	return s.pendingGuides(models.StatusCreated, models.AssignmentPickup, func(g models.ShippingGuide) bool {
+		return s.pickupInScope(scope, g)
+	}), nil
+}
+
+func (s *Store) GetPendingDeliveries(scope models.HubScope) ([]models.PendingGuide, error) {
+	pending := s.pendingGuides(models.StatusInWarehouse, models.AssignmentDelivery, func(g models.ShippingGuide) bool {
+		return s.deliveryInScope(scope, g)
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Reintentos: entregas fallidas sin devolución creada
+	for _, g := range s.sortedGuides() {
+		if g.CurrentStatus != models.StatusDeliveryFailed || s.hasReturnAssignment(g.GuideID) ||
+			!s.deliveryInScope(scope, g) {
+			continue
+		}
+		pg := models.PendingGuide{
+			GuideID:             g.GuideID,
+			ServiceType:         string(g.ServiceType),
+			CurrentStatus:       string(g.CurrentStatus),
+			OriginCityName:      g.OriginCityName,
+			DestinationCityName: g.DestinationCityName,
+			CreatedAt:           g.CreatedAt.Format("2006-01-02 15:04:05"),
+			AssignmentType:      models.AssignmentDelivery,
+			AttemptCount:        s.countAttempts(g.GuideID),
+			IsReattempt:         true,
+		}
+		if g.Receiver != nil {
+			pg.ContactName = g.Receiver.FullName
+			pg.ContactAddress = g.Receiver.Address
+			pg.ContactPhone = g.Receiver.Phone
+		}
+		for _, a := range s.assignments {
+			if a.GuideID == g.GuideID && a.AssignmentType == models.AssignmentDelivery && a.Status == models.AssignmentPending {
+				pg.ReattemptAssignmentID = a.AssignmentID
+				pg.ReattemptDeliveryUserID = a.DeliveryUserID
+				pg.ScheduledDate = a.ScheduledDate
+			}
+		}
+		pending = append(pending, pg)
+	}
+
+	for i := range pending {
+		pending[i].Bins = s.guideBins(pending[i].GuideID)
+	}
+	return pending, nil
+}
+
+func (s *Store) GetPendingTransfers(scope models.HubScope) ([]models.PendingGuide, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pending := []models.PendingGuide{}
+	for _, g := range s.sortedGuides() {
+		city := s.cities[g.DestinationCityID]
+		if g.CurrentStatus != models.StatusInWarehouse || !s.transferInScope(scope, g) ||
+			s.dispatched(g.GuideID) || s.hasActiveAssignment(g.GuideID, models.AssignmentTransfer) ||
+			s.transferredToDestination(g) {
+			continue
+		}
+
+		pg := models.PendingGuide{
+			GuideID:             g.GuideID,
+			ServiceType:         string(g.ServiceType),
+			CurrentStatus:       string(g.CurrentStatus),
+			OriginCityName:      g.OriginCityName,
+			DestinationCityName: city.Name,
+			CreatedAt:           g.CreatedAt.Format("2006-01-02 15:04:05"),
+			AssignmentType:      models.AssignmentTransfer,
+			Bins:                s.guideBins(g.GuideID),
+			WarehouseID:         s.guideWarehouse(g.GuideID),
+		}
+		if g.Receiver != nil {
+			pg.ContactName = g.Receiver.FullName
+			pg.ContactAddress = g.Receiver.Address
+			pg.ContactPhone = g.Receiver.Phone
+		}
+		pending = append(pending, pg)
+	}
+	return pending, nil
+}
+
+// pickupInScope indica si un hub activo del alcance cubre el origen de la
+// guía (requiere el mutex tomado)
+func (s *Store) pickupInScope(scope models.HubScope, g models.ShippingGuide) bool {
+	for _, h := range s.scopeHubs(scope) {
+		if s.hubCovers(h, g.OriginCityID) {
+			return true
+		}
+	}
+	return false
+}
+
+// deliveryInScope indica si un hub activo del alcance cubre el destino de
+// la guía y la guía ya está en él: entrega fallida, origen cubierto por el
+// mismo hub, traslado completado a una bodega del hub o recibida de un
+// manifiesto (requiere el mutex tomado)
+func (s *Store) deliveryInScope(scope models.HubScope, g models.ShippingGuide) bool {
+	for _, h := range s.scopeHubs(scope) {
+		if !s.hubCovers(h, g.DestinationCityID) {
+			continue
+		}
+		if g.CurrentStatus == models.StatusDeliveryFailed || s.hubCovers(h, g.OriginCityID) ||
+			s.transferredToHub(h, g.GuideID) || s.receivedFromManifest(g.GuideID) {
+			return true
+		}
+	}
+	return false
+}
+
+// transferInScope indica si un hub activo del alcance cubre la ciudad donde
+// está la guía (su bodega o, si no está ubicada, el origen) pero no su
+// destino (requiere el mutex tomado)
+func (s *Store) transferInScope(scope models.HubScope, g models.ShippingGuide) bool {
+	cityID := g.OriginCityID
+	if w, ok := s.warehouses[s.guideWarehouse(g.GuideID)]; ok {
+		cityID = w.CityID
+	}
+	for _, h := range s.scopeHubs(scope) {
+		if s.hubCovers(h, cityID) && !s.hubCovers(h, g.DestinationCityID) {
+			return true
+		}
+	}
+	return false
+}
+
+// transferredToHub indica si la guía llegó por traslado a una bodega de una
+// ciudad cubierta por el hub (requiere el mutex tomado)
+func (s *Store) transferredToHub(h models.Hub, guideID int64) bool {
+	for _, a := range s.assignments {
+		if a.GuideID == guideID && a.AssignmentType == models.AssignmentTransfer &&
+			a.Status == models.AssignmentCompleted &&
+			s.hubCovers(h, s.warehouses[a.DestinationWarehouseID].CityID) {
+			return true
+		}
+	}
+	return false
+}
+
+// receivedFromManifest indica si la guía se recibió de un manifiesto
+// (requiere el mutex tomado)
+func (s *Store) receivedFromManifest(guideID int64) bool {
+	for _, g := range s.manifestGuides {
+		if g.GuideID == guideID &&
+			(g.Status == models.ManifestGuideReceived || g.Status == models.ManifestGuidePartial) {
+			return true
+		}
+	}
+	return false
+}
+
+// transferredToDestination indica si la guía ya llegó por traslado a una
+// bodega de su ciudad de destino (requiere el mutex tomado)
+func (s *Store) transferredToDestination(g models.ShippingGuide) bool {
+	for _, a := range s.assignments {
+		if a.GuideID == g.GuideID && a.AssignmentType == models.AssignmentTransfer &&
+			a.Status == models.AssignmentCompleted &&
+			s.warehouses[a.DestinationWarehouseID].CityID == g.DestinationCityID {
+			return true
+		}
+	}
+	return false
+}
+
+// guideWarehouse bodega de los bins de la guía o, si no está ubicada, la de
+// destino de su último traslado (requiere el mutex tomado)
+func (s *Store) guideWarehouse(guideID int64) int64 {
+	for key, item := range s.stock {
+		if key.guideID == guideID {
+			return s.bins[item.BinID].WarehouseID
+		}
+	}
+	var last models.DeliveryAssignment
+	for _, a := range s.assignments {
+		if a.GuideID == guideID && a.AssignmentType == models.AssignmentTransfer &&
+			a.Status == models.AssignmentCompleted && a.CompletedAt != nil &&
+			(last.CompletedAt == nil || a.CompletedAt.After(*last.CompletedAt)) {
+			last = a
+		}
+	}
+	return last.DestinationWarehouseID
+}
+
+func (s *Store) RegisterDeliveryAttempt(assignmentID int64, req models.RegisterAttemptRequest, maxAttempts int, nextDate time.Time, userUUID string) (models.DeliveryAttemptResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result models.DeliveryAttemptResult
+
+	assignment, ok := s.assignments[assignmentID]
+	if !ok {
+		return result, fmt.Errorf("asignación no encontrada")
+	}
+	if assignment.AssignmentType != models.AssignmentDelivery {
+		return result, fmt.Errorf("solo las asignaciones de entrega admiten intentos")
+	}
+	if assignment.Status != models.AssignmentInProgress {
+		return result, fmt.Errorf("la entrega debe estar en progreso para registrar un intento")
+	}
+	guide, ok := s.guides[assignment.GuideID]
+	if !ok {
+		return result, fmt.Errorf("Guía no encontrada")
+	}
+	if _, ok := models.FindGuideTransition(guide.CurrentStatus, models.StatusDeliveryFailed); !ok {
+		return result, fmt.Errorf("transición de estado no permitida")
+	}
+
+	now := time.Now()
+	attempt := models.DeliveryAttempt{
+		AttemptID:     s.newID(),
+		AssignmentID:  assignmentID,
+		GuideID:       assignment.GuideID,
+		AttemptNumber: s.countAttempts(assignment.GuideID) + 1,
+		ReasonCode:    req.ReasonCode,
+		Notes:         req.Notes,
+		Latitude:      req.Latitude,
+		Longitude:     req.Longitude,
+		AttemptedBy:   userUUID,
+		AttemptedAt:   now,
+	}
+	s.attempts = append(s.attempts, attempt)
+
+	assignment.Status = models.AssignmentCancelled
+	assignment.UpdatedAt = now
+	s.assignments[assignmentID] = assignment
+	s.logAssignment(models.AssignmentHistory{
+		AssignmentID:   assignmentID,
+		Action:         models.ActionStatusChange,
+		PreviousStatus: string(models.AssignmentInProgress),
+		NewStatus:      string(models.AssignmentCancelled),
+		ChangedBy:      userUUID,
+		Notes:          fmt.Sprintf("Intento de entrega %d fallido: %s", attempt.AttemptNumber, req.ReasonCode.Label()),
+	})
+
+	if err := s.updateGuideStatus(assignment.GuideID, models.StatusDeliveryFailed, req.ReasonCode, req.Notes, userUUID); err != nil {
+		return result, err
+	}
+
+	next := models.DeliveryAssignment{
+		AssignmentID:   s.newID(),
+		GuideID:        assignment.GuideID,
+		DeliveryUserID: assignment.DeliveryUserID,
+		AssignmentType: models.AssignmentDelivery,
+		Status:         models.AssignmentPending,
+		AssignedBy:     userUUID,
+		AssignedAt:     now,
+		UpdatedAt:      now,
+		AttemptNumber:  attempt.AttemptNumber + 1,
+		ScheduledDate:  nextDate.Format("2006-01-02"),
+	}
+	next.Notes = fmt.Sprintf("Reintento de entrega %d programado para %s", next.AttemptNumber, next.ScheduledDate)
+	if attempt.AttemptNumber >= maxAttempts {
+		next.AssignmentType = models.AssignmentReturn
+		next.AttemptNumber = 1
+		next.Notes = fmt.Sprintf("Devolución al remitente: se agotaron los %d intentos de entrega", maxAttempts)
+		result.ReturnToSender = true
+	}
+	s.assignments[next.AssignmentID] = next
+	s.logAssignment(models.AssignmentHistory{
+		AssignmentID:      next.AssignmentID,
+		Action:            models.ActionCreated,
+		NewDeliveryUserID: next.DeliveryUserID,
+		NewStatus:         string(models.AssignmentPending),
+		ChangedBy:         userUUID,
+		Notes:             next.Notes,
+	})
+
+	result.Attempt = attempt
+	result.NextAssignmentID = next.AssignmentID
+	return result, nil
+}
+
+func (s *Store) GetGuideDeliveryAttempts(guideID int64) ([]models.DeliveryAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempts := []models.DeliveryAttempt{}
+	for _, a := range s.attempts {
+		if a.GuideID == guideID {
+			attempts = append(attempts, a)
+		}
+	}
+	return attempts, nil
+}
+
+func (s *Store) CompleteDeliveryWithProof(assignmentID int64, proof models.DeliveryProofRequest, notes string, changedBy string, guideChange *models.GuideStatusChange) (models.DeliveryAssignment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	assignment, ok := s.assignments[assignmentID]
+	if !ok {
+		return assignment, fmt.Errorf("asignación no encontrada")
+	}
+	if assignment.Status == models.AssignmentCompleted || assignment.Status == models.AssignmentCancelled {
+		return assignment, fmt.Errorf("la asignación ya está cerrada")
+	}
+	if err := s.checkGuideChange(assignment.GuideID, guideChange); err != nil {
+		return assignment, err
+	}
+
+	previous := assignment.Status
+	now := time.Now()
+	assignment.Status = models.AssignmentCompleted
+	assignment.UpdatedAt = now
+	assignment.CompletedAt = &now
+	s.assignments[assignmentID] = assignment
+	s.logAssignment(models.AssignmentHistory{
+		AssignmentID:   assignmentID,
+		Action:         models.ActionStatusChange,
+		PreviousStatus: string(previous),
+		NewStatus:      string(models.AssignmentCompleted),
+		ChangedBy:      changedBy,
+		Notes:          notes,
+	})
+
+	s.proofs = append(s.proofs, models.DeliveryProof{
+		ProofID:          s.newID(),
+		AssignmentID:     assignmentID,
+		GuideID:          assignment.GuideID,
+		ReceiverName:     proof.ReceiverName,
+		ReceiverDocument: proof.ReceiverDocument,
+		SignatureS3Key:   proof.SignatureKey,
+		PhotoS3Keys:      append([]string(nil), proof.PhotoKeys...),
+		Latitude:         proof.Latitude,
+		Longitude:        proof.Longitude,
+		CapturedBy:       changedBy,
+		CapturedAt:       now,
+	})
+
+	if guideChange != nil {
+		s.updateGuideStatus(assignment.GuideID, guideChange.Status, guideChange.Reason, "", changedBy)
+	}
+
+	return assignment, nil
+}
+
+func (s *Store) GetDeliveryProofByGuide(guideID int64) (models.DeliveryProof, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := len(s.proofs) - 1; i >= 0; i-- {
+		if s.proofs[i].GuideID == guideID {
+			return s.proofs[i], nil
+		}
+	}
+	return models.DeliveryProof{}, fmt.Errorf("prueba de entrega no encontrada")
+}
+
+func (s *Store) countAttempts(guideID int64) int {
+	count := 0
+	for _, a := range s.attempts {
+		if a.GuideID == guideID {
+			count++
+		}
+	}
+	return count
+}
+
+// hasReturnAssignment indica si la guía ya tiene una devolución activa o completada
+func (s *Store) hasReturnAssignment(guideID int64) bool {
+	for _, a := range s.assignments {
+		if a.GuideID == guideID && a.AssignmentType == models.AssignmentReturn && a.Status != models.AssignmentCancelled {
+			return true
+		}
+	}
+	return false
+}
+
+// pendingGuides guías en el estado dado sin asignación activa del tipo dado
+// que cumplen keep (llamado con el mutex tomado)
+func (s *Store) pendingGuides(status models.GuideStatus, assignmentType models.AssignmentType, keep func(models.ShippingGuide) bool) []models.PendingGuide {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pending := []models.PendingGuide{}
+	for _, g := range s.sortedGuides() {
+		if g.CurrentStatus != status || s.hasActiveAssignment(g.GuideID, assignmentType) || !keep(g) {
+			continue
+		}
+
+		contact := g.Sender
+		if assignmentType == models.AssignmentDelivery {
+			contact = g.Receiver
+		}
+
+		pg := models.PendingGuide{
+			GuideID:             g.GuideID,
+			ServiceType:         string(g.ServiceType),
+			CurrentStatus:       string(g.CurrentStatus),
+			OriginCityName:      g.OriginCityName,
+			DestinationCityName: g.DestinationCityName,
+			CreatedAt:           g.CreatedAt.Format("2006-01-02 15:04:05"),
+			AssignmentType:      assignmentType,
+		}
+		if contact != nil {
+			pg.ContactName = contact.FullName
+			pg.ContactAddress = contact.Address
+			pg.ContactPhone = contact.Phone
+		}
+		pending = append(pending, pg)
+	}
+	return pending
+}
+
+func (s *Store) hasActiveAssignment(guideID int64, assignmentType models.AssignmentType) bool {
+	for _, a := range s.assignments {
+		if a.GuideID == guideID && a.AssignmentType == assignmentType &&
+			a.Status != models.AssignmentCancelled && a.Status != models.AssignmentCompleted {
+			return true
+		}
+	}
+	return false
+}
+
+// filterAssignments se llama con el mutex tomado; ordena por fecha de asignación descendente
+func (s *Store) filterAssignments(filters models.AssignmentFilters) []models.DeliveryAssignment {
+	result := []models.DeliveryAssignment{}
+	for _, a := range s.assignments {
+		a.HubID = s.users[a.DeliveryUserID].HubID
+		if filters.HubID != nil && (a.HubID == nil || *a.HubID != *filters.HubID) {
+			continue
+		}
+		if filters.Status != "" && a.Status != filters.Status {
+			continue
+		}
+		if filters.AssignmentType != "" && a.AssignmentType != filters.AssignmentType {
+			continue
+		}
+		if filters.DeliveryUserID != "" && a.DeliveryUserID != filters.DeliveryUserID {
+			continue
+		}
+		if filters.GuideID != nil && a.GuideID != *filters.GuideID {
+			continue
+		}
+		if filters.DateFrom != nil && a.AssignedAt.Before(*filters.DateFrom) {
+			continue
+		}
+		if filters.DateTo != nil && a.AssignedAt.After(*filters.DateTo) {
+			continue
+		}
+		result = append(result, a)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].AssignmentID > result[j].AssignmentID })
+	return result
+}
+
+func (s *Store) logAssignment(entry models.AssignmentHistory) {
+	entry.HistoryID = s.nextHistoryID
+	s.nextHistoryID++
+	entry.ChangedAt = time.Now()
+	s.assignmentLog[entry.AssignmentID] = append(s.assignmentLog[entry.AssignmentID], entry)
+}
+
+// cancelOpenAssignments replica bd.cancelOpenAssignments (requiere el mutex tomado)
+func (s *Store) cancelOpenAssignments(guideID int64, assignmentType models.AssignmentType, changedBy string, notes string) {
+	for id, a := range s.assignments {
+		if a.GuideID != guideID || a.AssignmentType != assignmentType ||
+			(a.Status != models.AssignmentPending && a.Status != models.AssignmentInProgress) {
+			continue
+		}
+		previous := a.Status
+		a.Status = models.AssignmentCancelled
+		a.UpdatedAt = time.Now()
+		s.assignments[id] = a
+		s.logAssignment(models.AssignmentHistory{
+			AssignmentID:   id,
+			Action:         models.ActionCancelled,
+			PreviousStatus: string(previous),
+			NewStatus:      string(models.AssignmentCancelled),
+			ChangedBy:      changedBy,
+			Notes:          notes,
+		})
+	}
+}
+
+// ==========================================
+// RatingRepository
+// ==========================================
+
+func (s *Store) CreateDeliveryRating(req models.CreateRatingRequest, clientUserID string) (models.DeliveryRating, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	assignment, ok := s.assignments[req.AssignmentID]
+	if !ok || assignment.Status != models.AssignmentCompleted {
+		return models.DeliveryRating{}, fmt.Errorf("asignación no encontrada o no está completada")
+	}
+	for _, r := range s.ratings {
+		if r.AssignmentID == req.AssignmentID {
+			return models.DeliveryRating{}, fmt.Errorf("ya existe una calificación para esta asignación")
+		}
+	}
+
+	now := time.Now()
+	rating := models.DeliveryRating{
+		RatingID:       s.newID(),
+		AssignmentID:   req.AssignmentID,
+		GuideID:        assignment.GuideID,
+		DeliveryUserID: assignment.DeliveryUserID,
+		ClientUserID:   clientUserID,
+		Rating:         req.Rating,
+		Comment:        req.Comment,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	s.ratings[rating.RatingID] = rating
+	return rating, nil
+}
+
+func (s *Store) GetRatingByAssignmentID(assignmentID int64) (models.DeliveryRating, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, r := range s.ratings {
+		if r.AssignmentID == assignmentID {
+			return r, nil
+		}
+	}
+	return models.DeliveryRating{}, fmt.Errorf("calificación no encontrada")
+}
+
+func (s *Store) GetDeliveryUserRatings(deliveryUserID string, limit int) ([]models.DeliveryRating, int, float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ratings := []models.DeliveryRating{}
+	sum := 0
+	for _, r := range s.ratings {
+		if r.DeliveryUserID == deliveryUserID {
+			ratings = append(ratings, r)
+			sum += r.Rating
+		}
+	}
+	sort.Slice(ratings, func(i, j int) bool { return ratings[i].RatingID > ratings[j].RatingID })
+
+	avg := 0.0
+	if len(ratings) > 0 {
+		avg = float64(sum) / float64(len(ratings))
+	}
+	return paginate(ratings, limit, 0), len(ratings), avg, nil
+}
+
+func (s *Store) GetDeliveryPerformanceStats(deliveryUserID string) (models.DeliveryPerformanceStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := models.DeliveryPerformanceStats{
+		DailyPerformance: []models.DailyPerformance{},
+		RecentReviews:    []models.CustomerReview{},
+	}
+
+	weekAgo := time.Now().AddDate(0, 0, -7)
+	twoWeeksAgo := weekAgo.AddDate(0, 0, -7)
+	total, completed := 0, 0
+	for _, a := range s.assignments {
+		if a.DeliveryUserID != deliveryUserID {
+			continue
+		}
+		if a.AssignmentType == models.AssignmentTransfer {
+			if a.Status == models.AssignmentCompleted && a.CompletedAt != nil {
+				if a.CompletedAt.After(weekAgo) {
+					stats.TransfersThisWeek++
+				} else if a.CompletedAt.After(twoWeeksAgo) {
+					stats.TransfersLastWeek++
+				}
+			}
+			continue
+		}
+		if a.AssignmentType != models.AssignmentDelivery {
+			continue
+		}
+		total++
+		if a.Status != models.AssignmentCompleted || a.CompletedAt == nil {
+			continue
+		}
+		completed++
+		if a.CompletedAt.After(weekAgo) {
+			stats.DeliveriesThisWeek++
+		} else if a.CompletedAt.After(twoWeeksAgo) {
+			stats.DeliveriesLastWeek++
+		}
+	}
+	if total > 0 {
+		stats.SuccessRate = float64(completed) * 100 / float64(total)
+	}
+
+	sum := 0
+	for _, r := range s.ratings {
+		if r.DeliveryUserID == deliveryUserID {
+			stats.TotalRatings++
+			sum += r.Rating
+		}
+	}
+	if stats.TotalRatings > 0 {
+		stats.AvgRating = float64(sum) / float64(stats.TotalRatings)
+	}
+	return stats, nil
+}
+
+func (s *Store) GetClientPendingRatings(clientUserID string) ([]models.ClientPendingRating, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rated := map[int64]bool{}
+	for _, r := range s.ratings {
+		rated[r.AssignmentID] = true
+	}
+
+	pending := []models.ClientPendingRating{}
+	for _, a := range s.assignments {
+		if a.AssignmentType != models.AssignmentDelivery || a.Status != models.AssignmentCompleted || rated[a.AssignmentID] {
+			continue
+		}
+		guide, ok := s.guides[a.GuideID]
+		if !ok || !s.ownsGuide(guide, clientUserID) {
+			continue
+		}
+		item := models.ClientPendingRating{
+			AssignmentID:   a.AssignmentID,
+			GuideID:        a.GuideID,
+			DeliveryUserID: a.DeliveryUserID,
+			ServiceType:    string(guide.ServiceType),
+		}
+		if u, ok := s.users[a.DeliveryUserID]; ok {
+			item.DeliveryUserName = u.FullName
+		}
+		if a.CompletedAt != nil {
+			item.CompletedAt = a.CompletedAt.Format("2006-01-02 15:04:05")
+		}
+		pending = append(pending, item)
+	}
+	return pending, nil
+}
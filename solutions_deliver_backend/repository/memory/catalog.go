@@ -0,0 +1,615 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+)
+
+// ==========================================
+// LocationRepository
+// ==========================================
+
+func (s *Store) GetAllDepartments() ([]models.Department, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	departments := []models.Department{}
+	for _, d := range s.departments {
+		departments = append(departments, d)
+	}
+	sort.Slice(departments, func(i, j int) bool { return departments[i].Name < departments[j].Name })
+	return departments, nil
+}
+
+func (s *Store) DepartmentExists(departmentID int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.departments[departmentID]
+	return ok
+}
+
+func (s *Store) GetAllCities() ([]models.City, error) {
+	return s.filterCities(func(models.City) bool { return true }), nil
+}
+
+func (s *Store) GetCitiesByDepartment(departmentID int64) ([]models.City, error) {
+	return s.filterCities(func(c models.City) bool { return c.DepartmentID == departmentID }), nil
+}
+
+func (s *Store) GetCityByID(cityID int64) (models.City, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	city, ok := s.cities[cityID]
+	if !ok {
+		return city, fmt.Errorf("Ciudad no encontrada")
+	}
+	return city, nil
+}
+
+func (s *Store) SearchCities(searchTerm string) ([]models.City, error) {
+	term := strings.ToUpper(searchTerm)
+	cities := s.filterCities(func(c models.City) bool { return strings.Contains(strings.ToUpper(c.Name), term) })
+	for i := range cities {
+		cities[i].FullName = cities[i].Name + ", " + cities[i].DepartmentName
+	}
+	return paginate(cities, 50, 0), nil
+}
+
+func (s *Store) filterCities(keep func(models.City) bool) []models.City {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cities := []models.City{}
+	for _, c := range s.cities {
+		if keep(c) {
+			cities = append(cities, c)
+		}
+	}
+	sort.Slice(cities, func(i, j int) bool { return cities[i].Name < cities[j].Name })
+	return cities
+}
+
+// ==========================================
+// RateRepository
+// ==========================================
+
+// GetShippingRate replica bd.GetShippingRate: la tarifa en vigor en la fecha con mayor effective_date
+func (s *Store) GetShippingRate(originCityID, destinationCityID int64, date time.Time) (models.ShippingRate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	best, found := s.shippingRate(originCityID, destinationCityID, date)
+	if !found {
+		return best, fmt.Errorf("no hay tarifa para la ruta")
+	}
+	return s.withRateCities(best), nil
+}
+
+// shippingRate tarifa en vigor entre las ciudades (requiere el mutex tomado)
+func (s *Store) shippingRate(originCityID, destinationCityID int64, date time.Time) (models.ShippingRate, bool) {
+	var best models.ShippingRate
+	found := false
+	day := date.Format("2006-01-02")
+	for _, r := range s.rates {
+		if r.OriginCityID != originCityID || r.DestinationCityID != destinationCityID ||
+			r.Status != models.RateActive || r.EffectiveDate.Format("2006-01-02") > day {
+			continue
+		}
+		if !found || r.EffectiveDate.After(best.EffectiveDate) ||
+			(r.EffectiveDate.Equal(best.EffectiveDate) && r.ID > best.ID) {
+			best, found = r, true
+		}
+	}
+	return best, found
+}
+
+func (s *Store) GetRatesByFilters(filters models.RateFilters) ([]models.ShippingRate, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []models.ShippingRate
+	for _, r := range s.rates {
+		if filters.OriginCityID != nil && r.OriginCityID != *filters.OriginCityID {
+			continue
+		}
+		if filters.DestinationCityID != nil && r.DestinationCityID != *filters.DestinationCityID {
+			continue
+		}
+		if filters.Route != "" && !strings.Contains(strings.ToUpper(r.Route), strings.ToUpper(filters.Route)) {
+			continue
+		}
+		if filters.Status != "" && r.Status != filters.Status {
+			continue
+		}
+		result = append(result, s.withRateCities(r))
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.Route != b.Route {
+			return a.Route < b.Route
+		}
+		if a.OriginCityID != b.OriginCityID {
+			return a.OriginCityID < b.OriginCityID
+		}
+		if a.DestinationCityID != b.DestinationCityID {
+			return a.DestinationCityID < b.DestinationCityID
+		}
+		if !a.EffectiveDate.Equal(b.EffectiveDate) {
+			return a.EffectiveDate.After(b.EffectiveDate)
+		}
+		return a.ID > b.ID
+	})
+
+	total := len(result)
+	return paginate(result, filters.Limit, filters.Offset), total, nil
+}
+
+func (s *Store) GetRateByID(rateID int64) (models.ShippingRate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.rateIndex(rateID)
+	if i < 0 {
+		return models.ShippingRate{}, fmt.Errorf("tarifa no encontrada")
+	}
+	return s.withRateCities(s.rates[i]), nil
+}
+
+func (s *Store) CreateRate(rate *models.ShippingRate, userUUID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.rateVersionExists(*rate) {
+		return fmt.Errorf("ya existe una tarifa para la ruta en esa fecha")
+	}
+
+	now := time.Now()
+	rate.ID = s.newID()
+	rate.Status = models.RateActive
+	rate.CreatedBy = userUUID
+	rate.CreatedAt = now
+	rate.UpdatedAt = now
+	s.rates = append(s.rates, *rate)
+	s.addRateChange(rate.ID, models.RateActionCreated, nil, rate, userUUID)
+	return nil
+}
+
+func (s *Store) UpdateRate(previous, updated models.ShippingRate, userUUID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.rateIndex(updated.ID)
+	if i < 0 {
+		return fmt.Errorf("tarifa no encontrada")
+	}
+	if s.rateVersionExists(updated) {
+		return fmt.Errorf("ya existe una tarifa para la ruta en esa fecha")
+	}
+
+	stored := s.rates[i]
+	stored.Route = updated.Route
+	stored.TravelFrequency = updated.TravelFrequency
+	stored.MinDispatchKg = updated.MinDispatchKg
+	stored.PricePerKg = updated.PricePerKg
+	stored.MinValue = updated.MinValue
+	stored.EffectiveDate = updated.EffectiveDate
+	stored.UpdatedAt = time.Now()
+	s.rates[i] = stored
+	s.addRateChange(updated.ID, models.RateActionUpdated, &previous, &updated, userUUID)
+	return nil
+}
+
+func (s *Store) RetireRate(rateID int64, userUUID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.rateIndex(rateID)
+	if i < 0 || s.rates[i].Status != models.RateActive {
+		return fmt.Errorf("la tarifa ya está retirada")
+	}
+
+	now := time.Now()
+	s.rates[i].Status = models.RateRetired
+	s.rates[i].RetiredAt = &now
+	s.rates[i].UpdatedAt = now
+	s.addRateChange(rateID, models.RateActionRetired, nil, nil, userUUID)
+	return nil
+}
+
+func (s *Store) GetRateHistory(rateID int64) ([]models.RateChange, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var history []models.RateChange
+	for i := len(s.rateHistory) - 1; i >= 0; i-- {
+		change := s.rateHistory[i]
+		if change.RateID != rateID {
+			continue
+		}
+		if user, ok := s.users[change.ChangedBy]; ok {
+			change.ChangedByName = user.FullName
+		}
+		history = append(history, change)
+	}
+	return history, nil
+}
+
+func (s *Store) GetCurrentRates(date time.Time) ([]models.ShippingRate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	day := date.Format("2006-01-02")
+	current := map[[2]int64]models.ShippingRate{}
+	for _, r := range s.rates {
+		if r.Status != models.RateActive || r.EffectiveDate.Format("2006-01-02") > day {
+			continue
+		}
+		key := [2]int64{r.OriginCityID, r.DestinationCityID}
+		best, found := current[key]
+		if !found || r.EffectiveDate.After(best.EffectiveDate) ||
+			(r.EffectiveDate.Equal(best.EffectiveDate) && r.ID > best.ID) {
+			current[key] = r
+		}
+	}
+
+	rates := make([]models.ShippingRate, 0, len(current))
+	for _, r := range current {
+		rates = append(rates, s.withRateCities(r))
+	}
+	sort.Slice(rates, func(i, j int) bool {
+		a, b := rates[i], rates[j]
+		if a.Route != b.Route {
+			return a.Route < b.Route
+		}
+		if a.OriginCityName != b.OriginCityName {
+			return a.OriginCityName < b.OriginCityName
+		}
+		return a.DestinationCityName < b.DestinationCityName
+	})
+	return rates, nil
+}
+
+// ImportRates replica la transacción de bd: si una ruta ya tiene versión en
+// la fecha no se guarda ninguna tarifa
+func (s *Store) ImportRates(rates []models.ShippingRate, userUUID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, rate := range rates {
+		if s.rateVersionExists(rate) {
+			return fmt.Errorf("ya existe una tarifa para la ruta en esa fecha")
+		}
+	}
+
+	now := time.Now()
+	for i := range rates {
+		rates[i].ID = s.newID()
+		rates[i].Status = models.RateActive
+		rates[i].CreatedBy = userUUID
+		rates[i].CreatedAt = now
+		rates[i].UpdatedAt = now
+		s.rates = append(s.rates, rates[i])
+		s.addRateChange(rates[i].ID, models.RateActionCreated, nil, &rates[i], userUUID)
+	}
+	return nil
+}
+
+func (s *Store) rateIndex(rateID int64) int {
+	for i, r := range s.rates {
+		if r.ID == rateID {
+			return i
+		}
+	}
+	return -1
+}
+
+// rateVersionExists replica la validación de bd: una sola tarifa activa por ruta y fecha
+func (s *Store) rateVersionExists(rate models.ShippingRate) bool {
+	day := rate.EffectiveDate.Format("2006-01-02")
+	for _, r := range s.rates {
+		if r.ID != rate.ID && r.Status == models.RateActive &&
+			r.OriginCityID == rate.OriginCityID && r.DestinationCityID == rate.DestinationCityID &&
+			r.EffectiveDate.Format("2006-01-02") == day {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Store) addRateChange(rateID int64, action models.RateAction, previous, updated *models.ShippingRate, userUUID string) {
+	change := models.RateChange{
+		ID:        s.newID(),
+		RateID:    rateID,
+		Action:    action,
+		ChangedBy: userUUID,
+		ChangedAt: time.Now(),
+	}
+	if previous != nil {
+		price, minValue, minKg, date := previous.PricePerKg, previous.MinValue, previous.MinDispatchKg, previous.EffectiveDate
+		change.PreviousPricePerKg, change.PreviousMinValue = &price, &minValue
+		change.PreviousMinDispatchKg, change.PreviousEffectiveDate = &minKg, &date
+	}
+	if updated != nil {
+		price, minValue, minKg, date := updated.PricePerKg, updated.MinValue, updated.MinDispatchKg, updated.EffectiveDate
+		change.NewPricePerKg, change.NewMinValue = &price, &minValue
+		change.NewMinDispatchKg, change.NewEffectiveDate = &minKg, &date
+	}
+	s.rateHistory = append(s.rateHistory, change)
+}
+
+// withRateCities completa los nombres de ciudades como el JOIN de bd
+func (s *Store) withRateCities(rate models.ShippingRate) models.ShippingRate {
+	rate.OriginCityName = s.cities[rate.OriginCityID].Name
+	rate.DestinationCityName = s.cities[rate.DestinationCityID].Name
+	return rate
+}
+
+// ==========================================
+// FrequentPartyRepository
+// ==========================================
+
+func (s *Store) SearchFrequentPartiesByNameOnly(searchTerm string, partyType models.PartyType) ([]models.FrequentPartyUnique, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	byDocument := map[string]*models.FrequentPartyUnique{}
+	cities := map[string]map[int64]bool{}
+	var order []string
+	for _, p := range s.frequentParty {
+		if !partyMatches(p, searchTerm, partyType) {
+			continue
+		}
+		u, ok := byDocument[p.DocumentNumber]
+		if !ok {
+			u = &models.FrequentPartyUnique{
+				FullName:       p.FullName,
+				DocumentType:   p.DocumentType,
+				DocumentNumber: p.DocumentNumber,
+				Phone:          p.Phone,
+				Email:          p.Email,
+			}
+			byDocument[p.DocumentNumber] = u
+			cities[p.DocumentNumber] = map[int64]bool{}
+			order = append(order, p.DocumentNumber)
+		}
+		u.TotalUsage += p.UsageCount
+		cities[p.DocumentNumber][p.CityID] = true
+	}
+
+	result := []models.FrequentPartyUnique{}
+	for _, doc := range order {
+		u := byDocument[doc]
+		u.TotalCities = len(cities[doc])
+		result = append(result, *u)
+	}
+	return result, len(result), nil
+}
+
+func (s *Store) SearchFrequentPartiesByNameAndCity(searchTerm string, cityID int64, partyType models.PartyType) ([]models.FrequentParty, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := []models.FrequentParty{}
+	for _, p := range s.frequentParty {
+		if p.CityID == cityID && partyMatches(p, searchTerm, partyType) {
+			result = append(result, p)
+		}
+	}
+	return result, len(result), nil
+}
+
+func (s *Store) GetFrequentPartiesByDocument(documentNumber string, cityID int64, partyType models.PartyType) ([]models.FrequentParty, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := []models.FrequentParty{}
+	for _, p := range s.frequentParty {
+		if p.DocumentNumber != documentNumber || p.CityID != cityID {
+			continue
+		}
+		if partyType != "" && p.PartyType != partyType {
+			continue
+		}
+		result = append(result, p)
+	}
+	return result, len(result), nil
+}
+
+func (s *Store) UpsertFrequentParty(req models.CreateFrequentPartyRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for i, p := range s.frequentParty {
+		if p.DocumentNumber == req.DocumentNumber && p.CityID == req.CityID && p.Address == req.Address {
+			s.frequentParty[i].UsageCount++
+			s.frequentParty[i].LastUsedAt = now
+			s.frequentParty[i].FullName = req.FullName
+			s.frequentParty[i].Phone = req.Phone
+			s.frequentParty[i].Email = req.Email
+			return nil
+		}
+	}
+
+	s.frequentParty = append(s.frequentParty, models.FrequentParty{
+		ID:             s.newID(),
+		PartyType:      req.PartyType,
+		FullName:       req.FullName,
+		DocumentType:   req.DocumentType,
+		DocumentNumber: req.DocumentNumber,
+		Phone:          req.Phone,
+		Email:          req.Email,
+		CityID:         req.CityID,
+		Address:        req.Address,
+		FirstUsedAt:    now,
+		LastUsedAt:     now,
+		UsageCount:     1,
+		UserUUID:       req.UserUUID,
+	})
+	return nil
+}
+
+func (s *Store) GetFrequentPartyStats() (map[string]interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unique := map[string]bool{}
+	for _, p := range s.frequentParty {
+		unique[p.DocumentNumber] = true
+	}
+	return map[string]interface{}{
+		"total_unique_parties": len(unique),
+		"total_records":        len(s.frequentParty),
+	}, nil
+}
+
+func partyMatches(p models.FrequentParty, term string, partyType models.PartyType) bool {
+	if partyType != "" && p.PartyType != partyType {
+		return false
+	}
+	return strings.Contains(strings.ToLower(p.FullName), strings.ToLower(term))
+}
+
+// ==========================================
+// CashCloseRepository
+// ==========================================
+
+func (s *Store) CreateCashClose(close *models.CashClose) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	close.CloseID = s.newID()
+	close.CreatedAt = time.Now()
+	if close.HubID != nil {
+		close.HubCode = s.hubs[*close.HubID].Code
+	}
+	s.cashCloses[close.CloseID] = *close
+	return close.CloseID, nil
+}
+
+func (s *Store) CreateCashCloseDetail(detail *models.CashCloseDetail) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	detail.DetailID = s.newID()
+	s.cashDetails[detail.CloseID] = append(s.cashDetails[detail.CloseID], *detail)
+	return nil
+}
+
+func (s *Store) GetAllCashCloses(limit, offset int, scope models.HubScope) ([]models.CashClose, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	closes := []models.CashClose{}
+	for _, c := range s.cashCloses {
+		if !scope.Consolidated() && !scope.Includes(c.HubID) {
+			continue
+		}
+		closes = append(closes, c)
+	}
+	sort.Slice(closes, func(i, j int) bool { return closes[i].CloseID > closes[j].CloseID })
+	return paginate(closes, limit, offset), len(closes), nil
+}
+
+func (s *Store) GetCashCloseByID(closeID int64) (models.CashClose, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	close, ok := s.cashCloses[closeID]
+	if !ok {
+		return close, fmt.Errorf("cash close not found")
+	}
+	return close, nil
+}
+
+func (s *Store) GetCashCloseDetails(closeID int64) ([]models.CashCloseDetail, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]models.CashCloseDetail{}, s.cashDetails[closeID]...), nil
+}
+
+func (s *Store) GetCashCloseStats(scope models.HubScope) (models.CashCloseStatsResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := models.CashCloseStatsResponse{ByPaymentMethod: map[string]float64{}}
+	now := time.Now()
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for _, g := range s.guides {
+		if g.CurrentStatus != models.StatusDelivered || !s.guideInScope(scope, g.OriginCityID) {
+			continue
+		}
+		stats.ByPaymentMethod[string(g.PaymentMethod)] += g.Price
+		if !g.UpdatedAt.Before(startOfDay) {
+			stats.TodayTotal += g.Price
+		}
+		if g.UpdatedAt.After(startOfDay.AddDate(0, 0, -7)) {
+			stats.WeekTotal += g.Price
+		}
+		if g.UpdatedAt.Year() == now.Year() && g.UpdatedAt.Month() == now.Month() {
+			stats.MonthTotal += g.Price
+		}
+		if g.UpdatedAt.Year() == now.Year() {
+			stats.YearTotal += g.Price
+		}
+	}
+	return stats, nil
+}
+
+func (s *Store) UpdateCashClosePDF(closeID int64, pdfURL, pdfS3Key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	close, ok := s.cashCloses[closeID]
+	if !ok {
+		return fmt.Errorf("cash close not found")
+	}
+	close.PDFURL = pdfURL
+	close.PDFS3Key = pdfS3Key
+	s.cashCloses[closeID] = close
+	return nil
+}
+
+func (s *Store) GetGuidesForCashClose(startDate, endDate time.Time, scope models.HubScope) ([]models.CashCloseDetail, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	details := []models.CashCloseDetail{}
+	for _, g := range s.sortedGuides() {
+		if g.CurrentStatus != models.StatusDelivered || g.UpdatedAt.Before(startDate) || g.UpdatedAt.After(endDate) ||
+			!s.guideInScope(scope, g.OriginCityID) {
+			continue
+		}
+		detail := models.CashCloseDetail{
+			GuideID:       g.GuideID,
+			Date:          g.UpdatedAt.Format("2006-01-02"),
+			Destination:   g.DestinationCityName,
+			Units:         1,
+			Freight:       g.Price,
+			TotalValue:    g.Price,
+			PaymentMethod: string(g.PaymentMethod),
+		}
+		if g.Sender != nil {
+			detail.Sender = g.Sender.FullName
+		}
+		if len(g.Pieces) > 0 {
+			detail.Units = len(g.Pieces)
+			for _, piece := range g.Pieces {
+				detail.Weight += piece.WeightKg
+			}
+		} else if g.Package != nil {
+			detail.Weight = g.Package.WeightKg
+			if g.Package.Pieces > 0 {
+				detail.Units = g.Package.Pieces
+			}
+		}
+		details = append(details, detail)
+	}
+	return details, nil
+}
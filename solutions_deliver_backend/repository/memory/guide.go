@@ -0,0 +1,399 @@
+package memory
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+)
+
+// ==========================================
+// GuideRepository
+// ==========================================
+
+func (s *Store) GetGuideByID(guideID int64) (models.ShippingGuide, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	guide, ok := s.guides[guideID]
+	if !ok {
+		return guide, fmt.Errorf("Guía no encontrada")
+	}
+	return withPartial(guide), nil
+}
+
+func (s *Store) GetGuidesByFilters(filters models.GuideFilters) ([]models.ShippingGuide, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []models.ShippingGuide
+	for _, g := range s.sortedGuides() {
+		if filters.Status != "" && g.CurrentStatus != filters.Status {
+			continue
+		}
+		if filters.OriginCityID != nil && g.OriginCityID != *filters.OriginCityID {
+			continue
+		}
+		if filters.DestinationCityID != nil && g.DestinationCityID != *filters.DestinationCityID {
+			continue
+		}
+		if filters.DateFrom != nil && g.CreatedAt.Before(*filters.DateFrom) {
+			continue
+		}
+		if filters.DateTo != nil && g.CreatedAt.After(*filters.DateTo) {
+			continue
+		}
+		if filters.CreatedBy != "" && g.CreatedBy != filters.CreatedBy {
+			continue
+		}
+		if filters.SearchTerm != "" && !guideMatches(g, filters.SearchTerm) {
+			continue
+		}
+		result = append(result, withPartial(g))
+	}
+
+	return paginate(result, filters.Limit, filters.Offset), len(result), nil
+}
+
+func (s *Store) GetGuideStats(userUUID string) (models.GuideStatsResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := models.GuideStatsResponse{ByStatus: map[string]int{}}
+	today := time.Now().Format("2006-01-02")
+	for _, g := range s.guides {
+		stats.ByStatus[string(g.CurrentStatus)]++
+		if g.CreatedAt.Format("2006-01-02") == today {
+			stats.TotalToday++
+		}
+		if g.CurrentStatus == models.StatusDelivered {
+			stats.TotalProcessed++
+		} else if !g.CurrentStatus.IsFinal() {
+			stats.TotalPending++
+		}
+	}
+	return stats, nil
+}
+
+func (s *Store) UpdateGuideStatus(guideID int64, status models.GuideStatus, reason models.GuideStatusReason, notes string, userUUID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.updateGuideStatus(guideID, status, reason, notes, userUUID)
+}
+
+// updateGuideStatus replica bd.updateGuideStatusTx (requiere el mutex tomado)
+func (s *Store) updateGuideStatus(guideID int64, status models.GuideStatus, reason models.GuideStatusReason, notes string, userUUID string) error {
+	guide, ok := s.guides[guideID]
+	if !ok {
+		return fmt.Errorf("Guía no encontrada")
+	}
+
+	transition, ok := models.FindGuideTransition(guide.CurrentStatus, status)
+	if !ok {
+		return fmt.Errorf("transición de estado no permitida")
+	}
+
+	now := time.Now()
+	guide.CurrentStatus = status
+	guide.UpdatedAt = now
+	guide.History = append(guide.History, models.StatusHistory{
+		HistoryID:  s.newID(),
+		GuideID:    guideID,
+		Status:     status,
+		ReasonCode: reason,
+		Notes:      notes,
+		UpdatedBy:  userUUID,
+		UpdatedAt:  now,
+	})
+	s.guides[guideID] = guide
+	s.statusRequests = append(s.statusRequests, StatusChange{GuideID: guideID, Status: status, Reason: reason, Notes: notes, UserID: userUUID})
+
+	effectNotes := fmt.Sprintf("Cerrada automáticamente: la guía pasó a %s", status)
+	if transition.HasEffect(models.EffectCancelOpenPickups) {
+		s.cancelOpenAssignments(guideID, models.AssignmentPickup, userUUID, effectNotes)
+	}
+	if transition.HasEffect(models.EffectCancelOpenDeliveries) {
+		s.cancelOpenAssignments(guideID, models.AssignmentDelivery, userUUID, effectNotes)
+	}
+	if transition.HasEffect(models.EffectCancelOpenReturns) {
+		s.cancelOpenAssignments(guideID, models.AssignmentReturn, userUUID, effectNotes)
+	}
+	return nil
+}
+
+func (s *Store) GuideExists(guideID int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.guides[guideID]
+	return ok
+}
+
+func (s *Store) GetGuidePDFInfo(guideID int64) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.guides[guideID]; !ok {
+		return "", fmt.Errorf("Guía no encontrada")
+	}
+	return s.guidePDFKeys[guideID], nil
+}
+
+func (s *Store) CreateGuide(guide *models.ShippingGuide, numberPrefix string, userUUID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Consecutivo por prefijo, como guide_number_sequences
+	s.guideNumbers[numberPrefix]++
+	number, err := models.NewGuideNumber(numberPrefix, s.guideNumbers[numberPrefix])
+	if err != nil {
+		s.guideNumbers[numberPrefix]--
+		return err
+	}
+
+	now := time.Now()
+	guide.GuideID = s.nextGuideID
+	s.nextGuideID++
+	guide.GuideNumber = number
+	guide.CurrentStatus = models.StatusCreated
+	guide.CreatedBy = userUUID
+	guide.CreatedAt = now
+	guide.UpdatedAt = now
+
+	for _, party := range []*models.GuideParty{guide.Sender, guide.Receiver} {
+		party.PartyID = s.newID()
+		party.GuideID = guide.GuideID
+	}
+	guide.Package.PackageID = s.newID()
+	guide.Package.GuideID = guide.GuideID
+	for i := range guide.Pieces {
+		piece := &guide.Pieces[i]
+		piece.PieceID = s.newID()
+		piece.GuideID = guide.GuideID
+		piece.PieceNumber = i + 1
+		piece.PieceCode = models.NewPieceCode(number, piece.PieceNumber)
+		piece.Status = models.StatusCreated
+	}
+	guide.History = []models.StatusHistory{{
+		HistoryID: s.newID(),
+		GuideID:   guide.GuideID,
+		Status:    models.StatusCreated,
+		UpdatedBy: userUUID,
+		UpdatedAt: now,
+	}}
+
+	// Copias para que el llamador no modifique lo almacenado
+	stored := *guide
+	sender, receiver, pkg := *guide.Sender, *guide.Receiver, *guide.Package
+	stored.Sender, stored.Receiver, stored.Package = &sender, &receiver, &pkg
+	stored.Pieces = append([]models.GuidePiece(nil), guide.Pieces...)
+	s.guides[guide.GuideID] = stored
+	return nil
+}
+
+func (s *Store) GetGuideIDByNumber(guideNumber string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, g := range s.guides {
+		if g.GuideNumber != "" && g.GuideNumber == guideNumber {
+			return g.GuideID, nil
+		}
+	}
+	return 0, fmt.Errorf("Guía no encontrada")
+}
+
+func (s *Store) UpdateGuidePDF(guideID int64, pdfURL, pdfS3Key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	guide, ok := s.guides[guideID]
+	if !ok {
+		return fmt.Errorf("Guía no encontrada")
+	}
+	guide.PDFUrl = pdfURL
+	guide.PDFS3Key = pdfS3Key
+	s.guides[guideID] = guide
+	s.guidePDFKeys[guideID] = pdfS3Key
+	return nil
+}
+
+func (s *Store) ValidateGuideAccess(guideID int64, userUUID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	guide, ok := s.guides[guideID]
+	if !ok {
+		return false, fmt.Errorf("Guía no encontrada")
+	}
+	return s.ownsGuide(guide, userUUID), nil
+}
+
+// ownsGuide replica la regla de bd.ValidateGuideAccess: el creador o el
+// usuario cuyo documento coincide con remitente o destinatario
+func (s *Store) ownsGuide(guide models.ShippingGuide, userUUID string) bool {
+	if guide.CreatedBy == userUUID {
+		return true
+	}
+
+	user, ok := s.users[userUUID]
+	if !ok || user.NumberDocument == "" {
+		return false
+	}
+	if guide.Sender != nil && guide.Sender.DocumentNumber == user.NumberDocument {
+		return true
+	}
+	if guide.Receiver != nil && guide.Receiver.DocumentNumber == user.NumberDocument {
+		return true
+	}
+	return false
+}
+
+func guideMatches(g models.ShippingGuide, term string) bool {
+	term = strings.ToLower(term)
+	if strings.Contains(strconv.FormatInt(g.GuideID, 10), term) ||
+		(g.GuideNumber != "" && strings.Contains(g.GuideNumber, models.NormalizeGuideNumber(term))) {
+		return true
+	}
+	for _, p := range []*models.GuideParty{g.Sender, g.Receiver} {
+		if p == nil {
+			continue
+		}
+		if strings.Contains(strings.ToLower(p.FullName), term) || strings.Contains(p.DocumentNumber, term) {
+			return true
+		}
+	}
+	return false
+}
+
+// ==========================================
+// ClientRepository
+// ==========================================
+
+func (s *Store) GetClientActiveGuides(userUUID string) ([]models.ShippingGuide, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	guides := []models.ShippingGuide{}
+	for _, g := range s.sortedGuides() {
+		if s.ownsGuide(g, userUUID) && !g.CurrentStatus.IsFinal() {
+			guides = append(guides, g)
+		}
+	}
+	return guides, nil
+}
+
+func (s *Store) GetClientGuideHistory(filters models.ClientGuideFilters) ([]models.ShippingGuide, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	guides := []models.ShippingGuide{}
+	for _, g := range s.sortedGuides() {
+		if !s.ownsGuide(g, filters.UserUUID) {
+			continue
+		}
+		if filters.Status != nil && g.CurrentStatus != *filters.Status {
+			continue
+		}
+		if filters.DateFrom != nil && g.CreatedAt.Before(*filters.DateFrom) {
+			continue
+		}
+		if filters.DateTo != nil && g.CreatedAt.After(*filters.DateTo) {
+			continue
+		}
+		if filters.SearchTerm != "" && !guideMatches(g, filters.SearchTerm) {
+			continue
+		}
+		guides = append(guides, g)
+	}
+	return paginate(guides, filters.Limit, filters.Offset), nil
+}
+
+func (s *Store) GetClientStats(userUUID string) (models.ClientStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var stats models.ClientStats
+	for _, g := range s.guides {
+		if !s.ownsGuide(g, userUUID) {
+			continue
+		}
+		stats.TotalGuides++
+		stats.TotalSpent += g.Price
+		if g.CurrentStatus == models.StatusDelivered {
+			stats.DeliveredGuides++
+		} else if !g.CurrentStatus.IsFinal() {
+			stats.ActiveGuides++
+		}
+	}
+	return stats, nil
+}
+
+// ==========================================
+// UserRepository
+// ==========================================
+
+func (s *Store) GetUserRole(userUUID string) (models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[userUUID]
+	if !ok {
+		return user, fmt.Errorf("Usuario no encontrado")
+	}
+	return user, nil
+}
+
+func (s *Store) UpdateLastLogin(userUUID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastLogin[userUUID] = true
+	return nil
+}
+
+func (s *Store) GetUserProfile(userUUID string) (models.ClientProfile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[userUUID]
+	if !ok {
+		return models.ClientProfile{}, fmt.Errorf("Usuario no encontrado")
+	}
+	return profileFromUser(user), nil
+}
+
+func (s *Store) UpdateUserProfile(userUUID string, updateData models.ClientProfileUpdate) (models.ClientProfile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[userUUID]
+	if !ok {
+		return models.ClientProfile{}, fmt.Errorf("Usuario no encontrado")
+	}
+	if updateData.FullName != "" {
+		user.FullName = updateData.FullName
+	}
+	if updateData.Phone != "" {
+		user.Phone = updateData.Phone
+	}
+	if updateData.DocumentType != "" {
+		user.TypeDocument = updateData.DocumentType
+	}
+	if updateData.DocumentNumber != "" {
+		user.NumberDocument = updateData.DocumentNumber
+	}
+	s.users[userUUID] = user
+	return profileFromUser(user), nil
+}
+
+func profileFromUser(user models.User) models.ClientProfile {
+	return models.ClientProfile{
+		UserUUID:       user.UserUUID,
+		FullName:       user.FullName,
+		Email:          user.UserEmail,
+		Phone:          user.Phone,
+		DocumentType:   user.TypeDocument,
+		DocumentNumber: user.NumberDocument,
+	}
+}
@@ -0,0 +1,122 @@
+package memory
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+)
+
+// ==========================================
+// Código de entrega (AssignmentRepository)
+// ==========================================
+
+func (s *Store) SaveHandoverCode(code models.HandoverCode, changedBy string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Los fallos acumulados y el bloqueo se conservan al reenviar
+	if previous, ok := s.handoverCodes[code.AssignmentID]; ok {
+		code.FailedAttempts = previous.FailedAttempts
+		code.LockedUntil = previous.LockedUntil
+	}
+	code.VerifiedAt = nil
+	code.OverriddenBy = ""
+	code.OverrideReason = ""
+	code.CreatedAt = time.Now()
+	s.handoverCodes[code.AssignmentID] = code
+	s.logAssignment(models.AssignmentHistory{
+		AssignmentID: code.AssignmentID,
+		Action:       models.ActionHandoverCodeSent,
+		ChangedBy:    changedBy,
+		Notes:        fmt.Sprintf("Código de entrega enviado a %s", code.SentTo),
+	})
+	return nil
+}
+
+func (s *Store) GetHandoverCode(assignmentID int64) (models.HandoverCode, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	code, ok := s.handoverCodes[assignmentID]
+	if !ok {
+		return code, fmt.Errorf("código de entrega no encontrado")
+	}
+	return code, nil
+}
+
+func (s *Store) VerifyHandoverCode(assignmentID int64, codeHash string, maxAttempts int, lockout time.Duration, changedBy string) (models.HandoverVerification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result models.HandoverVerification
+	code, ok := s.handoverCodes[assignmentID]
+	if !ok {
+		return result, fmt.Errorf("código de entrega no encontrado")
+	}
+
+	now := time.Now()
+	switch {
+	case code.VerifiedAt != nil:
+		result.Result = models.HandoverValid
+		return result, nil
+	case code.LockedUntil != nil && code.LockedUntil.After(now):
+		result.Result = models.HandoverLocked
+		result.LockedUntil = code.LockedUntil
+		return result, nil
+	case code.ExpiresAt.Before(now):
+		result.Result = models.HandoverExpired
+		return result, nil
+	}
+
+	if code.CodeHash == codeHash {
+		code.VerifiedAt = &now
+		s.handoverCodes[assignmentID] = code
+		result.Result = models.HandoverValid
+		return result, nil
+	}
+
+	code.FailedAttempts++
+	result.Result = models.HandoverInvalid
+	result.RemainingAttempts = models.HandoverRemainingAttempts(code.FailedAttempts, maxAttempts)
+	notes := fmt.Sprintf("Código de entrega incorrecto (%d fallos, quedan %d intentos)", code.FailedAttempts, result.RemainingAttempts)
+	if duration := models.HandoverLockout(code.FailedAttempts, maxAttempts, lockout); duration > 0 {
+		until := now.Add(duration)
+		code.LockedUntil = &until
+		notes = fmt.Sprintf("Código de entrega incorrecto (%d fallos): bloqueado hasta %s", code.FailedAttempts, until.Format("2006-01-02 15:04"))
+		result.Result = models.HandoverLocked
+		result.LockedUntil = &until
+		result.RemainingAttempts = 0
+	}
+	s.handoverCodes[assignmentID] = code
+	s.logAssignment(models.AssignmentHistory{
+		AssignmentID: assignmentID,
+		Action:       models.ActionHandoverCodeFailed,
+		ChangedBy:    changedBy,
+		Notes:        notes,
+	})
+	return result, nil
+}
+
+func (s *Store) OverrideHandoverCode(assignmentID int64, guideID int64, reason string, adminUUID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	code, ok := s.handoverCodes[assignmentID]
+	if !ok {
+		code = models.HandoverCode{AssignmentID: assignmentID, GuideID: guideID, ExpiresAt: now, CreatedAt: now}
+	}
+	code.VerifiedAt = &now
+	code.LockedUntil = nil
+	code.OverriddenBy = adminUUID
+	code.OverrideReason = reason
+	s.handoverCodes[assignmentID] = code
+	s.logAssignment(models.AssignmentHistory{
+		AssignmentID: assignmentID,
+		Action:       models.ActionHandoverCodeOverride,
+		ChangedBy:    adminUUID,
+		Notes:        reason,
+	})
+	return nil
+}
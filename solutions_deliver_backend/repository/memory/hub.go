@@ -0,0 +1,133 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+)
+
+// ==========================================
+// Hubs y su cobertura (HubRepository)
+// ==========================================
+
+func (s *Store) CreateHub(hub *models.Hub) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, h := range s.hubs {
+		if h.Code == hub.Code {
+			return fmt.Errorf("ya existe un hub con ese código")
+		}
+	}
+	hub.HubID = s.newID()
+	hub.Active = true
+	hub.CreatedAt = time.Now()
+	s.hubs[hub.HubID] = *hub
+	return nil
+}
+
+func (s *Store) GetHubs() ([]models.Hub, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var hubs []models.Hub
+	for _, h := range s.hubs {
+		hubs = append(hubs, s.withHubDetails(h))
+	}
+	sort.Slice(hubs, func(i, j int) bool { return hubs[i].Code < hubs[j].Code })
+	return hubs, nil
+}
+
+func (s *Store) GetHubByID(hubID int64) (models.Hub, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	h, ok := s.hubs[hubID]
+	if !ok {
+		return h, fmt.Errorf("Hub no encontrado")
+	}
+	return s.withHubDetails(h), nil
+}
+
+func (s *Store) UpdateHub(hub models.Hub) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	h, ok := s.hubs[hub.HubID]
+	if !ok {
+		return fmt.Errorf("Hub no encontrado")
+	}
+	h.Name = hub.Name
+	h.Active = hub.Active
+	h.Coverage = hub.Coverage
+	s.hubs[h.HubID] = h
+	return nil
+}
+
+// withHubDetails completa nombres de ciudades y usuarios asignados
+// (requiere el mutex tomado)
+func (s *Store) withHubDetails(h models.Hub) models.Hub {
+	h.CityName = s.cities[h.CityID].Name
+	h.Users = 0
+	for _, u := range s.users {
+		if u.HubID != nil && *u.HubID == h.HubID {
+			h.Users++
+		}
+	}
+	coverage := make([]models.HubCoverage, 0, len(h.Coverage))
+	for _, c := range h.Coverage {
+		if c.CityID != 0 {
+			c.CityName = s.cities[c.CityID].Name
+		}
+		if c.DepartmentID != 0 {
+			c.DepartmentName = s.departments[c.DepartmentID].Name
+		}
+		coverage = append(coverage, c)
+	}
+	h.Coverage = coverage
+	return h
+}
+
+// hubCovers indica si el hub cubre la ciudad, directamente o por su
+// departamento (requiere el mutex tomado)
+func (s *Store) hubCovers(h models.Hub, cityID int64) bool {
+	city, ok := s.cities[cityID]
+	if !ok {
+		city = models.City{ID: cityID}
+	}
+	return h.Covers(city)
+}
+
+// scopeHubs hubs activos dentro del alcance (requiere el mutex tomado)
+func (s *Store) scopeHubs(scope models.HubScope) []models.Hub {
+	var hubs []models.Hub
+	for _, h := range s.hubs {
+		if h.Active && scope.Includes(&h.HubID) {
+			hubs = append(hubs, h)
+		}
+	}
+	return hubs
+}
+
+// guideInScope indica si el hub del alcance cubre alguna de las ciudades
+// de la guía; en el consolidado siempre (requiere el mutex tomado)
+func (s *Store) guideInScope(scope models.HubScope, cityIDs ...int64) bool {
+	if scope.Consolidated() {
+		return true
+	}
+	h := s.hubs[*scope.HubID]
+	for _, cityID := range cityIDs {
+		if s.hubCovers(h, cityID) {
+			return true
+		}
+	}
+	return false
+}
+
+// userInScope indica si el usuario pertenece al hub del alcance
+// (requiere el mutex tomado)
+func (s *Store) userInScope(scope models.HubScope, userUUID string) bool {
+	return scope.Includes(s.users[userUUID].HubID)
+}
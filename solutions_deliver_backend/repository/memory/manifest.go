@@ -0,0 +1,299 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+)
+
+// ==========================================
+// Manifiestos de despacho (ManifestRepository)
+// ==========================================
+
+func (s *Store) CreateManifest(manifest *models.Manifest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	manifest.ManifestID = s.newID()
+	manifest.Status = models.ManifestOpen
+	manifest.CreatedAt = time.Now()
+	stored := *manifest
+	stored.Guides = nil
+	s.manifests[manifest.ManifestID] = stored
+	return nil
+}
+
+func (s *Store) GetManifests(filters models.ManifestFilters) ([]models.Manifest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var manifests []models.Manifest
+	for _, m := range s.manifests {
+		if filters.Status != "" && m.Status != filters.Status {
+			continue
+		}
+		if filters.Route != "" && !strings.EqualFold(m.Route, filters.Route) {
+			continue
+		}
+		if filters.DispatchDate != nil && m.DispatchDate.Format("2006-01-02") != filters.DispatchDate.Format("2006-01-02") {
+			continue
+		}
+		m = s.withManifestGuides(m)
+		m.Guides = nil
+		manifests = append(manifests, m)
+	}
+	sort.Slice(manifests, func(i, j int) bool {
+		if !manifests[i].DispatchDate.Equal(manifests[j].DispatchDate) {
+			return manifests[i].DispatchDate.After(manifests[j].DispatchDate)
+		}
+		return manifests[i].ManifestID > manifests[j].ManifestID
+	})
+	return manifests, nil
+}
+
+func (s *Store) GetManifestByID(manifestID int64) (models.Manifest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.manifests[manifestID]
+	if !ok {
+		return m, fmt.Errorf("Manifiesto no encontrado")
+	}
+	return s.withManifestGuides(m), nil
+}
+
+// withManifestGuides completa las guías del manifiesto y sus totales
+func (s *Store) withManifestGuides(m models.Manifest) models.Manifest {
+	m.Guides = nil
+	for _, g := range s.manifestGuides {
+		if g.ManifestID != m.ManifestID {
+			continue
+		}
+		guide := s.guides[g.GuideID]
+		g.GuideNumber = guide.GuideNumber
+		g.DestinationCityName = s.cities[guide.DestinationCityID].Name
+		if guide.Receiver != nil {
+			g.ReceiverName = guide.Receiver.FullName
+		}
+		g.Route = m.Route
+		m.Guides = append(m.Guides, g)
+	}
+	models.ApplyManifestTotals(&m)
+	return m
+}
+
+func (s *Store) GetPendingDispatchGuides(route string) ([]models.ManifestGuide, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var guides []models.ManifestGuide
+	for _, g := range s.sortedGuides() {
+		if g.CurrentStatus != models.StatusInWarehouse || s.dispatched(g.GuideID) ||
+			s.hasActiveAssignment(g.GuideID, models.AssignmentTransfer) || s.transferredToDestination(g) {
+			continue
+		}
+		city := s.cities[g.DestinationCityID]
+		if strings.ToUpper(city.Name) == "BOGOTÁ D.C." {
+			continue
+		}
+
+		guideRoute := s.guideRoute(g)
+		if route != "" && !strings.EqualFold(guideRoute, route) {
+			continue
+		}
+
+		g.DestinationCityName = city.Name
+		line := models.NewManifestGuide(g, guideRoute)
+		line.Status = ""
+		guides = append(guides, line)
+	}
+	sort.Slice(guides, func(i, j int) bool {
+		if guides[i].Route != guides[j].Route {
+			return guides[i].Route < guides[j].Route
+		}
+		return guides[i].GuideID < guides[j].GuideID
+	})
+	return guides, nil
+}
+
+// dispatched indica si la guía está en un manifiesto abierto o en ruta, o
+// si ya se recibió de uno (replica el NOT EXISTS de bd.GetPendingDispatchGuides)
+func (s *Store) dispatched(guideID int64) bool {
+	for _, g := range s.manifestGuides {
+		if g.GuideID != guideID {
+			continue
+		}
+		status := s.manifests[g.ManifestID].Status
+		if status == models.ManifestOpen || status == models.ManifestClosed ||
+			g.Status == models.ManifestGuideReceived || g.Status == models.ManifestGuidePartial {
+			return true
+		}
+	}
+	return false
+}
+
+// guideRoute ruta de la tarifa de la guía, o de la vigente al crearla
+func (s *Store) guideRoute(g models.ShippingGuide) string {
+	if g.RateID > 0 {
+		if i := s.rateIndex(g.RateID); i >= 0 {
+			return s.rates[i].Route
+		}
+	}
+	if rate, ok := s.shippingRate(g.OriginCityID, g.DestinationCityID, g.CreatedAt); ok {
+		return rate.Route
+	}
+	return ""
+}
+
+func (s *Store) GetActiveManifestByGuide(guideID int64) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.activeManifest(guideID), nil
+}
+
+func (s *Store) activeManifest(guideID int64) int64 {
+	for _, g := range s.manifestGuides {
+		status := s.manifests[g.ManifestID].Status
+		if g.GuideID == guideID && (status == models.ManifestOpen || status == models.ManifestClosed) {
+			return g.ManifestID
+		}
+	}
+	return 0
+}
+
+func (s *Store) AddManifestGuides(manifestID int64, guides []models.ManifestGuide, userUUID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.manifests[manifestID]
+	if !ok {
+		return fmt.Errorf("Manifiesto no encontrado")
+	}
+	if m.Status != models.ManifestOpen {
+		return fmt.Errorf("el manifiesto no está abierto")
+	}
+	for _, g := range guides {
+		if s.activeManifest(g.GuideID) != 0 {
+			return fmt.Errorf("la guía ya está en un manifiesto")
+		}
+	}
+
+	now := time.Now()
+	for _, g := range guides {
+		at := now
+		s.manifestGuides = append(s.manifestGuides, models.ManifestGuide{
+			ManifestID:    manifestID,
+			GuideID:       g.GuideID,
+			PaymentMethod: g.PaymentMethod,
+			Pieces:        g.Pieces,
+			WeightKg:      g.WeightKg,
+			DeclaredValue: g.DeclaredValue,
+			CODAmount:     g.CODAmount,
+			Status:        models.ManifestGuideLoaded,
+			AddedBy:       userUUID,
+			AddedAt:       &at,
+		})
+	}
+	return nil
+}
+
+func (s *Store) CloseManifest(manifestID int64, userUUID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.manifests[manifestID]
+	if !ok {
+		return fmt.Errorf("Manifiesto no encontrado")
+	}
+	if m.Status != models.ManifestOpen {
+		return fmt.Errorf("el manifiesto no está abierto")
+	}
+
+	var guideIDs []int64
+	for _, g := range s.manifestGuides {
+		if g.ManifestID == manifestID {
+			guideIDs = append(guideIDs, g.GuideID)
+		}
+	}
+	if len(guideIDs) == 0 {
+		return fmt.Errorf("el manifiesto no tiene guías")
+	}
+
+	// Validar todo antes de cambiar algo (en bd lo garantiza la transacción)
+	for _, guideID := range guideIDs {
+		guide := s.guides[guideID]
+		if _, ok := models.FindGuideTransition(guide.CurrentStatus, models.StatusInRoute); !ok {
+			return fmt.Errorf("la guía %s no se puede despachar: transición de estado no permitida", guide.GuideNumber)
+		}
+	}
+
+	notes := fmt.Sprintf("Despachada en el manifiesto %d", manifestID)
+	for _, guideID := range guideIDs {
+		if err := s.updateGuideStatus(guideID, models.StatusInRoute, "", notes, userUUID); err != nil {
+			return err
+		}
+		s.checkOutGuideItems(guideID, nil, notes, userUUID)
+	}
+
+	now := time.Now()
+	m.Status = models.ManifestClosed
+	m.ClosedBy = userUUID
+	m.ClosedAt = &now
+	s.manifests[manifestID] = m
+	return nil
+}
+
+func (s *Store) ReceiveManifest(manifestID int64, guides []models.ManifestGuide, notes string, userUUID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.manifests[manifestID]
+	if !ok {
+		return fmt.Errorf("Manifiesto no encontrado")
+	}
+	if m.Status != models.ManifestClosed {
+		return fmt.Errorf("el manifiesto no está en ruta")
+	}
+
+	for _, g := range guides {
+		if g.Status != models.ManifestGuideReceived && g.Status != models.ManifestGuidePartial {
+			continue
+		}
+		guide := s.guides[g.GuideID]
+		if _, ok := models.FindGuideTransition(guide.CurrentStatus, models.StatusInWarehouse); !ok {
+			return fmt.Errorf("la guía %s no se puede recibir: transición de estado no permitida", g.GuideNumber)
+		}
+	}
+
+	for _, g := range guides {
+		for i := range s.manifestGuides {
+			line := &s.manifestGuides[i]
+			if line.ManifestID == manifestID && line.GuideID == g.GuideID {
+				line.Status = g.Status
+				line.ReceivedPieces = g.ReceivedPieces
+			}
+		}
+
+		if g.Status != models.ManifestGuideReceived && g.Status != models.ManifestGuidePartial {
+			continue
+		}
+		historyNotes := fmt.Sprintf("Recibida del manifiesto %d", manifestID)
+		if g.Status == models.ManifestGuidePartial {
+			historyNotes += fmt.Sprintf(" (parcial: %d de %d piezas)", g.ReceivedPieces, g.Pieces)
+		}
+		if err := s.updateGuideStatus(g.GuideID, models.StatusInWarehouse, "", historyNotes, userUUID); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	m.Status = models.ManifestReceived
+	m.ReceivedBy = userUUID
+	m.ReceivedAt = &now
+	m.ReceiveNotes = notes
+	s.manifests[manifestID] = m
+	return nil
+}
@@ -0,0 +1,266 @@
+// Package memory implementa los repositorios en memoria para pruebas.
+// No requiere MySQL: los datos se cargan con los métodos Add* y viven
+// mientras exista el Store.
+package memory
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/repository"
+)
+
+// Store guarda todas las entidades en mapas protegidos por un mutex.
+// Implementa todas las interfaces de repository.
+type Store struct {
+	mu sync.Mutex
+
+	users          map[string]models.User
+	guides         map[int64]models.ShippingGuide
+	assignments    map[int64]models.DeliveryAssignment
+	assignmentLog  map[int64][]models.AssignmentHistory
+	attempts       []models.DeliveryAttempt
+	proofs         []models.DeliveryProof
+	handoverCodes  map[int64]models.HandoverCode
+	scans          []models.GuideScan
+	rateLimits     map[string]models.RateLimitHit
+	ratings        map[int64]models.DeliveryRating
+	cashCloses     map[int64]models.CashClose
+	cashDetails    map[int64][]models.CashCloseDetail
+	departments    map[int64]models.Department
+	cities         map[int64]models.City
+	frequentParty  []models.FrequentParty
+	rates          []models.ShippingRate
+	rateHistory    []models.RateChange
+	guidePDFKeys   map[int64]string
+	lastLogin      map[string]bool
+	nextID         int64
+	nextHistoryID  int64
+	nextGuideID    int64
+	guideNumbers   map[string]int64
+	statusRequests []StatusChange
+
+	warehouses      map[int64]models.Warehouse
+	bins            map[int64]models.WarehouseBin
+	stock           map[stockKey]models.StockItem
+	movements       []models.WarehouseMovement
+	inventoryCounts map[int64]models.InventoryCount
+	inventoryItems  []models.InventoryCountItem
+
+	manifests      map[int64]models.Manifest
+	manifestGuides []models.ManifestGuide
+
+	rndcSubmissions []models.RNDCSubmission
+
+	hubs map[int64]models.Hub
+}
+
+// StatusChange registra cada llamada a UpdateGuideStatus, útil para
+// verificar cascadas de estado en pruebas.
+type StatusChange struct {
+	GuideID int64
+	Status  models.GuideStatus
+	Reason  models.GuideStatusReason
+	Notes   string
+	UserID  string
+}
+
+// New crea un Store vacío
+func New() *Store {
+	return &Store{
+		users:         map[string]models.User{},
+		guides:        map[int64]models.ShippingGuide{},
+		assignments:   map[int64]models.DeliveryAssignment{},
+		assignmentLog: map[int64][]models.AssignmentHistory{},
+		handoverCodes: map[int64]models.HandoverCode{},
+		rateLimits:    map[string]models.RateLimitHit{},
+		ratings:       map[int64]models.DeliveryRating{},
+		cashCloses:    map[int64]models.CashClose{},
+		cashDetails:   map[int64][]models.CashCloseDetail{},
+		departments:   map[int64]models.Department{},
+		cities:        map[int64]models.City{},
+		guidePDFKeys:  map[int64]string{},
+		lastLogin:     map[string]bool{},
+		nextID:        1,
+		nextHistoryID: 1,
+		nextGuideID:   10000000,
+		guideNumbers:  map[string]int64{},
+
+		warehouses:      map[int64]models.Warehouse{},
+		bins:            map[int64]models.WarehouseBin{},
+		stock:           map[stockKey]models.StockItem{},
+		inventoryCounts: map[int64]models.InventoryCount{},
+
+		manifests: map[int64]models.Manifest{},
+
+		hubs: map[int64]models.Hub{},
+	}
+}
+
+// Repositories retorna el Store expuesto como todos los repositorios
+func (s *Store) Repositories() repository.Repositories {
+	return repository.Repositories{
+		Users:           s,
+		Guides:          s,
+		Clients:         s,
+		Assignments:     s,
+		Ratings:         s,
+		CashCloses:      s,
+		Locations:       s,
+		FrequentParties: s,
+		Admin:           s,
+		Rates:           s,
+		RateLimits:      s,
+		Warehouses:      s,
+		Manifests:       s,
+		RNDC:            s,
+		Hubs:            s,
+	}
+}
+
+// NewRepositories crea un Store vacío y retorna sus repositorios
+func NewRepositories() (repository.Repositories, *Store) {
+	s := New()
+	return s.Repositories(), s
+}
+
+// AddUser registra un usuario
+func (s *Store) AddUser(user models.User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[user.UserUUID] = user
+}
+
+// AddGuide registra una guía; si no trae ID se le asigna uno
+func (s *Store) AddGuide(guide models.ShippingGuide) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if guide.GuideID == 0 {
+		guide.GuideID = s.nextGuideID
+		s.nextGuideID++
+	}
+	s.guides[guide.GuideID] = guide
+	return guide.GuideID
+}
+
+// AddAssignment registra una asignación; si no trae ID se le asigna uno
+func (s *Store) AddAssignment(assignment models.DeliveryAssignment) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if assignment.AssignmentID == 0 {
+		assignment.AssignmentID = s.newID()
+	}
+	s.assignments[assignment.AssignmentID] = assignment
+	return assignment.AssignmentID
+}
+
+// AddDepartment registra un departamento
+func (s *Store) AddDepartment(department models.Department) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.departments[department.ID] = department
+}
+
+// AddCity registra una ciudad
+func (s *Store) AddCity(city models.City) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cities[city.ID] = city
+}
+
+// AddHub registra un hub activo con su cobertura; si no trae ID se le asigna uno
+func (s *Store) AddHub(hub models.Hub) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if hub.HubID == 0 {
+		hub.HubID = s.newID()
+	}
+	hub.Active = true
+	s.hubs[hub.HubID] = hub
+	return hub.HubID
+}
+
+// AddRate registra una tarifa de envío; si no trae ID se le asigna uno
+func (s *Store) AddRate(rate models.ShippingRate) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if rate.ID == 0 {
+		rate.ID = s.newID()
+	}
+	if rate.Status == "" {
+		rate.Status = models.RateActive
+	}
+	s.rates = append(s.rates, rate)
+	return rate.ID
+}
+
+// SetGuidePDFKey asocia la llave S3 del PDF de una guía
+func (s *Store) SetGuidePDFKey(guideID int64, key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.guidePDFKeys[guideID] = key
+}
+
+// Guide retorna la guía almacenada (para verificar en pruebas)
+func (s *Store) Guide(guideID int64) (models.ShippingGuide, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	guide, ok := s.guides[guideID]
+	return guide, ok
+}
+
+// StatusChanges retorna las llamadas registradas a UpdateGuideStatus
+func (s *Store) StatusChanges() []StatusChange {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]StatusChange(nil), s.statusRequests...)
+}
+
+// newID genera IDs secuenciales; se llama con el mutex tomado
+func (s *Store) newID() int64 {
+	id := s.nextID
+	s.nextID++
+	return id
+}
+
+// paginate aplica limit/offset sobre un slice ya ordenado
+func paginate[T any](items []T, limit, offset int) []T {
+	if offset > len(items) {
+		return []T{}
+	}
+	items = items[offset:]
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
+
+// sortedGuides retorna las guías ordenadas de la más reciente a la más antigua
+func (s *Store) sortedGuides() []models.ShippingGuide {
+	guides := make([]models.ShippingGuide, 0, len(s.guides))
+	for _, g := range s.guides {
+		guides = append(guides, g)
+	}
+	sort.Slice(guides, func(i, j int) bool {
+		if guides[i].CreatedAt.Equal(guides[j].CreatedAt) {
+			return guides[i].GuideID > guides[j].GuideID
+		}
+		return guides[i].CreatedAt.After(guides[j].CreatedAt)
+	})
+	return guides
+}
+
+// Verificación en compilación de que Store implementa todas las interfaces
+var (
+	_ repository.UserRepository          = (*Store)(nil)
+	_ repository.GuideRepository         = (*Store)(nil)
+	_ repository.ClientRepository        = (*Store)(nil)
+	_ repository.AssignmentRepository    = (*Store)(nil)
+	_ repository.RatingRepository        = (*Store)(nil)
+	_ repository.CashCloseRepository     = (*Store)(nil)
+	_ repository.LocationRepository      = (*Store)(nil)
+	_ repository.FrequentPartyRepository = (*Store)(nil)
+	_ repository.AdminRepository         = (*Store)(nil)
+	_ repository.RateRepository          = (*Store)(nil)
+)
@@ -0,0 +1,25 @@
+package memory
+
+import (
+	"time"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+)
+
+// ==========================================
+// RateLimitRepository
+// ==========================================
+
+func (s *Store) HitRateLimit(bucket string, window time.Duration) (models.RateLimitHit, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	hit, ok := s.rateLimits[bucket]
+	if !ok || !now.Before(hit.ResetAt) {
+		hit = models.RateLimitHit{ResetAt: now.Add(window)}
+	}
+	hit.Count++
+	s.rateLimits[bucket] = hit
+	return hit, nil
+}
@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+)
+
+// ==========================================
+// Envíos al RNDC (RNDCRepository)
+// ==========================================
+
+func (s *Store) SaveRNDCSubmission(submission *models.RNDCSubmission) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	submission.SubmissionID = s.newID()
+	submission.SubmittedAt = time.Now()
+	s.rndcSubmissions = append(s.rndcSubmissions, *submission)
+
+	if !submission.Success {
+		return nil
+	}
+	switch {
+	case submission.Process == models.RNDCRemesa && submission.GuideID != nil:
+		if guide, ok := s.guides[*submission.GuideID]; ok {
+			guide.RNDCRemesaID = submission.IngresoID
+			s.guides[guide.GuideID] = guide
+		}
+	case submission.Process == models.RNDCManifiesto && submission.ManifestID != nil:
+		if m, ok := s.manifests[*submission.ManifestID]; ok {
+			m.RNDCManifestID = submission.IngresoID
+			s.manifests[m.ManifestID] = m
+		}
+		for _, line := range s.manifestGuides {
+			if line.ManifestID != *submission.ManifestID {
+				continue
+			}
+			if guide, ok := s.guides[line.GuideID]; ok {
+				guide.RNDCManifestID = submission.IngresoID
+				s.guides[guide.GuideID] = guide
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Store) GetRNDCSubmissions(filters models.RNDCFilters) ([]models.RNDCSubmission, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var submissions []models.RNDCSubmission
+	for _, sub := range s.rndcSubmissions {
+		if filters.GuideID != nil && (sub.GuideID == nil || *sub.GuideID != *filters.GuideID) {
+			continue
+		}
+		if filters.ManifestID != nil && (sub.ManifestID == nil || *sub.ManifestID != *filters.ManifestID) {
+			continue
+		}
+		submissions = append(submissions, sub)
+	}
+	sort.SliceStable(submissions, func(i, j int) bool {
+		return submissions[i].SubmissionID > submissions[j].SubmissionID
+	})
+	return submissions, nil
+}
@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+)
+
+// ==========================================
+// Escaneos de guías (GuideRepository)
+// ==========================================
+
+func (s *Store) RecordGuideScan(scan *models.GuideScan) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.guides[scan.GuideID]; !ok {
+		return fmt.Errorf("Guía no encontrada")
+	}
+	scan.ScanID = s.newID()
+	scan.ScannedAt = time.Now()
+	s.scans = append(s.scans, *scan)
+	return nil
+}
+
+func (s *Store) GetRecentGuideScan(guideID int64, pieceNumber int, point models.ScanPoint, window time.Duration) (models.GuideScan, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	since := time.Now().Add(-window)
+	for i := len(s.scans) - 1; i >= 0; i-- {
+		scan := s.scans[i]
+		if scan.GuideID == guideID && scan.PieceNumber == pieceNumber && scan.ScanPoint == point && !scan.ScannedAt.Before(since) {
+			return scan, nil
+		}
+	}
+	return models.GuideScan{}, fmt.Errorf("Escaneo no encontrado")
+}
+
+func (s *Store) UpdatePieceScanStatus(guideID int64, pieceNumber int, status models.GuideStatus, point models.ScanPoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	guide, ok := s.guides[guideID]
+	if !ok {
+		return fmt.Errorf("Guía no encontrada")
+	}
+
+	now := time.Now()
+	pieces := append([]models.GuidePiece(nil), guide.Pieces...)
+	for i := range pieces {
+		if pieceNumber == 0 || pieces[i].PieceNumber == pieceNumber {
+			pieces[i].Status = status
+			pieces[i].LastScanPoint = point
+			pieces[i].LastScannedAt = &now
+		}
+	}
+	guide.Pieces = pieces
+	s.guides[guideID] = guide
+	return nil
+}
+
+// withPartial calcula el indicador de piezas en estados distintos
+func withPartial(guide models.ShippingGuide) models.ShippingGuide {
+	guide.Partial = !guide.CurrentStatus.IsFinal() && models.PiecesPartial(guide.Pieces)
+	return guide
+}
+
+// Scans retorna los escaneos registrados (para verificar en pruebas)
+func (s *Store) Scans() []models.GuideScan {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]models.GuideScan(nil), s.scans...)
+}
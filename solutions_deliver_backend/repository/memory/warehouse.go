@@ -0,0 +1,462 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+)
+
+// ==========================================
+// Bodegas, bins y conteos (WarehouseRepository)
+// ==========================================
+
+// stockKey ubicación de una guía (pieza 0) o de una de sus piezas
+type stockKey struct {
+	guideID int64
+	piece   int
+}
+
+func (s *Store) CreateWarehouse(warehouse *models.Warehouse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, w := range s.warehouses {
+		if w.Code == warehouse.Code {
+			return fmt.Errorf("ya existe una bodega con ese código")
+		}
+	}
+	warehouse.WarehouseID = s.newID()
+	warehouse.Active = true
+	warehouse.CreatedAt = time.Now()
+	if city, ok := s.cities[warehouse.CityID]; ok {
+		warehouse.CityName = city.Name
+	}
+	s.warehouses[warehouse.WarehouseID] = *warehouse
+	return nil
+}
+
+func (s *Store) GetWarehouses() ([]models.Warehouse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var warehouses []models.Warehouse
+	for _, w := range s.warehouses {
+		warehouses = append(warehouses, w)
+	}
+	sort.Slice(warehouses, func(i, j int) bool { return warehouses[i].Code < warehouses[j].Code })
+	return warehouses, nil
+}
+
+func (s *Store) GetWarehouseByID(warehouseID int64) (models.Warehouse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, ok := s.warehouses[warehouseID]
+	if !ok {
+		return w, fmt.Errorf("Bodega no encontrada")
+	}
+	return w, nil
+}
+
+func (s *Store) CreateBin(bin *models.WarehouseBin) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, b := range s.bins {
+		if b.WarehouseID == bin.WarehouseID && b.Code == bin.Code {
+			return fmt.Errorf("ya existe un bin con ese código en la bodega")
+		}
+	}
+	bin.BinID = s.newID()
+	bin.Active = true
+	bin.CreatedAt = time.Now()
+	s.bins[bin.BinID] = *bin
+	return nil
+}
+
+func (s *Store) GetBins(warehouseID int64) ([]models.WarehouseBin, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var bins []models.WarehouseBin
+	for _, b := range s.bins {
+		if b.WarehouseID == warehouseID {
+			bins = append(bins, s.withItems(b))
+		}
+	}
+	sort.Slice(bins, func(i, j int) bool { return bins[i].Code < bins[j].Code })
+	return bins, nil
+}
+
+func (s *Store) GetBinByID(binID int64) (models.WarehouseBin, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.bins[binID]
+	if !ok {
+		return b, fmt.Errorf("Bin no encontrado")
+	}
+	return s.withItems(b), nil
+}
+
+func (s *Store) withItems(bin models.WarehouseBin) models.WarehouseBin {
+	bin.Items = 0
+	for _, item := range s.stock {
+		if item.BinID == bin.BinID {
+			bin.Items++
+		}
+	}
+	return bin
+}
+
+func (s *Store) GetStock(filters models.StockFilters) ([]models.StockItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []models.StockItem
+	for _, raw := range s.stock {
+		item := s.stockItem(raw)
+		if filters.WarehouseID != nil && item.WarehouseID != *filters.WarehouseID {
+			continue
+		}
+		if filters.BinID != nil && item.BinID != *filters.BinID {
+			continue
+		}
+		if filters.GuideID != nil && item.GuideID != *filters.GuideID {
+			continue
+		}
+		items = append(items, item)
+	}
+	sortStock(items)
+	return items, nil
+}
+
+// stockItem completa la ubicación con los datos de la guía, la pieza y el bin
+func (s *Store) stockItem(item models.StockItem) models.StockItem {
+	if g, ok := s.guides[item.GuideID]; ok {
+		item.GuideNumber = g.GuideNumber
+		item.CurrentStatus = g.CurrentStatus
+		if piece, ok := models.FindPiece(g.Pieces, item.PieceNumber); ok {
+			item.PieceCode = piece.PieceCode
+		}
+	}
+	if b, ok := s.bins[item.BinID]; ok {
+		item.BinCode = b.Code
+		item.WarehouseID = b.WarehouseID
+		item.WarehouseCode = s.warehouses[b.WarehouseID].Code
+	}
+	return item
+}
+
+func sortStock(items []models.StockItem) {
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].BinCode != items[j].BinCode {
+			return items[i].BinCode < items[j].BinCode
+		}
+		if items[i].GuideID != items[j].GuideID {
+			return items[i].GuideID < items[j].GuideID
+		}
+		return items[i].PieceNumber < items[j].PieceNumber
+	})
+}
+
+func (s *Store) LocateGuideItems(guideID int64, pieces []int, binID int64, movement models.MovementType, notes string, userUUID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.bins[binID]; !ok {
+		return fmt.Errorf("Bin no encontrado")
+	}
+
+	now := time.Now()
+	for _, piece := range pieces {
+		key := stockKey{guideID: guideID, piece: piece}
+		current, located := s.stock[key]
+		if located && current.BinID == binID {
+			continue
+		}
+
+		m := models.WarehouseMovement{
+			MovementID:   s.newID(),
+			GuideID:      guideID,
+			PieceNumber:  piece,
+			MovementType: movement,
+			ToBinID:      &binID,
+			ToBinCode:    s.bins[binID].Code,
+			Notes:        notes,
+			MovedBy:      userUUID,
+			MovedAt:      now,
+		}
+		if located {
+			from := current.BinID
+			m.FromBinID = &from
+			m.FromBinCode = s.bins[from].Code
+		} else if movement == models.MovementMove {
+			m.MovementType = models.MovementCheckIn
+		}
+
+		at := now
+		s.stock[key] = models.StockItem{GuideID: guideID, PieceNumber: piece, BinID: binID, LocatedBy: userUUID, LocatedAt: &at}
+		s.movements = append(s.movements, m)
+	}
+	return nil
+}
+
+func (s *Store) CheckOutGuideItems(guideID int64, pieces []int, notes string, userUUID string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.checkOutGuideItems(guideID, pieces, notes, userUUID), nil
+}
+
+// checkOutGuideItems replica bd.checkOutGuideItemsTx (requiere el mutex tomado)
+func (s *Store) checkOutGuideItems(guideID int64, pieces []int, notes string, userUUID string) int {
+	if pieces == nil {
+		for key := range s.stock {
+			if key.guideID == guideID {
+				pieces = append(pieces, key.piece)
+			}
+		}
+		sort.Ints(pieces)
+	}
+
+	removed := 0
+	for _, piece := range pieces {
+		key := stockKey{guideID: guideID, piece: piece}
+		current, ok := s.stock[key]
+		if !ok {
+			continue
+		}
+		from := current.BinID
+		s.movements = append(s.movements, models.WarehouseMovement{
+			MovementID:   s.newID(),
+			GuideID:      guideID,
+			PieceNumber:  piece,
+			MovementType: models.MovementCheckOut,
+			FromBinID:    &from,
+			FromBinCode:  s.bins[from].Code,
+			Notes:        notes,
+			MovedBy:      userUUID,
+			MovedAt:      time.Now(),
+		})
+		delete(s.stock, key)
+		removed++
+	}
+	return removed
+}
+
+func (s *Store) GetGuideMovements(guideID int64) ([]models.WarehouseMovement, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var movements []models.WarehouseMovement
+	for _, m := range s.movements {
+		if m.GuideID == guideID {
+			movements = append(movements, m)
+		}
+	}
+	return movements, nil
+}
+
+// guideBins códigos de los bins donde está ubicada la guía (con s.mu tomado)
+func (s *Store) guideBins(guideID int64) []string {
+	seen := map[string]bool{}
+	var bins []string
+	for key, item := range s.stock {
+		code := s.bins[item.BinID].Code
+		if key.guideID == guideID && !seen[code] {
+			seen[code] = true
+			bins = append(bins, code)
+		}
+	}
+	sort.Strings(bins)
+	return bins
+}
+
+func (s *Store) GetWarehouseAging(filters models.AgingFilters) ([]models.AgingItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var items []models.AgingItem
+	for _, g := range s.sortedGuides() {
+		if g.CurrentStatus != models.StatusInWarehouse {
+			continue
+		}
+
+		since := g.UpdatedAt
+		for _, h := range g.History {
+			if h.Status == models.StatusInWarehouse && h.UpdatedAt.After(since) {
+				since = h.UpdatedAt
+			}
+		}
+		if since.After(now.AddDate(0, 0, -filters.MinDays)) {
+			continue
+		}
+
+		item := models.AgingItem{
+			GuideID:             g.GuideID,
+			GuideNumber:         g.GuideNumber,
+			ServiceType:         string(g.ServiceType),
+			DestinationCityName: g.DestinationCityName,
+			InWarehouseSince:    since,
+			DaysInWarehouse:     int(now.Sub(since).Hours() / 24),
+			Pieces:              len(g.Pieces),
+			Bins:                s.guideBins(g.GuideID),
+		}
+		if item.Pieces == 0 {
+			item.Pieces = 1
+		}
+		if g.Receiver != nil {
+			item.ReceiverName = g.Receiver.FullName
+		}
+
+		inWarehouse := filters.WarehouseID == nil
+		for key, stock := range s.stock {
+			if key.guideID != g.GuideID {
+				continue
+			}
+			item.LocatedPieces++
+			if filters.WarehouseID != nil && s.bins[stock.BinID].WarehouseID == *filters.WarehouseID {
+				inWarehouse = true
+			}
+		}
+		if inWarehouse {
+			items = append(items, item)
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool { return items[i].InWarehouseSince.Before(items[j].InWarehouseSince) })
+	return items, nil
+}
+
+func (s *Store) CreateInventoryCount(count *models.InventoryCount) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, c := range s.inventoryCounts {
+		if c.WarehouseID == count.WarehouseID && c.Status == models.InventoryCountOpen {
+			return fmt.Errorf("ya hay un conteo abierto en la bodega")
+		}
+	}
+	count.CountID = s.newID()
+	count.Status = models.InventoryCountOpen
+	count.StartedAt = time.Now()
+	s.inventoryCounts[count.CountID] = *count
+	return nil
+}
+
+func (s *Store) GetInventoryCount(countID int64) (models.InventoryCount, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.inventoryCounts[countID]
+	if !ok {
+		return c, fmt.Errorf("Conteo no encontrado")
+	}
+	return c, nil
+}
+
+func (s *Store) AddInventoryCountItems(items []models.InventoryCountItem) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range items {
+		items[i].ItemID = s.newID()
+		items[i].ScannedAt = time.Now()
+		s.inventoryItems = append(s.inventoryItems, items[i])
+	}
+	return nil
+}
+
+func (s *Store) GetInventoryCountItems(countID int64) ([]models.InventoryCountItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []models.InventoryCountItem
+	for _, item := range s.inventoryItems {
+		if item.CountID == countID {
+			item.BinCode = s.bins[item.BinID].Code
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+func (s *Store) GetExpectedInventory(warehouseID int64) ([]models.StockItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []models.StockItem
+	for _, g := range s.sortedGuides() {
+		if g.CurrentStatus != models.StatusInWarehouse {
+			continue
+		}
+
+		pieces := []int{0}
+		if len(g.Pieces) > 0 {
+			pieces = pieces[:0]
+			for _, p := range g.Pieces {
+				pieces = append(pieces, p.PieceNumber)
+			}
+		}
+
+		for _, piece := range pieces {
+			item := models.StockItem{GuideID: g.GuideID, PieceNumber: piece}
+			if located, ok := s.stock[stockKey{guideID: g.GuideID, piece: piece}]; ok {
+				item = located
+			}
+			item = s.stockItem(item)
+			if item.BinID != 0 && item.WarehouseID != warehouseID {
+				continue
+			}
+			items = append(items, item)
+		}
+	}
+	sortStock(items)
+	return items, nil
+}
+
+func (s *Store) CloseInventoryCount(countID int64, applied bool, userUUID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.inventoryCounts[countID]
+	if !ok {
+		return fmt.Errorf("Conteo no encontrado")
+	}
+	if c.Status != models.InventoryCountOpen {
+		return fmt.Errorf("el conteo ya está cerrado")
+	}
+
+	now := time.Now()
+	c.Status = models.InventoryCountClosed
+	c.Applied = applied
+	c.ClosedBy = userUUID
+	c.ClosedAt = &now
+	s.inventoryCounts[countID] = c
+	return nil
+}
+
+// AddWarehouse registra una bodega; si no trae ID se le asigna uno
+func (s *Store) AddWarehouse(warehouse models.Warehouse) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if warehouse.WarehouseID == 0 {
+		warehouse.WarehouseID = s.newID()
+	}
+	s.warehouses[warehouse.WarehouseID] = warehouse
+	return warehouse.WarehouseID
+}
+
+// AddBin registra un bin; si no trae ID se le asigna uno
+func (s *Store) AddBin(bin models.WarehouseBin) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if bin.BinID == 0 {
+		bin.BinID = s.newID()
+	}
+	s.bins[bin.BinID] = bin
+	return bin.BinID
+}
@@ -0,0 +1,503 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/bd"
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+)
+
+// NewMySQLRepositories retorna los repositorios respaldados por MySQL (paquete bd)
+func NewMySQLRepositories() Repositories {
+	return Repositories{
+		Users:           mysqlUserRepository{},
+		Guides:          mysqlGuideRepository{},
+		Clients:         mysqlClientRepository{},
+		Assignments:     mysqlAssignmentRepository{},
+		Ratings:         mysqlRatingRepository{},
+		CashCloses:      mysqlCashCloseRepository{},
+		Locations:       mysqlLocationRepository{},
+		FrequentParties: mysqlFrequentPartyRepository{},
+		Admin:           mysqlAdminRepository{},
+		Rates:           mysqlRateRepository{},
+		RateLimits:      mysqlRateLimitRepository{},
+		Warehouses:      mysqlWarehouseRepository{},
+		Manifests:       mysqlManifestRepository{},
+		RNDC:            mysqlRNDCRepository{},
+		Hubs:            mysqlHubRepository{},
+	}
+}
+
+type mysqlUserRepository struct{}
+
+func (mysqlUserRepository) GetUserRole(userUUID string) (models.User, error) {
+	return bd.GetUserRole(userUUID)
+}
+
+func (mysqlUserRepository) UpdateLastLogin(userUUID string) error {
+	return bd.UpdateLastLogin(userUUID)
+}
+
+func (mysqlUserRepository) GetUserProfile(userUUID string) (models.ClientProfile, error) {
+	return bd.GetUserProfile(userUUID)
+}
+
+func (mysqlUserRepository) UpdateUserProfile(userUUID string, updateData models.ClientProfileUpdate) (models.ClientProfile, error) {
+	return bd.UpdateUserProfile(userUUID, updateData)
+}
+
+type mysqlGuideRepository struct{}
+
+func (mysqlGuideRepository) GetGuideByID(guideID int64) (models.ShippingGuide, error) {
+	return bd.GetGuideByID(guideID)
+}
+
+func (mysqlGuideRepository) GetGuidesByFilters(filters models.GuideFilters) ([]models.ShippingGuide, int, error) {
+	return bd.GetGuidesByFilters(filters)
+}
+
+func (mysqlGuideRepository) GetGuideStats(userUUID string) (models.GuideStatsResponse, error) {
+	return bd.GetGuideStats(userUUID)
+}
+
+func (mysqlGuideRepository) UpdateGuideStatus(guideID int64, status models.GuideStatus, reason models.GuideStatusReason, notes string, userUUID string) error {
+	return bd.UpdateGuideStatus(guideID, status, reason, notes, userUUID)
+}
+
+func (mysqlGuideRepository) GuideExists(guideID int64) bool {
+	return bd.GuideExists(guideID)
+}
+
+func (mysqlGuideRepository) GetGuidePDFInfo(guideID int64) (string, error) {
+	return bd.GetGuidePDFInfo(guideID)
+}
+
+func (mysqlGuideRepository) ValidateGuideAccess(guideID int64, userUUID string) (bool, error) {
+	return bd.ValidateGuideAccess(guideID, userUUID)
+}
+
+func (mysqlGuideRepository) CreateGuide(guide *models.ShippingGuide, numberPrefix string, userUUID string) error {
+	return bd.CreateGuide(guide, numberPrefix, userUUID)
+}
+
+func (mysqlGuideRepository) GetGuideIDByNumber(guideNumber string) (int64, error) {
+	return bd.GetGuideIDByNumber(guideNumber)
+}
+
+func (mysqlGuideRepository) UpdateGuidePDF(guideID int64, pdfURL, pdfS3Key string) error {
+	return bd.UpdateGuidePDF(guideID, pdfURL, pdfS3Key)
+}
+
+func (mysqlGuideRepository) RecordGuideScan(scan *models.GuideScan) error {
+	return bd.RecordGuideScan(scan)
+}
+
+func (mysqlGuideRepository) GetRecentGuideScan(guideID int64, pieceNumber int, point models.ScanPoint, window time.Duration) (models.GuideScan, error) {
+	return bd.GetRecentGuideScan(guideID, pieceNumber, point, window)
+}
+
+func (mysqlGuideRepository) UpdatePieceScanStatus(guideID int64, pieceNumber int, status models.GuideStatus, point models.ScanPoint) error {
+	return bd.UpdatePieceScanStatus(guideID, pieceNumber, status, point)
+}
+
+type mysqlClientRepository struct{}
+
+func (mysqlClientRepository) GetClientActiveGuides(userUUID string) ([]models.ShippingGuide, error) {
+	return bd.GetClientActiveGuides(userUUID)
+}
+
+func (mysqlClientRepository) GetClientGuideHistory(filters models.ClientGuideFilters) ([]models.ShippingGuide, error) {
+	return bd.GetClientGuideHistory(filters)
+}
+
+func (mysqlClientRepository) GetClientStats(userUUID string) (models.ClientStats, error) {
+	return bd.GetClientStats(userUUID)
+}
+
+type mysqlAssignmentRepository struct{}
+
+func (mysqlAssignmentRepository) CreateAssignment(req models.CreateAssignmentRequest, assignedBy string) (models.DeliveryAssignment, error) {
+	return bd.CreateAssignment(req, assignedBy)
+}
+
+func (mysqlAssignmentRepository) GetAssignmentByID(assignmentID int64) (models.DeliveryAssignment, error) {
+	return bd.GetAssignmentByID(assignmentID)
+}
+
+func (mysqlAssignmentRepository) GetAssignmentsByFilters(filters models.AssignmentFilters) ([]models.DeliveryAssignment, int, error) {
+	return bd.GetAssignmentsByFilters(filters)
+}
+
+func (mysqlAssignmentRepository) ReassignDelivery(assignmentID int64, newDeliveryUserID string, notes string, changedBy string) (models.DeliveryAssignment, error) {
+	return bd.ReassignDelivery(assignmentID, newDeliveryUserID, notes, changedBy)
+}
+
+func (mysqlAssignmentRepository) UpdateAssignmentStatus(assignmentID int64, newStatus models.AssignmentStatus, notes string, changedBy string, guideChange *models.GuideStatusChange) (models.DeliveryAssignment, error) {
+	return bd.UpdateAssignmentStatus(assignmentID, newStatus, notes, changedBy, guideChange)
+}
+
+func (mysqlAssignmentRepository) GetAssignmentHistory(assignmentID int64) ([]models.AssignmentHistory, error) {
+	return bd.GetAssignmentHistory(assignmentID)
+}
+
+func (mysqlAssignmentRepository) GetAssignmentStats(scope models.HubScope) (models.AssignmentStatsResponse, error) {
+	return bd.GetAssignmentStats(scope)
+}
+
+func (mysqlAssignmentRepository) GetMyAssignments(deliveryUserID string) (models.MyAssignmentsResponse, error) {
+	return bd.GetMyAssignments(deliveryUserID)
+}
+
+func (mysqlAssignmentRepository) GetDeliveryUsers(scope models.HubScope) ([]models.DeliveryUser, error) {
+	return bd.GetDeliveryUsers(scope)
+}
+
+func (mysqlAssignmentRepository) GetPendingPickups(scope models.HubScope) ([]models.PendingGuide, error) {
+	return bd.GetPendingPickups(scope)
+}
+
+func (mysqlAssignmentRepository) GetPendingDeliveries(scope models.HubScope) ([]models.PendingGuide, error) {
+	return bd.GetPendingDeliveries(scope)
+}
+
+func (mysqlAssignmentRepository) GetPendingTransfers(scope models.HubScope) ([]models.PendingGuide, error) {
+	return bd.GetPendingTransfers(scope)
+}
+
+func (mysqlAssignmentRepository) RegisterDeliveryAttempt(assignmentID int64, req models.RegisterAttemptRequest, maxAttempts int, nextDate time.Time, userUUID string) (models.DeliveryAttemptResult, error) {
+	return bd.RegisterDeliveryAttempt(assignmentID, req, maxAttempts, nextDate, userUUID)
+}
+
+func (mysqlAssignmentRepository) GetGuideDeliveryAttempts(guideID int64) ([]models.DeliveryAttempt, error) {
+	return bd.GetGuideDeliveryAttempts(guideID)
+}
+
+func (mysqlAssignmentRepository) CompleteDeliveryWithProof(assignmentID int64, proof models.DeliveryProofRequest, notes string, changedBy string, guideChange *models.GuideStatusChange) (models.DeliveryAssignment, error) {
+	return bd.CompleteDeliveryWithProof(assignmentID, proof, notes, changedBy, guideChange)
+}
+
+func (mysqlAssignmentRepository) GetDeliveryProofByGuide(guideID int64) (models.DeliveryProof, error) {
+	return bd.GetDeliveryProofByGuide(guideID)
+}
+
+func (mysqlAssignmentRepository) SaveHandoverCode(code models.HandoverCode, changedBy string) error {
+	return bd.SaveHandoverCode(code, changedBy)
+}
+
+func (mysqlAssignmentRepository) GetHandoverCode(assignmentID int64) (models.HandoverCode, error) {
+	return bd.GetHandoverCode(assignmentID)
+}
+
+func (mysqlAssignmentRepository) VerifyHandoverCode(assignmentID int64, codeHash string, maxAttempts int, lockout time.Duration, changedBy string) (models.HandoverVerification, error) {
+	return bd.VerifyHandoverCode(assignmentID, codeHash, maxAttempts, lockout, changedBy)
+}
+
+func (mysqlAssignmentRepository) OverrideHandoverCode(assignmentID int64, guideID int64, reason string, adminUUID string) error {
+	return bd.OverrideHandoverCode(assignmentID, guideID, reason, adminUUID)
+}
+
+type mysqlRatingRepository struct{}
+
+func (mysqlRatingRepository) CreateDeliveryRating(req models.CreateRatingRequest, clientUserID string) (models.DeliveryRating, error) {
+	return bd.CreateDeliveryRating(req, clientUserID)
+}
+
+func (mysqlRatingRepository) GetRatingByAssignmentID(assignmentID int64) (models.DeliveryRating, error) {
+	return bd.GetRatingByAssignmentID(assignmentID)
+}
+
+func (mysqlRatingRepository) GetDeliveryUserRatings(deliveryUserID string, limit int) ([]models.DeliveryRating, int, float64, error) {
+	return bd.GetDeliveryUserRatings(deliveryUserID, limit)
+}
+
+func (mysqlRatingRepository) GetDeliveryPerformanceStats(deliveryUserID string) (models.DeliveryPerformanceStats, error) {
+	return bd.GetDeliveryPerformanceStats(deliveryUserID)
+}
+
+func (mysqlRatingRepository) GetClientPendingRatings(clientUserID string) ([]models.ClientPendingRating, error) {
+	return bd.GetClientPendingRatings(clientUserID)
+}
+
+type mysqlCashCloseRepository struct{}
+
+func (mysqlCashCloseRepository) CreateCashClose(close *models.CashClose) (int64, error) {
+	return bd.CreateCashClose(close)
+}
+
+func (mysqlCashCloseRepository) CreateCashCloseDetail(detail *models.CashCloseDetail) error {
+	return bd.CreateCashCloseDetail(detail)
+}
+
+func (mysqlCashCloseRepository) GetAllCashCloses(limit, offset int, scope models.HubScope) ([]models.CashClose, int, error) {
+	return bd.GetAllCashCloses(limit, offset, scope)
+}
+
+func (mysqlCashCloseRepository) GetCashCloseByID(closeID int64) (models.CashClose, error) {
+	return bd.GetCashCloseByID(closeID)
+}
+
+func (mysqlCashCloseRepository) GetCashCloseDetails(closeID int64) ([]models.CashCloseDetail, error) {
+	return bd.GetCashCloseDetails(closeID)
+}
+
+func (mysqlCashCloseRepository) GetCashCloseStats(scope models.HubScope) (models.CashCloseStatsResponse, error) {
+	return bd.GetCashCloseStats(scope)
+}
+
+func (mysqlCashCloseRepository) UpdateCashClosePDF(closeID int64, pdfURL, pdfS3Key string) error {
+	return bd.UpdateCashClosePDF(closeID, pdfURL, pdfS3Key)
+}
+
+func (mysqlCashCloseRepository) GetGuidesForCashClose(startDate, endDate time.Time, scope models.HubScope) ([]models.CashCloseDetail, error) {
+	return bd.GetGuidesForCashClose(startDate, endDate, scope)
+}
+
+type mysqlLocationRepository struct{}
+
+func (mysqlLocationRepository) GetAllDepartments() ([]models.Department, error) {
+	return bd.GetAllDepartments()
+}
+
+func (mysqlLocationRepository) DepartmentExists(departmentID int64) bool {
+	return bd.DepartmentExists(departmentID)
+}
+
+func (mysqlLocationRepository) GetAllCities() ([]models.City, error) {
+	return bd.GetAllCities()
+}
+
+func (mysqlLocationRepository) GetCitiesByDepartment(departmentID int64) ([]models.City, error) {
+	return bd.GetCitiesByDepartment(departmentID)
+}
+
+func (mysqlLocationRepository) GetCityByID(cityID int64) (models.City, error) {
+	return bd.GetCityByID(cityID)
+}
+
+func (mysqlLocationRepository) SearchCities(searchTerm string) ([]models.City, error) {
+	return bd.SearchCities(searchTerm)
+}
+
+type mysqlFrequentPartyRepository struct{}
+
+func (mysqlFrequentPartyRepository) SearchFrequentPartiesByNameOnly(searchTerm string, partyType models.PartyType) ([]models.FrequentPartyUnique, int, error) {
+	return bd.SearchFrequentPartiesByNameOnly(searchTerm, partyType)
+}
+
+func (mysqlFrequentPartyRepository) SearchFrequentPartiesByNameAndCity(searchTerm string, cityID int64, partyType models.PartyType) ([]models.FrequentParty, int, error) {
+	return bd.SearchFrequentPartiesByNameAndCity(searchTerm, cityID, partyType)
+}
+
+func (mysqlFrequentPartyRepository) GetFrequentPartiesByDocument(documentNumber string, cityID int64, partyType models.PartyType) ([]models.FrequentParty, int, error) {
+	return bd.GetFrequentPartiesByDocument(documentNumber, cityID, partyType)
+}
+
+func (mysqlFrequentPartyRepository) UpsertFrequentParty(req models.CreateFrequentPartyRequest) error {
+	return bd.UpsertFrequentParty(req)
+}
+
+func (mysqlFrequentPartyRepository) GetFrequentPartyStats() (map[string]interface{}, error) {
+	return bd.GetFrequentPartyStats()
+}
+
+type mysqlAdminRepository struct{}
+
+func (mysqlAdminRepository) GetAdminDashboardStats(scope models.HubScope) (models.AdminDashboardStats, error) {
+	return bd.GetAdminDashboardStats(scope)
+}
+
+func (mysqlAdminRepository) GetEmployees(role string, scope models.HubScope) ([]models.Employee, error) {
+	return bd.GetEmployees(role, scope)
+}
+
+func (mysqlAdminRepository) GetEmployeeByID(userUUID string) (models.Employee, error) {
+	return bd.GetEmployeeByID(userUUID)
+}
+
+func (mysqlAdminRepository) GetUserByDocument(documentNumber string) (models.Employee, error) {
+	return bd.GetUserByDocument(documentNumber)
+}
+
+func (mysqlAdminRepository) UpdateEmployee(userUUID string, req models.UpdateEmployeeRequest) error {
+	return bd.UpdateEmployee(userUUID, req)
+}
+
+func (mysqlAdminRepository) GetClientRanking(filters models.ClientRankingFilters) (models.ClientRankingResponse, error) {
+	return bd.GetClientRanking(filters)
+}
+
+type mysqlRateRepository struct{}
+
+func (mysqlRateRepository) GetShippingRate(originCityID, destinationCityID int64, date time.Time) (models.ShippingRate, error) {
+	return bd.GetShippingRate(originCityID, destinationCityID, date)
+}
+
+func (mysqlRateRepository) GetRatesByFilters(filters models.RateFilters) ([]models.ShippingRate, int, error) {
+	return bd.GetRatesByFilters(filters)
+}
+
+func (mysqlRateRepository) GetRateByID(rateID int64) (models.ShippingRate, error) {
+	return bd.GetRateByID(rateID)
+}
+
+func (mysqlRateRepository) CreateRate(rate *models.ShippingRate, userUUID string) error {
+	return bd.CreateRate(rate, userUUID)
+}
+
+func (mysqlRateRepository) UpdateRate(previous, updated models.ShippingRate, userUUID string) error {
+	return bd.UpdateRate(previous, updated, userUUID)
+}
+
+func (mysqlRateRepository) RetireRate(rateID int64, userUUID string) error {
+	return bd.RetireRate(rateID, userUUID)
+}
+
+func (mysqlRateRepository) GetRateHistory(rateID int64) ([]models.RateChange, error) {
+	return bd.GetRateHistory(rateID)
+}
+
+func (mysqlRateRepository) GetCurrentRates(date time.Time) ([]models.ShippingRate, error) {
+	return bd.GetCurrentRates(date)
+}
+
+func (mysqlRateRepository) ImportRates(rates []models.ShippingRate, userUUID string) error {
+	return bd.ImportRates(rates, userUUID)
+}
+
+type mysqlRateLimitRepository struct{}
+
+func (mysqlRateLimitRepository) HitRateLimit(bucket string, window time.Duration) (models.RateLimitHit, error) {
+	return bd.HitRateLimit(bucket, window)
+}
+
+type mysqlWarehouseRepository struct{}
+
+func (mysqlWarehouseRepository) CreateWarehouse(warehouse *models.Warehouse) error {
+	return bd.CreateWarehouse(warehouse)
+}
+
+func (mysqlWarehouseRepository) GetWarehouses() ([]models.Warehouse, error) {
+	return bd.GetWarehouses()
+}
+
+func (mysqlWarehouseRepository) GetWarehouseByID(warehouseID int64) (models.Warehouse, error) {
+	return bd.GetWarehouseByID(warehouseID)
+}
+
+func (mysqlWarehouseRepository) CreateBin(bin *models.WarehouseBin) error {
+	return bd.CreateBin(bin)
+}
+
+func (mysqlWarehouseRepository) GetBins(warehouseID int64) ([]models.WarehouseBin, error) {
+	return bd.GetBins(warehouseID)
+}
+
+func (mysqlWarehouseRepository) GetBinByID(binID int64) (models.WarehouseBin, error) {
+	return bd.GetBinByID(binID)
+}
+
+func (mysqlWarehouseRepository) GetStock(filters models.StockFilters) ([]models.StockItem, error) {
+	return bd.GetStock(filters)
+}
+
+func (mysqlWarehouseRepository) LocateGuideItems(guideID int64, pieces []int, binID int64, movement models.MovementType, notes string, userUUID string) error {
+	return bd.LocateGuideItems(guideID, pieces, binID, movement, notes, userUUID)
+}
+
+func (mysqlWarehouseRepository) CheckOutGuideItems(guideID int64, pieces []int, notes string, userUUID string) (int, error) {
+	return bd.CheckOutGuideItems(guideID, pieces, notes, userUUID)
+}
+
+func (mysqlWarehouseRepository) GetGuideMovements(guideID int64) ([]models.WarehouseMovement, error) {
+	return bd.GetGuideMovements(guideID)
+}
+
+func (mysqlWarehouseRepository) GetWarehouseAging(filters models.AgingFilters) ([]models.AgingItem, error) {
+	return bd.GetWarehouseAging(filters)
+}
+
+func (mysqlWarehouseRepository) CreateInventoryCount(count *models.InventoryCount) error {
+	return bd.CreateInventoryCount(count)
+}
+
+func (mysqlWarehouseRepository) GetInventoryCount(countID int64) (models.InventoryCount, error) {
+	return bd.GetInventoryCount(countID)
+}
+
+func (mysqlWarehouseRepository) AddInventoryCountItems(items []models.InventoryCountItem) error {
+	return bd.AddInventoryCountItems(items)
+}
+
+func (mysqlWarehouseRepository) GetInventoryCountItems(countID int64) ([]models.InventoryCountItem, error) {
+	return bd.GetInventoryCountItems(countID)
+}
+
+func (mysqlWarehouseRepository) GetExpectedInventory(warehouseID int64) ([]models.StockItem, error) {
+	return bd.GetExpectedInventory(warehouseID)
+}
+
+func (mysqlWarehouseRepository) CloseInventoryCount(countID int64, applied bool, userUUID string) error {
+	return bd.CloseInventoryCount(countID, applied, userUUID)
+}
+
+type mysqlManifestRepository struct{}
+
+func (mysqlManifestRepository) CreateManifest(manifest *models.Manifest) error {
+	return bd.CreateManifest(manifest)
+}
+
+func (mysqlManifestRepository) GetManifests(filters models.ManifestFilters) ([]models.Manifest, error) {
+	return bd.GetManifests(filters)
+}
+
+func (mysqlManifestRepository) GetManifestByID(manifestID int64) (models.Manifest, error) {
+	return bd.GetManifestByID(manifestID)
+}
+
+func (mysqlManifestRepository) GetPendingDispatchGuides(route string) ([]models.ManifestGuide, error) {
+	return bd.GetPendingDispatchGuides(route)
+}
+
+func (mysqlManifestRepository) GetActiveManifestByGuide(guideID int64) (int64, error) {
+	return bd.GetActiveManifestByGuide(guideID)
+}
+
+func (mysqlManifestRepository) AddManifestGuides(manifestID int64, guides []models.ManifestGuide, userUUID string) error {
+	return bd.AddManifestGuides(manifestID, guides, userUUID)
+}
+
+func (mysqlManifestRepository) CloseManifest(manifestID int64, userUUID string) error {
+	return bd.CloseManifest(manifestID, userUUID)
+}
+
+func (mysqlManifestRepository) ReceiveManifest(manifestID int64, guides []models.ManifestGuide, notes string, userUUID string) error {
+	return bd.ReceiveManifest(manifestID, guides, notes, userUUID)
+}
+
+type mysqlRNDCRepository struct{}
+
+func (mysqlRNDCRepository) SaveRNDCSubmission(submission *models.RNDCSubmission) error {
+	return bd.SaveRNDCSubmission(submission)
+}
+
+func (mysqlRNDCRepository) GetRNDCSubmissions(filters models.RNDCFilters) ([]models.RNDCSubmission, error) {
+	return bd.GetRNDCSubmissions(filters)
+}
+
+type mysqlHubRepository struct{}
+
+func (mysqlHubRepository) CreateHub(hub *models.Hub) error {
+	return bd.CreateHub(hub)
+}
+
+func (mysqlHubRepository) GetHubs() ([]models.Hub, error) {
+	return bd.GetHubs()
+}
+
+func (mysqlHubRepository) GetHubByID(hubID int64) (models.Hub, error) {
+	return bd.GetHubByID(hubID)
+}
+
+func (mysqlHubRepository) UpdateHub(hub models.Hub) error {
+	return bd.UpdateHub(hub)
+}
@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+)
+
+// Interfaces de acceso a datos usadas por routers y handlers.
+// La implementación de producción (MySQL) delega en el paquete bd;
+// repository/memory ofrece una implementación en memoria para pruebas.
+
+// UserRepository acceso a usuarios, roles y perfil del cliente
+type UserRepository interface {
+	GetUserRole(userUUID string) (models.User, error)
+	UpdateLastLogin(userUUID string) error
+	GetUserProfile(userUUID string) (models.ClientProfile, error)
+	UpdateUserProfile(userUUID string, updateData models.ClientProfileUpdate) (models.ClientProfile, error)
+}
+
+// GuideRepository acceso a guías de envío
+type GuideRepository interface {
+	GetGuideByID(guideID int64) (models.ShippingGuide, error)
+	GetGuidesByFilters(filters models.GuideFilters) ([]models.ShippingGuide, int, error)
+	GetGuideStats(userUUID string) (models.GuideStatsResponse, error)
+	UpdateGuideStatus(guideID int64, status models.GuideStatus, reason models.GuideStatusReason, notes string, userUUID string) error
+	GuideExists(guideID int64) bool
+	GetGuidePDFInfo(guideID int64) (string, error)
+	ValidateGuideAccess(guideID int64, userUUID string) (bool, error)
+	CreateGuide(guide *models.ShippingGuide, numberPrefix string, userUUID string) error
+	GetGuideIDByNumber(guideNumber string) (int64, error)
+	UpdateGuidePDF(guideID int64, pdfURL, pdfS3Key string) error
+	RecordGuideScan(scan *models.GuideScan) error
+	GetRecentGuideScan(guideID int64, pieceNumber int, point models.ScanPoint, window time.Duration) (models.GuideScan, error)
+	UpdatePieceScanStatus(guideID int64, pieceNumber int, status models.GuideStatus, point models.ScanPoint) error
+}
+
+// RateRepository acceso a tarifas de envío y su historial de versiones
+type RateRepository interface {
+	GetShippingRate(originCityID, destinationCityID int64, date time.Time) (models.ShippingRate, error)
+	GetRatesByFilters(filters models.RateFilters) ([]models.ShippingRate, int, error)
+	GetRateByID(rateID int64) (models.ShippingRate, error)
+	CreateRate(rate *models.ShippingRate, userUUID string) error
+	UpdateRate(previous, updated models.ShippingRate, userUUID string) error
+	RetireRate(rateID int64, userUUID string) error
+	GetRateHistory(rateID int64) ([]models.RateChange, error)
+	GetCurrentRates(date time.Time) ([]models.ShippingRate, error)
+	ImportRates(rates []models.ShippingRate, userUUID string) error
+}
+
+// ClientRepository acceso a consultas de guías desde el portal del cliente
+type ClientRepository interface {
+	GetClientActiveGuides(userUUID string) ([]models.ShippingGuide, error)
+	GetClientGuideHistory(filters models.ClientGuideFilters) ([]models.ShippingGuide, error)
+	GetClientStats(userUUID string) (models.ClientStats, error)
+}
+
+// AssignmentRepository acceso a asignaciones de recogida, entrega y traslado
+type AssignmentRepository interface {
+	CreateAssignment(req models.CreateAssignmentRequest, assignedBy string) (models.DeliveryAssignment, error)
+	GetAssignmentByID(assignmentID int64) (models.DeliveryAssignment, error)
+	GetAssignmentsByFilters(filters models.AssignmentFilters) ([]models.DeliveryAssignment, int, error)
+	ReassignDelivery(assignmentID int64, newDeliveryUserID string, notes string, changedBy string) (models.DeliveryAssignment, error)
+	UpdateAssignmentStatus(assignmentID int64, newStatus models.AssignmentStatus, notes string, changedBy string, guideChange *models.GuideStatusChange) (models.DeliveryAssignment, error)
+	GetAssignmentHistory(assignmentID int64) ([]models.AssignmentHistory, error)
+	GetAssignmentStats(scope models.HubScope) (models.AssignmentStatsResponse, error)
+	GetMyAssignments(deliveryUserID string) (models.MyAssignmentsResponse, error)
+	GetDeliveryUsers(scope models.HubScope) ([]models.DeliveryUser, error)
+	GetPendingPickups(scope models.HubScope) ([]models.PendingGuide, error)
+	GetPendingDeliveries(scope models.HubScope) ([]models.PendingGuide, error)
+	GetPendingTransfers(scope models.HubScope) ([]models.PendingGuide, error)
+	RegisterDeliveryAttempt(assignmentID int64, req models.RegisterAttemptRequest, maxAttempts int, nextDate time.Time, userUUID string) (models.DeliveryAttemptResult, error)
+	GetGuideDeliveryAttempts(guideID int64) ([]models.DeliveryAttempt, error)
+	CompleteDeliveryWithProof(assignmentID int64, proof models.DeliveryProofRequest, notes string, changedBy string, guideChange *models.GuideStatusChange) (models.DeliveryAssignment, error)
+	GetDeliveryProofByGuide(guideID int64) (models.DeliveryProof, error)
+	SaveHandoverCode(code models.HandoverCode, changedBy string) error
+	GetHandoverCode(assignmentID int64) (models.HandoverCode, error)
+	VerifyHandoverCode(assignmentID int64, codeHash string, maxAttempts int, lockout time.Duration, changedBy string) (models.HandoverVerification, error)
+	OverrideHandoverCode(assignmentID int64, guideID int64, reason string, adminUUID string) error
+}
+
+// RatingRepository acceso a calificaciones de entregas
+type RatingRepository interface {
+	CreateDeliveryRating(req models.CreateRatingRequest, clientUserID string) (models.DeliveryRating, error)
+	GetRatingByAssignmentID(assignmentID int64) (models.DeliveryRating, error)
+	GetDeliveryUserRatings(deliveryUserID string, limit int) ([]models.DeliveryRating, int, float64, error)
+	GetDeliveryPerformanceStats(deliveryUserID string) (models.DeliveryPerformanceStats, error)
+	GetClientPendingRatings(clientUserID string) ([]models.ClientPendingRating, error)
+}
+
+// CashCloseRepository acceso a cierres de caja
+type CashCloseRepository interface {
+	CreateCashClose(close *models.CashClose) (int64, error)
+	CreateCashCloseDetail(detail *models.CashCloseDetail) error
+	GetAllCashCloses(limit, offset int, scope models.HubScope) ([]models.CashClose, int, error)
+	GetCashCloseByID(closeID int64) (models.CashClose, error)
+	GetCashCloseDetails(closeID int64) ([]models.CashCloseDetail, error)
+	GetCashCloseStats(scope models.HubScope) (models.CashCloseStatsResponse, error)
+	UpdateCashClosePDF(closeID int64, pdfURL, pdfS3Key string) error
+	GetGuidesForCashClose(startDate, endDate time.Time, scope models.HubScope) ([]models.CashCloseDetail, error)
+}
+
+// LocationRepository acceso a departamentos y ciudades
+type LocationRepository interface {
+	GetAllDepartments() ([]models.Department, error)
+	DepartmentExists(departmentID int64) bool
+	GetAllCities() ([]models.City, error)
+	GetCitiesByDepartment(departmentID int64) ([]models.City, error)
+	GetCityByID(cityID int64) (models.City, error)
+	SearchCities(searchTerm string) ([]models.City, error)
+}
+
+// FrequentPartyRepository acceso a remitentes y destinatarios frecuentes
+type FrequentPartyRepository interface {
+	SearchFrequentPartiesByNameOnly(searchTerm string, partyType models.PartyType) ([]models.FrequentPartyUnique, int, error)
+	SearchFrequentPartiesByNameAndCity(searchTerm string, cityID int64, partyType models.PartyType) ([]models.FrequentParty, int, error)
+	GetFrequentPartiesByDocument(documentNumber string, cityID int64, partyType models.PartyType) ([]models.FrequentParty, int, error)
+	UpsertFrequentParty(req models.CreateFrequentPartyRequest) error
+	GetFrequentPartyStats() (map[string]interface{}, error)
+}
+
+// AdminRepository acceso a panel de administración
+type AdminRepository interface {
+	GetAdminDashboardStats(scope models.HubScope) (models.AdminDashboardStats, error)
+	GetEmployees(role string, scope models.HubScope) ([]models.Employee, error)
+	GetEmployeeByID(userUUID string) (models.Employee, error)
+	GetUserByDocument(documentNumber string) (models.Employee, error)
+	UpdateEmployee(userUUID string, req models.UpdateEmployeeRequest) error
+	GetClientRanking(filters models.ClientRankingFilters) (models.ClientRankingResponse, error)
+}
+
+// RateLimitRepository contadores de solicitudes por ventana de tiempo,
+// compartidos entre contenedores (p.ej. rastreo público por IP y por guía)
+type RateLimitRepository interface {
+	HitRateLimit(bucket string, window time.Duration) (models.RateLimitHit, error)
+}
+
+// WarehouseRepository acceso a bodegas, bins, ubicación de guías y piezas,
+// conteos físicos y antigüedad en bodega
+type WarehouseRepository interface {
+	CreateWarehouse(warehouse *models.Warehouse) error
+	GetWarehouses() ([]models.Warehouse, error)
+	GetWarehouseByID(warehouseID int64) (models.Warehouse, error)
+	CreateBin(bin *models.WarehouseBin) error
+	GetBins(warehouseID int64) ([]models.WarehouseBin, error)
+	GetBinByID(binID int64) (models.WarehouseBin, error)
+	GetStock(filters models.StockFilters) ([]models.StockItem, error)
+	LocateGuideItems(guideID int64, pieces []int, binID int64, movement models.MovementType, notes string, userUUID string) error
+	CheckOutGuideItems(guideID int64, pieces []int, notes string, userUUID string) (int, error)
+	GetGuideMovements(guideID int64) ([]models.WarehouseMovement, error)
+	GetWarehouseAging(filters models.AgingFilters) ([]models.AgingItem, error)
+	CreateInventoryCount(count *models.InventoryCount) error
+	GetInventoryCount(countID int64) (models.InventoryCount, error)
+	AddInventoryCountItems(items []models.InventoryCountItem) error
+	GetInventoryCountItems(countID int64) ([]models.InventoryCountItem, error)
+	GetExpectedInventory(warehouseID int64) ([]models.StockItem, error)
+	CloseInventoryCount(countID int64, applied bool, userUUID string) error
+}
+
+// ManifestRepository acceso a manifiestos de despacho intermunicipal
+type ManifestRepository interface {
+	CreateManifest(manifest *models.Manifest) error
+	GetManifests(filters models.ManifestFilters) ([]models.Manifest, error)
+	GetManifestByID(manifestID int64) (models.Manifest, error)
+	GetPendingDispatchGuides(route string) ([]models.ManifestGuide, error)
+	GetActiveManifestByGuide(guideID int64) (int64, error)
+	AddManifestGuides(manifestID int64, guides []models.ManifestGuide, userUUID string) error
+	CloseManifest(manifestID int64, userUUID string) error
+	ReceiveManifest(manifestID int64, guides []models.ManifestGuide, notes string, userUUID string) error
+}
+
+// RNDCRepository envíos de remesas y manifiestos al RNDC
+type RNDCRepository interface {
+	SaveRNDCSubmission(submission *models.RNDCSubmission) error
+	GetRNDCSubmissions(filters models.RNDCFilters) ([]models.RNDCSubmission, error)
+}
+
+// HubRepository acceso a hubs (sedes) y su cobertura
+type HubRepository interface {
+	CreateHub(hub *models.Hub) error
+	GetHubs() ([]models.Hub, error)
+	GetHubByID(hubID int64) (models.Hub, error)
+	UpdateHub(hub models.Hub) error
+}
+
+// Repositories agrupa todos los repositorios que reciben routers y handlers
+type Repositories struct {
+	Users           UserRepository
+	Guides          GuideRepository
+	Clients         ClientRepository
+	Assignments     AssignmentRepository
+	Ratings         RatingRepository
+	CashCloses      CashCloseRepository
+	Locations       LocationRepository
+	FrequentParties FrequentPartyRepository
+	Admin           AdminRepository
+	Rates           RateRepository
+	RateLimits      RateLimitRepository
+	Warehouses      WarehouseRepository
+	Manifests       ManifestRepository
+	RNDC            RNDCRepository
+	Hubs            HubRepository
+}
@@ -0,0 +1,152 @@
+package rndc
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+)
+
+// Códigos de las tablas del RNDC que usa la operación de paquetería
+const (
+	operationPackages = "P"      // CODOPERACIONTRANSPORTE: paqueteo
+	natureNormal      = "1"      // CODNATURALEZACARGA: carga normal
+	unitKilograms     = "1"      // UNIDADMEDIDACAPACIDAD: kilogramos
+	packagingPackages = "17"     // CODTIPOEMPAQUE: paquetes
+	goodsVarious      = "009880" // MERCANCIAREMESA: mercancías varias (paquetes)
+	idTypeNIT         = "N"
+	idTypeCC          = "C"
+	defaultProduct    = "PAQUETES"
+)
+
+// Company empresa de transporte que reporta al RNDC
+type Company struct {
+	NIT string // sin dígito de verificación
+}
+
+// CompanyFromEnv lee el NIT de la empresa de RNDC_NIT
+func CompanyFromEnv() Company {
+	return Company{NIT: digits(os.Getenv("RNDC_NIT"))}
+}
+
+// BuildRemesa arma la remesa de la guía: remitente y destinatario de
+// guide_parties, municipios con su código DANE y el peso del paquete. El
+// consecutivo de la remesa es el número de la guía.
+func BuildRemesa(company Company, guide models.ShippingGuide, origin, destination models.City) (Message, error) {
+	if guide.Sender == nil || guide.Receiver == nil {
+		return Message{}, fmt.Errorf("la guía no tiene remitente y destinatario")
+	}
+	if guide.Package == nil {
+		return Message{}, fmt.Errorf("la guía no tiene paquete")
+	}
+
+	product := defaultProduct
+	if description := strings.TrimSpace(guide.Package.Description); description != "" {
+		product = truncate(strings.ToUpper(description), 60)
+	}
+	weight := guide.Package.WeightKg
+	if len(guide.Pieces) > 0 {
+		weight = 0
+		for _, p := range guide.Pieces {
+			weight += p.WeightKg
+		}
+	}
+
+	m := Message{ProcessID: ProcessRemesa}
+	m.set("NUMNITEMPRESATRANSPORTE", company.NIT)
+	m.set("CONSECUTIVOREMESA", RemesaConsecutive(guide))
+	m.set("CODOPERACIONTRANSPORTE", operationPackages)
+	m.set("CODNATURALEZACARGA", natureNormal)
+	m.set("CANTIDADCARGADA", strconv.FormatFloat(weight, 'f', 2, 64))
+	m.set("UNIDADMEDIDACAPACIDAD", unitKilograms)
+	m.set("CODTIPOEMPAQUE", packagingPackages)
+	m.set("MERCANCIAREMESA", goodsVarious)
+	m.set("DESCRIPCIONCORTAPRODUCTO", product)
+	m.set("CODTIPOIDREMITENTE", IDType(guide.Sender.DocumentType))
+	m.set("NUMIDREMITENTE", digits(guide.Sender.DocumentNumber))
+	m.set("NOMREMITENTE", truncate(strings.ToUpper(strings.TrimSpace(guide.Sender.FullName)), 100))
+	m.set("CODMUNICIPIOREMITENTE", DANECode(origin))
+	m.set("CODTIPOIDDESTINATARIO", IDType(guide.Receiver.DocumentType))
+	m.set("NUMIDDESTINATARIO", digits(guide.Receiver.DocumentNumber))
+	m.set("NOMDESTINATARIO", truncate(strings.ToUpper(strings.TrimSpace(guide.Receiver.FullName)), 100))
+	m.set("CODMUNICIPIODESTINATARIO", DANECode(destination))
+	if guide.DeclaredValue > 0 {
+		m.set("VALORDECLARADOREMESA", strconv.FormatFloat(guide.DeclaredValue, 'f', 0, 64))
+	}
+	m.set("FECHACITAPACTADACARGUE", guide.CreatedAt.Format("02/01/2006"))
+	return m, nil
+}
+
+// BuildManifiesto arma el manifiesto de carga del despacho con los
+// consecutivos de sus remesas. El titular es la empresa; freight es el flete
+// pactado del viaje.
+func BuildManifiesto(company Company, manifest models.Manifest, remesas []string, origin, destination models.City, freight float64) Message {
+	m := Message{ProcessID: ProcessManifiesto, Remesas: remesas}
+	m.set("NUMNITEMPRESATRANSPORTE", company.NIT)
+	m.set("NUMMANIFIESTOCARGA", strconv.FormatInt(manifest.ManifestID, 10))
+	m.set("CODOPERACIONTRANSPORTE", operationPackages)
+	m.set("FECHAEXPEDICIONMANIFIESTO", manifest.DispatchDate.Format("02/01/2006"))
+	m.set("CODMUNICIPIOORIGENMANIFIESTO", DANECode(origin))
+	m.set("CODMUNICIPIODESTINOMANIFIESTO", DANECode(destination))
+	m.set("CODIDTITULARMANIFIESTO", idTypeNIT)
+	m.set("NUMIDTITULARMANIFIESTO", company.NIT)
+	m.set("NUMPLACA", manifest.VehiclePlate)
+	m.set("CODIDCONDUCTOR", idTypeCC)
+	m.set("NUMIDCONDUCTOR", digits(manifest.DriverDocument))
+	m.set("VALORFLETEPACTADOVIAJE", strconv.FormatFloat(freight, 'f', 0, 64))
+	return m
+}
+
+// RemesaConsecutive consecutivo de la remesa de la guía
+func RemesaConsecutive(guide models.ShippingGuide) string {
+	if guide.GuideNumber != "" {
+		return guide.GuideNumber
+	}
+	return strconv.FormatInt(guide.GuideID, 10)
+}
+
+// IDType código del RNDC para el tipo de documento de guide_parties
+func IDType(documentType string) string {
+	switch strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(documentType), ".", "")) {
+	case "NIT":
+		return idTypeNIT
+	case "CE", "CEDULA DE EXTRANJERIA", "CÉDULA DE EXTRANJERÍA":
+		return "E"
+	case "PP", "PA", "PASAPORTE":
+		return "P"
+	case "TI", "TARJETA DE IDENTIDAD":
+		return "T"
+	}
+	return idTypeCC
+}
+
+// DANECode código DANE del municipio a 8 dígitos (los de departamentos de
+// un dígito se guardan sin el cero inicial)
+func DANECode(city models.City) string {
+	code := digits(city.DaneCode)
+	if code != "" && len(code) < 8 {
+		code = strings.Repeat("0", 8-len(code)) + code
+	}
+	return code
+}
+
+// digits deja solo los dígitos (documentos con puntos o guiones)
+func digits(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func truncate(value string, max int) string {
+	runes := []rune(value)
+	if len(runes) <= max {
+		return value
+	}
+	return string(runes[:max])
+}
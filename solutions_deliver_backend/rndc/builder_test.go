@@ -0,0 +1,220 @@
+package rndc
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+)
+
+var (
+	testCompany     = Company{NIT: "900555111"}
+	testOrigin      = models.City{ID: 1, DaneCode: "11001000", Name: "Bogotá"}
+	testDestination = models.City{ID: 2, DaneCode: "5001000", Name: "Medellín"}
+)
+
+func testGuide() models.ShippingGuide {
+	return models.ShippingGuide{
+		GuideID:       18,
+		GuideNumber:   "SD00000018",
+		DeclaredValue: 500000,
+		CreatedAt:     time.Date(2024, 3, 15, 9, 30, 0, 0, time.UTC),
+		Sender: &models.GuideParty{
+			FullName:       "Comercializadora El Puerto & Cía S.A.S.",
+			DocumentType:   "N.I.T.",
+			DocumentNumber: "900.123.456-7",
+		},
+		Receiver: &models.GuideParty{
+			FullName:       " Ana María Pérez ",
+			DocumentType:   "CC",
+			DocumentNumber: "52.123.456",
+		},
+		Package: &models.Package{WeightKg: 10, Pieces: 2, Description: "repuestos"},
+		Pieces: []models.GuidePiece{
+			{PieceNumber: 1, WeightKg: 8},
+			{PieceNumber: 2, WeightKg: 4.5},
+		},
+	}
+}
+
+func testManifest() models.Manifest {
+	return models.Manifest{
+		ManifestID:     42,
+		DispatchDate:   time.Date(2024, 3, 16, 6, 0, 0, 0, time.UTC),
+		VehiclePlate:   "WTK123",
+		DriverDocument: "79.456.123",
+	}
+}
+
+func TestBuildRemesaXML(t *testing.T) {
+	m, err := BuildRemesa(testCompany, testGuide(), testOrigin, testDestination)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Validate(m); err != nil {
+		t.Fatalf("remesa inválida: %v", err)
+	}
+
+	got, err := Encode(m, "usuario", "clave")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		`<root>`,
+		`<acceso><username>usuario</username><password>clave</password></acceso>`,
+		`<solicitud><tipo>1</tipo><procesoid>3</procesoid></solicitud>`,
+		`<variables>`,
+		`<NUMNITEMPRESATRANSPORTE>900555111</NUMNITEMPRESATRANSPORTE>`,
+		`<CONSECUTIVOREMESA>SD00000018</CONSECUTIVOREMESA>`,
+		`<CODOPERACIONTRANSPORTE>P</CODOPERACIONTRANSPORTE>`,
+		`<CODNATURALEZACARGA>1</CODNATURALEZACARGA>`,
+		// Con detalle de piezas el peso es la suma de las piezas
+		`<CANTIDADCARGADA>12.50</CANTIDADCARGADA>`,
+		`<UNIDADMEDIDACAPACIDAD>1</UNIDADMEDIDACAPACIDAD>`,
+		`<CODTIPOEMPAQUE>17</CODTIPOEMPAQUE>`,
+		`<MERCANCIAREMESA>009880</MERCANCIAREMESA>`,
+		`<DESCRIPCIONCORTAPRODUCTO>REPUESTOS</DESCRIPCIONCORTAPRODUCTO>`,
+		`<CODTIPOIDREMITENTE>N</CODTIPOIDREMITENTE>`,
+		`<NUMIDREMITENTE>9001234567</NUMIDREMITENTE>`,
+		`<NOMREMITENTE>COMERCIALIZADORA EL PUERTO &amp; CÍA S.A.S.</NOMREMITENTE>`,
+		`<CODMUNICIPIOREMITENTE>11001000</CODMUNICIPIOREMITENTE>`,
+		`<CODTIPOIDDESTINATARIO>C</CODTIPOIDDESTINATARIO>`,
+		`<NUMIDDESTINATARIO>52123456</NUMIDDESTINATARIO>`,
+		`<NOMDESTINATARIO>ANA MARÍA PÉREZ</NOMDESTINATARIO>`,
+		`<CODMUNICIPIODESTINATARIO>05001000</CODMUNICIPIODESTINATARIO>`,
+		`<VALORDECLARADOREMESA>500000</VALORDECLARADOREMESA>`,
+		`<FECHACITAPACTADACARGUE>15/03/2024</FECHACITAPACTADACARGUE>`,
+		`</variables>`,
+		`</root>`,
+	}, "")
+	if string(got) != want {
+		t.Errorf("XML =\n%s\nse esperaba\n%s", got, want)
+	}
+
+	masked, err := EncodeMasked(m, "usuario")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(masked), "clave") || !strings.Contains(string(masked), "<password>********</password>") {
+		t.Errorf("XML enmascarado con la contraseña: %s", masked)
+	}
+}
+
+func TestBuildRemesaDefaults(t *testing.T) {
+	guide := testGuide()
+	guide.GuideNumber = ""
+	guide.DeclaredValue = 0
+	guide.Pieces = nil
+	guide.Package.Description = " "
+
+	m, err := BuildRemesa(testCompany, guide, testOrigin, testDestination)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"CONSECUTIVOREMESA":        "18",
+		"CANTIDADCARGADA":          "10.00",
+		"DESCRIPCIONCORTAPRODUCTO": "PAQUETES",
+		"VALORDECLARADOREMESA":     "",
+	}
+	for name, want := range tests {
+		if got := m.Get(name); got != want {
+			t.Errorf("%s = %q, se esperaba %q", name, got, want)
+		}
+	}
+}
+
+func TestBuildRemesaMissingData(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(g *models.ShippingGuide)
+		err    string
+	}{
+		{"sin remitente", func(g *models.ShippingGuide) { g.Sender = nil }, "la guía no tiene remitente y destinatario"},
+		{"sin destinatario", func(g *models.ShippingGuide) { g.Receiver = nil }, "la guía no tiene remitente y destinatario"},
+		{"sin paquete", func(g *models.ShippingGuide) { g.Package = nil }, "la guía no tiene paquete"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guide := testGuide()
+			tt.modify(&guide)
+			_, err := BuildRemesa(testCompany, guide, testOrigin, testDestination)
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("error = %v, se esperaba %s", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestBuildManifiestoXML(t *testing.T) {
+	m := BuildManifiesto(testCompany, testManifest(), []string{"SD00000018", "SD00000026"}, testOrigin, testDestination, 1850000)
+	if err := Validate(m); err != nil {
+		t.Fatalf("manifiesto inválido: %v", err)
+	}
+
+	got, err := Encode(m, "usuario", "clave")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		`<root>`,
+		`<acceso><username>usuario</username><password>clave</password></acceso>`,
+		`<solicitud><tipo>1</tipo><procesoid>4</procesoid></solicitud>`,
+		`<variables>`,
+		`<NUMNITEMPRESATRANSPORTE>900555111</NUMNITEMPRESATRANSPORTE>`,
+		`<NUMMANIFIESTOCARGA>42</NUMMANIFIESTOCARGA>`,
+		`<CODOPERACIONTRANSPORTE>P</CODOPERACIONTRANSPORTE>`,
+		`<FECHAEXPEDICIONMANIFIESTO>16/03/2024</FECHAEXPEDICIONMANIFIESTO>`,
+		`<CODMUNICIPIOORIGENMANIFIESTO>11001000</CODMUNICIPIOORIGENMANIFIESTO>`,
+		`<CODMUNICIPIODESTINOMANIFIESTO>05001000</CODMUNICIPIODESTINOMANIFIESTO>`,
+		`<CODIDTITULARMANIFIESTO>N</CODIDTITULARMANIFIESTO>`,
+		`<NUMIDTITULARMANIFIESTO>900555111</NUMIDTITULARMANIFIESTO>`,
+		`<NUMPLACA>WTK123</NUMPLACA>`,
+		`<CODIDCONDUCTOR>C</CODIDCONDUCTOR>`,
+		`<NUMIDCONDUCTOR>79456123</NUMIDCONDUCTOR>`,
+		`<VALORFLETEPACTADOVIAJE>1850000</VALORFLETEPACTADOVIAJE>`,
+		`<REMESASMAN>`,
+		`<REMESA><CONSECUTIVOREMESA>SD00000018</CONSECUTIVOREMESA></REMESA>`,
+		`<REMESA><CONSECUTIVOREMESA>SD00000026</CONSECUTIVOREMESA></REMESA>`,
+		`</REMESASMAN>`,
+		`</variables>`,
+		`</root>`,
+	}, "")
+	if string(got) != want {
+		t.Errorf("XML =\n%s\nse esperaba\n%s", got, want)
+	}
+
+	// El stub lee el mismo XML
+	decoded, username, password, err := Decode(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if username != "usuario" || password != "clave" {
+		t.Errorf("credenciales = %s/%s", username, password)
+	}
+	if len(decoded.Remesas) != 2 || decoded.Get("NUMPLACA") != "WTK123" || len(decoded.Variables) != len(m.Variables) {
+		t.Errorf("mensaje decodificado = %+v", decoded)
+	}
+}
+
+func TestIDTypeAndDANECode(t *testing.T) {
+	idTypes := map[string]string{
+		"NIT": "N", "n.i.t.": "N", "CC": "C", "": "C", "CE": "E", "Cédula de extranjería": "E",
+		"PA": "P", "Pasaporte": "P", "TI": "T",
+	}
+	for documentType, want := range idTypes {
+		if got := IDType(documentType); got != want {
+			t.Errorf("IDType(%q) = %s, se esperaba %s", documentType, got, want)
+		}
+	}
+
+	codes := map[string]string{"5001000": "05001000", "11001000": "11001000", "05-001-000": "05001000", "": ""}
+	for dane, want := range codes {
+		if got := DANECode(models.City{DaneCode: dane}); got != want {
+			t.Errorf("DANECode(%q) = %s, se esperaba %s", dane, got, want)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package rndc
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// soapAction método del servicio BPMServices del RNDC
+const (
+	soapNamespace = "urn:BPMServicesIntf-IBPMServices"
+	soapAction    = soapNamespace + "#AtenderMensajeRNDC"
+)
+
+// Client envía mensajes al RNDC
+type Client interface {
+	Submit(m Message) (Result, error)
+}
+
+// Result resultado de un envío. RequestXML (sin la contraseña) y ResponseXML
+// se llenan también cuando el envío falla, para guardarlos.
+type Result struct {
+	IngresoID   string
+	RequestXML  string
+	ResponseXML string
+}
+
+// RejectedError el RNDC recibió el mensaje y lo rechazó (ErrorMSG)
+type RejectedError struct {
+	Message string
+}
+
+func (e *RejectedError) Error() string {
+	return "RNDC rechazó el mensaje: " + e.Message
+}
+
+// SOAPClient cliente del servicio SOAP del RNDC (o del stub local)
+type SOAPClient struct {
+	URL        string
+	Username   string
+	Password   string
+	HTTPClient *http.Client
+}
+
+// NewClientFromEnv construye el cliente con RNDC_URL, RNDC_USERNAME y
+// RNDC_PASSWORD. Retorna nil si no hay URL configurada. En desarrollo
+// RNDC_URL apunta al stub (go run ./cmd/rndc-stub).
+func NewClientFromEnv() Client {
+	url := strings.TrimSpace(os.Getenv("RNDC_URL"))
+	if url == "" {
+		return nil
+	}
+	return &SOAPClient{
+		URL:        url,
+		Username:   os.Getenv("RNDC_USERNAME"),
+		Password:   os.Getenv("RNDC_PASSWORD"),
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Submit valida el mensaje, lo envía y retorna el ingresoid asignado. Si el
+// RNDC lo rechaza retorna un *RejectedError; si no pasa la validación, un
+// *ValidationError (sin enviarlo).
+func (c *SOAPClient) Submit(m Message) (Result, error) {
+	var result Result
+
+	masked, err := EncodeMasked(m, c.Username)
+	if err != nil {
+		return result, err
+	}
+	result.RequestXML = string(masked)
+
+	if err := Validate(m); err != nil {
+		return result, err
+	}
+
+	data, err := Encode(m, c.Username, c.Password)
+	if err != nil {
+		return result, err
+	}
+
+	request, err := http.NewRequest("POST", c.URL, bytes.NewReader(soapRequest(data)))
+	if err != nil {
+		return result, err
+	}
+	request.Header.Set("Content-Type", "text/xml; charset=utf-8")
+	request.Header.Set("SOAPAction", soapAction)
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return result, fmt.Errorf("no se pudo conectar con el RNDC: %s", err.Error())
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if err != nil {
+		return result, fmt.Errorf("error leyendo la respuesta del RNDC: %s", err.Error())
+	}
+
+	inner, err := soapResponse(body)
+	if err != nil {
+		result.ResponseXML = string(body)
+		return result, err
+	}
+	result.ResponseXML = inner
+
+	rndcResponse, err := DecodeResponse([]byte(inner))
+	if err != nil {
+		return result, err
+	}
+	if rndcResponse.Error != "" {
+		return result, &RejectedError{Message: rndcResponse.Error}
+	}
+	result.IngresoID = rndcResponse.IngresoID
+	return result, nil
+}
+
+// El XML del mensaje viaja como texto (escapado) dentro del sobre SOAP
+
+type soapEnvelope struct {
+	XMLName xml.Name `xml:"http://schemas.xmlsoap.org/soap/envelope/ Envelope"`
+	Body    struct {
+		Request  *soapCall     `xml:"AtenderMensajeRNDC,omitempty"`
+		Response *soapReturn   `xml:"AtenderMensajeRNDCResponse,omitempty"`
+		Fault    *soapFaultMsg `xml:"Fault,omitempty"`
+	} `xml:"http://schemas.xmlsoap.org/soap/envelope/ Body"`
+}
+
+type soapCall struct {
+	XMLNS   string `xml:"xmlns,attr,omitempty"`
+	Request string `xml:"Request"`
+}
+
+type soapReturn struct {
+	XMLNS  string `xml:"xmlns,attr,omitempty"`
+	Return string `xml:"return"`
+}
+
+type soapFaultMsg struct {
+	Code   string `xml:"faultcode"`
+	String string `xml:"faultstring"`
+}
+
+func soapRequest(message []byte) []byte {
+	var envelope soapEnvelope
+	envelope.Body.Request = &soapCall{XMLNS: soapNamespace, Request: string(message)}
+	data, _ := xml.Marshal(envelope)
+	return append([]byte(xml.Header), data...)
+}
+
+func soapResponse(data []byte) (string, error) {
+	var envelope soapEnvelope
+	if err := xml.Unmarshal(data, &envelope); err != nil {
+		return "", fmt.Errorf("respuesta SOAP del RNDC inválida: %s", err.Error())
+	}
+	if envelope.Body.Fault != nil {
+		return "", fmt.Errorf("error SOAP del RNDC: %s", envelope.Body.Fault.String)
+	}
+	if envelope.Body.Response == nil {
+		return "", fmt.Errorf("respuesta SOAP del RNDC sin AtenderMensajeRNDCResponse")
+	}
+	return envelope.Body.Response.Return, nil
+}
+
+func soapReturnEnvelope(inner []byte) []byte {
+	var envelope soapEnvelope
+	envelope.Body.Response = &soapReturn{XMLNS: soapNamespace, Return: string(inner)}
+	data, _ := xml.Marshal(envelope)
+	return append([]byte(xml.Header), data...)
+}
+
+func soapFaultEnvelope(message string) []byte {
+	var envelope soapEnvelope
+	envelope.Body.Fault = &soapFaultMsg{Code: "soap:Client", String: message}
+	data, _ := xml.Marshal(envelope)
+	return append([]byte(xml.Header), data...)
+}
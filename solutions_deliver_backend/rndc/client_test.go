@@ -0,0 +1,154 @@
+package rndc
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// newStubClient levanta el stub en un servidor httptest y retorna el cliente
+// con las credenciales dadas
+func newStubClient(t *testing.T, username, password string) (*SOAPClient, *int32) {
+	t.Helper()
+	stub := NewStub("usuario", "clave")
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.Header.Get("SOAPAction") != soapAction {
+			t.Errorf("SOAPAction = %q", r.Header.Get("SOAPAction"))
+		}
+		stub.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return &SOAPClient{URL: server.URL, Username: username, Password: password, HTTPClient: server.Client()}, &requests
+}
+
+func TestSOAPRoundTrip(t *testing.T) {
+	client, _ := newStubClient(t, "usuario", "clave")
+
+	remesa, err := BuildRemesa(testCompany, testGuide(), testOrigin, testDestination)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := client.Submit(remesa)
+	if err != nil {
+		t.Fatalf("remesa: %v", err)
+	}
+	if result.IngresoID != "1000001" {
+		t.Errorf("ingresoid = %s, se esperaba 1000001", result.IngresoID)
+	}
+	if strings.Contains(result.RequestXML, "clave") || !strings.Contains(result.RequestXML, "<CONSECUTIVOREMESA>SD00000018</CONSECUTIVOREMESA>") {
+		t.Errorf("RequestXML = %s", result.RequestXML)
+	}
+	if !strings.Contains(result.ResponseXML, "<ingresoid>1000001</ingresoid>") {
+		t.Errorf("ResponseXML = %s", result.ResponseXML)
+	}
+
+	// La misma remesa otra vez: el RNDC la rechaza
+	_, err = client.Submit(remesa)
+	var rejected *RejectedError
+	if !errors.As(err, &rejected) || !strings.Contains(rejected.Message, "ya fue registrada con el ingresoid 1000001") {
+		t.Errorf("error = %v, se esperaba remesa repetida", err)
+	}
+
+	// Manifiesto con una remesa que no se ha reportado
+	manifiesto := BuildManifiesto(testCompany, testManifest(), []string{"SD00000018", "SD00000026"}, testOrigin, testDestination, 1850000)
+	_, err = client.Submit(manifiesto)
+	if !errors.As(err, &rejected) || rejected.Message != "La remesa SD00000026 no está registrada" {
+		t.Errorf("error = %v, se esperaba remesa no registrada", err)
+	}
+
+	manifiesto.Remesas = []string{"SD00000018"}
+	result, err = client.Submit(manifiesto)
+	if err != nil {
+		t.Fatalf("manifiesto: %v", err)
+	}
+	if result.IngresoID != "1000002" {
+		t.Errorf("ingresoid = %s, se esperaba 1000002", result.IngresoID)
+	}
+}
+
+func TestSOAPInvalidCredentials(t *testing.T) {
+	client, _ := newStubClient(t, "usuario", "otra")
+
+	remesa, _ := BuildRemesa(testCompany, testGuide(), testOrigin, testDestination)
+	_, err := client.Submit(remesa)
+	var rejected *RejectedError
+	if !errors.As(err, &rejected) || rejected.Message != "Usuario o contraseña inválidos" {
+		t.Errorf("error = %v, se esperaba credenciales inválidas", err)
+	}
+}
+
+// Un mensaje inválido no se envía, pero su XML queda en el resultado
+func TestSOAPValidationBeforeSubmit(t *testing.T) {
+	client, requests := newStubClient(t, "usuario", "clave")
+
+	remesa, _ := BuildRemesa(testCompany, testGuide(), testOrigin, testDestination)
+	result, err := client.Submit(without(remesa, "NUMIDREMITENTE"))
+
+	var validation *ValidationError
+	if !errors.As(err, &validation) {
+		t.Fatalf("error = %v, se esperaba *ValidationError", err)
+	}
+	if atomic.LoadInt32(requests) != 0 {
+		t.Errorf("se enviaron %d solicitudes, se esperaba 0", *requests)
+	}
+	if result.RequestXML == "" {
+		t.Error("RequestXML vacío")
+	}
+}
+
+func TestSOAPFault(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		err     string
+	}{
+		{"fault del servicio", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write(soapFaultEnvelope("Servicio no disponible"))
+		}, "error SOAP del RNDC: Servicio no disponible"},
+		{"sobre sin respuesta", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`<?xml version="1.0"?><Envelope xmlns="http://schemas.xmlsoap.org/soap/envelope/"><Body></Body></Envelope>`))
+		}, "respuesta SOAP del RNDC sin AtenderMensajeRNDCResponse"},
+		{"respuesta sin ingresoid", func(w http.ResponseWriter, r *http.Request) {
+			w.Write(soapReturnEnvelope([]byte("<root></root>")))
+		}, "respuesta del RNDC sin ingresoid ni ErrorMSG"},
+	}
+
+	remesa, _ := BuildRemesa(testCompany, testGuide(), testOrigin, testDestination)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			client := &SOAPClient{URL: server.URL, Username: "usuario", Password: "clave", HTTPClient: server.Client()}
+			result, err := client.Submit(remesa)
+			if err == nil || err.Error() != tt.err {
+				t.Fatalf("error = %v, se esperaba %s", err, tt.err)
+			}
+			if result.ResponseXML == "" {
+				t.Error("ResponseXML vacío: la respuesta debe quedar guardada")
+			}
+		})
+	}
+}
+
+// El stub responde con un fault a lo que no es AtenderMensajeRNDC
+func TestStubFault(t *testing.T) {
+	server := httptest.NewServer(NewStub("", ""))
+	defer server.Close()
+
+	response, err := http.Post(server.URL, "text/xml", strings.NewReader("<Envelope/>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusInternalServerError {
+		t.Errorf("status = %d, se esperaba 500", response.StatusCode)
+	}
+}
@@ -0,0 +1,209 @@
+// Package rndc arma, valida y envía los mensajes del RNDC (Registro Nacional
+// de Despachos de Carga del Ministerio de Transporte): la remesa de cada
+// guía y el manifiesto de carga del despacho.
+//
+// El RNDC expone un servicio SOAP con un único método, AtenderMensajeRNDC,
+// que recibe el XML del mensaje como texto:
+//
+//	<root>
+//	  <acceso><username>..</username><password>..</password></acceso>
+//	  <solicitud><tipo>1</tipo><procesoid>3</procesoid></solicitud>
+//	  <variables><NUMNITEMPRESATRANSPORTE>..</NUMNITEMPRESATRANSPORTE>...</variables>
+//	</root>
+//
+// y responde <root><ingresoid>..</ingresoid></root> con el consecutivo
+// asignado, o <root><ErrorMSG>..</ErrorMSG></root> si rechaza el mensaje.
+package rndc
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Procesos del RNDC (solicitud/procesoid)
+const (
+	ProcessRemesa     = 3
+	ProcessManifiesto = 4
+)
+
+// requestTypeIngreso solicitud/tipo: 1 registra el documento
+const requestTypeIngreso = 1
+
+// maskedPassword reemplaza la contraseña en el XML que se guarda
+const maskedPassword = "********"
+
+// Variable campo del mensaje: <NOMBRE>valor</NOMBRE>
+type Variable struct {
+	Name  string
+	Value string
+}
+
+// Message mensaje de un proceso del RNDC. Remesas son los consecutivos de
+// las remesas del manifiesto (<REMESASMAN>); no aplica a la remesa.
+type Message struct {
+	ProcessID int
+	Variables []Variable
+	Remesas   []string
+}
+
+// Get valor de la variable; vacío si no está
+func (m Message) Get(name string) string {
+	for _, v := range m.Variables {
+		if v.Name == name {
+			return v.Value
+		}
+	}
+	return ""
+}
+
+func (m *Message) set(name, value string) {
+	m.Variables = append(m.Variables, Variable{Name: name, Value: value})
+}
+
+// ProcessName nombre del proceso para mostrar
+func ProcessName(processID int) string {
+	switch processID {
+	case ProcessRemesa:
+		return "remesa"
+	case ProcessManifiesto:
+		return "manifiesto"
+	}
+	return "proceso " + strconv.Itoa(processID)
+}
+
+// Encode XML del mensaje con las credenciales de acceso
+func Encode(m Message, username, password string) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := xml.NewEncoder(&buf)
+
+	start := func(name string) {
+		enc.EncodeToken(xml.StartElement{Name: xml.Name{Local: name}})
+	}
+	end := func(name string) {
+		enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: name}})
+	}
+	element := func(name, value string) {
+		start(name)
+		enc.EncodeToken(xml.CharData(value))
+		end(name)
+	}
+
+	start("root")
+	start("acceso")
+	element("username", username)
+	element("password", password)
+	end("acceso")
+	start("solicitud")
+	element("tipo", strconv.Itoa(requestTypeIngreso))
+	element("procesoid", strconv.Itoa(m.ProcessID))
+	end("solicitud")
+	start("variables")
+	for _, v := range m.Variables {
+		element(v.Name, v.Value)
+	}
+	if m.ProcessID == ProcessManifiesto {
+		start("REMESASMAN")
+		for _, consecutive := range m.Remesas {
+			start("REMESA")
+			element("CONSECUTIVOREMESA", consecutive)
+			end("REMESA")
+		}
+		end("REMESASMAN")
+	}
+	end("variables")
+	end("root")
+
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// EncodeMasked XML del mensaje sin la contraseña, para mostrar o guardar
+func EncodeMasked(m Message, username string) ([]byte, error) {
+	return Encode(m, username, maskedPassword)
+}
+
+type xmlRequest struct {
+	XMLName xml.Name `xml:"root"`
+	Acceso  struct {
+		Username string `xml:"username"`
+		Password string `xml:"password"`
+	} `xml:"acceso"`
+	Solicitud struct {
+		Tipo      int `xml:"tipo"`
+		ProcesoID int `xml:"procesoid"`
+	} `xml:"solicitud"`
+	Variables struct {
+		Fields []xmlField `xml:",any"`
+	} `xml:"variables"`
+}
+
+type xmlField struct {
+	XMLName xml.Name
+	Value   string `xml:",chardata"`
+	Remesas []struct {
+		Consecutive string `xml:"CONSECUTIVOREMESA"`
+	} `xml:"REMESA"`
+}
+
+// Decode lee el XML de un mensaje (lo usa el stub); retorna el mensaje y las
+// credenciales de acceso
+func Decode(data []byte) (Message, string, string, error) {
+	var request xmlRequest
+	if err := xml.Unmarshal(data, &request); err != nil {
+		return Message{}, "", "", fmt.Errorf("XML inválido: %s", err.Error())
+	}
+	if request.Solicitud.Tipo != requestTypeIngreso {
+		return Message{}, "", "", fmt.Errorf("solicitud/tipo %d no soportado", request.Solicitud.Tipo)
+	}
+
+	m := Message{ProcessID: request.Solicitud.ProcesoID}
+	for _, f := range request.Variables.Fields {
+		if f.XMLName.Local == "REMESASMAN" {
+			for _, r := range f.Remesas {
+				m.Remesas = append(m.Remesas, strings.TrimSpace(r.Consecutive))
+			}
+			continue
+		}
+		m.set(f.XMLName.Local, strings.TrimSpace(f.Value))
+	}
+	return m, request.Acceso.Username, request.Acceso.Password, nil
+}
+
+// Response respuesta del RNDC: el ingresoid asignado o el error
+type Response struct {
+	IngresoID string
+	Error     string
+}
+
+type xmlResponse struct {
+	XMLName   xml.Name `xml:"root"`
+	IngresoID string   `xml:"ingresoid,omitempty"`
+	Error     string   `xml:"ErrorMSG,omitempty"`
+}
+
+// EncodeResponse XML de la respuesta (lo usa el stub)
+func EncodeResponse(r Response) []byte {
+	data, _ := xml.Marshal(xmlResponse{IngresoID: r.IngresoID, Error: r.Error})
+	return append([]byte(xml.Header), data...)
+}
+
+// DecodeResponse lee la respuesta del RNDC
+func DecodeResponse(data []byte) (Response, error) {
+	var response xmlResponse
+	if err := xml.Unmarshal(data, &response); err != nil {
+		return Response{}, fmt.Errorf("respuesta del RNDC inválida: %s", err.Error())
+	}
+	r := Response{
+		IngresoID: strings.TrimSpace(response.IngresoID),
+		Error:     strings.TrimSpace(response.Error),
+	}
+	if r.IngresoID == "" && r.Error == "" {
+		return r, fmt.Errorf("respuesta del RNDC sin ingresoid ni ErrorMSG")
+	}
+	return r, nil
+}
@@ -0,0 +1,192 @@
+package rndc
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Esquema de las variables de cada proceso según el diccionario de datos
+// publicado por el Ministerio de Transporte para el RNDC: tipo, longitud
+// máxima, obligatoriedad y valores permitidos. El RNDC no publica un XSD;
+// el mensaje se valida contra esta tabla antes de enviarlo (y el stub valida
+// con la misma).
+
+type fieldKind int
+
+const (
+	kindText    fieldKind = iota // texto libre
+	kindDigits                   // solo dígitos
+	kindDecimal                  // número con punto decimal, mayor que 0
+	kindDate                     // DD/MM/AAAA
+	kindDANE                     // código DANE de municipio, 8 dígitos
+	kindPlate                    // placa de vehículo de carga: 3 letras y 3 dígitos
+)
+
+type fieldSpec struct {
+	name     string
+	kind     fieldKind
+	required bool
+	max      int
+	values   []string // valores permitidos (códigos de las tablas del RNDC)
+}
+
+// Tipos de identificación del RNDC
+var idTypes = []string{"C", "N", "E", "P", "T", "U"}
+
+var schemas = map[int][]fieldSpec{
+	ProcessRemesa: {
+		{name: "NUMNITEMPRESATRANSPORTE", kind: kindDigits, required: true, max: 10},
+		{name: "CONSECUTIVOREMESA", kind: kindText, required: true, max: 20},
+		{name: "CODOPERACIONTRANSPORTE", kind: kindText, required: true, max: 1, values: []string{"G", "P", "C", "V"}},
+		{name: "CODNATURALEZACARGA", kind: kindDigits, required: true, max: 1, values: []string{"1", "2", "3", "4", "5", "6", "7"}},
+		{name: "CANTIDADCARGADA", kind: kindDecimal, required: true, max: 12},
+		{name: "UNIDADMEDIDACAPACIDAD", kind: kindDigits, required: true, max: 1, values: []string{"1", "2"}},
+		{name: "CODTIPOEMPAQUE", kind: kindDigits, required: true, max: 2},
+		{name: "MERCANCIAREMESA", kind: kindDigits, required: true, max: 6},
+		{name: "DESCRIPCIONCORTAPRODUCTO", kind: kindText, required: true, max: 60},
+		{name: "CODTIPOIDREMITENTE", kind: kindText, required: true, max: 1, values: idTypes},
+		{name: "NUMIDREMITENTE", kind: kindDigits, required: true, max: 15},
+		{name: "NOMREMITENTE", kind: kindText, required: true, max: 100},
+		{name: "CODMUNICIPIOREMITENTE", kind: kindDANE, required: true, max: 8},
+		{name: "CODTIPOIDDESTINATARIO", kind: kindText, required: true, max: 1, values: idTypes},
+		{name: "NUMIDDESTINATARIO", kind: kindDigits, required: true, max: 15},
+		{name: "NOMDESTINATARIO", kind: kindText, required: true, max: 100},
+		{name: "CODMUNICIPIODESTINATARIO", kind: kindDANE, required: true, max: 8},
+		{name: "VALORDECLARADOREMESA", kind: kindDigits, required: false, max: 12},
+		{name: "FECHACITAPACTADACARGUE", kind: kindDate, required: true, max: 10},
+	},
+	ProcessManifiesto: {
+		{name: "NUMNITEMPRESATRANSPORTE", kind: kindDigits, required: true, max: 10},
+		{name: "NUMMANIFIESTOCARGA", kind: kindDigits, required: true, max: 15},
+		{name: "CODOPERACIONTRANSPORTE", kind: kindText, required: true, max: 1, values: []string{"G", "P", "C", "V"}},
+		{name: "FECHAEXPEDICIONMANIFIESTO", kind: kindDate, required: true, max: 10},
+		{name: "CODMUNICIPIOORIGENMANIFIESTO", kind: kindDANE, required: true, max: 8},
+		{name: "CODMUNICIPIODESTINOMANIFIESTO", kind: kindDANE, required: true, max: 8},
+		{name: "CODIDTITULARMANIFIESTO", kind: kindText, required: true, max: 1, values: idTypes},
+		{name: "NUMIDTITULARMANIFIESTO", kind: kindDigits, required: true, max: 15},
+		{name: "NUMPLACA", kind: kindPlate, required: true, max: 6},
+		{name: "CODIDCONDUCTOR", kind: kindText, required: true, max: 1, values: idTypes},
+		{name: "NUMIDCONDUCTOR", kind: kindDigits, required: true, max: 15},
+		{name: "VALORFLETEPACTADOVIAJE", kind: kindDigits, required: true, max: 12},
+	},
+}
+
+var platePattern = regexp.MustCompile(`^[A-Z]{3}[0-9]{3}$`)
+
+// ValidationError problemas encontrados al validar el mensaje
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "mensaje RNDC inválido: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate valida el mensaje contra el esquema de su proceso. Retorna un
+// *ValidationError con todos los problemas encontrados.
+func Validate(m Message) error {
+	specs, ok := schemas[m.ProcessID]
+	if !ok {
+		return &ValidationError{Problems: []string{fmt.Sprintf("procesoid %d no soportado", m.ProcessID)}}
+	}
+
+	var problems []string
+	known := map[string]bool{}
+	for _, spec := range specs {
+		known[spec.name] = true
+		if problem := spec.check(m.Get(spec.name)); problem != "" {
+			problems = append(problems, spec.name+": "+problem)
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, v := range m.Variables {
+		if !known[v.Name] {
+			problems = append(problems, v.Name+": variable no definida para la "+ProcessName(m.ProcessID))
+		} else if seen[v.Name] {
+			problems = append(problems, v.Name+": variable repetida")
+		}
+		seen[v.Name] = true
+	}
+
+	if m.ProcessID == ProcessManifiesto {
+		if len(m.Remesas) == 0 {
+			problems = append(problems, "REMESASMAN: el manifiesto debe tener al menos una remesa")
+		}
+		consecutive := fieldSpec{name: "CONSECUTIVOREMESA", kind: kindText, required: true, max: 20}
+		for i, r := range m.Remesas {
+			if problem := consecutive.check(r); problem != "" {
+				problems = append(problems, fmt.Sprintf("REMESASMAN/REMESA[%d]: %s", i+1, problem))
+			}
+		}
+	} else if len(m.Remesas) > 0 {
+		problems = append(problems, "REMESASMAN: solo aplica al manifiesto")
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// check retorna el problema del valor, o vacío si es válido
+func (spec fieldSpec) check(value string) string {
+	if value == "" {
+		if spec.required {
+			return "es obligatoria"
+		}
+		return ""
+	}
+	if len([]rune(value)) > spec.max {
+		return fmt.Sprintf("máximo %d caracteres", spec.max)
+	}
+	if len(spec.values) > 0 && !contains(spec.values, value) {
+		return fmt.Sprintf("valor %q no permitido (%s)", value, strings.Join(spec.values, ", "))
+	}
+
+	switch spec.kind {
+	case kindDigits:
+		if !allDigits(value) {
+			return "debe tener solo dígitos"
+		}
+	case kindDecimal:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil || strings.Contains(value, ",") || n <= 0 {
+			return "debe ser un número mayor que 0 con punto decimal"
+		}
+	case kindDate:
+		if _, err := time.Parse("02/01/2006", value); err != nil {
+			return "debe tener el formato DD/MM/AAAA"
+		}
+	case kindDANE:
+		if len(value) != 8 || !allDigits(value) {
+			return "debe ser un código DANE de 8 dígitos"
+		}
+	case kindPlate:
+		if !platePattern.MatchString(value) {
+			return "debe ser una placa de 3 letras y 3 dígitos"
+		}
+	}
+	return ""
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func allDigits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return value != ""
+}
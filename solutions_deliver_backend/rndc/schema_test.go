@@ -0,0 +1,109 @@
+package rndc
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// without copia el mensaje sin la variable name
+func without(m Message, name string) Message {
+	c := Message{ProcessID: m.ProcessID, Remesas: m.Remesas}
+	for _, v := range m.Variables {
+		if v.Name != name {
+			c.Variables = append(c.Variables, v)
+		}
+	}
+	return c
+}
+
+// with copia el mensaje cambiando el valor de name
+func with(m Message, name, value string) Message {
+	c := without(m, name)
+	c.set(name, value)
+	return c
+}
+
+func validationProblems(t *testing.T, m Message) []string {
+	t.Helper()
+	err := Validate(m)
+	if err == nil {
+		return nil
+	}
+	var validation *ValidationError
+	if !errors.As(err, &validation) {
+		t.Fatalf("error = %v, se esperaba *ValidationError", err)
+	}
+	return validation.Problems
+}
+
+func TestValidateRequiredFields(t *testing.T) {
+	remesa, err := BuildRemesa(testCompany, testGuide(), testOrigin, testDestination)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifiesto := BuildManifiesto(testCompany, testManifest(), []string{"SD00000018"}, testOrigin, testDestination, 1850000)
+
+	for processID, m := range map[int]Message{ProcessRemesa: remesa, ProcessManifiesto: manifiesto} {
+		for _, spec := range schemas[processID] {
+			t.Run(ProcessName(processID)+"/"+spec.name, func(t *testing.T) {
+				problems := validationProblems(t, without(m, spec.name))
+				if !spec.required {
+					if len(problems) != 0 {
+						t.Errorf("variable opcional: %v", problems)
+					}
+					return
+				}
+				want := spec.name + ": es obligatoria"
+				if len(problems) != 1 || problems[0] != want {
+					t.Errorf("problemas = %v, se esperaba [%s]", problems, want)
+				}
+			})
+		}
+	}
+}
+
+func TestValidateValues(t *testing.T) {
+	remesa, err := BuildRemesa(testCompany, testGuide(), testOrigin, testDestination)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifiesto := BuildManifiesto(testCompany, testManifest(), []string{"SD00000018"}, testOrigin, testDestination, 1850000)
+
+	repeated := Message{ProcessID: ProcessRemesa, Variables: append(append([]Variable{}, remesa.Variables...), remesa.Variables[0])}
+
+	tests := []struct {
+		name    string
+		message Message
+		problem string
+	}{
+		{"NIT con letras", with(remesa, "NUMNITEMPRESATRANSPORTE", "90055511A"), "NUMNITEMPRESATRANSPORTE: debe tener solo dígitos"},
+		{"NIT muy largo", with(remesa, "NUMNITEMPRESATRANSPORTE", "90055511122"), "NUMNITEMPRESATRANSPORTE: máximo 10 caracteres"},
+		{"operación no permitida", with(remesa, "CODOPERACIONTRANSPORTE", "X"), `CODOPERACIONTRANSPORTE: valor "X" no permitido (G, P, C, V)`},
+		{"peso con coma", with(remesa, "CANTIDADCARGADA", "12,5"), "CANTIDADCARGADA: debe ser un número mayor que 0 con punto decimal"},
+		{"peso cero", with(remesa, "CANTIDADCARGADA", "0.00"), "CANTIDADCARGADA: debe ser un número mayor que 0 con punto decimal"},
+		{"fecha ISO", with(remesa, "FECHACITAPACTADACARGUE", "2024-03-15"), "FECHACITAPACTADACARGUE: debe tener el formato DD/MM/AAAA"},
+		{"DANE de 7 dígitos", with(remesa, "CODMUNICIPIOREMITENTE", "5001000"), "CODMUNICIPIOREMITENTE: debe ser un código DANE de 8 dígitos"},
+		{"tipo de documento", with(remesa, "CODTIPOIDDESTINATARIO", "X"), `CODTIPOIDDESTINATARIO: valor "X" no permitido (C, N, E, P, T, U)`},
+		{"nombre muy largo", with(remesa, "NOMDESTINATARIO", strings.Repeat("Ñ", 101)), "NOMDESTINATARIO: máximo 100 caracteres"},
+		{"variable desconocida", with(remesa, "PLACA", "WTK123"), "PLACA: variable no definida para la remesa"},
+		{"variable repetida", repeated, "NUMNITEMPRESATRANSPORTE: variable repetida"},
+		{"remesas en la remesa", Message{ProcessID: ProcessRemesa, Variables: remesa.Variables, Remesas: []string{"SD00000018"}},
+			"REMESASMAN: solo aplica al manifiesto"},
+		{"placa de moto", with(manifiesto, "NUMPLACA", "ABC12D"), "NUMPLACA: debe ser una placa de 3 letras y 3 dígitos"},
+		{"manifiesto sin remesas", Message{ProcessID: ProcessManifiesto, Variables: manifiesto.Variables},
+			"REMESASMAN: el manifiesto debe tener al menos una remesa"},
+		{"remesa vacía en el manifiesto", Message{ProcessID: ProcessManifiesto, Variables: manifiesto.Variables, Remesas: []string{"SD00000018", ""}},
+			"REMESASMAN/REMESA[2]: es obligatoria"},
+		{"proceso desconocido", Message{ProcessID: 9}, "procesoid 9 no soportado"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := validationProblems(t, tt.message)
+			if len(problems) != 1 || problems[0] != tt.problem {
+				t.Errorf("problemas = %v, se esperaba [%s]", problems, tt.problem)
+			}
+		})
+	}
+}
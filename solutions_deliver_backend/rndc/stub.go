@@ -0,0 +1,110 @@
+package rndc
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// Stub implementa localmente el contrato SOAP del RNDC para desarrollo y
+// pruebas sin conexión: valida credenciales y mensajes con el mismo esquema,
+// rechaza remesas repetidas y manifiestos con remesas no registradas, y
+// asigna ingresoid consecutivos.
+type Stub struct {
+	Username string // vacío: acepta cualquier usuario
+	Password string
+
+	mu       sync.Mutex
+	next     int64
+	remesas  map[string]string // CONSECUTIVOREMESA -> ingresoid
+	manifest map[string]string // NUMMANIFIESTOCARGA -> ingresoid
+}
+
+// NewStub crea el stub con las credenciales que aceptará
+func NewStub(username, password string) *Stub {
+	return &Stub{
+		Username: username,
+		Password: password,
+		next:     1000001,
+		remesas:  map[string]string{},
+		manifest: map[string]string{},
+	}
+}
+
+// ServeHTTP atiende AtenderMensajeRNDC
+func (s *Stub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		w.Write(soapFaultEnvelope("se espera POST"))
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(soapFaultEnvelope(err.Error()))
+		return
+	}
+
+	var envelope soapEnvelope
+	if err := xml.Unmarshal(body, &envelope); err != nil || envelope.Body.Request == nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(soapFaultEnvelope("sobre SOAP inválido: se espera AtenderMensajeRNDC"))
+		return
+	}
+
+	response := s.attend([]byte(envelope.Body.Request.Request))
+	w.Write(soapReturnEnvelope(EncodeResponse(response)))
+}
+
+func (s *Stub) attend(data []byte) Response {
+	m, username, password, err := Decode(data)
+	if err != nil {
+		return Response{Error: err.Error()}
+	}
+	if s.Username != "" && (username != s.Username || password != s.Password) {
+		return Response{Error: "Usuario o contraseña inválidos"}
+	}
+	if err := Validate(m); err != nil {
+		return Response{Error: err.Error()}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch m.ProcessID {
+	case ProcessRemesa:
+		consecutive := m.Get("CONSECUTIVOREMESA")
+		if id, ok := s.remesas[consecutive]; ok {
+			return Response{Error: fmt.Sprintf("La remesa %s ya fue registrada con el ingresoid %s", consecutive, id)}
+		}
+		id := s.newID()
+		s.remesas[consecutive] = id
+		return Response{IngresoID: id}
+
+	case ProcessManifiesto:
+		number := m.Get("NUMMANIFIESTOCARGA")
+		if id, ok := s.manifest[number]; ok {
+			return Response{Error: fmt.Sprintf("El manifiesto %s ya fue registrado con el ingresoid %s", number, id)}
+		}
+		for _, consecutive := range m.Remesas {
+			if _, ok := s.remesas[consecutive]; !ok {
+				return Response{Error: fmt.Sprintf("La remesa %s no está registrada", consecutive)}
+			}
+		}
+		id := s.newID()
+		s.manifest[number] = id
+		return Response{IngresoID: id}
+	}
+	return Response{Error: fmt.Sprintf("procesoid %d no soportado", m.ProcessID)}
+}
+
+func (s *Stub) newID() string {
+	id := strconv.FormatInt(s.next, 10)
+	s.next++
+	return id
+}
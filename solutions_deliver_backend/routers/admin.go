@@ -4,21 +4,17 @@ import (
 	"encoding/json"
 	"fmt"
 
-	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/bd"
 	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
 	"github.com/aws/aws-lambda-go/events"
 )
 
 // GetAdminDashboardStats obtiene todas las estadísticas del dashboard admin
-func GetAdminDashboardStats(userUUID string) (int, string) {
+// (del hub del alcance, o el consolidado con el resumen por hub)
+func GetAdminDashboardStats(scope models.HubScope) (int, string) {
 	fmt.Println("GetAdminDashboardStats")
 
 	// Verificar permisos
-	if !userIsAllowed(userUUID, models.RoleAdmin) {
-		return 403, `{"error": "No autorizado - Solo administradores"}`
-	}
-
-	stats, err := bd.GetAdminDashboardStats()
+	stats, err := repos.Admin.GetAdminDashboardStats(scope)
 	if err != nil {
 		return 500, fmt.Sprintf(`{"error": "Error al obtener estadísticas: %s"}`, err.Error())
 	}
@@ -31,21 +27,17 @@ func GetAdminDashboardStats(userUUID string) (int, string) {
 	return 200, string(jsonResponse)
 }
 
-// GetEmployees obtiene la lista de empleados
-func GetEmployees(request events.APIGatewayV2HTTPRequest, userUUID string) (int, string) {
+// GetEmployees obtiene la lista de empleados del alcance
+func GetEmployees(request events.APIGatewayV2HTTPRequest, scope models.HubScope) (int, string) {
 	fmt.Println("GetEmployees")
 
 	// Verificar permisos
-	if !userIsAllowed(userUUID, models.RoleAdmin) {
-		return 403, `{"error": "No autorizado - Solo administradores"}`
-	}
-
 	role := ""
 	if request.QueryStringParameters != nil {
 		role = request.QueryStringParameters["role"]
 	}
 
-	employees, err := bd.GetEmployees(role)
+	employees, err := repos.Admin.GetEmployees(role, scope)
 	if err != nil {
 		return 500, fmt.Sprintf(`{"error": "Error al obtener empleados: %s"}`, err.Error())
 	}
@@ -68,15 +60,11 @@ func GetEmployees(request events.APIGatewayV2HTTPRequest, userUUID string) (int,
 }
 
 // GetEmployeeByID obtiene un empleado por ID
-func GetEmployeeByID(userUUID string, employeeID string) (int, string) {
+func GetEmployeeByID(employeeID string) (int, string) {
 	fmt.Printf("GetEmployeeByID -> ID: %s\n", employeeID)
 
 	// Verificar permisos
-	if !userIsAllowed(userUUID, models.RoleAdmin) {
-		return 403, `{"error": "No autorizado - Solo administradores"}`
-	}
-
-	employee, err := bd.GetEmployeeByID(employeeID)
+	employee, err := repos.Admin.GetEmployeeByID(employeeID)
 	if err != nil {
 		if err.Error() == "empleado no encontrado" {
 			return 404, `{"error": "Empleado no encontrado"}`
@@ -93,19 +81,15 @@ func GetEmployeeByID(userUUID string, employeeID string) (int, string) {
 }
 
 // GetUserByDocument busca un usuario por número de documento
-func GetUserByDocument(userUUID string, documentNumber string) (int, string) {
+func GetUserByDocument(documentNumber string) (int, string) {
 	fmt.Printf("GetUserByDocument -> Document: %s\n", documentNumber)
 
 	// Verificar permisos
-	if !userIsAllowed(userUUID, models.RoleAdmin) {
-		return 403, `{"error": "No autorizado - Solo administradores"}`
-	}
-
 	if documentNumber == "" {
 		return 400, `{"error": "Número de documento requerido"}`
 	}
 
-	user, err := bd.GetUserByDocument(documentNumber)
+	user, err := repos.Admin.GetUserByDocument(documentNumber)
 	if err != nil {
 		if err.Error() == "usuario no encontrado" {
 			return 404, `{"error": "Usuario no encontrado con ese número de documento"}`
@@ -122,27 +106,30 @@ func GetUserByDocument(userUUID string, documentNumber string) (int, string) {
 }
 
 // UpdateEmployee actualiza un empleado
-func UpdateEmployee(body string, userUUID string, employeeID string) (int, string) {
+func UpdateEmployee(body string, employeeID string) (int, string) {
 	fmt.Printf("UpdateEmployee -> ID: %s\n", employeeID)
 
 	// Verificar permisos
-	if !userIsAllowed(userUUID, models.RoleAdmin) {
-		return 403, `{"error": "No autorizado - Solo administradores"}`
-	}
-
 	var req models.UpdateEmployeeRequest
 	err := json.Unmarshal([]byte(body), &req)
 	if err != nil {
 		return 400, fmt.Sprintf(`{"error": "Body inválido: %s"}`, err.Error())
 	}
 
-	err = bd.UpdateEmployee(employeeID, req)
+	// hub_id 0 quita el hub; cualquier otro debe existir
+	if req.HubID != nil && *req.HubID != 0 {
+		if _, status, message := findHub(*req.HubID); status != 0 {
+			return status, message
+		}
+	}
+
+	err = repos.Admin.UpdateEmployee(employeeID, req)
 	if err != nil {
 		return 500, fmt.Sprintf(`{"error": "Error al actualizar empleado: %s"}`, err.Error())
 	}
 
 	// Obtener empleado actualizado
-	employee, err := bd.GetEmployeeByID(employeeID)
+	employee, err := repos.Admin.GetEmployeeByID(employeeID)
 	if err != nil {
 		return 200, `{"success": true, "message": "Empleado actualizado correctamente"}`
 	}
@@ -157,21 +144,19 @@ func UpdateEmployee(body string, userUUID string, employeeID string) (int, strin
 	return 200, string(jsonResponse)
 }
 
-// GetClientRanking obtiene el ranking de mejores clientes
-func GetClientRanking(request events.APIGatewayV2HTTPRequest, userUUID string) (int, string) {
+// GetClientRanking obtiene el ranking de mejores clientes (guías con origen
+// en el hub del alcance)
+func GetClientRanking(request events.APIGatewayV2HTTPRequest, scope models.HubScope) (int, string) {
 	fmt.Println("GetClientRanking")
 
 	// Verificar permisos
-	if !userIsAllowed(userUUID, models.RoleAdmin) {
-		return 403, `{"error": "No autorizado - Solo administradores"}`
-	}
-
 	// Parse query parameters
 	filters := models.ClientRankingFilters{
 		SortBy:    "total_guides",
 		Order:     "desc",
 		Limit:     20,
 		MinGuides: 1,
+		HubID:     scope.HubID,
 	}
 
 	if request.QueryStringParameters != nil {
@@ -195,7 +180,7 @@ func GetClientRanking(request events.APIGatewayV2HTTPRequest, userUUID string) (
 		}
 	}
 
-	response, err := bd.GetClientRanking(filters)
+	response, err := repos.Admin.GetClientRanking(filters)
 	if err != nil {
 		return 500, fmt.Sprintf(`{"error": "Error al obtener ranking: %s"}`, err.Error())
 	}
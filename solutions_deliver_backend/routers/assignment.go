@@ -4,21 +4,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
-	"strings"
 
-	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/bd"
 	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
 	"github.com/aws/aws-lambda-go/events"
 )
 
-// CreateAssignment crea una nueva asignación (SECRETARY, ADMIN)
-func CreateAssignment(body string, userUUID string) (int, string) {
+// CreateAssignment crea una nueva asignación (SECRETARY, ADMIN). Con un
+// alcance por hub el repartidor debe pertenecer a ese hub.
+func CreateAssignment(body string, userUUID string, scope models.HubScope) (int, string) {
 	fmt.Println("CreateAssignment")
 
-	if !userIsAllowed(userUUID, models.RoleAdmin, models.RoleSecretary) {
-		return 403, `{"error": "No autorizado - Rol no permitido"}`
-	}
-
 	var req models.CreateAssignmentRequest
 	err := json.Unmarshal([]byte(body), &req)
 	if err != nil {
@@ -34,11 +29,37 @@ func CreateAssignment(body string, userUUID string) (int, string) {
 		return 400, `{"error": "delivery_user_id es requerido"}`
 	}
 
-	if req.AssignmentType != models.AssignmentPickup && req.AssignmentType != models.AssignmentDelivery {
-		return 400, `{"error": "assignment_type debe ser PICKUP o DELIVERY"}`
+	if !scope.Consolidated() {
+		courier, err := repos.Users.GetUserRole(req.DeliveryUserID)
+		if err != nil {
+			return 400, `{"error": "El repartidor no existe"}`
+		}
+		if !scope.Includes(courier.HubID) {
+			return 403, `{"error": "No autorizado - El repartidor no pertenece a tu hub"}`
+		}
+	}
+
+	if req.AssignmentType != models.AssignmentPickup && req.AssignmentType != models.AssignmentDelivery &&
+		req.AssignmentType != models.AssignmentReturn && req.AssignmentType != models.AssignmentTransfer {
+		return 400, `{"error": "assignment_type debe ser PICKUP, DELIVERY, RETURN o TRANSFER"}`
 	}
 
-	assignment, err := bd.CreateAssignment(req, userUUID)
+	if !scope.Consolidated() && req.AssignmentType != models.AssignmentTransfer {
+		if status, body := checkGuideInHub(req, *scope.HubID); status != 0 {
+			return status, body
+		}
+	}
+
+	// Vehículo y bodegas solo en los traslados entre ciudades
+	if req.AssignmentType == models.AssignmentTransfer {
+		if status, body := validateTransfer(&req); status != 0 {
+			return status, body
+		}
+	} else if req.VehiclePlate != "" || req.OriginWarehouseID != 0 || req.DestinationWarehouseID != 0 {
+		return 400, `{"error": "vehicle_plate, origin_warehouse_id y destination_warehouse_id solo aplican a traslados (TRANSFER)"}`
+	}
+
+	assignment, err := repos.Assignments.CreateAssignment(req, userUUID)
 	if err != nil {
 		return 500, fmt.Sprintf(`{"error": "Error al crear asignación de entregador: %s"}`, err.Error())
 	}
@@ -59,20 +80,44 @@ func CreateAssignment(body string, userUUID string) (int, string) {
 	return 201, string(jsonResponse)
 }
 
-// GetAssignmentByID obtiene una asignación por su ID
-func GetAssignmentByID(userUUID string, path string) (int, string) {
-	fmt.Printf("GetAssignmentByID empieza")
+// checkGuideInHub valida que el hub cubra la ciudad de la guía donde se hace
+// la asignación: el origen en las recogidas y el destino en las entregas y
+// devoluciones, como en v_pending_pickups y v_pending_deliveries.
+// Retorna status 0 si la guía está en la cobertura.
+func checkGuideInHub(req models.CreateAssignmentRequest, hubID int64) (int, string) {
+	guide, err := repos.Guides.GetGuideByID(req.GuideID)
+	if err != nil {
+		if err.Error() == "Guía no encontrada" {
+			return 404, `{"error": "Guía no encontrada"}`
+		}
+		return 500, fmt.Sprintf(`{"error": "Error al obtener la guía: %s"}`, err.Error())
+	}
 
-	assignmentID := extractIDFromPath(path, "/assignments/")
-	if assignmentID == 0 {
-		return 400, `{"error": "ID de asignación inválido"}`
+	cityID, place := guide.DestinationCityID, "destino"
+	if req.AssignmentType == models.AssignmentPickup {
+		cityID, place = guide.OriginCityID, "origen"
 	}
 
-	if !userIsAllowed(userUUID, models.RoleAdmin, models.RoleSecretary) {
-		return 403, `{"error": "No autorizado - Rol no permitido"}`
+	city, err := repos.Locations.GetCityByID(cityID)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al obtener la ciudad de %s de la guía: %s"}`, place, err.Error())
+	}
+
+	hub, status, body := findHub(hubID)
+	if status != 0 {
+		return status, body
+	}
+	if !hub.Active || !hub.Covers(city) {
+		return 403, fmt.Sprintf(`{"error": "No autorizado - La ciudad de %s de la guía no está en la cobertura de tu hub"}`, place)
 	}
+	return 0, ""
+}
+
+// GetAssignmentByID obtiene una asignación por su ID
+func GetAssignmentByID(assignmentID int64) (int, string) {
+	fmt.Printf("GetAssignmentByID -> AssignmentID: %d\n", assignmentID)
 
-	assignment, err := bd.GetAssignmentByID(assignmentID)
+	assignment, err := repos.Assignments.GetAssignmentByID(assignmentID)
 	if err != nil {
 		return 404, fmt.Sprintf(`{"error": "%s"}`, err.Error())
 	}
@@ -86,18 +131,9 @@ func GetAssignmentByID(userUUID string, path string) (int, string) {
 }
 
 // ReassingnDelivery reasigna una entrega a otro entregador
-func ReassignDelivery(body string, userUUID string, path string) (int, string) {
+func ReassignDelivery(body string, userUUID string, assignmentID int64) (int, string) {
 	fmt.Println("ReassignDelivery")
 
-	if !userIsAllowed(userUUID, models.RoleAdmin) {
-		return 403, `{"error": "No autorizado - Rol no permitido"}`
-	}
-
-	assignmentID := extractIDFromPathWithSuffix(path, "/assignments/", "/reassign")
-	if assignmentID == 0 {
-		return 400, `{"error": "ID de asignación inválido"}`
-	}
-
 	var req models.ReassignRequest
 	err := json.Unmarshal([]byte(body), &req)
 	if err != nil {
@@ -108,7 +144,7 @@ func ReassignDelivery(body string, userUUID string, path string) (int, string) {
 		return 400, `{"error": "new_delivery_user_id es requerido"}`
 	}
 
-	assignment, err := bd.ReassignDelivery(assignmentID, req.NewDeliveryUserID, req.Notes, userUUID)
+	assignment, err := repos.Assignments.ReassignDelivery(assignmentID, req.NewDeliveryUserID, req.Notes, userUUID)
 	if err != nil {
 		return 500, fmt.Sprintf(`{"error": "%s"}`, err.Error())
 	}
@@ -126,41 +162,15 @@ func ReassignDelivery(body string, userUUID string, path string) (int, string) {
 }
 
 // UpdateAssignmentStatus actualiza el estado de una asignación
-func UpdateAssignmentStatus(body string, userUUID string, path string) (int, string) {
+func UpdateAssignmentStatus(body string, userUUID string, assignmentID int64) (int, string) {
 	fmt.Println("UpdateAssignmentStatus")
 
-	if !userIsAllowed(userUUID, models.RoleAdmin, models.RoleDelivery, models.RoleSecretary) {
-		return 403, `{"error": "No autorizado - Rol no permitido"}`
-	}
-
-	assignmentID := extractIDFromPathWithSuffix(path, "/assignments/", "/status")
-	if assignmentID == 0 {
-		return 400, `{"error": "ID de asignación inválido"}`
-	}
-
 	var req models.UpdateAssignmentStatusRequest
 	err := json.Unmarshal([]byte(body), &req)
 	if err != nil {
 		return 400, fmt.Sprintf(`{"error": "Body inválido: %s"}`, err.Error())
 	}
 
-	// Verificar que DELIVERY solo pueda actualizar sus propias asignaciones
-	userRole, err := bd.GetUserRole(userUUID)
-	if err != nil {
-		return 500, fmt.Sprintf(`{"error": "Error al obtener rol: %s"}`, err.Error())
-	}
-
-	if userRole.Role == models.RoleDelivery {
-		// Verificar que la asignación pertenezca al usuario
-		currentAssignment, err := bd.GetAssignmentByID(assignmentID)
-		if err != nil {
-			return 404, fmt.Sprintf(`{"error": "%s"}`, err.Error())
-		}
-		if currentAssignment.DeliveryUserID != userUUID {
-			return 403, `{"error": "No autorizado - Solo puedes actualizar tus propias asignaciones"}`
-		}
-	}
-
 	validStatuses := []models.AssignmentStatus{
 		models.AssignmentPending,
 		models.AssignmentInProgress,
@@ -181,77 +191,119 @@ func UpdateAssignmentStatus(body string, userUUID string, path string) (int, str
 		return 400, `{"error": "status inválido. Valores permitidos: PENDING, IN_PROGRESS, COMPLETED, CANCELLED"}`
 	}
 
-	assignment, err := bd.UpdateAssignmentStatus(assignmentID, req.Status, req.Notes, userUUID)
+	current, err := repos.Assignments.GetAssignmentByID(assignmentID)
 	if err != nil {
-		return 500, fmt.Sprintf(`{"error": "%s"}`, err.Error())
+		return 404, fmt.Sprintf(`{"error": "%s"}`, err.Error())
 	}
 
-	// Log detallado para debug
-	fmt.Printf("=== ACTUALIZACIÓN DE ASIGNACIÓN ===\n")
-	fmt.Printf("AssignmentID: %d\n", assignment.AssignmentID)
-	fmt.Printf("GuideID: %d\n", assignment.GuideID)
-	fmt.Printf("AssignmentType: '%s'\n", assignment.AssignmentType)
-	fmt.Printf("Nuevo Status de Asignación: '%s'\n", req.Status)
-	fmt.Printf("Comparación PICKUP: %v (tipo='%s', esperado='%s')\n",
-		assignment.AssignmentType == models.AssignmentPickup,
-		assignment.AssignmentType,
-		models.AssignmentPickup)
-	fmt.Printf("Comparación COMPLETED: %v (status='%s', esperado='%s')\n",
-		req.Status == models.AssignmentCompleted,
-		req.Status,
-		models.AssignmentCompleted)
-
-	// Actualizar automáticamente el estado de la guía según el tipo de asignación y nuevo estado
-	var newGuideStatus models.GuideStatus
-	shouldUpdateGuide := false
-	guideStatusMessage := ""
+	// El traslado se completa al llegar a la bodega de destino: antes debe salir
+	if current.AssignmentType == models.AssignmentTransfer && req.Status == models.AssignmentCompleted &&
+		current.Status != models.AssignmentInProgress {
+		return 409, `{"error": "El traslado debe iniciarse (IN_PROGRESS) antes de completarse"}`
+	}
+
+	// Completar una entrega exige la prueba de entrega
+	isDeliveryCompletion := current.AssignmentType == models.AssignmentDelivery && req.Status == models.AssignmentCompleted
+	if req.Proof != nil && !isDeliveryCompletion {
+		return 400, `{"error": "proof solo aplica al completar una entrega"}`
+	}
+	if isDeliveryCompletion {
+		if req.Proof == nil {
+			req.Proof = &models.DeliveryProofRequest{}
+		}
+		if status, body := validateDeliveryProof(req.Proof, current); status != 0 {
+			return status, body
+		}
+	}
 
-	// Usar string comparison para mayor seguridad
-	assignmentTypeStr := string(assignment.AssignmentType)
-	statusStr := string(req.Status)
-
-	if assignmentTypeStr == "PICKUP" && statusStr == "COMPLETED" {
-		// PICKUP completado -> Guía pasa a EN RUTA
-		newGuideStatus = models.StatusInRoute
-		shouldUpdateGuide = true
-		guideStatusMessage = "Guía actualizada a 'En ruta'"
-		fmt.Printf(">>> Condición PICKUP+COMPLETED cumplida\n")
-	} else if assignmentTypeStr == "DELIVERY" {
-		if statusStr == "IN_PROGRESS" {
-			// DELIVERY iniciado -> Guía pasa a EN REPARTO
-			newGuideStatus = models.StatusOutForDelivery
-			shouldUpdateGuide = true
-			guideStatusMessage = "Guía actualizada a 'En reparto'"
-			fmt.Printf(">>> Condición DELIVERY+IN_PROGRESS cumplida\n")
-		} else if statusStr == "COMPLETED" {
-			// DELIVERY completado -> Guía pasa a ENTREGADA
-			newGuideStatus = models.StatusDelivered
-			shouldUpdateGuide = true
-			guideStatusMessage = "Guía actualizada a 'Entregada'"
-			fmt.Printf(">>> Condición DELIVERY+COMPLETED cumplida\n")
+	guide, err := repos.Guides.GetGuideByID(current.GuideID)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al obtener la guía de la asignación: %s"}`, err.Error())
+	}
+
+	// Estado al que pasa la guía según el tipo de asignación y su nuevo estado.
+	// La cascada se valida con la máquina de estados antes de tocar la
+	// asignación, para no dejar la asignación y la guía en desacuerdo.
+	newGuideStatus, shouldUpdateGuide := models.GuideStatusForAssignment(current.AssignmentType, req.Status, guide.CurrentStatus)
+	if shouldUpdateGuide && newGuideStatus == guide.CurrentStatus {
+		// La guía ya está en ese estado (p.ej. actualizada manualmente)
+		shouldUpdateGuide = false
+	}
+	if shouldUpdateGuide {
+		transition, ok := models.FindGuideTransition(guide.CurrentStatus, newGuideStatus)
+		if !ok || !transition.Allows("", models.TriggerAssignment) {
+			return guideTransitionConflict(guide.CurrentStatus, newGuideStatus, "", models.TriggerAssignment)
 		}
 	}
 
-	fmt.Printf("shouldUpdateGuide: %v\n", shouldUpdateGuide)
+	// Guías COD o de alto valor: el destinatario confirma con el código de entrega
+	if isDeliveryCompletion && requiresHandoverCode(guide) {
+		if status, body := checkHandoverCode(assignmentID, req.HandoverCode, userUUID); status != 0 {
+			return status, body
+		}
+	}
 
+	// La asignación y la guía cambian en la misma transacción
+	var guideChange *models.GuideStatusChange
 	if shouldUpdateGuide {
-		fmt.Printf(">>> Intentando actualizar guía %d a estado %s\n", assignment.GuideID, newGuideStatus)
-		err = bd.UpdateGuideStatus(assignment.GuideID, newGuideStatus, userUUID)
+		guideChange = &models.GuideStatusChange{Status: newGuideStatus}
+		// La devolución completada cierra la guía por intentos agotados
+		if current.AssignmentType == models.AssignmentReturn {
+			guideChange.Reason = models.ReasonMaxAttempts
+		}
+	}
+
+	var assignment models.DeliveryAssignment
+	if isDeliveryCompletion {
+		assignment, err = repos.Assignments.CompleteDeliveryWithProof(assignmentID, *req.Proof, req.Notes, userUUID, guideChange)
+	} else {
+		assignment, err = repos.Assignments.UpdateAssignmentStatus(assignmentID, req.Status, req.Notes, userUUID, guideChange)
+	}
+	if err != nil {
+		switch err.Error() {
+		case "la asignación ya está cerrada":
+			return 409, fmt.Sprintf(`{"error": "%s"}`, err.Error())
+		case "transición de estado no permitida":
+			// La guía cambió de estado entre la validación y la transacción
+			if latest, getErr := repos.Guides.GetGuideByID(current.GuideID); getErr == nil {
+				return guideTransitionConflict(latest.CurrentStatus, newGuideStatus, "", models.TriggerAssignment)
+			}
+			return 409, fmt.Sprintf(`{"error": "%s"}`, err.Error())
+		}
+		return 500, fmt.Sprintf(`{"error": "%s"}`, err.Error())
+	}
+
+	fmt.Printf("UpdateAssignmentStatus -> AssignmentID: %d, GuideID: %d, Type: %s, Status: %s\n",
+		assignment.AssignmentID, assignment.GuideID, assignment.AssignmentType, req.Status)
+
+	guideStatusMessage := ""
+	if shouldUpdateGuide {
+		guideStatusMessage = fmt.Sprintf("Guía actualizada a '%s'", newGuideStatus.Label())
+		// Al salir en un traslado la guía deja los bins de la bodega de origen
+		if assignment.AssignmentType == models.AssignmentTransfer && newGuideStatus == models.StatusInRoute {
+			checkOutTransferred(assignment, userUUID)
+		}
+	}
+
+	// Al salir a entregar se envía el código al destinatario
+	handoverMessage := ""
+	if assignment.AssignmentType == models.AssignmentDelivery && req.Status == models.AssignmentInProgress && requiresHandoverCode(guide) {
+		code, err := issueHandoverCode(assignment, guide, userUUID)
 		if err != nil {
-			fmt.Printf("!!! ERROR al actualizar estado de guía %d: %s\n", assignment.GuideID, err.Error())
-			// No retornamos error porque la asignación ya se actualizó correctamente
+			fmt.Printf("!!! ERROR al generar código de entrega de la asignación %d: %s\n", assignment.AssignmentID, err.Error())
+			handoverMessage = fmt.Sprintf("No se pudo enviar el código de entrega: %s", err.Error())
 		} else {
-			fmt.Printf(">>> ÉXITO: Guía %d actualizada a estado %s\n", assignment.GuideID, newGuideStatus)
+			handoverMessage = fmt.Sprintf("Código de entrega enviado a %s", code.SentTo)
 		}
-	} else {
-		fmt.Printf(">>> No se requiere actualizar la guía\n")
 	}
-	fmt.Printf("=== FIN ACTUALIZACIÓN ===\n")
 
 	message := "Estado de asignación actualizado correctamente"
 	if guideStatusMessage != "" {
 		message = message + ". " + guideStatusMessage
 	}
+	if handoverMessage != "" {
+		message = message + ". " + handoverMessage
+	}
 
 	response := models.UpdateAssignmentStatusResponse{
 		Success:    true,
@@ -263,13 +315,15 @@ func UpdateAssignmentStatus(body string, userUUID string, path string) (int, str
 	return 200, string(jsonResponse)
 }
 
-// GetAssignments obtiene lista de asignaciones con filtros
-func GetAssignments(request events.APIGatewayV2HTTPRequest) (int, string) {
+// GetAssignments obtiene lista de asignaciones con filtros (de los
+// repartidores del hub del alcance)
+func GetAssignments(request events.APIGatewayV2HTTPRequest, scope models.HubScope) (int, string) {
 	fmt.Println("GetAssignments")
 
 	filters := models.AssignmentFilters{
 		Limit:  50,
 		Offset: 0,
+		HubID:  scope.HubID,
 	}
 
 	// Parsear query params
@@ -308,7 +362,7 @@ func GetAssignments(request events.APIGatewayV2HTTPRequest) (int, string) {
 		}
 	}
 
-	assignments, total, err := bd.GetAssignmentsByFilters(filters)
+	assignments, total, err := repos.Assignments.GetAssignmentsByFilters(filters)
 	if err != nil {
 		return 500, fmt.Sprintf(`{"error": "%s"}`, err.Error())
 	}
@@ -332,11 +386,7 @@ func GetAssignments(request events.APIGatewayV2HTTPRequest) (int, string) {
 func GetMyAssignments(userUUID string) (int, string) {
 	fmt.Printf("GetMyAssignments -> UserID: %s\n", userUUID)
 
-	if !userIsAllowed(userUUID, models.RoleDelivery) {
-		return 403, `{"error": "No autorizado - Rol no permitido"}`
-	}
-
-	response, err := bd.GetMyAssignments(userUUID)
+	response, err := repos.Assignments.GetMyAssignments(userUUID)
 	if err != nil {
 		return 500, fmt.Sprintf(`{"Error al obtener mis asignaciones": "%s"}`, err.Error())
 	}
@@ -349,15 +399,19 @@ func GetMyAssignments(userUUID string) (int, string) {
 		response.Deliveries = []models.DeliveryAssignment{}
 	}
 
+	if response.Transfers == nil {
+		response.Transfers = []models.DeliveryAssignment{}
+	}
+
 	jsonResponse, err := json.Marshal(response)
 	return 200, string(jsonResponse)
 }
 
-// GetDeliveryUsers obtiene la lista de entregadores disponibles
-func GetDeliveryUsers() (int, string) {
+// GetDeliveryUsers obtiene la lista de entregadores disponibles del alcance
+func GetDeliveryUsers(scope models.HubScope) (int, string) {
 	fmt.Println("GetDeliveryUsers")
 
-	users, err := bd.GetDeliveryUsers()
+	users, err := repos.Assignments.GetDeliveryUsers(scope)
 	if err != nil {
 		return 500, fmt.Sprintf(`{"Error al obtener repartidores": "%s"}`, err.Error())
 	}
@@ -379,27 +433,29 @@ func GetDeliveryUsers() (int, string) {
 	return 200, string(jsonResponse)
 }
 
-// GetPendingGuides obtiene la lista de guías pendientes
-func GetPendingGuides(userUUID string) (int, string) {
+// GetPendingGuides obtiene la lista de guías pendientes del alcance
+func GetPendingGuides(scope models.HubScope) (int, string) {
 	fmt.Println("GetPendingGuides")
 
-	if !userIsAllowed(userUUID, models.RoleAdmin, models.RoleSecretary) {
-		return 403, `{"error": "No autorizado - Rol no permitido"}`
-	}
-
-	pickups, err := bd.GetPendingPickups()
+	pickups, err := repos.Assignments.GetPendingPickups(scope)
 	if err != nil {
 		return 500, fmt.Sprintf(`{"Error": "Error al obtener guías por recoger: %s"}`, err.Error())
 	}
 
-	deliveries, err := bd.GetPendingDeliveries()
+	deliveries, err := repos.Assignments.GetPendingDeliveries(scope)
 	if err != nil {
 		return 500, fmt.Sprintf(`{"Error": "Error al obtener guías por entregar: %s"}`, err.Error())
 	}
 
+	transfers, err := repos.Assignments.GetPendingTransfers(scope)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"Error": "Error al obtener guías por trasladar: %s"}`, err.Error())
+	}
+
 	response := models.PendingGuidesResponse{
 		Pickups:    pickups,
 		Deliveries: deliveries,
+		Transfers:  transfers,
 	}
 
 	if response.Pickups == nil {
@@ -410,6 +466,10 @@ func GetPendingGuides(userUUID string) (int, string) {
 		response.Deliveries = []models.PendingGuide{}
 	}
 
+	if response.Transfers == nil {
+		response.Transfers = []models.PendingGuide{}
+	}
+
 	jsonResponse, err := json.Marshal(response)
 	if err != nil {
 		return 500, fmt.Sprintf(`{"error": "Error al serializar respuesta: %s"}`, err.Error())
@@ -419,14 +479,10 @@ func GetPendingGuides(userUUID string) (int, string) {
 }
 
 // GetMyAssigmentStats obtiene estadisticas de asignaciones
-func GetAssignmentStats(userUUID string) (int, string) {
+func GetAssignmentStats(scope models.HubScope) (int, string) {
 	fmt.Printf("GetMyAssigmentStats")
 
-	if !userIsAllowed(userUUID, models.RoleAdmin, models.RoleSecretary) {
-		return 403, `{"error": "No autorizado - Rol no permitido"}`
-	}
-
-	stats, err := bd.GetAssignmentStats()
+	stats, err := repos.Assignments.GetAssignmentStats(scope)
 	if err != nil {
 		return 500, fmt.Sprintf(`{"error": "Error al obtener estadisticas de los entregadores: %s"}`, err.Error())
 	}
@@ -440,19 +496,10 @@ func GetAssignmentStats(userUUID string) (int, string) {
 }
 
 // GetAssignmentHistory obtiene el historial de una asignación
-func GetAssignmentHistory(userUUID string, path string) (int, string) {
+func GetAssignmentHistory(assignmentID int64) (int, string) {
 	fmt.Printf("GetAssignmentHistory empieza")
 
-	if !userIsAllowed(userUUID, models.RoleAdmin, models.RoleSecretary) {
-		return 403, `{"error": "No autorizado - Rol no permitido"}`
-	}
-
-	assignmentID := extractIDFromPathWithSuffix(path, "/assignments/", "/history")
-	if assignmentID == 0 {
-		return 400, `{"error": "ID de asignación inválido"}`
-	}
-
-	history, err := bd.GetAssignmentHistory(assignmentID)
+	history, err := repos.Assignments.GetAssignmentHistory(assignmentID)
 	if err != nil {
 		return 500, fmt.Sprintf(`{"error": "Error al obtener el historial de la asignación %d: %s"}`, assignmentID, err.Error())
 	}
@@ -468,49 +515,3 @@ func GetAssignmentHistory(userUUID string, path string) (int, string) {
 
 	return 200, string(jsonResponse)
 }
-
-// extractIDFromPath extrae el ID númerico de una ruta
-func extractIDFromPath(path, prefix string) int64 {
-	idStr := strings.TrimPrefix(path, prefix)
-	id, err := strconv.ParseInt(idStr, 10, 64)
-	if err != nil {
-		return 0
-	}
-	return id
-}
-
-// hasRole verifica si el usuario tiene alguno de los roles permitidos
-func hasRole(userRole models.UserRole, allowedRoles ...models.UserRole) bool {
-	for _, role := range allowedRoles {
-		if userRole == role {
-			return true
-		}
-	}
-	return false
-}
-
-func userIsAllowed(userUUID string, allowedRoles ...models.UserRole) bool {
-	userRole, err := bd.GetUserRole(userUUID)
-	if err != nil {
-		fmt.Printf("Error obteniendo rol: %s\n", err.Error())
-		return false
-	}
-
-	if !hasRole(userRole.Role, allowedRoles...) {
-		return false
-	}
-
-	return true
-}
-
-// extractIDFromPathWithSuffix extrae el ID de una ruta con sufijo
-func extractIDFromPathWithSuffix(path, prefix, suffix string) int64 {
-	// Ejemplo: "assignments/123/reassign" -> 123
-	path = strings.TrimPrefix(path, prefix)
-	path = strings.TrimSuffix(path, suffix)
-	id, err := strconv.ParseInt(path, 10, 64)
-	if err != nil {
-		return 0
-	}
-	return id
-}
@@ -0,0 +1,252 @@
+package routers
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/repository/memory"
+)
+
+// newTestStore inicializa los repositorios de routers sobre un Store en memoria
+func newTestStore(t *testing.T) *memory.Store {
+	t.Helper()
+	r, store := memory.NewRepositories()
+	InitRepositories(r)
+	return store
+}
+
+func TestUpdateAssignmentStatusCascade(t *testing.T) {
+	// Prueba de entrega mínima: solo el nombre de quien recibe
+	t.Setenv("DELIVERY_PROOF_REQUIRED", "RECEIVER_NAME")
+
+	const proof = `, "proof": {"receiver_name": "Ana Pérez"}`
+
+	tests := []struct {
+		name             string
+		assignmentType   models.AssignmentType
+		assignmentStatus models.AssignmentStatus
+		guideStatus      models.GuideStatus
+		newStatus        models.AssignmentStatus
+		extra            string
+		// esperado
+		status          int
+		wantAssignment  models.AssignmentStatus
+		wantGuide       models.GuideStatus
+		wantGuideReason models.GuideStatusReason
+	}{
+		// PICKUP
+		{"recogida iniciada", models.AssignmentPickup, models.AssignmentPending, models.StatusCreated, models.AssignmentInProgress, "",
+			200, models.AssignmentInProgress, models.StatusCreated, ""},
+		{"recogida completada", models.AssignmentPickup, models.AssignmentInProgress, models.StatusCreated, models.AssignmentCompleted, "",
+			200, models.AssignmentCompleted, models.StatusInRoute, ""},
+		{"recogida cancelada", models.AssignmentPickup, models.AssignmentPending, models.StatusCreated, models.AssignmentCancelled, "",
+			200, models.AssignmentCancelled, models.StatusCreated, ""},
+		{"recogida pendiente", models.AssignmentPickup, models.AssignmentPending, models.StatusCreated, models.AssignmentPending, "",
+			200, models.AssignmentPending, models.StatusCreated, ""},
+		{"recogida de guía anulada", models.AssignmentPickup, models.AssignmentInProgress, models.StatusCancelled, models.AssignmentCompleted, "",
+			409, models.AssignmentInProgress, models.StatusCancelled, ""},
+
+		// DELIVERY
+		{"entrega pendiente", models.AssignmentDelivery, models.AssignmentPending, models.StatusInWarehouse, models.AssignmentPending, "",
+			200, models.AssignmentPending, models.StatusInWarehouse, ""},
+		{"entrega iniciada", models.AssignmentDelivery, models.AssignmentPending, models.StatusInWarehouse, models.AssignmentInProgress, "",
+			200, models.AssignmentInProgress, models.StatusOutForDelivery, ""},
+		{"reintento de entrega", models.AssignmentDelivery, models.AssignmentPending, models.StatusDeliveryFailed, models.AssignmentInProgress, "",
+			200, models.AssignmentInProgress, models.StatusOutForDelivery, ""},
+		{"entrega completada", models.AssignmentDelivery, models.AssignmentInProgress, models.StatusOutForDelivery, models.AssignmentCompleted, proof,
+			200, models.AssignmentCompleted, models.StatusDelivered, ""},
+		{"entrega sin prueba", models.AssignmentDelivery, models.AssignmentInProgress, models.StatusOutForDelivery, models.AssignmentCompleted, "",
+			400, models.AssignmentInProgress, models.StatusOutForDelivery, ""},
+		{"entrega sin salir", models.AssignmentDelivery, models.AssignmentPending, models.StatusInWarehouse, models.AssignmentCompleted, proof,
+			409, models.AssignmentPending, models.StatusInWarehouse, ""},
+		{"entrega cancelada en reparto", models.AssignmentDelivery, models.AssignmentInProgress, models.StatusOutForDelivery, models.AssignmentCancelled, "",
+			200, models.AssignmentCancelled, models.StatusInWarehouse, ""},
+		{"entrega pendiente cancelada", models.AssignmentDelivery, models.AssignmentPending, models.StatusInWarehouse, models.AssignmentCancelled, "",
+			200, models.AssignmentCancelled, models.StatusInWarehouse, ""},
+		{"prueba fuera de una entrega", models.AssignmentPickup, models.AssignmentInProgress, models.StatusCreated, models.AssignmentCompleted, proof,
+			400, models.AssignmentInProgress, models.StatusCreated, ""},
+
+		// TRANSFER
+		{"traslado pendiente", models.AssignmentTransfer, models.AssignmentPending, models.StatusInWarehouse, models.AssignmentPending, "",
+			200, models.AssignmentPending, models.StatusInWarehouse, ""},
+		{"traslado iniciado", models.AssignmentTransfer, models.AssignmentPending, models.StatusInWarehouse, models.AssignmentInProgress, "",
+			200, models.AssignmentInProgress, models.StatusInRoute, ""},
+		{"traslado completado", models.AssignmentTransfer, models.AssignmentInProgress, models.StatusInRoute, models.AssignmentCompleted, "",
+			200, models.AssignmentCompleted, models.StatusInWarehouse, ""},
+		{"traslado completado sin salir", models.AssignmentTransfer, models.AssignmentPending, models.StatusInWarehouse, models.AssignmentCompleted, "",
+			409, models.AssignmentPending, models.StatusInWarehouse, ""},
+		{"traslado cancelado en ruta", models.AssignmentTransfer, models.AssignmentInProgress, models.StatusInRoute, models.AssignmentCancelled, "",
+			200, models.AssignmentCancelled, models.StatusInWarehouse, ""},
+		{"traslado pendiente cancelado", models.AssignmentTransfer, models.AssignmentPending, models.StatusInWarehouse, models.AssignmentCancelled, "",
+			200, models.AssignmentCancelled, models.StatusInWarehouse, ""},
+
+		// RETURN
+		{"devolución pendiente", models.AssignmentReturn, models.AssignmentPending, models.StatusDeliveryFailed, models.AssignmentPending, "",
+			200, models.AssignmentPending, models.StatusDeliveryFailed, ""},
+		{"devolución iniciada", models.AssignmentReturn, models.AssignmentPending, models.StatusDeliveryFailed, models.AssignmentInProgress, "",
+			200, models.AssignmentInProgress, models.StatusDeliveryFailed, ""},
+		{"devolución completada", models.AssignmentReturn, models.AssignmentInProgress, models.StatusDeliveryFailed, models.AssignmentCompleted, "",
+			200, models.AssignmentCompleted, models.StatusReturnedToSender, models.ReasonMaxAttempts},
+		{"devolución de guía en bodega", models.AssignmentReturn, models.AssignmentInProgress, models.StatusInWarehouse, models.AssignmentCompleted, "",
+			409, models.AssignmentInProgress, models.StatusInWarehouse, ""},
+		{"devolución cancelada", models.AssignmentReturn, models.AssignmentPending, models.StatusDeliveryFailed, models.AssignmentCancelled, "",
+			200, models.AssignmentCancelled, models.StatusDeliveryFailed, ""},
+
+		{"estado inválido", models.AssignmentPickup, models.AssignmentPending, models.StatusCreated, "DONE", "",
+			400, models.AssignmentPending, models.StatusCreated, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTestStore(t)
+			guideID := store.AddGuide(models.ShippingGuide{CurrentStatus: tt.guideStatus})
+			assignmentID := store.AddAssignment(models.DeliveryAssignment{
+				GuideID:        guideID,
+				AssignmentType: tt.assignmentType,
+				Status:         tt.assignmentStatus,
+				DeliveryUserID: "delivery-1",
+			})
+
+			body := fmt.Sprintf(`{"status": "%s"%s}`, tt.newStatus, tt.extra)
+			status, response := UpdateAssignmentStatus(body, "delivery-1", assignmentID)
+			if status != tt.status {
+				t.Fatalf("status = %d (%s), se esperaba %d", status, response, tt.status)
+			}
+
+			assignment, _ := repos.Assignments.GetAssignmentByID(assignmentID)
+			if assignment.Status != tt.wantAssignment {
+				t.Errorf("asignación = %s, se esperaba %s", assignment.Status, tt.wantAssignment)
+			}
+
+			guide, _ := repos.Guides.GetGuideByID(guideID)
+			if guide.CurrentStatus != tt.wantGuide {
+				t.Errorf("guía = %s, se esperaba %s", guide.CurrentStatus, tt.wantGuide)
+			}
+
+			changes := store.StatusChanges()
+			if tt.wantGuide == tt.guideStatus {
+				if len(changes) != 0 {
+					t.Errorf("la guía no debía cambiar de estado: %v", changes)
+				}
+				return
+			}
+			if len(changes) != 1 {
+				t.Fatalf("cambios de estado = %v, se esperaba uno", changes)
+			}
+			if changes[0].Reason != tt.wantGuideReason {
+				t.Errorf("motivo = %s, se esperaba %s", changes[0].Reason, tt.wantGuideReason)
+			}
+		})
+	}
+}
+
+// La entrega completada cierra las demás entregas abiertas de la guía
+func TestUpdateAssignmentStatusCancelsOpenDeliveries(t *testing.T) {
+	t.Setenv("DELIVERY_PROOF_REQUIRED", "RECEIVER_NAME")
+
+	store := newTestStore(t)
+	guideID := store.AddGuide(models.ShippingGuide{CurrentStatus: models.StatusOutForDelivery})
+	assignmentID := store.AddAssignment(models.DeliveryAssignment{
+		GuideID: guideID, AssignmentType: models.AssignmentDelivery, Status: models.AssignmentInProgress, DeliveryUserID: "delivery-1",
+	})
+	otherID := store.AddAssignment(models.DeliveryAssignment{
+		GuideID: guideID, AssignmentType: models.AssignmentDelivery, Status: models.AssignmentPending, DeliveryUserID: "delivery-2",
+	})
+
+	body := `{"status": "COMPLETED", "proof": {"receiver_name": "Ana Pérez"}}`
+	if status, response := UpdateAssignmentStatus(body, "delivery-1", assignmentID); status != 200 {
+		t.Fatalf("status = %d (%s), se esperaba 200", status, response)
+	}
+
+	other, _ := repos.Assignments.GetAssignmentByID(otherID)
+	if other.Status != models.AssignmentCancelled {
+		t.Errorf("entrega abierta = %s, se esperaba CANCELLED", other.Status)
+	}
+	if _, err := repos.Assignments.GetDeliveryProofByGuide(guideID); err != nil {
+		t.Errorf("prueba de entrega no registrada: %v", err)
+	}
+}
+
+// Si la guía no puede hacer la transición dentro de la transacción, la
+// asignación tampoco cambia
+func TestUpdateAssignmentStatusGuideConflictIsAtomic(t *testing.T) {
+	store := newTestStore(t)
+	guideID := store.AddGuide(models.ShippingGuide{CurrentStatus: models.StatusCancelled})
+	assignmentID := store.AddAssignment(models.DeliveryAssignment{
+		GuideID: guideID, AssignmentType: models.AssignmentPickup, Status: models.AssignmentInProgress, DeliveryUserID: "delivery-1",
+	})
+
+	change := &models.GuideStatusChange{Status: models.StatusInRoute}
+	_, err := repos.Assignments.UpdateAssignmentStatus(assignmentID, models.AssignmentCompleted, "", "delivery-1", change)
+	if err == nil || err.Error() != "transición de estado no permitida" {
+		t.Fatalf("error = %v, se esperaba transición de estado no permitida", err)
+	}
+
+	assignment, _ := repos.Assignments.GetAssignmentByID(assignmentID)
+	if assignment.Status != models.AssignmentInProgress {
+		t.Errorf("asignación = %s, se esperaba IN_PROGRESS", assignment.Status)
+	}
+	history, _ := repos.Assignments.GetAssignmentHistory(assignmentID)
+	if len(history) != 0 {
+		t.Errorf("historial = %v, se esperaba vacío", history)
+	}
+}
+
+// La secretaria solo asigna guías de la cobertura de su hub: el origen en
+// las recogidas y el destino en las entregas y devoluciones
+func TestCreateAssignmentHubCoverage(t *testing.T) {
+	const (
+		bogota   = 11001
+		soacha   = 25754
+		medellin = 5001
+		envigado = 5266
+	)
+
+	tests := []struct {
+		name           string
+		assignmentType models.AssignmentType
+		origin         int64
+		destination    int64
+		status         int
+	}{
+		{"recogida en la ciudad del hub", models.AssignmentPickup, bogota, medellin, 201},
+		{"recogida en el departamento del hub", models.AssignmentPickup, soacha, medellin, 201},
+		{"recogida fuera del hub", models.AssignmentPickup, medellin, bogota, 403},
+		{"entrega en la ciudad del hub", models.AssignmentDelivery, medellin, bogota, 201},
+		{"entrega fuera del hub", models.AssignmentDelivery, bogota, envigado, 403},
+		{"devolución en el destino del hub", models.AssignmentReturn, medellin, soacha, 201},
+		{"devolución fuera del hub", models.AssignmentReturn, bogota, medellin, 403},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTestStore(t)
+			store.AddCity(models.City{ID: bogota, Name: "Bogotá", DepartmentID: 11})
+			store.AddCity(models.City{ID: soacha, Name: "Soacha", DepartmentID: 25})
+			store.AddCity(models.City{ID: medellin, Name: "Medellín", DepartmentID: 5})
+			store.AddCity(models.City{ID: envigado, Name: "Envigado", DepartmentID: 5})
+			hubID := store.AddHub(models.Hub{Code: "BOG", CityID: bogota, Coverage: []models.HubCoverage{
+				{CityID: bogota}, {DepartmentID: 25},
+			}})
+			store.AddUser(models.User{UserUUID: "delivery-1", Role: models.RoleDelivery, HubID: &hubID})
+
+			guideID := store.AddGuide(models.ShippingGuide{
+				CurrentStatus: models.StatusCreated, OriginCityID: tt.origin, DestinationCityID: tt.destination,
+			})
+
+			body := fmt.Sprintf(`{"guide_id": %d, "delivery_user_id": "delivery-1", "assignment_type": "%s"}`, guideID, tt.assignmentType)
+			status, response := CreateAssignment(body, "secretary", models.HubScope{HubID: &hubID})
+			if status != tt.status {
+				t.Fatalf("status = %d (%s), se esperaba %d", status, response, tt.status)
+			}
+
+			// El consolidado (ADMIN) no depende de la cobertura
+			if status == 403 {
+				if status, response := CreateAssignment(body, "admin", models.HubScope{}); status != 201 {
+					t.Errorf("consolidado = %d (%s), se esperaba 201", status, response)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package routers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/utils"
+)
+
+// Tamaño de las imágenes: píxeles por módulo y alto de las barras
+const (
+	barcodeModuleWidth = 2
+	barcodeHeight      = 80
+	qrModuleScale      = 8
+)
+
+// GetGuideBarcode genera el código de la guía como imagen: Code 128 o QR,
+// en PNG o SVG. Codifica el número de guía (o el guide_id si la guía es
+// anterior a los números de guía); ambos se aceptan en POST /scans. Con
+// piece codifica el código de esa pieza.
+func GetGuideBarcode(guideID int64, codeType string, format string, piece string) (int, string) {
+	fmt.Printf("GetGuideBarcode -> GuideID: %d, Type: %s, Format: %s, Piece: %s\n", guideID, codeType, format, piece)
+
+	codeType = strings.ToLower(strings.TrimSpace(codeType))
+	if codeType == "" {
+		codeType = "code128"
+	}
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format == "" {
+		format = "png"
+	}
+
+	if codeType != "code128" && codeType != "qr" {
+		return 400, `{"error": "type debe ser code128 o qr"}`
+	}
+	if format != "png" && format != "svg" {
+		return 400, `{"error": "format debe ser png o svg"}`
+	}
+
+	guide, err := repos.Guides.GetGuideByID(guideID)
+	if err != nil {
+		if err.Error() == "Guía no encontrada" {
+			return 404, `{"error": "Guía no encontrada"}`
+		}
+		return 500, fmt.Sprintf(`{"error": "Error al obtener la guía: %s"}`, err.Error())
+	}
+
+	value := guide.GuideNumber
+	if value == "" {
+		value = strconv.FormatInt(guideID, 10)
+	}
+
+	if piece = strings.TrimSpace(piece); piece != "" {
+		number, err := strconv.Atoi(piece)
+		if err != nil || number < 1 {
+			return 400, `{"error": "piece debe ser un número mayor a 0"}`
+		}
+		detail, ok := models.FindPiece(guide.Pieces, number)
+		if !ok {
+			return 404, fmt.Sprintf(`{"error": "La guía no tiene la pieza %d"}`, number)
+		}
+		value = detail.PieceCode
+	}
+
+	data, err := renderBarcode(value, codeType, format)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al generar el código: %s"}`, err.Error())
+	}
+
+	contentType := "image/png"
+	if format == "svg" {
+		contentType = "image/svg+xml"
+	}
+
+	response := models.BarcodeResponse{
+		GuideID:     guideID,
+		GuideNumber: guide.GuideNumber,
+		Value:       value,
+		Type:        codeType,
+		Format:      format,
+		FileName:    fmt.Sprintf("guia-%s-%s.%s", value, codeType, format),
+		ContentType: contentType,
+		Content:     base64.StdEncoding.EncodeToString(data),
+	}
+
+	jsonResponse, err := json.Marshal(response)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al serializar respuesta: %s"}`, err.Error())
+	}
+
+	return 200, string(jsonResponse)
+}
+
+func renderBarcode(value string, codeType string, format string) ([]byte, error) {
+	if codeType == "qr" {
+		matrix, err := utils.EncodeQR(value)
+		if err != nil {
+			return nil, err
+		}
+		if format == "svg" {
+			return utils.RenderMatrixSVG(matrix, qrModuleScale, utils.QRQuietModules), nil
+		}
+		return utils.RenderMatrixPNG(matrix, qrModuleScale, utils.QRQuietModules)
+	}
+
+	bars, err := utils.EncodeCode128(value)
+	if err != nil {
+		return nil, err
+	}
+	if format == "svg" {
+		return utils.RenderBarsSVG(bars, value, barcodeModuleWidth, barcodeHeight), nil
+	}
+	return utils.RenderBarsPNG(bars, barcodeModuleWidth, barcodeHeight)
+}
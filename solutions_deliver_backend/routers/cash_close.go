@@ -24,8 +24,9 @@ func init() {
 	}
 }
 
-// GenerateCashClose generates a cash close
-func GenerateCashClose(body string, userUUID string) (int, string) {
+// GenerateCashClose generates a cash close. With a hub scope only guides
+// originated in that hub are included and the close is stamped with the hub.
+func GenerateCashClose(body string, userUUID string, scope models.HubScope) (int, string) {
 	fmt.Println("GenerateCashClose")
 
 	var request models.CashCloseRequest
@@ -73,7 +74,7 @@ func GenerateCashClose(body string, userUUID string) (int, string) {
 	}
 
 	// Get guides for the period
-	details, err := bd.GetGuidesForCashClose(startDate, endDate)
+	details, err := repos.CashCloses.GetGuidesForCashClose(startDate, endDate, scope)
 	if err != nil {
 		return 500, fmt.Sprintf(`{"error": "Error getting guides: %s"}`, err.Error())
 	}
@@ -87,8 +88,16 @@ func GenerateCashClose(body string, userUUID string) (int, string) {
 		PeriodType: request.PeriodType,
 		StartDate:  startDate,
 		EndDate:    endDate,
+		HubID:      scope.HubID,
 		CreatedBy:  userUUID,
 	}
+	if !scope.Consolidated() {
+		hub, status, message := findHub(*scope.HubID)
+		if status != 0 {
+			return status, message
+		}
+		close.HubCode = hub.Code
+	}
 
 	for _, detail := range details {
 		close.TotalGuides++
@@ -111,7 +120,7 @@ func GenerateCashClose(body string, userUUID string) (int, string) {
 	}
 
 	// Create close in DB
-	closeID, err := bd.CreateCashClose(&close)
+	closeID, err := repos.CashCloses.CreateCashClose(&close)
 	if err != nil {
 		return 500, fmt.Sprintf(`{"error": "Error creating close: %s"}`, err.Error())
 	}
@@ -119,7 +128,7 @@ func GenerateCashClose(body string, userUUID string) (int, string) {
 	// Save details
 	for i := range details {
 		details[i].CloseID = closeID
-		err = bd.CreateCashCloseDetail(&details[i])
+		err = repos.CashCloses.CreateCashCloseDetail(&details[i])
 		if err != nil {
 			return 500, fmt.Sprintf(`{"error": "Error saving details: %s"}`, err.Error())
 		}
@@ -136,7 +145,7 @@ func GenerateCashClose(body string, userUUID string) (int, string) {
 		// Continuar sin PDF, no es error crítico
 	} else {
 		// Actualizar BD con URL del PDF
-		err = bd.UpdateCashClosePDF(closeID, pdfURL, pdfS3Key)
+		err = repos.CashCloses.UpdateCashClosePDF(closeID, pdfURL, pdfS3Key)
 		if err != nil {
 			fmt.Printf("Error actualizando PDF en BD: %s\n", err.Error())
 		} else {
@@ -192,7 +201,7 @@ func getStartOfWeek(year, week int) time.Time {
 func GetCashClosePDFURL(closeID int64) (int, string) {
 	fmt.Printf("GetCashClosePDFURL -> CloseID: %d\n", closeID)
 
-	close, err := bd.GetCashCloseByID(closeID)
+	close, err := repos.CashCloses.GetCashCloseByID(closeID)
 	if err != nil {
 		if err.Error() == "cash close not found" {
 			return 404, `{"error": "Cash close not found"}`
@@ -223,8 +232,8 @@ func GetCashClosePDFURL(closeID int64) (int, string) {
 	return 200, string(jsonResponse)
 }
 
-// GetCashCloses gets list of closes
-func GetCashCloses(request events.APIGatewayV2HTTPRequest) (int, string) {
+// GetCashCloses gets list of closes (only the hub's closes when scoped)
+func GetCashCloses(request events.APIGatewayV2HTTPRequest, scope models.HubScope) (int, string) {
 	fmt.Println("GetCashCloses")
 
 	limit := 20
@@ -239,7 +248,7 @@ func GetCashCloses(request events.APIGatewayV2HTTPRequest) (int, string) {
 		}
 	}
 
-	closes, total, err := bd.GetAllCashCloses(limit, offset)
+	closes, total, err := repos.CashCloses.GetAllCashCloses(limit, offset, scope)
 	if err != nil {
 		return 500, fmt.Sprintf(`{"error": "Error getting closes: %s"}`, err.Error())
 	}
@@ -261,7 +270,7 @@ func GetCashCloses(request events.APIGatewayV2HTTPRequest) (int, string) {
 func GetCashCloseByID(closeID int64) (int, string) {
 	fmt.Printf("GetCashCloseByID -> CloseID: %d\n", closeID)
 
-	close, err := bd.GetCashCloseByID(closeID)
+	close, err := repos.CashCloses.GetCashCloseByID(closeID)
 	if err != nil {
 		if err.Error() == "cash close not found" {
 			return 404, `{"error": "Cash close not found"}`
@@ -269,7 +278,7 @@ func GetCashCloseByID(closeID int64) (int, string) {
 		return 500, fmt.Sprintf(`{"error": "Error getting close: %s"}`, err.Error())
 	}
 
-	details, err := bd.GetCashCloseDetails(closeID)
+	details, err := repos.CashCloses.GetCashCloseDetails(closeID)
 	if err != nil {
 		return 500, fmt.Sprintf(`{"error": "Error getting details: %s"}`, err.Error())
 	}
@@ -289,10 +298,10 @@ func GetCashCloseByID(closeID int64) (int, string) {
 }
 
 // GetCashCloseStats gets statistics
-func GetCashCloseStats() (int, string) {
+func GetCashCloseStats(scope models.HubScope) (int, string) {
 	fmt.Println("GetCashCloseStats")
 
-	stats, err := bd.GetCashCloseStats()
+	stats, err := repos.CashCloses.GetCashCloseStats(scope)
 	if err != nil {
 		return 500, fmt.Sprintf(`{"error": "Error getting statistics: %s"}`, err.Error())
 	}
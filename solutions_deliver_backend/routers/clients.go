@@ -6,7 +6,6 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/bd"
 	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
 	"github.com/aws/aws-lambda-go/events"
 )
@@ -31,7 +30,7 @@ func init() {
 func GetClientProfile(userUUID string) (int, string) {
 	fmt.Printf("GetClientProfile -> UserUUID: %s\n", userUUID)
 
-	profile, err := bd.GetUserProfile(userUUID)
+	profile, err := repos.Users.GetUserProfile(userUUID)
 	if err != nil {
 		if err.Error() == "Usuario no encontrado" {
 			return 404, `{"error": "Usuario no encontrado"}`
@@ -58,7 +57,7 @@ func UpdateClientProfile(body string, userUUID string) (int, string) {
 	}
 
 	// Actualizar perfil
-	updatedProfile, err := bd.UpdateUserProfile(userUUID, updateData)
+	updatedProfile, err := repos.Users.UpdateUserProfile(userUUID, updateData)
 	if err != nil {
 		return 500, fmt.Sprintf(`{"error": "Error al actualizar perfil: %s"}`, err.Error())
 	}
@@ -79,7 +78,7 @@ func UpdateClientProfile(body string, userUUID string) (int, string) {
 func GetClientActiveGuides(userUUID string) (int, string) {
 	fmt.Printf("GetClientActiveGuides -> UserUUID: %s\n", userUUID)
 
-	guides, err := bd.GetClientActiveGuides(userUUID)
+	guides, err := repos.Clients.GetClientActiveGuides(userUUID)
 	if err != nil {
 		return 500, fmt.Sprintf(`{"error": "Error al obtener guías activas: %s"}`, err.Error())
 	}
@@ -154,7 +153,7 @@ func GetClientGuideHistory(request events.APIGatewayV2HTTPRequest, userUUID stri
 		}
 	}
 
-	guides, err := bd.GetClientGuideHistory(filters)
+	guides, err := repos.Clients.GetClientGuideHistory(filters)
 	if err != nil {
 		return 500, fmt.Sprintf(`{"error": "Error al obtener histórico: %s"}`, err.Error())
 	}
@@ -175,13 +174,13 @@ func GetClientGuideHistory(request events.APIGatewayV2HTTPRequest, userUUID stri
 func TrackGuideByNumber(guideNumber string, userUUID string) (int, string) {
 	fmt.Printf("TrackGuideByNumber -> GuideNumber: %s, UserUUID: %s\n", guideNumber, userUUID)
 
-	// Convertir guideNumber a guideID
-	guideID, err := strconv.ParseInt(guideNumber, 10, 64)
-	if err != nil {
-		return 400, `{"error": "Número de guía inválido"}`
+	// Acepta el número de guía o el guide_id
+	guideID, status, message := ResolveGuideRef(guideNumber)
+	if status != 0 {
+		return status, message
 	}
 
-	guide, err := bd.GetGuideByID(guideID)
+	guide, err := repos.Guides.GetGuideByID(guideID)
 	if err != nil {
 		if err.Error() == "Guía no encontrada" {
 			return 404, `{"error": "Guía no encontrada"}`
@@ -189,18 +188,45 @@ func TrackGuideByNumber(guideNumber string, userUUID string) (int, string) {
 		return 500, fmt.Sprintf(`{"error": "Error al rastrear guía: %s"}`, err.Error())
 	}
 
-	// ⭐ VALIDACIÓN DE SEGURIDAD: Verificar que el usuario tiene permiso para ver esta guía
-	hasAccess, err := bd.ValidateGuideAccess(guideID, userUUID)
-	if err != nil {
-		return 500, fmt.Sprintf(`{"error": "Error al validar acceso: %s"}`, err.Error())
+	// El acceso del cliente a la guía lo valida la política de la ruta
+	// (clientOwnsGuide en handlers)
+
+	response := models.ClientTrackGuideResponse{
+		Guide:             guide,
+		StatusLabel:       guide.CurrentStatus.Label(),
+		StatusDescription: guide.CurrentStatus.ClientDescription(),
+		Timeline:          []models.ClientTrackEvent{},
 	}
 
-	if !hasAccess {
-		return 403, `{"error": "No tienes permiso para ver esta guía"}`
+	// Las notas del historial son internas del despacho
+	response.Guide.History = make([]models.StatusHistory, len(guide.History))
+	for i, h := range guide.History {
+		h.Notes = ""
+		response.Guide.History[i] = h
+
+		event := models.ClientTrackEvent{
+			Status:      h.Status,
+			Label:       h.Status.Label(),
+			Description: h.Status.ClientDescription(),
+			Date:        h.UpdatedAt,
+		}
+		if h.ReasonCode != "" {
+			event.Reason = h.ReasonCode.Label()
+		}
+		response.Timeline = append(response.Timeline, event)
 	}
 
-	response := models.ClientTrackGuideResponse{
-		Guide: guide,
+	// Prueba de entrega: el cliente ve las imágenes y el documento enmascarado
+	if guide.CurrentStatus == models.StatusDelivered {
+		proof, err := repos.Assignments.GetDeliveryProofByGuide(guide.GuideID)
+		if err == nil {
+			withProofURLs(&proof)
+			proof.ReceiverDocument = maskDocument(proof.ReceiverDocument)
+			proof.SignatureS3Key = ""
+			proof.PhotoS3Keys = nil
+			proof.CapturedBy = ""
+			response.ProofOfDelivery = &proof
+		}
 	}
 
 	jsonResponse, err := json.Marshal(response)
@@ -219,7 +245,7 @@ func TrackGuideByNumber(guideNumber string, userUUID string) (int, string) {
 func GetClientStats(userUUID string) (int, string) {
 	fmt.Printf("GetClientStats -> UserUUID: %s\n", userUUID)
 
-	stats, err := bd.GetClientStats(userUUID)
+	stats, err := repos.Clients.GetClientStats(userUUID)
 	if err != nil {
 		return 500, fmt.Sprintf(`{"error": "Error al obtener estadísticas: %s"}`, err.Error())
 	}
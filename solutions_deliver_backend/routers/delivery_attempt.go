@@ -0,0 +1,148 @@
+package routers
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/utils"
+)
+
+// defaultMaxDeliveryAttempts intentos de entrega antes de devolver al remitente
+const defaultMaxDeliveryAttempts = 3
+
+// maxDeliveryAttempts lee MAX_DELIVERY_ATTEMPTS (por defecto 3)
+func maxDeliveryAttempts() int {
+	value, err := strconv.Atoi(os.Getenv("MAX_DELIVERY_ATTEMPTS"))
+	if err != nil || value < 1 {
+		return defaultMaxDeliveryAttempts
+	}
+	return value
+}
+
+// RegisterDeliveryAttempt registra un intento de entrega fallido (DELIVERY, ADMIN)
+func RegisterDeliveryAttempt(body string, userUUID string, assignmentID int64) (int, string) {
+	fmt.Printf("RegisterDeliveryAttempt -> AssignmentID: %d\n", assignmentID)
+
+	var req models.RegisterAttemptRequest
+	err := json.Unmarshal([]byte(body), &req)
+	if err != nil {
+		return 400, fmt.Sprintf(`{"error": "Body inválido: %s"}`, err.Error())
+	}
+
+	// Validaciones
+	if !models.IsValidGuideStatusReason(models.StatusDeliveryFailed, req.ReasonCode) {
+		return 400, fmt.Sprintf(`{"error": "reason_code inválido. Valores permitidos: %s"}`,
+			joinReasons(models.GuideStatusReasons(models.StatusDeliveryFailed)))
+	}
+
+	req.Notes = strings.TrimSpace(req.Notes)
+	if req.Notes == "" {
+		return 400, `{"error": "notes es requerido"}`
+	}
+
+	if (req.Latitude == nil) != (req.Longitude == nil) {
+		return 400, `{"error": "latitude y longitude deben enviarse juntas"}`
+	}
+	if req.Latitude != nil && (*req.Latitude < -90 || *req.Latitude > 90 || *req.Longitude < -180 || *req.Longitude > 180) {
+		return 400, `{"error": "Coordenadas fuera de rango"}`
+	}
+
+	current, err := repos.Assignments.GetAssignmentByID(assignmentID)
+	if err != nil {
+		return 404, fmt.Sprintf(`{"error": "%s"}`, err.Error())
+	}
+
+	if current.AssignmentType != models.AssignmentDelivery {
+		return 400, `{"error": "Solo las asignaciones de entrega admiten intentos"}`
+	}
+	if current.Status != models.AssignmentInProgress {
+		return 409, `{"error": "La entrega debe estar en progreso para registrar un intento"}`
+	}
+
+	guide, err := repos.Guides.GetGuideByID(current.GuideID)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al obtener la guía de la asignación: %s"}`, err.Error())
+	}
+
+	transition, ok := models.FindGuideTransition(guide.CurrentStatus, models.StatusDeliveryFailed)
+	if !ok || !transition.Allows("", models.TriggerAssignment) {
+		return guideTransitionConflict(guide.CurrentStatus, models.StatusDeliveryFailed, "", models.TriggerAssignment)
+	}
+
+	maxAttempts := maxDeliveryAttempts()
+	nextDate := utils.NextBusinessDay(time.Now().In(colombiaLoc))
+
+	result, err := repos.Assignments.RegisterDeliveryAttempt(assignmentID, req, maxAttempts, nextDate, userUUID)
+	if err != nil {
+		switch err.Error() {
+		case "asignación no encontrada":
+			return 404, fmt.Sprintf(`{"error": "%s"}`, err.Error())
+		case "la entrega debe estar en progreso para registrar un intento", "transición de estado no permitida":
+			return 409, fmt.Sprintf(`{"error": "%s"}`, err.Error())
+		}
+		return 500, fmt.Sprintf(`{"error": "Error al registrar el intento de entrega: %s"}`, err.Error())
+	}
+
+	response := models.RegisterAttemptResponse{
+		Success:        true,
+		Attempt:        result.Attempt,
+		MaxAttempts:    maxAttempts,
+		ReturnToSender: result.ReturnToSender,
+	}
+
+	next, err := repos.Assignments.GetAssignmentByID(result.NextAssignmentID)
+	if err == nil {
+		response.NextAssignment = &next
+	}
+
+	if result.ReturnToSender {
+		response.Message = fmt.Sprintf("Intento %d de %d registrado. Se agotaron los intentos: se creó la devolución al remitente",
+			result.Attempt.AttemptNumber, maxAttempts)
+	} else {
+		response.Message = fmt.Sprintf("Intento %d de %d registrado. Reintento programado para el %s",
+			result.Attempt.AttemptNumber, maxAttempts, nextDate.Format("2006-01-02"))
+	}
+
+	jsonResponse, err := json.Marshal(response)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al serializar respuesta: %s"}`, err.Error())
+	}
+
+	return 201, string(jsonResponse)
+}
+
+// GetGuideDeliveryAttempts obtiene los intentos de entrega de una guía
+func GetGuideDeliveryAttempts(guideID int64) (int, string) {
+	fmt.Printf("GetGuideDeliveryAttempts -> GuideID: %d\n", guideID)
+
+	if _, err := repos.Guides.GetGuideByID(guideID); err != nil {
+		return 404, `{"error": "Guía no encontrada"}`
+	}
+
+	attempts, err := repos.Assignments.GetGuideDeliveryAttempts(guideID)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al obtener los intentos de entrega: %s"}`, err.Error())
+	}
+
+	if attempts == nil {
+		attempts = []models.DeliveryAttempt{}
+	}
+
+	response := models.DeliveryAttemptsResponse{
+		GuideID:     guideID,
+		Attempts:    attempts,
+		MaxAttempts: maxDeliveryAttempts(),
+	}
+
+	jsonResponse, err := json.Marshal(response)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al serializar respuesta: %s"}`, err.Error())
+	}
+
+	return 200, string(jsonResponse)
+}
@@ -0,0 +1,266 @@
+package routers
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/bd"
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+)
+
+// Configuración de la prueba de entrega:
+//   DELIVERY_PROOF_REQUIRED    evidencias obligatorias separadas por coma
+//                              (RECEIVER_NAME, RECEIVER_DOCUMENT, SIGNATURE, PHOTO, LOCATION)
+//   DELIVERY_PROOF_MAX_PHOTOS  máximo de fotos por entrega (por defecto 3)
+
+const (
+	defaultProofMaxPhotos = 3
+	proofURLExpiration    = 15 // minutos
+)
+
+// proofObjectExists verifica que una imagen de la prueba se haya subido a S3
+var proofObjectExists = bd.S3ObjectExists
+
+var defaultProofRequired = []models.ProofEvidence{
+	models.EvidenceReceiverName,
+	models.EvidenceReceiverDocument,
+	models.EvidenceSignature,
+}
+
+// proofRequiredEvidence evidencias obligatorias para completar una entrega
+func proofRequiredEvidence() []models.ProofEvidence {
+	value := strings.TrimSpace(os.Getenv("DELIVERY_PROOF_REQUIRED"))
+	if value == "" {
+		return defaultProofRequired
+	}
+
+	required := []models.ProofEvidence{}
+	for _, item := range strings.Split(value, ",") {
+		evidence := models.ProofEvidence(strings.ToUpper(strings.TrimSpace(item)))
+		switch evidence {
+		case models.EvidenceReceiverName, models.EvidenceReceiverDocument, models.EvidenceSignature,
+			models.EvidencePhoto, models.EvidenceLocation:
+			required = append(required, evidence)
+		}
+	}
+	return required
+}
+
+// proofMaxPhotos máximo de fotos por entrega
+func proofMaxPhotos() int {
+	value, err := strconv.Atoi(os.Getenv("DELIVERY_PROOF_MAX_PHOTOS"))
+	if err != nil || value < 0 {
+		return defaultProofMaxPhotos
+	}
+	return value
+}
+
+// proofKeyPrefix carpeta S3 de las imágenes de una asignación
+func proofKeyPrefix(guideID, assignmentID int64) string {
+	return fmt.Sprintf("delivery-proofs/%d/%d/", guideID, assignmentID)
+}
+
+// RequestProofUploadURLs genera URLs pre-firmadas (PUT) para subir la firma y
+// las fotos de la prueba de entrega antes de completar la asignación
+func RequestProofUploadURLs(body string, assignmentID int64) (int, string) {
+	fmt.Printf("RequestProofUploadURLs -> AssignmentID: %d\n", assignmentID)
+
+	var req models.ProofUploadRequest
+	err := json.Unmarshal([]byte(body), &req)
+	if err != nil {
+		return 400, fmt.Sprintf(`{"error": "Body inválido: %s"}`, err.Error())
+	}
+
+	maxPhotos := proofMaxPhotos()
+	if req.Photos < 0 || req.Photos > maxPhotos {
+		return 400, fmt.Sprintf(`{"error": "photos debe estar entre 0 y %d"}`, maxPhotos)
+	}
+	if !req.Signature && req.Photos == 0 {
+		return 400, `{"error": "Debe solicitar la firma o al menos una foto"}`
+	}
+
+	if req.PhotoContentType == "" {
+		req.PhotoContentType = "image/jpeg"
+	}
+	photoExt := ""
+	switch req.PhotoContentType {
+	case "image/jpeg":
+		photoExt = "jpg"
+	case "image/png":
+		photoExt = "png"
+	default:
+		return 400, `{"error": "photo_content_type debe ser image/jpeg o image/png"}`
+	}
+
+	assignment, err := repos.Assignments.GetAssignmentByID(assignmentID)
+	if err != nil {
+		return 404, fmt.Sprintf(`{"error": "%s"}`, err.Error())
+	}
+	if assignment.AssignmentType != models.AssignmentDelivery {
+		return 400, `{"error": "Solo las asignaciones de entrega llevan prueba de entrega"}`
+	}
+	if assignment.Status != models.AssignmentPending && assignment.Status != models.AssignmentInProgress {
+		return 409, `{"error": "La asignación ya está cerrada"}`
+	}
+
+	prefix := proofKeyPrefix(assignment.GuideID, assignmentID)
+	stamp := time.Now().UnixNano()
+
+	response := models.ProofUploadResponse{
+		AssignmentID: assignmentID,
+		ExpiresIn:    proofURLExpiration,
+		Photos:       []models.ProofUploadURL{},
+		Required:     proofRequiredEvidence(),
+		MaxPhotos:    maxPhotos,
+	}
+
+	if req.Signature {
+		key := fmt.Sprintf("%ssignature-%d.png", prefix, stamp)
+		url, err := bd.GetPresignedPutURL(key, "image/png", proofURLExpiration)
+		if err != nil {
+			return 500, fmt.Sprintf(`{"error": "Error al generar URL de carga: %s"}`, err.Error())
+		}
+		response.Signature = &models.ProofUploadURL{S3Key: key, UploadURL: url, ContentType: "image/png"}
+	}
+
+	for i := 1; i <= req.Photos; i++ {
+		key := fmt.Sprintf("%sphoto-%d-%d.%s", prefix, i, stamp, photoExt)
+		url, err := bd.GetPresignedPutURL(key, req.PhotoContentType, proofURLExpiration)
+		if err != nil {
+			return 500, fmt.Sprintf(`{"error": "Error al generar URL de carga: %s"}`, err.Error())
+		}
+		response.Photos = append(response.Photos, models.ProofUploadURL{S3Key: key, UploadURL: url, ContentType: req.PhotoContentType})
+	}
+
+	jsonResponse, err := json.Marshal(response)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al serializar respuesta: %s"}`, err.Error())
+	}
+
+	return 200, string(jsonResponse)
+}
+
+// validateDeliveryProof valida la prueba de entrega contra la evidencia
+// obligatoria. Retorna status 0 si es válida.
+func validateDeliveryProof(proof *models.DeliveryProofRequest, assignment models.DeliveryAssignment) (int, string) {
+	proof.ReceiverName = strings.TrimSpace(proof.ReceiverName)
+	proof.ReceiverDocument = strings.TrimSpace(proof.ReceiverDocument)
+
+	missing := []models.ProofEvidence{}
+	for _, evidence := range proofRequiredEvidence() {
+		switch {
+		case evidence == models.EvidenceReceiverName && proof.ReceiverName == "",
+			evidence == models.EvidenceReceiverDocument && proof.ReceiverDocument == "",
+			evidence == models.EvidenceSignature && proof.SignatureKey == "",
+			evidence == models.EvidencePhoto && len(proof.PhotoKeys) == 0,
+			evidence == models.EvidenceLocation && (proof.Latitude == nil || proof.Longitude == nil):
+			missing = append(missing, evidence)
+		}
+	}
+
+	if len(missing) > 0 {
+		response := models.DeliveryProofErrorResponse{
+			Error:   "Falta evidencia obligatoria para completar la entrega",
+			Missing: missing,
+		}
+		jsonResponse, err := json.Marshal(response)
+		if err != nil {
+			return 500, fmt.Sprintf(`{"error": "Error al serializar respuesta: %s"}`, err.Error())
+		}
+		return 400, string(jsonResponse)
+	}
+
+	if maxPhotos := proofMaxPhotos(); len(proof.PhotoKeys) > maxPhotos {
+		return 400, fmt.Sprintf(`{"error": "Máximo %d fotos por entrega"}`, maxPhotos)
+	}
+
+	// Las imágenes deben ser las generadas para esta asignación
+	prefix := proofKeyPrefix(assignment.GuideID, assignment.AssignmentID)
+	if proof.SignatureKey != "" && !strings.HasPrefix(proof.SignatureKey, prefix) {
+		return 400, `{"error": "signature_key no corresponde a esta asignación"}`
+	}
+	for _, key := range proof.PhotoKeys {
+		if !strings.HasPrefix(key, prefix) {
+			return 400, `{"error": "photo_keys contiene una imagen que no corresponde a esta asignación"}`
+		}
+	}
+
+	// Y deben haberse subido: una llave sin archivo dejaría la prueba sin evidencia
+	keys := proof.PhotoKeys
+	if proof.SignatureKey != "" {
+		keys = append([]string{proof.SignatureKey}, keys...)
+	}
+	for _, key := range keys {
+		exists, err := proofObjectExists(key)
+		if err != nil {
+			return 500, fmt.Sprintf(`{"error": "Error al verificar la imagen %s: %s"}`, key, err.Error())
+		}
+		if !exists {
+			return 400, fmt.Sprintf(`{"error": "La imagen %s no se ha subido"}`, key)
+		}
+	}
+
+	if (proof.Latitude == nil) != (proof.Longitude == nil) {
+		return 400, `{"error": "latitude y longitude deben enviarse juntas"}`
+	}
+	if proof.Latitude != nil && (*proof.Latitude < -90 || *proof.Latitude > 90 || *proof.Longitude < -180 || *proof.Longitude > 180) {
+		return 400, `{"error": "Coordenadas fuera de rango"}`
+	}
+
+	return 0, ""
+}
+
+// withProofURLs agrega URLs pre-firmadas de lectura a la firma y las fotos
+func withProofURLs(proof *models.DeliveryProof) {
+	if proof.SignatureS3Key != "" {
+		url, err := bd.GetPresignedURL(proof.SignatureS3Key, 30)
+		if err != nil {
+			fmt.Printf("withProofURLs - Error firmando %s: %v\n", proof.SignatureS3Key, err)
+		}
+		proof.SignatureURL = url
+	}
+
+	proof.PhotoURLs = []string{}
+	for _, key := range proof.PhotoS3Keys {
+		url, err := bd.GetPresignedURL(key, 30)
+		if err != nil {
+			fmt.Printf("withProofURLs - Error firmando %s: %v\n", key, err)
+			continue
+		}
+		proof.PhotoURLs = append(proof.PhotoURLs, url)
+	}
+}
+
+// maskDocument deja visibles solo los últimos 4 caracteres del documento
+func maskDocument(document string) string {
+	if len(document) <= 4 {
+		return document
+	}
+	return strings.Repeat("*", len(document)-4) + document[len(document)-4:]
+}
+
+// GetDeliveryProof obtiene la prueba de entrega de una guía (ADMIN, SECRETARY)
+func GetDeliveryProof(guideID int64) (int, string) {
+	fmt.Printf("GetDeliveryProof -> GuideID: %d\n", guideID)
+
+	proof, err := repos.Assignments.GetDeliveryProofByGuide(guideID)
+	if err != nil {
+		if err.Error() == "prueba de entrega no encontrada" {
+			return 404, `{"error": "La guía no tiene prueba de entrega"}`
+		}
+		return 500, fmt.Sprintf(`{"error": "Error al obtener la prueba de entrega: %s"}`, err.Error())
+	}
+
+	withProofURLs(&proof)
+
+	jsonResponse, err := json.Marshal(proof)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al serializar respuesta: %s"}`, err.Error())
+	}
+
+	return 200, string(jsonResponse)
+}
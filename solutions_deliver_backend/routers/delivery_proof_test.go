@@ -0,0 +1,57 @@
+package routers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+)
+
+// stubProofObjects reemplaza la consulta a S3 por un conjunto de llaves subidas
+func stubProofObjects(t *testing.T, uploaded map[string]bool, err error) {
+	t.Helper()
+	previous := proofObjectExists
+	proofObjectExists = func(key string) (bool, error) {
+		return uploaded[key], err
+	}
+	t.Cleanup(func() { proofObjectExists = previous })
+}
+
+func TestValidateDeliveryProofObjects(t *testing.T) {
+	t.Setenv("DELIVERY_PROOF_REQUIRED", "RECEIVER_NAME,SIGNATURE")
+
+	assignment := models.DeliveryAssignment{AssignmentID: 7, GuideID: 3}
+	prefix := proofKeyPrefix(assignment.GuideID, assignment.AssignmentID)
+	signature := prefix + "signature-1.png"
+	photo := prefix + "photo-1-1.jpg"
+
+	tests := []struct {
+		name     string
+		proof    models.DeliveryProofRequest
+		uploaded map[string]bool
+		s3Err    error
+		status   int
+		body     string
+	}{
+		{"firma y foto subidas", models.DeliveryProofRequest{ReceiverName: "Ana", SignatureKey: signature, PhotoKeys: []string{photo}},
+			map[string]bool{signature: true, photo: true}, nil, 0, ""},
+		{"firma sin subir", models.DeliveryProofRequest{ReceiverName: "Ana", SignatureKey: signature},
+			map[string]bool{}, nil, 400, `{"error": "La imagen ` + signature + ` no se ha subido"}`},
+		{"foto sin subir", models.DeliveryProofRequest{ReceiverName: "Ana", SignatureKey: signature, PhotoKeys: []string{photo}},
+			map[string]bool{signature: true}, nil, 400, `{"error": "La imagen ` + photo + ` no se ha subido"}`},
+		{"llave de otra asignación", models.DeliveryProofRequest{ReceiverName: "Ana", SignatureKey: "delivery-proofs/3/8/signature-1.png"},
+			map[string]bool{"delivery-proofs/3/8/signature-1.png": true}, nil, 400, `{"error": "signature_key no corresponde a esta asignación"}`},
+		{"error de S3", models.DeliveryProofRequest{ReceiverName: "Ana", SignatureKey: signature},
+			nil, errors.New("timeout"), 500, `{"error": "Error al verificar la imagen ` + signature + `: timeout"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stubProofObjects(t, tt.uploaded, tt.s3Err)
+			status, body := validateDeliveryProof(&tt.proof, assignment)
+			if status != tt.status || body != tt.body {
+				t.Errorf("validateDeliveryProof = %d %s, se esperaba %d %s", status, body, tt.status, tt.body)
+			}
+		})
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 
-	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/bd"
 	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
 )
 
@@ -20,7 +19,7 @@ func SearchFrequentPartiesByNameOnly(searchTerm string, partyType models.PartyTy
 	}
 
 	// Buscar clientes únicos
-	parties, total, err := bd.SearchFrequentPartiesByNameOnly(searchTerm, partyType)
+	parties, total, err := repos.FrequentParties.SearchFrequentPartiesByNameOnly(searchTerm, partyType)
 	if err != nil {
 		return 500, fmt.Sprintf(`{"error": "Error al buscar partes frecuentes: %s"}`, err.Error())
 	}
@@ -49,7 +48,7 @@ func SearchFrequentPartiesByNameAndCity(searchTerm string, cityID int64, partyTy
 	}
 
 	// Buscar direcciones del cliente en esa ciudad
-	parties, total, err := bd.SearchFrequentPartiesByNameAndCity(searchTerm, cityID, partyType)
+	parties, total, err := repos.FrequentParties.SearchFrequentPartiesByNameAndCity(searchTerm, cityID, partyType)
 	if err != nil {
 		return 500, fmt.Sprintf(`{"error": "Error al buscar partes frecuentes: %s"}`, err.Error())
 	}
@@ -81,7 +80,7 @@ func GetFrequentPartiesByDocument(documentNumber string, cityID int64, partyType
 	}
 
 	// Obtener partes frecuentes
-	parties, total, err := bd.GetFrequentPartiesByDocument(documentNumber, cityID, partyType)
+	parties, total, err := repos.FrequentParties.GetFrequentPartiesByDocument(documentNumber, cityID, partyType)
 	if err != nil {
 		return 500, fmt.Sprintf(`{"error": "Error al obtener partes frecuentes: %s"}`, err.Error())
 	}
@@ -133,7 +132,7 @@ func UpsertFrequentParty(body string) (int, string) {
 	}
 
 	// Insertar o actualizar
-	err = bd.UpsertFrequentParty(request)
+	err = repos.FrequentParties.UpsertFrequentParty(request)
 	if err != nil {
 		return 500, fmt.Sprintf(`{"error": "Error al registrar parte frecuente: %s"}`, err.Error())
 	}
@@ -145,7 +144,7 @@ func UpsertFrequentParty(body string) (int, string) {
 func GetFrequentPartyStats() (int, string) {
 	fmt.Println("GetFrequentPartyStats")
 
-	stats, err := bd.GetFrequentPartyStats()
+	stats, err := repos.FrequentParties.GetFrequentPartyStats()
 	if err != nil {
 		return 500, fmt.Sprintf(`{"error": "Error al obtener estadísticas: %s"}`, err.Error())
 	}
@@ -4,9 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
-	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/bd"
 	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
 	"github.com/aws/aws-lambda-go/events"
 )
@@ -102,7 +102,7 @@ func GetGuides(request events.APIGatewayV2HTTPRequest, userUUID string) (int, st
 	}
 
 	// Obtener guías
-	guides, total, err := bd.GetGuidesByFilters(filters)
+	guides, total, err := repos.Guides.GetGuidesByFilters(filters)
 	if err != nil {
 		return 500, fmt.Sprintf(`{"error": "Error al obtener las guías: %s"}`, err.Error())
 	}
@@ -126,7 +126,7 @@ func GetGuides(request events.APIGatewayV2HTTPRequest, userUUID string) (int, st
 func GetGuideByID(guideID int64) (int, string) {
 	fmt.Printf("GetGuideByID -> GuideID: %d\n", guideID)
 
-	guide, err := bd.GetGuideByID(guideID)
+	guide, err := repos.Guides.GetGuideByID(guideID)
 	if err != nil {
 		if err.Error() == "Guía no encontrada" {
 			return 404, fmt.Sprintf(`{"error": "Guía no encontrada"}`)
@@ -147,65 +147,59 @@ func GetGuideByID(guideID int64) (int, string) {
 }
 
 // UpdateGuide actualiza el estado de una guía
-func UpdateGuideStatus(guideID int64, body string, userUUID string) (int, string) {
+func UpdateGuideStatus(guideID int64, body string, userUUID string, userRole models.UserRole) (int, string) {
 	fmt.Printf("UpdateGuideStatus -> GuideID: %d\n", guideID)
 
-	// Obtener rol del usuario
-	userRole, err := bd.GetUserRole(userUUID)
-	if err != nil {
-		return 500, fmt.Sprintf(`{"error": "Error al obtener rol del usuario: %s"}`, err.Error())
-	}
-
-	// Verificar que la guía existe
-	if !bd.GuideExists(guideID) {
-		return 404, fmt.Sprintf(`{"error": "Guía no encontrada"}`)
-	}
-
 	// Parsear body
 	var request models.UpdateStatusRequest
-	err = json.Unmarshal([]byte(body), &request)
+	err := json.Unmarshal([]byte(body), &request)
 	if err != nil {
 		return 400, fmt.Sprintf(`{"error": "Body inválido: %s"}`, err.Error())
 	}
 
 	// Validar estado
-	validStatuses := []models.GuideStatus{
-		models.StatusCreated,
-		models.StatusInRoute,
-		models.StatusInWarehouse,
-		models.StatusOutForDelivery,
-		models.StatusDelivered,
+	if !models.IsValidGuideStatus(request.Status) {
+		return 400, fmt.Sprintf(`{"error": "Estado inválido"}`)
 	}
 
-	isValid := false
-
-	for _, s := range validStatuses {
-		if request.Status == s {
-			isValid = true
-			break
+	// Los estados de excepción exigen motivo y nota
+	request.Notes = strings.TrimSpace(request.Notes)
+	if request.Status.RequiresReason() {
+		if !models.IsValidGuideStatusReason(request.Status, request.ReasonCode) {
+			return 400, fmt.Sprintf(`{"error": "reason_code inválido para %s. Valores permitidos: %s"}`,
+				request.Status, joinReasons(models.GuideStatusReasons(request.Status)))
+		}
+		if request.Notes == "" {
+			return 400, fmt.Sprintf(`{"error": "notes es requerido para %s"}`, request.Status)
 		}
+	} else if request.ReasonCode != "" {
+		return 400, fmt.Sprintf(`{"error": "reason_code no aplica para %s"}`, request.Status)
 	}
 
-	if !isValid {
-		return 400, fmt.Sprintf(`{"error": "Estado inválido"}`)
+	guide, err := repos.Guides.GetGuideByID(guideID)
+	if err != nil {
+		if err.Error() == "Guía no encontrada" {
+			return 404, `{"error": "Guía no encontrada"}`
+		}
+		return 500, fmt.Sprintf(`{"error": "Error al obtener la guía: %s"}`, err.Error())
 	}
 
-	// Validar permisos según rol
-	switch userRole.Role {
-	case models.RoleAdmin:
-		// Admin puede cambiar a cualquier estado
-	case models.RoleSecretary:
-		// Secretary solo puede cambiar a IN_WAREHOUSE
-		if request.Status != models.StatusInWarehouse {
-			return 403, `{"error": "Solo puedes cambiar el estado a 'En bodega'"}`
-		}
-	default:
-		return 403, `{"error": "No tienes permisos para cambiar el estado de la guía"}`
+	// Validar la transición y los permisos según rol
+	transition, ok := models.FindGuideTransition(guide.CurrentStatus, request.Status)
+	if !ok {
+		return guideTransitionConflict(guide.CurrentStatus, request.Status, userRole, models.TriggerManual)
+	}
+	if !transition.Allows(userRole, models.TriggerManual) {
+		return 403, fmt.Sprintf(`{"error": "Tu rol no puede cambiar la guía de %s a %s"}`, guide.CurrentStatus, request.Status)
 	}
 
 	// Actualizar estado
-	err = bd.UpdateGuideStatus(guideID, request.Status, userUUID)
+	err = repos.Guides.UpdateGuideStatus(guideID, request.Status, request.ReasonCode, request.Notes, userUUID)
 	if err != nil {
+		if err.Error() == "transición de estado no permitida" {
+			// El estado cambió entre la lectura y la actualización
+			return guideTransitionConflict(guide.CurrentStatus, request.Status, userRole, models.TriggerManual)
+		}
 		return 500, fmt.Sprintf(`{"error": "Error al actualizar el estado de la guía: %s"}`, err.Error())
 	}
 
@@ -228,7 +222,7 @@ func UpdateGuideStatus(guideID int64, body string, userUUID string) (int, string
 func GetGuidesStats(userUUID string) (int, string) {
 	fmt.Println("GetGuidesStats")
 
-	stats, err := bd.GetGuideStats(userUUID)
+	stats, err := repos.Guides.GetGuideStats(userUUID)
 	if err != nil {
 		return 500, fmt.Sprintf(`{"error": "Error al obtener estadísticas: %s"}`, err.Error())
 	}
@@ -250,6 +244,14 @@ func SearchGuides(searchTerm string) (int, string) {
 		return 400, `{"error": "El término de búsqueda debe tener al menos 3 caracteres"}`
 	}
 
+	// Si parece un número de guía, se valida el dígito de verificación
+	// para avisar del error de digitación en lugar de no encontrar nada
+	if models.LooksLikeGuideNumber(searchTerm) {
+		if _, err := models.ParseGuideNumber(searchTerm); err != nil {
+			return 400, fmt.Sprintf(`{"error": "%s"}`, err.Error())
+		}
+	}
+
 	// Construir filtros solo con término de búsqueda
 	filters := models.GuideFilters{
 		SearchTerm: searchTerm,
@@ -257,7 +259,7 @@ func SearchGuides(searchTerm string) (int, string) {
 		Offset:     0,
 	}
 
-	guides, total, err := bd.GetGuidesByFilters(filters)
+	guides, total, err := repos.Guides.GetGuidesByFilters(filters)
 	if err != nil {
 		return 500, fmt.Sprintf(`{"error": "Error al buscar guías: %s"}`, err.Error())
 	}
@@ -277,3 +279,54 @@ func SearchGuides(searchTerm string) (int, string) {
 
 	return 200, string(jsonResponse)
 }
+
+// GetGuideStatusReasons retorna el catálogo de motivos de los estados de excepción
+func GetGuideStatusReasons() (int, string) {
+	fmt.Println("GetGuideStatusReasons")
+
+	statuses := make([]models.GuideExceptionStatus, 0, len(models.ExceptionGuideStatuses))
+	for _, status := range models.ExceptionGuideStatuses {
+		item := models.GuideExceptionStatus{
+			Status:  status,
+			Label:   status.Label(),
+			Reasons: []models.GuideStatusReasonOption{},
+		}
+		for _, reason := range models.GuideStatusReasons(status) {
+			item.Reasons = append(item.Reasons, models.GuideStatusReasonOption{Code: reason, Label: reason.Label()})
+		}
+		statuses = append(statuses, item)
+	}
+
+	jsonResponse, err := json.Marshal(statuses)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al serializar respuesta: %s"}`, err.Error())
+	}
+
+	return 200, string(jsonResponse)
+}
+
+// guideTransitionConflict arma la respuesta 409 de una transición no
+// permitida, con los estados a los que sí puede pasar la guía
+func guideTransitionConflict(current, requested models.GuideStatus, role models.UserRole, trigger models.GuideTransitionTrigger) (int, string) {
+	response := models.GuideTransitionErrorResponse{
+		Error:           fmt.Sprintf("La guía no puede pasar de %s a %s", current, requested),
+		CurrentStatus:   current,
+		RequestedStatus: requested,
+		AllowedNext:     models.NextGuideStatuses(current, role, trigger),
+	}
+
+	jsonResponse, err := json.Marshal(response)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al serializar respuesta: %s"}`, err.Error())
+	}
+
+	return 409, string(jsonResponse)
+}
+
+func joinReasons(reasons []models.GuideStatusReason) string {
+	values := make([]string, len(reasons))
+	for i, r := range reasons {
+		values[i] = string(r)
+	}
+	return strings.Join(values, ", ")
+}
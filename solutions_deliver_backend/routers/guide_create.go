@@ -0,0 +1,350 @@
+package routers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/utils"
+)
+
+// CreateGuide crea una guía: valida el body, calcula el precio con la tarifa
+// vigente, inserta todo en una transacción y registra las partes frecuentes.
+// El PDF se genera después de crear la guía; si falla, la guía queda creada
+// y se puede reintentar con POST /guides/{id}/pdf.
+func CreateGuide(body string, userUUID string) (int, string) {
+	fmt.Printf("CreateGuide -> UserUUID: %s\n", userUUID)
+
+	guide, err := decodeNewGuide(body)
+	if err != nil {
+		return 400, fmt.Sprintf(`{"error": "Body inválido: %s"}`, err.Error())
+	}
+
+	normalizeNewGuide(&guide)
+
+	if message := validateNewGuide(guide); message != "" {
+		return 400, fmt.Sprintf(`{"error": "%s"}`, message)
+	}
+
+	// Sin detalle de piezas, el paquete se reparte en piezas iguales
+	if len(guide.Pieces) == 0 {
+		guide.Pieces = models.SplitPackage(*guide.Package)
+	}
+
+	if _, err := repos.Locations.GetCityByID(guide.OriginCityID); err != nil {
+		return 400, `{"error": "La ciudad de origen no existe"}`
+	}
+	if _, err := repos.Locations.GetCityByID(guide.DestinationCityID); err != nil {
+		return 400, `{"error": "La ciudad de destino no existe"}`
+	}
+
+	// El precio siempre se calcula en el servidor
+	pricing, status, message := calculatePrice(guide.OriginCityID, guide.DestinationCityID, guide.ServiceType, *guide.Package, guide.Pieces, guide.DeclaredValue, rateToday())
+	if status != 0 {
+		return status, message
+	}
+	guide.Price = pricing.Total
+	guide.RateID = pricing.RateID
+
+	err = repos.Guides.CreateGuide(&guide, guideNumberPrefix(), userUUID)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al crear la guía: %s"}`, err.Error())
+	}
+
+	// Registrar remitente y destinatario como partes frecuentes (no crítico)
+	for _, party := range []*models.GuideParty{guide.Sender, guide.Receiver} {
+		if err := repos.FrequentParties.UpsertFrequentParty(frequentPartyFromGuide(*party, userUUID)); err != nil {
+			fmt.Printf("Warning: no se pudo registrar la parte frecuente %s: %s\n", party.DocumentNumber, err.Error())
+		}
+	}
+
+	// Releer la guía para incluir nombres de ciudades e historial
+	if created, err := repos.Guides.GetGuideByID(guide.GuideID); err == nil {
+		guide = created
+	}
+
+	response := models.CreateGuideResponse{
+		Success:     true,
+		GuideID:     guide.GuideID,
+		GuideNumber: guide.GuideNumber,
+		Guide:       guide,
+		Pricing:     pricing,
+		Message:     "Guía creada correctamente",
+	}
+
+	// Generar PDF (no crítico)
+	pdfURL, err := generateGuidePDF(guide.GuideID)
+	if err != nil {
+		fmt.Printf("Error generando PDF de la guía %d: %s\n", guide.GuideID, err.Error())
+		response.Message = "Guía creada correctamente; el PDF se puede generar más tarde"
+	} else {
+		response.PDFURL = pdfURL
+	}
+
+	jsonResponse, err := json.Marshal(response)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al serializar respuesta: %s"}`, err.Error())
+	}
+
+	return 201, string(jsonResponse)
+}
+
+// GenerateGuidePDF (re)genera el PDF de una guía existente
+func GenerateGuidePDF(guideID int64) (int, string) {
+	fmt.Printf("GenerateGuidePDF -> GuideID: %d\n", guideID)
+
+	guide, err := repos.Guides.GetGuideByID(guideID)
+	if err != nil {
+		if err.Error() == "Guía no encontrada" {
+			return 404, `{"error": "Guía no encontrada"}`
+		}
+		return 500, fmt.Sprintf(`{"error": "Error al obtener la guía: %s"}`, err.Error())
+	}
+
+	pdfURL, err := generateGuidePDF(guideID)
+	if err != nil {
+		return 502, fmt.Sprintf(`{"error": "Error al generar el PDF: %s"}`, err.Error())
+	}
+
+	response := map[string]interface{}{
+		"guide_id":     guideID,
+		"guide_number": guide.GuideNumber,
+		"pdf_url":      pdfURL,
+		"message":      "PDF generado correctamente",
+	}
+
+	jsonResponse, err := json.Marshal(response)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al serializar respuesta: %s"}`, err.Error())
+	}
+
+	return 200, string(jsonResponse)
+}
+
+// generateGuidePDF invoca la Lambda de PDFs y guarda la URL en la guía
+func generateGuidePDF(guideID int64) (string, error) {
+	pdfURL, pdfS3Key, err := utils.GenerateGuidePDFWithLambda(guideID)
+	if err != nil {
+		return "", err
+	}
+
+	if err := repos.Guides.UpdateGuidePDF(guideID, pdfURL, pdfS3Key); err != nil {
+		return "", fmt.Errorf("error actualizando PDF en BD: %w", err)
+	}
+
+	return pdfURL, nil
+}
+
+// legacyGuideRequest es el formato anidado que envía el frontend
+// (service / pricing / route), heredado de la Lambda de Node.js
+type legacyGuideRequest struct {
+	Service *struct {
+		ServiceType   models.ServiceType   `json:"service_type"`
+		PaymentMethod models.PaymentMethod `json:"payment_method"`
+	} `json:"service"`
+	Pricing *struct {
+		DeclaredValue float64 `json:"declared_value"`
+	} `json:"pricing"`
+	Route *struct {
+		OriginCityID      int64 `json:"origin_city_id"`
+		DestinationCityID int64 `json:"destination_city_id"`
+	} `json:"route"`
+	// Package solo distingue si insured vino en el body
+	Package *struct {
+		Insured *bool `json:"insured"`
+	} `json:"package"`
+}
+
+// decodeNewGuide acepta un models.ShippingGuide o el formato anidado del frontend.
+// El precio enviado por el cliente se ignora en ambos casos y el seguro sigue
+// la misma regla que las cotizaciones (resolveInsured).
+func decodeNewGuide(body string) (models.ShippingGuide, error) {
+	var guide models.ShippingGuide
+	if err := json.Unmarshal([]byte(body), &guide); err != nil {
+		return guide, err
+	}
+
+	var legacy legacyGuideRequest
+	if err := json.Unmarshal([]byte(body), &legacy); err != nil {
+		return guide, err
+	}
+	if legacy.Service != nil {
+		guide.ServiceType = legacy.Service.ServiceType
+		guide.PaymentMethod = legacy.Service.PaymentMethod
+	}
+	if legacy.Pricing != nil {
+		guide.DeclaredValue = legacy.Pricing.DeclaredValue
+	}
+	if legacy.Route != nil {
+		guide.OriginCityID = legacy.Route.OriginCityID
+		guide.DestinationCityID = legacy.Route.DestinationCityID
+	}
+
+	// Con detalle de piezas el paquete puede no venir; normalizeNewGuide lo completa
+	if guide.Package == nil && len(guide.Pieces) > 0 {
+		guide.Package = &models.Package{}
+	}
+	if guide.Package != nil {
+		var insured *bool
+		if legacy.Package != nil {
+			insured = legacy.Package.Insured
+		}
+		guide.Package.Insured = resolveInsured(insured, guide.DeclaredValue)
+	}
+
+	guide.Price = 0
+	return guide, nil
+}
+
+// normalizeNewGuide completa valores por defecto antes de validar
+func normalizeNewGuide(guide *models.ShippingGuide) {
+	if guide.PaymentMethod == "" {
+		guide.PaymentMethod = models.PaymentCash
+	}
+	if guide.ServiceType == "" {
+		guide.ServiceType = models.ServiceNormal
+	}
+
+	if guide.Sender != nil {
+		guide.Sender.PartyRole = models.RoleSender
+		if guide.OriginCityID == 0 {
+			guide.OriginCityID = guide.Sender.CityID
+		}
+		if guide.Sender.CityID == 0 {
+			guide.Sender.CityID = guide.OriginCityID
+		}
+	}
+
+	if guide.Receiver != nil {
+		guide.Receiver.PartyRole = models.RoleReceiver
+		if guide.DestinationCityID == 0 {
+			guide.DestinationCityID = guide.Receiver.CityID
+		}
+		if guide.Receiver.CityID == 0 {
+			guide.Receiver.CityID = guide.DestinationCityID
+		}
+	}
+
+	// Con detalle de piezas, el paquete toma los totales de las piezas
+	if len(guide.Pieces) > 0 {
+		if guide.Package == nil {
+			guide.Package = &models.Package{}
+		}
+		for i := range guide.Pieces {
+			guide.Pieces[i].PieceNumber = i + 1
+		}
+		models.ApplyPieceTotals(guide.Package, guide.Pieces)
+	}
+
+	if guide.Package != nil && guide.Package.Pieces == 0 {
+		guide.Package.Pieces = 1
+	}
+}
+
+// validateNewGuide retorna el mensaje de error o vacío si la guía es válida
+func validateNewGuide(guide models.ShippingGuide) string {
+	if _, ok := serviceMultipliers[guide.ServiceType]; !ok {
+		return "service_type debe ser NORMAL, PRIORITY o EXPRESS"
+	}
+
+	switch guide.PaymentMethod {
+	case models.PaymentCash, models.PaymentCOD, models.PaymentCredit:
+	default:
+		return "payment_method debe ser CASH, COD o CREDIT"
+	}
+
+	if guide.DeclaredValue < 0 {
+		return "declared_value no puede ser negativo"
+	}
+
+	if guide.Sender == nil {
+		return "sender es requerido"
+	}
+	if message := validateGuideParty("sender", *guide.Sender); message != "" {
+		return message
+	}
+
+	if guide.Receiver == nil {
+		return "receiver es requerido"
+	}
+	if message := validateGuideParty("receiver", *guide.Receiver); message != "" {
+		return message
+	}
+
+	if guide.OriginCityID <= 0 || guide.DestinationCityID <= 0 {
+		return "origin_city_id y destination_city_id son requeridos"
+	}
+	if guide.Sender.CityID != guide.OriginCityID {
+		return "sender.city_id debe coincidir con origin_city_id"
+	}
+	if guide.Receiver.CityID != guide.DestinationCityID {
+		return "receiver.city_id debe coincidir con destination_city_id"
+	}
+
+	if len(guide.Pieces) > models.MaxGuidePieces {
+		return fmt.Sprintf("Se permiten máximo %d piezas por guía", models.MaxGuidePieces)
+	}
+	for i, piece := range guide.Pieces {
+		if piece.WeightKg <= 0 {
+			return fmt.Sprintf("pieces[%d].weight_kg debe ser mayor a 0", i)
+		}
+		if piece.LengthCM <= 0 || piece.WidthCM <= 0 || piece.HeightCM <= 0 {
+			return fmt.Sprintf("pieces[%d].length_cm, width_cm y height_cm deben ser mayores a 0", i)
+		}
+	}
+
+	if guide.Package == nil {
+		return "package es requerido"
+	}
+	pkg := guide.Package
+	if pkg.WeightKg <= 0 {
+		return "package.weight_kg debe ser mayor a 0"
+	}
+	if pkg.LengthCM <= 0 || pkg.WidthCM <= 0 || pkg.HeightCM <= 0 {
+		return "package.length_cm, width_cm y height_cm deben ser mayores a 0"
+	}
+	if pkg.Pieces < 1 || pkg.Pieces > models.MaxGuidePieces {
+		return fmt.Sprintf("package.pieces debe estar entre 1 y %d", models.MaxGuidePieces)
+	}
+	if pkg.Insured && guide.DeclaredValue <= 0 {
+		return "declared_value es requerido para paquetes asegurados"
+	}
+
+	return ""
+}
+
+func validateGuideParty(name string, party models.GuideParty) string {
+	if strings.TrimSpace(party.FullName) == "" {
+		return name + ".full_name es requerido"
+	}
+	if strings.TrimSpace(party.DocumentNumber) == "" {
+		return name + ".document_number es requerido"
+	}
+	if strings.TrimSpace(party.Phone) == "" {
+		return name + ".phone es requerido"
+	}
+	if strings.TrimSpace(party.Address) == "" {
+		return name + ".address es requerido"
+	}
+	return ""
+}
+
+func frequentPartyFromGuide(party models.GuideParty, userUUID string) models.CreateFrequentPartyRequest {
+	partyType := models.PartySender
+	if party.PartyRole == models.RoleReceiver {
+		partyType = models.PartyReceiver
+	}
+
+	return models.CreateFrequentPartyRequest{
+		PartyType:      partyType,
+		FullName:       party.FullName,
+		DocumentType:   party.DocumentType,
+		DocumentNumber: party.DocumentNumber,
+		Phone:          party.Phone,
+		Email:          party.Email,
+		CityID:         party.CityID,
+		Address:        party.Address,
+		UserUUID:       userUUID,
+	}
+}
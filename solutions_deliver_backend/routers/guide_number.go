@@ -0,0 +1,67 @@
+package routers
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+)
+
+// GUIDE_NUMBER_PREFIX prefijo de la oficina para los números de guía
+// (2 a 4 letras, por defecto SD)
+const defaultGuideNumberPrefix = "SD"
+
+func guideNumberPrefix() string {
+	prefix := strings.ToUpper(strings.TrimSpace(os.Getenv("GUIDE_NUMBER_PREFIX")))
+	if prefix == "" {
+		return defaultGuideNumberPrefix
+	}
+	if !models.ValidGuideNumberPrefix(prefix) {
+		fmt.Printf("Warning: GUIDE_NUMBER_PREFIX '%s' inválido, se usa %s\n", prefix, defaultGuideNumberPrefix)
+		return defaultGuideNumberPrefix
+	}
+	return prefix
+}
+
+// ResolveGuideRef convierte la referencia de una guía en su guide_id.
+// Acepta el guide_id numérico (incluido el formato de 8 dígitos anterior) o
+// el número de guía (p.ej. SD00001234). El número se valida antes de ir a la
+// base de datos: un error de digitación responde 400 con el motivo.
+// Retorna status 0 si la referencia es válida.
+func ResolveGuideRef(ref string) (int64, int, string) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return 0, 400, `{"error": "Número de guía requerido"}`
+	}
+
+	if guideID, err := strconv.ParseInt(ref, 10, 64); err == nil {
+		if guideID <= 0 {
+			return 0, 400, `{"error": "ID de guía inválido"}`
+		}
+		return guideID, 0, ""
+	}
+
+	return ResolveGuideNumber(ref)
+}
+
+// ResolveGuideNumber convierte un número de guía en su guide_id. A diferencia
+// de ResolveGuideRef no acepta el guide_id: el formato y el dígito Luhn se
+// validan antes de ir a la base de datos.
+// Retorna status 0 si el número es válido.
+func ResolveGuideNumber(ref string) (int64, int, string) {
+	number, err := models.ParseGuideNumber(strings.TrimSpace(ref))
+	if err != nil {
+		return 0, 400, fmt.Sprintf(`{"error": "%s"}`, err.Error())
+	}
+
+	guideID, err := repos.Guides.GetGuideIDByNumber(number)
+	if err != nil {
+		if err.Error() == "Guía no encontrada" {
+			return 0, 404, `{"error": "Guía no encontrada"}`
+		}
+		return 0, 500, fmt.Sprintf(`{"error": "Error al buscar la guía: %s"}`, err.Error())
+	}
+	return guideID, 0, ""
+}
@@ -0,0 +1,61 @@
+package routers
+
+import (
+	"testing"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+)
+
+func TestUpdateGuideStatusManual(t *testing.T) {
+	tests := []struct {
+		name   string
+		from   models.GuideStatus
+		body   string
+		role   models.UserRole
+		status int
+		want   models.GuideStatus
+	}{
+		{"admin no entrega sin asignación", models.StatusOutForDelivery, `{"status": "DELIVERED"}`,
+			models.RoleAdmin, 403, models.StatusOutForDelivery},
+		{"secretaria no entrega sin asignación", models.StatusOutForDelivery, `{"status": "DELIVERED"}`,
+			models.RoleSecretary, 403, models.StatusOutForDelivery},
+		{"recepción en bodega", models.StatusInRoute, `{"status": "IN_WAREHOUSE"}`,
+			models.RoleSecretary, 200, models.StatusInWarehouse},
+		{"despacho solo admin", models.StatusInWarehouse, `{"status": "IN_ROUTE"}`,
+			models.RoleSecretary, 403, models.StatusInWarehouse},
+		{"transición ilegal", models.StatusCreated, `{"status": "DELIVERED"}`,
+			models.RoleAdmin, 409, models.StatusCreated},
+		{"excepción sin motivo", models.StatusInWarehouse, `{"status": "ON_HOLD", "notes": "revisar"}`,
+			models.RoleAdmin, 400, models.StatusInWarehouse},
+		{"excepción con motivo", models.StatusInWarehouse, `{"status": "ON_HOLD", "reason_code": "DAMAGED_PACKAGE", "notes": "caja rota"}`,
+			models.RoleAdmin, 200, models.StatusOnHold},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTestStore(t)
+			guideID := store.AddGuide(models.ShippingGuide{CurrentStatus: tt.from})
+
+			status, response := UpdateGuideStatus(guideID, tt.body, "user-1", tt.role)
+			if status != tt.status {
+				t.Fatalf("status = %d (%s), se esperaba %d", status, response, tt.status)
+			}
+
+			guide, _ := repos.Guides.GetGuideByID(guideID)
+			if guide.CurrentStatus != tt.want {
+				t.Errorf("guía = %s, se esperaba %s", guide.CurrentStatus, tt.want)
+			}
+		})
+	}
+}
+
+// DELIVERED no aparece entre los estados manuales siguientes
+func TestDeliveredIsNotManual(t *testing.T) {
+	for _, role := range []models.UserRole{models.RoleAdmin, models.RoleSecretary} {
+		for _, next := range models.NextGuideStatuses(models.StatusOutForDelivery, role, models.TriggerManual) {
+			if next == models.StatusDelivered {
+				t.Errorf("%s puede pasar la guía a DELIVERED manualmente", role)
+			}
+		}
+	}
+}
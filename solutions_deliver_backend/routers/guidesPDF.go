@@ -19,13 +19,13 @@ func GetGuidePDFURL(guideID int64) (int, string) {
 	fmt.Printf("GetGuidePDFURL -> GuideID: %d\n", guideID)
 
 	// Verificar que la guía existe
-	if !bd.GuideExists(guideID) {
+	if !repos.Guides.GuideExists(guideID) {
 		fmt.Printf("ERROR: Guía %d no encontrada\n", guideID)
 		return 404, `{"error": "Guía no encontrada"}`
 	}
 
 	// Obtener información del PDF - AHORA SOLO RETORNA S3_KEY
-	s3Key, err := bd.GetGuidePDFInfo(guideID)
+	s3Key, err := repos.Guides.GetGuidePDFInfo(guideID)
 	if err != nil {
 		fmt.Printf("ERROR al obtener PDF info: %v\n", err)
 		return 404, fmt.Sprintf(`{"error": "PDF no encontrado: %s"}`, err.Error())
@@ -61,12 +61,12 @@ func DownloadGuidePDFDirect(guideID int64) (int, []byte, string, error) {
 	fmt.Printf("DownloadGuidePDFDirect -> GuideID: %d\n", guideID)
 
 	// Verificar que la guía existe
-	if !bd.GuideExists(guideID) {
+	if !repos.Guides.GuideExists(guideID) {
 		return 404, nil, "", fmt.Errorf("Guía no encontrada")
 	}
 
 	// Obtener información del PDF
-	s3Key, err := bd.GetGuidePDFInfo(guideID)
+	s3Key, err := repos.Guides.GetGuidePDFInfo(guideID)
 	if err != nil {
 		return 404, nil, "", fmt.Errorf("PDF no encontrado: %s", err.Error())
 	}
@@ -0,0 +1,356 @@
+package routers
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/notify"
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/secretm"
+)
+
+// Código de entrega (OTP) para guías COD o de alto valor:
+//   HANDOVER_CODE_MIN_VALUE     valor declarado desde el que se exige (por defecto 1.000.000)
+//   HANDOVER_CODE_TTL           vigencia del código (por defecto 4h)
+//   HANDOVER_CODE_MAX_ATTEMPTS  intentos fallidos antes del bloqueo (por defecto 5)
+//   HANDOVER_CODE_LOCKOUT       duración del primer bloqueo (por defecto 15m); cada bloqueo siguiente dura el doble
+//   HANDOVER_CODE_SECRET        llave del HMAC de los códigos (mínimo 32 caracteres)
+//   HANDOVER_CODE_SECRET_NAME   secreto de Secrets Manager con la llave, si no se define la anterior
+
+const (
+	handoverCodeDigits   = 6
+	handoverKeyMinLength = 32
+)
+
+var (
+	handoverKeyMu     sync.Mutex
+	handoverKeyCached []byte
+)
+
+// handoverKey llave del HMAC de los códigos. El valor de Secrets Manager se
+// lee una sola vez por contenedor.
+func handoverKey() ([]byte, error) {
+	if secret := os.Getenv("HANDOVER_CODE_SECRET"); secret != "" {
+		return checkHandoverKey(secret)
+	}
+
+	handoverKeyMu.Lock()
+	defer handoverKeyMu.Unlock()
+
+	if handoverKeyCached != nil {
+		return handoverKeyCached, nil
+	}
+
+	name := os.Getenv("HANDOVER_CODE_SECRET_NAME")
+	if name == "" {
+		return nil, fmt.Errorf("llave de códigos de entrega no configurada: defina HANDOVER_CODE_SECRET o HANDOVER_CODE_SECRET_NAME")
+	}
+	secret, err := secretm.GetSecretString(name)
+	if err != nil {
+		return nil, fmt.Errorf("error al leer la llave de códigos de entrega: %v", err)
+	}
+	key, err := checkHandoverKey(secret)
+	if err != nil {
+		return nil, err
+	}
+	handoverKeyCached = key
+	return key, nil
+}
+
+func checkHandoverKey(secret string) ([]byte, error) {
+	if len(secret) < handoverKeyMinLength {
+		return nil, fmt.Errorf("la llave de códigos de entrega debe tener al menos %d caracteres", handoverKeyMinLength)
+	}
+	return []byte(secret), nil
+}
+
+// hashHandoverCode HMAC del código con la llave del servidor
+func hashHandoverCode(assignmentID int64, plain string) (string, error) {
+	key, err := handoverKey()
+	if err != nil {
+		return "", err
+	}
+	return models.HashHandoverCode(key, assignmentID, plain), nil
+}
+
+func handoverMinValue() float64 {
+	value, err := strconv.ParseFloat(os.Getenv("HANDOVER_CODE_MIN_VALUE"), 64)
+	if err != nil || value <= 0 {
+		return 1000000
+	}
+	return value
+}
+
+func handoverTTL() time.Duration {
+	value, err := time.ParseDuration(os.Getenv("HANDOVER_CODE_TTL"))
+	if err != nil || value <= 0 {
+		return 4 * time.Hour
+	}
+	return value
+}
+
+func handoverMaxAttempts() int {
+	value, err := strconv.Atoi(os.Getenv("HANDOVER_CODE_MAX_ATTEMPTS"))
+	if err != nil || value < 1 {
+		return 5
+	}
+	return value
+}
+
+func handoverLockout() time.Duration {
+	value, err := time.ParseDuration(os.Getenv("HANDOVER_CODE_LOCKOUT"))
+	if err != nil || value <= 0 {
+		return 15 * time.Minute
+	}
+	return value
+}
+
+// requiresHandoverCode indica si la entrega de la guía exige código
+func requiresHandoverCode(guide models.ShippingGuide) bool {
+	return guide.PaymentMethod == models.PaymentCOD || guide.DeclaredValue >= handoverMinValue()
+}
+
+// issueHandoverCode genera un código nuevo, guarda su hash y lo envía al destinatario
+func issueHandoverCode(assignment models.DeliveryAssignment, guide models.ShippingGuide, userUUID string) (models.HandoverCode, error) {
+	var code models.HandoverCode
+
+	receiver := guide.Receiver
+	if receiver == nil || (receiver.Phone == "" && receiver.Email == "") {
+		return code, fmt.Errorf("el destinatario no tiene teléfono ni correo")
+	}
+
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return code, err
+	}
+	plain := fmt.Sprintf("%0*d", handoverCodeDigits, n.Int64())
+	codeHash, err := hashHandoverCode(assignment.AssignmentID, plain)
+	if err != nil {
+		return code, err
+	}
+
+	channels := []string{}
+	sentTo := []string{}
+	if receiver.Phone != "" {
+		channels = append(channels, "SMS")
+		sentTo = append(sentTo, maskDocument(receiver.Phone))
+	}
+	if receiver.Email != "" {
+		channels = append(channels, "EMAIL")
+		sentTo = append(sentTo, maskEmail(receiver.Email))
+	}
+
+	ttl := handoverTTL()
+	code = models.HandoverCode{
+		AssignmentID: assignment.AssignmentID,
+		GuideID:      guide.GuideID,
+		CodeHash:     codeHash,
+		Channel:      strings.Join(channels, ","),
+		SentTo:       strings.Join(sentTo, ", "),
+		ExpiresAt:    time.Now().Add(ttl),
+	}
+
+	err = repos.Assignments.SaveHandoverCode(code, userUUID)
+	if err != nil {
+		return code, err
+	}
+
+	err = notify.Send(notify.Message{
+		Phone:   receiver.Phone,
+		Email:   receiver.Email,
+		Subject: fmt.Sprintf("Código de entrega guía %s", guide.GuideNumber),
+		Body: fmt.Sprintf("Tu envío %s va en camino. Entrega este código al mensajero para recibirlo: %s. Válido por %s.",
+			guide.GuideNumber, plain, ttl),
+	})
+	if err != nil {
+		return code, fmt.Errorf("error al enviar el código de entrega: %v", err)
+	}
+
+	return code, nil
+}
+
+// checkHandoverCode valida el código al completar la entrega.
+// Retorna status 0 si la entrega puede completarse.
+func checkHandoverCode(assignmentID int64, plain string, userUUID string) (int, string) {
+	code, err := repos.Assignments.GetHandoverCode(assignmentID)
+	if err != nil {
+		if err.Error() == "código de entrega no encontrado" {
+			return 409, `{"error": "La guía exige código de entrega y no se ha generado. Reenvíelo con POST /assignments/{id}/handover-code"}`
+		}
+		return 500, fmt.Sprintf(`{"error": "Error al obtener el código de entrega: %s"}`, err.Error())
+	}
+	if code.VerifiedAt != nil {
+		return 0, ""
+	}
+
+	plain = strings.TrimSpace(plain)
+	if plain == "" {
+		return 400, `{"error": "handover_code es requerido para esta entrega"}`
+	}
+
+	codeHash, err := hashHandoverCode(assignmentID, plain)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al verificar el código de entrega: %s"}`, err.Error())
+	}
+
+	result, err := repos.Assignments.VerifyHandoverCode(assignmentID, codeHash,
+		handoverMaxAttempts(), handoverLockout(), userUUID)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al verificar el código de entrega: %s"}`, err.Error())
+	}
+
+	response := models.HandoverErrorResponse{RemainingAttempts: result.RemainingAttempts, LockedUntil: result.LockedUntil}
+	status := 0
+	switch result.Result {
+	case models.HandoverValid:
+		return 0, ""
+	case models.HandoverInvalid:
+		status, response.Error = 400, "Código de entrega incorrecto"
+	case models.HandoverLocked:
+		status, response.Error = 429, "Demasiados intentos con código incorrecto. Intente más tarde"
+	case models.HandoverExpired:
+		status, response.Error = 409, "El código de entrega expiró. Solicite uno nuevo"
+	}
+
+	jsonResponse, err := json.Marshal(response)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al serializar respuesta: %s"}`, err.Error())
+	}
+	return status, string(jsonResponse)
+}
+
+// maskEmail deja visibles la primera letra y el dominio
+func maskEmail(email string) string {
+	at := strings.Index(email, "@")
+	if at <= 1 {
+		return email
+	}
+	return email[:1] + strings.Repeat("*", at-1) + email[at:]
+}
+
+// handoverAssignment carga la entrega en curso y su guía, validando que exija código
+func handoverAssignment(assignmentID int64) (models.DeliveryAssignment, models.ShippingGuide, int, string) {
+	var guide models.ShippingGuide
+
+	assignment, err := repos.Assignments.GetAssignmentByID(assignmentID)
+	if err != nil {
+		return assignment, guide, 404, fmt.Sprintf(`{"error": "%s"}`, err.Error())
+	}
+	if assignment.AssignmentType != models.AssignmentDelivery {
+		return assignment, guide, 400, `{"error": "Solo las asignaciones de entrega usan código de entrega"}`
+	}
+	if assignment.Status != models.AssignmentInProgress {
+		return assignment, guide, 409, `{"error": "La entrega debe estar en progreso"}`
+	}
+
+	guide, err = repos.Guides.GetGuideByID(assignment.GuideID)
+	if err != nil {
+		return assignment, guide, 500, fmt.Sprintf(`{"error": "Error al obtener la guía de la asignación: %s"}`, err.Error())
+	}
+	if !requiresHandoverCode(guide) {
+		return assignment, guide, 400, `{"error": "La guía no exige código de entrega"}`
+	}
+
+	return assignment, guide, 0, ""
+}
+
+// ResendHandoverCode genera y envía un código nuevo (el anterior deja de
+// servir). Conserva los fallos acumulados y responde 409 mientras el código
+// esté bloqueado.
+func ResendHandoverCode(assignmentID int64, userUUID string) (int, string) {
+	fmt.Printf("ResendHandoverCode -> AssignmentID: %d\n", assignmentID)
+
+	assignment, guide, status, body := handoverAssignment(assignmentID)
+	if status != 0 {
+		return status, body
+	}
+
+	// Un código bloqueado no se reemplaza hasta que venza el bloqueo
+	current, err := repos.Assignments.GetHandoverCode(assignmentID)
+	if err != nil && err.Error() != "código de entrega no encontrado" {
+		return 500, fmt.Sprintf(`{"error": "Error al obtener el código de entrega: %s"}`, err.Error())
+	}
+	if err == nil && current.VerifiedAt == nil && current.LockedUntil != nil && current.LockedUntil.After(time.Now()) {
+		response := models.HandoverErrorResponse{
+			Error:       "El código de entrega está bloqueado por intentos fallidos. No se puede reenviar hasta que venza el bloqueo",
+			LockedUntil: current.LockedUntil,
+		}
+		jsonResponse, err := json.Marshal(response)
+		if err != nil {
+			return 500, fmt.Sprintf(`{"error": "Error al serializar respuesta: %s"}`, err.Error())
+		}
+		return 409, string(jsonResponse)
+	}
+
+	code, err := issueHandoverCode(assignment, guide, userUUID)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "%s"}`, err.Error())
+	}
+
+	response := models.HandoverCodeResponse{
+		Success:      true,
+		AssignmentID: assignmentID,
+		Code:         code,
+		Message:      fmt.Sprintf("Código de entrega enviado a %s", code.SentTo),
+	}
+
+	jsonResponse, err := json.Marshal(response)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al serializar respuesta: %s"}`, err.Error())
+	}
+
+	return 200, string(jsonResponse)
+}
+
+// OverrideHandoverCode permite completar la entrega sin código (ADMIN).
+// El motivo queda en assignment_history.
+func OverrideHandoverCode(body string, assignmentID int64, userUUID string) (int, string) {
+	fmt.Printf("OverrideHandoverCode -> AssignmentID: %d\n", assignmentID)
+
+	var req models.HandoverOverrideRequest
+	err := json.Unmarshal([]byte(body), &req)
+	if err != nil {
+		return 400, fmt.Sprintf(`{"error": "Body inválido: %s"}`, err.Error())
+	}
+
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		return 400, `{"error": "reason es requerido"}`
+	}
+
+	assignment, guide, status, body := handoverAssignment(assignmentID)
+	if status != 0 {
+		return status, body
+	}
+
+	err = repos.Assignments.OverrideHandoverCode(assignmentID, guide.GuideID, req.Reason, userUUID)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al omitir el código de entrega: %s"}`, err.Error())
+	}
+
+	code, err := repos.Assignments.GetHandoverCode(assignment.AssignmentID)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al obtener el código de entrega: %s"}`, err.Error())
+	}
+
+	response := models.HandoverCodeResponse{
+		Success:      true,
+		AssignmentID: assignmentID,
+		Code:         code,
+		Message:      "Código de entrega omitido. La entrega puede completarse sin código",
+	}
+
+	jsonResponse, err := json.Marshal(response)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al serializar respuesta: %s"}`, err.Error())
+	}
+
+	return 200, string(jsonResponse)
+}
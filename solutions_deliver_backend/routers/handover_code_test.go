@@ -0,0 +1,214 @@
+package routers
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/notify"
+)
+
+const testHandoverSecret = "llave-de-pruebas-de-codigos-de-entrega"
+
+// captureNotifier guarda los mensajes en lugar de enviarlos
+type captureNotifier struct {
+	messages []notify.Message
+}
+
+func (n *captureNotifier) Send(msg notify.Message) error {
+	n.messages = append(n.messages, msg)
+	return nil
+}
+
+func captureNotifications(t *testing.T) *captureNotifier {
+	t.Helper()
+	n := &captureNotifier{}
+	notify.SetNotifier(n)
+	t.Cleanup(func() { notify.SetNotifier(notify.LogNotifier{}) })
+	return n
+}
+
+var handoverCodePattern = regexp.MustCompile(`código al mensajero para recibirlo: (\d{6})`)
+
+// startCODDelivery crea una guía COD en bodega y sale a entregarla; retorna
+// la asignación y el código enviado al destinatario
+func startCODDelivery(t *testing.T, n *captureNotifier) (int64, int64, string) {
+	t.Helper()
+	store := newTestStore(t)
+	guideID := store.AddGuide(models.ShippingGuide{
+		GuideNumber:   "SD00000018",
+		CurrentStatus: models.StatusInWarehouse,
+		PaymentMethod: models.PaymentCOD,
+		Receiver:      &models.GuideParty{FullName: "Ana Pérez", Phone: "3001234567"},
+	})
+	assignmentID := store.AddAssignment(models.DeliveryAssignment{
+		GuideID: guideID, AssignmentType: models.AssignmentDelivery, Status: models.AssignmentPending, DeliveryUserID: "delivery-1",
+	})
+
+	if status, response := UpdateAssignmentStatus(`{"status": "IN_PROGRESS"}`, "delivery-1", assignmentID); status != 200 {
+		t.Fatalf("status = %d (%s), se esperaba 200", status, response)
+	}
+	if len(n.messages) != 1 {
+		t.Fatalf("mensajes = %d, se esperaba 1", len(n.messages))
+	}
+	match := handoverCodePattern.FindStringSubmatch(n.messages[0].Body)
+	if match == nil {
+		t.Fatalf("el mensaje no trae el código: %s", n.messages[0].Body)
+	}
+	return guideID, assignmentID, match[1]
+}
+
+func TestHandoverCodeMessageUsesGuideNumber(t *testing.T) {
+	t.Setenv("HANDOVER_CODE_SECRET", testHandoverSecret)
+	n := captureNotifications(t)
+
+	startCODDelivery(t, n)
+
+	msg := n.messages[0]
+	if msg.Subject != "Código de entrega guía SD00000018" {
+		t.Errorf("asunto = %q", msg.Subject)
+	}
+	if !strings.HasPrefix(msg.Body, "Tu envío SD00000018 va en camino") {
+		t.Errorf("cuerpo = %q", msg.Body)
+	}
+}
+
+// Una guía COD solo se entrega con el código correcto o con la anulación del ADMIN
+func TestCODDeliveryRequiresHandoverCode(t *testing.T) {
+	t.Setenv("DELIVERY_PROOF_REQUIRED", "RECEIVER_NAME")
+	t.Setenv("HANDOVER_CODE_SECRET", testHandoverSecret)
+
+	const proof = `"proof": {"receiver_name": "Ana Pérez"}`
+
+	tests := []struct {
+		name     string
+		body     func(code string) string
+		override bool
+		status   int
+		want     models.GuideStatus
+	}{
+		{"sin código", func(string) string { return `{"status": "COMPLETED", ` + proof + `}` },
+			false, 400, models.StatusOutForDelivery},
+		{"código incorrecto", func(code string) string {
+			wrong := "000000"
+			if code == wrong {
+				wrong = "111111"
+			}
+			return `{"status": "COMPLETED", "handover_code": "` + wrong + `", ` + proof + `}`
+		}, false, 400, models.StatusOutForDelivery},
+		{"código correcto", func(code string) string {
+			return `{"status": "COMPLETED", "handover_code": "` + code + `", ` + proof + `}`
+		}, false, 200, models.StatusDelivered},
+		{"anulación del admin", func(string) string { return `{"status": "COMPLETED", ` + proof + `}` },
+			true, 200, models.StatusDelivered},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := captureNotifications(t)
+			guideID, assignmentID, code := startCODDelivery(t, n)
+
+			if tt.override {
+				status, response := OverrideHandoverCode(`{"reason": "destinatario sin teléfono"}`, assignmentID, "admin")
+				if status != 200 {
+					t.Fatalf("override = %d (%s), se esperaba 200", status, response)
+				}
+			}
+
+			status, response := UpdateAssignmentStatus(tt.body(code), "delivery-1", assignmentID)
+			if status != tt.status {
+				t.Fatalf("status = %d (%s), se esperaba %d", status, response, tt.status)
+			}
+			guide, _ := repos.Guides.GetGuideByID(guideID)
+			if guide.CurrentStatus != tt.want {
+				t.Errorf("guía = %s, se esperaba %s", guide.CurrentStatus, tt.want)
+			}
+		})
+	}
+}
+
+// El hash guardado depende de la llave del servidor
+func TestHandoverCodeHashUsesServerKey(t *testing.T) {
+	t.Setenv("HANDOVER_CODE_SECRET", testHandoverSecret)
+	hash, err := hashHandoverCode(7, "123456")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != models.HashHandoverCode([]byte(testHandoverSecret), 7, "123456") {
+		t.Errorf("hash no usa la llave configurada")
+	}
+
+	t.Setenv("HANDOVER_CODE_SECRET", testHandoverSecret+"-otra")
+	other, _ := hashHandoverCode(7, "123456")
+	if other == hash {
+		t.Errorf("llaves distintas producen el mismo hash")
+	}
+
+	t.Setenv("HANDOVER_CODE_SECRET", "corta")
+	if _, err := hashHandoverCode(7, "123456"); err == nil {
+		t.Errorf("se esperaba error con una llave corta")
+	}
+
+	t.Setenv("HANDOVER_CODE_SECRET", "")
+	t.Setenv("HANDOVER_CODE_SECRET_NAME", "")
+	if _, err := hashHandoverCode(7, "123456"); err == nil {
+		t.Errorf("se esperaba error sin llave configurada")
+	}
+}
+
+// Reenviar el código no levanta el bloqueo ni reinicia los fallos
+func TestResendHandoverCodeKeepsLockout(t *testing.T) {
+	t.Setenv("HANDOVER_CODE_SECRET", testHandoverSecret)
+	t.Setenv("HANDOVER_CODE_MAX_ATTEMPTS", "2")
+	t.Setenv("HANDOVER_CODE_LOCKOUT", "50ms")
+	n := captureNotifications(t)
+
+	_, assignmentID, code := startCODDelivery(t, n)
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+
+	fail := func() int {
+		status, _ := checkHandoverCode(assignmentID, wrong, "delivery-1")
+		return status
+	}
+
+	if status := fail(); status != 400 {
+		t.Fatalf("primer fallo = %d, se esperaba 400", status)
+	}
+	if status := fail(); status != 429 {
+		t.Fatalf("segundo fallo = %d, se esperaba 429", status)
+	}
+
+	status, response := ResendHandoverCode(assignmentID, "delivery-1")
+	if status != 409 {
+		t.Fatalf("reenvío bloqueado = %d (%s), se esperaba 409", status, response)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if status, response := ResendHandoverCode(assignmentID, "delivery-1"); status != 200 {
+		t.Fatalf("reenvío = %d (%s), se esperaba 200", status, response)
+	}
+
+	current, err := repos.Assignments.GetHandoverCode(assignmentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current.FailedAttempts != 2 {
+		t.Errorf("fallos = %d, se esperaba 2", current.FailedAttempts)
+	}
+
+	// El siguiente bloqueo dura el doble
+	fail()
+	before := time.Now()
+	if status := fail(); status != 429 {
+		t.Fatalf("cuarto fallo = %d, se esperaba 429", status)
+	}
+	current, _ = repos.Assignments.GetHandoverCode(assignmentID)
+	if current.LockedUntil == nil || current.LockedUntil.Sub(before) < 90*time.Millisecond {
+		t.Errorf("bloqueo = %v, se esperaba al menos 100ms", current.LockedUntil)
+	}
+}
@@ -0,0 +1,282 @@
+package routers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+)
+
+// Hubs (sedes). Cada hub cubre ciudades o departamentos completos y una
+// ciudad solo puede pertenecer a un hub activo. Secretarias y repartidores
+// operan únicamente sobre su hub; ADMIN ve el consolidado o filtra con
+// ?hub_id=.
+
+// ResolveHubScope alcance por hub del usuario. ADMIN usa el hub pedido
+// (vacío: consolidado); los demás roles quedan en su propio hub.
+func ResolveHubScope(role models.UserRole, userHubID *int64, requested string) (models.HubScope, int, string) {
+	if role != models.RoleAdmin {
+		if userHubID == nil {
+			return models.HubScope{}, 403, `{"error": "No autorizado - El usuario no tiene un hub asignado"}`
+		}
+		return models.HubScope{HubID: userHubID}, 0, ""
+	}
+
+	if requested == "" {
+		return models.HubScope{}, 0, ""
+	}
+	hubID, err := strconv.ParseInt(requested, 10, 64)
+	if err != nil || hubID <= 0 {
+		return models.HubScope{}, 400, `{"error": "hub_id inválido"}`
+	}
+	if _, status, message := findHub(hubID); status != 0 {
+		return models.HubScope{}, status, message
+	}
+	return models.HubScope{HubID: &hubID}, 0, ""
+}
+
+// CreateHub crea un hub con su cobertura
+func CreateHub(body string, userUUID string) (int, string) {
+	fmt.Printf("CreateHub -> UserUUID: %s\n", userUUID)
+
+	var request models.CreateHubRequest
+	if err := json.Unmarshal([]byte(body), &request); err != nil {
+		return 400, fmt.Sprintf(`{"error": "Body inválido: %s"}`, err.Error())
+	}
+
+	hub := models.Hub{
+		Code:      strings.ToUpper(strings.Join(strings.Fields(request.Code), "")),
+		Name:      strings.TrimSpace(request.Name),
+		CityID:    request.CityID,
+		Active:    true,
+		CreatedBy: userUUID,
+	}
+	if hub.Code == "" || hub.Name == "" {
+		return 400, `{"error": "code y name son requeridos"}`
+	}
+	if hub.CityID <= 0 {
+		return 400, `{"error": "city_id es requerido"}`
+	}
+
+	coverage, status, message := buildHubCoverage(hub.CityID, request.CityIDs, request.DepartmentIDs)
+	if status != 0 {
+		return status, message
+	}
+	if status, message := checkCoverageOverlap(0, coverage); status != 0 {
+		return status, message
+	}
+	hub.Coverage = coverage
+
+	err := repos.Hubs.CreateHub(&hub)
+	if err != nil {
+		if err.Error() == "ya existe un hub con ese código" {
+			return 409, `{"error": "Ya existe un hub con ese código"}`
+		}
+		return 500, fmt.Sprintf(`{"error": "Error al crear el hub: %s"}`, err.Error())
+	}
+
+	created, status, message := findHub(hub.HubID)
+	if status != 0 {
+		return status, message
+	}
+
+	return jsonResult(201, map[string]interface{}{
+		"success": true,
+		"hub":     created,
+		"message": "Hub creado correctamente",
+	})
+}
+
+// GetHubs lista los hubs con su cobertura
+func GetHubs() (int, string) {
+	fmt.Println("GetHubs")
+
+	hubs, err := repos.Hubs.GetHubs()
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al obtener hubs: %s"}`, err.Error())
+	}
+	if hubs == nil {
+		hubs = []models.Hub{}
+	}
+
+	return jsonResult(200, map[string]interface{}{
+		"hubs":  hubs,
+		"total": len(hubs),
+	})
+}
+
+// GetHubByID obtiene un hub con su cobertura
+func GetHubByID(hubID int64) (int, string) {
+	fmt.Printf("GetHubByID -> HubID: %d\n", hubID)
+
+	hub, status, message := findHub(hubID)
+	if status != 0 {
+		return status, message
+	}
+	return jsonResult(200, hub)
+}
+
+// UpdateHub cambia nombre, estado o cobertura de un hub. La ciudad sede
+// siempre queda cubierta.
+func UpdateHub(hubID int64, body string) (int, string) {
+	fmt.Printf("UpdateHub -> HubID: %d\n", hubID)
+
+	var request models.UpdateHubRequest
+	if err := json.Unmarshal([]byte(body), &request); err != nil {
+		return 400, fmt.Sprintf(`{"error": "Body inválido: %s"}`, err.Error())
+	}
+
+	hub, status, message := findHub(hubID)
+	if status != 0 {
+		return status, message
+	}
+
+	if name := strings.TrimSpace(request.Name); name != "" {
+		hub.Name = name
+	}
+	if request.Active != nil {
+		hub.Active = *request.Active
+	}
+
+	cityIDs, departmentIDs := request.CityIDs, request.DepartmentIDs
+	if cityIDs == nil && departmentIDs == nil {
+		for _, c := range hub.Coverage {
+			if c.DepartmentID != 0 {
+				departmentIDs = append(departmentIDs, c.DepartmentID)
+			} else {
+				cityIDs = append(cityIDs, c.CityID)
+			}
+		}
+	}
+	coverage, status, message := buildHubCoverage(hub.CityID, cityIDs, departmentIDs)
+	if status != 0 {
+		return status, message
+	}
+	if hub.Active {
+		if status, message := checkCoverageOverlap(hub.HubID, coverage); status != 0 {
+			return status, message
+		}
+	}
+	hub.Coverage = coverage
+
+	if err := repos.Hubs.UpdateHub(hub); err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al actualizar el hub: %s"}`, err.Error())
+	}
+
+	updated, status, message := findHub(hubID)
+	if status != 0 {
+		return status, message
+	}
+
+	return jsonResult(200, map[string]interface{}{
+		"success": true,
+		"hub":     updated,
+		"message": "Hub actualizado correctamente",
+	})
+}
+
+func findHub(hubID int64) (models.Hub, int, string) {
+	hub, err := repos.Hubs.GetHubByID(hubID)
+	if err != nil {
+		if err.Error() == "Hub no encontrado" {
+			return hub, 404, `{"error": "Hub no encontrado"}`
+		}
+		return hub, 500, fmt.Sprintf(`{"error": "Error al obtener el hub: %s"}`, err.Error())
+	}
+	return hub, 0, ""
+}
+
+// buildHubCoverage valida ciudades y departamentos y agrega la ciudad sede
+// si ninguno de ellos la cubre
+func buildHubCoverage(hubCityID int64, cityIDs, departmentIDs []int64) ([]models.HubCoverage, int, string) {
+	hubCity, err := repos.Locations.GetCityByID(hubCityID)
+	if err != nil {
+		return nil, 400, `{"error": "La ciudad del hub no existe"}`
+	}
+
+	coverage := []models.HubCoverage{}
+	departments := map[int64]bool{}
+	for _, departmentID := range departmentIDs {
+		if departments[departmentID] {
+			continue
+		}
+		if !repos.Locations.DepartmentExists(departmentID) {
+			return nil, 400, fmt.Sprintf(`{"error": "El departamento %d no existe"}`, departmentID)
+		}
+		departments[departmentID] = true
+		coverage = append(coverage, models.HubCoverage{DepartmentID: departmentID})
+	}
+
+	cities := map[int64]bool{}
+	for _, cityID := range cityIDs {
+		if cities[cityID] {
+			continue
+		}
+		city, err := repos.Locations.GetCityByID(cityID)
+		if err != nil {
+			return nil, 400, fmt.Sprintf(`{"error": "La ciudad %d no existe"}`, cityID)
+		}
+		cities[cityID] = true
+		// Ya cubierta por su departamento
+		if departments[city.DepartmentID] {
+			continue
+		}
+		coverage = append(coverage, models.HubCoverage{CityID: cityID})
+	}
+
+	if !cities[hubCity.ID] && !departments[hubCity.DepartmentID] {
+		coverage = append(coverage, models.HubCoverage{CityID: hubCity.ID})
+	}
+
+	return coverage, 0, ""
+}
+
+// checkCoverageOverlap valida que ninguna ciudad de la cobertura quede en
+// otro hub activo (hubID 0: hub nuevo)
+func checkCoverageOverlap(hubID int64, coverage []models.HubCoverage) (int, string) {
+	hubs, err := repos.Hubs.GetHubs()
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al obtener hubs: %s"}`, err.Error())
+	}
+
+	for _, other := range hubs {
+		if other.HubID == hubID || !other.Active {
+			continue
+		}
+		for _, a := range coverage {
+			for _, b := range other.Coverage {
+				if coverageOverlaps(a, b) {
+					return 409, fmt.Sprintf(`{"error": "La cobertura se cruza con el hub %s"}`, other.Code)
+				}
+			}
+		}
+	}
+	return 0, ""
+}
+
+// coverageOverlaps indica si dos entradas de cobertura comparten ciudades
+func coverageOverlaps(a, b models.HubCoverage) bool {
+	if a.CityID != 0 && a.CityID == b.CityID {
+		return true
+	}
+	if a.DepartmentID != 0 && a.DepartmentID == b.DepartmentID {
+		return true
+	}
+	if a.CityID != 0 && b.DepartmentID != 0 {
+		return cityDepartment(a.CityID) == b.DepartmentID
+	}
+	if a.DepartmentID != 0 && b.CityID != 0 {
+		return cityDepartment(b.CityID) == a.DepartmentID
+	}
+	return false
+}
+
+func cityDepartment(cityID int64) int64 {
+	city, err := repos.Locations.GetCityByID(cityID)
+	if err != nil {
+		return 0
+	}
+	return city.DepartmentID
+}
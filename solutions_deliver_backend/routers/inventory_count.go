@@ -0,0 +1,307 @@
+package routers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+)
+
+// Conteo físico de una bodega: se abre el conteo, se escanean los bins (los
+// códigos de guía o de pieza que hay en cada uno) y al cerrarlo se concilia
+// contra lo esperado, las piezas IN_WAREHOUSE ubicadas en la bodega o sin
+// ubicar. La conciliación se calcula en cada consulta; cerrar con apply
+// reubica lo encontrado en otro bin o sin ubicar.
+
+// StartInventoryCount abre un conteo en la bodega
+func StartInventoryCount(warehouseID int64, body string, userUUID string) (int, string) {
+	fmt.Printf("StartInventoryCount -> WarehouseID: %d, UserUUID: %s\n", warehouseID, userUUID)
+
+	var request models.InventoryCountRequest
+	if strings.TrimSpace(body) != "" {
+		if err := json.Unmarshal([]byte(body), &request); err != nil {
+			return 400, fmt.Sprintf(`{"error": "Body inválido: %s"}`, err.Error())
+		}
+	}
+
+	if _, status, message := findWarehouse(warehouseID); status != 0 {
+		return status, message
+	}
+
+	count := models.InventoryCount{
+		WarehouseID: warehouseID,
+		Notes:       strings.TrimSpace(request.Notes),
+		StartedBy:   userUUID,
+	}
+	err := repos.Warehouses.CreateInventoryCount(&count)
+	if err != nil {
+		if err.Error() == "ya hay un conteo abierto en la bodega" {
+			return 409, `{"error": "Ya hay un conteo abierto en la bodega"}`
+		}
+		return 500, fmt.Sprintf(`{"error": "Error al iniciar el conteo: %s"}`, err.Error())
+	}
+
+	return jsonResult(201, map[string]interface{}{
+		"success": true,
+		"count":   count,
+		"message": "Conteo iniciado",
+	})
+}
+
+// GetInventoryCount conteo con la conciliación a la fecha
+func GetInventoryCount(countID int64) (int, string) {
+	fmt.Printf("GetInventoryCount -> CountID: %d\n", countID)
+
+	count, status, message := findInventoryCount(countID)
+	if status != 0 {
+		return status, message
+	}
+
+	reconciliation, status, message := inventoryReconciliation(count)
+	if status != 0 {
+		return status, message
+	}
+
+	return jsonResult(200, models.InventoryCountResponse{Count: count, Reconciliation: reconciliation})
+}
+
+// ScanInventoryCount registra los códigos escaneados en un bin de la bodega
+// del conteo. Un código rechazado no detiene el lote.
+func ScanInventoryCount(countID int64, body string, userUUID string) (int, string) {
+	fmt.Printf("ScanInventoryCount -> CountID: %d, UserUUID: %s\n", countID, userUUID)
+
+	var request models.InventoryScanRequest
+	if err := json.Unmarshal([]byte(body), &request); err != nil {
+		return 400, fmt.Sprintf(`{"error": "Body inválido: %s"}`, err.Error())
+	}
+	if request.BinID <= 0 {
+		return 400, `{"error": "bin_id es requerido"}`
+	}
+	if status, message := validateWarehouseCodes(request.Codes); status != 0 {
+		return status, message
+	}
+
+	count, status, message := findInventoryCount(countID)
+	if status != 0 {
+		return status, message
+	}
+	if count.Status != models.InventoryCountOpen {
+		return 409, `{"error": "El conteo está cerrado"}`
+	}
+
+	bin, err := repos.Warehouses.GetBinByID(request.BinID)
+	if err != nil || bin.WarehouseID != count.WarehouseID {
+		return 404, `{"error": "El bin no existe en la bodega del conteo"}`
+	}
+
+	var items []models.InventoryCountItem
+	response := models.WarehouseBatchResponse{Items: make([]models.WarehouseItemResult, 0, len(request.Codes))}
+
+	for _, raw := range request.Codes {
+		code := strings.TrimSpace(raw)
+		result := models.WarehouseItemResult{Code: code, BinCode: bin.Code}
+
+		guide, pieces, message := resolveItemCode(code)
+		if message != "" {
+			result.Error = message
+			response.Items = append(response.Items, result)
+			continue
+		}
+
+		for _, piece := range pieces {
+			items = append(items, models.InventoryCountItem{
+				CountID:     countID,
+				GuideID:     guide.GuideID,
+				PieceNumber: piece,
+				BinID:       bin.BinID,
+				Code:        code,
+				ScannedBy:   userUUID,
+			})
+		}
+		result.GuideID = guide.GuideID
+		result.GuideNumber = guide.GuideNumber
+		result.Pieces = withoutWholeGuide(pieces)
+		result.Success = true
+		response.Items = append(response.Items, result)
+	}
+
+	if len(items) > 0 {
+		if err := repos.Warehouses.AddInventoryCountItems(items); err != nil {
+			return 500, fmt.Sprintf(`{"error": "Error al registrar el conteo: %s"}`, err.Error())
+		}
+	}
+
+	return batchResult(response)
+}
+
+// CloseInventoryCount cierra el conteo y retorna la conciliación final.
+// Con apply, lo encontrado en otro bin (MISPLACED) o sin ubicar (UNLOCATED)
+// queda ubicado en el bin donde se escaneó.
+func CloseInventoryCount(countID int64, body string, userUUID string) (int, string) {
+	fmt.Printf("CloseInventoryCount -> CountID: %d, UserUUID: %s\n", countID, userUUID)
+
+	var request models.CloseInventoryCountRequest
+	if strings.TrimSpace(body) != "" {
+		if err := json.Unmarshal([]byte(body), &request); err != nil {
+			return 400, fmt.Sprintf(`{"error": "Body inválido: %s"}`, err.Error())
+		}
+	}
+
+	count, status, message := findInventoryCount(countID)
+	if status != 0 {
+		return status, message
+	}
+	if count.Status != models.InventoryCountOpen {
+		return 409, `{"error": "El conteo ya está cerrado"}`
+	}
+
+	reconciliation, status, message := inventoryReconciliation(count)
+	if status != 0 {
+		return status, message
+	}
+
+	if request.Apply {
+		notes := fmt.Sprintf("Conteo %d", countID)
+		for _, line := range reconciliation.Lines {
+			if line.Result != models.InventoryMisplaced && line.Result != models.InventoryUnlocated {
+				continue
+			}
+			err := repos.Warehouses.LocateGuideItems(line.GuideID, []int{line.PieceNumber}, line.ScannedBinID, models.MovementCountAdjust, notes, userUUID)
+			if err != nil {
+				return 500, fmt.Sprintf(`{"error": "Error al reubicar la guía %d: %s"}`, line.GuideID, err.Error())
+			}
+		}
+	}
+
+	err := repos.Warehouses.CloseInventoryCount(countID, request.Apply, userUUID)
+	if err != nil {
+		if err.Error() == "el conteo ya está cerrado" {
+			return 409, `{"error": "El conteo ya está cerrado"}`
+		}
+		return 500, fmt.Sprintf(`{"error": "Error al cerrar el conteo: %s"}`, err.Error())
+	}
+
+	if closed, err := repos.Warehouses.GetInventoryCount(countID); err == nil {
+		count = closed
+	}
+
+	return jsonResult(200, models.InventoryCountResponse{Count: count, Reconciliation: reconciliation})
+}
+
+func findInventoryCount(countID int64) (models.InventoryCount, int, string) {
+	count, err := repos.Warehouses.GetInventoryCount(countID)
+	if err != nil {
+		if err.Error() == "Conteo no encontrado" {
+			return count, 404, `{"error": "Conteo no encontrado"}`
+		}
+		return count, 500, fmt.Sprintf(`{"error": "Error al obtener el conteo: %s"}`, err.Error())
+	}
+	return count, 0, ""
+}
+
+// inventoryReconciliation concilia lo escaneado en el conteo contra lo
+// esperado hoy en la bodega
+func inventoryReconciliation(count models.InventoryCount) (models.InventoryReconciliation, int, string) {
+	var reconciliation models.InventoryReconciliation
+
+	expected, err := repos.Warehouses.GetExpectedInventory(count.WarehouseID)
+	if err != nil {
+		return reconciliation, 500, fmt.Sprintf(`{"error": "Error al obtener el inventario esperado: %s"}`, err.Error())
+	}
+	scanned, err := repos.Warehouses.GetInventoryCountItems(count.CountID)
+	if err != nil {
+		return reconciliation, 500, fmt.Sprintf(`{"error": "Error al obtener el conteo: %s"}`, err.Error())
+	}
+
+	reconciliation = reconcileInventory(expected, scanned)
+
+	// Lo inesperado no viene en el inventario esperado: completar la guía
+	guides := map[int64]models.ShippingGuide{}
+	for i, line := range reconciliation.Lines {
+		if line.Result != models.InventoryUnexpected {
+			continue
+		}
+		guide, ok := guides[line.GuideID]
+		if !ok {
+			guide, _ = repos.Guides.GetGuideByID(line.GuideID)
+			guides[line.GuideID] = guide
+		}
+		reconciliation.Lines[i].GuideNumber = guide.GuideNumber
+		reconciliation.Lines[i].CurrentStatus = guide.CurrentStatus
+	}
+
+	return reconciliation, 0, ""
+}
+
+// reconcileInventory compara cada pieza esperada con el último bin donde se
+// escaneó (si se escaneó en varios, cuenta el último)
+func reconcileInventory(expected []models.StockItem, scanned []models.InventoryCountItem) models.InventoryReconciliation {
+	last := map[scanKey]models.InventoryCountItem{}
+	var order []scanKey
+	for _, item := range scanned {
+		key := scanKey{guideID: item.GuideID, piece: item.PieceNumber}
+		if _, ok := last[key]; !ok {
+			order = append(order, key)
+		}
+		last[key] = item
+	}
+
+	r := models.InventoryReconciliation{
+		Expected: len(expected),
+		Scanned:  len(last),
+		Lines:    []models.InventoryLine{},
+	}
+
+	isExpected := map[scanKey]bool{}
+	for _, e := range expected {
+		key := scanKey{guideID: e.GuideID, piece: e.PieceNumber}
+		isExpected[key] = true
+
+		line := models.InventoryLine{
+			GuideID:         e.GuideID,
+			GuideNumber:     e.GuideNumber,
+			PieceNumber:     e.PieceNumber,
+			CurrentStatus:   e.CurrentStatus,
+			ExpectedBinCode: e.BinCode,
+		}
+
+		found, ok := last[key]
+		switch {
+		case !ok:
+			line.Result = models.InventoryMissing
+			r.Missing++
+		case e.BinID == 0:
+			line.Result = models.InventoryUnlocated
+			r.Unlocated++
+		case e.BinID == found.BinID:
+			line.Result = models.InventoryMatched
+			r.Matched++
+		default:
+			line.Result = models.InventoryMisplaced
+			r.Misplaced++
+		}
+		if ok {
+			line.ScannedBinID = found.BinID
+			line.ScannedBinCode = found.BinCode
+		}
+		r.Lines = append(r.Lines, line)
+	}
+
+	for _, key := range order {
+		if isExpected[key] {
+			continue
+		}
+		found := last[key]
+		r.Lines = append(r.Lines, models.InventoryLine{
+			GuideID:        found.GuideID,
+			PieceNumber:    found.PieceNumber,
+			Result:         models.InventoryUnexpected,
+			ScannedBinID:   found.BinID,
+			ScannedBinCode: found.BinCode,
+		})
+		r.Unexpected++
+	}
+
+	return r
+}
@@ -0,0 +1,87 @@
+package routers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/labels"
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+)
+
+// GetGuideLabel genera el rótulo térmico de 10x15 cm de la guía, en ZPL
+// (impresoras Zebra) o PDF, con un rótulo por pieza
+func GetGuideLabel(guideID int64, format string) (int, string) {
+	fmt.Printf("GetGuideLabel -> GuideID: %d, Format: %s\n", guideID, format)
+
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format == "" {
+		format = "zpl"
+	}
+	if format != "zpl" && format != "pdf" {
+		return 400, `{"error": "format debe ser zpl o pdf"}`
+	}
+
+	guide, err := repos.Guides.GetGuideByID(guideID)
+	if err != nil {
+		if err.Error() == "Guía no encontrada" {
+			return 404, `{"error": "Guía no encontrada"}`
+		}
+		return 500, fmt.Sprintf(`{"error": "Error al obtener la guía: %s"}`, err.Error())
+	}
+
+	label := labels.Label{Guide: guide}
+	if rate, ok := guideRate(guide); ok {
+		label.Route = rate.Route
+		label.TravelFrequency = rate.TravelFrequency
+	}
+	label.Guide.CreatedAt = guide.CreatedAt.In(guideColombiaLoc)
+
+	var data []byte
+	contentType := "application/zpl"
+	if format == "pdf" {
+		data, err = labels.RenderPDF(label)
+		contentType = "application/pdf"
+	} else {
+		data, err = labels.RenderZPL(label)
+	}
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al generar el rótulo: %s"}`, err.Error())
+	}
+
+	response := models.LabelResponse{
+		GuideID:     guideID,
+		GuideNumber: guide.GuideNumber,
+		Format:      format,
+		Pieces:      label.Pieces(),
+		FileName:    fmt.Sprintf("rotulo-%s.%s", label.BarcodeValue(), format),
+		ContentType: contentType,
+		Content:     base64.StdEncoding.EncodeToString(data),
+	}
+
+	jsonResponse, err := json.Marshal(response)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al serializar respuesta: %s"}`, err.Error())
+	}
+
+	return 200, string(jsonResponse)
+}
+
+// guideRate tarifa con la que se cotizó la guía; en guías sin rate_id, la
+// vigente para la ruta en la fecha de creación. La ruta es informativa en el
+// rótulo: si no se encuentra, el rótulo sale sin ella.
+func guideRate(guide models.ShippingGuide) (models.ShippingRate, bool) {
+	var rate models.ShippingRate
+	var err error
+	if guide.RateID > 0 {
+		rate, err = repos.Rates.GetRateByID(guide.RateID)
+	} else {
+		rate, err = repos.Rates.GetShippingRate(guide.OriginCityID, guide.DestinationCityID, guide.CreatedAt)
+	}
+	if err != nil {
+		fmt.Printf("Warning: sin tarifa para el rótulo de la guía %d: %s\n", guide.GuideID, err.Error())
+		return rate, false
+	}
+	return rate, true
+}
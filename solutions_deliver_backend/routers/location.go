@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"strconv"
 
-	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/bd"
 	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
 )
 
@@ -13,7 +12,7 @@ import (
 func GetDepartments() (int, string) {
 	fmt.Println("GetDepartments")
 
-	departments, err := bd.GetAllDepartments()
+	departments, err := repos.Locations.GetAllDepartments()
 	if err != nil {
 		return 500, fmt.Sprintf(`{"error": "Error al obtener los departamentos: %s"}`, err.Error())
 	}
@@ -47,14 +46,14 @@ func GetCities(deparmentIDStr string) (int, string) {
 		}
 
 		// Verificar que el departamento existe
-		if !bd.DepartmentExists(departmentID) {
+		if !repos.Locations.DepartmentExists(departmentID) {
 			return 404, fmt.Sprintf(`{"error": "Departamento no encontrado"}`)
 		}
 
-		cities, err = bd.GetCitiesByDepartment(departmentID)
+		cities, err = repos.Locations.GetCitiesByDepartment(departmentID)
 		filteredByDept = &departmentID
 	} else {
-		cities, err = bd.GetAllCities()
+		cities, err = repos.Locations.GetAllCities()
 	}
 
 	if err != nil {
@@ -79,7 +78,7 @@ func GetCities(deparmentIDStr string) (int, string) {
 func GetCityByID(cityID int64) (int, string) {
 	fmt.Printf("GetCityByID -> CityID: %d\n", cityID)
 
-	city, err := bd.GetCityByID(cityID)
+	city, err := repos.Locations.GetCityByID(cityID)
 	if err != nil {
 		if err.Error() == "Ciudad no encontrada" {
 			return 404, fmt.Sprintf(`{"error": "Ciudad no encontrada"}`)
@@ -108,7 +107,7 @@ func SearchCities(searchTerm string) (int, string) {
 		return 400, `{"error": "El término de búsqueda debe tener al menos 2 caracteres"}`
 	}
 
-	cities, err := bd.SearchCities(searchTerm)
+	cities, err := repos.Locations.SearchCities(searchTerm)
 	if err != nil {
 		return 500, fmt.Sprintf(`{"error": "Error al buscar ciudades: %s"}`, err.Error())
 	}
@@ -0,0 +1,429 @@
+package routers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/manifests"
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Manifiestos de despacho. Solo se cargan guías en bodega de la misma ruta
+// del manifiesto (la ruta de su tarifa) que no estén en otro manifiesto
+// activo. Los totales se calculan siempre de las guías cargadas.
+
+// CreateManifest crea un manifiesto abierto para una ruta, fecha, vehículo
+// y conductor
+func CreateManifest(body string, userUUID string) (int, string) {
+	fmt.Printf("CreateManifest -> UserUUID: %s\n", userUUID)
+
+	var request models.CreateManifestRequest
+	if err := json.Unmarshal([]byte(body), &request); err != nil {
+		return 400, fmt.Sprintf(`{"error": "Body inválido: %s"}`, err.Error())
+	}
+
+	manifest := models.Manifest{
+		VehiclePlate:   strings.ToUpper(strings.Join(strings.Fields(request.VehiclePlate), "")),
+		DriverName:     strings.TrimSpace(request.DriverName),
+		DriverDocument: strings.TrimSpace(request.DriverDocument),
+		DriverPhone:    strings.TrimSpace(request.DriverPhone),
+		Notes:          strings.TrimSpace(request.Notes),
+		CreatedBy:      userUUID,
+	}
+	if strings.TrimSpace(request.Route) == "" {
+		return 400, `{"error": "route es requerido"}`
+	}
+	if manifest.VehiclePlate == "" {
+		return 400, `{"error": "vehicle_plate es requerido"}`
+	}
+	if manifest.DriverName == "" || manifest.DriverDocument == "" {
+		return 400, `{"error": "driver_name y driver_document son requeridos"}`
+	}
+
+	manifest.DispatchDate = rateToday()
+	if request.DispatchDate != "" {
+		date, err := time.ParseInLocation("2006-01-02", request.DispatchDate, guideColombiaLoc)
+		if err != nil {
+			return 400, `{"error": "dispatch_date debe tener el formato YYYY-MM-DD"}`
+		}
+		manifest.DispatchDate = date
+	}
+
+	// La ruta debe existir en las tarifas vigentes; se guarda como está en la tarifa
+	rates, err := repos.Rates.GetCurrentRates(rateToday())
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al obtener las tarifas: %s"}`, err.Error())
+	}
+	for _, rate := range rates {
+		if strings.EqualFold(rate.Route, strings.TrimSpace(request.Route)) {
+			manifest.Route = rate.Route
+			break
+		}
+	}
+	if manifest.Route == "" {
+		return 400, `{"error": "La ruta no existe en las tarifas vigentes"}`
+	}
+
+	if err := repos.Manifests.CreateManifest(&manifest); err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al crear el manifiesto: %s"}`, err.Error())
+	}
+
+	return jsonResult(201, map[string]interface{}{
+		"success":  true,
+		"manifest": manifest,
+		"message":  "Manifiesto creado correctamente",
+	})
+}
+
+// GetManifests lista los manifiestos, con filtros por estado, ruta y fecha
+func GetManifests(request events.APIGatewayV2HTTPRequest) (int, string) {
+	fmt.Println("GetManifests")
+
+	var filters models.ManifestFilters
+	if status := request.QueryStringParameters["status"]; status != "" {
+		filters.Status = models.ManifestStatus(strings.ToUpper(status))
+		switch filters.Status {
+		case models.ManifestOpen, models.ManifestClosed, models.ManifestReceived:
+		default:
+			return 400, `{"error": "status debe ser OPEN, CLOSED o RECEIVED"}`
+		}
+	}
+	filters.Route = strings.TrimSpace(request.QueryStringParameters["route"])
+	if dateStr := request.QueryStringParameters["date"]; dateStr != "" {
+		date, err := time.ParseInLocation("2006-01-02", dateStr, guideColombiaLoc)
+		if err != nil {
+			return 400, `{"error": "date debe tener el formato YYYY-MM-DD"}`
+		}
+		filters.DispatchDate = &date
+	}
+
+	list, err := repos.Manifests.GetManifests(filters)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al obtener manifiestos: %s"}`, err.Error())
+	}
+	if list == nil {
+		list = []models.Manifest{}
+	}
+
+	return jsonResult(200, map[string]interface{}{
+		"manifests": list,
+		"total":     len(list),
+	})
+}
+
+// GetManifest obtiene el manifiesto con sus guías y totales
+func GetManifest(manifestID int64) (int, string) {
+	fmt.Printf("GetManifest -> ManifestID: %d\n", manifestID)
+
+	manifest, status, message := findManifest(manifestID)
+	if status != 0 {
+		return status, message
+	}
+	return jsonResult(200, manifest)
+}
+
+// GetPendingDispatchGuides guías en bodega pendientes de despacho
+// intermunicipal, opcionalmente de una ruta
+func GetPendingDispatchGuides(request events.APIGatewayV2HTTPRequest) (int, string) {
+	fmt.Println("GetPendingDispatchGuides")
+
+	route := strings.TrimSpace(request.QueryStringParameters["route"])
+	guides, err := repos.Manifests.GetPendingDispatchGuides(route)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al obtener guías pendientes: %s"}`, err.Error())
+	}
+	if guides == nil {
+		guides = []models.ManifestGuide{}
+	}
+
+	return jsonResult(200, map[string]interface{}{
+		"guides": guides,
+		"total":  len(guides),
+	})
+}
+
+// AddManifestGuides carga guías al manifiesto abierto. Cada código se
+// valida por separado; las válidas se cargan juntas.
+func AddManifestGuides(manifestID int64, body string, userUUID string) (int, string) {
+	fmt.Printf("AddManifestGuides -> ManifestID: %d, UserUUID: %s\n", manifestID, userUUID)
+
+	var request models.ManifestCodesRequest
+	if err := json.Unmarshal([]byte(body), &request); err != nil {
+		return 400, fmt.Sprintf(`{"error": "Body inválido: %s"}`, err.Error())
+	}
+	if status, message := validateWarehouseCodes(request.Codes); status != 0 {
+		return status, message
+	}
+
+	manifest, status, message := findManifest(manifestID)
+	if status != 0 {
+		return status, message
+	}
+	if manifest.Status != models.ManifestOpen {
+		return 409, `{"error": "El manifiesto no está abierto"}`
+	}
+
+	response := models.ManifestGuidesResponse{Items: make([]models.ManifestCodeResult, 0, len(request.Codes))}
+	var lines []models.ManifestGuide
+	var accepted []int // posición en Items de cada línea
+	seen := map[int64]bool{}
+	for _, g := range manifest.Guides {
+		seen[g.GuideID] = true
+	}
+
+	for _, raw := range request.Codes {
+		code := strings.TrimSpace(raw)
+		item := models.ManifestCodeResult{Code: code}
+
+		guide, _, message := resolveItemCode(code)
+		if message != "" {
+			item.Error = message
+			response.Items = append(response.Items, item)
+			continue
+		}
+		item.GuideID = guide.GuideID
+		item.GuideNumber = guide.GuideNumber
+
+		if message := manifestGuideError(manifest, guide, seen); message != "" {
+			item.Error = message
+			response.Items = append(response.Items, item)
+			continue
+		}
+
+		seen[guide.GuideID] = true
+		lines = append(lines, models.NewManifestGuide(guide, manifest.Route))
+		accepted = append(accepted, len(response.Items))
+		item.Success = true
+		response.Items = append(response.Items, item)
+	}
+
+	if len(lines) > 0 {
+		if err := repos.Manifests.AddManifestGuides(manifestID, lines, userUUID); err != nil {
+			switch err.Error() {
+			case "el manifiesto no está abierto":
+				return 409, `{"error": "El manifiesto no está abierto"}`
+			case "la guía ya está en un manifiesto":
+				return 409, `{"error": "Una de las guías ya está en otro manifiesto"}`
+			}
+			for _, i := range accepted {
+				response.Items[i].Success = false
+				response.Items[i].Error = fmt.Sprintf("Error al cargar: %s", err.Error())
+			}
+		}
+	}
+
+	for _, item := range response.Items {
+		if item.Success {
+			response.Succeeded++
+		} else {
+			response.Failed++
+		}
+	}
+	response.Total = len(response.Items)
+
+	response.Manifest, status, message = findManifest(manifestID)
+	if status != 0 {
+		return status, message
+	}
+	return jsonResult(200, response)
+}
+
+// manifestGuideError valida que la guía se pueda cargar al manifiesto
+func manifestGuideError(manifest models.Manifest, guide models.ShippingGuide, seen map[int64]bool) string {
+	if seen[guide.GuideID] {
+		return "La guía ya está en este manifiesto"
+	}
+	if guide.CurrentStatus != models.StatusInWarehouse {
+		return fmt.Sprintf("La guía no está en bodega (%s)", guide.CurrentStatus)
+	}
+
+	rate, ok := guideRate(guide)
+	if !ok {
+		return "La guía no tiene tarifa para determinar su ruta"
+	}
+	if !strings.EqualFold(rate.Route, manifest.Route) {
+		return fmt.Sprintf("La guía es de la ruta %s", rate.Route)
+	}
+
+	active, err := repos.Manifests.GetActiveManifestByGuide(guide.GuideID)
+	if err != nil {
+		return fmt.Sprintf("Error al validar la guía: %s", err.Error())
+	}
+	if active != 0 {
+		return fmt.Sprintf("La guía ya está en el manifiesto %s", manifests.Number(active))
+	}
+
+	transfer, ok, err := activeTransfer(guide.GuideID)
+	if err != nil {
+		return fmt.Sprintf("Error al validar la guía: %s", err.Error())
+	}
+	if ok {
+		return fmt.Sprintf("La guía tiene el traslado %d activo", transfer.AssignmentID)
+	}
+	return ""
+}
+
+// CloseManifest despacha el manifiesto: todas sus guías pasan a IN_ROUTE
+// en una sola transacción (con su historial) y salen de la bodega
+func CloseManifest(manifestID int64, userUUID string) (int, string) {
+	fmt.Printf("CloseManifest -> ManifestID: %d, UserUUID: %s\n", manifestID, userUUID)
+
+	err := repos.Manifests.CloseManifest(manifestID, userUUID)
+	if err != nil {
+		switch {
+		case err.Error() == "Manifiesto no encontrado":
+			return 404, `{"error": "Manifiesto no encontrado"}`
+		case err.Error() == "el manifiesto no está abierto":
+			return 409, `{"error": "El manifiesto no está abierto"}`
+		case err.Error() == "el manifiesto no tiene guías":
+			return 409, `{"error": "El manifiesto no tiene guías"}`
+		case strings.HasPrefix(err.Error(), "la guía"):
+			return 409, fmt.Sprintf(`{"error": %q}`, "No se pudo despachar: "+err.Error())
+		}
+		return 500, fmt.Sprintf(`{"error": "Error al cerrar el manifiesto: %s"}`, err.Error())
+	}
+
+	manifest, status, message := findManifest(manifestID)
+	if status != 0 {
+		return status, message
+	}
+	return jsonResult(200, map[string]interface{}{
+		"success":  true,
+		"manifest": manifest,
+		"message":  fmt.Sprintf("Manifiesto despachado con %d guías", manifest.Totals.Guides),
+	})
+}
+
+// ReceiveManifest recibe en destino el manifiesto en ruta con los códigos
+// escaneados al descargar. Las guías con todas sus piezas quedan RECEIVED,
+// con algunas PARTIAL y sin ninguna MISSING (siguen IN_ROUTE); las
+// recibidas, total o parcialmente, pasan a IN_WAREHOUSE.
+func ReceiveManifest(manifestID int64, body string, userUUID string) (int, string) {
+	fmt.Printf("ReceiveManifest -> ManifestID: %d, UserUUID: %s\n", manifestID, userUUID)
+
+	var request models.ReceiveManifestRequest
+	if err := json.Unmarshal([]byte(body), &request); err != nil {
+		return 400, fmt.Sprintf(`{"error": "Body inválido: %s"}`, err.Error())
+	}
+	if maxBatch := scanMaxBatch(); len(request.Codes) > maxBatch {
+		return 400, fmt.Sprintf(`{"error": "Se permiten máximo %d códigos por lote"}`, maxBatch)
+	}
+
+	manifest, status, message := findManifest(manifestID)
+	if status != 0 {
+		return status, message
+	}
+	if manifest.Status != models.ManifestClosed {
+		return 409, `{"error": "El manifiesto no está en ruta"}`
+	}
+
+	response := models.ManifestReceiveResponse{Unexpected: []string{}, Invalid: []string{}}
+	onManifest := map[int64]bool{}
+	for _, g := range manifest.Guides {
+		onManifest[g.GuideID] = true
+	}
+
+	// Piezas escaneadas por guía; la pieza 0 es la guía sin detalle de piezas
+	scanned := map[int64]map[int]bool{}
+	for _, raw := range request.Codes {
+		code := strings.TrimSpace(raw)
+		if code == "" {
+			continue
+		}
+		guide, pieces, message := resolveItemCode(code)
+		if message != "" {
+			response.Invalid = append(response.Invalid, code)
+			continue
+		}
+		if !onManifest[guide.GuideID] {
+			response.Unexpected = append(response.Unexpected, code)
+			continue
+		}
+		if scanned[guide.GuideID] == nil {
+			scanned[guide.GuideID] = map[int]bool{}
+		}
+		for _, piece := range pieces {
+			scanned[guide.GuideID][piece] = true
+		}
+	}
+
+	lines := make([]models.ManifestGuide, len(manifest.Guides))
+	for i, g := range manifest.Guides {
+		pieces := scanned[g.GuideID]
+		switch {
+		case pieces[0] || len(pieces) >= g.Pieces:
+			g.Status = models.ManifestGuideReceived
+			g.ReceivedPieces = g.Pieces
+			response.Received++
+		case len(pieces) > 0:
+			g.Status = models.ManifestGuidePartial
+			g.ReceivedPieces = len(pieces)
+			response.Partial++
+		default:
+			g.Status = models.ManifestGuideMissing
+			g.ReceivedPieces = 0
+			response.Missing++
+		}
+		lines[i] = g
+	}
+
+	err := repos.Manifests.ReceiveManifest(manifestID, lines, strings.TrimSpace(request.Notes), userUUID)
+	if err != nil {
+		switch {
+		case err.Error() == "el manifiesto no está en ruta":
+			return 409, `{"error": "El manifiesto no está en ruta"}`
+		case strings.HasPrefix(err.Error(), "la guía"):
+			return 409, fmt.Sprintf(`{"error": %q}`, "No se pudo recibir: "+err.Error())
+		}
+		return 500, fmt.Sprintf(`{"error": "Error al recibir el manifiesto: %s"}`, err.Error())
+	}
+
+	response.Manifest, status, message = findManifest(manifestID)
+	if status != 0 {
+		return status, message
+	}
+	return jsonResult(200, response)
+}
+
+// GetManifestPDF genera el manifiesto impreso (carta) en PDF
+func GetManifestPDF(manifestID int64) (int, string) {
+	fmt.Printf("GetManifestPDF -> ManifestID: %d\n", manifestID)
+
+	manifest, status, message := findManifest(manifestID)
+	if status != 0 {
+		return status, message
+	}
+	if len(manifest.Guides) == 0 {
+		return 409, `{"error": "El manifiesto no tiene guías"}`
+	}
+
+	data, err := manifests.RenderPDF(manifest, time.Now().In(guideColombiaLoc))
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al generar el manifiesto: %s"}`, err.Error())
+	}
+
+	return jsonResult(200, models.ManifestPDFResponse{
+		ManifestID:  manifestID,
+		FileName:    "manifiesto-" + manifests.Number(manifestID) + ".pdf",
+		ContentType: "application/pdf",
+		Content:     base64.StdEncoding.EncodeToString(data),
+	})
+}
+
+func findManifest(manifestID int64) (models.Manifest, int, string) {
+	manifest, err := repos.Manifests.GetManifestByID(manifestID)
+	if err != nil {
+		if err.Error() == "Manifiesto no encontrado" {
+			return manifest, 404, `{"error": "Manifiesto no encontrado"}`
+		}
+		return manifest, 500, fmt.Sprintf(`{"error": "Error al obtener el manifiesto: %s"}`, err.Error())
+	}
+	if manifest.Guides == nil {
+		manifest.Guides = []models.ManifestGuide{}
+	}
+	return manifest, 0, ""
+}
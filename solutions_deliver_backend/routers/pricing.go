@@ -0,0 +1,100 @@
+package routers
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+)
+
+// volumetricDivisor convierte cm³ a kg volumétrico (400 kg/m³, transporte terrestre)
+const volumetricDivisor = 2500.0
+
+// insuranceRate porcentaje del valor declarado que se cobra si el paquete va asegurado
+const insuranceRate = 0.01
+
+// resolveInsured regla única para cotizaciones y guías: si el cliente envía
+// insured se respeta; si no, el paquete va asegurado cuando tiene valor declarado
+func resolveInsured(insured *bool, declaredValue float64) bool {
+	if insured != nil {
+		return *insured
+	}
+	return declaredValue > 0
+}
+
+// serviceMultipliers recargo sobre el flete según el tipo de servicio
+var serviceMultipliers = map[models.ServiceType]float64{
+	models.ServiceNormal:   1.0,
+	models.ServicePriority: 1.5,
+	models.ServiceExpress:  2.0,
+}
+
+// calculatePrice calcula el precio con la tarifa de shipping_rates vigente en date:
+// flete = max(peso cobrable × precio/kg, valor mínimo) × multiplicador del servicio,
+// más el seguro si el paquete va asegurado. El peso cobrable es el mayor entre
+// el peso real, el volumétrico (dimensiones por pieza × piezas, o la suma de
+// cada pieza si la guía trae el detalle) y el mínimo de despacho de la tarifa.
+// Status 0 indica que el cálculo es válido.
+func calculatePrice(originCityID, destinationCityID int64, serviceType models.ServiceType, pkg models.Package, pieces []models.GuidePiece, declaredValue float64, date time.Time) (models.PriceBreakdown, int, string) {
+	var breakdown models.PriceBreakdown
+
+	multiplier, ok := serviceMultipliers[serviceType]
+	if !ok {
+		return breakdown, 400, `{"error": "service_type debe ser NORMAL, PRIORITY o EXPRESS"}`
+	}
+
+	rate, err := repos.Rates.GetShippingRate(originCityID, destinationCityID, date)
+	if err != nil {
+		if err.Error() == "no hay tarifa para la ruta" {
+			return breakdown, 422, fmt.Sprintf(`{"error": "No hay tarifa para la ruta %d -> %d"}`, originCityID, destinationCityID)
+		}
+		return breakdown, 500, fmt.Sprintf(`{"error": "Error al obtener tarifa: %s"}`, err.Error())
+	}
+
+	count := pkg.Pieces
+	if count <= 0 {
+		count = 1
+	}
+
+	volumetric := pkg.LengthCM * pkg.WidthCM * pkg.HeightCM / volumetricDivisor * float64(count)
+	if len(pieces) > 0 {
+		volumetric = 0
+		for _, p := range pieces {
+			volumetric += p.LengthCM * p.WidthCM * p.HeightCM / volumetricDivisor
+		}
+	}
+	billable := math.Max(math.Max(pkg.WeightKg, volumetric), float64(rate.MinDispatchKg))
+
+	freight := math.Max(billable*rate.PricePerKg, rate.MinValue) * multiplier
+
+	var insurance float64
+	if pkg.Insured {
+		insurance = declaredValue * insuranceRate
+	}
+
+	breakdown = models.PriceBreakdown{
+		RateID:             rate.ID,
+		Route:              rate.Route,
+		TravelFrequency:    rate.TravelFrequency,
+		EffectiveDate:      rate.EffectiveDate.Format("2006-01-02"),
+		ServiceType:        serviceType,
+		ActualWeightKg:     pkg.WeightKg,
+		VolumetricWeightKg: roundTo(volumetric, 2),
+		MinDispatchKg:      rate.MinDispatchKg,
+		BillableWeightKg:   roundTo(billable, 2),
+		PricePerKg:         rate.PricePerKg,
+		MinValue:           rate.MinValue,
+		Freight:            math.Round(freight),
+		ServiceMultiplier:  multiplier,
+		Insurance:          math.Round(insurance),
+	}
+	breakdown.Total = breakdown.Freight + breakdown.Insurance
+
+	return breakdown, 0, ""
+}
+
+func roundTo(value float64, decimals int) float64 {
+	factor := math.Pow(10, float64(decimals))
+	return math.Round(value*factor) / factor
+}
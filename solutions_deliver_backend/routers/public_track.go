@@ -0,0 +1,274 @@
+package routers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/utils"
+)
+
+// Rastreo público (sin cuenta). El número de guía es secuencial, así que
+// se exige un segundo factor y se limita la cantidad de consultas:
+//   PUBLIC_TRACK_IP_LIMIT     consultas por IP en la ventana (por defecto 30)
+//   PUBLIC_TRACK_GUIDE_LIMIT  consultas por número de guía en la ventana (por defecto 10)
+//   PUBLIC_TRACK_WINDOW       duración de la ventana (por defecto 15m)
+// La fecha estimada de entrega usa días hábiles según el servicio:
+//   TRANSIT_DAYS_NORMAL, TRANSIT_DAYS_PRIORITY, TRANSIT_DAYS_EXPRESS (por defecto 3, 2 y 1)
+
+const publicTrackVerifyDigits = 4
+
+func publicTrackIPLimit() int {
+	value, err := strconv.Atoi(os.Getenv("PUBLIC_TRACK_IP_LIMIT"))
+	if err != nil || value < 1 {
+		return 30
+	}
+	return value
+}
+
+func publicTrackGuideLimit() int {
+	value, err := strconv.Atoi(os.Getenv("PUBLIC_TRACK_GUIDE_LIMIT"))
+	if err != nil || value < 1 {
+		return 10
+	}
+	return value
+}
+
+func publicTrackWindow() time.Duration {
+	value, err := time.ParseDuration(os.Getenv("PUBLIC_TRACK_WINDOW"))
+	if err != nil || value <= 0 {
+		return 15 * time.Minute
+	}
+	return value
+}
+
+// transitDays días hábiles de tránsito del servicio
+func transitDays(service models.ServiceType) int {
+	defaults := map[models.ServiceType]int{
+		models.ServiceNormal:   3,
+		models.ServicePriority: 2,
+		models.ServiceExpress:  1,
+	}
+
+	value, err := strconv.Atoi(os.Getenv("TRANSIT_DAYS_" + string(service)))
+	if err != nil || value < 0 {
+		if days, ok := defaults[service]; ok {
+			return days
+		}
+		return defaults[models.ServiceNormal]
+	}
+	return value
+}
+
+// PublicTrackGuide rastreo de una guía sin autenticación. verify son los
+// últimos 4 dígitos del teléfono o documento del remitente o destinatario.
+// Guía inexistente y verificación incorrecta responden igual (404) para no
+// revelar qué números de guía existen.
+func PublicTrackGuide(guideNumber string, verify string, sourceIP string) (int, string) {
+	fmt.Printf("PublicTrackGuide -> GuideNumber: %s, IP: %s\n", guideNumber, sourceIP)
+
+	// Solo el número de guía: el guide_id es secuencial y no tiene dígito de
+	// verificación. Formato y dígito Luhn sin consultar la base de datos.
+	if _, err := models.ParseGuideNumber(guideNumber); err != nil {
+		return 400, fmt.Sprintf(`{"error": "%s"}`, err.Error())
+	}
+
+	if len(verify) != publicTrackVerifyDigits || !isDigits(verify) {
+		return 400, `{"error": "verify debe tener los últimos 4 dígitos del teléfono o documento del remitente o destinatario"}`
+	}
+
+	// Primero la IP (enumeración de guías) y luego la guía (adivinar los dígitos)
+	if status, message := checkPublicTrackLimit("track:ip:"+sourceIP, publicTrackIPLimit()); status != 0 {
+		return status, message
+	}
+
+	notFound := `{"error": "Guía no encontrada o datos de verificación incorrectos"}`
+
+	guideID, status, message := ResolveGuideNumber(guideNumber)
+	if status == 404 {
+		return 404, notFound
+	}
+	if status != 0 {
+		return status, message
+	}
+
+	if status, message := checkPublicTrackLimit("track:guide:"+strconv.FormatInt(guideID, 10), publicTrackGuideLimit()); status != 0 {
+		return status, message
+	}
+
+	guide, err := repos.Guides.GetGuideByID(guideID)
+	if err != nil {
+		if err.Error() == "Guía no encontrada" {
+			return 404, notFound
+		}
+		return 500, fmt.Sprintf(`{"error": "Error al rastrear guía: %s"}`, err.Error())
+	}
+
+	if !verifyGuideParty(guide, verify) {
+		return 404, notFound
+	}
+
+	response := models.PublicTrackResponse{
+		GuideNumber:       guide.GuideNumber,
+		ServiceType:       guide.ServiceType,
+		Status:            guide.CurrentStatus,
+		StatusLabel:       guide.CurrentStatus.Label(),
+		StatusDescription: guide.CurrentStatus.ClientDescription(),
+		OriginCity:        guide.OriginCityName,
+		DestinationCity:   guide.DestinationCityName,
+		CreatedAt:         guide.CreatedAt,
+		Timeline:          []models.ClientTrackEvent{},
+	}
+	if guide.Sender != nil {
+		response.SenderName = maskName(guide.Sender.FullName)
+	}
+	if guide.Receiver != nil {
+		response.ReceiverName = maskName(guide.Receiver.FullName)
+	}
+
+	// Solo estado, motivo y fecha: las notas y el usuario son internos
+	for _, h := range guide.History {
+		event := models.ClientTrackEvent{
+			Status:      h.Status,
+			Label:       h.Status.Label(),
+			Description: h.Status.ClientDescription(),
+			Date:        h.UpdatedAt,
+		}
+		if h.ReasonCode != "" {
+			event.Reason = h.ReasonCode.Label()
+		}
+		response.Timeline = append(response.Timeline, event)
+
+		if h.Status == models.StatusDelivered {
+			deliveredAt := h.UpdatedAt
+			response.DeliveredAt = &deliveredAt
+		}
+	}
+
+	if eta, ok := estimatedDelivery(guide, time.Now().In(colombiaLoc)); ok {
+		response.EstimatedDelivery = eta.Format("2006-01-02")
+	}
+
+	jsonResponse, err := json.Marshal(response)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al serializar respuesta: %s"}`, err.Error())
+	}
+
+	return 200, string(jsonResponse)
+}
+
+// checkPublicTrackLimit suma la consulta al bucket y responde 429 si supera el límite
+func checkPublicTrackLimit(bucket string, limit int) (int, string) {
+	hit, err := repos.RateLimits.HitRateLimit(bucket, publicTrackWindow())
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al validar límite de consultas: %s"}`, err.Error())
+	}
+	if hit.Count <= limit {
+		return 0, ""
+	}
+
+	retryAfter := int(math.Ceil(time.Until(hit.ResetAt).Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	return 429, fmt.Sprintf(`{"error": "Demasiadas consultas. Intente más tarde", "retry_after_seconds": %d}`, retryAfter)
+}
+
+// verifyGuideParty compara los últimos dígitos contra el teléfono y el
+// documento del remitente y del destinatario
+func verifyGuideParty(guide models.ShippingGuide, verify string) bool {
+	matched := false
+	for _, party := range []*models.GuideParty{guide.Sender, guide.Receiver} {
+		if party == nil {
+			continue
+		}
+		for _, value := range []string{party.Phone, party.DocumentNumber} {
+			digits := onlyDigits(value)
+			if len(digits) < publicTrackVerifyDigits {
+				continue
+			}
+			last := digits[len(digits)-publicTrackVerifyDigits:]
+			// Se revisan todos los valores para no filtrar cuál coincidió por tiempo
+			if subtle.ConstantTimeCompare([]byte(last), []byte(verify)) == 1 {
+				matched = true
+			}
+		}
+	}
+	return matched
+}
+
+// estimatedDelivery fecha estimada de entrega; no aplica a guías entregadas,
+// devueltas, canceladas o retenidas
+func estimatedDelivery(guide models.ShippingGuide, now time.Time) (time.Time, bool) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	switch guide.CurrentStatus {
+	case models.StatusDelivered, models.StatusReturnedToSender, models.StatusCancelled, models.StatusOnHold:
+		return time.Time{}, false
+	case models.StatusDeliveryFailed:
+		// El reintento se programa para el siguiente día hábil
+		failedAt := guide.UpdatedAt
+		if len(guide.History) > 0 {
+			failedAt = guide.History[len(guide.History)-1].UpdatedAt
+		}
+		eta := utils.NextBusinessDay(failedAt.In(now.Location()))
+		if eta.Before(today) {
+			eta = today
+		}
+		return nextBusinessDayFrom(eta), true
+	}
+
+	eta := utils.AddBusinessDays(guide.CreatedAt.In(now.Location()), transitDays(guide.ServiceType))
+	if eta.Before(today) {
+		// Va retrasada: lo más pronto posible
+		eta = today
+	}
+	return nextBusinessDayFrom(eta), true
+}
+
+// nextBusinessDayFrom retorna day si es hábil, o el siguiente día hábil
+func nextBusinessDayFrom(day time.Time) time.Time {
+	if utils.IsBusinessDay(day) {
+		return day
+	}
+	return utils.NextBusinessDay(day)
+}
+
+// maskName deja el primer nombre y las iniciales: "Ana María Pérez" -> "Ana M. P."
+func maskName(fullName string) string {
+	words := strings.Fields(fullName)
+	if len(words) == 0 {
+		return ""
+	}
+
+	first := []rune(strings.ToLower(words[0]))
+	first[0] = unicode.ToUpper(first[0])
+
+	masked := []string{string(first)}
+	for _, word := range words[1:] {
+		initial := []rune(word)[0]
+		masked = append(masked, string(unicode.ToUpper(initial))+".")
+	}
+	return strings.Join(masked, " ")
+}
+
+func onlyDigits(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func isDigits(value string) bool {
+	return value != "" && onlyDigits(value) == value
+}
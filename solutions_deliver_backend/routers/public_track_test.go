@@ -0,0 +1,47 @@
+package routers
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+)
+
+func TestPublicTrackGuideRef(t *testing.T) {
+	store := newTestStore(t)
+	number, err := models.NewGuideNumber("SD", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	guideID := store.AddGuide(models.ShippingGuide{
+		GuideNumber:   number,
+		CurrentStatus: models.StatusInWarehouse,
+		Receiver:      &models.GuideParty{FullName: "Ana Pérez", Phone: "3001234567"},
+	})
+
+	// Mismo consecutivo con otro dígito de verificación
+	badCheck := number[:len(number)-1] + strconv.Itoa((int(number[len(number)-1]-'0')+1)%10)
+
+	tests := []struct {
+		name   string
+		ref    string
+		verify string
+		status int
+	}{
+		{"número de guía", number, "4567", 200},
+		{"número en minúsculas con guiones", "sd-0000001-" + number[len(number)-1:], "4567", 200},
+		{"guide_id", strconv.FormatInt(guideID, 10), "4567", 400},
+		{"guide_id de 8 dígitos", "00000001", "4567", 400},
+		{"dígito de verificación incorrecto", badCheck, "4567", 400},
+		{"verificación incorrecta", number, "0000", 404},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := PublicTrackGuide(tt.ref, tt.verify, "198.51.100.7")
+			if status != tt.status {
+				t.Errorf("status = %d (%s), se esperaba %d", status, body, tt.status)
+			}
+		})
+	}
+}
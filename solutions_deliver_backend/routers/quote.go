@@ -0,0 +1,104 @@
+package routers
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+)
+
+// CreateQuote cotiza un envío con la tarifa vigente en la fecha solicitada.
+// Es una ruta pública: no crea nada ni depende del usuario.
+func CreateQuote(body string) (int, string) {
+	fmt.Println("CreateQuote")
+
+	var request models.QuoteRequest
+	if err := json.Unmarshal([]byte(body), &request); err != nil {
+		return 400, fmt.Sprintf(`{"error": "Body inválido: %s"}`, err.Error())
+	}
+
+	if request.ServiceType == "" {
+		request.ServiceType = models.ServiceNormal
+	}
+	if request.Pieces == 0 {
+		request.Pieces = 1
+	}
+
+	if message := validateQuoteRequest(request); message != "" {
+		return 400, fmt.Sprintf(`{"error": "%s"}`, message)
+	}
+
+	date := rateToday()
+	if request.Date != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", request.Date, guideColombiaLoc)
+		if err != nil {
+			return 400, `{"error": "date debe tener formato YYYY-MM-DD"}`
+		}
+		date = parsed
+	}
+
+	origin, err := repos.Locations.GetCityByID(request.OriginCityID)
+	if err != nil {
+		return 400, `{"error": "La ciudad de origen no existe"}`
+	}
+	destination, err := repos.Locations.GetCityByID(request.DestinationCityID)
+	if err != nil {
+		return 400, `{"error": "La ciudad de destino no existe"}`
+	}
+
+	pkg := models.Package{
+		WeightKg: request.WeightKg,
+		Pieces:   request.Pieces,
+		LengthCM: request.LengthCM,
+		WidthCM:  request.WidthCM,
+		HeightCM: request.HeightCM,
+		Insured:  resolveInsured(request.Insured, request.DeclaredValue),
+	}
+
+	quote, status, message := calculatePrice(request.OriginCityID, request.DestinationCityID, request.ServiceType, pkg, nil, request.DeclaredValue, date)
+	if status != 0 {
+		return status, message
+	}
+
+	response := models.QuoteResponse{
+		Success:         true,
+		OriginCity:      origin.Name,
+		DestinationCity: destination.Name,
+		Date:            date.Format("2006-01-02"),
+		Quote:           quote,
+	}
+
+	jsonResponse, err := json.Marshal(response)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al serializar respuesta: %s"}`, err.Error())
+	}
+
+	return 200, string(jsonResponse)
+}
+
+// validateQuoteRequest retorna el mensaje de error o vacío si la cotización es válida
+func validateQuoteRequest(request models.QuoteRequest) string {
+	if _, ok := serviceMultipliers[request.ServiceType]; !ok {
+		return "service_type debe ser NORMAL, PRIORITY o EXPRESS"
+	}
+	if request.OriginCityID <= 0 || request.DestinationCityID <= 0 {
+		return "origin_city_id y destination_city_id son requeridos"
+	}
+	if request.WeightKg <= 0 {
+		return "weight_kg debe ser mayor a 0"
+	}
+	if request.LengthCM <= 0 || request.WidthCM <= 0 || request.HeightCM <= 0 {
+		return "length_cm, width_cm y height_cm deben ser mayores a 0"
+	}
+	if request.Pieces < 1 {
+		return "pieces debe ser al menos 1"
+	}
+	if request.DeclaredValue < 0 {
+		return "declared_value no puede ser negativo"
+	}
+	if request.Insured != nil && *request.Insured && request.DeclaredValue <= 0 {
+		return "declared_value es requerido para paquetes asegurados"
+	}
+	return ""
+}
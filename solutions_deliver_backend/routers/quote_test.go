@@ -0,0 +1,71 @@
+package routers
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+)
+
+// La cotización y la guía cobran lo mismo para el mismo envío, también el seguro
+func TestQuoteMatchesGuidePrice(t *testing.T) {
+	tests := []struct {
+		name          string
+		insured       string // fragmento JSON del campo insured, vacío si no se envía
+		wantInsurance float64
+	}{
+		{"seguro por valor declarado", "", 2000},
+		{"asegurado explícito", `, "insured": true`, 2000},
+		{"sin seguro explícito", `, "insured": false`, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTestStore(t)
+			store.AddCity(models.City{ID: 1, Name: "Bogotá"})
+			store.AddCity(models.City{ID: 2, Name: "Medellín"})
+			store.AddRate(models.ShippingRate{
+				OriginCityID:      1,
+				DestinationCityID: 2,
+				MinDispatchKg:     1,
+				PricePerKg:        1500,
+				MinValue:          8000,
+				EffectiveDate:     time.Now().AddDate(0, 0, -1),
+			})
+
+			quoteBody := fmt.Sprintf(`{"origin_city_id": 1, "destination_city_id": 2, "weight_kg": 12,
+				"length_cm": 40, "width_cm": 30, "height_cm": 20, "declared_value": 200000%s}`, tt.insured)
+			status, response := CreateQuote(quoteBody)
+			if status != 200 {
+				t.Fatalf("cotización: status = %d (%s)", status, response)
+			}
+			var quote models.QuoteResponse
+			if err := json.Unmarshal([]byte(response), &quote); err != nil {
+				t.Fatal(err)
+			}
+
+			party := `"full_name": "Ana Pérez", "document_number": "123", "phone": "3001234567", "address": "Calle 1"`
+			guideBody := fmt.Sprintf(`{"origin_city_id": 1, "destination_city_id": 2, "declared_value": 200000,
+				"sender": {%s}, "receiver": {%s},
+				"package": {"weight_kg": 12, "length_cm": 40, "width_cm": 30, "height_cm": 20%s}}`, party, party, tt.insured)
+			status, response = CreateGuide(guideBody, "user-1")
+			if status != 201 {
+				t.Fatalf("guía: status = %d (%s)", status, response)
+			}
+			var guide models.CreateGuideResponse
+			if err := json.Unmarshal([]byte(response), &guide); err != nil {
+				t.Fatal(err)
+			}
+
+			if quote.Quote.Insurance != tt.wantInsurance || guide.Pricing.Insurance != tt.wantInsurance {
+				t.Errorf("seguro: cotización %.0f, guía %.0f, se esperaba %.0f",
+					quote.Quote.Insurance, guide.Pricing.Insurance, tt.wantInsurance)
+			}
+			if quote.Quote.Total != guide.Pricing.Total {
+				t.Errorf("total: cotización %.0f, guía %.0f", quote.Quote.Total, guide.Pricing.Total)
+			}
+		})
+	}
+}
@@ -0,0 +1,350 @@
+package routers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// GetRates lista las tarifas con filtros por origen, destino, ruta y estado
+func GetRates(request events.APIGatewayV2HTTPRequest) (int, string) {
+	fmt.Println("GetRates")
+
+	filters := models.RateFilters{
+		Limit:  50,
+		Offset: 0,
+	}
+
+	if request.QueryStringParameters != nil {
+		if originStr := request.QueryStringParameters["origin_city_id"]; originStr != "" {
+			originCityID, err := strconv.ParseInt(originStr, 10, 64)
+			if err != nil {
+				return 400, `{"error": "origin_city_id debe ser un número válido"}`
+			}
+			filters.OriginCityID = &originCityID
+		}
+
+		if destStr := request.QueryStringParameters["destination_city_id"]; destStr != "" {
+			destCityID, err := strconv.ParseInt(destStr, 10, 64)
+			if err != nil {
+				return 400, `{"error": "destination_city_id debe ser un número válido"}`
+			}
+			filters.DestinationCityID = &destCityID
+		}
+
+		filters.Route = strings.TrimSpace(request.QueryStringParameters["route"])
+
+		if statusStr := request.QueryStringParameters["status"]; statusStr != "" {
+			status := models.RateStatus(strings.ToUpper(statusStr))
+			if status != models.RateActive && status != models.RateRetired {
+				return 400, `{"error": "status debe ser ACTIVE o RETIRED"}`
+			}
+			filters.Status = status
+		}
+
+		if limitStr := request.QueryStringParameters["limit"]; limitStr != "" {
+			limit, err := strconv.Atoi(limitStr)
+			if err == nil && limit > 0 && limit <= 100 {
+				filters.Limit = limit
+			}
+		}
+
+		if offsetStr := request.QueryStringParameters["offset"]; offsetStr != "" {
+			offset, err := strconv.Atoi(offsetStr)
+			if err == nil && offset >= 0 {
+				filters.Offset = offset
+			}
+		}
+	}
+
+	rates, total, err := repos.Rates.GetRatesByFilters(filters)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al obtener tarifas: %s"}`, err.Error())
+	}
+
+	if rates == nil {
+		rates = []models.ShippingRate{}
+	}
+
+	response := models.RatesListResponse{
+		Rates:  rates,
+		Total:  total,
+		Limit:  filters.Limit,
+		Offset: filters.Offset,
+	}
+
+	jsonResponse, err := json.Marshal(response)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al serializar respuesta: %s"}`, err.Error())
+	}
+
+	return 200, string(jsonResponse)
+}
+
+// GetRateByID obtiene una tarifa por ID
+func GetRateByID(rateID int64) (int, string) {
+	fmt.Printf("GetRateByID -> RateID: %d\n", rateID)
+
+	rate, status, message := findRate(rateID)
+	if status != 0 {
+		return status, message
+	}
+
+	jsonResponse, err := json.Marshal(map[string]interface{}{"rate": rate})
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al serializar respuesta: %s"}`, err.Error())
+	}
+
+	return 200, string(jsonResponse)
+}
+
+// CreateRate crea una tarifa nueva o programa una nueva versión de una ruta
+// existente. La versión anterior sigue aplicando hasta la effective_date.
+func CreateRate(body string, userUUID string) (int, string) {
+	fmt.Printf("CreateRate -> UserUUID: %s\n", userUUID)
+
+	var request models.CreateRateRequest
+	if err := json.Unmarshal([]byte(body), &request); err != nil {
+		return 400, fmt.Sprintf(`{"error": "Body inválido: %s"}`, err.Error())
+	}
+
+	today := rateToday()
+	effectiveDate := today
+	if request.EffectiveDate != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", request.EffectiveDate, guideColombiaLoc)
+		if err != nil {
+			return 400, `{"error": "effective_date debe tener formato YYYY-MM-DD"}`
+		}
+		effectiveDate = parsed
+	}
+
+	rate := models.ShippingRate{
+		OriginCityID:      request.OriginCityID,
+		DestinationCityID: request.DestinationCityID,
+		Route:             strings.TrimSpace(request.Route),
+		TravelFrequency:   strings.TrimSpace(request.TravelFrequency),
+		MinDispatchKg:     request.MinDispatchKg,
+		PricePerKg:        request.PricePerKg,
+		MinValue:          request.MinValue,
+		EffectiveDate:     effectiveDate,
+	}
+
+	if rate.OriginCityID <= 0 || rate.DestinationCityID <= 0 {
+		return 400, `{"error": "origin_city_id y destination_city_id son requeridos"}`
+	}
+	if message := validateRate(rate, today); message != "" {
+		return 400, fmt.Sprintf(`{"error": "%s"}`, message)
+	}
+
+	if _, err := repos.Locations.GetCityByID(rate.OriginCityID); err != nil {
+		return 400, `{"error": "La ciudad de origen no existe"}`
+	}
+	if _, err := repos.Locations.GetCityByID(rate.DestinationCityID); err != nil {
+		return 400, `{"error": "La ciudad de destino no existe"}`
+	}
+
+	err := repos.Rates.CreateRate(&rate, userUUID)
+	if err != nil {
+		if err.Error() == "ya existe una tarifa para la ruta en esa fecha" {
+			return 409, `{"error": "Ya existe una tarifa activa para la ruta en esa fecha"}`
+		}
+		return 500, fmt.Sprintf(`{"error": "Error al crear la tarifa: %s"}`, err.Error())
+	}
+
+	return rateResponse(201, rate.ID, "Tarifa creada correctamente")
+}
+
+// UpdateRate actualiza una tarifa. Si ya está en vigor solo se pueden corregir
+// la ruta y la frecuencia; los cambios de precio se programan como una nueva
+// versión para no alterar las guías ya cotizadas con ella.
+func UpdateRate(rateID int64, body string, userUUID string) (int, string) {
+	fmt.Printf("UpdateRate -> RateID: %d, UserUUID: %s\n", rateID, userUUID)
+
+	var request models.UpdateRateRequest
+	if err := json.Unmarshal([]byte(body), &request); err != nil {
+		return 400, fmt.Sprintf(`{"error": "Body inválido: %s"}`, err.Error())
+	}
+
+	previous, status, message := findRate(rateID)
+	if status != 0 {
+		return status, message
+	}
+
+	if previous.Status != models.RateActive {
+		return 409, `{"error": "La tarifa está retirada y no se puede modificar"}`
+	}
+
+	today := rateToday()
+	changesPrice := request.MinDispatchKg != nil || request.PricePerKg != nil ||
+		request.MinValue != nil || request.EffectiveDate != nil
+	if changesPrice && previous.InForce(today) {
+		return 409, `{"error": "La tarifa ya está en vigor; programe una nueva versión con POST /admin/rates"}`
+	}
+
+	updated := previous
+	if request.Route != nil {
+		updated.Route = strings.TrimSpace(*request.Route)
+	}
+	if request.TravelFrequency != nil {
+		updated.TravelFrequency = strings.TrimSpace(*request.TravelFrequency)
+	}
+	if request.MinDispatchKg != nil {
+		updated.MinDispatchKg = *request.MinDispatchKg
+	}
+	if request.PricePerKg != nil {
+		updated.PricePerKg = *request.PricePerKg
+	}
+	if request.MinValue != nil {
+		updated.MinValue = *request.MinValue
+	}
+	if request.EffectiveDate != nil {
+		parsed, err := time.ParseInLocation("2006-01-02", *request.EffectiveDate, guideColombiaLoc)
+		if err != nil {
+			return 400, `{"error": "effective_date debe tener formato YYYY-MM-DD"}`
+		}
+		updated.EffectiveDate = parsed
+	}
+
+	// Una tarifa en vigor conserva su fecha; solo se valida la nueva
+	validationDate := today
+	if previous.InForce(today) {
+		validationDate = previous.EffectiveDate
+	}
+	if message := validateRate(updated, validationDate); message != "" {
+		return 400, fmt.Sprintf(`{"error": "%s"}`, message)
+	}
+
+	err := repos.Rates.UpdateRate(previous, updated, userUUID)
+	if err != nil {
+		if err.Error() == "ya existe una tarifa para la ruta en esa fecha" {
+			return 409, `{"error": "Ya existe una tarifa activa para la ruta en esa fecha"}`
+		}
+		return 500, fmt.Sprintf(`{"error": "Error al actualizar la tarifa: %s"}`, err.Error())
+	}
+
+	return rateResponse(200, rateID, "Tarifa actualizada correctamente")
+}
+
+// RetireRate retira una tarifa; las guías que la usan conservan la referencia
+func RetireRate(rateID int64, userUUID string) (int, string) {
+	fmt.Printf("RetireRate -> RateID: %d, UserUUID: %s\n", rateID, userUUID)
+
+	rate, status, message := findRate(rateID)
+	if status != 0 {
+		return status, message
+	}
+
+	if rate.Status != models.RateActive {
+		return 409, `{"error": "La tarifa ya está retirada"}`
+	}
+
+	err := repos.Rates.RetireRate(rateID, userUUID)
+	if err != nil {
+		if err.Error() == "la tarifa ya está retirada" {
+			return 409, `{"error": "La tarifa ya está retirada"}`
+		}
+		return 500, fmt.Sprintf(`{"error": "Error al retirar la tarifa: %s"}`, err.Error())
+	}
+
+	return rateResponse(200, rateID, "Tarifa retirada correctamente")
+}
+
+// GetRateHistory obtiene quién cambió qué valores de una tarifa
+func GetRateHistory(rateID int64) (int, string) {
+	fmt.Printf("GetRateHistory -> RateID: %d\n", rateID)
+
+	if _, status, message := findRate(rateID); status != 0 {
+		return status, message
+	}
+
+	history, err := repos.Rates.GetRateHistory(rateID)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al obtener historial: %s"}`, err.Error())
+	}
+
+	if history == nil {
+		history = []models.RateChange{}
+	}
+
+	response := models.RateHistoryResponse{
+		RateID:  rateID,
+		History: history,
+		Total:   len(history),
+	}
+
+	jsonResponse, err := json.Marshal(response)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al serializar respuesta: %s"}`, err.Error())
+	}
+
+	return 200, string(jsonResponse)
+}
+
+// findRate obtiene la tarifa o el error HTTP; status 0 indica que existe
+func findRate(rateID int64) (models.ShippingRate, int, string) {
+	rate, err := repos.Rates.GetRateByID(rateID)
+	if err != nil {
+		if err.Error() == "tarifa no encontrada" {
+			return rate, 404, `{"error": "Tarifa no encontrada"}`
+		}
+		return rate, 500, fmt.Sprintf(`{"error": "Error al obtener la tarifa: %s"}`, err.Error())
+	}
+	return rate, 0, ""
+}
+
+// validateRate retorna el mensaje de error o vacío si la tarifa es válida.
+// La effective_date no puede ser anterior a minDate para no recotizar el pasado.
+func validateRate(rate models.ShippingRate, minDate time.Time) string {
+	if rate.OriginCityID == rate.DestinationCityID {
+		return "origin_city_id y destination_city_id deben ser distintos"
+	}
+	if rate.Route == "" {
+		return "route es requerido"
+	}
+	if rate.TravelFrequency == "" {
+		return "travel_frequency es requerido"
+	}
+	if rate.MinDispatchKg < 0 {
+		return "min_dispatch_kg no puede ser negativo"
+	}
+	if rate.PricePerKg <= 0 {
+		return "price_per_kg debe ser mayor a 0"
+	}
+	if rate.MinValue < 0 {
+		return "min_value no puede ser negativo"
+	}
+	if rate.EffectiveDate.Before(minDate) {
+		return "effective_date no puede ser anterior a " + minDate.Format("2006-01-02")
+	}
+	return ""
+}
+
+// rateResponse relee la tarifa y arma la respuesta de las operaciones de escritura
+func rateResponse(status int, rateID int64, message string) (int, string) {
+	response := map[string]interface{}{
+		"success": true,
+		"message": message,
+	}
+	if rate, err := repos.Rates.GetRateByID(rateID); err == nil {
+		response["rate"] = rate
+	}
+
+	jsonResponse, err := json.Marshal(response)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al serializar respuesta: %s"}`, err.Error())
+	}
+
+	return status, string(jsonResponse)
+}
+
+// rateToday retorna el inicio del día actual en Colombia, la fecha con la
+// que se comparan las effective_date de las tarifas
+func rateToday() time.Time {
+	now := time.Now().In(guideColombiaLoc)
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, guideColombiaLoc)
+}
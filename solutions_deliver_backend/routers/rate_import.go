@@ -0,0 +1,593 @@
+package routers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/utils"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// maxRateImportRows límite de filas por archivo (el tarifario completo tiene ~1.100)
+const maxRateImportRows = 5000
+
+// Columnas del archivo de tarifas, en el orden en que se exportan.
+// Son las mismas del seed de shipping_rates más el departamento de cada
+// ciudad, que permite resolver nombres repetidos (p.ej. ALBANIA).
+const (
+	colOrigin                = "origin"
+	colOriginDepartment      = "origin_department"
+	colDestination           = "destination"
+	colDestinationDepartment = "destination_department"
+	colRoute                 = "route"
+	colTravelFrequency       = "travel_frequency"
+	colMinDispatchKg         = "min_dispatch_kg"
+	colPricePerKg            = "price_per_kg"
+	colMinValue              = "min_value"
+)
+
+var rateFileColumns = []string{
+	colOrigin, colOriginDepartment, colDestination, colDestinationDepartment,
+	colRoute, colTravelFrequency, colMinDispatchKg, colPricePerKg, colMinValue,
+}
+
+// rateColumnAliases encabezados aceptados (normalizados) para cada columna
+var rateColumnAliases = map[string][]string{
+	colOrigin:                {"origin", "origen", "ciudad_origen", "origin_city"},
+	colOriginDepartment:      {"origin_department", "departamento_origen"},
+	colDestination:           {"destination", "destino", "ciudad_destino", "destination_city"},
+	colDestinationDepartment: {"destination_department", "departamento_destino", "departamento"},
+	colRoute:                 {"route", "ruta"},
+	colTravelFrequency:       {"travel_frequency", "frecuencia", "frecuencia_de_viaje"},
+	colMinDispatchKg:         {"min_dispatch_kg", "kg_minimo", "min_kg", "despacho_minimo_kg"},
+	colPricePerKg:            {"price_per_kg", "precio_kg", "precio_por_kg", "valor_kg"},
+	colMinValue:              {"min_value", "valor_minimo", "minimo"},
+}
+
+var rateFileContentTypes = map[models.RateImportFormat]string{
+	models.RateFormatCSV:  "text/csv",
+	models.RateFormatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// ImportRates importa un tarifario en CSV o XLSX. Resuelve los nombres de
+// ciudades contra cities (sin tildes ni mayúsculas), compara cada fila con la
+// tarifa que estaría vigente en la effective_date y, si no es dry_run y no
+// hay errores, crea todas las versiones nuevas en una sola transacción.
+func ImportRates(body string, userUUID string) (int, string) {
+	fmt.Printf("ImportRates -> UserUUID: %s\n", userUUID)
+
+	var request models.RateImportRequest
+	if err := json.Unmarshal([]byte(body), &request); err != nil {
+		return 400, fmt.Sprintf(`{"error": "Body inválido: %s"}`, err.Error())
+	}
+
+	format, ok := rateFileFormat(request.Format, request.FileName)
+	if !ok {
+		return 400, `{"error": "format debe ser CSV o XLSX"}`
+	}
+
+	content := request.Content
+	if i := strings.Index(content, "base64,"); i >= 0 {
+		content = content[i+len("base64,"):]
+	}
+	data, err := base64.StdEncoding.DecodeString(content)
+	if err != nil || len(data) == 0 {
+		return 400, `{"error": "content debe ser el archivo codificado en base64"}`
+	}
+
+	today := rateToday()
+	effectiveDate := today
+	if request.EffectiveDate != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", request.EffectiveDate, guideColombiaLoc)
+		if err != nil {
+			return 400, `{"error": "effective_date debe tener formato YYYY-MM-DD"}`
+		}
+		effectiveDate = parsed
+	}
+	if effectiveDate.Before(today) {
+		return 400, fmt.Sprintf(`{"error": "effective_date no puede ser anterior a %s"}`, today.Format("2006-01-02"))
+	}
+
+	var sheet [][]string
+	if format == models.RateFormatXLSX {
+		sheet, err = utils.ReadXLSX(data)
+	} else {
+		sheet, err = utils.ReadCSV(data)
+	}
+	if err != nil {
+		return 400, fmt.Sprintf(`{"error": "%s"}`, err.Error())
+	}
+
+	sheet = dropEmptyRows(sheet)
+	if len(sheet) < 2 {
+		return 400, `{"error": "El archivo no tiene filas de tarifas"}`
+	}
+	if len(sheet)-1 > maxRateImportRows {
+		return 400, fmt.Sprintf(`{"error": "El archivo supera el máximo de %d filas"}`, maxRateImportRows)
+	}
+
+	columns, missing := mapRateColumns(sheet[0])
+	if request.OriginCityID != 0 && missing == colOrigin {
+		missing = ""
+	}
+	if missing != "" {
+		return 400, fmt.Sprintf(`{"error": "Falta la columna '%s'"}`, missing)
+	}
+
+	var defaultOrigin *models.City
+	if request.OriginCityID != 0 {
+		city, err := repos.Locations.GetCityByID(request.OriginCityID)
+		if err != nil {
+			return 400, `{"error": "La ciudad de origen no existe"}`
+		}
+		defaultOrigin = &city
+	}
+
+	cities, err := repos.Locations.GetAllCities()
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al obtener ciudades: %s"}`, err.Error())
+	}
+	index := newCityIndex(cities)
+
+	currentRates, err := repos.Rates.GetCurrentRates(effectiveDate)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al obtener tarifas vigentes: %s"}`, err.Error())
+	}
+	current := make(map[[2]int64]models.ShippingRate, len(currentRates))
+	for _, rate := range currentRates {
+		current[[2]int64{rate.OriginCityID, rate.DestinationCityID}] = rate
+	}
+
+	response := models.RateImportResponse{
+		DryRun:        request.DryRun,
+		EffectiveDate: effectiveDate.Format("2006-01-02"),
+	}
+	var toImport []models.ShippingRate
+	seen := map[[2]int64]int{}
+
+	for i, values := range sheet[1:] {
+		row, rate := parseRateRow(i+2, values, columns, defaultOrigin, index)
+		if row.Status == "" {
+			row.Status, rate.EffectiveDate = models.RateRowNew, effectiveDate
+			key := [2]int64{rate.OriginCityID, rate.DestinationCityID}
+
+			if line, dup := seen[key]; dup {
+				row.Status, row.Error = models.RateRowInvalid, fmt.Sprintf("ruta repetida en la línea %d", line)
+			} else if message := validateRate(rate, today); message != "" {
+				row.Status, row.Error = models.RateRowInvalid, message
+			} else if previous, ok := current[key]; ok {
+				compareWithCurrent(&row, rate, previous, effectiveDate)
+			}
+			if _, dup := seen[key]; !dup {
+				seen[key] = row.Line
+			}
+
+			if row.Status == models.RateRowNew || row.Status == models.RateRowChanged {
+				toImport = append(toImport, rate)
+			}
+		}
+
+		countRateRow(&response.Summary, row.Status)
+		response.Rows = append(response.Rows, row)
+	}
+	response.Summary.TotalRows = len(response.Rows)
+
+	summary := response.Summary
+	hasErrors := summary.Unmatched+summary.Ambiguous+summary.Invalid > 0
+
+	switch {
+	case request.DryRun:
+		response.Success = !hasErrors
+		response.Message = "Vista previa de la importación; no se guardó ningún cambio"
+		return rateImportResponse(200, response)
+	case hasErrors:
+		response.Message = "El archivo tiene filas con errores; corríjalas para importar"
+		return rateImportResponse(422, response)
+	case len(toImport) == 0:
+		response.Success = true
+		response.Message = "El archivo no tiene cambios frente a la tarifa vigente"
+		return rateImportResponse(200, response)
+	}
+
+	err = repos.Rates.ImportRates(toImport, userUUID)
+	if err != nil {
+		if err.Error() == "ya existe una tarifa para la ruta en esa fecha" {
+			return 409, `{"error": "Ya existe una tarifa activa para alguna ruta en esa fecha"}`
+		}
+		return 500, fmt.Sprintf(`{"error": "Error al importar tarifas: %s"}`, err.Error())
+	}
+
+	response.Success = true
+	response.Applied = true
+	response.Message = fmt.Sprintf("%d tarifas importadas con vigencia desde %s", len(toImport), response.EffectiveDate)
+	return rateImportResponse(201, response)
+}
+
+// ExportRates exporta la tarifa vigente (o la de ?date=YYYY-MM-DD) en el
+// mismo formato que acepta ImportRates
+func ExportRates(request events.APIGatewayV2HTTPRequest) (int, string) {
+	fmt.Println("ExportRates")
+
+	format := models.RateFormatCSV
+	date := rateToday()
+
+	if request.QueryStringParameters != nil {
+		if formatStr := request.QueryStringParameters["format"]; formatStr != "" {
+			parsed, ok := rateFileFormat(models.RateImportFormat(formatStr), "")
+			if !ok {
+				return 400, `{"error": "format debe ser csv o xlsx"}`
+			}
+			format = parsed
+		}
+
+		if dateStr := request.QueryStringParameters["date"]; dateStr != "" {
+			parsed, err := time.ParseInLocation("2006-01-02", dateStr, guideColombiaLoc)
+			if err != nil {
+				return 400, `{"error": "date debe tener formato YYYY-MM-DD"}`
+			}
+			date = parsed
+		}
+	}
+
+	rates, err := repos.Rates.GetCurrentRates(date)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al obtener tarifas: %s"}`, err.Error())
+	}
+
+	cities, err := repos.Locations.GetAllCities()
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al obtener ciudades: %s"}`, err.Error())
+	}
+	departments := make(map[int64]string, len(cities))
+	for _, city := range cities {
+		departments[city.ID] = city.DepartmentName
+	}
+
+	sheet := [][]string{rateFileColumns}
+	for _, rate := range rates {
+		sheet = append(sheet, []string{
+			rate.OriginCityName,
+			departments[rate.OriginCityID],
+			rate.DestinationCityName,
+			departments[rate.DestinationCityID],
+			rate.Route,
+			rate.TravelFrequency,
+			strconv.Itoa(rate.MinDispatchKg),
+			strconv.FormatFloat(rate.PricePerKg, 'f', -1, 64),
+			strconv.FormatFloat(rate.MinValue, 'f', -1, 64),
+		})
+	}
+
+	var data []byte
+	if format == models.RateFormatXLSX {
+		data, err = utils.WriteXLSX("Tarifas", sheet)
+	} else {
+		data, err = utils.WriteCSV(sheet)
+	}
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al generar el archivo: %s"}`, err.Error())
+	}
+
+	response := models.RateExportResponse{
+		FileName:    fmt.Sprintf("tarifas_%s.%s", date.Format("2006-01-02"), strings.ToLower(string(format))),
+		Format:      format,
+		ContentType: rateFileContentTypes[format],
+		Content:     base64.StdEncoding.EncodeToString(data),
+		Date:        date.Format("2006-01-02"),
+		Total:       len(rates),
+	}
+
+	jsonResponse, err := json.Marshal(response)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al serializar respuesta: %s"}`, err.Error())
+	}
+
+	return 200, string(jsonResponse)
+}
+
+// rateFileFormat valida el formato o lo deduce de la extensión del archivo
+func rateFileFormat(format models.RateImportFormat, fileName string) (models.RateImportFormat, bool) {
+	if format == "" {
+		lower := strings.ToLower(fileName)
+		switch {
+		case strings.HasSuffix(lower, ".xlsx"):
+			return models.RateFormatXLSX, true
+		case strings.HasSuffix(lower, ".csv"):
+			return models.RateFormatCSV, true
+		}
+		return "", false
+	}
+
+	format = models.RateImportFormat(strings.ToUpper(string(format)))
+	_, ok := rateFileContentTypes[format]
+	return format, ok
+}
+
+// mapRateColumns ubica cada columna por su encabezado. Retorna la primera
+// columna requerida que falta; las de departamento son opcionales.
+func mapRateColumns(header []string) (map[string]int, string) {
+	columns := map[string]int{}
+	for i, name := range header {
+		normalized := strings.ReplaceAll(strings.ToLower(normalizeName(name)), " ", "_")
+		for column, aliases := range rateColumnAliases {
+			for _, alias := range aliases {
+				if normalized == alias {
+					if _, exists := columns[column]; !exists {
+						columns[column] = i
+					}
+				}
+			}
+		}
+	}
+
+	for _, column := range rateFileColumns {
+		if column == colOriginDepartment || column == colDestinationDepartment {
+			continue
+		}
+		if _, ok := columns[column]; !ok {
+			return columns, column
+		}
+	}
+	return columns, ""
+}
+
+// parseRateRow convierte una fila del archivo en tarifa. Si la fila tiene
+// errores el Status queda asignado; vacío indica que se debe comparar.
+func parseRateRow(line int, values []string, columns map[string]int, defaultOrigin *models.City, index cityIndex) (models.RateImportRow, models.ShippingRate) {
+	cell := func(column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(values) {
+			return ""
+		}
+		return strings.TrimSpace(values[i])
+	}
+
+	row := models.RateImportRow{
+		Line:            line,
+		Origin:          cell(colOrigin),
+		Destination:     cell(colDestination),
+		Route:           cell(colRoute),
+		TravelFrequency: cell(colTravelFrequency),
+	}
+	var rate models.ShippingRate
+
+	invalid := func(message string) (models.RateImportRow, models.ShippingRate) {
+		row.Status, row.Error = models.RateRowInvalid, message
+		return row, rate
+	}
+
+	minKg, err := parseSheetNumber(cell(colMinDispatchKg))
+	if err != nil || minKg != math.Trunc(minKg) {
+		return invalid("min_dispatch_kg debe ser un número entero")
+	}
+	row.MinDispatchKg = int(minKg)
+
+	if row.PricePerKg, err = parseSheetNumber(cell(colPricePerKg)); err != nil {
+		return invalid("price_per_kg debe ser un número")
+	}
+	if row.MinValue, err = parseSheetNumber(cell(colMinValue)); err != nil {
+		return invalid("min_value debe ser un número")
+	}
+
+	var origin models.City
+	if row.Origin == "" && defaultOrigin != nil {
+		origin = *defaultOrigin
+		row.Origin = origin.Name
+	} else {
+		var candidates []models.City
+		origin, candidates = index.resolve(row.Origin, cell(colOriginDepartment))
+		if origin.ID == 0 {
+			return unresolvedCity(row, rate, "origen", row.Origin, candidates)
+		}
+	}
+	row.OriginCityID = origin.ID
+
+	destination, candidates := index.resolve(row.Destination, cell(colDestinationDepartment))
+	if destination.ID == 0 {
+		return unresolvedCity(row, rate, "destino", row.Destination, candidates)
+	}
+	row.DestinationCityID = destination.ID
+
+	rate = models.ShippingRate{
+		OriginCityID:        origin.ID,
+		OriginCityName:      origin.Name,
+		DestinationCityID:   destination.ID,
+		DestinationCityName: destination.Name,
+		Route:               row.Route,
+		TravelFrequency:     row.TravelFrequency,
+		MinDispatchKg:       row.MinDispatchKg,
+		PricePerKg:          row.PricePerKg,
+		MinValue:            row.MinValue,
+	}
+	return row, rate
+}
+
+func unresolvedCity(row models.RateImportRow, rate models.ShippingRate, role string, name string, candidates []models.City) (models.RateImportRow, models.ShippingRate) {
+	if name == "" {
+		row.Status, row.Error = models.RateRowInvalid, "la ciudad de "+role+" es requerida"
+		return row, rate
+	}
+	if len(candidates) == 0 {
+		row.Status, row.Error = models.RateRowUnmatched, fmt.Sprintf("ciudad de %s '%s' no encontrada", role, name)
+		return row, rate
+	}
+	row.Status = models.RateRowAmbiguous
+	row.Error = fmt.Sprintf("ciudad de %s '%s' existe en %d departamentos; indique el departamento", role, name, len(candidates))
+	row.Candidates = candidates
+	return row, rate
+}
+
+// compareWithCurrent marca la fila como CHANGED o UNCHANGED frente a la tarifa
+// vigente. Una versión ya programada para la misma fecha no se puede reemplazar.
+func compareWithCurrent(row *models.RateImportRow, rate models.ShippingRate, previous models.ShippingRate, effectiveDate time.Time) {
+	row.CurrentRateID = previous.ID
+	row.CurrentPricePerKg = &previous.PricePerKg
+	row.CurrentMinValue = &previous.MinValue
+	row.CurrentMinDispatchKg = &previous.MinDispatchKg
+	row.CurrentRoute = previous.Route
+	row.CurrentFrequency = previous.TravelFrequency
+
+	unchanged := previous.Route == rate.Route &&
+		previous.TravelFrequency == rate.TravelFrequency &&
+		previous.MinDispatchKg == rate.MinDispatchKg &&
+		previous.PricePerKg == rate.PricePerKg &&
+		previous.MinValue == rate.MinValue
+
+	switch {
+	case unchanged:
+		row.Status = models.RateRowUnchanged
+	case previous.EffectiveDate.Format("2006-01-02") == effectiveDate.Format("2006-01-02"):
+		row.Status, row.Error = models.RateRowInvalid, "ya existe una tarifa para la ruta en esa fecha"
+	default:
+		row.Status = models.RateRowChanged
+	}
+}
+
+func countRateRow(summary *models.RateImportSummary, status models.RateImportRowStatus) {
+	switch status {
+	case models.RateRowNew:
+		summary.New++
+	case models.RateRowChanged:
+		summary.Changed++
+	case models.RateRowUnchanged:
+		summary.Unchanged++
+	case models.RateRowUnmatched:
+		summary.Unmatched++
+	case models.RateRowAmbiguous:
+		summary.Ambiguous++
+	case models.RateRowInvalid:
+		summary.Invalid++
+	}
+}
+
+func rateImportResponse(status int, response models.RateImportResponse) (int, string) {
+	if response.Rows == nil {
+		response.Rows = []models.RateImportRow{}
+	}
+
+	jsonResponse, err := json.Marshal(response)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al serializar respuesta: %s"}`, err.Error())
+	}
+
+	return status, string(jsonResponse)
+}
+
+// dropEmptyRows descarta filas sin ningún valor (comunes al final de un XLSX)
+func dropEmptyRows(rows [][]string) [][]string {
+	result := rows[:0]
+	for _, row := range rows {
+		for _, value := range row {
+			if strings.TrimSpace(value) != "" {
+				result = append(result, row)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// parseSheetNumber interpreta números con o sin separadores de miles.
+// "1.300,50" y "1,300.50" son 1300.5: el último separador es el decimal.
+// Con un solo tipo de separador, grupos de 3 dígitos son miles ("1.300"
+// es 1300, como en las listas de precios en pesos) y si no es decimal ("1,5").
+func parseSheetNumber(value string) (float64, error) {
+	value = strings.NewReplacer("$", "", " ", "").Replace(value)
+	if value == "" {
+		return 0, fmt.Errorf("valor vacío")
+	}
+
+	lastDot, lastComma := strings.LastIndex(value, "."), strings.LastIndex(value, ",")
+	switch {
+	case lastDot >= 0 && lastComma >= 0:
+		decimal, thousands := ".", ","
+		if lastComma > lastDot {
+			decimal, thousands = ",", "."
+		}
+		value = strings.ReplaceAll(value, thousands, "")
+		value = strings.Replace(value, decimal, ".", 1)
+	case lastDot >= 0 || lastComma >= 0:
+		separator := "."
+		if lastComma >= 0 {
+			separator = ","
+		}
+		if isThousandsGrouped(value, separator) {
+			value = strings.ReplaceAll(value, separator, "")
+		} else {
+			value = strings.Replace(value, separator, ".", 1)
+		}
+	}
+
+	return strconv.ParseFloat(value, 64)
+}
+
+// isThousandsGrouped indica si todos los grupos después del separador tienen 3 dígitos
+func isThousandsGrouped(value string, separator string) bool {
+	groups := strings.Split(value, separator)
+	for _, group := range groups[1:] {
+		if len(group) != 3 {
+			return false
+		}
+	}
+	return len(groups[0]) > 0 && len(groups[0]) <= 3
+}
+
+// accentReplacer quita tildes y diéresis como lo hace la collation
+// utf8mb4_unicode_ci que usa bd.SearchCities
+var accentReplacer = strings.NewReplacer(
+	"Á", "A", "É", "E", "Í", "I", "Ó", "O", "Ú", "U", "Ü", "U", "Ñ", "N",
+	"á", "A", "é", "E", "í", "I", "ó", "O", "ú", "U", "ü", "U", "ñ", "N",
+)
+
+// normalizeName compara nombres sin tildes, mayúsculas ni espacios repetidos
+func normalizeName(name string) string {
+	return strings.Join(strings.Fields(accentReplacer.Replace(strings.ToUpper(name))), " ")
+}
+
+// cityIndex agrupa las ciudades por nombre normalizado
+type cityIndex map[string][]models.City
+
+func newCityIndex(cities []models.City) cityIndex {
+	index := cityIndex{}
+	for _, city := range cities {
+		key := normalizeName(city.Name)
+		index[key] = append(index[key], city)
+	}
+	return index
+}
+
+// resolve busca la ciudad por nombre. El departamento puede venir en su
+// propia columna o después de una coma ("ALBANIA, SANTANDER"). Si no hay una
+// única coincidencia retorna una ciudad vacía y los candidatos encontrados.
+func (index cityIndex) resolve(name string, department string) (models.City, []models.City) {
+	if department == "" {
+		if i := strings.LastIndex(name, ","); i >= 0 && len(index[normalizeName(name)]) == 0 {
+			name, department = name[:i], name[i+1:]
+		}
+	}
+
+	candidates := index[normalizeName(name)]
+	if department != "" && len(candidates) > 0 {
+		var filtered []models.City
+		for _, city := range candidates {
+			if normalizeName(city.DepartmentName) == normalizeName(department) {
+				filtered = append(filtered, city)
+			}
+		}
+		if len(filtered) == 0 {
+			return models.City{}, nil
+		}
+		candidates = filtered
+	}
+
+	if len(candidates) == 1 {
+		return candidates[0], nil
+	}
+	return models.City{}, candidates
+}
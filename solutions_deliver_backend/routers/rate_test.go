@@ -0,0 +1,45 @@
+package routers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// limit fuera de 1..100 se ignora y se usa el valor por defecto
+func TestGetRatesLimit(t *testing.T) {
+	tests := []struct {
+		limit string
+		want  int
+	}{
+		{"", 50},
+		{"20", 20},
+		{"100", 100},
+		{"101", 50},
+		{"100000", 50},
+		{"0", 50},
+		{"abc", 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.limit, func(t *testing.T) {
+			newTestStore(t)
+
+			request := events.APIGatewayV2HTTPRequest{QueryStringParameters: map[string]string{"limit": tt.limit}}
+			status, response := GetRates(request)
+			if status != 200 {
+				t.Fatalf("status = %d (%s)", status, response)
+			}
+
+			var list models.RatesListResponse
+			if err := json.Unmarshal([]byte(response), &list); err != nil {
+				t.Fatal(err)
+			}
+			if list.Limit != tt.want {
+				t.Errorf("limit = %d, se esperaba %d", list.Limit, tt.want)
+			}
+		})
+	}
+}
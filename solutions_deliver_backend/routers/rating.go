@@ -4,18 +4,14 @@ import (
 	"encoding/json"
 	"fmt"
 
-	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/bd"
 	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
 )
 
-// CreateDeliveryRating crea una calificación para una entrega completada (CLIENT)
-func CreateDeliveryRating(body string, userUUID string) (int, string) {
+// CreateDeliveryRating crea una calificación para una entrega completada (CLIENT).
+// assignmentID es la asignación de la ruta (0: la del body).
+func CreateDeliveryRating(body string, userUUID string, assignmentID int64) (int, string) {
 	fmt.Println("CreateDeliveryRating")
 
-	if !userIsAllowed(userUUID, models.RoleClient) {
-		return 403, `{"error": "No autorizado - Solo clientes pueden calificar entregas"}`
-	}
-
 	var req models.CreateRatingRequest
 	err := json.Unmarshal([]byte(body), &req)
 	if err != nil {
@@ -23,6 +19,14 @@ func CreateDeliveryRating(body string, userUUID string) (int, string) {
 	}
 
 	// Validaciones
+	if assignmentID != 0 {
+		if req.AssignmentID == 0 {
+			req.AssignmentID = assignmentID
+		}
+		if req.AssignmentID != assignmentID {
+			return 400, `{"error": "assignment_id no coincide con la asignación de la ruta"}`
+		}
+	}
 	if req.AssignmentID <= 0 {
 		return 400, `{"error": "assignment_id es requerido"}`
 	}
@@ -31,7 +35,7 @@ func CreateDeliveryRating(body string, userUUID string) (int, string) {
 		return 400, `{"error": "rating debe estar entre 1 y 5"}`
 	}
 
-	rating, err := bd.CreateDeliveryRating(req, userUUID)
+	rating, err := repos.Ratings.CreateDeliveryRating(req, userUUID)
 	if err != nil {
 		return 400, fmt.Sprintf(`{"error": "%s"}`, err.Error())
 	}
@@ -51,10 +55,10 @@ func CreateDeliveryRating(body string, userUUID string) (int, string) {
 }
 
 // GetAssignmentRating obtiene la calificación de una asignación
-func GetAssignmentRating(userUUID string, assignmentID int64) (int, string) {
+func GetAssignmentRating(assignmentID int64) (int, string) {
 	fmt.Printf("GetAssignmentRating -> AssignmentID: %d\n", assignmentID)
 
-	rating, err := bd.GetRatingByAssignmentID(assignmentID)
+	rating, err := repos.Ratings.GetRatingByAssignmentID(assignmentID)
 	if err != nil {
 		return 404, fmt.Sprintf(`{"error": "%s"}`, err.Error())
 	}
@@ -68,14 +72,10 @@ func GetAssignmentRating(userUUID string, assignmentID int64) (int, string) {
 }
 
 // GetDeliveryUserRatings obtiene las calificaciones de un repartidor (ADMIN, SECRETARY)
-func GetDeliveryUserRatings(userUUID string, deliveryUserID string) (int, string) {
+func GetDeliveryUserRatings(deliveryUserID string) (int, string) {
 	fmt.Printf("GetDeliveryUserRatings -> DeliveryUserID: %s\n", deliveryUserID)
 
-	if !userIsAllowed(userUUID, models.RoleAdmin, models.RoleSecretary) {
-		return 403, `{"error": "No autorizado - Rol no permitido"}`
-	}
-
-	ratings, total, avgRating, err := bd.GetDeliveryUserRatings(deliveryUserID, 50)
+	ratings, total, avgRating, err := repos.Ratings.GetDeliveryUserRatings(deliveryUserID, 50)
 	if err != nil {
 		return 500, fmt.Sprintf(`{"error": "%s"}`, err.Error())
 	}
@@ -102,11 +102,7 @@ func GetDeliveryUserRatings(userUUID string, deliveryUserID string) (int, string
 func GetMyPerformanceStats(userUUID string) (int, string) {
 	fmt.Printf("GetMyPerformanceStats -> UserID: %s\n", userUUID)
 
-	if !userIsAllowed(userUUID, models.RoleDelivery) {
-		return 403, `{"error": "No autorizado - Rol no permitido"}`
-	}
-
-	stats, err := bd.GetDeliveryPerformanceStats(userUUID)
+	stats, err := repos.Ratings.GetDeliveryPerformanceStats(userUUID)
 	if err != nil {
 		return 500, fmt.Sprintf(`{"error": "Error al obtener estadísticas: %s"}`, err.Error())
 	}
@@ -130,11 +126,7 @@ func GetMyPerformanceStats(userUUID string) (int, string) {
 func GetClientPendingRatings(userUUID string) (int, string) {
 	fmt.Printf("GetClientPendingRatings -> UserID: %s\n", userUUID)
 
-	if !userIsAllowed(userUUID, models.RoleClient) {
-		return 403, `{"error": "No autorizado - Rol no permitido"}`
-	}
-
-	pending, err := bd.GetClientPendingRatings(userUUID)
+	pending, err := repos.Ratings.GetClientPendingRatings(userUUID)
 	if err != nil {
 		return 500, fmt.Sprintf(`{"error": "Error al obtener entregas pendientes: %s"}`, err.Error())
 	}
@@ -0,0 +1,59 @@
+package routers
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+)
+
+func TestCreateDeliveryRatingAssignmentID(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		pathID   bool
+		status   int
+		formatID bool
+	}{
+		{"id de la ruta", `{"rating": 5}`, true, 201, false},
+		{"body coincide con la ruta", `{"assignment_id": %d, "rating": 5}`, true, 201, true},
+		{"body no coincide con la ruta", `{"assignment_id": 999, "rating": 5}`, true, 400, false},
+		{"id del body", `{"assignment_id": %d, "rating": 4}`, false, 201, true},
+		{"sin id", `{"rating": 4}`, false, 400, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTestStore(t)
+			assignmentID := store.AddAssignment(models.DeliveryAssignment{
+				GuideID:        1,
+				DeliveryUserID: "delivery-1",
+				Status:         models.AssignmentCompleted,
+			})
+
+			body := tt.body
+			if tt.formatID {
+				body = fmt.Sprintf(tt.body, assignmentID)
+			}
+			var pathID int64
+			if tt.pathID {
+				pathID = assignmentID
+			}
+
+			status, response := CreateDeliveryRating(body, "client-1", pathID)
+			if status != tt.status {
+				t.Fatalf("status = %d (%s), se esperaba %d", status, response, tt.status)
+			}
+			if status != 201 {
+				return
+			}
+			rating, err := repos.Ratings.GetRatingByAssignmentID(assignmentID)
+			if err != nil {
+				t.Fatalf("calificación no registrada: %v", err)
+			}
+			if rating.ClientUserID != "client-1" {
+				t.Errorf("client = %s, se esperaba client-1", rating.ClientUserID)
+			}
+		})
+	}
+}
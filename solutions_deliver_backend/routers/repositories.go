@@ -0,0 +1,15 @@
+package routers
+
+import (
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/repository"
+)
+
+// repos son los repositorios de datos que usan los routers.
+// Por defecto apuntan a MySQL; las pruebas pueden reemplazarlos con
+// InitRepositories(memory.NewRepositories()).
+var repos = repository.NewMySQLRepositories()
+
+// InitRepositories inyecta los repositorios que usarán los routers
+func InitRepositories(r repository.Repositories) {
+	repos = r
+}
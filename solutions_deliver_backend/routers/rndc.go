@@ -0,0 +1,374 @@
+package routers
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/rndc"
+)
+
+// Reporte al RNDC del Ministerio de Transporte: cada guía despachada es una
+// remesa y cada manifiesto de despacho un manifiesto de carga. El
+// ingresoid que asigna el RNDC queda en la guía y en el manifiesto.
+
+// rndcClient cliente del RNDC; nil si no hay RNDC_URL configurada
+var rndcClient = rndc.NewClientFromEnv()
+
+// rndcCompany empresa que reporta (RNDC_NIT)
+var rndcCompany = rndc.CompanyFromEnv()
+
+// InitRNDC inyecta el cliente del RNDC y la empresa (stub local o pruebas)
+func InitRNDC(client rndc.Client, company rndc.Company) {
+	rndcClient = client
+	rndcCompany = company
+}
+
+// GetGuideRNDC XML de la remesa de la guía con su validación y los envíos
+// anteriores
+func GetGuideRNDC(guideID int64) (int, string) {
+	fmt.Printf("GetGuideRNDC -> GuideID: %d\n", guideID)
+
+	guide, status, message := findRNDCGuide(guideID)
+	if status != 0 {
+		return status, message
+	}
+
+	submissions, err := repos.RNDC.GetRNDCSubmissions(models.RNDCFilters{GuideID: &guideID})
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al obtener los envíos al RNDC: %s"}`, err.Error())
+	}
+
+	response := models.RNDCPreviewResponse{
+		Process:     models.RNDCRemesa,
+		Consecutive: rndc.RemesaConsecutive(guide),
+		IngresoID:   guide.RNDCRemesaID,
+		Submissions: submissions,
+	}
+	m, err := buildRemesa(guide)
+	response.Problems = rndcProblems(m, err)
+	response.Valid = len(response.Problems) == 0
+	if err == nil {
+		data, _ := rndc.EncodeMasked(m, os.Getenv("RNDC_USERNAME"))
+		response.XML = string(data)
+	}
+	if response.Submissions == nil {
+		response.Submissions = []models.RNDCSubmission{}
+	}
+
+	return jsonResult(200, response)
+}
+
+// SubmitGuideRNDC reporta la remesa de la guía al RNDC
+func SubmitGuideRNDC(guideID int64, userUUID string) (int, string) {
+	fmt.Printf("SubmitGuideRNDC -> GuideID: %d, UserUUID: %s\n", guideID, userUUID)
+
+	if rndcClient == nil {
+		return 503, `{"error": "El RNDC no está configurado (RNDC_URL)"}`
+	}
+
+	guide, status, message := findRNDCGuide(guideID)
+	if status != 0 {
+		return status, message
+	}
+	if guide.RNDCRemesaID != "" {
+		return 409, fmt.Sprintf(`{"error": "La remesa ya fue reportada al RNDC (ingresoid %s)"}`, guide.RNDCRemesaID)
+	}
+
+	submission, status, message := submitRemesa(guide, userUUID)
+	if status != 0 {
+		return status, message
+	}
+
+	return jsonResult(200, models.RNDCSubmitResponse{
+		Success: true,
+		Remesas: []models.RNDCSubmission{submission},
+		Message: "Remesa reportada al RNDC con el ingresoid " + submission.IngresoID,
+	})
+}
+
+// GetManifestRNDC XML del manifiesto de carga con la validación de las
+// remesas de sus guías y los envíos anteriores
+func GetManifestRNDC(manifestID int64) (int, string) {
+	fmt.Printf("GetManifestRNDC -> ManifestID: %d\n", manifestID)
+
+	manifest, status, message := findManifest(manifestID)
+	if status != 0 {
+		return status, message
+	}
+	guides, status, message := manifestRNDCGuides(manifest)
+	if status != 0 {
+		return status, message
+	}
+
+	submissions, err := repos.RNDC.GetRNDCSubmissions(models.RNDCFilters{ManifestID: &manifestID})
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al obtener los envíos al RNDC: %s"}`, err.Error())
+	}
+
+	response := models.RNDCPreviewResponse{
+		Process:     models.RNDCManifiesto,
+		Consecutive: strconv.FormatInt(manifestID, 10),
+		IngresoID:   manifest.RNDCManifestID,
+		Remesas:     make([]models.RNDCRemesaInfo, 0, len(guides)),
+		Submissions: submissions,
+	}
+	for _, guide := range guides {
+		info := models.RNDCRemesaInfo{
+			GuideID:     guide.GuideID,
+			GuideNumber: guide.GuideNumber,
+			Consecutive: rndc.RemesaConsecutive(guide),
+			IngresoID:   guide.RNDCRemesaID,
+		}
+		if info.IngresoID == "" {
+			m, err := buildRemesa(guide)
+			info.Problems = rndcProblems(m, err)
+		}
+		info.Valid = len(info.Problems) == 0
+		response.Remesas = append(response.Remesas, info)
+	}
+
+	m, err := buildManifiesto(manifest, guides)
+	response.Problems = rndcProblems(m, err)
+	if manifest.Status == models.ManifestOpen {
+		response.Problems = append(response.Problems, "el manifiesto está abierto: se reporta al despacharlo")
+	}
+	response.Valid = len(response.Problems) == 0
+	for _, info := range response.Remesas {
+		response.Valid = response.Valid && info.Valid
+	}
+	if err == nil {
+		data, _ := rndc.EncodeMasked(m, os.Getenv("RNDC_USERNAME"))
+		response.XML = string(data)
+	}
+	if response.Submissions == nil {
+		response.Submissions = []models.RNDCSubmission{}
+	}
+
+	return jsonResult(200, response)
+}
+
+// SubmitManifestRNDC reporta el manifiesto despachado al RNDC: primero las
+// remesas de las guías que no se han reportado y luego el manifiesto con
+// todas. Si una remesa falla se detiene; las ya aceptadas quedan guardadas
+// y el reporte se puede reintentar.
+func SubmitManifestRNDC(manifestID int64, userUUID string) (int, string) {
+	fmt.Printf("SubmitManifestRNDC -> ManifestID: %d, UserUUID: %s\n", manifestID, userUUID)
+
+	if rndcClient == nil {
+		return 503, `{"error": "El RNDC no está configurado (RNDC_URL)"}`
+	}
+
+	manifest, status, message := findManifest(manifestID)
+	if status != 0 {
+		return status, message
+	}
+	if manifest.Status == models.ManifestOpen {
+		return 409, `{"error": "El manifiesto está abierto: se reporta al despacharlo"}`
+	}
+	if manifest.RNDCManifestID != "" {
+		return 409, fmt.Sprintf(`{"error": "El manifiesto ya fue reportado al RNDC (ingresoid %s)"}`, manifest.RNDCManifestID)
+	}
+
+	guides, status, message := manifestRNDCGuides(manifest)
+	if status != 0 {
+		return status, message
+	}
+
+	// Validar todo antes de enviar algo
+	m, err := buildManifiesto(manifest, guides)
+	if problems := rndcProblems(m, err); len(problems) > 0 {
+		return jsonResult(400, map[string]interface{}{"error": "El manifiesto no cumple el esquema del RNDC", "problems": problems})
+	}
+	for _, guide := range guides {
+		if guide.RNDCRemesaID != "" {
+			continue
+		}
+		remesa, err := buildRemesa(guide)
+		if problems := rndcProblems(remesa, err); len(problems) > 0 {
+			return jsonResult(400, map[string]interface{}{
+				"error":    fmt.Sprintf("La remesa de la guía %s no cumple el esquema del RNDC", guide.GuideNumber),
+				"problems": problems,
+			})
+		}
+	}
+
+	response := models.RNDCSubmitResponse{Remesas: []models.RNDCSubmission{}}
+	for _, guide := range guides {
+		if guide.RNDCRemesaID != "" {
+			continue
+		}
+		submission, status, message := submitRemesa(guide, userUUID)
+		if status != 0 {
+			if submission.SubmissionID == 0 {
+				return status, message
+			}
+			response.Remesas = append(response.Remesas, submission)
+			response.Message = fmt.Sprintf("No se pudo reportar la remesa de la guía %s: %s", guide.GuideNumber, submission.Error)
+			return jsonResult(status, response)
+		}
+		response.Remesas = append(response.Remesas, submission)
+	}
+
+	submission, status, message := submitRNDC(m, models.RNDCSubmission{
+		Process:     models.RNDCManifiesto,
+		ManifestID:  &manifestID,
+		Consecutive: m.Get("NUMMANIFIESTOCARGA"),
+		SubmittedBy: userUUID,
+	})
+	if status != 0 && submission.SubmissionID == 0 {
+		return status, message
+	}
+	response.Manifiesto = &submission
+	if status != 0 {
+		response.Message = "No se pudo reportar el manifiesto: " + submission.Error
+		return jsonResult(status, response)
+	}
+
+	response.Success = true
+	response.Message = fmt.Sprintf("Manifiesto reportado al RNDC con el ingresoid %s (%d remesas nuevas)",
+		submission.IngresoID, len(response.Remesas))
+	return jsonResult(200, response)
+}
+
+func findRNDCGuide(guideID int64) (models.ShippingGuide, int, string) {
+	guide, err := repos.Guides.GetGuideByID(guideID)
+	if err != nil {
+		if err.Error() == "Guía no encontrada" {
+			return guide, 404, `{"error": "Guía no encontrada"}`
+		}
+		return guide, 500, fmt.Sprintf(`{"error": "Error al obtener la guía: %s"}`, err.Error())
+	}
+	return guide, 0, ""
+}
+
+// manifestRNDCGuides guías completas del manifiesto (partes y paquete)
+func manifestRNDCGuides(manifest models.Manifest) ([]models.ShippingGuide, int, string) {
+	guides := make([]models.ShippingGuide, 0, len(manifest.Guides))
+	for _, line := range manifest.Guides {
+		guide, status, message := findRNDCGuide(line.GuideID)
+		if status != 0 {
+			return nil, status, message
+		}
+		guides = append(guides, guide)
+	}
+	return guides, 0, ""
+}
+
+func buildRemesa(guide models.ShippingGuide) (rndc.Message, error) {
+	origin, err := repos.Locations.GetCityByID(guide.OriginCityID)
+	if err != nil {
+		return rndc.Message{}, fmt.Errorf("ciudad de origen %d: %s", guide.OriginCityID, err.Error())
+	}
+	destination, err := repos.Locations.GetCityByID(guide.DestinationCityID)
+	if err != nil {
+		return rndc.Message{}, fmt.Errorf("ciudad de destino %d: %s", guide.DestinationCityID, err.Error())
+	}
+	return rndc.BuildRemesa(rndcCompany, guide, origin, destination)
+}
+
+// buildManifiesto arma el manifiesto de carga: origen el de las guías y
+// destino el más frecuente entre ellas (el final de la ruta); el flete
+// pactado es la suma de los fletes de las guías
+func buildManifiesto(manifest models.Manifest, guides []models.ShippingGuide) (rndc.Message, error) {
+	if len(guides) == 0 {
+		return rndc.Message{}, fmt.Errorf("el manifiesto no tiene guías")
+	}
+
+	var remesas []string
+	var freight float64
+	count := map[int64]int{}
+	destinationID := guides[0].DestinationCityID
+	for _, guide := range guides {
+		remesas = append(remesas, rndc.RemesaConsecutive(guide))
+		freight += guide.Price
+		count[guide.DestinationCityID]++
+		if count[guide.DestinationCityID] > count[destinationID] {
+			destinationID = guide.DestinationCityID
+		}
+	}
+
+	origin, err := repos.Locations.GetCityByID(guides[0].OriginCityID)
+	if err != nil {
+		return rndc.Message{}, fmt.Errorf("ciudad de origen %d: %s", guides[0].OriginCityID, err.Error())
+	}
+	destination, err := repos.Locations.GetCityByID(destinationID)
+	if err != nil {
+		return rndc.Message{}, fmt.Errorf("ciudad de destino %d: %s", destinationID, err.Error())
+	}
+	return rndc.BuildManifiesto(rndcCompany, manifest, remesas, origin, destination, freight), nil
+}
+
+// rndcProblems problemas del mensaje: el error al armarlo o los de la
+// validación contra el esquema
+func rndcProblems(m rndc.Message, err error) []string {
+	if err != nil {
+		return []string{err.Error()}
+	}
+	var validation *rndc.ValidationError
+	if err := rndc.Validate(m); errors.As(err, &validation) {
+		return validation.Problems
+	}
+	return []string{}
+}
+
+func submitRemesa(guide models.ShippingGuide, userUUID string) (models.RNDCSubmission, int, string) {
+	m, err := buildRemesa(guide)
+	if problems := rndcProblems(m, err); len(problems) > 0 {
+		status, message := jsonResult(400, map[string]interface{}{"error": "La remesa no cumple el esquema del RNDC", "problems": problems})
+		return models.RNDCSubmission{}, status, message
+	}
+
+	guideID := guide.GuideID
+	return submitRNDC(m, models.RNDCSubmission{
+		Process:     models.RNDCRemesa,
+		GuideID:     &guideID,
+		Consecutive: m.Get("CONSECUTIVOREMESA"),
+		SubmittedBy: userUUID,
+	})
+}
+
+// submitRNDC envía el mensaje y guarda el envío. Retorna status 0 si el RNDC
+// lo aceptó; si lo rechazó o no respondió, el envío queda guardado con el
+// error (SubmissionID > 0) y el status es 422 o 502.
+func submitRNDC(m rndc.Message, submission models.RNDCSubmission) (models.RNDCSubmission, int, string) {
+	result, err := rndcClient.Submit(m)
+	submission.RequestXML = result.RequestXML
+	submission.ResponseXML = result.ResponseXML
+
+	var validation *rndc.ValidationError
+	if errors.As(err, &validation) {
+		status, message := jsonResult(400, map[string]interface{}{"error": "El mensaje no cumple el esquema del RNDC", "problems": validation.Problems})
+		return submission, status, message
+	}
+
+	status := 0
+	var rejected *rndc.RejectedError
+	switch {
+	case errors.As(err, &rejected):
+		submission.Error = rejected.Message
+		status = 422
+	case err != nil:
+		submission.Error = err.Error()
+		status = 502
+	default:
+		submission.Success = true
+		submission.IngresoID = result.IngresoID
+	}
+
+	if saveErr := repos.RNDC.SaveRNDCSubmission(&submission); saveErr != nil {
+		if submission.Success {
+			// El RNDC ya lo registró: sin guardar el ingresoid un reintento lo duplicaría
+			return models.RNDCSubmission{}, 500, fmt.Sprintf(`{"error": "El RNDC asignó el ingresoid %s pero no se pudo guardar: %s"}`,
+				submission.IngresoID, saveErr.Error())
+		}
+		fmt.Printf("Warning: no se pudo guardar el envío al RNDC: %s\n", saveErr.Error())
+	}
+
+	if status != 0 {
+		return submission, status, fmt.Sprintf(`{"error": %q}`, "RNDC: "+submission.Error)
+	}
+	return submission, 0, ""
+}
@@ -0,0 +1,278 @@
+package routers
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+)
+
+// Escaneo de guías por lotes (POST /scans). Cada punto de la operación
+// aplica un estado a la guía (ver models.ScanPoint):
+//   SCAN_DEDUP_WINDOW  un escaneo repetido del mismo punto dentro de la
+//                      ventana no se vuelve a aplicar (por defecto 10m)
+//   SCAN_MAX_BATCH     códigos por lote (por defecto 200)
+
+func scanDedupWindow() time.Duration {
+	value, err := time.ParseDuration(os.Getenv("SCAN_DEDUP_WINDOW"))
+	if err != nil || value <= 0 {
+		return 10 * time.Minute
+	}
+	return value
+}
+
+func scanMaxBatch() int {
+	value, err := strconv.Atoi(os.Getenv("SCAN_MAX_BATCH"))
+	if err != nil || value < 1 {
+		return 200
+	}
+	return value
+}
+
+// RegisterScans procesa un lote de códigos escaneados en un punto. Cada
+// código se resuelve (guide_id, número de guía o código de pieza) y se le
+// aplica la transición del punto con repos.Guides.UpdateGuideStatus. El
+// resultado es por código: un código rechazado no detiene el lote.
+func RegisterScans(body string, userUUID string, userRole models.UserRole) (int, string) {
+	fmt.Printf("RegisterScans -> UserUUID: %s\n", userUUID)
+
+	var request models.ScanBatchRequest
+	if err := json.Unmarshal([]byte(body), &request); err != nil {
+		return 400, fmt.Sprintf(`{"error": "Body inválido: %s"}`, err.Error())
+	}
+
+	request.ScanPoint = models.ScanPoint(strings.ToUpper(strings.TrimSpace(string(request.ScanPoint))))
+	target, ok := request.ScanPoint.TargetStatus()
+	if !ok {
+		return 400, `{"error": "scan_point debe ser RECEPTION, WAREHOUSE o DISPATCH"}`
+	}
+	if len(request.Codes) == 0 {
+		return 400, `{"error": "codes es requerido"}`
+	}
+	if maxBatch := scanMaxBatch(); len(request.Codes) > maxBatch {
+		return 400, fmt.Sprintf(`{"error": "Se permiten máximo %d códigos por lote"}`, maxBatch)
+	}
+
+	notes := "Escaneo en " + request.ScanPoint.Label()
+	if extra := strings.TrimSpace(request.Notes); extra != "" {
+		notes += ": " + extra
+	}
+
+	response := models.ScanBatchResponse{
+		ScanPoint: request.ScanPoint,
+		Items:     make([]models.ScanItemResult, 0, len(request.Codes)),
+	}
+
+	window := scanDedupWindow()
+	seen := map[scanKey]bool{}
+	for _, code := range request.Codes {
+		item := processScan(strings.TrimSpace(code), request.ScanPoint, target, notes, window, seen, userUUID, userRole)
+
+		switch item.Result {
+		case models.ScanApplied:
+			response.Applied++
+		case models.ScanUnchanged:
+			response.Unchanged++
+		case models.ScanDuplicate:
+			response.Duplicates++
+		default:
+			response.Rejected++
+		}
+		response.Items = append(response.Items, item)
+	}
+	response.Total = len(response.Items)
+
+	jsonResponse, err := json.Marshal(response)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al serializar respuesta: %s"}`, err.Error())
+	}
+
+	return 200, string(jsonResponse)
+}
+
+// scanKey guía y pieza de un código (pieza 0: la guía completa)
+type scanKey struct {
+	guideID int64
+	piece   int
+}
+
+// processScan aplica un código del lote: el de la guía (guide_id o número)
+// o el de una de sus piezas. La guía cambia de estado con el primer código
+// que la mueve; cada pieza guarda su propio estado para detectar envíos
+// parciales. seen guarda lo ya procesado en el lote para que un código
+// repetido (o la misma guía por guide_id y por número) cuente como duplicado.
+func processScan(code string, point models.ScanPoint, target models.GuideStatus, notes string, window time.Duration,
+	seen map[scanKey]bool, userUUID string, userRole models.UserRole) models.ScanItemResult {
+
+	item := models.ScanItemResult{Code: code, Result: models.ScanRejected}
+
+	ref, pieceNumber := code, 0
+	if number, piece, ok := models.ParsePieceCode(code); ok {
+		ref, pieceNumber = number, piece
+	}
+
+	guideID, status, message := ResolveGuideRef(ref)
+	if status != 0 {
+		item.Error = responseError(message)
+		return item
+	}
+	item.GuideID = guideID
+	item.PieceNumber = pieceNumber
+
+	guide, err := repos.Guides.GetGuideByID(guideID)
+	if err != nil {
+		item.Error = err.Error()
+		return item
+	}
+	item.GuideNumber = guide.GuideNumber
+
+	// Estado previo: el de la pieza escaneada o el de la guía
+	previous := guide.CurrentStatus
+	if pieceNumber > 0 {
+		piece, ok := models.FindPiece(guide.Pieces, pieceNumber)
+		if !ok {
+			item.Error = fmt.Sprintf("La guía no tiene la pieza %d", pieceNumber)
+			return item
+		}
+		previous = piece.Status
+	}
+	item.PreviousStatus = previous
+
+	// Escaneo repetido: en el mismo lote o dentro de la ventana
+	key := scanKey{guideID: guideID, piece: pieceNumber}
+	if seen[key] {
+		return duplicateScan(item, previous)
+	}
+	seen[key] = true
+
+	recent, err := repos.Guides.GetRecentGuideScan(guideID, pieceNumber, point, window)
+	if err == nil {
+		item.PreviousStatus = recent.PreviousStatus
+		return duplicateScan(item, previous)
+	}
+	if err.Error() != "Escaneo no encontrado" {
+		item.Error = fmt.Sprintf("Error al consultar escaneos: %s", err.Error())
+		return item
+	}
+
+	guideChanges := guide.CurrentStatus != target
+	piecesChange := false
+	for _, p := range guide.Pieces {
+		if (pieceNumber == 0 || p.PieceNumber == pieceNumber) && p.Status != target {
+			piecesChange = true
+		}
+	}
+
+	if guideChanges {
+		if message := validateScanTransition(guide, target, userRole); message != "" {
+			item.Error = message
+			return item
+		}
+
+		err = repos.Guides.UpdateGuideStatus(guideID, target, "", notes, userUUID)
+		if err != nil {
+			if err.Error() == "transición de estado no permitida" {
+				// El estado cambió entre la lectura y la actualización
+				item.Error = fmt.Sprintf("La guía ya no está en %s", guide.CurrentStatus)
+			} else {
+				item.Error = fmt.Sprintf("Error al actualizar el estado de la guía: %s", err.Error())
+			}
+			return item
+		}
+	}
+
+	if piecesChange {
+		// Si falla, el mismo código se puede volver a escanear: la guía ya
+		// está en el estado y solo se actualizan las piezas
+		if err := repos.Guides.UpdatePieceScanStatus(guideID, pieceNumber, target, point); err != nil {
+			item.Error = fmt.Sprintf("Error al actualizar las piezas: %s", err.Error())
+			return item
+		}
+		for i := range guide.Pieces {
+			if pieceNumber == 0 || guide.Pieces[i].PieceNumber == pieceNumber {
+				guide.Pieces[i].Status = target
+			}
+		}
+	}
+
+	scan := models.GuideScan{
+		GuideID:        guideID,
+		PieceNumber:    pieceNumber,
+		ScanPoint:      point,
+		Code:           code,
+		Result:         models.ScanUnchanged,
+		PreviousStatus: previous,
+		NewStatus:      target,
+		ScannedBy:      userUUID,
+	}
+	if guideChanges || piecesChange {
+		scan.Result = models.ScanApplied
+	}
+
+	// Lo que sale a reparto deja de estar ubicado en la bodega
+	if target == models.StatusOutForDelivery && scan.Result == models.ScanApplied {
+		checkOutDispatched(guideID, pieceNumber, userUUID)
+	}
+
+	// El registro del escaneo solo alimenta la deduplicación (no crítico)
+	if err := repos.Guides.RecordGuideScan(&scan); err != nil {
+		fmt.Printf("Warning: no se pudo registrar el escaneo de la guía %d: %s\n", guideID, err.Error())
+	}
+
+	item.Success = true
+	item.Result = scan.Result
+	item.NewStatus = target
+	item.Partial = models.PiecesPartial(guide.Pieces)
+	return item
+}
+
+// validateScanTransition retorna el motivo por el que el escaneo no puede
+// mover la guía al estado del punto, o vacío si puede
+func validateScanTransition(guide models.ShippingGuide, target models.GuideStatus, userRole models.UserRole) string {
+	transition, ok := models.FindGuideTransition(guide.CurrentStatus, target)
+	if !ok || !transition.Allows(userRole, models.TriggerScan) {
+		return fmt.Sprintf("La guía no puede pasar de %s a %s por escaneo", guide.CurrentStatus, target)
+	}
+
+	// La salida a reparto exige una entrega asignada: el entregador la
+	// inicia después y completa la entrega sobre esa asignación
+	if target == models.StatusOutForDelivery {
+		assignments, _, err := repos.Assignments.GetAssignmentsByFilters(models.AssignmentFilters{
+			GuideID:        &guide.GuideID,
+			AssignmentType: models.AssignmentDelivery,
+		})
+		if err != nil {
+			return fmt.Sprintf("Error al consultar las asignaciones: %s", err.Error())
+		}
+		for _, a := range assignments {
+			if a.Status == models.AssignmentPending || a.Status == models.AssignmentInProgress {
+				return ""
+			}
+		}
+		return "La guía no tiene una entrega asignada"
+	}
+
+	return ""
+}
+
+func duplicateScan(item models.ScanItemResult, current models.GuideStatus) models.ScanItemResult {
+	item.Success = true
+	item.Result = models.ScanDuplicate
+	item.NewStatus = current
+	return item
+}
+
+// responseError extrae el mensaje de una respuesta {"error": "..."}
+func responseError(body string) string {
+	var response struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(body), &response); err != nil || response.Error == "" {
+		return body
+	}
+	return response.Error
+}
@@ -0,0 +1,100 @@
+package routers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/manifests"
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+)
+
+// Traslados (TRANSFER): el trayecto de una guía entre las bodegas (hubs) de
+// dos ciudades, con conductor (delivery_user_id) y vehículo. Al iniciarlo la
+// guía pasa a IN_ROUTE y sale de los bins de origen; al completarlo pasa a
+// IN_WAREHOUSE en la bodega de destino, donde se ubica con el check-in.
+
+// validateTransfer valida los datos de un traslado antes de crearlo y
+// normaliza la placa
+func validateTransfer(req *models.CreateAssignmentRequest) (int, string) {
+	req.VehiclePlate = strings.ToUpper(strings.Join(strings.Fields(req.VehiclePlate), ""))
+	if req.VehiclePlate == "" {
+		return 400, `{"error": "vehicle_plate es requerido en los traslados"}`
+	}
+	if req.OriginWarehouseID <= 0 || req.DestinationWarehouseID <= 0 {
+		return 400, `{"error": "origin_warehouse_id y destination_warehouse_id son requeridos en los traslados"}`
+	}
+	if req.OriginWarehouseID == req.DestinationWarehouseID {
+		return 400, `{"error": "La bodega de destino debe ser distinta a la de origen"}`
+	}
+
+	for _, warehouseID := range []int64{req.OriginWarehouseID, req.DestinationWarehouseID} {
+		warehouse, status, body := findWarehouse(warehouseID)
+		if status != 0 {
+			return status, body
+		}
+		if !warehouse.Active {
+			return 400, fmt.Sprintf(`{"error": "La bodega %s está inactiva"}`, warehouse.Code)
+		}
+	}
+
+	guide, err := repos.Guides.GetGuideByID(req.GuideID)
+	if err != nil {
+		if err.Error() == "Guía no encontrada" {
+			return 404, `{"error": "Guía no encontrada"}`
+		}
+		return 500, fmt.Sprintf(`{"error": "Error al obtener la guía: %s"}`, err.Error())
+	}
+	if guide.CurrentStatus != models.StatusInWarehouse {
+		return 409, fmt.Sprintf(`{"error": "La guía no está en bodega (%s)"}`, guide.CurrentStatus)
+	}
+
+	manifestID, err := repos.Manifests.GetActiveManifestByGuide(guide.GuideID)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al validar la guía: %s"}`, err.Error())
+	}
+	if manifestID != 0 {
+		return 409, fmt.Sprintf(`{"error": "La guía está en el manifiesto %s"}`, manifests.Number(manifestID))
+	}
+
+	// Si la guía está ubicada en bins, deben ser de la bodega de origen
+	stock, err := repos.Warehouses.GetStock(models.StockFilters{GuideID: &guide.GuideID})
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al obtener la ubicación de la guía: %s"}`, err.Error())
+	}
+	for _, item := range stock {
+		if item.WarehouseID != req.OriginWarehouseID {
+			return 409, fmt.Sprintf(`{"error": "La guía está ubicada en la bodega %s"}`, item.WarehouseCode)
+		}
+	}
+	return 0, ""
+}
+
+// activeTransfer traslado PENDING o IN_PROGRESS de la guía, si lo tiene
+func activeTransfer(guideID int64) (models.DeliveryAssignment, bool, error) {
+	transfers, _, err := repos.Assignments.GetAssignmentsByFilters(models.AssignmentFilters{
+		GuideID:        &guideID,
+		AssignmentType: models.AssignmentTransfer,
+		Limit:          100,
+	})
+	if err != nil {
+		return models.DeliveryAssignment{}, false, err
+	}
+	for _, t := range transfers {
+		if t.Status == models.AssignmentPending || t.Status == models.AssignmentInProgress {
+			return t, true, nil
+		}
+	}
+	return models.DeliveryAssignment{}, false, nil
+}
+
+// checkOutTransferred saca de los bins de origen la guía que salió en un
+// traslado (no crítico)
+func checkOutTransferred(assignment models.DeliveryAssignment, userUUID string) {
+	notes := "Traslado entre bodegas"
+	if assignment.DestinationWarehouseName != "" {
+		notes = "Traslado a " + assignment.DestinationWarehouseName
+	}
+	if _, err := repos.Warehouses.CheckOutGuideItems(assignment.GuideID, nil, notes, userUUID); err != nil {
+		fmt.Printf("Warning: no se pudo sacar de la bodega la guía %d: %s\n", assignment.GuideID, err.Error())
+	}
+}
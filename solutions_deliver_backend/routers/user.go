@@ -4,13 +4,12 @@ import (
 	"encoding/json"
 	"fmt"
 
-	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/bd"
 )
 
 func GetRole(userUUID string) (int, string) {
 	fmt.Printf("GetRole -> UserUUID: %s\n", userUUID)
 
-	user, err := bd.GetUserRole(userUUID)
+	user, err := repos.Users.GetUserRole(userUUID)
 
 	if err != nil {
 		fmt.Printf("GetRole -> Error: %s\n", err.Error())
@@ -22,7 +21,7 @@ func GetRole(userUUID string) (int, string) {
 	}
 
 	// Actualizar last_login (no crítico, solo log si falla)
-	if updateErr := bd.UpdateLastLogin(userUUID); updateErr != nil {
+	if updateErr := repos.Users.UpdateLastLogin(userUUID); updateErr != nil {
 		fmt.Printf("Warning: Could not update last_login for user %s: %s\n", userUUID, updateErr.Error())
 	}
 
@@ -0,0 +1,526 @@
+package routers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Junior_Jurado/solutions_delivery/solutions_deliver_backend/models"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Bodegas y ubicación de guías. Las guías se ubican por pieza en los bins:
+// el código de la guía mueve todas sus piezas y el código de una pieza solo
+// esa. El ingreso (check-in) es el mismo escaneo del punto WAREHOUSE, así que
+// también pasa la guía a IN_WAREHOUSE; el escaneo de DISPATCH la saca de la
+// bodega.
+
+// agingBuckets rangos de días del reporte de antigüedad
+var agingBuckets = []struct {
+	label    string
+	min, max int // max 0: sin límite
+}{
+	{"0-1 días", 0, 1},
+	{"1-3 días", 1, 3},
+	{"3-7 días", 3, 7},
+	{"7+ días", 7, 0},
+}
+
+// CreateWarehouse crea una bodega
+func CreateWarehouse(body string, userUUID string) (int, string) {
+	fmt.Printf("CreateWarehouse -> UserUUID: %s\n", userUUID)
+
+	var request models.CreateWarehouseRequest
+	if err := json.Unmarshal([]byte(body), &request); err != nil {
+		return 400, fmt.Sprintf(`{"error": "Body inválido: %s"}`, err.Error())
+	}
+
+	warehouse := models.Warehouse{
+		Code:      strings.ToUpper(strings.TrimSpace(request.Code)),
+		Name:      strings.TrimSpace(request.Name),
+		CityID:    request.CityID,
+		Address:   strings.TrimSpace(request.Address),
+		CreatedBy: userUUID,
+	}
+	if warehouse.Code == "" || warehouse.Name == "" {
+		return 400, `{"error": "code y name son requeridos"}`
+	}
+	if warehouse.CityID <= 0 {
+		return 400, `{"error": "city_id es requerido"}`
+	}
+	city, err := repos.Locations.GetCityByID(warehouse.CityID)
+	if err != nil {
+		return 400, `{"error": "La ciudad no existe"}`
+	}
+
+	err = repos.Warehouses.CreateWarehouse(&warehouse)
+	if err != nil {
+		if err.Error() == "ya existe una bodega con ese código" {
+			return 409, `{"error": "Ya existe una bodega con ese código"}`
+		}
+		return 500, fmt.Sprintf(`{"error": "Error al crear la bodega: %s"}`, err.Error())
+	}
+	warehouse.CityName = city.Name
+
+	return jsonResult(201, map[string]interface{}{
+		"success":   true,
+		"warehouse": warehouse,
+		"message":   "Bodega creada correctamente",
+	})
+}
+
+// GetWarehouses lista las bodegas
+func GetWarehouses() (int, string) {
+	fmt.Println("GetWarehouses")
+
+	warehouses, err := repos.Warehouses.GetWarehouses()
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al obtener bodegas: %s"}`, err.Error())
+	}
+	if warehouses == nil {
+		warehouses = []models.Warehouse{}
+	}
+
+	return jsonResult(200, map[string]interface{}{
+		"warehouses": warehouses,
+		"total":      len(warehouses),
+	})
+}
+
+// CreateBin crea un bin en la bodega
+func CreateBin(warehouseID int64, body string) (int, string) {
+	fmt.Printf("CreateBin -> WarehouseID: %d\n", warehouseID)
+
+	var request models.CreateBinRequest
+	if err := json.Unmarshal([]byte(body), &request); err != nil {
+		return 400, fmt.Sprintf(`{"error": "Body inválido: %s"}`, err.Error())
+	}
+
+	if _, status, message := findWarehouse(warehouseID); status != 0 {
+		return status, message
+	}
+
+	bin := models.WarehouseBin{
+		WarehouseID: warehouseID,
+		Code:        strings.ToUpper(strings.Join(strings.Fields(request.Code), "")),
+		Description: strings.TrimSpace(request.Description),
+	}
+	if bin.Code == "" {
+		return 400, `{"error": "code es requerido"}`
+	}
+
+	err := repos.Warehouses.CreateBin(&bin)
+	if err != nil {
+		if err.Error() == "ya existe un bin con ese código en la bodega" {
+			return 409, `{"error": "Ya existe un bin con ese código en la bodega"}`
+		}
+		return 500, fmt.Sprintf(`{"error": "Error al crear el bin: %s"}`, err.Error())
+	}
+
+	return jsonResult(201, map[string]interface{}{
+		"success": true,
+		"bin":     bin,
+		"message": "Bin creado correctamente",
+	})
+}
+
+// GetWarehouseBins lista los bins de la bodega con lo ubicado en cada uno
+func GetWarehouseBins(warehouseID int64) (int, string) {
+	fmt.Printf("GetWarehouseBins -> WarehouseID: %d\n", warehouseID)
+
+	warehouse, status, message := findWarehouse(warehouseID)
+	if status != 0 {
+		return status, message
+	}
+
+	bins, err := repos.Warehouses.GetBins(warehouseID)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al obtener bins: %s"}`, err.Error())
+	}
+	if bins == nil {
+		bins = []models.WarehouseBin{}
+	}
+
+	return jsonResult(200, map[string]interface{}{
+		"warehouse": warehouse,
+		"bins":      bins,
+		"total":     len(bins),
+	})
+}
+
+// GetWarehouseStock lista lo ubicado en la bodega, opcionalmente en un bin
+func GetWarehouseStock(warehouseID int64, request events.APIGatewayV2HTTPRequest) (int, string) {
+	fmt.Printf("GetWarehouseStock -> WarehouseID: %d\n", warehouseID)
+
+	if _, status, message := findWarehouse(warehouseID); status != 0 {
+		return status, message
+	}
+
+	filters := models.StockFilters{WarehouseID: &warehouseID}
+	if binStr := request.QueryStringParameters["bin_id"]; binStr != "" {
+		binID, err := strconv.ParseInt(binStr, 10, 64)
+		if err != nil {
+			return 400, `{"error": "bin_id debe ser un número válido"}`
+		}
+		filters.BinID = &binID
+	}
+
+	items, err := repos.Warehouses.GetStock(filters)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al obtener el inventario: %s"}`, err.Error())
+	}
+	if items == nil {
+		items = []models.StockItem{}
+	}
+
+	return jsonResult(200, map[string]interface{}{
+		"items": items,
+		"total": len(items),
+	})
+}
+
+// WarehouseCheckIn ingresa las guías o piezas a la bodega y las ubica en el
+// bin. Cada código pasa primero por el escaneo de WAREHOUSE (transición a
+// IN_WAREHOUSE, piezas y deduplicación); si se rechaza no se ubica.
+func WarehouseCheckIn(body string, userUUID string, userRole models.UserRole) (int, string) {
+	fmt.Printf("WarehouseCheckIn -> UserUUID: %s\n", userUUID)
+	return locateCodes(body, userUUID, userRole, true)
+}
+
+// WarehouseMove cambia de bin guías o piezas que ya están en bodega
+func WarehouseMove(body string, userUUID string, userRole models.UserRole) (int, string) {
+	fmt.Printf("WarehouseMove -> UserUUID: %s\n", userUUID)
+	return locateCodes(body, userUUID, userRole, false)
+}
+
+func locateCodes(body string, userUUID string, userRole models.UserRole, checkIn bool) (int, string) {
+	var request models.WarehouseMoveRequest
+	if err := json.Unmarshal([]byte(body), &request); err != nil {
+		return 400, fmt.Sprintf(`{"error": "Body inválido: %s"}`, err.Error())
+	}
+	if request.BinID <= 0 {
+		return 400, `{"error": "bin_id es requerido"}`
+	}
+	if status, message := validateWarehouseCodes(request.Codes); status != 0 {
+		return status, message
+	}
+
+	bin, err := repos.Warehouses.GetBinByID(request.BinID)
+	if err != nil {
+		if err.Error() == "Bin no encontrado" {
+			return 404, `{"error": "Bin no encontrado"}`
+		}
+		return 500, fmt.Sprintf(`{"error": "Error al obtener el bin: %s"}`, err.Error())
+	}
+	if !bin.Active {
+		return 409, `{"error": "El bin está inactivo"}`
+	}
+
+	notes := strings.TrimSpace(request.Notes)
+	scanNotes := "Escaneo en " + models.ScanWarehouse.Label() + " (bin " + bin.Code + ")"
+	if notes != "" {
+		scanNotes += ": " + notes
+	}
+
+	response := models.WarehouseBatchResponse{Items: make([]models.WarehouseItemResult, 0, len(request.Codes))}
+	window := scanDedupWindow()
+	seen := map[scanKey]bool{}
+
+	for _, raw := range request.Codes {
+		code := strings.TrimSpace(raw)
+		item := models.WarehouseItemResult{Code: code, BinCode: bin.Code}
+
+		guide, pieces, message := resolveItemCode(code)
+		if message != "" {
+			item.Error = message
+			response.Items = append(response.Items, item)
+			continue
+		}
+		item.GuideID = guide.GuideID
+		item.GuideNumber = guide.GuideNumber
+
+		if checkIn {
+			scan := processScan(code, models.ScanWarehouse, models.StatusInWarehouse, scanNotes, window, seen, userUUID, userRole)
+			if scan.Result == models.ScanRejected {
+				item.Error = scan.Error
+				response.Items = append(response.Items, item)
+				continue
+			}
+		} else if guide.CurrentStatus != models.StatusInWarehouse {
+			item.Error = fmt.Sprintf("La guía no está en bodega (%s)", guide.CurrentStatus)
+			response.Items = append(response.Items, item)
+			continue
+		}
+
+		item.FromBins = currentBins(guide.GuideID, pieces)
+
+		err := repos.Warehouses.LocateGuideItems(guide.GuideID, pieces, bin.BinID, models.MovementMove, notes, userUUID)
+		if err != nil {
+			item.Error = fmt.Sprintf("Error al ubicar: %s", err.Error())
+			response.Items = append(response.Items, item)
+			continue
+		}
+
+		item.Success = true
+		item.Pieces = withoutWholeGuide(pieces)
+		response.Items = append(response.Items, item)
+	}
+
+	return batchResult(response)
+}
+
+// WarehouseCheckOut saca guías o piezas de la bodega sin cambiar su estado
+// (p.ej. devoluciones o traslados). El despacho a reparto las saca solo.
+func WarehouseCheckOut(body string, userUUID string) (int, string) {
+	fmt.Printf("WarehouseCheckOut -> UserUUID: %s\n", userUUID)
+
+	var request models.WarehouseCheckOutRequest
+	if err := json.Unmarshal([]byte(body), &request); err != nil {
+		return 400, fmt.Sprintf(`{"error": "Body inválido: %s"}`, err.Error())
+	}
+	if status, message := validateWarehouseCodes(request.Codes); status != 0 {
+		return status, message
+	}
+
+	notes := strings.TrimSpace(request.Notes)
+	response := models.WarehouseBatchResponse{Items: make([]models.WarehouseItemResult, 0, len(request.Codes))}
+
+	for _, raw := range request.Codes {
+		code := strings.TrimSpace(raw)
+		item := models.WarehouseItemResult{Code: code}
+
+		guide, pieces, message := resolveItemCode(code)
+		if message != "" {
+			item.Error = message
+			response.Items = append(response.Items, item)
+			continue
+		}
+		item.GuideID = guide.GuideID
+		item.GuideNumber = guide.GuideNumber
+		item.FromBins = currentBins(guide.GuideID, pieces)
+
+		removed, err := repos.Warehouses.CheckOutGuideItems(guide.GuideID, pieces, notes, userUUID)
+		if err != nil {
+			item.Error = fmt.Sprintf("Error al sacar de la bodega: %s", err.Error())
+		} else if removed == 0 {
+			item.Error = "La guía no está ubicada en ningún bin"
+		} else {
+			item.Success = true
+			item.Pieces = withoutWholeGuide(pieces)
+		}
+		response.Items = append(response.Items, item)
+	}
+
+	return batchResult(response)
+}
+
+// checkOutDispatched saca de la bodega lo despachado a reparto (no crítico)
+func checkOutDispatched(guideID int64, pieceNumber int, userUUID string) {
+	var pieces []int
+	if pieceNumber > 0 {
+		pieces = []int{pieceNumber}
+	}
+	if _, err := repos.Warehouses.CheckOutGuideItems(guideID, pieces, "Despacho a reparto", userUUID); err != nil {
+		fmt.Printf("Warning: no se pudo sacar de la bodega la guía %d: %s\n", guideID, err.Error())
+	}
+}
+
+// GetGuideLocation ubicación actual (bins por pieza) e historial de
+// movimientos de bodega de una guía
+func GetGuideLocation(guideID int64) (int, string) {
+	fmt.Printf("GetGuideLocation -> GuideID: %d\n", guideID)
+
+	guide, err := repos.Guides.GetGuideByID(guideID)
+	if err != nil {
+		if err.Error() == "Guía no encontrada" {
+			return 404, `{"error": "Guía no encontrada"}`
+		}
+		return 500, fmt.Sprintf(`{"error": "Error al obtener la guía: %s"}`, err.Error())
+	}
+
+	locations, err := repos.Warehouses.GetStock(models.StockFilters{GuideID: &guideID})
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al obtener la ubicación: %s"}`, err.Error())
+	}
+	movements, err := repos.Warehouses.GetGuideMovements(guideID)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al obtener los movimientos: %s"}`, err.Error())
+	}
+
+	response := models.GuideLocationResponse{
+		GuideID:       guide.GuideID,
+		GuideNumber:   guide.GuideNumber,
+		CurrentStatus: guide.CurrentStatus,
+		Locations:     locations,
+		Movements:     movements,
+	}
+	if response.Locations == nil {
+		response.Locations = []models.StockItem{}
+	}
+	if response.Movements == nil {
+		response.Movements = []models.WarehouseMovement{}
+	}
+
+	return jsonResult(200, response)
+}
+
+// GetWarehouseAging reporte de guías en bodega con más de min_days días
+// (por defecto todas), de la más antigua a la más reciente, con el resumen
+// por rangos de días
+func GetWarehouseAging(request events.APIGatewayV2HTTPRequest) (int, string) {
+	fmt.Println("GetWarehouseAging")
+
+	var filters models.AgingFilters
+	if minStr := request.QueryStringParameters["min_days"]; minStr != "" {
+		minDays, err := strconv.Atoi(minStr)
+		if err != nil || minDays < 0 {
+			return 400, `{"error": "min_days debe ser un número mayor o igual a 0"}`
+		}
+		filters.MinDays = minDays
+	}
+	if warehouseStr := request.QueryStringParameters["warehouse_id"]; warehouseStr != "" {
+		warehouseID, err := strconv.ParseInt(warehouseStr, 10, 64)
+		if err != nil {
+			return 400, `{"error": "warehouse_id debe ser un número válido"}`
+		}
+		filters.WarehouseID = &warehouseID
+	}
+
+	items, err := repos.Warehouses.GetWarehouseAging(filters)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al obtener el reporte: %s"}`, err.Error())
+	}
+
+	report := models.AgingReport{
+		GeneratedAt: time.Now().In(guideColombiaLoc),
+		MinDays:     filters.MinDays,
+		Total:       len(items),
+		Items:       items,
+	}
+	if report.Items == nil {
+		report.Items = []models.AgingItem{}
+	}
+	for _, b := range agingBuckets {
+		bucket := models.AgingBucket{Label: b.label, MinDays: b.min}
+		if b.max > 0 {
+			max := b.max
+			bucket.MaxDays = &max
+		}
+		for _, item := range items {
+			if item.DaysInWarehouse >= b.min && (b.max == 0 || item.DaysInWarehouse < b.max) {
+				bucket.Count++
+			}
+		}
+		report.Buckets = append(report.Buckets, bucket)
+	}
+
+	return jsonResult(200, report)
+}
+
+// resolveItemCode resuelve un código de guía o de pieza a la guía y a las
+// piezas que representa: el de la guía son todas sus piezas (o la pieza 0
+// si la guía no tiene detalle de piezas)
+func resolveItemCode(code string) (models.ShippingGuide, []int, string) {
+	ref, piece := code, 0
+	if number, n, ok := models.ParsePieceCode(code); ok {
+		ref, piece = number, n
+	}
+
+	var guide models.ShippingGuide
+	guideID, status, message := ResolveGuideRef(ref)
+	if status != 0 {
+		return guide, nil, responseError(message)
+	}
+
+	guide, err := repos.Guides.GetGuideByID(guideID)
+	if err != nil {
+		return guide, nil, err.Error()
+	}
+
+	if piece > 0 {
+		if _, ok := models.FindPiece(guide.Pieces, piece); !ok {
+			return guide, nil, fmt.Sprintf("La guía no tiene la pieza %d", piece)
+		}
+		return guide, []int{piece}, ""
+	}
+
+	if len(guide.Pieces) == 0 {
+		return guide, []int{0}, ""
+	}
+	pieces := make([]int, len(guide.Pieces))
+	for i, p := range guide.Pieces {
+		pieces[i] = p.PieceNumber
+	}
+	return guide, pieces, ""
+}
+
+// currentBins bins donde están hoy las piezas (para informar el movimiento)
+func currentBins(guideID int64, pieces []int) []string {
+	stock, err := repos.Warehouses.GetStock(models.StockFilters{GuideID: &guideID})
+	if err != nil {
+		return nil
+	}
+
+	var bins []string
+	seen := map[string]bool{}
+	for _, item := range stock {
+		for _, piece := range pieces {
+			if item.PieceNumber == piece && !seen[item.BinCode] {
+				seen[item.BinCode] = true
+				bins = append(bins, item.BinCode)
+			}
+		}
+	}
+	return bins
+}
+
+// withoutWholeGuide omite la pieza 0 (guía sin detalle de piezas) en las respuestas
+func withoutWholeGuide(pieces []int) []int {
+	if len(pieces) == 1 && pieces[0] == 0 {
+		return nil
+	}
+	return pieces
+}
+
+func validateWarehouseCodes(codes []string) (int, string) {
+	if len(codes) == 0 {
+		return 400, `{"error": "codes es requerido"}`
+	}
+	if maxBatch := scanMaxBatch(); len(codes) > maxBatch {
+		return 400, fmt.Sprintf(`{"error": "Se permiten máximo %d códigos por lote"}`, maxBatch)
+	}
+	return 0, ""
+}
+
+func findWarehouse(warehouseID int64) (models.Warehouse, int, string) {
+	warehouse, err := repos.Warehouses.GetWarehouseByID(warehouseID)
+	if err != nil {
+		if err.Error() == "Bodega no encontrada" {
+			return warehouse, 404, `{"error": "Bodega no encontrada"}`
+		}
+		return warehouse, 500, fmt.Sprintf(`{"error": "Error al obtener la bodega: %s"}`, err.Error())
+	}
+	return warehouse, 0, ""
+}
+
+func batchResult(response models.WarehouseBatchResponse) (int, string) {
+	for _, item := range response.Items {
+		if item.Success {
+			response.Succeeded++
+		} else {
+			response.Failed++
+		}
+	}
+	response.Total = len(response.Items)
+	return jsonResult(200, response)
+}
+
+func jsonResult(status int, value interface{}) (int, string) {
+	jsonResponse, err := json.Marshal(value)
+	if err != nil {
+		return 500, fmt.Sprintf(`{"error": "Error al serializar respuesta: %s"}`, err.Error())
+	}
+	return status, string(jsonResponse)
+}
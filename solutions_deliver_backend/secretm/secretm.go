@@ -28,3 +28,23 @@ func GetSecret(nombreSecret string) (models.SecretRDSJson, error) {
 
 	return datosSecret, nil
 }
+
+// GetSecretString retorna el valor de un secreto de texto plano
+func GetSecretString(nombreSecret string) (string, error) {
+	fmt.Println(" > Pido Secreto " + nombreSecret)
+
+	svc := secretsmanager.NewFromConfig(awsgo.Cfg)
+	clave, err := svc.GetSecretValue(awsgo.Ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(nombreSecret),
+	})
+	if err != nil {
+		fmt.Println(err.Error())
+		return "", err
+	}
+	if clave.SecretString == nil {
+		return "", fmt.Errorf("el secreto %s no tiene valor de texto", nombreSecret)
+	}
+
+	fmt.Println(" > Lectura Secret OK " + nombreSecret)
+	return *clave.SecretString, nil
+}
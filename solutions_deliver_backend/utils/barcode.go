@@ -0,0 +1,246 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+)
+
+// Códigos de barras de las guías (Code 128 y QR) con la librería estándar.
+// Los codificadores retornan módulos (true = negro) y los Render* los
+// dibujan como PNG o SVG con su zona de silencio.
+
+// code128Patterns anchos barra/espacio de cada símbolo (0-105) y el de parada (106)
+var code128Patterns = []string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232", "2331112",
+}
+
+const (
+	code128CodeC  = 99
+	code128CodeB  = 100
+	code128StartB = 104
+	code128StartC = 105
+	code128Stop   = 106
+)
+
+// Code128QuietModules zona de silencio mínima a cada lado (10 módulos)
+const Code128QuietModules = 10
+
+// EncodeCode128 codifica texto ASCII imprimible en Code 128. Usa el set B
+// para letras y el set C (pares de dígitos) en tramos de 4 o más dígitos,
+// lo que acorta los números de guía.
+func EncodeCode128(text string) ([]bool, error) {
+	if text == "" {
+		return nil, errors.New("texto vacío para Code 128")
+	}
+	position := 0
+	for _, r := range text {
+		position++
+		if r < 32 || r > 126 {
+			return nil, fmt.Errorf("carácter no soportado en Code 128: %q en la posición %d", r, position)
+		}
+	}
+
+	var values []int
+	set := 0
+	for i := 0; i < len(text); {
+		run := digitRun(text, i)
+		if run >= 4 || (run == len(text) && run >= 2 && run%2 == 0) {
+			// Tramo numérico: si es impar, el primer dígito va en el set B
+			if run%2 == 1 {
+				values = code128Switch(values, &set, code128StartB)
+				values = append(values, int(text[i])-32)
+				i++
+				run--
+			}
+			values = code128Switch(values, &set, code128StartC)
+			for end := i + run; i < end; i += 2 {
+				values = append(values, int(text[i]-'0')*10+int(text[i+1]-'0'))
+			}
+			continue
+		}
+
+		values = code128Switch(values, &set, code128StartB)
+		values = append(values, int(text[i])-32)
+		i++
+	}
+
+	checksum := values[0]
+	for i := 1; i < len(values); i++ {
+		checksum += values[i] * i
+	}
+	values = append(values, checksum%103, code128Stop)
+
+	var modules []bool
+	for _, value := range values {
+		black := true
+		for _, width := range code128Patterns[value] {
+			for w := 0; w < int(width-'0'); w++ {
+				modules = append(modules, black)
+			}
+			black = !black
+		}
+	}
+	return modules, nil
+}
+
+// code128Switch agrega el inicio o el cambio de set (B o C) si hace falta.
+// set es el set actual: 0 antes del inicio.
+func code128Switch(values []int, set *int, start int) []int {
+	switch {
+	case *set == 0:
+		values = append(values, start)
+	case *set == start:
+		return values
+	case start == code128StartC:
+		values = append(values, code128CodeC)
+	default:
+		values = append(values, code128CodeB)
+	}
+	*set = start
+	return values
+}
+
+func digitRun(text string, from int) int {
+	n := 0
+	for from+n < len(text) && text[from+n] >= '0' && text[from+n] <= '9' {
+		n++
+	}
+	return n
+}
+
+// RenderBarsPNG dibuja un código 1D: moduleWidth píxeles por módulo y
+// height píxeles de alto, con la zona de silencio a cada lado
+func RenderBarsPNG(bars []bool, moduleWidth int, height int) ([]byte, error) {
+	if moduleWidth < 1 || height < 1 {
+		return nil, errors.New("tamaño de código de barras inválido")
+	}
+
+	width := (len(bars) + 2*Code128QuietModules) * moduleWidth
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	fillWhite(img)
+
+	for i, black := range bars {
+		if !black {
+			continue
+		}
+		x0 := (i + Code128QuietModules) * moduleWidth
+		for x := x0; x < x0+moduleWidth; x++ {
+			for y := 0; y < height; y++ {
+				img.SetGray(x, y, color.Gray{Y: 0})
+			}
+		}
+	}
+
+	return encodePNG(img)
+}
+
+// RenderBarsSVG dibuja un código 1D en SVG con el texto legible debajo
+func RenderBarsSVG(bars []bool, text string, moduleWidth int, height int) []byte {
+	width := (len(bars) + 2*Code128QuietModules) * moduleWidth
+	textHeight := 0
+	if text != "" {
+		textHeight = moduleWidth*10 + 4
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`,
+		width, height+textHeight, width, height+textHeight)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, width, height+textHeight)
+
+	for i := 0; i < len(bars); {
+		if !bars[i] {
+			i++
+			continue
+		}
+		start := i
+		for i < len(bars) && bars[i] {
+			i++
+		}
+		fmt.Fprintf(&b, "M%d 0h%dv%dh-%dz", (start+Code128QuietModules)*moduleWidth, (i-start)*moduleWidth, height, (i-start)*moduleWidth)
+	}
+	b.WriteString(`"/>`)
+
+	if text != "" {
+		fmt.Fprintf(&b, `<text x="%d" y="%d" font-family="monospace" font-size="%d" text-anchor="middle">%s</text>`,
+			width/2, height+textHeight-2, moduleWidth*10, html.EscapeString(text))
+	}
+	b.WriteString(`</svg>`)
+	return []byte(b.String())
+}
+
+// RenderMatrixPNG dibuja un código 2D (QR): scale píxeles por módulo,
+// con quiet módulos de margen
+func RenderMatrixPNG(matrix [][]bool, scale int, quiet int) ([]byte, error) {
+	if scale < 1 || quiet < 0 {
+		return nil, errors.New("tamaño de código QR inválido")
+	}
+
+	size := (len(matrix) + 2*quiet) * scale
+	img := image.NewGray(image.Rect(0, 0, size, size))
+	fillWhite(img)
+
+	for row := range matrix {
+		for col, black := range matrix[row] {
+			if !black {
+				continue
+			}
+			x0, y0 := (col+quiet)*scale, (row+quiet)*scale
+			for y := y0; y < y0+scale; y++ {
+				for x := x0; x < x0+scale; x++ {
+					img.SetGray(x, y, color.Gray{Y: 0})
+				}
+			}
+		}
+	}
+
+	return encodePNG(img)
+}
+
+// RenderMatrixSVG dibuja un código 2D (QR) en SVG
+func RenderMatrixSVG(matrix [][]bool, scale int, quiet int) []byte {
+	size := (len(matrix) + 2*quiet) * scale
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		size, size, len(matrix)+2*quiet, len(matrix)+2*quiet)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="#fff"/><path fill="#000" d="`)
+	for row := range matrix {
+		for col, black := range matrix[row] {
+			if black {
+				fmt.Fprintf(&b, "M%d %dh1v1h-1z", col+quiet, row+quiet)
+			}
+		}
+	}
+	b.WriteString(`"/></svg>`)
+	return []byte(b.String())
+}
+
+func fillWhite(img *image.Gray) {
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+}
+
+func encodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
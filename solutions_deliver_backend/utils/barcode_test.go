@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// code128Values decodifica los módulos en los valores de cada símbolo
+func code128Values(t *testing.T, modules []bool) []int {
+	t.Helper()
+
+	patterns := map[string]int{}
+	for value, pattern := range code128Patterns {
+		patterns[pattern] = value
+	}
+
+	// Anchos de cada barra y espacio
+	var widths []byte
+	for i := 0; i < len(modules); {
+		start := i
+		for i < len(modules) && modules[i] == modules[start] {
+			i++
+		}
+		widths = append(widths, byte('0'+i-start))
+	}
+
+	var values []int
+	for len(widths) > 0 {
+		n := 6
+		if len(widths) == 7 {
+			n = 7
+		}
+		if len(widths) < n {
+			t.Fatalf("símbolo incompleto: %s", widths)
+		}
+		value, ok := patterns[string(widths[:n])]
+		if !ok {
+			t.Fatalf("patrón desconocido: %s", widths[:n])
+		}
+		values = append(values, value)
+		widths = widths[n:]
+	}
+	return values
+}
+
+func TestEncodeCode128Values(t *testing.T) {
+	tests := []struct {
+		text   string
+		values []int
+	}{
+		// Set B: 104 + 48·1 + 42·2 + 42·3 + 17·4 + 18·5 + 19·6 + 35·7 = 879; 879 % 103 = 55
+		{"PJJ123C", []int{104, 48, 42, 42, 17, 18, 19, 35, 55, 106}},
+		// Solo dígitos pares: set C desde el inicio; 665 % 103 = 47
+		{"12345678", []int{105, 12, 34, 56, 78, 47, 106}},
+		// Número de guía: prefijo en B y consecutivo en C; 650 % 103 = 32
+		{"SD00000018", []int{104, 51, 36, 99, 0, 0, 0, 18, 32, 106}},
+		// Tramo impar: el primer dígito va en B; 1528 % 103 = 86
+		{"SD1234567", []int{104, 51, 36, 17, 99, 23, 45, 67, 86, 106}},
+		// Dígitos sueltos se quedan en B; 225 % 103 = 19
+		{"A12", []int{104, 33, 17, 18, 19, 106}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			modules, err := EncodeCode128(tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := code128Values(t, modules); !reflect.DeepEqual(got, tt.values) {
+				t.Errorf("valores = %v, se esperaba %v", got, tt.values)
+			}
+			if want := 11*(len(tt.values)-1) + 13; len(modules) != want {
+				t.Errorf("módulos = %d, se esperaba %d", len(modules), want)
+			}
+		})
+	}
+}
+
+func TestEncodeCode128Bars(t *testing.T) {
+	// Inicio B, "A", checksum 34 y parada
+	want := "11010010000" + "10100011000" + "10001011000" + "1100011101011"
+
+	modules, err := EncodeCode128("A")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got strings.Builder
+	for _, black := range modules {
+		if black {
+			got.WriteByte('1')
+		} else {
+			got.WriteByte('0')
+		}
+	}
+	if got.String() != want {
+		t.Errorf("barras = %s, se esperaba %s", got.String(), want)
+	}
+}
+
+func TestEncodeCode128Errors(t *testing.T) {
+	tests := []struct {
+		text string
+		err  string
+	}{
+		{"", "texto vacío para Code 128"},
+		{"SDÑ01", "carácter no soportado en Code 128: 'Ñ' en la posición 3"},
+		{"é", "carácter no soportado en Code 128: 'é' en la posición 1"},
+		{"SD\t1", "carácter no soportado en Code 128: '\\t' en la posición 3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			_, err := EncodeCode128(tt.text)
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("error = %v, se esperaba %s", err, tt.err)
+			}
+		})
+	}
+}
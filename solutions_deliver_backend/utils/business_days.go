@@ -0,0 +1,109 @@
+package utils
+
+import "time"
+
+// Días hábiles en Colombia: lunes a viernes, sin festivos nacionales
+// (Ley 51 de 1983). Los festivos "puente" se trasladan al lunes siguiente.
+
+// NextBusinessDay retorna el siguiente día hábil después de t (sin hora)
+func NextBusinessDay(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()).AddDate(0, 0, 1)
+	for !IsBusinessDay(day) {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day
+}
+
+// AddBusinessDays retorna la fecha (sin hora) n días hábiles después de t
+func AddBusinessDays(t time.Time, n int) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	for i := 0; i < n; i++ {
+		day = NextBusinessDay(day)
+	}
+	return day
+}
+
+// IsBusinessDay indica si la fecha es día hábil
+func IsBusinessDay(t time.Time) bool {
+	if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		return false
+	}
+	return !IsHoliday(t)
+}
+
+// IsHoliday indica si la fecha es festivo nacional en Colombia
+func IsHoliday(t time.Time) bool {
+	for _, h := range colombianHolidays(t.Year(), t.Location()) {
+		if h.Year() == t.Year() && h.YearDay() == t.YearDay() {
+			return true
+		}
+	}
+	return false
+}
+
+// colombianHolidays festivos nacionales del año dado
+func colombianHolidays(year int, loc *time.Location) []time.Time {
+	date := func(month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, loc)
+	}
+
+	// Fechas fijas
+	holidays := []time.Time{
+		date(time.January, 1),   // Año nuevo
+		date(time.May, 1),       // Día del trabajo
+		date(time.July, 20),     // Independencia
+		date(time.August, 7),    // Batalla de Boyacá
+		date(time.December, 8),  // Inmaculada Concepción
+		date(time.December, 25), // Navidad
+	}
+
+	// Trasladables al lunes siguiente (Ley Emiliani)
+	for _, h := range []time.Time{
+		date(time.January, 6),   // Reyes Magos
+		date(time.March, 19),    // San José
+		date(time.June, 29),     // San Pedro y San Pablo
+		date(time.August, 15),   // Asunción de la Virgen
+		date(time.October, 12),  // Día de la Raza
+		date(time.November, 1),  // Todos los Santos
+		date(time.November, 11), // Independencia de Cartagena
+	} {
+		holidays = append(holidays, nextMonday(h))
+	}
+
+	// Dependientes de la Pascua
+	easter := easterSunday(year, loc)
+	holidays = append(holidays,
+		easter.AddDate(0, 0, -3),             // Jueves Santo
+		easter.AddDate(0, 0, -2),             // Viernes Santo
+		nextMonday(easter.AddDate(0, 0, 39)), // Ascensión
+		nextMonday(easter.AddDate(0, 0, 60)), // Corpus Christi
+		nextMonday(easter.AddDate(0, 0, 68)), // Sagrado Corazón
+	)
+
+	return holidays
+}
+
+// nextMonday retorna la fecha si es lunes, o el lunes siguiente
+func nextMonday(t time.Time) time.Time {
+	offset := (int(time.Monday) - int(t.Weekday()) + 7) % 7
+	return t.AddDate(0, 0, offset)
+}
+
+// easterSunday domingo de Pascua (algoritmo gregoriano anónimo)
+func easterSunday(year int, loc *time.Location) time.Time {
+	a := year % 19
+	b := year / 100
+	c := year % 100
+	d := b / 4
+	e := b % 4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i := c / 4
+	k := c % 4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, loc)
+}
@@ -0,0 +1,19 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+)
+
+// FormatCOP formatea pesos sin decimales y con punto de miles ($ 120.000)
+func FormatCOP(value float64) string {
+	digits := strconv.FormatInt(int64(value+0.5), 10)
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(d)
+	}
+	return "$ " + b.String()
+}
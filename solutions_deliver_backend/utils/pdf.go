@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Escritura de PDFs simples con la librería estándar: páginas de un solo
+// tamaño con texto (Helvetica / Helvetica-Bold, WinAnsiEncoding), líneas y
+// rectángulos. Suficiente para rótulos; no incrusta fuentes ni imágenes.
+// La salida es determinística (sin fecha de creación).
+
+// PDFPointsPerMM puntos PDF por milímetro
+const PDFPointsPerMM = 72 / 25.4
+
+// PDFDocument documento en construcción
+type PDFDocument struct {
+	width  float64
+	height float64
+	pages  []*PDFPage
+}
+
+// PDFPage página del documento. Las coordenadas son en puntos con origen
+// en la esquina superior izquierda; y crece hacia abajo.
+type PDFPage struct {
+	height  float64
+	content bytes.Buffer
+}
+
+// NewPDFDocument crea un documento con páginas de width x height puntos
+func NewPDFDocument(width, height float64) *PDFDocument {
+	return &PDFDocument{width: width, height: height}
+}
+
+// AddPage agrega una página en blanco
+func (d *PDFDocument) AddPage() *PDFPage {
+	page := &PDFPage{height: d.height}
+	d.pages = append(d.pages, page)
+	return page
+}
+
+// Text escribe una línea de texto. y es la línea base.
+func (p *PDFPage) Text(x, y, size float64, bold bool, text string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(&p.content, "BT /%s %s Tf %s %s Td (%s) Tj ET\n",
+		font, pdfNumber(size), pdfNumber(x), pdfNumber(p.height-y), pdfString(text))
+}
+
+// FillRect dibuja un rectángulo relleno en negro
+func (p *PDFPage) FillRect(x, y, w, h float64) {
+	fmt.Fprintf(&p.content, "%s %s %s %s re f\n",
+		pdfNumber(x), pdfNumber(p.height-y-h), pdfNumber(w), pdfNumber(h))
+}
+
+// StrokeRect dibuja el borde de un rectángulo
+func (p *PDFPage) StrokeRect(x, y, w, h, lineWidth float64) {
+	fmt.Fprintf(&p.content, "%s w %s %s %s %s re S\n",
+		pdfNumber(lineWidth), pdfNumber(x), pdfNumber(p.height-y-h), pdfNumber(w), pdfNumber(h))
+}
+
+// Line dibuja una línea recta
+func (p *PDFPage) Line(x1, y1, x2, y2, lineWidth float64) {
+	fmt.Fprintf(&p.content, "%s w %s %s m %s %s l S\n",
+		pdfNumber(lineWidth), pdfNumber(x1), pdfNumber(p.height-y1), pdfNumber(x2), pdfNumber(p.height-y2))
+}
+
+// Bytes serializa el documento
+func (d *PDFDocument) Bytes() []byte {
+	var buf bytes.Buffer
+	var offsets []int
+
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// 1 catálogo, 2 árbol de páginas, 3 y 4 fuentes; luego página y contenido
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfNumber(d.width), pdfNumber(d.height), 6+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.content.Len(), page.content.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return buf.Bytes()
+}
+
+// pdfNumber formatea un número con máximo 2 decimales
+func pdfNumber(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 2, 64)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" || s == "" {
+		return "0"
+	}
+	return s
+}
+
+// pdfString convierte el texto a WinAnsi (Latin-1 para los caracteres del
+// español) y escapa los delimitadores. Lo que no se puede representar se
+// reemplaza con '?'.
+func pdfString(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(byte(r))
+		case r >= 32 && r < 127:
+			b.WriteByte(byte(r))
+		case r >= 0xA0 && r <= 0xFF:
+			b.WriteByte(byte(r))
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,519 @@
+package utils
+
+import (
+	"errors"
+)
+
+// Codificador QR (ISO/IEC 18004) en modo byte con corrección de errores
+// nivel M, versiones 1 a 10 (hasta 213 bytes): suficiente para números de
+// guía y URLs de rastreo. La máscara se elige por la menor penalización.
+
+// QRQuietModules zona de silencio mínima alrededor del QR (4 módulos)
+const QRQuietModules = 4
+
+// qrVersionM bloques de corrección de errores nivel M por versión
+type qrVersionM struct {
+	ecPerBlock int
+	// groups: cantidad de bloques y codewords de datos por bloque
+	groups [][2]int
+}
+
+var qrVersionsM = []qrVersionM{
+	{},
+	{10, [][2]int{{1, 16}}},
+	{16, [][2]int{{1, 28}}},
+	{26, [][2]int{{1, 44}}},
+	{18, [][2]int{{2, 32}}},
+	{24, [][2]int{{2, 43}}},
+	{16, [][2]int{{4, 27}}},
+	{18, [][2]int{{4, 31}}},
+	{22, [][2]int{{2, 38}, {2, 39}}},
+	{22, [][2]int{{3, 36}, {2, 37}}},
+	{26, [][2]int{{4, 43}, {1, 44}}},
+}
+
+var qrAlignmentPositions = [][]int{
+	{}, {}, {6, 18}, {6, 22}, {6, 26}, {6, 30}, {6, 34},
+	{6, 22, 38}, {6, 24, 42}, {6, 26, 46}, {6, 28, 50},
+}
+
+func (v qrVersionM) dataCodewords() int {
+	total := 0
+	for _, g := range v.groups {
+		total += g[0] * g[1]
+	}
+	return total
+}
+
+// EncodeQR codifica el texto como QR y retorna la matriz de módulos
+func EncodeQR(text string) ([][]bool, error) {
+	data := []byte(text)
+	if len(data) == 0 {
+		return nil, errors.New("texto vacío para QR")
+	}
+
+	version := 0
+	for v := 1; v < len(qrVersionsM); v++ {
+		countBits := 8
+		if v >= 10 {
+			countBits = 16
+		}
+		if 4+countBits+8*len(data) <= 8*qrVersionsM[v].dataCodewords() {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, errors.New("texto demasiado largo para QR")
+	}
+
+	codewords := qrInterleave(version, qrDataCodewords(version, data))
+
+	qr := newQRMatrix(version)
+	qr.drawFunctionPatterns()
+	qr.drawCodewords(codewords)
+
+	bestMask, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		candidate := qr.clone()
+		candidate.applyMask(mask)
+		candidate.drawFormatBits(mask)
+		if penalty := candidate.penalty(); bestPenalty < 0 || penalty < bestPenalty {
+			bestMask, bestPenalty = mask, penalty
+		}
+	}
+
+	qr.applyMask(bestMask)
+	qr.drawFormatBits(bestMask)
+	return qr.modules, nil
+}
+
+// qrDataCodewords arma el flujo de bits (modo, longitud, datos, terminador
+// y relleno) y lo retorna en codewords
+func qrDataCodewords(version int, data []byte) []byte {
+	capacity := qrVersionsM[version].dataCodewords()
+
+	var bits qrBits
+	bits.append(0x4, 4) // modo byte
+	if version >= 10 {
+		bits.append(len(data), 16)
+	} else {
+		bits.append(len(data), 8)
+	}
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+
+	// Terminador (hasta 4 ceros) y alineación a byte
+	for i := 0; i < 4 && len(bits) < capacity*8; i++ {
+		bits = append(bits, false)
+	}
+	for len(bits)%8 != 0 {
+		bits = append(bits, false)
+	}
+
+	codewords := make([]byte, 0, capacity)
+	for i := 0; i < len(bits); i += 8 {
+		var b byte
+		for j := 0; j < 8; j++ {
+			if bits[i+j] {
+				b |= 1 << (7 - j)
+			}
+		}
+		codewords = append(codewords, b)
+	}
+	for pad := byte(0xEC); len(codewords) < capacity; pad ^= 0xEC ^ 0x11 {
+		codewords = append(codewords, pad)
+	}
+	return codewords
+}
+
+// qrInterleave divide en bloques, calcula la corrección de errores de cada
+// bloque y entrelaza datos y corrección
+func qrInterleave(version int, data []byte) []byte {
+	info := qrVersionsM[version]
+
+	var dataBlocks, ecBlocks [][]byte
+	offset := 0
+	for _, g := range info.groups {
+		for i := 0; i < g[0]; i++ {
+			block := data[offset : offset+g[1]]
+			offset += g[1]
+			dataBlocks = append(dataBlocks, block)
+			ecBlocks = append(ecBlocks, reedSolomon(block, info.ecPerBlock))
+		}
+	}
+
+	var result []byte
+	for i := 0; ; i++ {
+		added := false
+		for _, block := range dataBlocks {
+			if i < len(block) {
+				result = append(result, block[i])
+				added = true
+			}
+		}
+		if !added {
+			break
+		}
+	}
+	for i := 0; i < info.ecPerBlock; i++ {
+		for _, block := range ecBlocks {
+			result = append(result, block[i])
+		}
+	}
+	return result
+}
+
+// ==========================================
+// Reed-Solomon sobre GF(256), polinomio 0x11D
+// ==========================================
+
+var gfExp, gfLog [512]int
+
+func init() {
+	x := 1
+	for i := 0; i < 255; i++ {
+		gfExp[i] = x
+		gfLog[x] = i
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11D
+		}
+	}
+	for i := 255; i < 512; i++ {
+		gfExp[i] = gfExp[i-255]
+	}
+}
+
+func gfMul(a, b int) int {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[gfLog[a]+gfLog[b]]
+}
+
+// reedSolomon retorna los n codewords de corrección de errores de data
+func reedSolomon(data []byte, n int) []byte {
+	// Polinomio generador: (x - α^0)(x - α^1)...(x - α^(n-1))
+	generator := []int{1}
+	for i := 0; i < n; i++ {
+		next := make([]int, len(generator)+1)
+		for j, coef := range generator {
+			next[j] ^= coef
+			next[j+1] ^= gfMul(coef, gfExp[i])
+		}
+		generator = next
+	}
+
+	remainder := make([]int, n)
+	for _, b := range data {
+		factor := int(b) ^ remainder[0]
+		copy(remainder, remainder[1:])
+		remainder[n-1] = 0
+		for j := 0; j < n; j++ {
+			remainder[j] ^= gfMul(generator[j+1], factor)
+		}
+	}
+
+	ec := make([]byte, n)
+	for i, v := range remainder {
+		ec[i] = byte(v)
+	}
+	return ec
+}
+
+// ==========================================
+// Matriz
+// ==========================================
+
+type qrBits []bool
+
+func (b *qrBits) append(value int, length int) {
+	for i := length - 1; i >= 0; i-- {
+		*b = append(*b, (value>>i)&1 == 1)
+	}
+}
+
+type qrMatrix struct {
+	version  int
+	size     int
+	modules  [][]bool
+	function [][]bool // módulos reservados (patrones, formato, versión)
+}
+
+func newQRMatrix(version int) *qrMatrix {
+	size := 17 + 4*version
+	qr := &qrMatrix{version: version, size: size}
+	qr.modules = make([][]bool, size)
+	qr.function = make([][]bool, size)
+	for i := range qr.modules {
+		qr.modules[i] = make([]bool, size)
+		qr.function[i] = make([]bool, size)
+	}
+	return qr
+}
+
+func (qr *qrMatrix) clone() *qrMatrix {
+	c := newQRMatrix(qr.version)
+	for i := range qr.modules {
+		copy(c.modules[i], qr.modules[i])
+		copy(c.function[i], qr.function[i])
+	}
+	return c
+}
+
+func (qr *qrMatrix) setFunction(row, col int, black bool) {
+	qr.modules[row][col] = black
+	qr.function[row][col] = true
+}
+
+func (qr *qrMatrix) drawFunctionPatterns() {
+	// Patrones de temporización
+	for i := 0; i < qr.size; i++ {
+		qr.setFunction(6, i, i%2 == 0)
+		qr.setFunction(i, 6, i%2 == 0)
+	}
+
+	// Patrones de posición con su separador
+	qr.drawFinder(3, 3)
+	qr.drawFinder(3, qr.size-4)
+	qr.drawFinder(qr.size-4, 3)
+
+	// Patrones de alineación (excepto donde chocan con los de posición)
+	positions := qrAlignmentPositions[qr.version]
+	last := len(positions) - 1
+	for i, row := range positions {
+		for j, col := range positions {
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			for dr := -2; dr <= 2; dr++ {
+				for dc := -2; dc <= 2; dc++ {
+					ring := max(abs(dr), abs(dc))
+					qr.setFunction(row+dr, col+dc, ring != 1)
+				}
+			}
+		}
+	}
+
+	// Reserva del formato (se dibuja con la máscara) y módulo oscuro
+	qr.drawFormatBits(0)
+	qr.setFunction(qr.size-8, 8, true)
+
+	// Información de versión (7 en adelante)
+	if qr.version >= 7 {
+		bits := qr.version<<12 | bchRemainder(qr.version, 0x1F25, 12)
+		for i := 0; i < 18; i++ {
+			black := (bits>>i)&1 == 1
+			a, b := qr.size-11+i%3, i/3
+			qr.setFunction(a, b, black)
+			qr.setFunction(b, a, black)
+		}
+	}
+}
+
+func (qr *qrMatrix) drawFinder(centerRow, centerCol int) {
+	for dr := -4; dr <= 4; dr++ {
+		for dc := -4; dc <= 4; dc++ {
+			row, col := centerRow+dr, centerCol+dc
+			if row < 0 || row >= qr.size || col < 0 || col >= qr.size {
+				continue
+			}
+			ring := max(abs(dr), abs(dc))
+			qr.setFunction(row, col, ring != 2 && ring != 4)
+		}
+	}
+}
+
+// drawFormatBits escribe el nivel de corrección (M = 00) y la máscara en
+// las dos copias de la información de formato
+func (qr *qrMatrix) drawFormatBits(mask int) {
+	data := 0<<3 | mask
+	bits := (data<<10 | bchRemainder(data, 0x537, 10)) ^ 0x5412
+
+	bit := func(i int) bool { return (bits>>i)&1 == 1 }
+
+	// Primera copia, alrededor del patrón superior izquierdo
+	for i := 0; i <= 5; i++ {
+		qr.setFunction(i, 8, bit(i))
+	}
+	qr.setFunction(7, 8, bit(6))
+	qr.setFunction(8, 8, bit(7))
+	qr.setFunction(8, 7, bit(8))
+	for i := 9; i < 15; i++ {
+		qr.setFunction(8, 14-i, bit(i))
+	}
+
+	// Segunda copia, repartida entre los otros dos patrones
+	for i := 0; i < 8; i++ {
+		qr.setFunction(8, qr.size-1-i, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		qr.setFunction(qr.size-15+i, 8, bit(i))
+	}
+	qr.setFunction(qr.size-8, 8, true)
+}
+
+// bchRemainder residuo de value·x^degree módulo el polinomio generador
+func bchRemainder(value int, generator int, degree int) int {
+	rem := value << degree
+	for i := bitLength(rem) - 1; i >= degree; i-- {
+		if (rem>>i)&1 == 1 {
+			rem ^= generator << (i - degree)
+		}
+	}
+	return rem
+}
+
+func bitLength(v int) int {
+	n := 0
+	for v > 0 {
+		n++
+		v >>= 1
+	}
+	return n
+}
+
+// drawCodewords coloca los codewords en zigzag de a dos columnas, de
+// derecha a izquierda, saltando la columna de temporización
+func (qr *qrMatrix) drawCodewords(codewords []byte) {
+	total := len(codewords) * 8
+	i := 0
+	for right := qr.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < qr.size; vert++ {
+			for j := 0; j < 2; j++ {
+				col := right - j
+				upward := ((right + 1) & 2) == 0
+				row := vert
+				if upward {
+					row = qr.size - 1 - vert
+				}
+				if qr.function[row][col] {
+					continue
+				}
+				if i < total {
+					qr.modules[row][col] = (codewords[i/8]>>(7-i%8))&1 == 1
+					i++
+				}
+				// Los bits restantes (remainder bits) quedan en blanco
+			}
+		}
+	}
+}
+
+func (qr *qrMatrix) applyMask(mask int) {
+	for row := 0; row < qr.size; row++ {
+		for col := 0; col < qr.size; col++ {
+			if qr.function[row][col] {
+				continue
+			}
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (row+col)%2 == 0
+			case 1:
+				invert = row%2 == 0
+			case 2:
+				invert = col%3 == 0
+			case 3:
+				invert = (row+col)%3 == 0
+			case 4:
+				invert = (row/2+col/3)%2 == 0
+			case 5:
+				invert = row*col%2+row*col%3 == 0
+			case 6:
+				invert = (row*col%2+row*col%3)%2 == 0
+			case 7:
+				invert = ((row+col)%2+row*col%3)%2 == 0
+			}
+			if invert {
+				qr.modules[row][col] = !qr.modules[row][col]
+			}
+		}
+	}
+}
+
+// penalty puntaje de las cuatro reglas de la norma; menor es mejor
+func (qr *qrMatrix) penalty() int {
+	score := 0
+	at := func(row, col int, horizontal bool) bool {
+		if horizontal {
+			return qr.modules[row][col]
+		}
+		return qr.modules[col][row]
+	}
+
+	for _, horizontal := range []bool{true, false} {
+		for line := 0; line < qr.size; line++ {
+			// Regla 1: 5 o más módulos seguidos del mismo color
+			run := 1
+			for i := 1; i < qr.size; i++ {
+				if at(line, i, horizontal) == at(line, i-1, horizontal) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					score += run - 2
+				}
+				run = 1
+			}
+			if run >= 5 {
+				score += run - 2
+			}
+
+			// Regla 3: patrón 1:1:3:1:1 con 4 blancos a un lado
+			for i := 0; i+11 <= qr.size; i++ {
+				pattern := []bool{true, false, true, true, true, false, true, false, false, false, false}
+				forward, backward := true, true
+				for k := 0; k < 11; k++ {
+					if at(line, i+k, horizontal) != pattern[k] {
+						forward = false
+					}
+					if at(line, i+k, horizontal) != pattern[10-k] {
+						backward = false
+					}
+				}
+				if forward {
+					score += 40
+				}
+				if backward {
+					score += 40
+				}
+			}
+		}
+	}
+
+	// Regla 2: bloques de 2x2 del mismo color
+	dark := 0
+	for row := 0; row < qr.size; row++ {
+		for col := 0; col < qr.size; col++ {
+			if qr.modules[row][col] {
+				dark++
+			}
+			if row+1 < qr.size && col+1 < qr.size {
+				c := qr.modules[row][col]
+				if qr.modules[row][col+1] == c && qr.modules[row+1][col] == c && qr.modules[row+1][col+1] == c {
+					score += 3
+				}
+			}
+		}
+	}
+
+	// Regla 4: proporción de módulos oscuros lejos del 50%
+	total := qr.size * qr.size
+	deviation := abs(dark*20-total*10) / total
+	score += deviation * 10
+
+	return score
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,212 @@
+package utils
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// Información de formato nivel M por máscara (ISO/IEC 18004, tabla C.1)
+var qrFormatBitsM = []int{
+	0x5412, 0x5125, 0x5E7C, 0x5B4B, 0x45F9, 0x40CE, 0x4F97, 0x4AA0,
+}
+
+// Información de versión 7 a 10 (ISO/IEC 18004, tabla D.1)
+var qrVersionBits = map[int]int{
+	7: 0x07C94, 8: 0x085BC, 9: 0x09A99, 10: 0x0A4D3,
+}
+
+// readFormatBits lee las dos copias de la información de formato
+func readFormatBits(matrix [][]bool) (int, int) {
+	size := len(matrix)
+	var first, second int
+	set := func(bits *int, i int, black bool) {
+		if black {
+			*bits |= 1 << i
+		}
+	}
+
+	for i := 0; i <= 5; i++ {
+		set(&first, i, matrix[i][8])
+	}
+	set(&first, 6, matrix[7][8])
+	set(&first, 7, matrix[8][8])
+	set(&first, 8, matrix[8][7])
+	for i := 9; i < 15; i++ {
+		set(&first, i, matrix[8][14-i])
+	}
+
+	for i := 0; i < 8; i++ {
+		set(&second, i, matrix[8][size-1-i])
+	}
+	for i := 8; i < 15; i++ {
+		set(&second, i, matrix[size-15+i][8])
+	}
+	return first, second
+}
+
+// qrText URL de rastreo de n bytes
+func qrText(n int) string {
+	prefix := "https://sd.co/t/"
+	return prefix + strings.Repeat("9", n-len(prefix))
+}
+
+func TestFormatBitsM(t *testing.T) {
+	for mask, want := range qrFormatBitsM {
+		data := 0<<3 | mask
+		if got := (data<<10 | bchRemainder(data, 0x537, 10)) ^ 0x5412; got != want {
+			t.Errorf("máscara %d: formato = %015b, se esperaba %015b", mask, got, want)
+		}
+	}
+}
+
+func TestVersionBits(t *testing.T) {
+	for version, want := range qrVersionBits {
+		if got := version<<12 | bchRemainder(version, 0x1F25, 12); got != want {
+			t.Errorf("versión %d: %018b, se esperaba %018b", version, got, want)
+		}
+	}
+}
+
+func TestReedSolomon(t *testing.T) {
+	// "HELLO WORLD" 1-M en modo alfanumérico
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+
+	if got := reedSolomon(data, 10); !bytes.Equal(got, want) {
+		t.Errorf("corrección = %v, se esperaba %v", got, want)
+	}
+}
+
+func TestQRDataCodewords(t *testing.T) {
+	// Modo byte 0100, longitud 5, "hello", terminador y relleno EC/11
+	want := []byte{0x40, 0x56, 0x86, 0x56, 0xC6, 0xC6, 0xF0, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC}
+
+	if got := qrDataCodewords(1, []byte("hello")); !bytes.Equal(got, want) {
+		t.Errorf("codewords = % X, se esperaba % X", got, want)
+	}
+}
+
+func TestEncodeQRFunctionPatterns(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		version int
+	}{
+		{"número de guía", "SD00000018", 1},
+		{"versión 2", qrText(20), 2},
+		{"versión 7", qrText(110), 7},
+		{"versión 10", qrText(200), 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matrix, err := EncodeQR(tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			size := len(matrix)
+			if want := 17 + 4*tt.version; size != want {
+				t.Fatalf("tamaño = %d, se esperaba %d (versión %d)", size, want, tt.version)
+			}
+
+			first, second := readFormatBits(matrix)
+			if first != second {
+				t.Errorf("copias de formato distintas: %015b y %015b", first, second)
+			}
+			valid := false
+			for _, bits := range qrFormatBitsM {
+				valid = valid || first == bits
+			}
+			if !valid {
+				t.Errorf("formato %015b no es de nivel M", first)
+			}
+			if !matrix[size-8][8] {
+				t.Errorf("falta el módulo oscuro")
+			}
+
+			if tt.version < 7 {
+				return
+			}
+			want := qrVersionBits[tt.version]
+			for i := 0; i < 18; i++ {
+				black := (want>>i)&1 == 1
+				a, b := size-11+i%3, i/3
+				if matrix[a][b] != black || matrix[b][a] != black {
+					t.Fatalf("bit %d de versión incorrecto", i)
+				}
+			}
+		})
+	}
+}
+
+// Quitando la máscara y leyendo en zigzag se recuperan los codewords
+func TestEncodeQRCodewords(t *testing.T) {
+	text := "SD00000018"
+	matrix, err := EncodeQR(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	format, _ := readFormatBits(matrix)
+	mask := -1
+	for m, bits := range qrFormatBitsM {
+		if bits == format {
+			mask = m
+		}
+	}
+	if mask < 0 {
+		t.Fatalf("formato %015b desconocido", format)
+	}
+
+	qr := newQRMatrix(1)
+	for i := range matrix {
+		copy(qr.modules[i], matrix[i])
+	}
+	qr.drawFunctionPatterns()
+	for i := range matrix {
+		copy(qr.modules[i], matrix[i])
+	}
+	qr.applyMask(mask)
+
+	want := qrInterleave(1, qrDataCodewords(1, []byte(text)))
+	got := make([]byte, len(want))
+	i := 0
+	for right := qr.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < qr.size; vert++ {
+			for j := 0; j < 2; j++ {
+				col := right - j
+				row := vert
+				if ((right + 1) & 2) == 0 {
+					row = qr.size - 1 - vert
+				}
+				if qr.function[row][col] || i >= len(want)*8 {
+					continue
+				}
+				if qr.modules[row][col] {
+					got[i/8] |= 1 << (7 - i%8)
+				}
+				i++
+			}
+		}
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("codewords = % X, se esperaba % X", got, want)
+	}
+}
+
+func TestEncodeQRErrors(t *testing.T) {
+	if _, err := EncodeQR(""); err == nil {
+		t.Error("se esperaba error con texto vacío")
+	}
+	if _, err := EncodeQR(strings.Repeat("x", 214)); err == nil {
+		t.Error("se esperaba error con más de 213 bytes")
+	}
+	if _, err := EncodeQR(strings.Repeat("x", 213)); err != nil {
+		t.Errorf("213 bytes: %v", err)
+	}
+}
@@ -32,6 +32,31 @@ type PDFGenerationResponse struct {
 func GenerateCashClosePDFWithLambda(close models.CashClose, details []models.CashCloseDetail) (string, string, error) {
 	fmt.Println("GenerateCashClosePDFWithLambda - Llamando a Lambda de Node.js")
 
+	// Preparar payload
+	payload := map[string]interface{}{
+		"type":       "CASH_CLOSE",
+		"close_data": close,
+		"details":    details,
+	}
+
+	return invokePDFLambda(payload)
+}
+
+// GenerateGuidePDFWithLambda genera el PDF de una guía ya creada.
+// La Lambda lee la guía de la BD, sube el PDF a S3 y retorna URL y llave.
+func GenerateGuidePDFWithLambda(guideID int64) (string, string, error) {
+	fmt.Printf("GenerateGuidePDFWithLambda -> GuideID: %d\n", guideID)
+
+	payload := map[string]interface{}{
+		"type":     "GUIDE_PDF",
+		"guide_id": guideID,
+	}
+
+	return invokePDFLambda(payload)
+}
+
+// invokePDFLambda invoca la Lambda de Node.js de forma sincrónica y retorna URL y llave S3
+func invokePDFLambda(payload map[string]interface{}) (string, string, error) {
 	lambdaFunctionName := os.Getenv("PDF_LAMBDA_FUNCTION")
 	if lambdaFunctionName == "" {
 		return "", "", fmt.Errorf("PDF_LAMBDA_FUNCTION environment variable not set")
@@ -45,13 +70,6 @@ func GenerateCashClosePDFWithLambda(close models.CashClose, details []models.Cas
 
 	lambdaClient := lambda.NewFromConfig(cfg)
 
-	// Preparar payload
-	payload := map[string]interface{}{
-		"type":       "CASH_CLOSE",
-		"close_data": close,
-		"details":    details,
-	}
-
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
 		return "", "", fmt.Errorf("error marshaling payload: %w", err)
@@ -0,0 +1,281 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Lectura y escritura de hojas de cálculo simples (una hoja, solo valores)
+// para importar y exportar tarifas. XLSX se maneja con la librería estándar:
+// es un zip con XML (SpreadsheetML), sin estilos ni fórmulas.
+
+// xlsxMaxColumns columnas de una hoja de Excel (A..XFD)
+const xlsxMaxColumns = 16384
+
+// xlsxMaxEntrySize tamaño máximo descomprimido de cada archivo del zip;
+// evita que un zip bomb agote la memoria de la Lambda
+var xlsxMaxEntrySize int64 = 20 << 20
+
+// ReadCSV lee un CSV y retorna sus filas. Detecta ';' como separador
+// (formato de Excel en español) y descarta el BOM de UTF-8.
+func ReadCSV(data []byte) ([][]string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	firstLine := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		firstLine = data[:i]
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("CSV inválido: %v", err)
+	}
+	return rows, nil
+}
+
+// WriteCSV escribe las filas como CSV separado por comas
+func WriteCSV(rows [][]string) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxStringItem `xml:"si"`
+}
+
+type xlsxStringItem struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (si xlsxStringItem) value() string {
+	if len(si.Runs) == 0 {
+		return si.Text
+	}
+	var sb strings.Builder
+	for _, run := range si.Runs {
+		sb.WriteString(run.Text)
+	}
+	return sb.String()
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		Cells []struct {
+			Ref    string         `xml:"r,attr"`
+			Type   string         `xml:"t,attr"`
+			Value  string         `xml:"v"`
+			Inline xlsxStringItem `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// ReadXLSX lee la primera hoja de un archivo XLSX y retorna sus filas
+func ReadXLSX(data []byte) ([][]string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("XLSX inválido: %v", err)
+	}
+
+	files := map[string]*zip.File{}
+	var sheets []string
+	for _, f := range archive.File {
+		files[f.Name] = f
+		if path.Dir(f.Name) == "xl/worksheets" && strings.HasSuffix(f.Name, ".xml") {
+			sheets = append(sheets, f.Name)
+		}
+	}
+	if len(sheets) == 0 {
+		return nil, fmt.Errorf("XLSX inválido: no tiene hojas")
+	}
+
+	// sheet1.xml es la primera hoja en los archivos de Excel y LibreOffice
+	sheetName := "xl/worksheets/sheet1.xml"
+	if files[sheetName] == nil {
+		sort.Strings(sheets)
+		sheetName = sheets[0]
+	}
+
+	var shared xlsxSharedStrings
+	if f := files["xl/sharedStrings.xml"]; f != nil {
+		if err := decodeZipXML(f, &shared); err != nil {
+			return nil, fmt.Errorf("XLSX inválido: %v", err)
+		}
+	}
+
+	var sheet xlsxWorksheet
+	if err := decodeZipXML(files[sheetName], &sheet); err != nil {
+		return nil, fmt.Errorf("XLSX inválido: %v", err)
+	}
+
+	rows := make([][]string, 0, len(sheet.Rows))
+	for _, row := range sheet.Rows {
+		var values []string
+		for i, cell := range row.Cells {
+			col := i
+			if cell.Ref != "" {
+				col, err = xlsxColumnIndex(cell.Ref)
+				if err != nil {
+					return nil, fmt.Errorf("XLSX inválido: %v", err)
+				}
+			}
+			if col >= xlsxMaxColumns {
+				return nil, fmt.Errorf("XLSX inválido: la hoja excede %d columnas", xlsxMaxColumns)
+			}
+			for len(values) <= col {
+				values = append(values, "")
+			}
+
+			switch cell.Type {
+			case "s":
+				idx, err := strconv.Atoi(cell.Value)
+				if err != nil || idx < 0 || idx >= len(shared.Items) {
+					return nil, fmt.Errorf("XLSX inválido: celda %s con texto inexistente", cell.Ref)
+				}
+				values[col] = shared.Items[idx].value()
+			case "inlineStr":
+				values[col] = cell.Inline.value()
+			default:
+				values[col] = cell.Value
+			}
+		}
+		rows = append(rows, values)
+	}
+
+	return rows, nil
+}
+
+// decodeZipXML decodifica un archivo del zip, leyendo como máximo
+// xlsxMaxEntrySize bytes descomprimidos
+func decodeZipXML(f *zip.File, target interface{}) error {
+	reader, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(io.LimitReader(reader, xlsxMaxEntrySize+1))
+	if err != nil {
+		return err
+	}
+	if int64(len(data)) > xlsxMaxEntrySize {
+		return fmt.Errorf("%s excede %d MB descomprimido", f.Name, xlsxMaxEntrySize>>20)
+	}
+	return xml.Unmarshal(data, target)
+}
+
+// xlsxColumnIndex convierte la referencia de celda ("C7") en índice de columna (2)
+func xlsxColumnIndex(ref string) (int, error) {
+	col := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A'+1)
+		if col > xlsxMaxColumns {
+			return 0, fmt.Errorf("celda %s fuera de rango", ref)
+		}
+	}
+	if col == 0 {
+		return 0, fmt.Errorf("referencia de celda inválida '%s'", ref)
+	}
+	return col - 1, nil
+}
+
+// xlsxColumnName convierte un índice de columna (2) en letras ("C")
+func xlsxColumnName(col int) string {
+	name := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return name
+}
+
+// WriteXLSX genera un XLSX de una hoja. Las celdas que son números se
+// guardan como numéricas para que Excel pueda operar con ellas.
+func WriteXLSX(sheetName string, rows [][]string) ([]byte, error) {
+	var sheet bytes.Buffer
+	sheet.WriteString(xml.Header)
+	sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for r, row := range rows {
+		fmt.Fprintf(&sheet, `<row r="%d">`, r+1)
+		for c, value := range row {
+			ref := xlsxColumnName(c) + strconv.Itoa(r+1)
+			if _, err := strconv.ParseFloat(value, 64); err == nil && r > 0 {
+				fmt.Fprintf(&sheet, `<c r="%s"><v>%s</v></c>`, ref, value)
+				continue
+			}
+			fmt.Fprintf(&sheet, `<c r="%s" t="inlineStr"><is><t>`, ref)
+			if err := xml.EscapeText(&sheet, []byte(value)); err != nil {
+				return nil, err
+			}
+			sheet.WriteString(`</t></is></c>`)
+		}
+		sheet.WriteString(`</row>`)
+	}
+	sheet.WriteString(`</sheetData></worksheet>`)
+
+	var escapedName bytes.Buffer
+	if err := xml.EscapeText(&escapedName, []byte(sheetName)); err != nil {
+		return nil, err
+	}
+
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+			`</Types>`},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="` + escapedName.String() + `" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+			`</Relationships>`},
+		{"xl/worksheets/sheet1.xml", sheet.String()},
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, part := range parts {
+		w, err := archive.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(w, part.content); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
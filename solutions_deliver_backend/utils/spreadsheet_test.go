@@ -0,0 +1,132 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// buildXLSX arma un XLSX mínimo con la hoja y los textos compartidos dados
+func buildXLSX(t *testing.T, sheet string, shared string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	files := map[string]string{"xl/worksheets/sheet1.xml": sheet}
+	if shared != "" {
+		files["xl/sharedStrings.xml"] = shared
+	}
+	for name, content := range files {
+		w, err := archive.Create(name)
+		if err != nil {
+			t.Fatalf("no se pudo crear %s: %v", name, err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("no se pudo cerrar el zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func sheetXML(cells string) string {
+	return `<worksheet><sheetData><row>` + cells + `</row></sheetData></worksheet>`
+}
+
+func TestXLSXRoundTrip(t *testing.T) {
+	rows := [][]string{
+		{"origen", "destino", "valor"},
+		{"Bogotá", "Medellín", "12500"},
+		{"Cali", "", "8000.5"},
+	}
+
+	data, err := WriteXLSX("Tarifas", rows)
+	if err != nil {
+		t.Fatalf("WriteXLSX: %v", err)
+	}
+	got, err := ReadXLSX(data)
+	if err != nil {
+		t.Fatalf("ReadXLSX: %v", err)
+	}
+	if !reflect.DeepEqual(got, rows) {
+		t.Errorf("filas = %v, se esperaba %v", got, rows)
+	}
+}
+
+func TestReadXLSX(t *testing.T) {
+	shared := `<sst><si><t>hola</t></si><si><r><t>ho</t></r><r><t>la</t></r></si></sst>`
+
+	tests := []struct {
+		name   string
+		sheet  string
+		shared string
+		want   [][]string
+		err    string
+	}{
+		{"textos compartidos", sheetXML(`<c r="A1" t="s"><v>0</v></c><c r="C1" t="s"><v>1</v></c>`), shared,
+			[][]string{{"hola", "", "hola"}}, ""},
+		{"texto en línea y número", sheetXML(`<c r="B1" t="inlineStr"><is><t>x</t></is></c><c r="C1"><v>42</v></c>`), "",
+			[][]string{{"", "x", "42"}}, ""},
+		{"sin referencia", sheetXML(`<c><v>1</v></c><c><v>2</v></c>`), "",
+			[][]string{{"1", "2"}}, ""},
+		{"referencia sin letras", sheetXML(`<c r="5"><v>1</v></c>`), "",
+			nil, "XLSX inválido: referencia de celda inválida '5'"},
+		{"referencia en minúsculas", sheetXML(`<c r="a1"><v>1</v></c>`), "",
+			nil, "XLSX inválido: referencia de celda inválida 'a1'"},
+		{"columna fuera de rango", sheetXML(`<c r="ZZZZZZ1"><v>1</v></c>`), "",
+			nil, "XLSX inválido: celda ZZZZZZ1 fuera de rango"},
+		{"columna más allá de XFD", sheetXML(`<c r="XFE1"><v>1</v></c>`), "",
+			nil, "XLSX inválido: celda XFE1 fuera de rango"},
+		{"texto compartido inexistente", sheetXML(`<c r="A1" t="s"><v>9</v></c>`), shared,
+			nil, "XLSX inválido: celda A1 con texto inexistente"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadXLSX(buildXLSX(t, tt.sheet, tt.shared))
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("error = %v, se esperaba %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error inesperado: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filas = %v, se esperaba %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadXLSXEntrySizeLimit(t *testing.T) {
+	previous := xlsxMaxEntrySize
+	xlsxMaxEntrySize = 1 << 20
+	defer func() { xlsxMaxEntrySize = previous }()
+
+	// Unos pocos KB comprimidos que se expanden a más de 1 MB
+	padding := strings.Repeat(" ", 2<<20)
+	data := buildXLSX(t, `<worksheet>`+padding+`<sheetData></sheetData></worksheet>`, "")
+	if len(data) > 64<<10 {
+		t.Fatalf("el zip de prueba debería ser pequeño: %d bytes", len(data))
+	}
+
+	_, err := ReadXLSX(data)
+	want := "XLSX inválido: xl/worksheets/sheet1.xml excede 1 MB descomprimido"
+	if err == nil || err.Error() != want {
+		t.Fatalf("error = %v, se esperaba %q", err, want)
+	}
+}
+
+func TestXLSXColumnName(t *testing.T) {
+	for col, name := range map[int]string{0: "A", 25: "Z", 26: "AA", 701: "ZZ", 16383: "XFD"} {
+		if got := xlsxColumnName(col); got != name {
+			t.Errorf("xlsxColumnName(%d) = %s, se esperaba %s", col, got, name)
+		}
+		if got, err := xlsxColumnIndex(name + "1"); err != nil || got != col {
+			t.Errorf("xlsxColumnIndex(%s1) = %d, %v; se esperaba %d", name, got, err, col)
+		}
+	}
+}
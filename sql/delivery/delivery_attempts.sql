@@ -0,0 +1,107 @@
+-- =====================================================
+-- INTENTOS DE ENTREGA Y DEVOLUCIÓN AL REMITENTE
+-- Cada intento fallido cierra la asignación DELIVERY en curso y
+-- programa un reintento para el siguiente día hábil. Al agotar los
+-- intentos (MAX_DELIVERY_ATTEMPTS) se crea una asignación RETURN
+-- hacia el remitente.
+-- =====================================================
+
+ALTER TABLE delivery_assignments
+  MODIFY assignment_type ENUM('PICKUP', 'DELIVERY', 'RETURN') NOT NULL,
+  ADD COLUMN attempt_number INT NOT NULL DEFAULT 1 AFTER status,
+  ADD COLUMN scheduled_date DATE NULL AFTER attempt_number;
+
+-- =====================================================
+-- TABLA: delivery_attempts
+-- Registro de cada intento de entrega fallido
+-- =====================================================
+CREATE TABLE delivery_attempts (
+  attempt_id BIGINT AUTO_INCREMENT,
+  assignment_id BIGINT NOT NULL,
+  guide_id BIGINT NOT NULL,
+
+  attempt_number INT NOT NULL,
+
+  reason_code VARCHAR(40) NOT NULL,
+  notes TEXT,
+
+  -- Ubicación del entregador al registrar el intento (opcional)
+  latitude DECIMAL(10, 7) NULL,
+  longitude DECIMAL(10, 7) NULL,
+
+  attempted_by VARCHAR(255) NOT NULL,
+  attempted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+  CONSTRAINT pk_delivery_attempts PRIMARY KEY (attempt_id),
+
+  CONSTRAINT fk_attempt_assignment
+    FOREIGN KEY (assignment_id)
+    REFERENCES delivery_assignments(assignment_id)
+    ON DELETE CASCADE,
+
+  CONSTRAINT fk_attempt_guide
+    FOREIGN KEY (guide_id)
+    REFERENCES shipping_guides(guide_id)
+    ON DELETE CASCADE,
+
+  CONSTRAINT fk_attempt_user
+    FOREIGN KEY (attempted_by)
+    REFERENCES users(user_uuid),
+
+  UNIQUE KEY uk_attempt_guide_number (guide_id, attempt_number),
+  INDEX idx_attempt_assignment (assignment_id)
+) ENGINE=InnoDB
+DEFAULT CHARSET=utf8mb4
+COLLATE=utf8mb4_unicode_ci;
+
+-- =====================================================
+-- VISTA: v_pending_deliveries
+-- Guías pendientes de entregar (destino Bogotá):
+--   - en bodega sin asignación activa
+--   - con entrega fallida y reintento programado (o por asignar),
+--     mientras no se haya creado la devolución al remitente
+-- =====================================================
+
+CREATE OR REPLACE VIEW v_pending_deliveries AS
+SELECT
+  sg.guide_id,
+  sg.service_type,
+  sg.current_status,
+  sg.origin_city_id,
+  oc.name AS origin_city_name,
+  sg.destination_city_id,
+  dc.name AS destination_city_name,
+  sg.created_at,
+  receiver.full_name AS receiver_name,
+  receiver.address AS delivery_address,
+  receiver.phone AS receiver_phone,
+  (SELECT COUNT(*) FROM delivery_attempts att WHERE att.guide_id = sg.guide_id) AS attempt_count,
+  retry.assignment_id AS reattempt_assignment_id,
+  retry.delivery_user_id AS reattempt_delivery_user_id,
+  retry.scheduled_date
+FROM shipping_guides sg
+LEFT JOIN cities oc ON sg.origin_city_id = oc.id
+LEFT JOIN cities dc ON sg.destination_city_id = dc.id
+LEFT JOIN guide_parties receiver ON sg.guide_id = receiver.guide_id AND receiver.party_role = 'RECEIVER'
+LEFT JOIN delivery_assignments retry ON retry.guide_id = sg.guide_id
+  AND retry.assignment_type = 'DELIVERY'
+  AND retry.status = 'PENDING'
+  AND sg.current_status = 'DELIVERY_FAILED'
+WHERE UPPER(dc.name) = 'BOGOTÁ D.C.'
+  AND (
+    (sg.current_status = 'IN_WAREHOUSE'
+      AND NOT EXISTS (
+        SELECT 1 FROM delivery_assignments da
+        WHERE da.guide_id = sg.guide_id
+        AND da.assignment_type = 'DELIVERY'
+        AND da.status IN ('PENDING', 'IN_PROGRESS')
+      ))
+    OR
+    (sg.current_status = 'DELIVERY_FAILED'
+      AND NOT EXISTS (
+        SELECT 1 FROM delivery_assignments da
+        WHERE da.guide_id = sg.guide_id
+        AND da.assignment_type = 'RETURN'
+        AND da.status IN ('PENDING', 'IN_PROGRESS', 'COMPLETED')
+      ))
+  );
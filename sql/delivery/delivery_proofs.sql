@@ -0,0 +1,45 @@
+-- =====================================================
+-- TABLA: delivery_proofs
+-- Prueba de entrega: se registra en la misma transacción en que la
+-- asignación DELIVERY pasa a COMPLETED. La firma y las fotos viven
+-- en S3 (delivery-proofs/{guide_id}/{assignment_id}/...).
+-- =====================================================
+CREATE TABLE delivery_proofs (
+  proof_id BIGINT AUTO_INCREMENT,
+  assignment_id BIGINT NOT NULL,
+  guide_id BIGINT NOT NULL,
+
+  receiver_name VARCHAR(255),
+  receiver_document VARCHAR(50),
+
+  signature_s3_key VARCHAR(500),
+  -- Arreglo JSON con las llaves S3 de las fotos
+  photo_s3_keys JSON,
+
+  latitude DECIMAL(10, 7) NULL,
+  longitude DECIMAL(10, 7) NULL,
+
+  captured_by VARCHAR(255) NOT NULL,
+  captured_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+  CONSTRAINT pk_delivery_proofs PRIMARY KEY (proof_id),
+
+  CONSTRAINT fk_proof_assignment
+    FOREIGN KEY (assignment_id)
+    REFERENCES delivery_assignments(assignment_id)
+    ON DELETE CASCADE,
+
+  CONSTRAINT fk_proof_guide
+    FOREIGN KEY (guide_id)
+    REFERENCES shipping_guides(guide_id)
+    ON DELETE CASCADE,
+
+  CONSTRAINT fk_proof_user
+    FOREIGN KEY (captured_by)
+    REFERENCES users(user_uuid),
+
+  UNIQUE KEY uk_proof_assignment (assignment_id),
+  INDEX idx_proof_guide (guide_id)
+) ENGINE=InnoDB
+DEFAULT CHARSET=utf8mb4
+COLLATE=utf8mb4_unicode_ci;
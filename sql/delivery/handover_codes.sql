@@ -0,0 +1,57 @@
+-- =====================================================
+-- TABLA: handover_codes
+-- Código de entrega (OTP) de guías COD o de alto valor. Se genera
+-- cuando la asignación DELIVERY pasa a IN_PROGRESS; solo se guarda
+-- el hash. Una fila por asignación: reenviar reemplaza el código.
+-- =====================================================
+CREATE TABLE handover_codes (
+  assignment_id BIGINT NOT NULL,
+  guide_id BIGINT NOT NULL,
+
+  code_hash CHAR(64) NOT NULL,
+  channel VARCHAR(20),
+  sent_to VARCHAR(255),
+  expires_at TIMESTAMP NOT NULL,
+
+  -- Intentos fallidos desde el último bloqueo
+  failed_attempts INT NOT NULL DEFAULT 0,
+  locked_until TIMESTAMP NULL,
+
+  verified_at TIMESTAMP NULL,
+  -- Omisión del código por un ADMIN (auditada en assignment_history)
+  overridden_by VARCHAR(255),
+  override_reason TEXT,
+
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+  CONSTRAINT pk_handover_codes PRIMARY KEY (assignment_id),
+
+  CONSTRAINT fk_handover_assignment
+    FOREIGN KEY (assignment_id)
+    REFERENCES delivery_assignments(assignment_id)
+    ON DELETE CASCADE,
+
+  CONSTRAINT fk_handover_guide
+    FOREIGN KEY (guide_id)
+    REFERENCES shipping_guides(guide_id)
+    ON DELETE CASCADE,
+
+  CONSTRAINT fk_handover_overridden_by
+    FOREIGN KEY (overridden_by)
+    REFERENCES users(user_uuid),
+
+  INDEX idx_handover_guide (guide_id)
+) ENGINE=InnoDB
+DEFAULT CHARSET=utf8mb4
+COLLATE=utf8mb4_unicode_ci;
+
+ALTER TABLE assignment_history
+  MODIFY action ENUM(
+    'CREATED',
+    'REASSIGNED',
+    'STATUS_CHANGE',
+    'CANCELLED',
+    'HANDOVER_CODE_SENT',
+    'HANDOVER_CODE_FAILED',
+    'HANDOVER_CODE_OVERRIDE'
+  ) NOT NULL;
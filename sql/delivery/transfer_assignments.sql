@@ -0,0 +1,21 @@
+-- =====================================================
+-- TRASLADOS ENTRE BODEGAS (TRANSFER)
+-- Trayecto troncal de una guía entre las bodegas (hubs) de
+-- dos ciudades: conductor (delivery_user_id), vehículo y
+-- bodegas de origen y destino. Al iniciarlo la guía pasa a
+-- IN_ROUTE; al completarlo, a IN_WAREHOUSE en la bodega de
+-- destino. Las demás asignaciones dejan estas columnas en NULL.
+-- =====================================================
+
+ALTER TABLE delivery_assignments
+  MODIFY assignment_type ENUM('PICKUP', 'DELIVERY', 'RETURN', 'TRANSFER') NOT NULL,
+  ADD COLUMN vehicle_plate VARCHAR(10) NULL AFTER scheduled_date,
+  ADD COLUMN origin_warehouse_id BIGINT NULL AFTER vehicle_plate,
+  ADD COLUMN destination_warehouse_id BIGINT NULL AFTER origin_warehouse_id,
+  ADD CONSTRAINT fk_assignment_origin_warehouse
+    FOREIGN KEY (origin_warehouse_id)
+    REFERENCES warehouses(warehouse_id),
+  ADD CONSTRAINT fk_assignment_destination_warehouse
+    FOREIGN KEY (destination_warehouse_id)
+    REFERENCES warehouses(warehouse_id),
+  ADD INDEX idx_assignment_destination_warehouse (destination_warehouse_id);
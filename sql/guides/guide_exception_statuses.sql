@@ -0,0 +1,36 @@
+-- =====================================================
+-- ESTADOS DE EXCEPCIÓN DE LA GUÍA
+-- Entrega fallida, devolución al remitente, anulación y retención.
+-- Cada cambio a uno de estos estados exige un código de motivo y
+-- una nota, que quedan en guide_status_history.
+-- =====================================================
+
+ALTER TABLE shipping_guides
+  MODIFY current_status ENUM(
+    'CREATED',
+    'IN_ROUTE',
+    'IN_WAREHOUSE',
+    'OUT_FOR_DELIVERY',
+    'DELIVERED',
+    'DELIVERY_FAILED',
+    'RETURNED_TO_SENDER',
+    'CANCELLED',
+    'ON_HOLD'
+  ) NOT NULL DEFAULT 'CREATED';
+
+ALTER TABLE guide_status_history
+  MODIFY status ENUM(
+    'CREATED',
+    'IN_ROUTE',
+    'IN_WAREHOUSE',
+    'OUT_FOR_DELIVERY',
+    'DELIVERED',
+    'DELIVERY_FAILED',
+    'RETURNED_TO_SENDER',
+    'CANCELLED',
+    'ON_HOLD'
+  ) NOT NULL DEFAULT 'CREATED',
+  ADD COLUMN reason_code VARCHAR(40) NULL AFTER status,
+  ADD COLUMN notes TEXT
+    CHARACTER SET utf8mb4
+    COLLATE utf8mb4_unicode_ci NULL AFTER reason_code;
@@ -0,0 +1,25 @@
+-- =====================================================
+-- Número de guía legible: prefijo de oficina + consecutivo
+-- + dígito de verificación Luhn (p.ej. SD00001234).
+-- Independiente del guide_id interno.
+-- =====================================================
+ALTER TABLE shipping_guides
+  ADD COLUMN guide_number VARCHAR(20) NULL AFTER guide_id,
+  ADD CONSTRAINT uq_shipping_guides_number UNIQUE (guide_number);
+
+-- =====================================================
+-- TABLA: guide_number_sequences
+-- Último consecutivo usado por prefijo. CreateGuide bloquea
+-- la fila del prefijo dentro de la transacción de creación.
+-- =====================================================
+CREATE TABLE guide_number_sequences (
+  prefix VARCHAR(4) NOT NULL,
+  last_value BIGINT NOT NULL DEFAULT 0,
+
+  CONSTRAINT pk_guide_number_sequences PRIMARY KEY (prefix)
+) ENGINE=InnoDB
+DEFAULT CHARSET=utf8mb4
+COLLATE=utf8mb4_unicode_ci;
+
+-- Las guías existentes se numeran con:
+--   go run ./cmd/backfill-guide-numbers -prefix SD
@@ -0,0 +1,37 @@
+-- =====================================================
+-- TABLA: guide_scans
+-- Escaneos exitosos de guías por punto de la operación
+-- (recepción, bodega, despacho). Un escaneo repetido del
+-- mismo punto dentro de la ventana (SCAN_DEDUP_WINDOW) no
+-- se vuelve a aplicar.
+-- =====================================================
+CREATE TABLE guide_scans (
+  scan_id BIGINT AUTO_INCREMENT,
+  guide_id BIGINT NOT NULL,
+
+  scan_point ENUM('RECEPTION', 'WAREHOUSE', 'DISPATCH') NOT NULL,
+  code VARCHAR(64) NOT NULL,
+  result ENUM('APPLIED', 'UNCHANGED') NOT NULL,
+
+  previous_status VARCHAR(30) NOT NULL,
+  new_status VARCHAR(30) NOT NULL,
+
+  scanned_by VARCHAR(255)
+    CHARACTER SET utf8mb4
+    COLLATE utf8mb4_unicode_ci,
+  scanned_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  CONSTRAINT pk_guide_scans PRIMARY KEY (scan_id),
+
+  CONSTRAINT fk_scans_guide
+    FOREIGN KEY (guide_id)
+    REFERENCES shipping_guides(guide_id),
+
+  CONSTRAINT fk_scans_user
+    FOREIGN KEY (scanned_by)
+    REFERENCES users(user_uuid),
+
+  INDEX idx_scans_guide_point (guide_id, scan_point, scanned_at)
+) ENGINE=InnoDB
+DEFAULT CHARSET=utf8mb4
+COLLATE=utf8mb4_unicode_ci;
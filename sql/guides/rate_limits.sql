@@ -0,0 +1,19 @@
+-- =====================================================
+-- TABLA: rate_limits
+-- Contadores por ventana de tiempo para rutas públicas
+-- (p.ej. rastreo sin cuenta: por IP y por número de guía).
+-- Una fila por bucket; la ventana se reinicia al vencer.
+-- bd.HitRateLimit borra las filas con más de 24 h sin uso
+-- (en ~1 de cada 100 solicitudes) usando idx_rate_limits_window.
+-- =====================================================
+CREATE TABLE rate_limits (
+  bucket VARCHAR(120) NOT NULL,
+  window_start TIMESTAMP NOT NULL,
+  hits INT NOT NULL DEFAULT 0,
+
+  CONSTRAINT pk_rate_limits PRIMARY KEY (bucket),
+
+  INDEX idx_rate_limits_window (window_start)
+) ENGINE=InnoDB
+DEFAULT CHARSET=utf8mb4
+COLLATE=utf8mb4_unicode_ci;
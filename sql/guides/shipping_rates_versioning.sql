@@ -0,0 +1,76 @@
+-- =====================================================
+-- VERSIONAMIENTO DE TARIFAS
+-- Las tarifas ya no se sobrescriben: un cambio de precio se programa
+-- como una nueva fila con otra effective_date. Las guías guardan la
+-- tarifa con la que se cotizaron (rate_id).
+-- =====================================================
+
+ALTER TABLE shipping_rates
+  ADD COLUMN status ENUM('ACTIVE','RETIRED') NOT NULL DEFAULT 'ACTIVE',
+  ADD COLUMN retired_at TIMESTAMP NULL,
+  ADD COLUMN created_by VARCHAR(255)
+    CHARACTER SET utf8mb4
+    COLLATE utf8mb4_unicode_ci NULL,
+  ADD COLUMN created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  ADD COLUMN updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    ON UPDATE CURRENT_TIMESTAMP,
+  ADD INDEX idx_rate_version (origin_city_id, destination_city_id, status, effective_date);
+
+-- =====================================================
+-- HISTORIAL DE CAMBIOS DE TARIFAS
+-- =====================================================
+
+CREATE TABLE shipping_rate_history (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  rate_id BIGINT NOT NULL,
+  action ENUM('CREATED','UPDATED','RETIRED') NOT NULL,
+
+  previous_price_per_kg DECIMAL(10,2) NULL,
+  new_price_per_kg DECIMAL(10,2) NULL,
+  previous_min_value DECIMAL(10,2) NULL,
+  new_min_value DECIMAL(10,2) NULL,
+  previous_min_dispatch_kg INT NULL,
+  new_min_dispatch_kg INT NULL,
+  previous_effective_date DATE NULL,
+  new_effective_date DATE NULL,
+
+  changed_by VARCHAR(255)
+    CHARACTER SET utf8mb4
+    COLLATE utf8mb4_unicode_ci NOT NULL,
+  changed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+  CONSTRAINT fk_rate_history_rate
+    FOREIGN KEY (rate_id)
+    REFERENCES shipping_rates(id),
+
+  CONSTRAINT fk_rate_history_user
+    FOREIGN KEY (changed_by)
+    REFERENCES users(user_uuid),
+
+  INDEX idx_rate_history_rate (rate_id, changed_at)
+) ENGINE=InnoDB
+DEFAULT CHARSET=utf8mb4
+COLLATE=utf8mb4_unicode_ci;
+
+-- =====================================================
+-- TARIFA APLICADA A CADA GUÍA
+-- =====================================================
+
+ALTER TABLE shipping_guides
+  ADD COLUMN rate_id BIGINT NULL AFTER price,
+  ADD CONSTRAINT fk_guide_rate
+    FOREIGN KEY (rate_id)
+    REFERENCES shipping_rates(id);
+
+-- Backfill: guías existentes con la tarifa vigente el día de su creación
+UPDATE shipping_guides sg
+SET sg.rate_id = (
+  SELECT r.id
+  FROM shipping_rates r
+  WHERE r.origin_city_id = sg.origin_city_id
+    AND r.destination_city_id = sg.destination_city_id
+    AND r.effective_date <= DATE(sg.created_at)
+  ORDER BY r.effective_date DESC, r.id DESC
+  LIMIT 1
+)
+WHERE sg.rate_id IS NULL;
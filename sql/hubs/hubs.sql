@@ -0,0 +1,218 @@
+-- =====================================================
+-- TABLA: hubs
+-- Sedes de la operación. Secretarias y repartidores
+-- pertenecen a un hub y solo operan sobre las guías de
+-- las ciudades que cubre; ADMIN ve el consolidado.
+-- =====================================================
+CREATE TABLE hubs (
+  hub_id        BIGINT AUTO_INCREMENT,
+  code          VARCHAR(20) NOT NULL,
+  name          VARCHAR(100) NOT NULL,
+  city_id       BIGINT NOT NULL,
+  active        BOOLEAN NOT NULL DEFAULT TRUE,
+  created_by    VARCHAR(36),
+  created_at    TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  CONSTRAINT pk_hubs PRIMARY KEY (hub_id),
+  CONSTRAINT uq_hubs_code UNIQUE (code),
+
+  CONSTRAINT fk_hubs_city
+    FOREIGN KEY (city_id)
+    REFERENCES cities(id)
+) ENGINE=InnoDB
+DEFAULT CHARSET=utf8mb4
+COLLATE=utf8mb4_unicode_ci;
+
+-- =====================================================
+-- TABLA: hub_coverage
+-- Ciudades o departamentos completos que cubre cada hub
+-- (una de las dos columnas por fila). La API valida que
+-- una ciudad no quede en dos hubs activos.
+-- =====================================================
+CREATE TABLE hub_coverage (
+  coverage_id    BIGINT AUTO_INCREMENT,
+  hub_id         BIGINT NOT NULL,
+  city_id        BIGINT NULL,
+  department_id  BIGINT NULL,
+
+  CONSTRAINT pk_hub_coverage PRIMARY KEY (coverage_id),
+  CONSTRAINT chk_hub_coverage_target CHECK (
+    (city_id IS NOT NULL AND department_id IS NULL) OR
+    (city_id IS NULL AND department_id IS NOT NULL)
+  ),
+
+  CONSTRAINT fk_hub_coverage_hub
+    FOREIGN KEY (hub_id)
+    REFERENCES hubs(hub_id)
+    ON DELETE CASCADE,
+  CONSTRAINT fk_hub_coverage_city
+    FOREIGN KEY (city_id)
+    REFERENCES cities(id),
+  CONSTRAINT fk_hub_coverage_department
+    FOREIGN KEY (department_id)
+    REFERENCES departments(id),
+
+  INDEX idx_hub_coverage_city (city_id),
+  INDEX idx_hub_coverage_department (department_id)
+) ENGINE=InnoDB
+DEFAULT CHARSET=utf8mb4
+COLLATE=utf8mb4_unicode_ci;
+
+-- =====================================================
+-- Hub de usuarios y cierres de caja
+-- users.hub_id: secretarias y repartidores (NULL en
+-- clientes y ADMIN). cash_closes.hub_id: NULL en el
+-- cierre consolidado de todos los hubs.
+-- =====================================================
+ALTER TABLE users
+  ADD COLUMN hub_id BIGINT NULL AFTER role,
+  ADD CONSTRAINT fk_users_hub
+    FOREIGN KEY (hub_id)
+    REFERENCES hubs(hub_id),
+  ADD INDEX idx_users_hub (hub_id, role);
+
+ALTER TABLE cash_closes
+  ADD COLUMN hub_id BIGINT NULL AFTER total_weight,
+  ADD CONSTRAINT fk_close_hub
+    FOREIGN KEY (hub_id)
+    REFERENCES hubs(hub_id),
+  ADD INDEX idx_close_hub (hub_id, created_at);
+
+-- =====================================================
+-- Hub inicial: la operación existente era solo Bogotá
+-- =====================================================
+INSERT INTO hubs (code, name, city_id)
+SELECT 'BOG', 'Bogotá', c.id
+FROM cities c
+WHERE UPPER(c.name) = 'BOGOTÁ D.C.';
+
+INSERT INTO hub_coverage (hub_id, city_id)
+SELECT h.hub_id, h.city_id
+FROM hubs h
+WHERE h.code = 'BOG';
+
+UPDATE users
+SET hub_id = (SELECT hub_id FROM hubs WHERE code = 'BOG')
+WHERE role IN ('SECRETARY', 'DELIVERY');
+
+-- =====================================================
+-- VISTA: v_pending_pickups
+-- Reemplaza la de delivery_assignments.sql: guías por
+-- recoger con el hub que cubre su ciudad de origen
+-- =====================================================
+
+CREATE OR REPLACE VIEW v_pending_pickups AS
+SELECT
+  h.hub_id,
+  sg.guide_id,
+  sg.service_type,
+  sg.current_status,
+  sg.origin_city_id,
+  oc.name AS origin_city_name,
+  sg.destination_city_id,
+  dc.name AS destination_city_name,
+  sg.created_at,
+  sender.full_name AS sender_name,
+  sender.address AS pickup_address,
+  sender.phone AS sender_phone
+FROM shipping_guides sg
+JOIN cities oc ON sg.origin_city_id = oc.id
+LEFT JOIN cities dc ON sg.destination_city_id = dc.id
+JOIN hubs h ON h.active = TRUE AND EXISTS (
+  SELECT 1 FROM hub_coverage hc
+  WHERE hc.hub_id = h.hub_id
+  AND (hc.city_id = oc.id OR hc.department_id = oc.department_id)
+)
+LEFT JOIN guide_parties sender ON sg.guide_id = sender.guide_id AND sender.party_role = 'SENDER'
+WHERE sg.current_status = 'CREATED'
+  AND NOT EXISTS (
+    SELECT 1 FROM delivery_assignments da
+    WHERE da.guide_id = sg.guide_id
+    AND da.assignment_type = 'PICKUP'
+    AND da.status NOT IN ('CANCELLED')
+  );
+
+-- =====================================================
+-- VISTA: v_pending_deliveries
+-- Reemplaza la de warehouses.sql: guías por entregar con
+-- el hub que cubre su ciudad de destino, cuando ya están
+-- en él (origen del mismo hub, traslado completado a una
+-- bodega del hub, recibidas de un manifiesto o con una
+-- entrega fallida)
+-- =====================================================
+
+CREATE OR REPLACE VIEW v_pending_deliveries AS
+SELECT
+  h.hub_id,
+  sg.guide_id,
+  sg.service_type,
+  sg.current_status,
+  sg.origin_city_id,
+  oc.name AS origin_city_name,
+  sg.destination_city_id,
+  dc.name AS destination_city_name,
+  sg.created_at,
+  receiver.full_name AS receiver_name,
+  receiver.address AS delivery_address,
+  receiver.phone AS receiver_phone,
+  (SELECT COUNT(*) FROM delivery_attempts att WHERE att.guide_id = sg.guide_id) AS attempt_count,
+  retry.assignment_id AS reattempt_assignment_id,
+  retry.delivery_user_id AS reattempt_delivery_user_id,
+  retry.scheduled_date,
+  (SELECT GROUP_CONCAT(DISTINCT wb.code ORDER BY wb.code SEPARATOR ',')
+   FROM warehouse_stock ws
+   JOIN warehouse_bins wb ON wb.bin_id = ws.bin_id
+   WHERE ws.guide_id = sg.guide_id) AS bins
+FROM shipping_guides sg
+LEFT JOIN cities oc ON sg.origin_city_id = oc.id
+JOIN cities dc ON sg.destination_city_id = dc.id
+JOIN hubs h ON h.active = TRUE AND EXISTS (
+  SELECT 1 FROM hub_coverage hc
+  WHERE hc.hub_id = h.hub_id
+  AND (hc.city_id = dc.id OR hc.department_id = dc.department_id)
+)
+LEFT JOIN guide_parties receiver ON sg.guide_id = receiver.guide_id AND receiver.party_role = 'RECEIVER'
+LEFT JOIN delivery_assignments retry ON retry.guide_id = sg.guide_id
+  AND retry.assignment_type = 'DELIVERY'
+  AND retry.status = 'PENDING'
+  AND sg.current_status = 'DELIVERY_FAILED'
+WHERE (
+    sg.current_status = 'DELIVERY_FAILED'
+    OR EXISTS (
+      SELECT 1 FROM hub_coverage hc
+      WHERE hc.hub_id = h.hub_id
+      AND (hc.city_id = oc.id OR hc.department_id = oc.department_id)
+    )
+    OR EXISTS (
+      SELECT 1 FROM delivery_assignments t
+      JOIN warehouses tw ON tw.warehouse_id = t.destination_warehouse_id
+      JOIN cities twc ON twc.id = tw.city_id
+      JOIN hub_coverage hc ON hc.hub_id = h.hub_id
+        AND (hc.city_id = twc.id OR hc.department_id = twc.department_id)
+      WHERE t.guide_id = sg.guide_id
+      AND t.assignment_type = 'TRANSFER'
+      AND t.status = 'COMPLETED'
+    )
+    OR EXISTS (
+      SELECT 1 FROM manifest_guides mg
+      WHERE mg.guide_id = sg.guide_id
+      AND mg.status IN ('RECEIVED', 'PARTIAL')
+    )
+  )
+  AND (
+    (sg.current_status = 'IN_WAREHOUSE'
+      AND NOT EXISTS (
+        SELECT 1 FROM delivery_assignments da
+        WHERE da.guide_id = sg.guide_id
+        AND da.assignment_type = 'DELIVERY'
+        AND da.status IN ('PENDING', 'IN_PROGRESS')
+      ))
+    OR
+    (sg.current_status = 'DELIVERY_FAILED'
+      AND NOT EXISTS (
+        SELECT 1 FROM delivery_assignments da
+        WHERE da.guide_id = sg.guide_id
+        AND da.assignment_type = 'RETURN'
+        AND da.status IN ('PENDING', 'IN_PROGRESS', 'COMPLETED')
+      ))
+  );
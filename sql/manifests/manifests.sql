@@ -0,0 +1,71 @@
+-- =====================================================
+-- TABLA: manifests
+-- Manifiestos de despacho intermunicipal: guías de una
+-- ruta (shipping_rates.route) que salen en un vehículo
+-- con su conductor. OPEN: cargando guías; CLOSED:
+-- despachado (guías IN_ROUTE); RECEIVED: recibido en
+-- destino.
+-- =====================================================
+CREATE TABLE manifests (
+  manifest_id      BIGINT AUTO_INCREMENT,
+  route            VARCHAR(100) NOT NULL,
+  dispatch_date    DATE NOT NULL,
+  vehicle_plate    VARCHAR(10) NOT NULL,
+  driver_name      VARCHAR(150) NOT NULL,
+  driver_document  VARCHAR(20) NOT NULL,
+  driver_phone     VARCHAR(20),
+  status           ENUM('OPEN','CLOSED','RECEIVED') NOT NULL DEFAULT 'OPEN',
+  notes            VARCHAR(255),
+  receive_notes    VARCHAR(255),
+  created_by       VARCHAR(36),
+  created_at       TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  closed_by        VARCHAR(36),
+  closed_at        TIMESTAMP NULL,
+  received_by      VARCHAR(36),
+  received_at      TIMESTAMP NULL,
+
+  CONSTRAINT pk_manifests PRIMARY KEY (manifest_id),
+
+  INDEX idx_manifests_dispatch (dispatch_date, status),
+  INDEX idx_manifests_route (route)
+) ENGINE=InnoDB
+DEFAULT CHARSET=utf8mb4
+COLLATE=utf8mb4_unicode_ci;
+
+-- =====================================================
+-- TABLA: manifest_guides
+-- Guías cargadas en el manifiesto. Piezas, peso y
+-- valores se copian de la guía al cargarla; los totales
+-- del manifiesto se calculan de estas filas. status es
+-- el resultado de la recepción en destino.
+-- =====================================================
+CREATE TABLE manifest_guides (
+  manifest_guide_id  BIGINT AUTO_INCREMENT,
+  manifest_id        BIGINT NOT NULL,
+  guide_id           BIGINT NOT NULL,
+  payment_method     VARCHAR(20) NOT NULL,
+  pieces             INT NOT NULL DEFAULT 1,
+  weight_kg          DECIMAL(10,2) NOT NULL DEFAULT 0,
+  declared_value     DECIMAL(12,2) NOT NULL DEFAULT 0,
+  cod_amount         DECIMAL(12,2) NOT NULL DEFAULT 0,
+  status             ENUM('LOADED','RECEIVED','PARTIAL','MISSING') NOT NULL DEFAULT 'LOADED',
+  received_pieces    INT NOT NULL DEFAULT 0,
+  added_by           VARCHAR(36),
+  added_at           TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  CONSTRAINT pk_manifest_guides PRIMARY KEY (manifest_guide_id),
+  CONSTRAINT uq_manifest_guides UNIQUE (manifest_id, guide_id),
+
+  CONSTRAINT fk_manifest_guides_manifest
+    FOREIGN KEY (manifest_id)
+    REFERENCES manifests(manifest_id)
+    ON DELETE CASCADE,
+
+  CONSTRAINT fk_manifest_guides_guide
+    FOREIGN KEY (guide_id)
+    REFERENCES shipping_guides(guide_id),
+
+  INDEX idx_manifest_guides_guide (guide_id)
+) ENGINE=InnoDB
+DEFAULT CHARSET=utf8mb4
+COLLATE=utf8mb4_unicode_ci;
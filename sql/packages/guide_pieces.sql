@@ -0,0 +1,65 @@
+-- =====================================================
+-- TABLA: guide_pieces
+-- Piezas (cajas) de una guía, cada una con su peso,
+-- medidas, descripción y código propio
+-- (<número de guía>-<pieza>, p.ej. SD00000018-002).
+-- packages guarda los totales calculados de las piezas.
+-- status es el estado del último escaneo de la pieza; si
+-- las piezas de una guía difieren, la guía es parcial.
+-- =====================================================
+CREATE TABLE guide_pieces (
+  piece_id        BIGINT AUTO_INCREMENT,
+  guide_id        BIGINT NOT NULL,
+  package_id      BIGINT NOT NULL,
+
+  piece_number    INT NOT NULL,
+  piece_code      VARCHAR(32) NOT NULL,
+
+  weight_kg       DECIMAL(8,2) NOT NULL,
+  length_cm       DECIMAL(8,2) NOT NULL,
+  width_cm        DECIMAL(8,2) NOT NULL,
+  height_cm       DECIMAL(8,2) NOT NULL,
+  description     TEXT,
+
+  status          VARCHAR(30) NOT NULL DEFAULT 'CREATED',
+  last_scan_point ENUM('RECEPTION', 'WAREHOUSE', 'DISPATCH') NULL,
+  last_scanned_at TIMESTAMP NULL,
+
+  CONSTRAINT pk_guide_pieces PRIMARY KEY (piece_id),
+  CONSTRAINT uq_guide_pieces_number UNIQUE (guide_id, piece_number),
+  CONSTRAINT uq_guide_pieces_code UNIQUE (piece_code),
+
+  CONSTRAINT fk_pieces_guide
+    FOREIGN KEY (guide_id)
+    REFERENCES shipping_guides(guide_id),
+
+  CONSTRAINT fk_pieces_package
+    FOREIGN KEY (package_id)
+    REFERENCES packages(package_id)
+) ENGINE=InnoDB
+DEFAULT CHARSET=utf8mb4
+COLLATE=utf8mb4_unicode_ci;
+
+-- Escaneos por pieza (0: la guía completa)
+ALTER TABLE guide_scans
+  ADD COLUMN piece_number INT NOT NULL DEFAULT 0 AFTER guide_id,
+  DROP INDEX idx_scans_guide_point,
+  ADD INDEX idx_scans_guide_point (guide_id, piece_number, scan_point, scanned_at);
+
+-- Guías existentes: una fila por pieza con las medidas del
+-- paquete y el peso repartido (la última lleva el residuo)
+INSERT INTO guide_pieces
+  (guide_id, package_id, piece_number, piece_code, weight_kg,
+   length_cm, width_cm, height_cm, description, status)
+WITH RECURSIVE seq (n) AS (
+  SELECT 1 UNION ALL SELECT n + 1 FROM seq WHERE n < 999
+)
+SELECT p.guide_id, p.package_id, seq.n,
+       CONCAT(COALESCE(sg.guide_number, sg.guide_id), '-', LPAD(seq.n, 3, '0')),
+       CASE WHEN seq.n = p.pieces
+            THEN p.weight_kg - FLOOR(p.weight_kg / p.pieces * 100) / 100 * (p.pieces - 1)
+            ELSE FLOOR(p.weight_kg / p.pieces * 100) / 100 END,
+       p.length_cm, p.width_cm, p.height_cm, p.description, sg.current_status
+FROM packages p
+JOIN shipping_guides sg ON sg.guide_id = p.guide_id
+JOIN seq ON seq.n <= GREATEST(p.pieces, 1);
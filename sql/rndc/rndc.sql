@@ -0,0 +1,47 @@
+-- =====================================================
+-- Reporte al RNDC (Registro Nacional de Despachos de
+-- Carga): ingresoid que asigna el Ministerio de
+-- Transporte a la remesa de cada guía y al manifiesto
+-- de carga de cada despacho.
+-- =====================================================
+ALTER TABLE shipping_guides
+  ADD COLUMN rndc_remesa_id VARCHAR(20) NULL AFTER pdf_s3_key,
+  ADD COLUMN rndc_manifest_id VARCHAR(20) NULL AFTER rndc_remesa_id;
+
+ALTER TABLE manifests
+  ADD COLUMN rndc_manifest_id VARCHAR(20) NULL AFTER receive_notes;
+
+-- =====================================================
+-- TABLA: rndc_submissions
+-- Cada envío al RNDC, aceptado o rechazado, con el XML
+-- enviado (sin la contraseña) y la respuesta.
+-- =====================================================
+CREATE TABLE rndc_submissions (
+  submission_id  BIGINT AUTO_INCREMENT,
+  process        ENUM('REMESA','MANIFIESTO') NOT NULL,
+  guide_id       BIGINT NULL,
+  manifest_id    BIGINT NULL,
+  consecutive    VARCHAR(20) NOT NULL,
+  success        BOOLEAN NOT NULL DEFAULT FALSE,
+  ingreso_id     VARCHAR(20),
+  error_message  TEXT,
+  request_xml    TEXT,
+  response_xml   TEXT,
+  submitted_by   VARCHAR(36),
+  submitted_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  CONSTRAINT pk_rndc_submissions PRIMARY KEY (submission_id),
+
+  CONSTRAINT fk_rndc_submissions_guide
+    FOREIGN KEY (guide_id)
+    REFERENCES shipping_guides(guide_id),
+
+  CONSTRAINT fk_rndc_submissions_manifest
+    FOREIGN KEY (manifest_id)
+    REFERENCES manifests(manifest_id),
+
+  INDEX idx_rndc_submissions_guide (guide_id),
+  INDEX idx_rndc_submissions_manifest (manifest_id)
+) ENGINE=InnoDB
+DEFAULT CHARSET=utf8mb4
+COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,225 @@
+-- =====================================================
+-- TABLA: warehouses
+-- Bodegas de la operación
+-- =====================================================
+CREATE TABLE warehouses (
+  warehouse_id  BIGINT AUTO_INCREMENT,
+  code          VARCHAR(20) NOT NULL,
+  name          VARCHAR(100) NOT NULL,
+  city_id       BIGINT NOT NULL,
+  address       VARCHAR(255),
+  active        BOOLEAN NOT NULL DEFAULT TRUE,
+  created_by    VARCHAR(36),
+  created_at    TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  CONSTRAINT pk_warehouses PRIMARY KEY (warehouse_id),
+  CONSTRAINT uq_warehouses_code UNIQUE (code),
+
+  CONSTRAINT fk_warehouses_city
+    FOREIGN KEY (city_id)
+    REFERENCES cities(id)
+) ENGINE=InnoDB
+DEFAULT CHARSET=utf8mb4
+COLLATE=utf8mb4_unicode_ci;
+
+-- =====================================================
+-- TABLA: warehouse_bins
+-- Ubicaciones dentro de la bodega (estante, nivel,
+-- posición). El código es único por bodega.
+-- =====================================================
+CREATE TABLE warehouse_bins (
+  bin_id        BIGINT AUTO_INCREMENT,
+  warehouse_id  BIGINT NOT NULL,
+  code          VARCHAR(30) NOT NULL,
+  description   VARCHAR(255),
+  active        BOOLEAN NOT NULL DEFAULT TRUE,
+  created_at    TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  CONSTRAINT pk_warehouse_bins PRIMARY KEY (bin_id),
+  CONSTRAINT uq_warehouse_bins_code UNIQUE (warehouse_id, code),
+
+  CONSTRAINT fk_bins_warehouse
+    FOREIGN KEY (warehouse_id)
+    REFERENCES warehouses(warehouse_id)
+) ENGINE=InnoDB
+DEFAULT CHARSET=utf8mb4
+COLLATE=utf8mb4_unicode_ci;
+
+-- =====================================================
+-- TABLA: warehouse_stock
+-- Ubicación actual de cada guía en bodega, por pieza
+-- (0: guía sin detalle de piezas). Una pieza está en un
+-- solo bin; al despacharla o sacarla se borra la fila.
+-- =====================================================
+CREATE TABLE warehouse_stock (
+  stock_id      BIGINT AUTO_INCREMENT,
+  guide_id      BIGINT NOT NULL,
+  piece_number  INT NOT NULL DEFAULT 0,
+  bin_id        BIGINT NOT NULL,
+  located_by    VARCHAR(36) NOT NULL,
+  located_at    TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  CONSTRAINT pk_warehouse_stock PRIMARY KEY (stock_id),
+  CONSTRAINT uq_warehouse_stock_piece UNIQUE (guide_id, piece_number),
+
+  CONSTRAINT fk_stock_guide
+    FOREIGN KEY (guide_id)
+    REFERENCES shipping_guides(guide_id),
+
+  CONSTRAINT fk_stock_bin
+    FOREIGN KEY (bin_id)
+    REFERENCES warehouse_bins(bin_id),
+
+  INDEX idx_stock_bin (bin_id)
+) ENGINE=InnoDB
+DEFAULT CHARSET=utf8mb4
+COLLATE=utf8mb4_unicode_ci;
+
+-- =====================================================
+-- TABLA: warehouse_movements
+-- Historial de ingresos, movimientos entre bins, salidas
+-- y reubicaciones por conteo
+-- =====================================================
+CREATE TABLE warehouse_movements (
+  movement_id   BIGINT AUTO_INCREMENT,
+  guide_id      BIGINT NOT NULL,
+  piece_number  INT NOT NULL DEFAULT 0,
+  movement_type ENUM('CHECK_IN', 'MOVE', 'CHECK_OUT', 'COUNT_ADJUST') NOT NULL,
+  from_bin_id   BIGINT NULL,
+  to_bin_id     BIGINT NULL,
+  notes         TEXT,
+  moved_by      VARCHAR(36) NOT NULL,
+  moved_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  CONSTRAINT pk_warehouse_movements PRIMARY KEY (movement_id),
+
+  CONSTRAINT fk_movements_guide
+    FOREIGN KEY (guide_id)
+    REFERENCES shipping_guides(guide_id),
+
+  CONSTRAINT fk_movements_from_bin
+    FOREIGN KEY (from_bin_id)
+    REFERENCES warehouse_bins(bin_id),
+
+  CONSTRAINT fk_movements_to_bin
+    FOREIGN KEY (to_bin_id)
+    REFERENCES warehouse_bins(bin_id),
+
+  INDEX idx_movements_guide (guide_id, moved_at)
+) ENGINE=InnoDB
+DEFAULT CHARSET=utf8mb4
+COLLATE=utf8mb4_unicode_ci;
+
+-- =====================================================
+-- TABLA: inventory_counts
+-- Conteos físicos por bodega (uno abierto a la vez)
+-- =====================================================
+CREATE TABLE inventory_counts (
+  count_id      BIGINT AUTO_INCREMENT,
+  warehouse_id  BIGINT NOT NULL,
+  status        ENUM('OPEN', 'CLOSED') NOT NULL DEFAULT 'OPEN',
+  notes         TEXT,
+  applied       BOOLEAN NOT NULL DEFAULT FALSE,
+  started_by    VARCHAR(36) NOT NULL,
+  started_at    TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  closed_by     VARCHAR(36) NULL,
+  closed_at     TIMESTAMP NULL,
+
+  CONSTRAINT pk_inventory_counts PRIMARY KEY (count_id),
+
+  CONSTRAINT fk_counts_warehouse
+    FOREIGN KEY (warehouse_id)
+    REFERENCES warehouses(warehouse_id),
+
+  INDEX idx_counts_warehouse_status (warehouse_id, status)
+) ENGINE=InnoDB
+DEFAULT CHARSET=utf8mb4
+COLLATE=utf8mb4_unicode_ci;
+
+-- =====================================================
+-- TABLA: inventory_count_items
+-- Códigos escaneados por bin durante el conteo. Si una
+-- pieza se escanea en varios bins, cuenta el último.
+-- =====================================================
+CREATE TABLE inventory_count_items (
+  item_id       BIGINT AUTO_INCREMENT,
+  count_id      BIGINT NOT NULL,
+  guide_id      BIGINT NOT NULL,
+  piece_number  INT NOT NULL DEFAULT 0,
+  bin_id        BIGINT NOT NULL,
+  code          VARCHAR(40) NOT NULL,
+  scanned_by    VARCHAR(36) NOT NULL,
+  scanned_at    TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  CONSTRAINT pk_inventory_count_items PRIMARY KEY (item_id),
+
+  CONSTRAINT fk_count_items_count
+    FOREIGN KEY (count_id)
+    REFERENCES inventory_counts(count_id),
+
+  CONSTRAINT fk_count_items_guide
+    FOREIGN KEY (guide_id)
+    REFERENCES shipping_guides(guide_id),
+
+  CONSTRAINT fk_count_items_bin
+    FOREIGN KEY (bin_id)
+    REFERENCES warehouse_bins(bin_id),
+
+  INDEX idx_count_items_count (count_id, scanned_at)
+) ENGINE=InnoDB
+DEFAULT CHARSET=utf8mb4
+COLLATE=utf8mb4_unicode_ci;
+
+-- =====================================================
+-- VISTA: v_pending_deliveries
+-- Igual a la definida en delivery_attempts.sql, con los
+-- bins donde está ubicada cada guía para alistarla
+-- =====================================================
+
+CREATE OR REPLACE VIEW v_pending_deliveries AS
+SELECT
+  sg.guide_id,
+  sg.service_type,
+  sg.current_status,
+  sg.origin_city_id,
+  oc.name AS origin_city_name,
+  sg.destination_city_id,
+  dc.name AS destination_city_name,
+  sg.created_at,
+  receiver.full_name AS receiver_name,
+  receiver.address AS delivery_address,
+  receiver.phone AS receiver_phone,
+  (SELECT COUNT(*) FROM delivery_attempts att WHERE att.guide_id = sg.guide_id) AS attempt_count,
+  retry.assignment_id AS reattempt_assignment_id,
+  retry.delivery_user_id AS reattempt_delivery_user_id,
+  retry.scheduled_date,
+  (SELECT GROUP_CONCAT(DISTINCT wb.code ORDER BY wb.code SEPARATOR ',')
+   FROM warehouse_stock ws
+   JOIN warehouse_bins wb ON wb.bin_id = ws.bin_id
+   WHERE ws.guide_id = sg.guide_id) AS bins
+FROM shipping_guides sg
+LEFT JOIN cities oc ON sg.origin_city_id = oc.id
+LEFT JOIN cities dc ON sg.destination_city_id = dc.id
+LEFT JOIN guide_parties receiver ON sg.guide_id = receiver.guide_id AND receiver.party_role = 'RECEIVER'
+LEFT JOIN delivery_assignments retry ON retry.guide_id = sg.guide_id
+  AND retry.assignment_type = 'DELIVERY'
+  AND retry.status = 'PENDING'
+  AND sg.current_status = 'DELIVERY_FAILED'
+WHERE UPPER(dc.name) = 'BOGOTÁ D.C.'
+  AND (
+    (sg.current_status = 'IN_WAREHOUSE'
+      AND NOT EXISTS (
+        SELECT 1 FROM delivery_assignments da
+        WHERE da.guide_id = sg.guide_id
+        AND da.assignment_type = 'DELIVERY'
+        AND da.status IN ('PENDING', 'IN_PROGRESS')
+      ))
+    OR
+    (sg.current_status = 'DELIVERY_FAILED'
+      AND NOT EXISTS (
+        SELECT 1 FROM delivery_assignments da
+        WHERE da.guide_id = sg.guide_id
+        AND da.assignment_type = 'RETURN'
+        AND da.status IN ('PENDING', 'IN_PROGRESS', 'COMPLETED')
+      ))
+  );